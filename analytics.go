@@ -0,0 +1,228 @@
+package onvif
+
+import "encoding/xml"
+
+// Analytics service namespace.
+const analyticsNamespace = "http://www.onvif.org/ver20/analytics/wsdl"
+
+// SimpleItemValue returns the value of the named simple item.
+func (l *ItemList) SimpleItemValue(name string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	for _, item := range l.SimpleItem {
+		if item.Name == name {
+			return item.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// SetSimpleItem sets the value of the named simple item, adding it if missing.
+func (l *ItemList) SetSimpleItem(name, value string) {
+	for i := range l.SimpleItem {
+		if l.SimpleItem[i].Name == name {
+			l.SimpleItem[i].Value = value
+
+			return
+		}
+	}
+
+	l.SimpleItem = append(l.SimpleItem, SimpleItem{Name: name, Value: value})
+}
+
+// ElementItemXML returns the XML content of the named element item.
+func (l *ItemList) ElementItemXML(name string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	for _, item := range l.ElementItem {
+		if item.Name == name {
+			return item.XML, true
+		}
+	}
+
+	return "", false
+}
+
+// SetElementItem sets the XML content of the named element item, adding it if missing.
+func (l *ItemList) SetElementItem(name, xmlContent string) {
+	for i := range l.ElementItem {
+		if l.ElementItem[i].Name == name {
+			l.ElementItem[i].XML = xmlContent
+
+			return
+		}
+	}
+
+	l.ElementItem = append(l.ElementItem, ElementItem{Name: name, XML: xmlContent})
+}
+
+// SimpleItemValue returns the value of the named simple item parameter of a rule or module.
+func (c *Config) SimpleItemValue(name string) (string, bool) {
+	return c.Parameters.SimpleItemValue(name)
+}
+
+// SetSimpleItem sets the named simple item parameter of a rule or module, e.g.
+// rule.SetSimpleItem("Sensitivity", "80").
+func (c *Config) SetSimpleItem(name, value string) {
+	if c.Parameters == nil {
+		c.Parameters = &ItemList{}
+	}
+
+	c.Parameters.SetSimpleItem(name, value)
+}
+
+// ElementItemXML returns the XML content of the named element item parameter of a rule or module.
+func (c *Config) ElementItemXML(name string) (string, bool) {
+	return c.Parameters.ElementItemXML(name)
+}
+
+// SetElementItem sets the XML content of the named element item parameter of a rule or module.
+func (c *Config) SetElementItem(name, xmlContent string) {
+	if c.Parameters == nil {
+		c.Parameters = &ItemList{}
+	}
+
+	c.Parameters.SetElementItem(name, xmlContent)
+}
+
+// itemListResponse is the wire form of an ItemList received from the device.
+type itemListResponse struct {
+	SimpleItem []struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:"Value,attr"`
+	} `xml:"SimpleItem"`
+	ElementItem []elementItemResponse `xml:"ElementItem"`
+}
+
+// elementItemResponse is the wire form of an ElementItem received from the device.
+type elementItemResponse struct {
+	Name string
+	XML  string
+}
+
+// UnmarshalXML implements xml.Unmarshaler. The content is decoded with the
+// namespace declarations it inherits, so that it can be sent back as is.
+func (r *elementItemResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "Name" {
+			r.Name = attr.Value
+		}
+	}
+
+	content, err := decodeInnerXML(d, start)
+	if err != nil {
+		return err
+	}
+
+	r.XML = content
+
+	return nil
+}
+
+// configResponse is the wire form of an analytics module or rule received from the device.
+type configResponse struct {
+	Name       string            `xml:"Name,attr"`
+	Type       string            `xml:"Type,attr"`
+	Parameters *itemListResponse `xml:"Parameters"`
+}
+
+// itemListRequest is the wire form of an ItemList sent to the device.
+type itemListRequest struct {
+	SimpleItem []struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:"Value,attr"`
+	} `xml:"tt:SimpleItem"`
+	ElementItem []struct {
+		Name string `xml:"Name,attr"`
+		XML  string `xml:",innerxml"`
+	} `xml:"tt:ElementItem"`
+}
+
+// configRequest is the wire form of an analytics module or rule sent to the device.
+type configRequest struct {
+	Name       string          `xml:"Name,attr"`
+	Type       string          `xml:"Type,attr"`
+	Parameters itemListRequest `xml:"tt:Parameters"`
+}
+
+// toConfig converts a decoded module or rule.
+func (r *configResponse) toConfig() *Config {
+	config := &Config{
+		Name:       r.Name,
+		Type:       r.Type,
+		Parameters: &ItemList{},
+	}
+
+	if r.Parameters == nil {
+		return config
+	}
+
+	for _, item := range r.Parameters.SimpleItem {
+		config.Parameters.SimpleItem = append(config.Parameters.SimpleItem, SimpleItem{Name: item.Name, Value: item.Value})
+	}
+
+	for _, item := range r.Parameters.ElementItem {
+		config.Parameters.ElementItem = append(config.Parameters.ElementItem, ElementItem{Name: item.Name, XML: item.XML})
+	}
+
+	return config
+}
+
+// newConfigRequest converts a module or rule to its wire form.
+func newConfigRequest(config *Config) configRequest {
+	req := configRequest{
+		Name: config.Name,
+		Type: config.Type,
+	}
+
+	if config.Parameters == nil {
+		return req
+	}
+
+	for _, item := range config.Parameters.SimpleItem {
+		req.Parameters.SimpleItem = append(req.Parameters.SimpleItem, struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:"Value,attr"`
+		}{Name: item.Name, Value: item.Value})
+	}
+
+	for _, item := range config.Parameters.ElementItem {
+		req.Parameters.ElementItem = append(req.Parameters.ElementItem, struct {
+			Name string `xml:"Name,attr"`
+			XML  string `xml:",innerxml"`
+		}{Name: item.Name, XML: item.XML})
+	}
+
+	return req
+}
+
+// NormalizedToPixel maps a point in the normalized coordinates of analytics
+// rules and metadata, where x runs from -1 (left) to 1 (right) and y from -1
+// (bottom) to 1 (top) across the rectangle, to pixel coordinates with the
+// origin at the top left. For video source bounds, such as
+// VideoSourceConfiguration.Bounds, the result is in video source pixels; use
+// a rectangle of the image size at the origin to draw on a snapshot.
+func (r *IntRectangle) NormalizedToPixel(x, y float64) (px, py float64) {
+	px = float64(r.X) + (x+1)/2*float64(r.Width)
+	py = float64(r.Y) + (1-y)/2*float64(r.Height)
+
+	return px, py
+}
+
+// PixelToNormalized is the inverse of NormalizedToPixel. A rectangle without
+// area maps every point to the origin.
+func (r *IntRectangle) PixelToNormalized(px, py float64) (x, y float64) {
+	if r.Width == 0 || r.Height == 0 {
+		return 0, 0
+	}
+
+	x = (px-float64(r.X))/float64(r.Width)*2 - 1
+	y = 1 - (py-float64(r.Y))/float64(r.Height)*2
+
+	return x, y
+}
@@ -0,0 +1,157 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testVideoAnalyticsConfigurationResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:acme="http://www.acme.example/onvif">
+	<soap:Body>
+		<trt:GetVideoAnalyticsConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<trt:Configuration token="VAC_1">
+				<tt:Name>Analytics</tt:Name>
+				<tt:UseCount>1</tt:UseCount>
+				<tt:AnalyticsEngineConfiguration>
+					<tt:AnalyticsModule Name="MyCellMotion" Type="tt:CellMotionEngine">
+						<tt:Parameters>
+							<tt:SimpleItem Name="Sensitivity" Value="50"/>
+							<tt:ElementItem Name="Layout"><tt:CellLayout Columns="22" Rows="18"/></tt:ElementItem>
+							<tt:ElementItem Name="Zone"><acme:Zone Level="3"><acme:Point x="0.5"/></acme:Zone></tt:ElementItem>
+						</tt:Parameters>
+					</tt:AnalyticsModule>
+				</tt:AnalyticsEngineConfiguration>
+				<tt:RuleEngineConfiguration>
+					<tt:Rule Name="MyMotionDetectorRule" Type="tt:CellMotionDetector">
+						<tt:Parameters>
+							<tt:SimpleItem Name="MinCount" Value="5"/>
+							<tt:SimpleItem Name="AlarmOnDelay" Value="1000"/>
+						</tt:Parameters>
+					</tt:Rule>
+				</tt:RuleEngineConfiguration>
+			</trt:Configuration>
+		</trt:GetVideoAnalyticsConfigurationResponse>
+	</soap:Body>
+</soap:Envelope>`
+
+func TestVideoAnalyticsConfigurationItems(t *testing.T) {
+	var setRequest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		if strings.Contains(string(body), "SetVideoAnalyticsConfiguration") {
+			setRequest = string(body)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><trt:SetVideoAnalyticsConfigurationResponse/></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testVideoAnalyticsConfigurationResponse))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	config, err := client.GetVideoAnalyticsConfiguration(ctx, "VAC_1")
+	if err != nil {
+		t.Fatalf("GetVideoAnalyticsConfiguration() failed: %v", err)
+	}
+
+	if config.AnalyticsEngineConfiguration == nil || len(config.AnalyticsEngineConfiguration.AnalyticsModules) != 1 {
+		t.Fatalf("Expected 1 analytics module, got %+v", config.AnalyticsEngineConfiguration)
+	}
+
+	module := config.AnalyticsEngineConfiguration.AnalyticsModules[0]
+	if module.Name != "MyCellMotion" || module.Type != "tt:CellMotionEngine" {
+		t.Errorf("Unexpected module: %+v", module)
+	}
+
+	if value, ok := module.SimpleItemValue("Sensitivity"); !ok || value != "50" {
+		t.Errorf("Sensitivity = %q, %v, want 50", value, ok)
+	}
+
+	layout, ok := module.ElementItemXML("Layout")
+	if !ok || !strings.Contains(layout, `Columns="22"`) {
+		t.Errorf("Unexpected Layout element item: %q", layout)
+	}
+
+	// The vendor prefix declared on the envelope is declared within the item.
+	zone := `<ns1:Zone Level="3" xmlns:ns1="http://www.acme.example/onvif"><ns1:Point x="0.5"></ns1:Point></ns1:Zone>`
+	if item, _ := module.ElementItemXML("Zone"); item != zone {
+		t.Errorf("Zone element item = %q, want %q", item, zone)
+	}
+
+	if config.RuleEngineConfiguration == nil || len(config.RuleEngineConfiguration.Rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %+v", config.RuleEngineConfiguration)
+	}
+
+	rule := config.RuleEngineConfiguration.Rules[0]
+	if value, _ := rule.SimpleItemValue("AlarmOnDelay"); value != "1000" {
+		t.Errorf("AlarmOnDelay = %q, want 1000", value)
+	}
+
+	if _, ok := rule.SimpleItemValue("Missing"); ok {
+		t.Error("Expected missing item to be reported as absent")
+	}
+
+	module.SetSimpleItem("Sensitivity", "80")
+	rule.SetSimpleItem("AlarmOffDelay", "2000")
+
+	if err := client.SetVideoAnalyticsConfiguration(ctx, config, true); err != nil {
+		t.Fatalf("SetVideoAnalyticsConfiguration() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<tt:AnalyticsModule Name="MyCellMotion" Type="tt:CellMotionEngine">`,
+		`<tt:SimpleItem Name="Sensitivity" Value="80"></tt:SimpleItem>`,
+		`<tt:ElementItem Name="Layout"><tt:CellLayout Columns="22" Rows="18" xmlns:tt="http://www.onvif.org/ver10/schema"></tt:CellLayout></tt:ElementItem>`,
+		`<tt:ElementItem Name="Zone">` + zone + `</tt:ElementItem>`,
+		`<tt:SimpleItem Name="MinCount" Value="5"></tt:SimpleItem>`,
+		`<tt:SimpleItem Name="AlarmOffDelay" Value="2000"></tt:SimpleItem>`,
+	} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+}
+
+func TestIntRectangleNormalizedToPixel(t *testing.T) {
+	bounds := &IntRectangle{X: 100, Y: 50, Width: 1600, Height: 900}
+
+	tests := []struct {
+		x, y   float64
+		px, py float64
+	}{
+		{x: -1, y: 1, px: 100, py: 50},
+		{x: 1, y: -1, px: 1700, py: 950},
+		{x: 0, y: 0, px: 900, py: 500},
+		{x: 0.5, y: 0.5, px: 1300, py: 275},
+	}
+
+	for _, tt := range tests {
+		px, py := bounds.NormalizedToPixel(tt.x, tt.y)
+		if px != tt.px || py != tt.py {
+			t.Errorf("NormalizedToPixel(%v, %v) = %v, %v, want %v, %v", tt.x, tt.y, px, py, tt.px, tt.py)
+		}
+
+		if x, y := bounds.PixelToNormalized(px, py); x != tt.x || y != tt.y {
+			t.Errorf("PixelToNormalized(%v, %v) = %v, %v, want %v, %v", px, py, x, y, tt.x, tt.y)
+		}
+	}
+
+	if x, y := (&IntRectangle{}).PixelToNormalized(10, 10); x != 0 || y != 0 {
+		t.Errorf("Expected the origin for an empty rectangle, got %v, %v", x, y)
+	}
+}
@@ -0,0 +1,254 @@
+package onvif
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+)
+
+// DefaultReachablePollInterval is the polling interval used by WaitForReachable
+// and WaitForReboot when none is given.
+const DefaultReachablePollInterval = 2 * time.Second
+
+// attachmentChunkSize is the number of raw bytes encoded per character data
+// token when marshalling attachment data; a multiple of 3 keeps the base64
+// chunks free of padding.
+const attachmentChunkSize = 3 * 1024
+
+// attachmentDataXML is the wire form of tt:AttachmentData. The content is
+// carried inline as base64 character data; an xop:Include reference is kept
+// as is, since MTOM parts are not resolved.
+type attachmentDataXML struct {
+	ContentType string
+	Data        []byte
+	Href        string
+}
+
+// UnmarshalXML decodes the contentType attribute, inline base64 content and an
+// optional xop:Include reference. The content is decoded as its character data
+// is read, so that together with CallStream only the decoded blob is held in
+// memory.
+func (a *attachmentDataXML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "contentType" {
+			a.ContentType = attr.Value
+		}
+	}
+
+	var content attachmentDecoder
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if err := content.write(t); err != nil {
+				return err
+			}
+		case xml.StartElement:
+			if t.Name.Local == "Include" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "href" {
+						a.Href = attr.Value
+					}
+				}
+			}
+
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			data, err := content.close()
+			if err != nil {
+				return err
+			}
+
+			a.Data = data
+
+			return nil
+		}
+	}
+}
+
+// attachmentDecoder decodes base64 content that arrives in pieces, ignoring
+// whitespace. Characters that do not yet form a complete quantum of four are
+// kept until the next piece.
+type attachmentDecoder struct {
+	data    []byte
+	pending []byte
+	padded  bool
+}
+
+// write decodes the complete quanta of the content read so far.
+func (a *attachmentDecoder) write(content []byte) error {
+	for _, b := range content {
+		switch b {
+		case ' ', '\t', '\n', '\r':
+		default:
+			a.pending = append(a.pending, b)
+		}
+	}
+
+	n := len(a.pending) / 4 * 4 //nolint:mnd // base64 quantum
+	if n == 0 {
+		return nil
+	}
+
+	// Padding ends the content.
+	if a.padded {
+		return fmt.Errorf("%w: invalid base64 attachment data: content after padding", ErrInvalidResponse)
+	}
+
+	start := len(a.data)
+	a.data = slices.Grow(a.data, base64.StdEncoding.DecodedLen(n))
+
+	m, err := base64.StdEncoding.Decode(a.data[start:start+base64.StdEncoding.DecodedLen(n)], a.pending[:n])
+	if err != nil {
+		return fmt.Errorf("%w: invalid base64 attachment data: %w", ErrInvalidResponse, err)
+	}
+
+	a.data = a.data[:start+m]
+	a.padded = a.pending[n-1] == '='
+	a.pending = append(a.pending[:0], a.pending[n:]...)
+
+	return nil
+}
+
+// close returns the decoded content, or an error if it ended with an
+// incomplete quantum.
+func (a *attachmentDecoder) close() ([]byte, error) {
+	if len(a.pending) > 0 {
+		return nil, fmt.Errorf("%w: invalid base64 attachment data: truncated content", ErrInvalidResponse)
+	}
+
+	return a.data, nil
+}
+
+// MarshalXML encodes the content as base64 character data in chunks.
+func (a attachmentDataXML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if a.ContentType != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "contentType"}, Value: a.ContentType})
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, base64.StdEncoding.EncodedLen(attachmentChunkSize))
+
+	for data := a.Data; len(data) > 0; {
+		n := min(len(data), attachmentChunkSize)
+		base64.StdEncoding.Encode(chunk, data[:n])
+
+		if err := e.EncodeToken(xml.CharData(chunk[:base64.StdEncoding.EncodedLen(n)])); err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// toAttachmentData converts the wire form to an AttachmentData.
+func (a *attachmentDataXML) toAttachmentData() AttachmentData {
+	data := AttachmentData{
+		ContentType: a.ContentType,
+		Data:        a.Data,
+	}
+
+	if a.Href != "" {
+		data.Include = &Include{Href: a.Href}
+	}
+
+	return data
+}
+
+// backupFileXML is the wire form of tt:BackupFile in responses.
+type backupFileXML struct {
+	Name string            `xml:"Name"`
+	Data attachmentDataXML `xml:"Data"`
+}
+
+// backupFileRequest is the wire form of tt:BackupFile in requests.
+type backupFileRequest struct {
+	Name string            `xml:"tt:Name"`
+	Data attachmentDataXML `xml:"tt:Data"`
+}
+
+// newBackupFileRequest converts a BackupFile to its request form.
+func newBackupFileRequest(file *BackupFile) backupFileRequest {
+	return backupFileRequest{
+		Name: file.Name,
+		Data: attachmentDataXML{
+			ContentType: file.Data.ContentType,
+			Data:        file.Data.Data,
+		},
+	}
+}
+
+// WaitForReachable polls the device until it answers, or until ctx is done.
+// Any HTTP response, including a fault or an error status such as 401,
+// counts as reachable. A device that is
+// about to reboot still answers, so use WaitForReboot after RestoreSystem or
+// SystemReboot. A non-positive interval uses DefaultReachablePollInterval.
+func (c *Client) WaitForReachable(ctx context.Context, interval time.Duration) error {
+	return c.pollReachable(ctx, interval, true)
+}
+
+// WaitForReboot waits for the device to stop answering and then to answer
+// again, e.g. after RestoreSystem or SystemReboot, or until ctx is done. The
+// device is polled every interval, so a device that reboots in less than the
+// interval may be missed; bound ctx accordingly. A non-positive interval uses
+// DefaultReachablePollInterval.
+func (c *Client) WaitForReboot(ctx context.Context, interval time.Duration) error {
+	if err := c.pollReachable(ctx, interval, false); err != nil {
+		return err
+	}
+
+	return c.pollReachable(ctx, interval, true)
+}
+
+// pollReachable polls the device with GetSystemDateAndTime until its
+// reachability equals want, or until ctx is done.
+func (c *Client) pollReachable(ctx context.Context, interval time.Duration, want bool) error {
+	if interval <= 0 {
+		interval = DefaultReachablePollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := c.GetSystemDateAndTime(ctx)
+
+		var (
+			fault   *soap.FaultError
+			httpErr *soap.HTTPError
+		)
+
+		reachable := err == nil || errors.As(err, &fault) || errors.As(err, &httpErr)
+		if reachable == want {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if want {
+				return fmt.Errorf("device not reachable: %w", ctx.Err())
+			}
+
+			return fmt.Errorf("device did not go down: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// EstimatedBandwidth estimates the bitrate in kbps the device sends when every
+// profile is streamed to one client. perProfile maps profile tokens to the
+// video bitrate (TargetBitrate, else BitrateLimit) plus the audio bitrate of
+// the profile. In the total, an encoder configuration that streams to a
+// multicast group on its own (AutoStart) is counted once, however many
+// profiles share it, since one multicast stream serves them all. Profiles
+// without encoder configurations count as zero.
+func (c *Client) EstimatedBandwidth(ctx context.Context) (kbps int, perProfile map[string]int, err error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	// The profiles may omit multicast settings; take them from the full configurations.
+	videoConfigs := map[string]*VideoEncoderConfiguration{}
+
+	configs, err := c.GetVideoEncoderConfigurations(ctx)
+	if err != nil {
+		c.skipCheck("video encoder multicast settings", err)
+	}
+
+	for _, config := range configs {
+		videoConfigs[config.Token] = config
+	}
+
+	audioConfigs := c.profileAudioEncoderConfigurations(ctx, profiles)
+
+	perProfile = make(map[string]int, len(profiles))
+	multicast := map[string]bool{}
+
+	for _, profile := range profiles {
+		var profileKbps int
+
+		if video := profile.VideoEncoderConfiguration; video != nil {
+			multicastConfig := video.Multicast
+			if full, ok := videoConfigs[video.Token]; ok && multicastConfig == nil {
+				multicastConfig = full.Multicast
+			}
+
+			bitrate := videoBitrate(video)
+			profileKbps += bitrate
+
+			if !countOnce(multicast, "video/"+video.Token, multicastConfig) {
+				kbps += bitrate
+			}
+		}
+
+		if audio := profile.AudioEncoderConfiguration; audio != nil {
+			bitrate, multicastConfig := audio.Bitrate, audio.Multicast
+			if full, ok := audioConfigs[audio.Token]; ok {
+				if bitrate == 0 {
+					bitrate = full.Bitrate
+				}
+
+				if multicastConfig == nil {
+					multicastConfig = full.Multicast
+				}
+			}
+
+			profileKbps += bitrate
+
+			if !countOnce(multicast, "audio/"+audio.Token, multicastConfig) {
+				kbps += bitrate
+			}
+		}
+
+		perProfile[profile.Token] = profileKbps
+	}
+
+	return kbps, perProfile, nil
+}
+
+// profileAudioEncoderConfigurations returns the audio encoder configurations
+// of the device keyed by token. They are only read if some profile has an
+// audio encoder; nil is returned otherwise, or if they cannot be read.
+func (c *Client) profileAudioEncoderConfigurations(
+	ctx context.Context,
+	profiles []*Profile,
+) map[string]*AudioEncoderConfiguration {
+	hasAudio := slices.ContainsFunc(profiles, func(profile *Profile) bool {
+		return profile.AudioEncoderConfiguration != nil
+	})
+	if !hasAudio {
+		return nil
+	}
+
+	configs, err := c.GetAudioEncoderConfigurations(ctx)
+	if err != nil {
+		c.skipCheck("audio encoder multicast settings", err)
+
+		return nil
+	}
+
+	byToken := make(map[string]*AudioEncoderConfiguration, len(configs))
+	for _, config := range configs {
+		byToken[config.Token] = config
+	}
+
+	return byToken
+}
+
+// videoBitrate returns the target bitrate of a video encoder in kbps, falling
+// back to its bitrate limit.
+func videoBitrate(config *VideoEncoderConfiguration) int {
+	if config.RateControl == nil {
+		return 0
+	}
+
+	if config.RateControl.TargetBitrate > 0 {
+		return config.RateControl.TargetBitrate
+	}
+
+	return config.RateControl.BitrateLimit
+}
+
+// countOnce reports whether the stream of an autostarted multicast encoder
+// configuration was already counted, recording it in seen.
+func countOnce(seen map[string]bool, key string, multicast *MulticastConfiguration) bool {
+	if multicast == nil || !multicast.AutoStart {
+		return false
+	}
+
+	if seen[key] {
+		return true
+	}
+
+	seen[key] = true
+
+	return false
+}
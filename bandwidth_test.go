@@ -0,0 +1,94 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEstimatedBandwidth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Main"><tt:Name>Main</tt:Name>
+					<tt:VideoEncoderConfiguration token="VEC_1"><tt:RateControl><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Recording"><tt:Name>Recording</tt:Name>
+					<tt:VideoEncoderConfiguration token="VEC_1"><tt:RateControl><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Sub"><tt:Name>Sub</tt:Name>
+					<tt:VideoEncoderConfiguration token="VEC_2"><tt:RateControl><tt:BitrateLimit>1024</tt:BitrateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Empty"><tt:Name>Empty</tt:Name></trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfigurations"):
+			response = `<trt:GetVideoEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configurations token="VEC_1">
+					<tt:RateControl><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+					<tt:Multicast><tt:Port>5000</tt:Port><tt:TTL>1</tt:TTL><tt:AutoStart>true</tt:AutoStart></tt:Multicast>
+				</trt:Configurations>
+				<trt:Configurations token="VEC_2">
+					<tt:RateControl><tt:BitrateLimit>1024</tt:BitrateLimit></tt:RateControl>
+				</trt:Configurations>
+			</trt:GetVideoEncoderConfigurationsResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion1))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	kbps, perProfile, err := client.EstimatedBandwidth(context.Background())
+	if err != nil {
+		t.Fatalf("EstimatedBandwidth() failed: %v", err)
+	}
+
+	// The multicast stream of VEC_1 is shared by Main and Recording.
+	if kbps != 5120 {
+		t.Errorf("Expected 5120 kbps in total, got %d", kbps)
+	}
+
+	want := map[string]int{"Main": 4096, "Recording": 4096, "Sub": 1024, "Empty": 0}
+	for token, bitrate := range want {
+		if perProfile[token] != bitrate {
+			t.Errorf("Expected %d kbps for %s, got %d", bitrate, token, perProfile[token])
+		}
+	}
+}
+
+func TestVideoBitrate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *VideoEncoderConfiguration
+		want   int
+	}{
+		{"no rate control", &VideoEncoderConfiguration{}, 0},
+		{"bitrate limit", &VideoEncoderConfiguration{RateControl: &VideoRateControl{BitrateLimit: 2048}}, 2048},
+		{"target bitrate preferred", &VideoEncoderConfiguration{
+			RateControl: &VideoRateControl{BitrateLimit: 4096, TargetBitrate: 3000},
+		}, 3000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := videoBitrate(tt.config); got != tt.want {
+				t.Errorf("videoBitrate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+)
+
+// Defaults used by batch helpers such as ListStreams and GetAllConfigurations.
+const (
+	// DefaultBatchRetryBudget is the total number of retries one batch call may spend.
+	DefaultBatchRetryBudget = 4
+	// DefaultBatchRetries is the number of times a single sub-request is retried.
+	DefaultBatchRetries = 2
+	// DefaultBatchRetryDelay is the delay before the first retry of a sub-request;
+	// it grows linearly with each further attempt.
+	DefaultBatchRetryDelay = 200 * time.Millisecond
+)
+
+// defaultBusySubcodes are the fault subcodes devices use to report that they
+// are temporarily too busy to handle a request. None of them is standardized;
+// they are the ones seen from vendors under load.
+var defaultBusySubcodes = []string{"Busy", "DeviceBusy", "ServiceBusy", "TooManyRequests", "ServerBusy"}
+
+// RetryBudget caps the total number of retries across the sub-requests of a
+// batch operation, so that a degraded device is not hit with a retry storm.
+// A budget is safe for concurrent use and may be shared between batch calls,
+// e.g. to bound the retries of a whole fleet sweep.
+type RetryBudget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+// NewRetryBudget creates a budget allowing the given number of retries in total.
+func NewRetryBudget(retries int) *RetryBudget {
+	return &RetryBudget{remaining: retries}
+}
+
+// Remaining returns the number of retries left.
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.remaining
+}
+
+// take spends one retry, reporting false when the budget is exhausted.
+func (b *RetryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.remaining <= 0 {
+		return false
+	}
+
+	b.remaining--
+
+	return true
+}
+
+// BatchOption configures a batch helper.
+type BatchOption func(*batchConfig)
+
+// batchConfig holds the retry settings of one batch call.
+type batchConfig struct {
+	budget       *RetryBudget
+	retries      int
+	delay        time.Duration
+	busySubcodes []string
+}
+
+// WithRetryBudget makes the batch call spend its retries from budget instead of
+// a fresh budget of DefaultBatchRetryBudget retries.
+func WithRetryBudget(budget *RetryBudget) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.budget = budget
+	}
+}
+
+// WithBatchRetries sets how often a single failed sub-request is retried,
+// within the retry budget. Zero disables retries.
+func WithBatchRetries(retries int) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.retries = retries
+	}
+}
+
+// WithBatchRetryDelay sets the delay before the first retry of a sub-request.
+func WithBatchRetryDelay(delay time.Duration) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.delay = delay
+	}
+}
+
+// WithBusySubcodes adds fault subcodes that mark a device as temporarily busy,
+// in addition to the built-in ones such as DeviceBusy and TooManyRequests.
+// Faults carrying one of them are retried like network errors.
+func WithBusySubcodes(subcodes ...string) BatchOption {
+	return func(cfg *batchConfig) {
+		cfg.busySubcodes = append(cfg.busySubcodes, subcodes...)
+	}
+}
+
+// newBatchConfig applies opts to the defaults.
+func newBatchConfig(opts []BatchOption) *batchConfig {
+	cfg := &batchConfig{
+		retries:      DefaultBatchRetries,
+		delay:        DefaultBatchRetryDelay,
+		busySubcodes: slices.Clone(defaultBusySubcodes),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.budget == nil {
+		cfg.budget = NewRetryBudget(DefaultBatchRetryBudget)
+	}
+
+	return cfg
+}
+
+// withCredentialSnapshot pins the current credentials to ctx, so that all
+// sub-requests of a batch authenticate alike even when SetCredentials runs
+// while the batch is in progress.
+func (c *Client) withCredentialSnapshot(ctx context.Context) context.Context {
+	username, password := c.GetCredentials()
+
+	return soap.WithCredentials(ctx, username, password)
+}
+
+// batchCall runs one sub-request of a batch, retrying transient failures while
+// both the per-request retries and the shared budget allow.
+func batchCall[T any](ctx context.Context, cfg *batchConfig, fn func(context.Context) (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := fn(ctx)
+		if err == nil || attempt > cfg.retries || !isTransientError(err, cfg.busySubcodes) || !cfg.budget.take() {
+			return result, err
+		}
+
+		select {
+		case <-time.After(cfg.delay * time.Duration(attempt)):
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+// isTransientError reports whether a failed request may succeed when retried:
+// network errors, HTTP 5xx or 429 responses without a SOAP fault, and faults
+// carrying one of busySubcodes. Other SOAP faults are answers from the device
+// and are not retried; neither is a fault that also reports InvalidArgVal, nor
+// an error reporting an unsupported operation, such as HTTP 501.
+func isTransientError(err error, busySubcodes []string) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrActionNotSupported) {
+		return false
+	}
+
+	var fault *soap.FaultError
+	if errors.As(err, &fault) {
+		if fault.HasSubcode("InvalidArgVal") {
+			return false
+		}
+
+		return slices.ContainsFunc(busySubcodes, fault.HasSubcode)
+	}
+
+	var httpErr *soap.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// MediaConfigurations holds the media configurations of a device.
+type MediaConfigurations struct {
+	VideoSources  []*VideoSourceConfiguration
+	VideoEncoders []*VideoEncoderConfiguration
+	AudioSources  []*AudioSourceConfiguration
+	AudioEncoders []*AudioEncoderConfiguration
+	Metadata      []*MetadataConfiguration
+}
+
+// GetAllConfigurations retrieves all media configurations of the device in one
+// batch. Transient failures of the individual requests are retried within a
+// shared retry budget, see WithRetryBudget. The credentials are read once at
+// the start; a concurrent SetCredentials takes effect with the next batch.
+func (c *Client) GetAllConfigurations(ctx context.Context, opts ...BatchOption) (*MediaConfigurations, error) {
+	ctx = c.withCredentialSnapshot(ctx)
+	cfg := newBatchConfig(opts)
+	configs := &MediaConfigurations{}
+
+	var err error
+
+	if configs.VideoSources, err = batchCall(ctx, cfg, c.GetVideoSourceConfigurations); err != nil {
+		return nil, fmt.Errorf("failed to get video source configurations: %w", err)
+	}
+
+	if configs.VideoEncoders, err = batchCall(ctx, cfg, c.GetVideoEncoderConfigurations); err != nil {
+		return nil, fmt.Errorf("failed to get video encoder configurations: %w", err)
+	}
+
+	if configs.AudioSources, err = batchCall(ctx, cfg, c.GetAudioSourceConfigurations); err != nil {
+		return nil, fmt.Errorf("failed to get audio source configurations: %w", err)
+	}
+
+	if configs.AudioEncoders, err = batchCall(ctx, cfg, c.GetAudioEncoderConfigurations); err != nil {
+		return nil, fmt.Errorf("failed to get audio encoder configurations: %w", err)
+	}
+
+	if configs.Metadata, err = batchCall(ctx, cfg, c.GetMetadataConfigurations); err != nil {
+		return nil, fmt.Errorf("failed to get metadata configurations: %w", err)
+	}
+
+	return configs, nil
+}
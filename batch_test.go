@@ -0,0 +1,275 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMockBatchServer answers every media request with an empty response, except
+// that the first failures requests are answered with HTTP 503.
+func newMockBatchServer(t *testing.T, failures int32, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	operation := regexp.MustCompile(`<trt:(\w+)`)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		op := operation.FindSubmatch(body)
+		if op == nil {
+			t.Errorf("Unexpected request: %s", body)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:` + string(op[1]) + `Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestGetAllConfigurationsRetryBudget(t *testing.T) {
+	t.Run("transient failure retried", func(t *testing.T) {
+		var requests atomic.Int32
+
+		server := newMockBatchServer(t, 1, &requests)
+		defer server.Close()
+
+		client, err := NewClient(server.URL)
+		if err != nil {
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+
+		budget := NewRetryBudget(3)
+
+		if _, err := client.GetAllConfigurations(context.Background(), WithRetryBudget(budget), WithBatchRetryDelay(0)); err != nil {
+			t.Fatalf("GetAllConfigurations() failed: %v", err)
+		}
+
+		if got := requests.Load(); got != 6 {
+			t.Errorf("Expected 6 requests (5 + 1 retry), got %d", got)
+		}
+
+		if budget.Remaining() != 2 {
+			t.Errorf("Expected 2 retries left, got %d", budget.Remaining())
+		}
+	})
+
+	t.Run("budget exhausted", func(t *testing.T) {
+		var requests atomic.Int32
+
+		server := newMockBatchServer(t, 100, &requests)
+		defer server.Close()
+
+		client, err := NewClient(server.URL)
+		if err != nil {
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+
+		_, err = client.GetAllConfigurations(context.Background(),
+			WithRetryBudget(NewRetryBudget(1)), WithBatchRetries(5), WithBatchRetryDelay(0))
+		if err == nil {
+			t.Fatal("Expected GetAllConfigurations() to fail")
+		}
+
+		if got := requests.Load(); got != 2 {
+			t.Errorf("Expected 2 requests (1 + 1 retry from the budget), got %d", got)
+		}
+	})
+}
+
+func TestBatchCallDoesNotRetryFaults(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<soap:Fault>
+		<soap:Code><soap:Value>soap:Receiver</soap:Value></soap:Code>
+		<soap:Reason><soap:Text xml:lang="en">Action not supported</soap:Text></soap:Reason>
+	</soap:Fault>
+</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.ListStreams(context.Background(), WithBatchRetryDelay(0)); err == nil {
+		t.Fatal("Expected ListStreams() to fail")
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected SOAP fault not to be retried, got %d requests", got)
+	}
+}
+
+func TestBatchCallDoesNotRetryUnsupported(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.ListStreams(context.Background(), WithBatchRetryDelay(0)); err == nil {
+		t.Fatal("Expected ListStreams() to fail")
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected an unsupported operation not to be retried, got %d requests", got)
+	}
+}
+
+func TestBatchCallRetriesBusyFaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		subcodes string
+		opts     []BatchOption
+		want     int32
+	}{
+		{"busy retried", "<soap:Subcode><soap:Value>ter:DeviceBusy</soap:Value></soap:Subcode>", nil, 2},
+		{"invalid argument not retried", "<soap:Subcode><soap:Value>ter:InvalidArgVal</soap:Value>" +
+			"<soap:Subcode><soap:Value>ter:DeviceBusy</soap:Value></soap:Subcode></soap:Subcode>", nil, 1},
+		{"vendor code not retried by default", "<soap:Subcode><soap:Value>acme:Overloaded</soap:Value></soap:Subcode>", nil, 1},
+		{"vendor code added by option", "<soap:Subcode><soap:Value>acme:Overloaded</soap:Value></soap:Subcode>",
+			[]BatchOption{WithBusySubcodes("Overloaded")}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/soap+xml")
+
+				if requests.Add(1) > 1 {
+					_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+</soap:Body></soap:Envelope>`))
+
+					return
+				}
+
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<soap:Fault>
+		<soap:Code><soap:Value>soap:Receiver</soap:Value>` + tt.subcodes + `</soap:Code>
+		<soap:Reason><soap:Text xml:lang="en">Busy</soap:Text></soap:Reason>
+	</soap:Fault>
+</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			cfg := newBatchConfig(append([]BatchOption{WithBatchRetryDelay(0)}, tt.opts...))
+			_, _ = batchCall(context.Background(), cfg, client.GetProfiles)
+
+			if got := requests.Load(); got != tt.want {
+				t.Errorf("Expected %d requests, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetAllConfigurationsCredentialSnapshot(t *testing.T) {
+	username := regexp.MustCompile(`<Username>(\w+)</Username>`)
+	operation := regexp.MustCompile(`<trt:(\w+)`)
+
+	var (
+		mu    sync.Mutex
+		users = map[string]bool{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if m := username.FindSubmatch(body); m != nil {
+			mu.Lock()
+			users[string(m[1])] = true
+			mu.Unlock()
+		}
+
+		op := operation.FindSubmatch(body)
+		if op == nil {
+			t.Errorf("Unexpected request: %s", body)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:` + string(op[1]) + `Response xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>
+</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("alice", "secret"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// Rotate the credentials while the batches run.
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(50 * time.Microsecond):
+				client.SetCredentials([]string{"alice", "bob"}[i%2], "secret")
+			}
+		}
+	}()
+
+	for range 20 {
+		mu.Lock()
+		clear(users)
+		mu.Unlock()
+
+		if _, err := client.GetAllConfigurations(context.Background()); err != nil {
+			t.Fatalf("GetAllConfigurations() failed: %v", err)
+		}
+
+		mu.Lock()
+		if len(users) != 1 {
+			t.Errorf("Expected one username per batch, got %v", users)
+		}
+		mu.Unlock()
+	}
+}
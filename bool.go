@@ -0,0 +1,53 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Bool is an xs:boolean that tolerates the spellings devices use in practice.
+// Besides "true"/"false" and "1"/"0" it accepts "t"/"f", as strconv.ParseBool
+// does, and "yes"/"no", all in any case, and treats an empty value as false.
+type Bool bool
+
+// parseBool parses a lenient boolean value.
+func parseBool(s string) (Bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "1", "t", "yes":
+		return true, nil
+	case "false", "0", "f", "no", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: invalid boolean value %q", ErrInvalidResponse, s)
+	}
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (b *Bool) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+
+	v, err := parseBool(s)
+	if err != nil {
+		return err
+	}
+
+	*b = v
+
+	return nil
+}
+
+// UnmarshalXMLAttr implements xml.UnmarshalerAttr.
+func (b *Bool) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, err := parseBool(attr.Value)
+	if err != nil {
+		return err
+	}
+
+	*b = v
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+func TestBoolUnmarshal(t *testing.T) {
+	type fixture struct {
+		Attr    Bool `xml:"flag,attr"`
+		Element Bool `xml:"Enabled"`
+	}
+
+	tests := []struct {
+		value string
+		want  Bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1", true},
+		{"0", false},
+		{"yes", true},
+		{"no", false},
+		{"t", true},
+		{"F", false},
+		{" TRUE ", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		var f fixture
+
+		data := `<fixture flag="` + tt.value + `"><Enabled>` + tt.value + `</Enabled></fixture>`
+		if err := xml.Unmarshal([]byte(data), &f); err != nil {
+			t.Errorf("Unmarshal(%q) failed: %v", tt.value, err)
+
+			continue
+		}
+
+		if f.Attr != tt.want || f.Element != tt.want {
+			t.Errorf("Unmarshal(%q) = attr %v, element %v, want %v", tt.value, f.Attr, f.Element, tt.want)
+		}
+	}
+
+	var f fixture
+	if err := xml.Unmarshal([]byte(`<fixture flag="maybe"/>`), &f); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse for invalid boolean value, got %v", err)
+	}
+
+	if err := xml.Unmarshal([]byte(`<fixture><Enabled>maybe</Enabled></fixture>`), &f); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse for invalid boolean element, got %v", err)
+	}
+}
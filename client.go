@@ -2,18 +2,21 @@ package onvif
 
 import (
 	"context"
-	"crypto/md5" //nolint:gosec // MD5 used for ONVIF digest authentication
-	"crypto/rand"
 	"crypto/tls"
-	"encoding/hex"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"net"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // Default client configuration constants.
@@ -39,12 +42,124 @@ type Client struct {
 	mu         sync.RWMutex
 
 	// Service endpoints
-	mediaEndpoint   string
-	ptzEndpoint     string
-	imagingEndpoint string
-	eventEndpoint   string
+	mediaEndpoint    string
+	media2Endpoint   string
+	ptzEndpoint      string
+	imagingEndpoint  string
+	eventEndpoint    string
+	replayEndpoint   string
+	displayEndpoint  string
+	deviceIOEndpoint string
+	receiverEndpoint string
+
+	// serviceEndpoints holds every address resolved by Initialize keyed by namespace, see ServiceEndpoint
+	serviceEndpoints map[string]string
+
+	// serviceVersions holds the service versions from GetServices keyed by namespace, see ServiceVersion
+	serviceVersions map[string]OnvifVersion
+
+	// serviceCapabilities holds the capability XML from GetServices keyed by namespace, see WithServiceCapabilities
+	includeCapabilities bool
+	serviceCapabilities map[string][]byte
+
+	// mediaVersion selects the media service used by GetProfiles and GetStreamURI, see WithMediaVersion
+	mediaVersion MediaVersion
+
+	// defaultCallTimeout bounds SOAP calls whose context has no deadline, see WithDefaultCallTimeout
+	defaultCallTimeout time.Duration
+
+	// logger receives diagnostic messages; nil disables logging
+	logger func(format string, args ...interface{})
+
+	// Pull point limits advertised by the device in PullMessagesFaultResponse
+	pullMaxTimeout      time.Duration
+	pullMaxMessageLimit int
+
+	// checkEncoderInstances gates AddVideoEncoderConfiguration on the guaranteed encoder instances
+	checkEncoderInstances bool
+
+	// skipLockOutCheck disables the SetIPAddressFilter self lock-out guard, see WithoutIPFilterLockOutCheck
+	skipLockOutCheck bool
+
+	// streamNameRoles maps lower-cased profile names to stream roles, see WithStreamNameRoles
+	streamNameRoles map[string]StreamRole
+
+	// ptzSpaces caches the PTZ spaces per profile token, see GetPTZSpaces
+	ptzSpacesMu sync.Mutex
+	ptzSpaces   map[string]ptzSpacesResult
+
+	// moveOptions caches the focus move options per video source token, see Move
+	moveOptionsMu sync.Mutex
+	moveOptions   map[string]*MoveOptions
+
+	// checkCapabilities caches the service capabilities consulted by client-side
+	// checks, keyed by namespace, see checkedCapabilities
+	checkCapabilitiesMu sync.Mutex
+	checkCapabilities   map[string]any
+
+	// downloadAuth caches the HTTP authentication scheme per host for DownloadFile
+	downloadAuth map[string]httpAuthScheme
+
+	// flights de-duplicates concurrent identical calls; nil unless WithSingleFlight is used
+	flights *flightGroup
+
+	// syncClock makes Initialize measure the device clock offset, see WithClockSync
+	syncClock bool
+	// clockOffset is the device clock minus the local clock, valid if clockOffsetKnown
+	clockOffset      time.Duration
+	clockOffsetKnown bool
+
+	// digest caches the HTTP Digest challenges of SOAP calls across calls
+	digest *soap.DigestSession
+
+	// soapClient is the SOAP client shared by calls, built by newSOAPClient for soapHTTPClient
+	soapClient     *soap.Client
+	soapHTTPClient *http.Client
+
+	// lenientNamespaces enables the default-namespace request fallback, see WithLenientNamespaces
+	lenientNamespaces bool
+	// namespaceStyle is the request form the device accepted, valid if namespaceStyleKnown
+	namespaceStyle      NamespaceStyle
+	namespaceStyleKnown bool
+
+	// strictDecoding logs the response elements dropped by decoding, see WithStrictDecoding
+	strictDecoding bool
+
+	// lenientServerErrors treats HTTP 500 errors of optional calls as unsupported, see WithLenientServerErrors
+	lenientServerErrors bool
+
+	// extraNamespaces are declared on the Envelope of every request, see WithExtraNamespaces
+	extraNamespaces map[string]string
+
+	// mediaURIs caches stream and snapshot URIs; nil unless WithMediaURICache is used
+	mediaURIs *mediaURICache
+
+	// rebootMu guards the reboot detector state, see OnReboot
+	rebootMu       sync.Mutex
+	rebootHandlers []func()
+	// rebootBaseline is the device clock offset last read, valid if rebootBaselineKnown
+	rebootBaseline      time.Duration
+	rebootBaselineKnown bool
+	// deviceUnreachable is set when a call failed because the device could not be reached
+	deviceUnreachable bool
+	// rebootChecking is set while the device clock is read for a reboot check
+	rebootChecking bool
+	// rebootChecks tracks the reboot checks running in the background
+	rebootChecks sync.WaitGroup
 }
 
+// NamespaceStyle selects how the elements of outgoing requests are namespaced,
+// see WithLenientNamespaces.
+type NamespaceStyle = soap.NamespaceStyle
+
+const (
+	// NamespacePrefixed sends elements with prefixes, e.g. <trt:GetProfiles xmlns:trt="...">.
+	NamespacePrefixed = soap.NamespacePrefixed
+	// NamespaceDefault sends unprefixed elements with default namespace
+	// declarations, e.g. <GetProfiles xmlns="...">.
+	NamespaceDefault = soap.NamespaceDefault
+)
+
 // ClientOption is a functional option for configuring the Client.
 type ClientOption func(*Client)
 
@@ -76,7 +191,61 @@ func WithInsecureSkipVerify() ClientOption {
 	}
 }
 
-// WithCredentials sets the authentication credentials.
+// WithDisableKeepAlive makes every request use a fresh connection, for
+// devices whose firmware returns stale or truncated responses on reused
+// connections. Each call then pays for a new TCP (and TLS) handshake, which
+// adds noticeable latency, so only use it for such devices. The transport is
+// cloned, so that an HTTP client set with WithHTTPClient before it is not
+// affected. A transport other than *http.Transport is wrapped instead, so that
+// every request asks for the connection to be closed.
+func WithDisableKeepAlive() ClientOption {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+
+		switch transport := c.httpClient.Transport.(type) {
+		case nil:
+			if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+				httpClient.Transport = disableKeepAlives(transport)
+			} else {
+				httpClient.Transport = closeConnectionTransport{next: http.DefaultTransport}
+			}
+		case *http.Transport:
+			httpClient.Transport = disableKeepAlives(transport)
+		default:
+			httpClient.Transport = closeConnectionTransport{next: transport}
+		}
+
+		c.httpClient = &httpClient
+	}
+}
+
+// disableKeepAlives returns a clone of transport with keep-alives disabled.
+func disableKeepAlives(transport *http.Transport) *http.Transport {
+	transport = transport.Clone()
+	transport.DisableKeepAlives = true
+
+	return transport
+}
+
+// closeConnectionTransport marks every request to close its connection once
+// the response is read, for transports whose keep-alives cannot be disabled
+// directly.
+type closeConnectionTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip sends a copy of req with Close set, which sends
+// "Connection: close" and keeps the connection from being reused.
+func (t closeConnectionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Close = true
+
+	return t.next.RoundTrip(req)
+}
+
+// WithCredentials sets the authentication credentials. They are sent in a
+// WS-Security UsernameToken, and SOAP calls answer HTTP Digest challenges from
+// devices that require Digest authentication instead.
 func WithCredentials(username, password string) ClientOption {
 	return func(c *Client) {
 		c.username = username
@@ -84,6 +253,118 @@ func WithCredentials(username, password string) ClientOption {
 	}
 }
 
+// WithClockSync makes Initialize measure the device clock offset with
+// SyncClock, so that the Created time of the WS-Security UsernameToken sent
+// with every call follows the device clock and devices whose clock is off do
+// not reject requests for clock skew. The offset can also be set with
+// SetClockOffset.
+func WithClockSync() ClientOption {
+	return func(c *Client) {
+		c.syncClock = true
+	}
+}
+
+// WithDefaultCallTimeout bounds every SOAP call whose context has no deadline,
+// such as context.Background(), to timeout, so that a hung device cannot block
+// a call forever when the HTTP client has no timeout of its own. An existing
+// context deadline is never overridden.
+func WithDefaultCallTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.defaultCallTimeout = timeout
+	}
+}
+
+// WithLogger sets a logger for diagnostic messages, such as request values
+// adjusted to fit device limits.
+func WithLogger(logger func(format string, args ...interface{})) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithVideoEncoderInstanceCheck makes AddVideoEncoderConfiguration verify with
+// CanAddVideoEncoder that the device guarantees another encoder instance for
+// the codec, returning ErrVideoEncoderLimitReached instead of adding an encoder
+// that may fail to stream. SetStreamParameters likewise returns
+// ErrStreamNotSustainable instead of only logging a warning.
+func WithVideoEncoderInstanceCheck() ClientOption {
+	return func(c *Client) {
+		c.checkEncoderInstances = true
+	}
+}
+
+// WithoutIPFilterLockOutCheck lets SetIPAddressFilter apply filters that block
+// the client's own source address instead of returning ErrWouldLockOut.
+func WithoutIPFilterLockOutCheck() ClientOption {
+	return func(c *Client) {
+		c.skipLockOutCheck = true
+	}
+}
+
+// WithServiceCapabilities makes Initialize request the capabilities of every
+// service along with its address from GetServices. The capability accessors,
+// such as GetMediaServiceCapabilities, then answer from this response instead
+// of calling GetServiceCapabilities on each service.
+func WithServiceCapabilities() ClientOption {
+	return func(c *Client) {
+		c.includeCapabilities = true
+	}
+}
+
+// WithLenientNamespaces rescues devices whose SOAP parser does not resolve
+// namespace prefixes. Until a call succeeds, a request the device rejects as
+// malformed, with a Sender fault without an ONVIF subcode or an HTTP 400
+// error, is retried with unprefixed elements and default namespace
+// declarations. The form of the first
+// successful call is used for all later calls, see Client.NamespaceStyle.
+func WithLenientNamespaces() ClientOption {
+	return func(c *Client) {
+		c.lenientNamespaces = true
+	}
+}
+
+// WithLenientServerErrors makes Optional and ListSnapshotURIs take a response
+// with HTTP status 500 and no SOAP fault, such as an empty or HTML error page,
+// as an unsupported operation. Some devices answer operations they do not
+// implement that way instead of with an ActionNotSupported fault, which
+// otherwise surfaces as a generic HTTPError. Other calls still return the
+// HTTPError, which does not match ErrActionNotSupported, since a 500 may also
+// be a genuine failure of a supported operation.
+func WithLenientServerErrors() ClientOption {
+	return func(c *Client) {
+		c.lenientServerErrors = true
+	}
+}
+
+// WithStrictDecoding reports the elements of responses that the library does
+// not decode, which are otherwise silently dropped. The paths of such
+// elements, e.g. "GetProfilesResponse/Profiles/Extension", are passed to the
+// logger of WithLogger after each call. Responses are still decoded leniently;
+// the check only helps to find data a device returns that is not captured.
+func WithStrictDecoding(strict bool) ClientOption {
+	return func(c *Client) {
+		c.strictDecoding = strict
+	}
+}
+
+// WithExtraNamespaces declares additional namespaces, keyed by prefix, on the
+// Envelope element of every request. Vendor operations sent with RawCall, or
+// devices that expect prefixes declared up front, then resolve them instead of
+// faulting with an unknown namespace prefix. Common ONVIF prefixes are:
+//
+//	"tas":  "http://www.onvif.org/ver10/advancedsecurity/wsdl"
+//	"tptz": "http://www.onvif.org/ver20/ptz/wsdl"
+//	"tmd":  "http://www.onvif.org/ver10/deviceIO/wsdl"
+//	"tt":   "http://www.onvif.org/ver10/schema"
+func WithExtraNamespaces(namespaces map[string]string) ClientOption {
+	return func(c *Client) {
+		c.extraNamespaces = make(map[string]string, len(namespaces))
+		for prefix, uri := range namespaces {
+			c.extraNamespaces[prefix] = uri
+		}
+	}
+}
+
 // NewClient creates a new ONVIF client
 // The endpoint can be provided in multiple formats:
 //   - Full URL: "http://192.168.1.100/onvif/device_service"
@@ -98,6 +379,7 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 
 	client := &Client{
 		endpoint: normalizedEndpoint,
+		digest:   soap.NewDigestSession(),
 		httpClient: &http.Client{
 			Timeout: DefaultTimeout,
 			Transport: &http.Transport{
@@ -121,6 +403,13 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 	return client, nil
 }
 
+// logf logs a diagnostic message if a logger is configured.
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger(format, args...)
+	}
+}
+
 // normalizeEndpoint converts various endpoint formats to a full ONVIF URL.
 func normalizeEndpoint(endpoint string) (string, error) {
 	// Check if endpoint starts with a scheme
@@ -195,30 +484,335 @@ func (c *Client) fixLocalhostURL(serviceURL string) string {
 	return serviceURL
 }
 
+// Initialization sources reported in InitReport.Source.
+const (
+	// InitSourceGetServices indicates endpoints were discovered with GetServices.
+	InitSourceGetServices = "GetServices"
+	// InitSourceGetCapabilities indicates endpoints were discovered with GetCapabilities.
+	InitSourceGetCapabilities = "GetCapabilities"
+)
+
+// InitReport describes the outcome of InitializeWithReport.
+type InitReport struct {
+	// Source is the operation the endpoints were discovered from.
+	Source string
+	// Endpoints maps the namespace of each resolved service to its address.
+	Endpoints map[string]string
+	// Unresolved lists the services the device advertised without a usable address.
+	Unresolved []string
+	// Warnings holds non-fatal errors encountered while discovering endpoints.
+	Warnings []error
+}
+
 // Initialize discovers and initializes service endpoints.
+// See InitializeWithReport for details on partial failures.
 func (c *Client) Initialize(ctx context.Context) error {
-	// Get device information and capabilities
-	capabilities, err := c.GetCapabilities(ctx)
+	_, err := c.InitializeWithReport(ctx)
+
+	return err
+}
+
+// InitializeWithReport discovers and initializes service endpoints, returning a
+// report of what was resolved. GetServices is preferred; when it is unavailable
+// the GetCapabilities response is decoded category by category so that one
+// malformed category does not prevent the others from being used. An error is
+// only returned when no endpoint at all could be resolved. The endpoints of an
+// earlier initialization are replaced, so that services the device no longer
+// advertises are not called at their old address. The addresses of
+// all services, including those without dedicated operations such as
+// analytics, are available from ServiceEndpoint afterwards.
+func (c *Client) InitializeWithReport(ctx context.Context) (*InitReport, error) {
+	report := &InitReport{
+		Endpoints: make(map[string]string),
+	}
+
+	if _, known := c.ClockOffset(); c.syncClock && !known {
+		if _, err := c.SyncClock(ctx); err != nil {
+			report.Warnings = append(report.Warnings, err)
+		}
+	}
+
+	addrs, versions, capabilities, err := c.serviceAddresses(ctx)
+	if err == nil && len(addrs) > 0 {
+		report.Source = InitSourceGetServices
+	} else {
+		if err != nil {
+			report.Warnings = append(report.Warnings, err)
+		}
+
+		addrs, err = c.capabilityAddresses(ctx)
+		if err != nil {
+			return report, fmt.Errorf("failed to get capabilities: %w", err)
+		}
+
+		report.Source = InitSourceGetCapabilities
+		versions, capabilities = nil, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.serviceVersions = versions
+	c.serviceCapabilities = capabilities
+	c.resetCheckedCapabilities()
+
+	for _, endpoint := range c.serviceEndpointFields() {
+		*endpoint = ""
+	}
+
+	for namespace, addr := range addrs {
+		if addr == "" {
+			report.Unresolved = append(report.Unresolved, namespace)
+
+			continue
+		}
+
+		// Some cameras incorrectly report localhost instead of their actual IP
+		addr = c.fixLocalhostURL(addr)
+		if _, err := url.Parse(addr); err != nil {
+			report.Unresolved = append(report.Unresolved, namespace)
+			report.Warnings = append(report.Warnings, fmt.Errorf("%s: %w", namespace, err))
+
+			continue
+		}
+
+		switch namespace {
+		case mediaNamespace:
+			c.mediaEndpoint = addr
+		case media2Namespace:
+			c.media2Endpoint = addr
+		case ptzNamespace:
+			c.ptzEndpoint = addr
+		case imagingNamespace:
+			c.imagingEndpoint = addr
+		case eventNamespace:
+			c.eventEndpoint = addr
+		case replayNamespace:
+			c.replayEndpoint = addr
+		case displayNamespace:
+			c.displayEndpoint = addr
+		case deviceIONamespace:
+			c.deviceIOEndpoint = addr
+		case receiverNamespace:
+			c.receiverEndpoint = addr
+		}
+
+		report.Endpoints[namespace] = addr
+	}
+
+	sort.Strings(report.Unresolved)
+
+	c.serviceEndpoints = maps.Clone(report.Endpoints)
+
+	if len(report.Endpoints) == 0 {
+		return report, fmt.Errorf("%w: no service endpoints resolved", ErrServiceNotSupported)
+	}
+
+	return report, nil
+}
+
+// serviceEndpointFields returns the fields holding the service endpoints set
+// by Initialize. The caller must hold c.mu.
+func (c *Client) serviceEndpointFields() []*string {
+	return []*string{
+		&c.mediaEndpoint, &c.media2Endpoint, &c.ptzEndpoint, &c.imagingEndpoint, &c.eventEndpoint,
+		&c.replayEndpoint, &c.displayEndpoint, &c.deviceIOEndpoint, &c.receiverEndpoint,
+	}
+}
+
+// serviceAddresses returns the service addresses, versions and, with
+// WithServiceCapabilities, the capability XML advertised by GetServices keyed
+// by namespace.
+func (c *Client) serviceAddresses(ctx context.Context) (
+	map[string]string, map[string]OnvifVersion, map[string][]byte, error,
+) {
+	services, err := c.GetServices(ctx, c.includeCapabilities)
 	if err != nil {
-		return fmt.Errorf("failed to get capabilities: %w", err)
+		return nil, nil, nil, err
 	}
 
-	// Extract service endpoints and fix any localhost addresses
-	// Some cameras incorrectly report localhost instead of their actual IP
-	if capabilities.Media != nil && capabilities.Media.XAddr != "" {
-		c.mediaEndpoint = c.fixLocalhostURL(capabilities.Media.XAddr)
+	addrs := make(map[string]string, len(services))
+	versions := make(map[string]OnvifVersion, len(services))
+
+	var capabilities map[string][]byte
+
+	for _, svc := range services {
+		addrs[svc.Namespace] = strings.TrimSpace(svc.XAddr)
+		versions[svc.Namespace] = svc.Version
+
+		if len(svc.CapabilitiesXML) > 0 {
+			if capabilities == nil {
+				capabilities = make(map[string][]byte)
+			}
+
+			capabilities[svc.Namespace] = svc.CapabilitiesXML
+		}
 	}
-	if capabilities.PTZ != nil && capabilities.PTZ.XAddr != "" {
-		c.ptzEndpoint = c.fixLocalhostURL(capabilities.PTZ.XAddr)
+
+	return addrs, versions, capabilities, nil
+}
+
+// cachedCapabilities decodes the capability XML of the service with the given
+// namespace, cached by Initialize with WithServiceCapabilities, into v. It
+// reports false when nothing is cached or the XML cannot be decoded, in which
+// case the caller asks the service itself.
+func (c *Client) cachedCapabilities(namespace string, v any) bool {
+	c.mu.RLock()
+	data, ok := c.serviceCapabilities[namespace]
+	c.mu.RUnlock()
+
+	if !ok {
+		return false
 	}
-	if capabilities.Imaging != nil && capabilities.Imaging.XAddr != "" {
-		c.imagingEndpoint = c.fixLocalhostURL(capabilities.Imaging.XAddr)
+
+	if err := xml.Unmarshal(data, v); err != nil {
+		c.logf("onvif: ignoring cached capabilities of %s: %v", namespace, err)
+
+		return false
 	}
-	if capabilities.Events != nil && capabilities.Events.XAddr != "" {
-		c.eventEndpoint = c.fixLocalhostURL(capabilities.Events.XAddr)
+
+	return true
+}
+
+// checkedCapabilities returns the capabilities of the service with the given
+// namespace, read by get, for the client-side checks made before operations.
+// They are cached per client so that the checks do not cost a round trip on
+// every call; failed reads are not cached. The cache is cleared when the
+// credentials change, Initialize resolves the services again, the device
+// moves, or a call finds the device unreachable, as it is while rebooting
+// after a firmware upgrade.
+func checkedCapabilities[T any](
+	ctx context.Context,
+	c *Client,
+	namespace string,
+	get func(context.Context) (T, error),
+) (T, error) {
+	c.checkCapabilitiesMu.Lock()
+	cached, ok := c.checkCapabilities[namespace].(T)
+	c.checkCapabilitiesMu.Unlock()
+
+	if ok {
+		return cached, nil
 	}
 
-	return nil
+	caps, err := get(ctx)
+	if err != nil {
+		return caps, err
+	}
+
+	c.checkCapabilitiesMu.Lock()
+	if c.checkCapabilities == nil {
+		c.checkCapabilities = make(map[string]any)
+	}
+	c.checkCapabilities[namespace] = caps
+	c.checkCapabilitiesMu.Unlock()
+
+	return caps, nil
+}
+
+// resetCheckedCapabilities drops the capabilities cached by checkedCapabilities.
+func (c *Client) resetCheckedCapabilities() {
+	c.checkCapabilitiesMu.Lock()
+	c.checkCapabilities = nil
+	c.checkCapabilitiesMu.Unlock()
+}
+
+// skipCheck logs that the client-side check named by check is skipped because
+// the information it needs could not be read. The operation is then sent
+// unchecked and left to the device to accept or reject.
+func (c *Client) skipCheck(check string, err error) {
+	c.logf("onvif: skipping %s: %v", check, err)
+}
+
+// ServiceVersion returns the version of the service with the given namespace,
+// e.g. "http://www.onvif.org/ver20/media/wsdl", as advertised by GetServices
+// during Initialize. ok is false before Initialize, for services the device
+// does not advertise, and when Initialize had to fall back to GetCapabilities,
+// which carries no per-service versions.
+func (c *Client) ServiceVersion(namespace string) (major, minor int, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	version, ok := c.serviceVersions[namespace]
+
+	return version.Major, version.Minor, ok
+}
+
+// ServiceEndpoint returns the address of the service with the given namespace,
+// e.g. "http://www.onvif.org/ver20/analytics/wsdl", as resolved by Initialize.
+// It covers services the client has no dedicated operations for, such as
+// recording, search and analytics. ok is false before Initialize and for
+// services the device does not advertise.
+func (c *Client) ServiceEndpoint(namespace string) (addr string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	addr, ok = c.serviceEndpoints[namespace]
+
+	return addr, ok
+}
+
+// capabilityAddresses returns the service addresses advertised by GetCapabilities keyed by namespace.
+// Only the XAddr of each category is decoded so malformed capability values elsewhere are ignored.
+// Services added after ONVIF 1.0, such as recording and search, are read from the Extension element.
+func (c *Client) capabilityAddresses(ctx context.Context) (map[string]string, error) {
+	type GetCapabilities struct {
+		XMLName  xml.Name `xml:"tds:GetCapabilities"`
+		Xmlns    string   `xml:"xmlns:tds,attr"`
+		Category []string `xml:"tds:Category,omitempty"`
+	}
+
+	type category struct {
+		XMLName xml.Name
+		XAddr   string `xml:"XAddr"`
+	}
+
+	type GetCapabilitiesResponse struct {
+		XMLName      xml.Name `xml:"GetCapabilitiesResponse"`
+		Capabilities struct {
+			Categories []category `xml:",any"`
+			Extension  struct {
+				Categories []category `xml:",any"`
+			} `xml:"Extension"`
+		} `xml:"Capabilities"`
+	}
+
+	req := GetCapabilities{
+		Xmlns:    deviceNamespace,
+		Category: []string{"All"},
+	}
+
+	var resp GetCapabilitiesResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetCapabilities failed: %w", err)
+	}
+
+	categoryNamespaces := map[string]string{
+		"Device":    deviceNamespace,
+		"Media":     mediaNamespace,
+		"PTZ":       ptzNamespace,
+		"Imaging":   imagingNamespace,
+		"Events":    eventNamespace,
+		"Analytics": analyticsNamespace,
+		"DeviceIO":  deviceIONamespace,
+		"Display":   displayNamespace,
+		"Recording": recordingNamespace,
+		"Search":    searchNamespace,
+		"Replay":    replayNamespace,
+		"Receiver":  receiverNamespace,
+	}
+
+	addrs := make(map[string]string)
+	for _, category := range slices.Concat(resp.Capabilities.Categories, resp.Capabilities.Extension.Categories) {
+		if namespace, ok := categoryNamespaces[category.XMLName.Local]; ok {
+			addrs[namespace] = strings.TrimSpace(category.XAddr)
+		}
+	}
+
+	return addrs, nil
 }
 
 // Endpoint returns the device endpoint.
@@ -232,6 +826,9 @@ func (c *Client) SetCredentials(username, password string) {
 	defer c.mu.Unlock()
 	c.username = username
 	c.password = password
+	c.soapClient = nil
+
+	c.resetCheckedCapabilities()
 }
 
 // GetCredentials returns the current credentials.
@@ -242,9 +839,271 @@ func (c *Client) GetCredentials() (username, password string) {
 	return c.username, c.password
 }
 
-// DownloadFile downloads a file from the given URL with authentication.
-// Supports both Basic and Digest authentication (tries basic first, falls back to digest).
+// newSOAPClient returns the SOAP client shared by calls. It is built on first
+// use and rebuilt after the credentials, clock offset or request form change,
+// or when the HTTP client was replaced.
+func (c *Client) newSOAPClient() *soap.Client {
+	c.mu.RLock()
+	soapClient := c.soapClient
+	valid := soapClient != nil && c.soapHTTPClient == c.httpClient
+	c.mu.RUnlock()
+
+	if valid {
+		return soapClient
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.soapClient != nil && c.soapHTTPClient == c.httpClient {
+		return c.soapClient
+	}
+
+	soapClient = soap.NewClient(c.httpClient, c.username, c.password)
+	soapClient.SetDefaultTimeout(c.defaultCallTimeout)
+	soapClient.SetDigestSession(c.digest)
+
+	if c.clockOffsetKnown {
+		soapClient.SetTimeOffset(c.clockOffset)
+	}
+
+	if c.lenientNamespaces {
+		if c.namespaceStyleKnown {
+			soapClient.SetNamespaceStyle(c.namespaceStyle)
+		} else {
+			soapClient.SetNamespaceFallback(c.setNamespaceStyle)
+		}
+	}
+
+	if len(c.extraNamespaces) > 0 {
+		soapClient.SetExtraNamespaces(c.extraNamespaces)
+	}
+
+	soapClient.SetServerErrorsUnsupported(c.lenientServerErrors)
+
+	soapClient.SetCallObserver(c.observeCall)
+
+	if c.strictDecoding {
+		soapClient.SetUnmappedElements(func(paths []string) {
+			c.logf("onvif: unmapped response elements: %s", strings.Join(paths, ", "))
+		})
+	}
+
+	c.soapClient, c.soapHTTPClient = soapClient, c.httpClient
+
+	return soapClient
+}
+
+// NamespaceStyle returns the request form the device accepted. ok is false
+// unless WithLenientNamespaces is used and a call has succeeded.
+func (c *Client) NamespaceStyle() (style NamespaceStyle, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.namespaceStyle, c.namespaceStyleKnown
+}
+
+// setNamespaceStyle records the request form the device accepted. The first
+// recorded form wins, so that concurrent calls cannot flip it.
+func (c *Client) setNamespaceStyle(style NamespaceStyle) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.namespaceStyleKnown {
+		return
+	}
+
+	c.namespaceStyle, c.namespaceStyleKnown = style, true
+	c.soapClient = nil
+
+	if style == NamespaceDefault {
+		c.logf("onvif: device rejected prefixed requests, using default namespaces")
+	}
+}
+
+// RawCall sends an operation the library does not implement, such as a
+// vendor extension, with the client's authentication and options. request is
+// marshalled as the SOAP body, so it is typically a struct with prefixed XML
+// tags like the ones in this package; declare prefixes it does not declare
+// itself with WithExtraNamespaces. The body of the response is decoded into
+// response unless it is nil. An empty endpoint sends the call to the device
+// service; action sets the SOAP action and may be empty.
+func (c *Client) RawCall(ctx context.Context, endpoint, action string, request, response interface{}) error {
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, action, request, response); err != nil {
+		return fmt.Errorf("RawCall failed: %w", err)
+	}
+
+	return nil
+}
+
+// callWithoutAuth sends an operation the ONVIF specification allows before
+// authentication without a WS-Security header, since strict devices reject
+// one there and a header rejected for clock skew would keep the clock from
+// being read. Devices that nevertheless demand authentication are asked
+// again with the client credentials.
+func (c *Client) callWithoutAuth(ctx context.Context, endpoint string, request, response interface{}) error {
+	soapClient := c.newSOAPClient()
+
+	err := soapClient.Call(soap.WithoutAuth(ctx), endpoint, "", request, response)
+	if errors.Is(err, ErrUnauthorized) {
+		if username, _ := c.GetCredentials(); username != "" {
+			return soapClient.Call(ctx, endpoint, "", request, response)
+		}
+	}
+
+	return err
+}
+
+// httpAuthScheme is the HTTP authentication scheme a host uses for downloads.
+type httpAuthScheme int
+
+const (
+	httpAuthUnknown httpAuthScheme = iota
+	httpAuthNone
+	httpAuthBasic
+	httpAuthDigest
+)
+
+// DownloadFile downloads a file, such as a snapshot, from the given URL with
+// authentication. The first download from a host probes the URL without
+// credentials and picks Basic or Digest authentication from the
+// WWW-Authenticate challenge; the choice is cached per host. Hosts that need
+// no authentication keep being requested without credentials. When the device
+// sends no usable challenge, Basic is tried first, falling back to Digest.
 func (c *Client) DownloadFile(ctx context.Context, downloadURL string) ([]byte, error) {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL: %w", err)
+	}
+
+	host := parsed.Host
+
+	// A host that needs no authentication is always requested without
+	// credentials, so that the password is not sent in the clear to it; the
+	// request doubles as a probe in case the host starts asking for them.
+	scheme := c.downloadAuthScheme(host)
+	if scheme == httpAuthUnknown || scheme == httpAuthNone {
+		var data []byte
+
+		data, scheme, err = c.probeDownload(ctx, downloadURL)
+		if err != nil {
+			c.setDownloadAuthScheme(host, httpAuthUnknown)
+
+			return nil, err
+		}
+
+		c.setDownloadAuthScheme(host, scheme)
+
+		if scheme == httpAuthNone {
+			return data, nil
+		}
+	}
+
+	var data []byte
+
+	switch scheme {
+	case httpAuthDigest:
+		data, err = c.downloadWithDigestAuth(ctx, downloadURL)
+	case httpAuthBasic:
+		data, err = c.downloadWithBasicAuth(ctx, downloadURL)
+	case httpAuthNone, httpAuthUnknown:
+		return c.downloadBasicThenDigest(ctx, downloadURL)
+	}
+
+	if err != nil {
+		// The device may have changed its configuration; probe again next time.
+		c.setDownloadAuthScheme(host, httpAuthUnknown)
+	}
+
+	return data, err
+}
+
+// probeDownload requests downloadURL without credentials. It returns the
+// content if the host requires no authentication, otherwise the scheme
+// offered by its challenge, which is httpAuthUnknown if there is none.
+func (c *Client) probeDownload(ctx context.Context, downloadURL string) ([]byte, httpAuthScheme, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, http.NoBody)
+	if err != nil {
+		return nil, httpAuthUnknown, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "onvif-go-client")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, httpAuthUnknown, fmt.Errorf("download request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, httpAuthUnknown, fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		return data, httpAuthNone, nil
+	case http.StatusUnauthorized:
+		challenges := resp.Header.Values("WWW-Authenticate")
+		c.digest.StoreChallenge(req.URL.Host, challenges)
+
+		return nil, challengeScheme(challenges), nil
+	default:
+		return nil, httpAuthUnknown, nil
+	}
+}
+
+// challengeScheme returns the scheme to answer WWW-Authenticate challenges
+// with, preferring Digest when both are offered.
+func challengeScheme(challenges []string) httpAuthScheme {
+	scheme := httpAuthUnknown
+
+	for _, challenge := range challenges {
+		fields := strings.Fields(challenge)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSuffix(fields[0], ",")) {
+		case "digest":
+			return httpAuthDigest
+		case "basic":
+			scheme = httpAuthBasic
+		}
+	}
+
+	return scheme
+}
+
+// downloadAuthScheme returns the cached authentication scheme of host.
+func (c *Client) downloadAuthScheme(host string) httpAuthScheme {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.downloadAuth[host]
+}
+
+// setDownloadAuthScheme caches the authentication scheme of host.
+func (c *Client) setDownloadAuthScheme(host string, scheme httpAuthScheme) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.downloadAuth == nil {
+		c.downloadAuth = make(map[string]httpAuthScheme)
+	}
+
+	c.downloadAuth[host] = scheme
+}
+
+// downloadBasicThenDigest tries Basic authentication first and falls back to
+// Digest authentication on 401.
+func (c *Client) downloadBasicThenDigest(ctx context.Context, downloadURL string) ([]byte, error) {
 	// Try basic auth first
 	data, err := c.downloadWithBasicAuth(ctx, downloadURL)
 	if err == nil {
@@ -325,43 +1184,54 @@ func (c *Client) downloadWithBasicAuth(ctx context.Context, downloadURL string)
 }
 
 // downloadWithDigestAuth performs an HTTP download with Digest authentication.
+// The challenge of the host is kept in the digest session the client's SOAP
+// calls use, so that later downloads and calls answer it without another
+// round trip; a fresh challenge, as sent for an expired nonce, is answered once.
 func (c *Client) downloadWithDigestAuth(ctx context.Context, downloadURL string) ([]byte, error) {
-	if c.username == "" {
+	username, password := c.GetCredentials()
+	if username == "" {
 		return nil, fmt.Errorf("%w", ErrDigestAuthRequiresCredentials)
 	}
 
-	// Create a custom transport with digest auth
-	tr := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout:   DefaultTimeout,
-			KeepAlive: DefaultTimeout,
-		}).Dial,
-		MaxIdleConns:        DefaultMaxIdleConns,
-		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
-		IdleConnTimeout:     DefaultIdleConnTimeout,
+	parsed, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL: %w", err)
 	}
 
-	// Create a custom HTTP client for digest auth
-	digestClient := &http.Client{
-		Transport: &digestAuthTransport{
-			transport: tr,
-			username:  c.username,
-			password:  c.password,
-		},
-		Timeout: DefaultTimeout,
+	host, uri := parsed.Host, parsed.RequestURI()
+
+	get := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, http.NoBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", "onvif-go-client")
+
+		if authorization := c.digest.Authorize(host, "GET", uri, username, password); authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("digest auth request failed: %w", err)
+		}
+
+		return resp, nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, http.NoBody)
+	resp, err := get()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "onvif-go-client")
-	req.Header.Set("Connection", "close")
+	if resp.StatusCode == http.StatusUnauthorized && c.digest.StoreChallenge(host, resp.Header.Values("WWW-Authenticate")) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
 
-	resp, err := digestClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("digest auth request failed: %w", err)
+		if resp, err = get(); err != nil {
+			return nil, err
+		}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -398,132 +1268,3 @@ func (c *Client) downloadWithDigestAuth(ctx context.Context, downloadURL string)
 
 	return data, nil
 }
-
-// digestAuthTransport implements digest authentication for HTTP transport.
-type digestAuthTransport struct {
-	transport *http.Transport
-	username  string
-	password  string
-	nc        int
-	ncMu      sync.Mutex // Protects nc field from concurrent access
-}
-
-// RoundTrip implements http.RoundTripper with digest auth support.
-func (d *digestAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// First request without auth to get the challenge
-	resp, err := d.transport.RoundTrip(req)
-	if err != nil {
-		return resp, fmt.Errorf("transport round trip failed: %w", err)
-	}
-
-	// If we get 401, handle digest auth challenge
-	if resp.StatusCode == http.StatusUnauthorized {
-		// Read the WWW-Authenticate header
-		authHeader := resp.Header.Get("WWW-Authenticate")
-		if strings.Contains(authHeader, "Digest") {
-			// Parse digest challenge and create auth header
-			authHeaderValue := d.createDigestAuthHeader(req, authHeader)
-
-			// Create new request with auth header
-			newReq := req.Clone(req.Context())
-			newReq.Header.Set("Authorization", authHeaderValue)
-
-			// Retry with auth
-			resp, err = d.transport.RoundTrip(newReq)
-			if err != nil {
-				return resp, fmt.Errorf("transport round trip with auth failed: %w", err)
-			}
-
-			return resp, nil
-		}
-	}
-
-	return resp, nil
-}
-
-// createDigestAuthHeader creates a digest auth header from the challenge.
-func (d *digestAuthTransport) createDigestAuthHeader(req *http.Request, authHeader string) string {
-	// Simple digest auth implementation - parse challenge and create response
-	// This is a basic implementation that handles most ONVIF cameras
-
-	// Extract digest parameters from WWW-Authenticate header
-	realm := extractParam(authHeader, "realm")
-	nonce := extractParam(authHeader, "nonce")
-	qop := extractParam(authHeader, "qop")
-	uri := req.URL.Path
-	if req.URL.RawQuery != "" {
-		uri += "?" + req.URL.RawQuery
-	}
-
-	// Generate response hash
-	ha1 := md5Hash(d.username + ":" + realm + ":" + d.password)
-
-	method := req.Method
-	ha2 := md5Hash(method + ":" + uri)
-
-	// Increment nonce count atomically to prevent race conditions
-	// HTTP transports must be safe for concurrent use
-	d.ncMu.Lock()
-	d.nc++
-	nc := d.nc
-	d.ncMu.Unlock()
-	ncStr := fmt.Sprintf("%08x", nc)
-	cnonce := generateNonce()
-
-	var responseStr string
-	if qop == "auth" {
-		responseStr = md5Hash(ha1 + ":" + nonce + ":" + ncStr + ":" + cnonce + ":auth:" + ha2)
-	} else {
-		responseStr = md5Hash(ha1 + ":" + nonce + ":" + ha2)
-	}
-
-	// Build Authorization header
-	authHeaderValue := fmt.Sprintf(`Digest username=%q, realm=%q, nonce=%q, uri=%q, response=%q`,
-		d.username, realm, nonce, uri, responseStr)
-
-	if qop == "auth" {
-		authHeaderValue += fmt.Sprintf(`, opaque=%q, qop=%s, nc=%s, cnonce=%q`,
-			extractParam(authHeader, "opaque"), qop, ncStr, cnonce)
-	}
-
-	return authHeaderValue
-}
-
-// Helper functions for digest auth.
-func extractParam(authHeader, param string) string {
-	prefix := param + `="`
-	idx := strings.Index(authHeader, prefix)
-	if idx == -1 {
-		return ""
-	}
-	start := idx + len(prefix)
-	end := strings.Index(authHeader[start:], `"`)
-	if end == -1 {
-		return ""
-	}
-
-	return authHeader[start : start+end]
-}
-
-func md5Hash(s string) string {
-	return fmt.Sprintf("%x", md5sum(s))
-}
-
-func md5sum(s string) interface{} {
-	// Use crypto/md5 - import it if not already present
-	h := md5.New() //nolint:gosec // MD5 required for ONVIF digest auth
-	h.Write([]byte(s))
-
-	return h.Sum(nil)
-}
-
-// generateNonce generates a cryptographically secure random nonce for digest authentication.
-func generateNonce() string {
-	bytes := make([]byte, NonceSize)
-	if _, err := rand.Read(bytes); err != nil {
-		// Fallback to time-based nonce if crypto/rand fails (shouldn't happen)
-		return fmt.Sprintf("%d", time.Now().UnixNano())
-	}
-
-	return hex.EncodeToString(bytes)
-}
@@ -2,15 +2,20 @@ package onvif
 
 import (
 	"context"
-	"encoding/hex"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"net"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 const (
@@ -808,6 +813,197 @@ func TestInitializeWithLocalhostURLs(t *testing.T) {
 	}
 }
 
+func TestInitializeWithReportPartialCapabilities(t *testing.T) {
+	mock := NewMockONVIFServer()
+	defer mock.Close()
+
+	// The PTZ category has no address and the Imaging category carries values
+	// that do not decode as booleans; neither should prevent Media from resolving.
+	mock.SetResponse("GetCapabilities", `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<tds:GetCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+			<tds:Capabilities>
+				<tt:Media xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tt:XAddr>`+mock.URL()+`/onvif/media_service</tt:XAddr>
+					<tt:StreamingCapabilities>
+						<tt:RTPMulticast>maybe</tt:RTPMulticast>
+					</tt:StreamingCapabilities>
+				</tt:Media>
+				<tt:PTZ xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tt:XAddr></tt:XAddr>
+				</tt:PTZ>
+				<tt:Extension xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tt:Recording>
+						<tt:XAddr>`+mock.URL()+`/onvif/recording_service</tt:XAddr>
+					</tt:Recording>
+				</tt:Extension>
+			</tds:Capabilities>
+		</tds:GetCapabilitiesResponse>
+	</soap:Body>
+</soap:Envelope>`)
+
+	client, err := NewClient(mock.URL() + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	report, err := client.InitializeWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("InitializeWithReport() failed: %v", err)
+	}
+
+	if report.Source != InitSourceGetCapabilities {
+		t.Errorf("Source = %q, want %q", report.Source, InitSourceGetCapabilities)
+	}
+
+	if client.mediaEndpoint != mock.URL()+"/onvif/media_service" {
+		t.Errorf("mediaEndpoint = %q", client.mediaEndpoint)
+	}
+
+	if addr, ok := client.ServiceEndpoint(recordingNamespace); !ok || addr != mock.URL()+"/onvif/recording_service" {
+		t.Errorf("ServiceEndpoint(recording) = %q, %v", addr, ok)
+	}
+
+	if len(report.Unresolved) != 1 || report.Unresolved[0] != ptzNamespace {
+		t.Errorf("Unresolved = %v, want [%s]", report.Unresolved, ptzNamespace)
+	}
+
+	// GetServices is not supported by the mock, which is reported as a warning
+	if len(report.Warnings) == 0 {
+		t.Error("Expected a warning for the failed GetServices call")
+	}
+}
+
+func TestInitializeWithReportPrefersGetServices(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if !strings.Contains(string(body), "GetServices") {
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+			<tds:Service>
+				<tds:Namespace>http://www.onvif.org/ver10/device/wsdl</tds:Namespace>
+				<tds:XAddr>` + server.URL + `/onvif/device_service</tds:XAddr>
+				<tds:Version><tt:Major xmlns:tt="http://www.onvif.org/ver10/schema">2</tt:Major><tt:Minor xmlns:tt="http://www.onvif.org/ver10/schema">60</tt:Minor></tds:Version>
+			</tds:Service>
+			<tds:Service>
+				<tds:Namespace>http://www.onvif.org/ver20/imaging/wsdl</tds:Namespace>
+				<tds:XAddr>` + server.URL + `/onvif/imaging_service</tds:XAddr>
+				<tds:Version><tt:Major xmlns:tt="http://www.onvif.org/ver10/schema">2</tt:Major><tt:Minor xmlns:tt="http://www.onvif.org/ver10/schema">50</tt:Minor></tds:Version>
+			</tds:Service>
+			<tds:Service>
+				<tds:Namespace>http://www.onvif.org/ver20/analytics/wsdl</tds:Namespace>
+				<tds:XAddr>` + server.URL + `/onvif/analytics_service</tds:XAddr>
+				<tds:Version><tt:Major xmlns:tt="http://www.onvif.org/ver10/schema">2</tt:Major><tt:Minor xmlns:tt="http://www.onvif.org/ver10/schema">60</tt:Minor></tds:Version>
+			</tds:Service>
+		</tds:GetServicesResponse>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, _, ok := client.ServiceVersion(imagingNamespace); ok {
+		t.Error("ServiceVersion() must not report a version before Initialize")
+	}
+
+	// An endpoint from an earlier initialization that the device no longer advertises.
+	client.ptzEndpoint = "http://192.0.2.1/onvif/ptz_service"
+
+	report, err := client.InitializeWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("InitializeWithReport() failed: %v", err)
+	}
+
+	if major, minor, ok := client.ServiceVersion(imagingNamespace); !ok || major != 2 || minor != 50 {
+		t.Errorf("ServiceVersion(imaging) = %d.%d, %v, want 2.50, true", major, minor, ok)
+	}
+
+	if _, _, ok := client.ServiceVersion(ptzNamespace); ok {
+		t.Error("ServiceVersion(ptz) must not report a version for a service the device does not advertise")
+	}
+
+	if report.Source != InitSourceGetServices {
+		t.Errorf("Source = %q, want %q", report.Source, InitSourceGetServices)
+	}
+
+	if client.imagingEndpoint != server.URL+"/onvif/imaging_service" {
+		t.Errorf("imagingEndpoint = %q", client.imagingEndpoint)
+	}
+
+	if client.ptzEndpoint != "" {
+		t.Errorf("Expected the stale ptzEndpoint to be cleared, got %q", client.ptzEndpoint)
+	}
+
+	if addr, ok := client.ServiceEndpoint(analyticsNamespace); !ok || addr != server.URL+"/onvif/analytics_service" {
+		t.Errorf("ServiceEndpoint(analytics) = %q, %v", addr, ok)
+	}
+
+	if len(report.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", report.Warnings)
+	}
+}
+
+func TestInitializeWithServiceCapabilities(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if !strings.Contains(string(body), "<tds:IncludeCapability>true</tds:IncludeCapability>") {
+			t.Errorf("Expected only GetServices with capabilities, got: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+	<soap:Body>
+		<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+			<tds:Service>
+				<tds:Namespace>http://www.onvif.org/ver10/media/wsdl</tds:Namespace>
+				<tds:XAddr>` + server.URL + `/onvif/media_service</tds:XAddr>
+				<tds:Capabilities>
+					<trt:Capabilities SnapshotUri="true" Rotation="false" OSD="true">
+						<trt:ProfileCapabilities MaximumNumberOfProfiles="8"/>
+					</trt:Capabilities>
+				</tds:Capabilities>
+			</tds:Service>
+		</tds:GetServicesResponse>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL+"/onvif/device_service", WithServiceCapabilities())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() failed: %v", err)
+	}
+
+	caps, err := client.GetMediaServiceCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("GetMediaServiceCapabilities() failed: %v", err)
+	}
+
+	if !caps.SnapshotURI || caps.Rotation || !caps.OSD || caps.MaximumNumberOfProfiles != 8 {
+		t.Errorf("Unexpected capabilities from GetServices: %+v", caps)
+	}
+}
+
 // TestDownloadFileWithBasicAuth tests DownloadFile with basic authentication.
 func TestDownloadFileWithBasicAuth(t *testing.T) {
 	// Create a mock server that requires basic auth
@@ -973,166 +1169,78 @@ func TestDownloadFileNetworkError(t *testing.T) {
 	}
 }
 
-// TestDigestAuthTransport tests the digest authentication transport.
-func TestDigestAuthTransport(t *testing.T) {
-	nonce := "test-nonce"
-	realm := testRealm
-	opaque := testOpaque
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Digest ") {
-			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
-				`Digest realm=%q, nonce=%q, opaque=%q, qop="auth"`,
-				realm, nonce, opaque))
-			w.WriteHeader(http.StatusUnauthorized)
-
-			return
-		}
-		// Verify digest auth header contains required fields
-		if !strings.Contains(authHeader, `username="`+testUsername+`"`) {
-			t.Error("Digest auth header missing username")
-		}
-		if !strings.Contains(authHeader, `realm="`+realm+`"`) {
-			t.Error("Digest auth header missing realm")
-		}
-		if !strings.Contains(authHeader, `nonce="`+nonce+`"`) {
-			t.Error("Digest auth header missing nonce")
-		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("success"))
-	}))
-	defer server.Close()
-
-	tr := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout:   DefaultTimeout,
-			KeepAlive: DefaultTimeout,
-		}).Dial,
-	}
-
-	digestClient := &http.Client{
-		Transport: &digestAuthTransport{
-			transport: tr,
-			username:  testUsername,
-			password:  "password",
-		},
-		Timeout: DefaultTimeout,
-	}
-
-	req, err := http.NewRequestWithContext(context.Background(), "GET", server.URL, http.NoBody)
-	if err != nil {
-		t.Fatalf("NewRequest() failed: %v", err)
-	}
-
-	resp, err := digestClient.Do(req)
-	if err != nil {
-		t.Fatalf("Do() failed: %v", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("Expected 200, got %d", resp.StatusCode)
-	}
-}
-
-// TestExtractParam tests the extractParam helper function.
-func TestExtractParam(t *testing.T) {
+// TestDownloadFileAuthSchemeProbe tests that DownloadFile picks the scheme from
+// the challenge and caches it per host.
+func TestDownloadFileAuthSchemeProbe(t *testing.T) {
 	tests := []struct {
-		name       string
-		authHeader string
-		param      string
-		expected   string
+		name      string
+		challenge string
+		wantAuth  string
 	}{
-		{
-			name:       "extract realm",
-			authHeader: `Digest realm="` + testRealm + `", nonce="123"`,
-			param:      "realm",
-			expected:   testRealm,
-		},
-		{
-			name:       "extract nonce",
-			authHeader: `Digest realm="test", nonce="abc123"`,
-			param:      "nonce",
-			expected:   "abc123",
-		},
-		{
-			name:       "extract qop",
-			authHeader: `Digest realm="test", qop="auth"`,
-			param:      "qop",
-			expected:   "auth",
-		},
-		{
-			name:       "missing param",
-			authHeader: `Digest realm="test"`,
-			param:      "nonce",
-			expected:   "",
-		},
-		{
-			name:       "empty header",
-			authHeader: "",
-			param:      "realm",
-			expected:   "",
-		},
+		{name: "basic", challenge: `Basic realm="camera"`, wantAuth: "Basic "},
+		{name: "digest", challenge: `Digest realm="camera", nonce="abc", qop="auth"`, wantAuth: "Digest "},
+		{name: "none", wantAuth: ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractParam(tt.authHeader, tt.param)
-			if result != tt.expected {
-				t.Errorf("extractParam() = %q, want %q", result, tt.expected)
+			var unauthenticated, wrongScheme atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth := r.Header.Get("Authorization")
+
+				switch {
+				case tt.wantAuth == "":
+					// A host that needs no authentication must not receive credentials.
+					if auth != "" {
+						wrongScheme.Add(1)
+					}
+				case auth == "":
+					unauthenticated.Add(1)
+					w.Header().Set("WWW-Authenticate", tt.challenge)
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				case !strings.HasPrefix(auth, tt.wantAuth):
+					wrongScheme.Add(1)
+					w.WriteHeader(http.StatusUnauthorized)
+
+					return
+				}
+
+				_, _ = w.Write([]byte("jpeg"))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithCredentials(testUsername, "password"))
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
 			}
-		})
-	}
-}
 
-// TestGenerateNonce tests nonce generation.
-func TestGenerateNonce(t *testing.T) {
-	// Generate multiple nonces and verify they're different and valid hex
-	nonces := make(map[string]bool)
-	for i := 0; i < 10; i++ {
-		nonce := generateNonce()
-		if len(nonce) != NonceSize*2 { // hex encoding doubles the length
-			t.Errorf("generateNonce() length = %d, want %d", len(nonce), NonceSize*2)
-		}
-		// Verify it's valid hex
-		_, err := hex.DecodeString(nonce)
-		if err != nil {
-			t.Errorf("generateNonce() returned invalid hex: %v", err)
-		}
-		nonces[nonce] = true
-	}
+			for i := 0; i < 2; i++ {
+				data, err := client.DownloadFile(context.Background(), server.URL+"/snapshot.jpg")
+				if err != nil {
+					t.Fatalf("DownloadFile() failed: %v", err)
+				}
 
-	// Verify nonces are unique (very unlikely to collide with crypto/rand)
-	if len(nonces) < 10 {
-		t.Error("generateNonce() generated duplicate nonces")
-	}
-}
+				if string(data) != "jpeg" {
+					t.Errorf("DownloadFile() = %q, want jpeg", data)
+				}
+			}
 
-// TestMd5Hash tests MD5 hash function.
-func TestMd5Hash(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string // Expected MD5 hash in hex
-	}{
-		{
-			name:     "empty string",
-			input:    "",
-			expected: "d41d8cd98f00b204e9800998ecf8427e",
-		},
-		{
-			name:     "simple string",
-			input:    "test",
-			expected: "098f6bcd4621d373cade4e832627b4f6",
-		},
-	}
+			if wrongScheme.Load() != 0 {
+				t.Errorf("Expected only %q authentication, got %d requests with another scheme", tt.wantAuth, wrongScheme.Load())
+			}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := md5Hash(tt.input)
-			if result != tt.expected {
-				t.Errorf("md5Hash(%q) = %q, want %q", tt.input, result, tt.expected)
+			// Only the first download probes; Digest answers the challenge of
+			// the probe and reuses it afterwards.
+			wantProbes := int32(1)
+			if tt.wantAuth == "" {
+				wantProbes = 0
+			}
+
+			if got := unauthenticated.Load(); got != wantProbes {
+				t.Errorf("Expected %d unauthenticated requests, got %d", wantProbes, got)
 			}
 		})
 	}
@@ -1163,6 +1271,83 @@ func TestErrorTypes(t *testing.T) {
 	})
 }
 
+func TestFaultClassification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<env:Body>
+		<env:Fault>
+			<env:Code>
+				<env:Value>env:Sender</env:Value>
+				<env:Subcode><env:Value>ter:NotAuthorized</env:Value></env:Subcode>
+			</env:Code>
+			<env:Reason><env:Text xml:lang="en">Sender not authorized</env:Text></env:Reason>
+		</env:Fault>
+	</env:Body>
+</env:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	_, err = client.GetDeviceInformation(context.Background())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Unexpected classification of %v", err)
+	}
+
+	if !errors.Is(err, ErrHTTPRequestFailed) {
+		t.Errorf("Expected ErrHTTPRequestFailed, got %v", err)
+	}
+
+	var fault *SOAPFault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expected *SOAPFault, got %T", err)
+	}
+
+	if fault.Reason != "Sender not authorized" || !fault.HasSubcode("NotAuthorized") {
+		t.Errorf("Unexpected fault: %+v", fault)
+	}
+}
+
+func TestWithDefaultCallTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><tds:GetDeviceInformationResponse><tds:Model>M</tds:Model></tds:GetDeviceInformationResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithHTTPClient(&http.Client{}), WithDefaultCallTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.GetDeviceInformation(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded without a context deadline, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.GetDeviceInformation(ctx); err != nil {
+		t.Errorf("Expected the context deadline to take precedence, got %v", err)
+	}
+}
+
 // TestClientConcurrency tests concurrent access to client.
 func TestClientConcurrency(t *testing.T) {
 	client, err := NewClient("http://192.168.1.100/onvif")
@@ -1287,6 +1472,100 @@ func TestWithInsecureSkipVerify(t *testing.T) {
 	}
 }
 
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithDisableKeepAlive(t *testing.T) {
+	shared := &http.Client{Transport: &http.Transport{}}
+
+	client, err := NewClient(testEndpoint, WithHTTPClient(shared), WithDisableKeepAlive())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok || !transport.DisableKeepAlives {
+		t.Error("DisableKeepAlives is not set")
+	}
+
+	if shared.Transport.(*http.Transport).DisableKeepAlives || client.httpClient == shared {
+		t.Error("Shared HTTP client was modified")
+	}
+
+	// A client without a transport gets a clone of the default one.
+	client, err = NewClient(testEndpoint, WithHTTPClient(&http.Client{}), WithDisableKeepAlive())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if transport, ok := client.httpClient.Transport.(*http.Transport); !ok || !transport.DisableKeepAlives ||
+		transport == http.DefaultTransport {
+		t.Error("Expected a clone of the default transport with keep-alives disabled")
+	}
+
+	// Other transports are wrapped to close every connection.
+	var closed bool
+
+	wrapped := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		closed = req.Close
+
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	client, err = NewClient(testEndpoint, WithHTTPClient(&http.Client{Transport: wrapped}), WithDisableKeepAlive())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, testEndpoint, http.NoBody)
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if !closed || req.Close {
+		t.Errorf("Expected a copy of the request to be sent with Close set, got %v, original %v", closed, req.Close)
+	}
+}
+
+func TestSOAPClientReuse(t *testing.T) {
+	client, err := NewClient(testEndpoint, WithCredentials("admin", "pw"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	first := client.newSOAPClient()
+	if client.newSOAPClient() != first {
+		t.Error("Expected the SOAP client to be reused")
+	}
+
+	client.SetCredentials("admin", "new")
+
+	second := client.newSOAPClient()
+	if second == first {
+		t.Error("Expected a new SOAP client after SetCredentials")
+	}
+
+	client.SetClockOffset(time.Minute)
+
+	third := client.newSOAPClient()
+	if third == second {
+		t.Error("Expected a new SOAP client after SetClockOffset")
+	}
+
+	client.httpClient = &http.Client{}
+	if client.newSOAPClient() == third {
+		t.Error("Expected a new SOAP client for a replaced HTTP client")
+	}
+}
+
 // TestDownloadFileContextCancellation tests context cancellation.
 func TestDownloadFileContextCancellation(t *testing.T) {
 	// Create a slow server
@@ -1314,102 +1593,432 @@ func TestDownloadFileContextCancellation(t *testing.T) {
 	}
 }
 
-// This verifies that the nc field is properly protected from race conditions.
-func TestDigestAuthTransportConcurrency(t *testing.T) {
-	nonce := "test-nonce"
-	realm := testRealm
-	opaque := testOpaque
+// TestDownloadFileDigestConcurrency tests that concurrent Digest downloads
+// share one challenge, each with its own nonce count.
+func TestDownloadFileDigestConcurrency(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ncs = make(map[string]bool)
+	)
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Digest ") {
 			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
-				`Digest realm=%q, nonce=%q, opaque=%q, qop="auth"`,
-				realm, nonce, opaque))
+				`Digest realm=%q, nonce="test-nonce", opaque=%q, qop="auth"`, testRealm, testOpaque))
 			w.WriteHeader(http.StatusUnauthorized)
 
 			return
 		}
-		// Verify nc (nonce count) is present and valid
-		if !strings.Contains(authHeader, "nc=") {
+
+		_, nc, ok := strings.Cut(authHeader, "nc=")
+		if !ok {
 			t.Error("Digest auth header missing nc (nonce count)")
 		}
+
+		nc, _, _ = strings.Cut(nc, ",")
+
+		mu.Lock()
+		if ncs[nc] {
+			t.Errorf("Nonce count %s used twice", nc)
+		}
+		ncs[nc] = true
+		mu.Unlock()
+
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("success"))
 	}))
 	defer server.Close()
 
-	tr := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout:   DefaultTimeout,
-			KeepAlive: DefaultTimeout,
-		}).Dial,
-	}
-
-	// Create a single transport instance that will be used concurrently
-	digestTransport := &digestAuthTransport{
-		transport: tr,
-		username:  testUsername,
-		password:  "password",
+	client, err := NewClient(server.URL, WithCredentials(testUsername, "password"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
 	}
 
-	digestClient := &http.Client{
-		Transport: digestTransport,
-		Timeout:   DefaultTimeout,
+	// The first download learns the scheme and challenge of the host.
+	if _, err := client.DownloadFile(context.Background(), server.URL); err != nil {
+		t.Fatalf("DownloadFile() failed: %v", err)
 	}
 
-	// Make concurrent requests to verify no race conditions
 	const numRequests = 10
-	done := make(chan bool, numRequests)
-	errors := make(chan error, numRequests)
+
+	var wg sync.WaitGroup
 
 	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+
 		go func(id int) {
-			req, err := http.NewRequestWithContext(context.Background(), "GET", server.URL, http.NoBody)
-			if err != nil {
-				errors <- fmt.Errorf("request %d: %w", id, fmt.Errorf("%w", ErrTestRequestNewFailed))
-				done <- true
+			defer wg.Done()
 
-				return
+			if _, err := client.DownloadFile(context.Background(), server.URL); err != nil {
+				t.Errorf("request %d: %v", id, err)
 			}
+		}(i)
+	}
 
-			resp, err := digestClient.Do(req)
-			if err != nil {
-				errors <- fmt.Errorf("request %d: %w", id, fmt.Errorf("%w", ErrTestRequestDoFailed))
-				done <- true
+	wg.Wait()
 
-				return
+	if len(ncs) != numRequests+1 {
+		t.Errorf("Expected %d distinct nonce counts, got %d", numRequests+1, len(ncs))
+	}
+}
+
+func TestWithSingleFlight(t *testing.T) {
+	var requests atomic.Int32
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:Profiles token="Profile_1"><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">Main</tt:Name></trt:Profiles>
+		</trt:GetProfilesResponse>
+	</s:Body>
+</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithSingleFlight())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	const callers = 5
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, callers)
+
+	for range callers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			profiles, err := client.GetProfiles(context.Background())
+			if err == nil && (len(profiles) != 1 || profiles[0].Token != "Profile_1") {
+				err = fmt.Errorf("unexpected profiles: %v", profiles)
 			}
-			defer func() { _ = resp.Body.Close() }()
+			errs <- err
+		}()
+	}
+
+	// Give every caller time to join the in-flight call before the device answers.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetProfiles() failed: %v", err)
+		}
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 device request, got %d", got)
+	}
+
+	// Calls that do not overlap are not shared.
+	if _, err := client.GetProfiles(context.Background()); err != nil {
+		t.Fatalf("GetProfiles() failed: %v", err)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 device requests, got %d", got)
+	}
+}
+
+func TestWithSingleFlightCredentialsAndDeadline(t *testing.T) {
+	var requests atomic.Int32
+
+	release := make(chan struct{})
+	abandoned := make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+
+		requests.Add(1)
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			abandoned <- struct{}{}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/></s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "secret"), WithSingleFlight())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// Calls made with different context credentials are not shared.
+	var wg sync.WaitGroup
 
-			if resp.StatusCode != http.StatusOK {
-				errors <- fmt.Errorf("request %d: expected 200, got %d: %w", id, resp.StatusCode, ErrTestRequestUnexpectedStatus)
+	for _, user := range []string{"operator", "viewer"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := client.GetProfiles(soap.WithCredentials(context.Background(), user, "pw")); err != nil {
+				t.Errorf("GetProfiles() as %s failed: %v", user, err)
 			}
-			done <- true
-		}(i)
+		}()
 	}
 
-	// Wait for all requests to complete
-	for i := 0; i < numRequests; i++ {
-		<-done
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 device requests, got %d", got)
+	}
+
+	// A shared call with a single caller is bounded by its deadline.
+	release = make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetProfiles(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	select {
+	case <-abandoned:
+	case <-time.After(2 * time.Second):
+		t.Error("Shared call outlived the deadline of its caller")
+	}
+}
+
+func TestWithSingleFlightJoinedDeadline(t *testing.T) {
+	var requests atomic.Int32
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+
+		requests.Add(1)
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/></s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "secret"), WithSingleFlight())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+
+	shortErr := make(chan error, 1)
+
+	go func() {
+		_, err := client.GetProfiles(short)
+		shortErr <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	client.flights.mu.Lock()
+	for key := range client.flights.calls {
+		if strings.Contains(key, "secret") {
+			t.Errorf("Expected the password not to be part of the key: %q", key)
+		}
+	}
+	client.flights.mu.Unlock()
+
+	// A caller with a later deadline joins; the shared call must outlive the
+	// deadline of the caller that started it.
+	long, cancelLong := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelLong()
+
+	longErr := make(chan error, 1)
+
+	go func() {
+		_, err := client.GetProfiles(long)
+		longErr <- err
+	}()
+
+	if err := <-shortErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-longErr; err != nil {
+		t.Errorf("GetProfiles() with the later deadline failed: %v", err)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 device request, got %d", got)
+	}
+}
+
+func TestWithLenientNamespaces(t *testing.T) {
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if strings.Contains(string(body), "<tds:") {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><soap:Fault>` +
+				`<soap:Code><soap:Value>soap:Sender</soap:Value></soap:Code>` +
+				`<soap:Reason><soap:Text>Malformed request</soap:Text></soap:Reason></soap:Fault></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` +
+			`<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">` +
+			`<tds:Manufacturer>Acme</tds:Manufacturer></tds:GetDeviceInformationResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithLenientNamespaces())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, ok := client.NamespaceStyle(); ok {
+		t.Error("Expected no namespace style before the first call")
+	}
+
+	info, err := client.GetDeviceInformation(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeviceInformation() failed: %v", err)
+	}
+
+	if info.Manufacturer != "Acme" || len(bodies) != 2 {
+		t.Errorf("Unexpected result %+v after %d requests", info, len(bodies))
+	}
+
+	if style, ok := client.NamespaceStyle(); !ok || style != NamespaceDefault {
+		t.Errorf("NamespaceStyle() = %v, %v, want %v", style, ok, NamespaceDefault)
+	}
+
+	// The recorded form is sent directly.
+	bodies = nil
+
+	if _, err := client.GetDeviceInformation(context.Background()); err != nil {
+		t.Fatalf("GetDeviceInformation() failed: %v", err)
+	}
+
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `<GetDeviceInformation xmlns="http://www.onvif.org/ver10/device/wsdl"`) {
+		t.Errorf("Expected one default-namespace request, got %q", bodies)
 	}
+}
 
-	// Check for errors
-	close(errors)
-	for err := range errors {
+func TestWithStrictDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` +
+			`<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+			`<tds:HostnameInformation><tt:FromDHCP>false</tt:FromDHCP><tt:Name>cam</tt:Name>` +
+			`<tt:Extension><tt:Domain>example</tt:Domain></tt:Extension></tds:HostnameInformation>` +
+			`</tds:GetHostnameResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	for _, strict := range []bool{false, true} {
+		var logs []string
+
+		client, err := NewClient(server.URL, WithStrictDecoding(strict), WithLogger(func(format string, args ...interface{}) {
+			logs = append(logs, fmt.Sprintf(format, args...))
+		}))
 		if err != nil {
-			t.Error(err)
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+
+		info, err := client.GetHostname(context.Background())
+		if err != nil {
+			t.Fatalf("GetHostname() failed: %v", err)
+		}
+
+		if info.Name != "cam" {
+			t.Errorf("Name = %q, want cam", info.Name)
+		}
+
+		logged := strings.Contains(strings.Join(logs, "\n"), "GetHostnameResponse/HostnameInformation/Extension")
+		if logged != strict {
+			t.Errorf("strict=%v: unexpected logs %q", strict, logs)
 		}
 	}
+}
+
+func TestRawCallWithExtraNamespaces(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` +
+			`<acm:GetWiperResponse xmlns:acm="http://example.com/acme"><acm:Running>true</acm:Running></acm:GetWiperResponse>` +
+			`</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	namespaces := map[string]string{"acm": "http://example.com/acme"}
 
-	// Verify that nc was incremented correctly (should be at least numRequests)
-	// Note: Each request triggers 2 RoundTrip calls (initial + retry with auth),
-	// so nc should be at least numRequests
-	digestTransport.ncMu.Lock()
-	finalNC := digestTransport.nc
-	digestTransport.ncMu.Unlock()
+	client, err := NewClient(server.URL, WithExtraNamespaces(namespaces))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// The option copies the map.
+	namespaces["acm"] = "changed"
+
+	req := struct {
+		XMLName xml.Name `xml:"acm:GetWiper"`
+		Token   string   `xml:"acm:Token"`
+	}{Token: "W1"}
+
+	var resp struct {
+		XMLName xml.Name `xml:"GetWiperResponse"`
+		Running bool     `xml:"Running"`
+	}
+
+	if err := client.RawCall(context.Background(), "", "", req, &resp); err != nil {
+		t.Fatalf("RawCall() failed: %v", err)
+	}
+
+	if !resp.Running {
+		t.Error("Expected the response to be decoded")
+	}
+
+	if !strings.Contains(request, `xmlns:acm="http://example.com/acme"`) {
+		t.Errorf("Expected the namespace declared on the envelope: %s", request)
+	}
 
-	if finalNC < numRequests {
-		t.Errorf("Expected nc >= %d, got %d", numRequests, finalNC)
+	if !strings.Contains(request, "<acm:Token>W1</acm:Token>") {
+		t.Errorf("Expected the request body: %s", request)
 	}
 }
@@ -0,0 +1,85 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SyncClock measures the offset of the device clock from the local clock with
+// GetSystemDateAndTime and records it with SetClockOffset. The offset is
+// taken against the midpoint of the request, and the device reports whole
+// seconds, so it is accurate to about a second.
+func (c *Client) SyncClock(ctx context.Context) (time.Duration, error) {
+	offset, err := c.measureClockOffset(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync clock: %w", err)
+	}
+
+	c.SetClockOffset(offset)
+
+	return offset, nil
+}
+
+// measureClockOffset measures the offset of the device clock from the local
+// clock, see SyncClock.
+func (c *Client) measureClockOffset(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+
+	dateTime, err := c.GetSystemDateAndTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	elapsed := time.Since(start)
+
+	device, ok := dateTime.UTC()
+	if !ok {
+		return 0, fmt.Errorf("%w: no UTC date and time", ErrInvalidResponse)
+	}
+
+	return device.Sub(start.Add(elapsed / 2)).Round(time.Second), nil
+}
+
+// NewDateTime returns t in UTC as an ONVIF date and time, e.g. for the
+// UTCDateTime of SetSystemDateAndTime. Fractions of a second are dropped.
+func NewDateTime(t time.Time) *DateTime {
+	t = t.UTC()
+
+	return &DateTime{
+		Time: Time{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second()},
+		Date: Date{Year: t.Year(), Month: int(t.Month()), Day: t.Day()},
+	}
+}
+
+// UTC returns the UTC date and time reported by the device, and false if the
+// device reported none.
+func (d *SystemDateTime) UTC() (time.Time, bool) {
+	utc := d.UTCDateTime
+	if utc == nil || utc.Date.Year == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Date(utc.Date.Year, time.Month(utc.Date.Month), utc.Date.Day,
+		utc.Time.Hour, utc.Time.Minute, utc.Time.Second, 0, time.UTC), true
+}
+
+// SetClockOffset sets the offset of the device clock from the local clock,
+// positive if the device clock is ahead. It is applied to the Created time of
+// the WS-Security header of later calls.
+func (c *Client) SetClockOffset(offset time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clockOffset, c.clockOffsetKnown = offset, true
+	c.soapClient = nil
+}
+
+// ClockOffset returns the offset of the device clock from the local clock.
+// ok is false unless it was set with SetClockOffset or SyncClock.
+func (c *Client) ClockOffset() (offset time.Duration, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.clockOffset, c.clockOffsetKnown
+}
@@ -0,0 +1,121 @@
+package onvif
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // SHA1 required for ONVIF digest auth
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncClockAndWSSecurityDigest(t *testing.T) {
+	deviceTime := time.Now().UTC().Add(time.Hour)
+
+	type usernameToken struct {
+		Username string `xml:"Username"`
+		Password string `xml:"Password"`
+		Nonce    string `xml:"Nonce"`
+		Created  string `xml:"Created"`
+	}
+
+	var tokens []usernameToken
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var envelope struct {
+			Header struct {
+				Security struct {
+					UsernameToken *usernameToken `xml:"UsernameToken"`
+				} `xml:"Security"`
+			} `xml:"Header"`
+		}
+
+		_ = xml.Unmarshal(body, &envelope)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetSystemDateAndTime"):
+			response = fmt.Sprintf(`<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:SystemDateAndTime><tt:UTCDateTime xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time>
+					<tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date>
+				</tt:UTCDateTime></tds:SystemDateAndTime></tds:GetSystemDateAndTimeResponse>`,
+				deviceTime.Hour(), deviceTime.Minute(), deviceTime.Second(),
+				deviceTime.Year(), int(deviceTime.Month()), deviceTime.Day())
+		case strings.Contains(string(body), "GetHostname"):
+			if token := envelope.Header.Security.UsernameToken; token != nil {
+				tokens = append(tokens, *token)
+			}
+
+			response = `<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:HostnameInformation><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">cam</tt:Name></tds:HostnameInformation>
+			</tds:GetHostnameResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "pw"), WithClockSync())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, ok := client.ClockOffset(); ok {
+		t.Error("Expected no clock offset before SyncClock")
+	}
+
+	offset, err := client.SyncClock(context.Background())
+	if err != nil {
+		t.Fatalf("SyncClock() failed: %v", err)
+	}
+
+	if offset < time.Hour-2*time.Second || offset > time.Hour+2*time.Second {
+		t.Errorf("SyncClock() = %v, want about 1h", offset)
+	}
+
+	for range 2 {
+		if _, err := client.GetHostname(context.Background()); err != nil {
+			t.Fatalf("GetHostname() failed: %v", err)
+		}
+	}
+
+	if len(tokens) != 2 {
+		t.Fatalf("Expected 2 authenticated requests, got %d", len(tokens))
+	}
+
+	if tokens[0].Nonce == tokens[1].Nonce {
+		t.Error("Expected a fresh nonce per call")
+	}
+
+	for _, token := range tokens {
+		created, err := time.Parse(time.RFC3339, token.Created)
+		if err != nil {
+			t.Fatalf("Invalid Created %q: %v", token.Created, err)
+		}
+
+		if skew := created.Sub(deviceTime).Abs(); skew > 5*time.Second {
+			t.Errorf("Created %v is %v off the device clock", created, skew)
+		}
+
+		nonce, _ := base64.StdEncoding.DecodeString(token.Nonce)
+		hash := sha1.New() //nolint:gosec // SHA1 required for ONVIF digest auth
+		hash.Write(nonce)
+		hash.Write([]byte(token.Created))
+		hash.Write([]byte("pw"))
+
+		if want := base64.StdEncoding.EncodeToString(hash.Sum(nil)); token.Password != want {
+			t.Errorf("PasswordDigest = %q, want %q", token.Password, want)
+		}
+	}
+}
@@ -517,7 +517,11 @@ func (c *CLI) getSystemDateTime(ctx context.Context) {
 		return
 	}
 
-	fmt.Printf("✅ System Date/Time: %v\n", dateTime)
+	if utc, ok := dateTime.UTC(); ok {
+		fmt.Printf("✅ System Date/Time: %s (%s)\n", utc.Format(time.RFC3339), dateTime.DateTimeType)
+	} else {
+		fmt.Printf("✅ System Date/Time: no UTC time reported (%s)\n", dateTime.DateTimeType)
+	}
 }
 
 func (c *CLI) rebootDevice(ctx context.Context) {
@@ -1276,8 +1280,12 @@ func (c *CLI) getImagingSettings(ctx context.Context, videoSourceToken string) {
 	if settings.Exposure != nil {
 		fmt.Printf("   Exposure Mode: %s\n", settings.Exposure.Mode)
 		if settings.Exposure.Mode == "MANUAL" {
-			fmt.Printf("     Exposure Time: %.2f\n", settings.Exposure.ExposureTime)
-			fmt.Printf("     Gain: %.2f\n", settings.Exposure.Gain)
+			if settings.Exposure.ExposureTime != nil {
+				fmt.Printf("     Exposure Time: %.2f\n", *settings.Exposure.ExposureTime)
+			}
+			if settings.Exposure.Gain != nil {
+				fmt.Printf("     Gain: %.2f\n", *settings.Exposure.Gain)
+			}
 		}
 	}
 
@@ -1291,7 +1299,9 @@ func (c *CLI) getImagingSettings(ctx context.Context, videoSourceToken string) {
 
 	if settings.WideDynamicRange != nil {
 		fmt.Printf("   WDR Mode: %s\n", settings.WideDynamicRange.Mode)
-		fmt.Printf("   WDR Level: %.1f\n", settings.WideDynamicRange.Level)
+		if settings.WideDynamicRange.Level != nil {
+			fmt.Printf("   WDR Level: %.1f\n", *settings.WideDynamicRange.Level)
+		}
 	}
 }
 
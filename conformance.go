@@ -0,0 +1,112 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ONVIF conformance profiles returned by ConformanceProfiles.
+const (
+	ProfileS = "S"
+	ProfileT = "T"
+	ProfileG = "G"
+	ProfileM = "M"
+	ProfileC = "C"
+	ProfileA = "A"
+	ProfileD = "D"
+	ProfileQ = "Q"
+)
+
+// profileScopePrefix is the scope prefix devices use to announce profile conformance.
+const profileScopePrefix = "onvif://www.onvif.org/profile/"
+
+// profileOrder is the order profiles are reported in.
+var profileOrder = []string{ProfileS, ProfileT, ProfileG, ProfileM, ProfileC, ProfileA, ProfileD, ProfileQ}
+
+// profileScopeNames maps the lower-cased scope suffix to the profile letter.
+// Profile S predates the single-letter naming and is announced as "Streaming".
+var profileScopeNames = map[string]string{
+	"streaming": ProfileS,
+	"s":         ProfileS,
+	"t":         ProfileT,
+	"g":         ProfileG,
+	"m":         ProfileM,
+	"c":         ProfileC,
+	"a":         ProfileA,
+	"d":         ProfileD,
+	"q":         ProfileQ,
+}
+
+// ConformanceProfiles returns the ONVIF profiles (S, T, G, M, C, A, D, Q) the
+// device claims conformance with. Profiles are taken from the device scopes
+// (onvif://www.onvif.org/Profile/...). Devices that announce no profile scope
+// are classified from the services reported by GetServices instead, which is a
+// best-effort inference rather than a conformance claim.
+func (c *Client) ConformanceProfiles(ctx context.Context) ([]string, error) {
+	scopes, err := c.GetScopes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scopes: %w", err)
+	}
+
+	profiles := profilesFromScopes(scopes)
+	if len(profiles) > 0 {
+		return profiles, nil
+	}
+
+	services, err := c.GetServices(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+
+	return profilesFromServices(services), nil
+}
+
+// profilesFromScopes extracts the announced profiles from device scopes.
+func profilesFromScopes(scopes []*Scope) []string {
+	found := make(map[string]bool)
+
+	for _, scope := range scopes {
+		item := strings.ToLower(strings.TrimSpace(scope.ScopeItem))
+		if !strings.HasPrefix(item, profileScopePrefix) {
+			continue
+		}
+
+		if profile, ok := profileScopeNames[strings.TrimSuffix(item[len(profileScopePrefix):], "/")]; ok {
+			found[profile] = true
+		}
+	}
+
+	return orderedProfiles(found)
+}
+
+// profilesFromServices infers profiles from the services a device implements.
+// A profile is only inferred when all the services it mandates are present.
+func profilesFromServices(services []*Service) []string {
+	namespaces := make(map[string]bool, len(services))
+	for _, svc := range services {
+		namespaces[svc.Namespace] = true
+	}
+
+	found := map[string]bool{
+		ProfileS: namespaces[mediaNamespace] && namespaces[eventNamespace],
+		ProfileT: namespaces[media2Namespace],
+		ProfileG: namespaces[recordingNamespace] && namespaces[searchNamespace] && namespaces[replayNamespace],
+		ProfileM: namespaces[analyticsNamespace] && namespaces[media2Namespace],
+	}
+
+	return orderedProfiles(found)
+}
+
+// orderedProfiles returns the profiles marked in found in canonical order.
+func orderedProfiles(found map[string]bool) []string {
+	profiles := make([]string, 0, len(found))
+	for _, profile := range profileOrder {
+		if found[profile] {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return slices.Clip(profiles)
+}
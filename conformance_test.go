@@ -0,0 +1,84 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConformanceProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   string
+		services string
+		want     []string
+	}{
+		{
+			name: "profiles from scopes",
+			scopes: `
+				<tds:Scopes><tt:ScopeDef>Fixed</tt:ScopeDef><tt:ScopeItem>onvif://www.onvif.org/type/video_encoder</tt:ScopeItem></tds:Scopes>
+				<tds:Scopes><tt:ScopeDef>Fixed</tt:ScopeDef><tt:ScopeItem>onvif://www.onvif.org/Profile/T</tt:ScopeItem></tds:Scopes>
+				<tds:Scopes><tt:ScopeDef>Fixed</tt:ScopeDef><tt:ScopeItem>onvif://www.onvif.org/Profile/Streaming</tt:ScopeItem></tds:Scopes>
+				<tds:Scopes><tt:ScopeDef>Fixed</tt:ScopeDef><tt:ScopeItem>onvif://www.onvif.org/Profile/G</tt:ScopeItem></tds:Scopes>`,
+			want: []string{ProfileS, ProfileT, ProfileG},
+		},
+		{
+			name: "profiles inferred from services",
+			scopes: `
+				<tds:Scopes><tt:ScopeDef>Fixed</tt:ScopeDef><tt:ScopeItem>onvif://www.onvif.org/name/Camera</tt:ScopeItem></tds:Scopes>`,
+			services: `
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver10/device/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/device_service</tds:XAddr></tds:Service>
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver10/media/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/media_service</tds:XAddr></tds:Service>
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver10/events/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/event_service</tds:XAddr></tds:Service>
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver20/media/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/media2_service</tds:XAddr></tds:Service>`,
+			want: []string{ProfileS, ProfileT},
+		},
+		{
+			name: "media without events is not profile S",
+			services: `
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver10/device/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/device_service</tds:XAddr></tds:Service>
+				<tds:Service><tds:Namespace>http://www.onvif.org/ver10/media/wsdl</tds:Namespace><tds:XAddr>http://camera/onvif/media_service</tds:XAddr></tds:Service>`,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				var response string
+				if strings.Contains(string(body), "GetScopes") {
+					response = `<tds:GetScopesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+						tt.scopes + `</tds:GetScopesResponse>`
+				} else {
+					response = `<tds:GetServicesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">` +
+						tt.services + `</tds:GetServicesResponse>`
+				}
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			profiles, err := client.ConformanceProfiles(context.Background())
+			if err != nil {
+				t.Fatalf("ConformanceProfiles() failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(profiles, tt.want) {
+				t.Errorf("ConformanceProfiles() = %v, want %v", profiles, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// ConfigurationKind names a kind of configuration a media profile references.
+type ConfigurationKind string
+
+// Configuration kinds checked by ValidateConsistency.
+const (
+	ConfigurationKindVideoSource  ConfigurationKind = "VideoSource"
+	ConfigurationKindAudioSource  ConfigurationKind = "AudioSource"
+	ConfigurationKindVideoEncoder ConfigurationKind = "VideoEncoder"
+	ConfigurationKindAudioEncoder ConfigurationKind = "AudioEncoder"
+	ConfigurationKindMetadata     ConfigurationKind = "Metadata"
+)
+
+// DanglingReference is a profile reference to a configuration the device
+// does not list.
+type DanglingReference struct {
+	ProfileToken       string
+	Kind               ConfigurationKind
+	ConfigurationToken string
+	// Repaired is set by RepairConsistency once the reference was removed.
+	Repaired bool
+}
+
+// ConsistencyReport describes the outcome of ValidateConsistency.
+type ConsistencyReport struct {
+	// Profiles is the number of profiles checked.
+	Profiles int
+	// Dangling lists the references to configurations that do not exist, in
+	// profile order.
+	Dangling []*DanglingReference
+	// Unchecked lists the configuration kinds whose list could not be read;
+	// references of these kinds are not checked.
+	Unchecked []ConfigurationKind
+	// Warnings holds the errors that left kinds unchecked or, for
+	// RepairConsistency, references unrepaired.
+	Warnings []error
+}
+
+// Consistent reports whether no dangling reference was found.
+func (r *ConsistencyReport) Consistent() bool {
+	return len(r.Dangling) == 0
+}
+
+// profileReferences holds the configuration tokens a profile references.
+type profileReferences struct {
+	Token  string
+	Tokens map[ConfigurationKind]string
+}
+
+// ValidateConsistency cross-checks the video source, audio source, video
+// encoder, audio encoder and metadata configurations referenced by every
+// media profile against the configurations the device lists, and reports the
+// references to configurations that do not exist. Such references typically
+// break streaming of the profile. A configuration list that cannot be read,
+// e.g. because the device has no audio, is reported in Unchecked.
+func (c *Client) ValidateConsistency(ctx context.Context) (*ConsistencyReport, error) {
+	profiles, err := c.getProfileReferences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	report := &ConsistencyReport{Profiles: len(profiles)}
+
+	lists := []struct {
+		kind   ConfigurationKind
+		tokens func() ([]string, error)
+	}{
+		{ConfigurationKindVideoSource, func() ([]string, error) {
+			configs, err := c.GetVideoSourceConfigurations(ctx)
+
+			return configurationTokens(configs, err, func(cfg *VideoSourceConfiguration) string { return cfg.Token })
+		}},
+		{ConfigurationKindAudioSource, func() ([]string, error) {
+			configs, err := c.GetAudioSourceConfigurations(ctx)
+
+			return configurationTokens(configs, err, func(cfg *AudioSourceConfiguration) string { return cfg.Token })
+		}},
+		{ConfigurationKindVideoEncoder, func() ([]string, error) {
+			configs, err := c.GetVideoEncoderConfigurations(ctx)
+
+			return configurationTokens(configs, err, func(cfg *VideoEncoderConfiguration) string { return cfg.Token })
+		}},
+		{ConfigurationKindAudioEncoder, func() ([]string, error) {
+			configs, err := c.GetAudioEncoderConfigurations(ctx)
+
+			return configurationTokens(configs, err, func(cfg *AudioEncoderConfiguration) string { return cfg.Token })
+		}},
+		{ConfigurationKindMetadata, func() ([]string, error) {
+			configs, err := c.GetMetadataConfigurations(ctx)
+
+			return configurationTokens(configs, err, func(cfg *MetadataConfiguration) string { return cfg.Token })
+		}},
+	}
+
+	existing := make(map[ConfigurationKind]map[string]bool, len(lists))
+
+	for _, list := range lists {
+		// Only read the lists that some profile references.
+		referenced := false
+		for _, profile := range profiles {
+			if profile.Tokens[list.kind] != "" {
+				referenced = true
+
+				break
+			}
+		}
+
+		if !referenced {
+			continue
+		}
+
+		tokens, err := list.tokens()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to get %s configurations: %w", list.kind, err)
+			}
+
+			report.Unchecked = append(report.Unchecked, list.kind)
+			report.Warnings = append(report.Warnings, fmt.Errorf("%s: %w", list.kind, err))
+
+			continue
+		}
+
+		existing[list.kind] = make(map[string]bool, len(tokens))
+		for _, token := range tokens {
+			existing[list.kind][token] = true
+		}
+	}
+
+	for _, profile := range profiles {
+		for _, list := range lists {
+			token := profile.Tokens[list.kind]
+			if token == "" || existing[list.kind] == nil || existing[list.kind][token] {
+				continue
+			}
+
+			report.Dangling = append(report.Dangling, &DanglingReference{
+				ProfileToken:       profile.Token,
+				Kind:               list.kind,
+				ConfigurationToken: token,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// RepairConsistency runs ValidateConsistency and removes each dangling
+// reference from its profile with the matching Remove*Configuration call,
+// e.g. RemoveVideoEncoderConfiguration. Removing a video source configuration
+// may also drop the encoder from the profile on some devices. References
+// that could not be removed are left with Repaired unset and the error in
+// Warnings.
+func (c *Client) RepairConsistency(ctx context.Context) (*ConsistencyReport, error) {
+	report, err := c.ValidateConsistency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removers := map[ConfigurationKind]func(context.Context, string) error{
+		ConfigurationKindVideoSource:  c.RemoveVideoSourceConfiguration,
+		ConfigurationKindAudioSource:  c.RemoveAudioSourceConfiguration,
+		ConfigurationKindVideoEncoder: c.RemoveVideoEncoderConfiguration,
+		ConfigurationKindAudioEncoder: c.RemoveAudioEncoderConfiguration,
+		ConfigurationKindMetadata:     c.RemoveMetadataConfiguration,
+	}
+
+	for _, ref := range report.Dangling {
+		if err := removers[ref.Kind](ctx, ref.ProfileToken); err != nil {
+			if ctx.Err() != nil {
+				return report, err
+			}
+
+			report.Warnings = append(report.Warnings, fmt.Errorf("profile %s: %w", ref.ProfileToken, err))
+
+			continue
+		}
+
+		ref.Repaired = true
+	}
+
+	return report, nil
+}
+
+// configurationTokens returns the tokens of configs, or err.
+func configurationTokens[T any](configs []T, err error, token func(T) string) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, len(configs))
+	for i, config := range configs {
+		tokens[i] = token(config)
+	}
+
+	return tokens, nil
+}
+
+// getProfileReferences retrieves the configuration tokens referenced by each
+// profile from the Media (ver10) service, whose Remove*Configuration calls
+// RepairConsistency uses.
+func (c *Client) getProfileReferences(ctx context.Context) ([]*profileReferences, error) {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"trt:GetProfiles"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+	}
+
+	type configurationRef struct {
+		Token string `xml:"token,attr"`
+	}
+
+	type GetProfilesResponse struct {
+		XMLName  xml.Name `xml:"GetProfilesResponse"`
+		Profiles []struct {
+			Token                     string            `xml:"token,attr"`
+			VideoSourceConfiguration  *configurationRef `xml:"VideoSourceConfiguration"`
+			AudioSourceConfiguration  *configurationRef `xml:"AudioSourceConfiguration"`
+			VideoEncoderConfiguration *configurationRef `xml:"VideoEncoderConfiguration"`
+			AudioEncoderConfiguration *configurationRef `xml:"AudioEncoderConfiguration"`
+			MetadataConfiguration     *configurationRef `xml:"MetadataConfiguration"`
+		} `xml:"Profiles"`
+	}
+
+	req := GetProfiles{
+		Xmlns: mediaNamespace,
+	}
+
+	var resp GetProfilesResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetProfiles failed: %w", err)
+	}
+
+	token := func(ref *configurationRef) string {
+		if ref == nil {
+			return ""
+		}
+
+		return ref.Token
+	}
+
+	profiles := make([]*profileReferences, len(resp.Profiles))
+	for i, p := range resp.Profiles {
+		profiles[i] = &profileReferences{
+			Token: p.Token,
+			Tokens: map[ConfigurationKind]string{
+				ConfigurationKindVideoSource:  token(p.VideoSourceConfiguration),
+				ConfigurationKindAudioSource:  token(p.AudioSourceConfiguration),
+				ConfigurationKindVideoEncoder: token(p.VideoEncoderConfiguration),
+				ConfigurationKindAudioEncoder: token(p.AudioEncoderConfiguration),
+				ConfigurationKindMetadata:     token(p.MetadataConfiguration),
+			},
+		}
+	}
+
+	return profiles, nil
+}
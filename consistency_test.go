@@ -0,0 +1,143 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockConsistencyServer(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, string(body))
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1">
+					<tt:Name>Main</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:Name>VSC</tt:Name></tt:VideoSourceConfiguration>
+					<tt:VideoEncoderConfiguration token="VEC_gone"><tt:Name>VEC</tt:Name></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Profile_2">
+					<tt:Name>Sub</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:Name>VSC</tt:Name></tt:VideoSourceConfiguration>
+					<tt:AudioEncoderConfiguration token="AEC_1"><tt:Name>AEC</tt:Name></tt:AudioEncoderConfiguration>
+					<tt:MetadataConfiguration token="MD_gone"><tt:Name>MD</tt:Name></tt:MetadataConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "GetVideoSourceConfigurations"):
+			response = `<trt:GetVideoSourceConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Configurations token="VSC_1"><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">VSC</tt:Name></trt:Configurations>
+			</trt:GetVideoSourceConfigurationsResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfigurations"):
+			response = `<trt:GetVideoEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Configurations token="VEC_1"><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">VEC</tt:Name></trt:Configurations>
+			</trt:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "GetAudioEncoderConfigurations"):
+			w.WriteHeader(http.StatusInternalServerError)
+			response = `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:ActionNotSupported</soap:Value></soap:Subcode></soap:Code>
+				<soap:Reason><soap:Text>No audio</soap:Text></soap:Reason></soap:Fault>`
+		case strings.Contains(string(body), "GetMetadataConfigurations"):
+			response = `<trt:GetMetadataConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		case strings.Contains(string(body), "RemoveVideoEncoderConfiguration"),
+			strings.Contains(string(body), "RemoveMetadataConfiguration"):
+			response = `<trt:RemoveConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestValidateConsistency(t *testing.T) {
+	var requests []string
+
+	server := newMockConsistencyServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	report, err := client.ValidateConsistency(context.Background())
+	if err != nil {
+		t.Fatalf("ValidateConsistency() failed: %v", err)
+	}
+
+	if report.Profiles != 2 || report.Consistent() {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	if len(report.Dangling) != 2 {
+		t.Fatalf("Expected 2 dangling references, got %d", len(report.Dangling))
+	}
+
+	if ref := report.Dangling[0]; ref.ProfileToken != "Profile_1" || ref.Kind != ConfigurationKindVideoEncoder ||
+		ref.ConfigurationToken != "VEC_gone" {
+		t.Errorf("Unexpected dangling reference: %+v", ref)
+	}
+
+	if ref := report.Dangling[1]; ref.ProfileToken != "Profile_2" || ref.Kind != ConfigurationKindMetadata {
+		t.Errorf("Unexpected dangling reference: %+v", ref)
+	}
+
+	if len(report.Unchecked) != 1 || report.Unchecked[0] != ConfigurationKindAudioEncoder {
+		t.Errorf("Expected audio encoders to be unchecked, got %v", report.Unchecked)
+	}
+
+	for _, req := range requests {
+		if strings.Contains(req, "GetAudioSourceConfigurations") {
+			t.Errorf("Unreferenced configuration list was requested")
+		}
+	}
+}
+
+func TestRepairConsistency(t *testing.T) {
+	var requests []string
+
+	server := newMockConsistencyServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	report, err := client.RepairConsistency(context.Background())
+	if err != nil {
+		t.Fatalf("RepairConsistency() failed: %v", err)
+	}
+
+	for _, ref := range report.Dangling {
+		if !ref.Repaired {
+			t.Errorf("Expected reference to be repaired: %+v", ref)
+		}
+	}
+
+	var removals []string
+
+	for _, req := range requests {
+		if strings.Contains(req, "Remove") {
+			removals = append(removals, req)
+		}
+	}
+
+	if len(removals) != 2 ||
+		!strings.Contains(removals[0], "RemoveVideoEncoderConfiguration") ||
+		!strings.Contains(removals[0], "<trt:ProfileToken>Profile_1</trt:ProfileToken>") ||
+		!strings.Contains(removals[1], "RemoveMetadataConfiguration") ||
+		!strings.Contains(removals[1], "<trt:ProfileToken>Profile_2</trt:ProfileToken>") {
+		t.Errorf("Unexpected removals: %v", removals)
+	}
+}
@@ -1,11 +1,14 @@
 package onvif
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"net"
+	"strconv"
+	"strings"
 )
 
 // Device service namespace.
@@ -13,18 +16,24 @@ const deviceNamespace = "http://www.onvif.org/ver10/device/wsdl"
 
 // GetDeviceInformation retrieves device information.
 func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation, error) {
+	return singleFlight(ctx, c, "GetDeviceInformation", c.getDeviceInformation)
+}
+
+// getDeviceInformation implements GetDeviceInformation.
+func (c *Client) getDeviceInformation(ctx context.Context) (*DeviceInformation, error) {
 	type GetDeviceInformation struct {
 		XMLName xml.Name `xml:"tds:GetDeviceInformation"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
 	}
 
 	type GetDeviceInformationResponse struct {
-		XMLName         xml.Name `xml:"GetDeviceInformationResponse"`
-		Manufacturer    string   `xml:"Manufacturer"`
-		Model           string   `xml:"Model"`
-		FirmwareVersion string   `xml:"FirmwareVersion"`
-		SerialNumber    string   `xml:"SerialNumber"`
-		HardwareID      string   `xml:"HardwareId"`
+		XMLName         xml.Name           `xml:"GetDeviceInformationResponse"`
+		Manufacturer    string             `xml:"Manufacturer"`
+		Model           string             `xml:"Model"`
+		FirmwareVersion string             `xml:"FirmwareVersion"`
+		SerialNumber    string             `xml:"SerialNumber"`
+		HardwareID      string             `xml:"HardwareId"`
+		Extra           []extensionElement `xml:",any"`
 	}
 
 	req := GetDeviceInformation{
@@ -33,8 +42,7 @@ func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation,
 
 	var resp GetDeviceInformationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDeviceInformation failed: %w", err)
@@ -46,13 +54,61 @@ func (c *Client) GetDeviceInformation(ctx context.Context) (*DeviceInformation,
 		FirmwareVersion: resp.FirmwareVersion,
 		SerialNumber:    resp.SerialNumber,
 		HardwareID:      resp.HardwareID,
+		Extensions:      flattenExtensions(resp.Extra, "", nil),
 	}, nil
 }
 
+// extensionElement is an element not otherwise decoded, such as OEM
+// additions to a response.
+type extensionElement struct {
+	XMLName  xml.Name
+	Text     string             `xml:",chardata"`
+	Children []extensionElement `xml:",any"`
+}
+
+// flattenExtensions adds the text of each leaf element in elements to values
+// keyed by its path of local names below prefix, e.g. "Extension/BuildDate",
+// descending into elements with children, and returns values. A repeated path
+// is numbered from its second occurrence on, e.g. "Extension/Item#2", so that
+// every value is kept. values is allocated on the first leaf.
+func flattenExtensions(elements []extensionElement, prefix string, values map[string]string) map[string]string {
+	for _, element := range elements {
+		path := prefix + element.XMLName.Local
+
+		if len(element.Children) > 0 {
+			values = flattenExtensions(element.Children, path+"/", values)
+
+			continue
+		}
+
+		if values == nil {
+			values = make(map[string]string)
+		}
+
+		key := path
+		for n := 2; ; n++ {
+			if _, ok := values[key]; !ok {
+				break
+			}
+
+			key = path + "#" + strconv.Itoa(n)
+		}
+
+		values[key] = strings.TrimSpace(element.Text)
+	}
+
+	return values
+}
+
 // GetCapabilities retrieves device capabilities.
+func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	return singleFlight(ctx, c, "GetCapabilities", c.getCapabilities)
+}
+
+// getCapabilities implements GetCapabilities.
 //
 //nolint:funlen // GetCapabilities has many statements due to parsing multiple service capabilities
-func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+func (c *Client) getCapabilities(ctx context.Context) (*Capabilities, error) {
 	type GetCapabilities struct {
 		XMLName  xml.Name `xml:"tds:GetCapabilities"`
 		Xmlns    string   `xml:"xmlns:tds,attr"`
@@ -64,24 +120,24 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 		Capabilities struct {
 			Analytics *struct {
 				XAddr                  string `xml:"XAddr"`
-				RuleSupport            bool   `xml:"RuleSupport"`
-				AnalyticsModuleSupport bool   `xml:"AnalyticsModuleSupport"`
+				RuleSupport            Bool   `xml:"RuleSupport"`
+				AnalyticsModuleSupport Bool   `xml:"AnalyticsModuleSupport"`
 			} `xml:"Analytics"`
 			Device *struct {
 				XAddr   string `xml:"XAddr"`
 				Network *struct {
-					IPFilter          bool `xml:"IPFilter"`
-					ZeroConfiguration bool `xml:"ZeroConfiguration"`
-					IPVersion6        bool `xml:"IPVersion6"`
-					DynDNS            bool `xml:"DynDNS"`
+					IPFilter          Bool `xml:"IPFilter"`
+					ZeroConfiguration Bool `xml:"ZeroConfiguration"`
+					IPVersion6        Bool `xml:"IPVersion6"`
+					DynDNS            Bool `xml:"DynDNS"`
 				} `xml:"Network"`
 				System *struct {
-					DiscoveryResolve  bool     `xml:"DiscoveryResolve"`
-					DiscoveryBye      bool     `xml:"DiscoveryBye"`
-					RemoteDiscovery   bool     `xml:"RemoteDiscovery"`
-					SystemBackup      bool     `xml:"SystemBackup"`
-					SystemLogging     bool     `xml:"SystemLogging"`
-					FirmwareUpgrade   bool     `xml:"FirmwareUpgrade"`
+					DiscoveryResolve  Bool     `xml:"DiscoveryResolve"`
+					DiscoveryBye      Bool     `xml:"DiscoveryBye"`
+					RemoteDiscovery   Bool     `xml:"RemoteDiscovery"`
+					SystemBackup      Bool     `xml:"SystemBackup"`
+					SystemLogging     Bool     `xml:"SystemLogging"`
+					FirmwareUpgrade   Bool     `xml:"FirmwareUpgrade"`
 					SupportedVersions []string `xml:"SupportedVersions>Major"`
 				} `xml:"System"`
 				IO *struct {
@@ -89,21 +145,21 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 					RelayOutputs    int `xml:"RelayOutputs"`
 				} `xml:"IO"`
 				Security *struct {
-					TLS11                bool `xml:"TLS1.1"`
-					TLS12                bool `xml:"TLS1.2"`
-					OnboardKeyGeneration bool `xml:"OnboardKeyGeneration"`
-					AccessPolicyConfig   bool `xml:"AccessPolicyConfig"`
-					X509Token            bool `xml:"X.509Token"`
-					SAMLToken            bool `xml:"SAMLToken"`
-					KerberosToken        bool `xml:"KerberosToken"`
-					RELToken             bool `xml:"RELToken"`
+					TLS11                Bool `xml:"TLS1.1"`
+					TLS12                Bool `xml:"TLS1.2"`
+					OnboardKeyGeneration Bool `xml:"OnboardKeyGeneration"`
+					AccessPolicyConfig   Bool `xml:"AccessPolicyConfig"`
+					X509Token            Bool `xml:"X.509Token"`
+					SAMLToken            Bool `xml:"SAMLToken"`
+					KerberosToken        Bool `xml:"KerberosToken"`
+					RELToken             Bool `xml:"RELToken"`
 				} `xml:"Security"`
 			} `xml:"Device"`
 			Events *struct {
 				XAddr                         string `xml:"XAddr"`
-				WSSubscriptionPolicySupport   bool   `xml:"WSSubscriptionPolicySupport"`
-				WSPullPointSupport            bool   `xml:"WSPullPointSupport"`
-				WSPausableSubscriptionSupport bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport"`
+				WSSubscriptionPolicySupport   Bool   `xml:"WSSubscriptionPolicySupport"`
+				WSPullPointSupport            Bool   `xml:"WSPullPointSupport"`
+				WSPausableSubscriptionSupport Bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport"`
 			} `xml:"Events"`
 			Imaging *struct {
 				XAddr string `xml:"XAddr"`
@@ -111,9 +167,9 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 			Media *struct {
 				XAddr                 string `xml:"XAddr"`
 				StreamingCapabilities *struct {
-					RTPMulticast bool `xml:"RTPMulticast"`
-					RTPTCP       bool `xml:"RTP_TCP"`
-					RTPRTSPTCP   bool `xml:"RTP_RTSP_TCP"`
+					RTPMulticast Bool `xml:"RTPMulticast"`
+					RTPTCP       Bool `xml:"RTP_TCP"`
+					RTPRTSPTCP   Bool `xml:"RTP_RTSP_TCP"`
 				} `xml:"StreamingCapabilities"`
 			} `xml:"Media"`
 			PTZ *struct {
@@ -129,8 +185,7 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 
 	var resp GetCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCapabilities failed: %w", err)
@@ -142,8 +197,8 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	if resp.Capabilities.Analytics != nil {
 		capabilities.Analytics = &AnalyticsCapabilities{
 			XAddr:                  resp.Capabilities.Analytics.XAddr,
-			RuleSupport:            resp.Capabilities.Analytics.RuleSupport,
-			AnalyticsModuleSupport: resp.Capabilities.Analytics.AnalyticsModuleSupport,
+			RuleSupport:            bool(resp.Capabilities.Analytics.RuleSupport),
+			AnalyticsModuleSupport: bool(resp.Capabilities.Analytics.AnalyticsModuleSupport),
 		}
 	}
 
@@ -154,20 +209,20 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 		}
 		if resp.Capabilities.Device.Network != nil {
 			capabilities.Device.Network = &NetworkCapabilities{
-				IPFilter:          resp.Capabilities.Device.Network.IPFilter,
-				ZeroConfiguration: resp.Capabilities.Device.Network.ZeroConfiguration,
-				IPVersion6:        resp.Capabilities.Device.Network.IPVersion6,
-				DynDNS:            resp.Capabilities.Device.Network.DynDNS,
+				IPFilter:          bool(resp.Capabilities.Device.Network.IPFilter),
+				ZeroConfiguration: bool(resp.Capabilities.Device.Network.ZeroConfiguration),
+				IPVersion6:        bool(resp.Capabilities.Device.Network.IPVersion6),
+				DynDNS:            bool(resp.Capabilities.Device.Network.DynDNS),
 			}
 		}
 		if resp.Capabilities.Device.System != nil {
 			capabilities.Device.System = &SystemCapabilities{
-				DiscoveryResolve:  resp.Capabilities.Device.System.DiscoveryResolve,
-				DiscoveryBye:      resp.Capabilities.Device.System.DiscoveryBye,
-				RemoteDiscovery:   resp.Capabilities.Device.System.RemoteDiscovery,
-				SystemBackup:      resp.Capabilities.Device.System.SystemBackup,
-				SystemLogging:     resp.Capabilities.Device.System.SystemLogging,
-				FirmwareUpgrade:   resp.Capabilities.Device.System.FirmwareUpgrade,
+				DiscoveryResolve:  bool(resp.Capabilities.Device.System.DiscoveryResolve),
+				DiscoveryBye:      bool(resp.Capabilities.Device.System.DiscoveryBye),
+				RemoteDiscovery:   bool(resp.Capabilities.Device.System.RemoteDiscovery),
+				SystemBackup:      bool(resp.Capabilities.Device.System.SystemBackup),
+				SystemLogging:     bool(resp.Capabilities.Device.System.SystemLogging),
+				FirmwareUpgrade:   bool(resp.Capabilities.Device.System.FirmwareUpgrade),
 				SupportedVersions: resp.Capabilities.Device.System.SupportedVersions,
 			}
 		}
@@ -179,14 +234,14 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 		}
 		if resp.Capabilities.Device.Security != nil {
 			capabilities.Device.Security = &SecurityCapabilities{
-				TLS11:                resp.Capabilities.Device.Security.TLS11,
-				TLS12:                resp.Capabilities.Device.Security.TLS12,
-				OnboardKeyGeneration: resp.Capabilities.Device.Security.OnboardKeyGeneration,
-				AccessPolicyConfig:   resp.Capabilities.Device.Security.AccessPolicyConfig,
-				X509Token:            resp.Capabilities.Device.Security.X509Token,
-				SAMLToken:            resp.Capabilities.Device.Security.SAMLToken,
-				KerberosToken:        resp.Capabilities.Device.Security.KerberosToken,
-				RELToken:             resp.Capabilities.Device.Security.RELToken,
+				TLS11:                bool(resp.Capabilities.Device.Security.TLS11),
+				TLS12:                bool(resp.Capabilities.Device.Security.TLS12),
+				OnboardKeyGeneration: bool(resp.Capabilities.Device.Security.OnboardKeyGeneration),
+				AccessPolicyConfig:   bool(resp.Capabilities.Device.Security.AccessPolicyConfig),
+				X509Token:            bool(resp.Capabilities.Device.Security.X509Token),
+				SAMLToken:            bool(resp.Capabilities.Device.Security.SAMLToken),
+				KerberosToken:        bool(resp.Capabilities.Device.Security.KerberosToken),
+				RELToken:             bool(resp.Capabilities.Device.Security.RELToken),
 			}
 		}
 	}
@@ -195,9 +250,9 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	if resp.Capabilities.Events != nil {
 		capabilities.Events = &EventCapabilities{
 			XAddr:                         resp.Capabilities.Events.XAddr,
-			WSSubscriptionPolicySupport:   resp.Capabilities.Events.WSSubscriptionPolicySupport,
-			WSPullPointSupport:            resp.Capabilities.Events.WSPullPointSupport,
-			WSPausableSubscriptionSupport: resp.Capabilities.Events.WSPausableSubscriptionSupport,
+			WSSubscriptionPolicySupport:   bool(resp.Capabilities.Events.WSSubscriptionPolicySupport),
+			WSPullPointSupport:            bool(resp.Capabilities.Events.WSPullPointSupport),
+			WSPausableSubscriptionSupport: bool(resp.Capabilities.Events.WSPausableSubscriptionSupport),
 		}
 	}
 
@@ -215,9 +270,9 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 		}
 		if resp.Capabilities.Media.StreamingCapabilities != nil {
 			capabilities.Media.StreamingCapabilities = &StreamingCapabilities{
-				RTPMulticast: resp.Capabilities.Media.StreamingCapabilities.RTPMulticast,
-				RTPTCP:       resp.Capabilities.Media.StreamingCapabilities.RTPTCP,
-				RTPRTSPTCP:   resp.Capabilities.Media.StreamingCapabilities.RTPRTSPTCP,
+				RTPMulticast: bool(resp.Capabilities.Media.StreamingCapabilities.RTPMulticast),
+				RTPTCP:       bool(resp.Capabilities.Media.StreamingCapabilities.RTPTCP),
+				RTPRTSPTCP:   bool(resp.Capabilities.Media.StreamingCapabilities.RTPRTSPTCP),
 			}
 		}
 	}
@@ -232,7 +287,11 @@ func (c *Client) GetCapabilities(ctx context.Context) (*Capabilities, error) {
 	return capabilities, nil
 }
 
-// SystemReboot reboots the device.
+// SystemReboot reboots the device and returns its message, e.g. "Rebooting in
+// 5 seconds". The device is unreachable while it restarts, and its service
+// endpoints may change, so callers should call Initialize again once it
+// answers. Media URIs marked InvalidAfterReboot are dropped from the cache of
+// WithMediaURICache.
 func (c *Client) SystemReboot(ctx context.Context) (string, error) {
 	type SystemReboot struct {
 		XMLName xml.Name `xml:"tds:SystemReboot"`
@@ -250,40 +309,97 @@ func (c *Client) SystemReboot(ctx context.Context) (string, error) {
 
 	var resp SystemRebootResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("SystemReboot failed: %w", err)
 	}
 
+	c.dropRebootMediaURIs()
+
 	return resp.Message, nil
 }
 
-// GetSystemDateAndTime retrieves the device's system date and time.
-func (c *Client) GetSystemDateAndTime(ctx context.Context) (interface{}, error) {
+// dateTimeResponse is the wire form of a date and time received from the device.
+type dateTimeResponse struct {
+	Time struct {
+		Hour   int `xml:"Hour"`
+		Minute int `xml:"Minute"`
+		Second int `xml:"Second"`
+	} `xml:"Time"`
+	Date struct {
+		Year  int `xml:"Year"`
+		Month int `xml:"Month"`
+		Day   int `xml:"Day"`
+	} `xml:"Date"`
+}
+
+// toDateTime converts a decoded date and time.
+func (r *dateTimeResponse) toDateTime() *DateTime {
+	if r == nil {
+		return nil
+	}
+
+	return &DateTime{
+		Time: Time{Hour: r.Time.Hour, Minute: r.Time.Minute, Second: r.Time.Second},
+		Date: Date{Year: r.Date.Year, Month: r.Date.Month, Day: r.Date.Day},
+	}
+}
+
+// GetSystemDateAndTime retrieves the device's system date and time: how the
+// clock is set, the time zone, whether daylight saving time is in effect, and
+// the UTC and local date and time. UTCDateTime, LocalDateTime and TimeZone are
+// nil if the device does not report them. The request is sent without
+// authentication, as the ONVIF specification allows, and repeated with the
+// client credentials if the device demands them. SyncClock uses it to correct
+// the WS-Security timestamps for the device clock.
+func (c *Client) GetSystemDateAndTime(ctx context.Context) (*SystemDateTime, error) {
 	type GetSystemDateAndTime struct {
 		XMLName xml.Name `xml:"tds:GetSystemDateAndTime"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
 	}
 
+	type GetSystemDateAndTimeResponse struct {
+		XMLName           xml.Name `xml:"GetSystemDateAndTimeResponse"`
+		SystemDateAndTime struct {
+			DateTimeType    string `xml:"DateTimeType"`
+			DaylightSavings Bool   `xml:"DaylightSavings"`
+			// DaylightSaving is the misspelling some devices send instead.
+			DaylightSaving Bool `xml:"DaylightSaving"`
+			TimeZone       *struct {
+				TZ string `xml:"TZ"`
+			} `xml:"TimeZone"`
+			UTCDateTime   *dateTimeResponse `xml:"UTCDateTime"`
+			LocalDateTime *dateTimeResponse `xml:"LocalDateTime"`
+		} `xml:"SystemDateAndTime"`
+	}
+
 	req := GetSystemDateAndTime{
 		Xmlns: deviceNamespace,
 	}
 
-	var resp interface{}
+	var resp GetSystemDateAndTimeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
-
-	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+	if err := c.callWithoutAuth(ctx, c.endpoint, req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSystemDateAndTime failed: %w", err)
 	}
 
-	return resp, nil
+	dateTime := &SystemDateTime{
+		DateTimeType:    SetDateTimeType(resp.SystemDateAndTime.DateTimeType),
+		DaylightSavings: bool(resp.SystemDateAndTime.DaylightSavings || resp.SystemDateAndTime.DaylightSaving),
+		UTCDateTime:     resp.SystemDateAndTime.UTCDateTime.toDateTime(),
+		LocalDateTime:   resp.SystemDateAndTime.LocalDateTime.toDateTime(),
+	}
+
+	if resp.SystemDateAndTime.TimeZone != nil {
+		dateTime.TimeZone = &TimeZone{TZ: resp.SystemDateAndTime.TimeZone.TZ}
+	}
+
+	return dateTime, nil
 }
 
-// GetHostname retrieves the device's hostname.
+// GetHostname retrieves the device's hostname and whether it was obtained
+// from DHCP.
 func (c *Client) GetHostname(ctx context.Context) (*HostnameInformation, error) {
 	type GetHostname struct {
 		XMLName xml.Name `xml:"tds:GetHostname"`
@@ -293,7 +409,7 @@ func (c *Client) GetHostname(ctx context.Context) (*HostnameInformation, error)
 	type GetHostnameResponse struct {
 		XMLName             xml.Name `xml:"GetHostnameResponse"`
 		HostnameInformation struct {
-			FromDHCP bool   `xml:"FromDHCP"`
+			FromDHCP Bool   `xml:"FromDHCP"`
 			Name     string `xml:"Name"`
 		} `xml:"HostnameInformation"`
 	}
@@ -304,21 +420,28 @@ func (c *Client) GetHostname(ctx context.Context) (*HostnameInformation, error)
 
 	var resp GetHostnameResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetHostname failed: %w", err)
 	}
 
 	return &HostnameInformation{
-		FromDHCP: resp.HostnameInformation.FromDHCP,
+		FromDHCP: bool(resp.HostnameInformation.FromDHCP),
 		Name:     resp.HostnameInformation.Name,
 	}, nil
 }
 
-// SetHostname sets the device's hostname.
+// SetHostname sets the device's hostname, e.g. to name a camera after its
+// location. name must be a valid hostname per RFC 1123, otherwise
+// ErrInvalidParameter is returned without calling the device. Devices that
+// take their hostname from DHCP may keep using it until SetHostnameFromDHCP
+// turns that off.
 func (c *Client) SetHostname(ctx context.Context, name string) error {
+	if err := validateHostname(name); err != nil {
+		return fmt.Errorf("SetHostname failed: %w", err)
+	}
+
 	type SetHostname struct {
 		XMLName xml.Name `xml:"tds:SetHostname"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
@@ -330,8 +453,7 @@ func (c *Client) SetHostname(ctx context.Context, name string) error {
 		Name:  name,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetHostname failed: %w", err)
@@ -340,7 +462,39 @@ func (c *Client) SetHostname(ctx context.Context, name string) error {
 	return nil
 }
 
-// GetDNS retrieves DNS configuration.
+// maxHostnameLength and maxHostnameLabelLength are the limits of RFC 1123.
+const (
+	maxHostnameLength      = 253
+	maxHostnameLabelLength = 63
+)
+
+// validateHostname checks that name is a valid hostname: dot-separated labels
+// of letters, digits and hyphens that do not start or end with a hyphen.
+func validateHostname(name string) error {
+	if name == "" || len(name) > maxHostnameLength {
+		return fmt.Errorf("%w: hostname %q must have 1 to %d characters", ErrInvalidParameter, name, maxHostnameLength)
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		valid := label != "" && len(label) <= maxHostnameLabelLength &&
+			label[0] != '-' && label[len(label)-1] != '-'
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				valid = false
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf("%w: %q is not a valid hostname", ErrInvalidParameter, name)
+		}
+	}
+
+	return nil
+}
+
+// GetDNS retrieves the DNS configuration, with the servers obtained from DHCP
+// and the manually configured ones.
 func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 	type GetDNS struct {
 		XMLName xml.Name `xml:"tds:GetDNS"`
@@ -350,15 +504,17 @@ func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 	type GetDNSResponse struct {
 		XMLName        xml.Name `xml:"GetDNSResponse"`
 		DNSInformation struct {
-			FromDHCP     bool     `xml:"FromDHCP"`
+			FromDHCP     Bool     `xml:"FromDHCP"`
 			SearchDomain []string `xml:"SearchDomain"`
 			DNSFromDHCP  []struct {
 				Type        string `xml:"Type"`
 				IPv4Address string `xml:"IPv4Address"`
+				IPv6Address string `xml:"IPv6Address"`
 			} `xml:"DNSFromDHCP"`
 			DNSManual []struct {
 				Type        string `xml:"Type"`
 				IPv4Address string `xml:"IPv4Address"`
+				IPv6Address string `xml:"IPv6Address"`
 			} `xml:"DNSManual"`
 		} `xml:"DNSInformation"`
 	}
@@ -369,15 +525,14 @@ func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 
 	var resp GetDNSResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDNS failed: %w", err)
 	}
 
 	dns := &DNSInformation{
-		FromDHCP:     resp.DNSInformation.FromDHCP,
+		FromDHCP:     bool(resp.DNSInformation.FromDHCP),
 		SearchDomain: resp.DNSInformation.SearchDomain,
 	}
 
@@ -385,6 +540,7 @@ func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 		dns.DNSFromDHCP = append(dns.DNSFromDHCP, IPAddress{
 			Type:        d.Type,
 			IPv4Address: d.IPv4Address,
+			IPv6Address: d.IPv6Address,
 		})
 	}
 
@@ -392,13 +548,15 @@ func (c *Client) GetDNS(ctx context.Context) (*DNSInformation, error) {
 		dns.DNSManual = append(dns.DNSManual, IPAddress{
 			Type:        d.Type,
 			IPv4Address: d.IPv4Address,
+			IPv6Address: d.IPv6Address,
 		})
 	}
 
 	return dns, nil
 }
 
-// GetNTP retrieves NTP configuration.
+// GetNTP retrieves the NTP configuration, with the servers obtained from DHCP
+// and the manually configured ones, named by IPv4 or IPv6 address or DNS name.
 func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 	type GetNTP struct {
 		XMLName xml.Name `xml:"tds:GetNTP"`
@@ -408,15 +566,17 @@ func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 	type GetNTPResponse struct {
 		XMLName        xml.Name `xml:"GetNTPResponse"`
 		NTPInformation struct {
-			FromDHCP    bool `xml:"FromDHCP"`
+			FromDHCP    Bool `xml:"FromDHCP"`
 			NTPFromDHCP []struct {
 				Type        string `xml:"Type"`
 				IPv4Address string `xml:"IPv4Address"`
+				IPv6Address string `xml:"IPv6Address"`
 				DNSname     string `xml:"DNSname"`
 			} `xml:"NTPFromDHCP"`
 			NTPManual []struct {
 				Type        string `xml:"Type"`
 				IPv4Address string `xml:"IPv4Address"`
+				IPv6Address string `xml:"IPv6Address"`
 				DNSname     string `xml:"DNSname"`
 			} `xml:"NTPManual"`
 		} `xml:"NTPInformation"`
@@ -428,21 +588,21 @@ func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 
 	var resp GetNTPResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNTP failed: %w", err)
 	}
 
 	ntp := &NTPInformation{
-		FromDHCP: resp.NTPInformation.FromDHCP,
+		FromDHCP: bool(resp.NTPInformation.FromDHCP),
 	}
 
 	for _, n := range resp.NTPInformation.NTPFromDHCP {
 		ntp.NTPFromDHCP = append(ntp.NTPFromDHCP, NetworkHost{
 			Type:        n.Type,
 			IPv4Address: n.IPv4Address,
+			IPv6Address: n.IPv6Address,
 			DNSname:     n.DNSname,
 		})
 	}
@@ -451,6 +611,7 @@ func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 		ntp.NTPManual = append(ntp.NTPManual, NetworkHost{
 			Type:        n.Type,
 			IPv4Address: n.IPv4Address,
+			IPv6Address: n.IPv6Address,
 			DNSname:     n.DNSname,
 		})
 	}
@@ -458,7 +619,9 @@ func (c *Client) GetNTP(ctx context.Context) (*NTPInformation, error) {
 	return ntp, nil
 }
 
-// GetNetworkInterfaces retrieves network interface configuration.
+// GetNetworkInterfaces retrieves the network interfaces of the device with
+// their token, enabled flag, hardware address, MTU and IPv4 configuration. IPv4
+// is nil for interfaces that report no IPv4 configuration.
 func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface, error) {
 	type GetNetworkInterfaces struct {
 		XMLName xml.Name `xml:"tds:GetNetworkInterfaces"`
@@ -469,20 +632,24 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 		XMLName           xml.Name `xml:"GetNetworkInterfacesResponse"`
 		NetworkInterfaces []struct {
 			Token   string `xml:"token,attr"`
-			Enabled bool   `xml:"Enabled"`
+			Enabled Bool   `xml:"Enabled"`
 			Info    struct {
 				Name      string `xml:"Name"`
 				HwAddress string `xml:"HwAddress"`
 				MTU       int    `xml:"MTU"`
 			} `xml:"Info"`
-			IPv4 struct {
-				Enabled bool `xml:"Enabled"`
+			IPv4 *struct {
+				Enabled Bool `xml:"Enabled"`
 				Config  struct {
 					Manual []struct {
 						Address      string `xml:"Address"`
 						PrefixLength int    `xml:"PrefixLength"`
 					} `xml:"Manual"`
-					DHCP bool `xml:"DHCP"`
+					FromDHCP *struct {
+						Address      string `xml:"Address"`
+						PrefixLength int    `xml:"PrefixLength"`
+					} `xml:"FromDHCP"`
+					DHCP Bool `xml:"DHCP"`
 				} `xml:"Config"`
 			} `xml:"IPv4"`
 		} `xml:"NetworkInterfaces"`
@@ -494,8 +661,7 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 
 	var resp GetNetworkInterfacesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNetworkInterfaces failed: %w", err)
@@ -505,7 +671,7 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 	for i, iface := range resp.NetworkInterfaces {
 		ni := &NetworkInterface{
 			Token:   iface.Token,
-			Enabled: iface.Enabled,
+			Enabled: bool(iface.Enabled),
 			Info: NetworkInterfaceInfo{
 				Name:      iface.Info.Name,
 				HwAddress: iface.Info.HwAddress,
@@ -513,11 +679,11 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 			},
 		}
 
-		if iface.IPv4.Enabled {
+		if iface.IPv4 != nil {
 			ni.IPv4 = &IPv4NetworkInterface{
-				Enabled: iface.IPv4.Enabled,
+				Enabled: bool(iface.IPv4.Enabled),
 				Config: IPv4Configuration{
-					DHCP: iface.IPv4.Config.DHCP,
+					DHCP: bool(iface.IPv4.Config.DHCP),
 				},
 			}
 
@@ -527,6 +693,13 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 					PrefixLength: m.PrefixLength,
 				})
 			}
+
+			if fromDHCP := iface.IPv4.Config.FromDHCP; fromDHCP != nil {
+				ni.IPv4.Config.FromDHCP = &PrefixedIPv4Address{
+					Address:      fromDHCP.Address,
+					PrefixLength: fromDHCP.PrefixLength,
+				}
+			}
 		}
 
 		interfaces[i] = ni
@@ -535,8 +708,128 @@ func (c *Client) GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface,
 	return interfaces, nil
 }
 
-// GetScopes retrieves configured scopes.
+// SetNetworkInterfaces changes the configuration of the network interface
+// with the given token, e.g. from DHCP to a static address by setting
+// IPv4.DHCP to false with an IPv4.Manual address, or back by setting
+// IPv4.DHCP to true. It reports whether the device needs a reboot for the
+// change to take effect, see SystemReboot. The device may become reachable
+// only at its new address; Provision handles the reconnection.
+//
+// Manual addresses must be IPv4 addresses with a prefix length of at most 32,
+// and disabling DHCP requires a manual address, since the device would
+// otherwise be left without one. Invalid configurations return
+// ErrInvalidParameter without calling the device.
+func (c *Client) SetNetworkInterfaces(
+	ctx context.Context, token string, cfg *NetworkInterfaceSetConfiguration,
+) (rebootNeeded bool, err error) {
+	if err := validateNetworkInterfaceSetConfiguration(cfg); err != nil {
+		return false, fmt.Errorf("SetNetworkInterfaces failed: %w", err)
+	}
+
+	type prefixedIPv4Address struct {
+		Address      string `xml:"tt:Address"`
+		PrefixLength int    `xml:"tt:PrefixLength"`
+	}
+
+	type SetNetworkInterfaces struct {
+		XMLName          xml.Name `xml:"tds:SetNetworkInterfaces"`
+		Xmlns            string   `xml:"xmlns:tds,attr"`
+		XmlnsTT          string   `xml:"xmlns:tt,attr"`
+		InterfaceToken   string   `xml:"tds:InterfaceToken"`
+		NetworkInterface struct {
+			Enabled *bool `xml:"tt:Enabled,omitempty"`
+			MTU     int   `xml:"tt:MTU,omitempty"`
+			IPv4    *struct {
+				Enabled *bool                 `xml:"tt:Enabled,omitempty"`
+				Manual  []prefixedIPv4Address `xml:"tt:Manual,omitempty"`
+				DHCP    *bool                 `xml:"tt:DHCP,omitempty"`
+			} `xml:"tt:IPv4,omitempty"`
+		} `xml:"tds:NetworkInterface"`
+	}
+
+	type SetNetworkInterfacesResponse struct {
+		XMLName      xml.Name `xml:"SetNetworkInterfacesResponse"`
+		RebootNeeded Bool     `xml:"RebootNeeded"`
+	}
+
+	req := SetNetworkInterfaces{
+		Xmlns:          deviceNamespace,
+		XmlnsTT:        "http://www.onvif.org/ver10/schema",
+		InterfaceToken: token,
+	}
+	req.NetworkInterface.Enabled = cfg.Enabled
+	req.NetworkInterface.MTU = cfg.MTU
+
+	if cfg.IPv4 != nil {
+		req.NetworkInterface.IPv4 = &struct {
+			Enabled *bool                 `xml:"tt:Enabled,omitempty"`
+			Manual  []prefixedIPv4Address `xml:"tt:Manual,omitempty"`
+			DHCP    *bool                 `xml:"tt:DHCP,omitempty"`
+		}{
+			Enabled: cfg.IPv4.Enabled,
+			DHCP:    cfg.IPv4.DHCP,
+		}
+
+		for _, m := range cfg.IPv4.Manual {
+			req.NetworkInterface.IPv4.Manual = append(req.NetworkInterface.IPv4.Manual, prefixedIPv4Address{
+				Address:      m.Address,
+				PrefixLength: m.PrefixLength,
+			})
+		}
+	}
+
+	var resp SetNetworkInterfacesResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+		return false, fmt.Errorf("SetNetworkInterfaces failed: %w", err)
+	}
+
+	return bool(resp.RebootNeeded), nil
+}
+
+// maxIPv4PrefixLength is the prefix length of a single IPv4 address.
+const maxIPv4PrefixLength = 32
+
+// validateNetworkInterfaceSetConfiguration checks the addresses of a network
+// interface change.
+func validateNetworkInterfaceSetConfiguration(cfg *NetworkInterfaceSetConfiguration) error {
+	if cfg == nil {
+		return fmt.Errorf("%w: no network interface configuration", ErrInvalidParameter)
+	}
+
+	ipv4 := cfg.IPv4
+	if ipv4 == nil {
+		return nil
+	}
+
+	if ipv4.DHCP != nil && !*ipv4.DHCP && len(ipv4.Manual) == 0 {
+		return fmt.Errorf("%w: disabling DHCP requires a manual IPv4 address", ErrInvalidParameter)
+	}
+
+	for _, m := range ipv4.Manual {
+		if ip := net.ParseIP(m.Address); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("%w: %q is not an IPv4 address", ErrInvalidParameter, m.Address)
+		}
+
+		if m.PrefixLength <= 0 || m.PrefixLength > maxIPv4PrefixLength {
+			return fmt.Errorf("%w: IPv4 prefix length %d out of range [1, %d]",
+				ErrInvalidParameter, m.PrefixLength, maxIPv4PrefixLength)
+		}
+	}
+
+	return nil
+}
+
+// GetScopes retrieves the scopes of the device, which WS-Discovery probes
+// match on. Only Configurable scopes can be changed.
 func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
+	return singleFlight(ctx, c, "GetScopes", c.getScopes)
+}
+
+// getScopes implements GetScopes.
+func (c *Client) getScopes(ctx context.Context) ([]*Scope, error) {
 	type GetScopes struct {
 		XMLName xml.Name `xml:"tds:GetScopes"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
@@ -545,8 +838,8 @@ func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
 	type GetScopesResponse struct {
 		XMLName xml.Name `xml:"GetScopesResponse"`
 		Scopes  []struct {
-			ScopeDef  string `xml:"ScopeDef"`
-			ScopeItem string `xml:"ScopeItem"`
+			ScopeDef  ScopeDefinition `xml:"ScopeDef"`
+			ScopeItem string          `xml:"ScopeItem"`
 		} `xml:"Scopes"`
 	}
 
@@ -556,8 +849,7 @@ func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
 
 	var resp GetScopesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetScopes failed: %w", err)
@@ -574,7 +866,8 @@ func (c *Client) GetScopes(ctx context.Context) ([]*Scope, error) {
 	return scopes, nil
 }
 
-// GetUsers retrieves user accounts.
+// GetUsers retrieves the user accounts of the device. Only the username and
+// level of each user are returned; devices never disclose passwords.
 func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 	type GetUsers struct {
 		XMLName xml.Name `xml:"tds:GetUsers"`
@@ -584,8 +877,8 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 	type GetUsersResponse struct {
 		XMLName xml.Name `xml:"GetUsersResponse"`
 		User    []struct {
-			Username  string `xml:"Username"`
-			UserLevel string `xml:"UserLevel"`
+			Username  string    `xml:"Username"`
+			UserLevel UserLevel `xml:"UserLevel"`
 		} `xml:"User"`
 	}
 
@@ -595,8 +888,7 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 
 	var resp GetUsersResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetUsers failed: %w", err)
@@ -613,15 +905,17 @@ func (c *Client) GetUsers(ctx context.Context) ([]*User, error) {
 	return users, nil
 }
 
-// CreateUsers creates new user accounts.
+// CreateUsers creates new user accounts. ErrUsernameClash is returned when
+// the device already has a user with one of the usernames, so callers can
+// update that user with SetUser instead.
 func (c *Client) CreateUsers(ctx context.Context, users []*User) error {
 	type CreateUsers struct {
 		XMLName xml.Name `xml:"tds:CreateUsers"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
 		User    []struct {
-			Username  string `xml:"tds:Username"`
-			Password  string `xml:"tds:Password"`
-			UserLevel string `xml:"tds:UserLevel"`
+			Username  string    `xml:"tds:Username"`
+			Password  string    `xml:"tds:Password"`
+			UserLevel UserLevel `xml:"tds:UserLevel"`
 		} `xml:"tds:User"`
 	}
 
@@ -631,9 +925,9 @@ func (c *Client) CreateUsers(ctx context.Context, users []*User) error {
 
 	for _, user := range users {
 		req.User = append(req.User, struct {
-			Username  string `xml:"tds:Username"`
-			Password  string `xml:"tds:Password"`
-			UserLevel string `xml:"tds:UserLevel"`
+			Username  string    `xml:"tds:Username"`
+			Password  string    `xml:"tds:Password"`
+			UserLevel UserLevel `xml:"tds:UserLevel"`
 		}{
 			Username:  user.Username,
 			Password:  user.Password,
@@ -641,10 +935,14 @@ func (c *Client) CreateUsers(ctx context.Context, users []*User) error {
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+		var fault *SOAPFault
+		if errors.As(err, &fault) && fault.HasSubcode("UsernameClash") {
+			return fmt.Errorf("CreateUsers failed: %w: %w", ErrUsernameClash, err)
+		}
+
 		return fmt.Errorf("CreateUsers failed: %w", err)
 	}
 
@@ -664,8 +962,7 @@ func (c *Client) DeleteUsers(ctx context.Context, usernames []string) error {
 		Username: usernames,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("DeleteUsers failed: %w", err)
@@ -674,29 +971,39 @@ func (c *Client) DeleteUsers(ctx context.Context, usernames []string) error {
 	return nil
 }
 
-// SetUser modifies an existing user account.
-func (c *Client) SetUser(ctx context.Context, user *User) error {
+// SetUser modifies existing user accounts, e.g. to rotate their passwords.
+// The password of a user is left unchanged when Password is empty.
+func (c *Client) SetUser(ctx context.Context, users ...*User) error {
+	type user struct {
+		Username  string    `xml:"tds:Username"`
+		Password  *string   `xml:"tds:Password,omitempty"`
+		UserLevel UserLevel `xml:"tds:UserLevel"`
+	}
+
 	type SetUser struct {
 		XMLName xml.Name `xml:"tds:SetUser"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
-		User    struct {
-			Username  string  `xml:"tds:Username"`
-			Password  *string `xml:"tds:Password,omitempty"`
-			UserLevel string  `xml:"tds:UserLevel"`
-		} `xml:"tds:User"`
+		User    []user   `xml:"tds:User"`
+	}
+
+	if len(users) == 0 {
+		return fmt.Errorf("SetUser failed: %w: no users", ErrInvalidParameter)
 	}
 
 	req := SetUser{
 		Xmlns: deviceNamespace,
 	}
-	req.User.Username = user.Username
-	if user.Password != "" {
-		req.User.Password = &user.Password
+
+	for _, u := range users {
+		entry := user{Username: u.Username, UserLevel: u.UserLevel}
+		if u.Password != "" {
+			entry.Password = &u.Password
+		}
+
+		req.User = append(req.User, entry)
 	}
-	req.User.UserLevel = user.UserLevel
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetUser failed: %w", err)
@@ -707,6 +1014,13 @@ func (c *Client) SetUser(ctx context.Context, user *User) error {
 
 // GetServices returns information about services on the device.
 func (c *Client) GetServices(ctx context.Context, includeCapability bool) ([]*Service, error) {
+	return singleFlight(ctx, c, fmt.Sprintf("GetServices/%t", includeCapability), func(ctx context.Context) ([]*Service, error) {
+		return c.getServices(ctx, includeCapability)
+	})
+}
+
+// getServices implements GetServices.
+func (c *Client) getServices(ctx context.Context, includeCapability bool) ([]*Service, error) {
 	type GetServices struct {
 		XMLName           xml.Name `xml:"tds:GetServices"`
 		Xmlns             string   `xml:"xmlns:tds,attr"`
@@ -716,10 +1030,12 @@ func (c *Client) GetServices(ctx context.Context, includeCapability bool) ([]*Se
 	type GetServicesResponse struct {
 		XMLName xml.Name `xml:"GetServicesResponse"`
 		Service []struct {
-			Namespace    string      `xml:"Namespace"`
-			XAddr        string      `xml:"XAddr"`
-			Capabilities interface{} `xml:"Capabilities"`
-			Version      struct {
+			Namespace    string `xml:"Namespace"`
+			XAddr        string `xml:"XAddr"`
+			Capabilities struct {
+				Inner []byte `xml:",innerxml"`
+			} `xml:"Capabilities"`
+			Version struct {
 				Major int `xml:"Major"`
 				Minor int `xml:"Minor"`
 			} `xml:"Version"`
@@ -733,8 +1049,7 @@ func (c *Client) GetServices(ctx context.Context, includeCapability bool) ([]*Se
 
 	var resp GetServicesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetServices failed: %w", err)
@@ -743,9 +1058,9 @@ func (c *Client) GetServices(ctx context.Context, includeCapability bool) ([]*Se
 	services := make([]*Service, len(resp.Service))
 	for i, svc := range resp.Service {
 		services[i] = &Service{
-			Namespace:    svc.Namespace,
-			XAddr:        svc.XAddr,
-			Capabilities: svc.Capabilities,
+			Namespace:       svc.Namespace,
+			XAddr:           svc.XAddr,
+			CapabilitiesXML: bytes.TrimSpace(svc.Capabilities.Inner),
 			Version: OnvifVersion{
 				Major: svc.Version.Major,
 				Minor: svc.Version.Minor,
@@ -756,7 +1071,11 @@ func (c *Client) GetServices(ctx context.Context, includeCapability bool) ([]*Se
 	return services, nil
 }
 
-// GetServiceCapabilities returns the capabilities of the device service.
+// GetServiceCapabilities returns the capabilities of the device service, which
+// tell the network, security and system features the device implements.
+// Callers can use them to gate optional operations, e.g. SetIPAddressFilter on
+// Network.IPFilter, GetDot11Status on Network.Dot11Configuration or
+// StartFirmwareUpgrade on System.HTTPFirmwareUpgrade.
 func (c *Client) GetServiceCapabilities(ctx context.Context) (*DeviceServiceCapabilities, error) {
 	type GetServiceCapabilities struct {
 		XMLName xml.Name `xml:"tds:GetServiceCapabilities"`
@@ -767,26 +1086,49 @@ func (c *Client) GetServiceCapabilities(ctx context.Context) (*DeviceServiceCapa
 		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
 		Capabilities struct {
 			Network struct {
-				IPFilter          bool `xml:"IPFilter,attr"`
-				ZeroConfiguration bool `xml:"ZeroConfiguration,attr"`
-				IPVersion6        bool `xml:"IPVersion6,attr"`
-				DynDNS            bool `xml:"DynDNS,attr"`
+				IPFilter           Bool `xml:"IPFilter,attr"`
+				ZeroConfiguration  Bool `xml:"ZeroConfiguration,attr"`
+				IPVersion6         Bool `xml:"IPVersion6,attr"`
+				DynDNS             Bool `xml:"DynDNS,attr"`
+				Dot11Configuration Bool `xml:"Dot11Configuration,attr"`
+				HostnameFromDHCP   Bool `xml:"HostnameFromDHCP,attr"`
+				NTP                int  `xml:"NTP,attr"`
+				DHCPv6             Bool `xml:"DHCPv6,attr"`
+				MaxIPFilterEntries int  `xml:"MaxIPFilterEntries,attr"`
 			} `xml:"Network"`
 			Security struct {
-				TLS10                bool `xml:"TLS1.0,attr"`
-				TLS11                bool `xml:"TLS1.1,attr"`
-				TLS12                bool `xml:"TLS1.2,attr"`
-				OnboardKeyGeneration bool `xml:"OnboardKeyGeneration,attr"`
-				AccessPolicyConfig   bool `xml:"AccessPolicyConfig,attr"`
+				TLS10                Bool `xml:"TLS1.0,attr"`
+				TLS11                Bool `xml:"TLS1.1,attr"`
+				TLS12                Bool `xml:"TLS1.2,attr"`
+				OnboardKeyGeneration Bool `xml:"OnboardKeyGeneration,attr"`
+				AccessPolicyConfig   Bool `xml:"AccessPolicyConfig,attr"`
+				DefaultAccessPolicy  Bool `xml:"DefaultAccessPolicy,attr"`
+				Dot1X                Bool `xml:"Dot1X,attr"`
+				RemoteUserHandling   Bool `xml:"RemoteUserHandling,attr"`
+				X509Token            Bool `xml:"X.509Token,attr"`
+				SAMLToken            Bool `xml:"SAMLToken,attr"`
+				KerberosToken        Bool `xml:"KerberosToken,attr"`
+				UsernameToken        Bool `xml:"UsernameToken,attr"`
+				HTTPDigest           Bool `xml:"HttpDigest,attr"`
+				RELToken             Bool `xml:"RELToken,attr"`
+				MaxUsers             int  `xml:"MaxUsers,attr"`
 			} `xml:"Security"`
 			System struct {
-				DiscoveryResolve bool `xml:"DiscoveryResolve,attr"`
-				DiscoveryBye     bool `xml:"DiscoveryBye,attr"`
-				RemoteDiscovery  bool `xml:"RemoteDiscovery,attr"`
-				SystemBackup     bool `xml:"SystemBackup,attr"`
-				SystemLogging    bool `xml:"SystemLogging,attr"`
-				FirmwareUpgrade  bool `xml:"FirmwareUpgrade,attr"`
+				DiscoveryResolve       Bool `xml:"DiscoveryResolve,attr"`
+				DiscoveryBye           Bool `xml:"DiscoveryBye,attr"`
+				RemoteDiscovery        Bool `xml:"RemoteDiscovery,attr"`
+				SystemBackup           Bool `xml:"SystemBackup,attr"`
+				SystemLogging          Bool `xml:"SystemLogging,attr"`
+				FirmwareUpgrade        Bool `xml:"FirmwareUpgrade,attr"`
+				HTTPFirmwareUpgrade    Bool `xml:"HttpFirmwareUpgrade,attr"`
+				HTTPSystemBackup       Bool `xml:"HttpSystemBackup,attr"`
+				HTTPSystemLogging      Bool `xml:"HttpSystemLogging,attr"`
+				HTTPSupportInformation Bool `xml:"HttpSupportInformation,attr"`
+				StorageConfiguration   Bool `xml:"StorageConfiguration,attr"`
 			} `xml:"System"`
+			Misc struct {
+				AuxiliaryCommands string `xml:"AuxiliaryCommands,attr"`
+			} `xml:"Misc"`
 		} `xml:"Capabilities"`
 	}
 
@@ -796,33 +1138,60 @@ func (c *Client) GetServiceCapabilities(ctx context.Context) (*DeviceServiceCapa
 
 	var resp GetServiceCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if !c.cachedCapabilities(deviceNamespace, &resp.Capabilities) {
+		soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+		if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+			return nil, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+		}
 	}
 
+	network, security, system := resp.Capabilities.Network, resp.Capabilities.Security, resp.Capabilities.System
+
 	return &DeviceServiceCapabilities{
 		Network: &NetworkCapabilities{
-			IPFilter:          resp.Capabilities.Network.IPFilter,
-			ZeroConfiguration: resp.Capabilities.Network.ZeroConfiguration,
-			IPVersion6:        resp.Capabilities.Network.IPVersion6,
-			DynDNS:            resp.Capabilities.Network.DynDNS,
+			IPFilter:           bool(network.IPFilter),
+			ZeroConfiguration:  bool(network.ZeroConfiguration),
+			IPVersion6:         bool(network.IPVersion6),
+			DynDNS:             bool(network.DynDNS),
+			MaxIPFilterEntries: network.MaxIPFilterEntries,
+			Dot11Configuration: bool(network.Dot11Configuration),
+			HostnameFromDHCP:   bool(network.HostnameFromDHCP),
+			DHCPv6:             bool(network.DHCPv6),
+			NTP:                network.NTP,
 		},
 		Security: &SecurityCapabilities{
-			TLS11:                resp.Capabilities.Security.TLS11,
-			TLS12:                resp.Capabilities.Security.TLS12,
-			OnboardKeyGeneration: resp.Capabilities.Security.OnboardKeyGeneration,
-			AccessPolicyConfig:   resp.Capabilities.Security.AccessPolicyConfig,
+			TLS10:                bool(security.TLS10),
+			TLS11:                bool(security.TLS11),
+			TLS12:                bool(security.TLS12),
+			OnboardKeyGeneration: bool(security.OnboardKeyGeneration),
+			AccessPolicyConfig:   bool(security.AccessPolicyConfig),
+			DefaultAccessPolicy:  bool(security.DefaultAccessPolicy),
+			Dot1X:                bool(security.Dot1X),
+			RemoteUserHandling:   bool(security.RemoteUserHandling),
+			X509Token:            bool(security.X509Token),
+			SAMLToken:            bool(security.SAMLToken),
+			KerberosToken:        bool(security.KerberosToken),
+			UsernameToken:        bool(security.UsernameToken),
+			HTTPDigest:           bool(security.HTTPDigest),
+			RELToken:             bool(security.RELToken),
+			MaxUsers:             security.MaxUsers,
 		},
 		System: &SystemCapabilities{
-			DiscoveryResolve: resp.Capabilities.System.DiscoveryResolve,
-			DiscoveryBye:     resp.Capabilities.System.DiscoveryBye,
-			RemoteDiscovery:  resp.Capabilities.System.RemoteDiscovery,
-			SystemBackup:     resp.Capabilities.System.SystemBackup,
-			SystemLogging:    resp.Capabilities.System.SystemLogging,
-			FirmwareUpgrade:  resp.Capabilities.System.FirmwareUpgrade,
+			DiscoveryResolve:       bool(system.DiscoveryResolve),
+			DiscoveryBye:           bool(system.DiscoveryBye),
+			RemoteDiscovery:        bool(system.RemoteDiscovery),
+			SystemBackup:           bool(system.SystemBackup),
+			SystemLogging:          bool(system.SystemLogging),
+			FirmwareUpgrade:        bool(system.FirmwareUpgrade),
+			HTTPFirmwareUpgrade:    bool(system.HTTPFirmwareUpgrade),
+			HTTPSystemBackup:       bool(system.HTTPSystemBackup),
+			HTTPSystemLogging:      bool(system.HTTPSystemLogging),
+			HTTPSupportInformation: bool(system.HTTPSupportInformation),
+			StorageConfiguration:   bool(system.StorageConfiguration),
+		},
+		Misc: &MiscCapabilities{
+			AuxiliaryCommands: strings.Fields(resp.Capabilities.Misc.AuxiliaryCommands),
 		},
 	}, nil
 }
@@ -845,8 +1214,7 @@ func (c *Client) GetDiscoveryMode(ctx context.Context) (DiscoveryMode, error) {
 
 	var resp GetDiscoveryModeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("GetDiscoveryMode failed: %w", err)
@@ -868,8 +1236,7 @@ func (c *Client) SetDiscoveryMode(ctx context.Context, mode DiscoveryMode) error
 		DiscoveryMode: mode,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetDiscoveryMode failed: %w", err)
@@ -896,8 +1263,7 @@ func (c *Client) GetRemoteDiscoveryMode(ctx context.Context) (DiscoveryMode, err
 
 	var resp GetRemoteDiscoveryModeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("GetRemoteDiscoveryMode failed: %w", err)
@@ -919,8 +1285,7 @@ func (c *Client) SetRemoteDiscoveryMode(ctx context.Context, mode DiscoveryMode)
 		RemoteDiscoveryMode: mode,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetRemoteDiscoveryMode failed: %w", err)
@@ -947,8 +1312,7 @@ func (c *Client) GetEndpointReference(ctx context.Context) (string, error) {
 
 	var resp GetEndpointReferenceResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("GetEndpointReference failed: %w", err)
@@ -968,7 +1332,7 @@ func (c *Client) GetNetworkProtocols(ctx context.Context) ([]*NetworkProtocol, e
 		XMLName          xml.Name `xml:"GetNetworkProtocolsResponse"`
 		NetworkProtocols []struct {
 			Name    string `xml:"Name"`
-			Enabled bool   `xml:"Enabled"`
+			Enabled Bool   `xml:"Enabled"`
 			Port    []int  `xml:"Port"`
 		} `xml:"NetworkProtocols"`
 	}
@@ -979,8 +1343,7 @@ func (c *Client) GetNetworkProtocols(ctx context.Context) ([]*NetworkProtocol, e
 
 	var resp GetNetworkProtocolsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNetworkProtocols failed: %w", err)
@@ -990,7 +1353,7 @@ func (c *Client) GetNetworkProtocols(ctx context.Context) ([]*NetworkProtocol, e
 	for i, proto := range resp.NetworkProtocols {
 		protocols[i] = &NetworkProtocol{
 			Name:    NetworkProtocolType(proto.Name),
-			Enabled: proto.Enabled,
+			Enabled: bool(proto.Enabled),
 			Port:    proto.Port,
 		}
 	}
@@ -1026,8 +1389,7 @@ func (c *Client) SetNetworkProtocols(ctx context.Context, protocols []*NetworkPr
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetNetworkProtocols failed: %w", err)
@@ -1057,8 +1419,7 @@ func (c *Client) GetNetworkDefaultGateway(ctx context.Context) (*NetworkGateway,
 
 	var resp GetNetworkDefaultGatewayResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetNetworkDefaultGateway failed: %w", err)
@@ -1085,8 +1446,7 @@ func (c *Client) SetNetworkDefaultGateway(ctx context.Context, gateway *NetworkG
 		IPv6Address: gateway.IPv6Address,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetNetworkDefaultGateway failed: %w", err)
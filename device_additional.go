@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // GetGeoLocation retrieves geographic location information. ONVIF Specification: GetGeoLocation operation.
@@ -25,8 +23,7 @@ func (c *Client) GetGeoLocation(ctx context.Context) ([]LocationEntity, error) {
 	}
 	var response GetGeoLocationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetGeoLocation failed: %w", err)
@@ -53,8 +50,7 @@ func (c *Client) SetGeoLocation(ctx context.Context, location []LocationEntity)
 	}
 	var response SetGeoLocationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetGeoLocation failed: %w", err)
@@ -81,8 +77,7 @@ func (c *Client) DeleteGeoLocation(ctx context.Context, location []LocationEntit
 	}
 	var response DeleteGeoLocationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("DeleteGeoLocation failed: %w", err)
@@ -108,8 +103,7 @@ func (c *Client) GetDPAddresses(ctx context.Context) ([]NetworkHost, error) {
 	}
 	var response GetDPAddressesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetDPAddresses failed: %w", err)
@@ -136,8 +130,7 @@ func (c *Client) SetDPAddresses(ctx context.Context, dpAddress []NetworkHost) er
 	}
 	var response SetDPAddressesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetDPAddresses failed: %w", err)
@@ -163,8 +156,7 @@ func (c *Client) GetAccessPolicy(ctx context.Context) (*AccessPolicy, error) {
 	}
 	var response GetAccessPolicyResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetAccessPolicy failed: %w", err)
@@ -191,8 +183,7 @@ func (c *Client) SetAccessPolicy(ctx context.Context, policy *AccessPolicy) erro
 	}
 	var response SetAccessPolicyResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetAccessPolicy failed: %w", err)
@@ -202,6 +193,7 @@ func (c *Client) SetAccessPolicy(ctx context.Context, policy *AccessPolicy) erro
 }
 
 // GetWsdlURL retrieves the WSDL URL (deprecated). ONVIF Specification: GetWsdlUrl operation.
+// Like GetSystemDateAndTime, the request is sent without authentication first.
 func (c *Client) GetWsdlURL(ctx context.Context) (string, error) {
 	type GetWsdlURLBody struct {
 		XMLName xml.Name `xml:"tds:GetWsdlUrl"`
@@ -218,10 +210,7 @@ func (c *Client) GetWsdlURL(ctx context.Context) (string, error) {
 	}
 	var response GetWsdlURLResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
-
-	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
+	if err := c.callWithoutAuth(ctx, c.endpoint, request, &response); err != nil {
 		return "", fmt.Errorf("GetWsdlURL failed: %w", err)
 	}
 
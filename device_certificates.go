@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // GetCertificates retrieves certificates. ONVIF Specification: GetCertificates operation.
@@ -25,8 +23,7 @@ func (c *Client) GetCertificates(ctx context.Context) ([]*Certificate, error) {
 	}
 	var response GetCertificatesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetCertificates failed: %w", err)
@@ -52,8 +49,7 @@ func (c *Client) GetCACertificates(ctx context.Context) ([]*Certificate, error)
 	}
 	var response GetCACertificatesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetCACertificates failed: %w", err)
@@ -80,8 +76,7 @@ func (c *Client) LoadCertificates(ctx context.Context, certificates []*Certifica
 	}
 	var response LoadCertificatesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("LoadCertificates failed: %w", err)
@@ -108,8 +103,7 @@ func (c *Client) LoadCACertificates(ctx context.Context, certificates []*Certifi
 	}
 	var response LoadCACertificatesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("LoadCACertificates failed: %w", err)
@@ -146,8 +140,7 @@ func (c *Client) CreateCertificate(
 	}
 	var response CreateCertificateResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("CreateCertificate failed: %w", err)
@@ -174,8 +167,7 @@ func (c *Client) DeleteCertificates(ctx context.Context, certificateIDs []string
 	}
 	var response DeleteCertificatesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("DeleteCertificates failed: %w", err)
@@ -204,8 +196,7 @@ func (c *Client) GetCertificateInformation(ctx context.Context, certificateID st
 	}
 	var response GetCertificateInformationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetCertificateInformation failed: %w", err)
@@ -231,8 +222,7 @@ func (c *Client) GetCertificatesStatus(ctx context.Context) ([]*CertificateStatu
 	}
 	var response GetCertificatesStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetCertificatesStatus failed: %w", err)
@@ -259,8 +249,7 @@ func (c *Client) SetCertificatesStatus(ctx context.Context, statuses []*Certific
 	}
 	var response SetCertificatesStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetCertificatesStatus failed: %w", err)
@@ -296,8 +285,7 @@ func (c *Client) GetPkcs10Request(
 	}
 	var response GetPkcs10RequestResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetPkcs10Request failed: %w", err)
@@ -350,8 +338,7 @@ func (c *Client) LoadCertificateWithPrivateKey(
 
 	var response LoadCertificateWithPrivateKeyResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("LoadCertificateWithPrivateKey failed: %w", err)
@@ -370,7 +357,7 @@ func (c *Client) GetClientCertificateMode(ctx context.Context) (bool, error) {
 
 	type GetClientCertificateModeResponse struct {
 		XMLName xml.Name `xml:"GetClientCertificateModeResponse"`
-		Enabled bool     `xml:"Enabled"`
+		Enabled Bool     `xml:"Enabled"`
 	}
 
 	request := GetClientCertificateModeBody{
@@ -378,14 +365,13 @@ func (c *Client) GetClientCertificateMode(ctx context.Context) (bool, error) {
 	}
 	var response GetClientCertificateModeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return false, fmt.Errorf("GetClientCertificateMode failed: %w", err)
 	}
 
-	return response.Enabled, nil
+	return bool(response.Enabled), nil
 }
 
 // SetClientCertificateMode sets the client certificate mode. ONVIF Specification: SetClientCertificateMode operation.
@@ -406,8 +392,7 @@ func (c *Client) SetClientCertificateMode(ctx context.Context, enabled bool) err
 	}
 	var response SetClientCertificateModeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetClientCertificateMode failed: %w", err)
@@ -3,45 +3,62 @@ package onvif
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"net"
+	"strings"
 )
 
-// SetDNS sets the DNS settings on a device.
+// SetDNS sets the DNS settings on a device. With fromDHCP the device uses the
+// DNS servers its DHCP server offers, otherwise dnsManual. The Type of each
+// server may be left empty; it is then derived from the address. Invalid
+// addresses return ErrInvalidParameter without calling the device.
 func (c *Client) SetDNS(ctx context.Context, fromDHCP bool, searchDomain []string, dnsManual []IPAddress) error {
+	type ipAddress struct {
+		Type        string `xml:"tt:Type"`
+		IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+		IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+	}
+
 	type SetDNS struct {
-		XMLName      xml.Name `xml:"tds:SetDNS"`
-		Xmlns        string   `xml:"xmlns:tds,attr"`
-		FromDHCP     bool     `xml:"tds:FromDHCP"`
-		SearchDomain []string `xml:"tds:SearchDomain,omitempty"`
-		DNSManual    []struct {
-			Type        string `xml:"tds:Type"`
-			IPv4Address string `xml:"tds:IPv4Address,omitempty"`
-			IPv6Address string `xml:"tds:IPv6Address,omitempty"`
-		} `xml:"tds:DNSManual,omitempty"`
+		XMLName      xml.Name    `xml:"tds:SetDNS"`
+		Xmlns        string      `xml:"xmlns:tds,attr"`
+		XmlnsTT      string      `xml:"xmlns:tt,attr"`
+		FromDHCP     bool        `xml:"tds:FromDHCP"`
+		SearchDomain []string    `xml:"tds:SearchDomain,omitempty"`
+		DNSManual    []ipAddress `xml:"tds:DNSManual,omitempty"`
 	}
 
 	req := SetDNS{
 		Xmlns:        deviceNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		FromDHCP:     fromDHCP,
 		SearchDomain: searchDomain,
 	}
 
 	for _, dns := range dnsManual {
-		req.DNSManual = append(req.DNSManual, struct {
-			Type        string `xml:"tds:Type"`
-			IPv4Address string `xml:"tds:IPv4Address,omitempty"`
-			IPv6Address string `xml:"tds:IPv6Address,omitempty"`
-		}{
-			Type:        dns.Type,
-			IPv4Address: dns.IPv4Address,
-			IPv6Address: dns.IPv6Address,
+		ipv4 := dns.IPv4Address
+		if ipv4 == "" && dns.IPv6Address == "" {
+			ipv4 = dns.Address
+		}
+
+		host, err := toNetworkHost(NetworkHost{Type: dns.Type, IPv4Address: ipv4, IPv6Address: dns.IPv6Address})
+		if err != nil {
+			return fmt.Errorf("SetDNS failed: %w", err)
+		}
+
+		if host.Type == NetworkHostDNS {
+			return fmt.Errorf("SetDNS failed: %w: DNS servers must be IP addresses", ErrInvalidParameter)
+		}
+
+		req.DNSManual = append(req.DNSManual, ipAddress{
+			Type:        host.Type,
+			IPv4Address: host.IPv4Address,
+			IPv6Address: host.IPv6Address,
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetDNS failed: %w", err)
@@ -50,41 +67,43 @@ func (c *Client) SetDNS(ctx context.Context, fromDHCP bool, searchDomain []strin
 	return nil
 }
 
-// SetNTP sets the NTP settings on a device.
+// SetNTP sets the NTP settings on a device. With fromDHCP the device uses the
+// NTP servers its DHCP server offers, otherwise ntpManual, which may name
+// servers by IPv4 or IPv6 address or by DNS name. The Type of each server may
+// be left empty; it is then derived from the address. Invalid addresses return
+// ErrInvalidParameter without calling the device.
 func (c *Client) SetNTP(ctx context.Context, fromDHCP bool, ntpManual []NetworkHost) error {
+	type networkHost struct {
+		Type        string `xml:"tt:Type"`
+		IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+		IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+		DNSname     string `xml:"tt:DNSname,omitempty"`
+	}
+
 	type SetNTP struct {
-		XMLName   xml.Name `xml:"tds:SetNTP"`
-		Xmlns     string   `xml:"xmlns:tds,attr"`
-		FromDHCP  bool     `xml:"tds:FromDHCP"`
-		NTPManual []struct {
-			Type        string `xml:"tds:Type"`
-			IPv4Address string `xml:"tds:IPv4Address,omitempty"`
-			IPv6Address string `xml:"tds:IPv6Address,omitempty"`
-			DNSname     string `xml:"tds:DNSname,omitempty"`
-		} `xml:"tds:NTPManual,omitempty"`
+		XMLName   xml.Name      `xml:"tds:SetNTP"`
+		Xmlns     string        `xml:"xmlns:tds,attr"`
+		XmlnsTT   string        `xml:"xmlns:tt,attr"`
+		FromDHCP  bool          `xml:"tds:FromDHCP"`
+		NTPManual []networkHost `xml:"tds:NTPManual,omitempty"`
 	}
 
 	req := SetNTP{
 		Xmlns:    deviceNamespace,
+		XmlnsTT:  "http://www.onvif.org/ver10/schema",
 		FromDHCP: fromDHCP,
 	}
 
 	for _, ntp := range ntpManual {
-		req.NTPManual = append(req.NTPManual, struct {
-			Type        string `xml:"tds:Type"`
-			IPv4Address string `xml:"tds:IPv4Address,omitempty"`
-			IPv6Address string `xml:"tds:IPv6Address,omitempty"`
-			DNSname     string `xml:"tds:DNSname,omitempty"`
-		}{
-			Type:        ntp.Type,
-			IPv4Address: ntp.IPv4Address,
-			IPv6Address: ntp.IPv6Address,
-			DNSname:     ntp.DNSname,
-		})
+		host, err := toNetworkHost(ntp)
+		if err != nil {
+			return fmt.Errorf("SetNTP failed: %w", err)
+		}
+
+		req.NTPManual = append(req.NTPManual, networkHost(host))
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetNTP failed: %w", err)
@@ -93,7 +112,51 @@ func (c *Client) SetNTP(ctx context.Context, fromDHCP bool, ntpManual []NetworkH
 	return nil
 }
 
+// toNetworkHost checks a network host and returns it with its Type set from
+// the address if it was empty.
+func toNetworkHost(host NetworkHost) (NetworkHost, error) {
+	if host.Type == "" {
+		switch {
+		case host.IPv4Address != "":
+			host.Type = NetworkHostIPv4
+		case host.IPv6Address != "":
+			host.Type = NetworkHostIPv6
+		default:
+			host.Type = NetworkHostDNS
+		}
+	}
+
+	// Only the address matching the type is sent.
+	checked := NetworkHost{Type: host.Type}
+
+	switch host.Type {
+	case NetworkHostIPv4:
+		if ip := net.ParseIP(host.IPv4Address); ip == nil || ip.To4() == nil {
+			return checked, fmt.Errorf("%w: %q is not an IPv4 address", ErrInvalidParameter, host.IPv4Address)
+		}
+
+		checked.IPv4Address = host.IPv4Address
+	case NetworkHostIPv6:
+		if ip := net.ParseIP(host.IPv6Address); ip == nil || ip.To4() != nil {
+			return checked, fmt.Errorf("%w: %q is not an IPv6 address", ErrInvalidParameter, host.IPv6Address)
+		}
+
+		checked.IPv6Address = host.IPv6Address
+	case NetworkHostDNS:
+		if host.DNSname == "" {
+			return checked, fmt.Errorf("%w: DNS host without name", ErrInvalidParameter)
+		}
+
+		checked.DNSname = host.DNSname
+	default:
+		return checked, fmt.Errorf("%w: unknown host type %q", ErrInvalidParameter, host.Type)
+	}
+
+	return checked, nil
+}
+
 // SetHostnameFromDHCP controls whether the hostname is set manually or retrieved via DHCP.
+// It reports whether the device needs a reboot for the change to take effect, see SystemReboot.
 func (c *Client) SetHostnameFromDHCP(ctx context.Context, fromDHCP bool) (bool, error) {
 	type SetHostnameFromDHCP struct {
 		XMLName  xml.Name `xml:"tds:SetHostnameFromDHCP"`
@@ -103,7 +166,7 @@ func (c *Client) SetHostnameFromDHCP(ctx context.Context, fromDHCP bool) (bool,
 
 	type SetHostnameFromDHCPResponse struct {
 		XMLName      xml.Name `xml:"SetHostnameFromDHCPResponse"`
-		RebootNeeded bool     `xml:"RebootNeeded"`
+		RebootNeeded Bool     `xml:"RebootNeeded"`
 	}
 
 	req := SetHostnameFromDHCP{
@@ -113,165 +176,98 @@ func (c *Client) SetHostnameFromDHCP(ctx context.Context, fromDHCP bool) (bool,
 
 	var resp SetHostnameFromDHCPResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return false, fmt.Errorf("SetHostnameFromDHCP failed: %w", err)
 	}
 
-	return resp.RebootNeeded, nil
+	return bool(resp.RebootNeeded), nil
 }
 
-// FixedGetSystemDateAndTime retrieves the device's system date and time with proper typing.
+// FixedGetSystemDateAndTime retrieves the device's system date and time.
+//
+// Deprecated: use GetSystemDateAndTime, which returns the same typed result.
 func (c *Client) FixedGetSystemDateAndTime(ctx context.Context) (*SystemDateTime, error) {
-	type GetSystemDateAndTime struct {
-		XMLName xml.Name `xml:"tds:GetSystemDateAndTime"`
-		Xmlns   string   `xml:"xmlns:tds,attr"`
-	}
-
-	type GetSystemDateAndTimeResponse struct {
-		XMLName           xml.Name `xml:"GetSystemDateAndTimeResponse"`
-		SystemDateAndTime struct {
-			DateTimeType    string `xml:"DateTimeType"`
-			DaylightSavings bool   `xml:"DaylightSavings"`
-			TimeZone        struct {
-				TZ string `xml:"TZ"`
-			} `xml:"TimeZone"`
-			UTCDateTime struct {
-				Time struct {
-					Hour   int `xml:"Hour"`
-					Minute int `xml:"Minute"`
-					Second int `xml:"Second"`
-				} `xml:"Time"`
-				Date struct {
-					Year  int `xml:"Year"`
-					Month int `xml:"Month"`
-					Day   int `xml:"Day"`
-				} `xml:"Date"`
-			} `xml:"UTCDateTime"`
-			LocalDateTime struct {
-				Time struct {
-					Hour   int `xml:"Hour"`
-					Minute int `xml:"Minute"`
-					Second int `xml:"Second"`
-				} `xml:"Time"`
-				Date struct {
-					Year  int `xml:"Year"`
-					Month int `xml:"Month"`
-					Day   int `xml:"Day"`
-				} `xml:"Date"`
-			} `xml:"LocalDateTime"`
-		} `xml:"SystemDateAndTime"`
-	}
-
-	req := GetSystemDateAndTime{
-		Xmlns: deviceNamespace,
-	}
-
-	var resp GetSystemDateAndTimeResponse
-
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
-
-	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetSystemDateAndTime failed: %w", err)
-	}
-
-	return &SystemDateTime{
-		DateTimeType:    SetDateTimeType(resp.SystemDateAndTime.DateTimeType),
-		DaylightSavings: resp.SystemDateAndTime.DaylightSavings,
-		TimeZone: &TimeZone{
-			TZ: resp.SystemDateAndTime.TimeZone.TZ,
-		},
-		UTCDateTime: &DateTime{
-			Time: Time{
-				Hour:   resp.SystemDateAndTime.UTCDateTime.Time.Hour,
-				Minute: resp.SystemDateAndTime.UTCDateTime.Time.Minute,
-				Second: resp.SystemDateAndTime.UTCDateTime.Time.Second,
-			},
-			Date: Date{
-				Year:  resp.SystemDateAndTime.UTCDateTime.Date.Year,
-				Month: resp.SystemDateAndTime.UTCDateTime.Date.Month,
-				Day:   resp.SystemDateAndTime.UTCDateTime.Date.Day,
-			},
-		},
-		LocalDateTime: &DateTime{
-			Time: Time{
-				Hour:   resp.SystemDateAndTime.LocalDateTime.Time.Hour,
-				Minute: resp.SystemDateAndTime.LocalDateTime.Time.Minute,
-				Second: resp.SystemDateAndTime.LocalDateTime.Time.Second,
-			},
-			Date: Date{
-				Year:  resp.SystemDateAndTime.LocalDateTime.Date.Year,
-				Month: resp.SystemDateAndTime.LocalDateTime.Date.Month,
-				Day:   resp.SystemDateAndTime.LocalDateTime.Date.Day,
-			},
-		},
-	}, nil
+	return c.GetSystemDateAndTime(ctx)
 }
 
-// SetSystemDateAndTime sets the device system date and time.
+// SetSystemDateAndTime sets how the device clock is kept, its time zone and
+// whether daylight saving time is in effect. With SetDateTimeManual the clock
+// is set to dateTime.UTCDateTime, which is required, see NewDateTime. With
+// SetDateTimeNTP the device takes the time from its NTP servers and
+// UTCDateTime is not sent, so a SystemDateTime read with
+// GetSystemDateAndTime can be changed and passed back. A nil TimeZone leaves
+// the time zone unchanged. Other modes, or a manual mode without a time,
+// return ErrInvalidParameter.
 func (c *Client) SetSystemDateAndTime(ctx context.Context, dateTime *SystemDateTime) error {
+	type dateTimeRequest struct {
+		Time struct {
+			Hour   int `xml:"tt:Hour"`
+			Minute int `xml:"tt:Minute"`
+			Second int `xml:"tt:Second"`
+		} `xml:"tt:Time"`
+		Date struct {
+			Year  int `xml:"tt:Year"`
+			Month int `xml:"tt:Month"`
+			Day   int `xml:"tt:Day"`
+		} `xml:"tt:Date"`
+	}
+
 	type SetSystemDateAndTime struct {
 		XMLName         xml.Name `xml:"tds:SetSystemDateAndTime"`
 		Xmlns           string   `xml:"xmlns:tds,attr"`
+		XmlnsTT         string   `xml:"xmlns:tt,attr"`
 		DateTimeType    string   `xml:"tds:DateTimeType"`
 		DaylightSavings bool     `xml:"tds:DaylightSavings"`
 		TimeZone        *struct {
-			TZ string `xml:"tds:TZ"`
+			TZ string `xml:"tt:TZ"`
 		} `xml:"tds:TimeZone,omitempty"`
-		UTCDateTime *struct {
-			Time struct {
-				Hour   int `xml:"tt:Hour"`
-				Minute int `xml:"tt:Minute"`
-				Second int `xml:"tt:Second"`
-			} `xml:"tt:Time"`
-			Date struct {
-				Year  int `xml:"tt:Year"`
-				Month int `xml:"tt:Month"`
-				Day   int `xml:"tt:Day"`
-			} `xml:"tt:Date"`
-		} `xml:"tds:UTCDateTime,omitempty"`
+		UTCDateTime *dateTimeRequest `xml:"tds:UTCDateTime,omitempty"`
+	}
+
+	if dateTime == nil {
+		return fmt.Errorf("SetSystemDateAndTime failed: %w: no date and time settings", ErrInvalidParameter)
 	}
 
 	req := SetSystemDateAndTime{
 		Xmlns:           deviceNamespace,
+		XmlnsTT:         "http://www.onvif.org/ver10/schema",
 		DateTimeType:    string(dateTime.DateTimeType),
 		DaylightSavings: dateTime.DaylightSavings,
 	}
 
 	if dateTime.TimeZone != nil {
 		req.TimeZone = &struct {
-			TZ string `xml:"tds:TZ"`
+			TZ string `xml:"tt:TZ"`
 		}{
 			TZ: dateTime.TimeZone.TZ,
 		}
 	}
 
-	if dateTime.UTCDateTime != nil {
-		req.UTCDateTime = &struct {
-			Time struct {
-				Hour   int `xml:"tt:Hour"`
-				Minute int `xml:"tt:Minute"`
-				Second int `xml:"tt:Second"`
-			} `xml:"tt:Time"`
-			Date struct {
-				Year  int `xml:"tt:Year"`
-				Month int `xml:"tt:Month"`
-				Day   int `xml:"tt:Day"`
-			} `xml:"tt:Date"`
-		}{}
-		req.UTCDateTime.Time.Hour = dateTime.UTCDateTime.Time.Hour
-		req.UTCDateTime.Time.Minute = dateTime.UTCDateTime.Time.Minute
-		req.UTCDateTime.Time.Second = dateTime.UTCDateTime.Time.Second
-		req.UTCDateTime.Date.Year = dateTime.UTCDateTime.Date.Year
-		req.UTCDateTime.Date.Month = dateTime.UTCDateTime.Date.Month
-		req.UTCDateTime.Date.Day = dateTime.UTCDateTime.Date.Day
-	}
-
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	switch dateTime.DateTimeType {
+	case SetDateTimeManual:
+		utc := dateTime.UTCDateTime
+		if utc == nil || utc.Date.Year == 0 {
+			return fmt.Errorf("SetSystemDateAndTime failed: %w: manual mode requires a UTC date and time",
+				ErrInvalidParameter)
+		}
+
+		req.UTCDateTime = &dateTimeRequest{}
+		req.UTCDateTime.Time.Hour = utc.Time.Hour
+		req.UTCDateTime.Time.Minute = utc.Time.Minute
+		req.UTCDateTime.Time.Second = utc.Time.Second
+		req.UTCDateTime.Date.Year = utc.Date.Year
+		req.UTCDateTime.Date.Month = utc.Date.Month
+		req.UTCDateTime.Date.Day = utc.Date.Day
+	case SetDateTimeNTP:
+		// The device takes the time from NTP.
+	default:
+		return fmt.Errorf("SetSystemDateAndTime failed: %w: date and time type %q, want %q or %q",
+			ErrInvalidParameter, dateTime.DateTimeType, SetDateTimeManual, SetDateTimeNTP)
+	}
+
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetSystemDateAndTime failed: %w", err)
@@ -280,7 +276,8 @@ func (c *Client) SetSystemDateAndTime(ctx context.Context, dateTime *SystemDateT
 	return nil
 }
 
-// AddScopes adds new configurable scope parameters to a device.
+// AddScopes adds configurable scopes to the device, e.g.
+// "onvif://www.onvif.org/location/building/floor1".
 func (c *Client) AddScopes(ctx context.Context, scopeItems []string) error {
 	type AddScopes struct {
 		XMLName   xml.Name `xml:"tds:AddScopes"`
@@ -293,8 +290,7 @@ func (c *Client) AddScopes(ctx context.Context, scopeItems []string) error {
 		ScopeItem: scopeItems,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddScopes failed: %w", err)
@@ -303,7 +299,10 @@ func (c *Client) AddScopes(ctx context.Context, scopeItems []string) error {
 	return nil
 }
 
-// RemoveScopes deletes scope-configurable scope parameters from a device.
+// RemoveScopes removes configurable scopes from the device and returns the
+// scopes the device reports as removed. The device fault is returned, wrapping
+// ErrFixedScope, when one of the scopes is Fixed; a scope the device does not
+// have matches ErrNotFound.
 func (c *Client) RemoveScopes(ctx context.Context, scopeItems []string) ([]string, error) {
 	type RemoveScopes struct {
 		XMLName   xml.Name `xml:"tds:RemoveScopes"`
@@ -323,17 +322,17 @@ func (c *Client) RemoveScopes(ctx context.Context, scopeItems []string) ([]strin
 
 	var resp RemoveScopesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("RemoveScopes failed: %w", err)
+		return nil, fmt.Errorf("RemoveScopes failed: %w", scopeError(err))
 	}
 
 	return resp.ScopeItem, nil
 }
 
-// SetScopes sets the scope parameters of a device.
+// SetScopes replaces all configurable scopes of the device. Fixed scopes
+// cannot be overwritten; the device fault then wraps ErrFixedScope.
 func (c *Client) SetScopes(ctx context.Context, scopes []string) error {
 	type SetScopes struct {
 		XMLName xml.Name `xml:"tds:SetScopes"`
@@ -346,16 +345,26 @@ func (c *Client) SetScopes(ctx context.Context, scopes []string) error {
 		Scopes: scopes,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
-		return fmt.Errorf("SetScopes failed: %w", err)
+		return fmt.Errorf("SetScopes failed: %w", scopeError(err))
 	}
 
 	return nil
 }
 
+// scopeError wraps ErrFixedScope around the faults devices return when asked
+// to change a Fixed scope.
+func scopeError(err error) error {
+	var fault *SOAPFault
+	if errors.As(err, &fault) && (fault.HasSubcode("FixedScope") || fault.HasSubcode("ScopeOverwrite")) {
+		return fmt.Errorf("%w: %w", ErrFixedScope, err)
+	}
+
+	return err
+}
+
 // GetRelayOutputs gets a list of all available relay outputs and their settings.
 func (c *Client) GetRelayOutputs(ctx context.Context) ([]*RelayOutput, error) {
 	type GetRelayOutputs struct {
@@ -381,8 +390,7 @@ func (c *Client) GetRelayOutputs(ctx context.Context) ([]*RelayOutput, error) {
 
 	var resp GetRelayOutputsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetRelayOutputs failed: %w", err)
@@ -424,8 +432,7 @@ func (c *Client) SetRelayOutputSettings(ctx context.Context, token string, setti
 	req.Properties.IdleState = string(settings.IdleState)
 	// DelayTime would need duration formatting
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetRelayOutputSettings failed: %w", err)
@@ -449,8 +456,7 @@ func (c *Client) SetRelayOutputState(ctx context.Context, token string, state Re
 		LogicalState:     state,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetRelayOutputState failed: %w", err)
@@ -479,8 +485,7 @@ func (c *Client) SendAuxiliaryCommand(ctx context.Context, command AuxiliaryData
 
 	var resp SendAuxiliaryCommandResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("SendAuxiliaryCommand failed: %w", err)
@@ -514,8 +519,7 @@ func (c *Client) GetSystemLog(ctx context.Context, logType SystemLogType) (*Syst
 
 	var resp GetSystemLogResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSystemLog failed: %w", err)
@@ -534,7 +538,10 @@ func (c *Client) GetSystemLog(ctx context.Context, logType SystemLogType) (*Syst
 	return systemLog, nil
 }
 
-// GetSystemBackup retrieves system backup configuration files from a device.
+// GetSystemBackup retrieves the system backup configuration files from a
+// device. The file contents are decoded from base64 into Data as the response
+// is read, so only the decoded files are held in memory; devices that send
+// them as MTOM attachments only fill Include.
 func (c *Client) GetSystemBackup(ctx context.Context) ([]*BackupFile, error) {
 	type GetSystemBackup struct {
 		XMLName xml.Name `xml:"tds:GetSystemBackup"`
@@ -542,13 +549,8 @@ func (c *Client) GetSystemBackup(ctx context.Context) ([]*BackupFile, error) {
 	}
 
 	type GetSystemBackupResponse struct {
-		XMLName     xml.Name `xml:"GetSystemBackupResponse"`
-		BackupFiles []struct {
-			Name string `xml:"Name"`
-			Data struct {
-				ContentType string `xml:"contentType,attr"`
-			} `xml:"Data"`
-		} `xml:"BackupFiles"`
+		XMLName     xml.Name        `xml:"GetSystemBackupResponse"`
+		BackupFiles []backupFileXML `xml:"BackupFiles"`
 	}
 
 	req := GetSystemBackup{
@@ -557,66 +559,63 @@ func (c *Client) GetSystemBackup(ctx context.Context) ([]*BackupFile, error) {
 
 	var resp GetSystemBackupResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
+	if err := soapClient.CallStream(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSystemBackup failed: %w", err)
 	}
 
 	backups := make([]*BackupFile, len(resp.BackupFiles))
-	for i, file := range resp.BackupFiles {
+	for i := range resp.BackupFiles {
 		backups[i] = &BackupFile{
-			Name: file.Name,
-			Data: AttachmentData{
-				ContentType: file.Data.ContentType,
-			},
+			Name: resp.BackupFiles[i].Name,
+			Data: resp.BackupFiles[i].Data.toAttachmentData(),
 		}
 	}
 
 	return backups, nil
 }
 
-// RestoreSystem restores the system backup configuration files.
+// RestoreSystem restores the system backup configuration files, as returned by
+// GetSystemBackup. Devices typically reboot after a restore; a message returned
+// by the device is logged. Use WaitForReboot to wait for the device to go
+// down and come back. The request is built in memory before it is sent, so
+// that it can be repeated for authentication, and takes about 4/3 of the size
+// of the files.
 func (c *Client) RestoreSystem(ctx context.Context, backupFiles []*BackupFile) error {
 	type RestoreSystem struct {
-		XMLName     xml.Name `xml:"tds:RestoreSystem"`
-		Xmlns       string   `xml:"xmlns:tds,attr"`
-		BackupFiles []struct {
-			Name string `xml:"tds:Name"`
-			Data struct {
-				ContentType string `xml:"contentType,attr"`
-			} `xml:"tds:Data"`
-		} `xml:"tds:BackupFiles"`
+		XMLName     xml.Name            `xml:"tds:RestoreSystem"`
+		Xmlns       string              `xml:"xmlns:tds,attr"`
+		Xmlnst      string              `xml:"xmlns:tt,attr"`
+		BackupFiles []backupFileRequest `xml:"tds:BackupFiles"`
+	}
+
+	type RestoreSystemResponse struct {
+		XMLName xml.Name `xml:"RestoreSystemResponse"`
+		Message string   `xml:"Message"`
 	}
 
 	req := RestoreSystem{
-		Xmlns: deviceNamespace,
+		Xmlns:  deviceNamespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
 	}
 
 	for _, file := range backupFiles {
-		req.BackupFiles = append(req.BackupFiles, struct {
-			Name string `xml:"tds:Name"`
-			Data struct {
-				ContentType string `xml:"contentType,attr"`
-			} `xml:"tds:Data"`
-		}{
-			Name: file.Name,
-			Data: struct {
-				ContentType string `xml:"contentType,attr"`
-			}{
-				ContentType: file.Data.ContentType,
-			},
-		})
+		req.BackupFiles = append(req.BackupFiles, newBackupFileRequest(file))
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	var resp RestoreSystemResponse
 
-	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("RestoreSystem failed: %w", err)
 	}
 
+	if resp.Message != "" {
+		c.logf("onvif: RestoreSystem: %s", resp.Message)
+	}
+
 	return nil
 }
 
@@ -647,8 +646,7 @@ func (c *Client) GetSystemUris(
 
 	var resp GetSystemUrisResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, "", "", fmt.Errorf("GetSystemUris failed: %w", err)
@@ -691,8 +689,7 @@ func (c *Client) GetSystemSupportInformation(ctx context.Context) (*SupportInfor
 
 	var resp GetSystemSupportInformationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSystemSupportInformation failed: %w", err)
@@ -712,7 +709,18 @@ func (c *Client) GetSystemSupportInformation(ctx context.Context) (*SupportInfor
 }
 
 // SetSystemFactoryDefault reloads the parameters on the device to their factory default values.
+// FactoryDefaultSoft keeps the network settings, so the device stays reachable at its
+// address, while FactoryDefaultHard resets everything, including the network settings
+// and users. After a hard reset the device usually comes back with DHCP or a vendor
+// default address, so it has to be found again, e.g. with WS-Discovery, and
+// provisioned anew. Either reset restarts the device, see SystemReboot, and drops
+// all URIs cached by WithMediaURICache since the media profiles are reset too.
 func (c *Client) SetSystemFactoryDefault(ctx context.Context, factoryDefault FactoryDefaultType) error {
+	if factoryDefault != FactoryDefaultHard && factoryDefault != FactoryDefaultSoft {
+		return fmt.Errorf("SetSystemFactoryDefault failed: %w: factory default type %q, want %q or %q",
+			ErrInvalidParameter, factoryDefault, FactoryDefaultHard, FactoryDefaultSoft)
+	}
+
 	type SetSystemFactoryDefault struct {
 		XMLName        xml.Name           `xml:"tds:SetSystemFactoryDefault"`
 		Xmlns          string             `xml:"xmlns:tds,attr"`
@@ -724,20 +732,22 @@ func (c *Client) SetSystemFactoryDefault(ctx context.Context, factoryDefault Fac
 		FactoryDefault: factoryDefault,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetSystemFactoryDefault failed: %w", err)
 	}
 
+	c.dropMediaURIs(func(*MediaURI) bool { return true })
+
 	return nil
 }
 
 // StartFirmwareUpgrade initiates a firmware upgrade using the HTTP POST mechanism.
-func (c *Client) StartFirmwareUpgrade(
-	ctx context.Context,
-) (uploadURI, uploadDelay, expectedDownTime string, err error) {
+// The firmware is then posted to the returned UploadURI with UploadFirmware after
+// waiting UploadDelay. Durations the device reports in an unparsable form are
+// left zero.
+func (c *Client) StartFirmwareUpgrade(ctx context.Context) (*FirmwareUpgradeInfo, error) {
 	type StartFirmwareUpgrade struct {
 		XMLName xml.Name `xml:"tds:StartFirmwareUpgrade"`
 		Xmlns   string   `xml:"xmlns:tds,attr"`
@@ -756,14 +766,20 @@ func (c *Client) StartFirmwareUpgrade(
 
 	var resp StartFirmwareUpgradeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
-		return "", "", "", fmt.Errorf("StartFirmwareUpgrade failed: %w", err)
+		return nil, fmt.Errorf("StartFirmwareUpgrade failed: %w", err)
 	}
 
-	return resp.UploadURI, resp.UploadDelay, resp.ExpectedDownTime, nil
+	info := &FirmwareUpgradeInfo{
+		// Some cameras report localhost instead of their actual address.
+		UploadURI: c.fixLocalhostURL(strings.TrimSpace(resp.UploadURI)),
+	}
+	info.UploadDelay, _ = parseDuration(resp.UploadDelay)
+	info.ExpectedDownTime, _ = parseDuration(resp.ExpectedDownTime)
+
+	return info, nil
 }
 
 // StartSystemRestore initiates a system restore from backed up configuration data.
@@ -785,8 +801,7 @@ func (c *Client) StartSystemRestore(ctx context.Context) (uploadURI, expectedDow
 
 	var resp StartSystemRestoreResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return "", "", fmt.Errorf("StartSystemRestore failed: %w", err)
@@ -2,11 +2,16 @@ package onvif
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func newMockDeviceExtendedServer() *httptest.Server {
@@ -129,6 +134,99 @@ func newMockDeviceExtendedServer() *httptest.Server {
 	}))
 }
 
+func TestSetSystemDateAndTime(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:SetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+		</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	local := time.Date(2025, 3, 9, 1, 30, 15, 500, time.FixedZone("EST", -5*60*60))
+
+	err = client.SetSystemDateAndTime(ctx, &SystemDateTime{
+		DateTimeType:    SetDateTimeManual,
+		DaylightSavings: true,
+		TimeZone:        &TimeZone{TZ: "EST5EDT,M3.2.0,M11.1.0"},
+		UTCDateTime:     NewDateTime(local),
+	})
+	if err != nil {
+		t.Fatalf("SetSystemDateAndTime() failed: %v", err)
+	}
+
+	var manual struct {
+		Body struct {
+			Request struct {
+				DateTimeType    string `xml:"http://www.onvif.org/ver10/device/wsdl DateTimeType"`
+				DaylightSavings bool   `xml:"http://www.onvif.org/ver10/device/wsdl DaylightSavings"`
+				TimeZone        struct {
+					TZ string `xml:"http://www.onvif.org/ver10/schema TZ"`
+				} `xml:"http://www.onvif.org/ver10/device/wsdl TimeZone"`
+				UTCDateTime struct {
+					Hour   int `xml:"http://www.onvif.org/ver10/schema Time>Hour"`
+					Minute int `xml:"http://www.onvif.org/ver10/schema Time>Minute"`
+					Second int `xml:"http://www.onvif.org/ver10/schema Time>Second"`
+					Year   int `xml:"http://www.onvif.org/ver10/schema Date>Year"`
+					Month  int `xml:"http://www.onvif.org/ver10/schema Date>Month"`
+					Day    int `xml:"http://www.onvif.org/ver10/schema Date>Day"`
+				} `xml:"http://www.onvif.org/ver10/device/wsdl UTCDateTime"`
+			} `xml:"http://www.onvif.org/ver10/device/wsdl SetSystemDateAndTime"`
+		} `xml:"Body"`
+	}
+
+	if err := xml.Unmarshal([]byte(requests[0]), &manual); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+
+	request := manual.Body.Request
+	if request.DateTimeType != "Manual" || !request.DaylightSavings || request.TimeZone.TZ != "EST5EDT,M3.2.0,M11.1.0" {
+		t.Errorf("Unexpected settings: %+v", request)
+	}
+
+	if utc := request.UTCDateTime; utc.Year != 2025 || utc.Month != 3 || utc.Day != 9 ||
+		utc.Hour != 6 || utc.Minute != 30 || utc.Second != 15 {
+		t.Errorf("Expected 2025-03-09 06:30:15 UTC, got %+v", utc)
+	}
+
+	// A SystemDateTime read from the device still carries its UTC time.
+	err = client.SetSystemDateAndTime(ctx, &SystemDateTime{
+		DateTimeType: SetDateTimeNTP,
+		UTCDateTime:  NewDateTime(local),
+	})
+	if err != nil {
+		t.Fatalf("SetSystemDateAndTime() failed: %v", err)
+	}
+
+	if strings.Contains(requests[1], "UTCDateTime") || strings.Contains(requests[1], "TimeZone") {
+		t.Errorf("Expected no UTC date and time or time zone in NTP mode: %s", requests[1])
+	}
+
+	for _, dateTime := range []*SystemDateTime{
+		nil,
+		{DateTimeType: SetDateTimeManual},
+		{DateTimeType: "Auto", UTCDateTime: NewDateTime(local)},
+	} {
+		if err := client.SetSystemDateAndTime(ctx, dateTime); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("SetSystemDateAndTime(%+v): expected ErrInvalidParameter, got %v", dateTime, err)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("Invalid settings should not be sent, got %d requests", len(requests))
+	}
+}
+
 func TestAddScopes(t *testing.T) {
 	server := newMockDeviceExtendedServer()
 	defer server.Close()
@@ -176,6 +274,108 @@ func TestRemoveScopes(t *testing.T) {
 	}
 }
 
+func TestSetNTPHosts(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:SetNTPResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+		</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	err = client.SetNTP(ctx, false, []NetworkHost{
+		{DNSname: "pool.ntp.org"},
+		{IPv6Address: "2001:db8::123"},
+		{Type: NetworkHostIPv4, IPv4Address: "192.168.1.1", DNSname: "ignored.example"},
+	})
+	if err != nil {
+		t.Fatalf("SetNTP() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`xmlns:tt="http://www.onvif.org/ver10/schema"`,
+		"<tt:DNSname>pool.ntp.org</tt:DNSname>",
+		"<tt:Type>IPv6</tt:Type>",
+		"<tt:IPv6Address>2001:db8::123</tt:IPv6Address>",
+		"<tt:IPv4Address>192.168.1.1</tt:IPv4Address>",
+	} {
+		if !strings.Contains(request, want) {
+			t.Errorf("Request missing %s: %s", want, request)
+		}
+	}
+
+	if strings.Contains(request, "ignored.example") {
+		t.Errorf("Expected only the address of the host type to be sent: %s", request)
+	}
+
+	invalid := [][]NetworkHost{
+		{{Type: NetworkHostIPv4, IPv4Address: "2001:db8::123"}},
+		{{Type: NetworkHostDNS}},
+		{{Type: "URL", DNSname: "pool.ntp.org"}},
+	}
+
+	for _, hosts := range invalid {
+		if err := client.SetNTP(ctx, false, hosts); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("SetNTP(%+v): expected ErrInvalidParameter, got %v", hosts, err)
+		}
+	}
+
+	if err := client.SetDNS(ctx, false, nil, []IPAddress{{IPv4Address: "8.8.8.8"}}); err != nil {
+		t.Fatalf("SetDNS() error = %v", err)
+	}
+
+	if !strings.Contains(request, "<tt:Type>IPv4</tt:Type>") || !strings.Contains(request, "<tt:IPv4Address>8.8.8.8</tt:IPv4Address>") {
+		t.Errorf("Unexpected SetDNS request: %s", request)
+	}
+}
+
+func TestRemoveFixedScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<soap:Body>
+		<soap:Fault>
+			<soap:Code>
+				<soap:Value>soap:Sender</soap:Value>
+				<soap:Subcode><soap:Value>ter:OperationProhibited</soap:Value>
+					<soap:Subcode><soap:Value>ter:FixedScope</soap:Value></soap:Subcode>
+				</soap:Subcode>
+			</soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">Scope is fixed</soap:Text></soap:Reason>
+		</soap:Fault>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.RemoveScopes(context.Background(), []string{"onvif://www.onvif.org/Profile/Streaming"})
+	if !errors.Is(err, ErrFixedScope) {
+		t.Fatalf("Expected ErrFixedScope, got %v", err)
+	}
+
+	var fault *SOAPFault
+	if !errors.As(err, &fault) || !errors.Is(err, ErrOperationProhibited) {
+		t.Errorf("Expected the device fault to be returned, got %v", err)
+	}
+}
+
 func TestSetScopes(t *testing.T) {
 	server := newMockDeviceExtendedServer()
 	defer server.Close()
@@ -333,6 +533,10 @@ func TestSetSystemFactoryDefault(t *testing.T) {
 	if err != nil {
 		t.Fatalf("SetSystemFactoryDefault (hard) failed: %v", err)
 	}
+
+	if err := client.SetSystemFactoryDefault(ctx, "Medium"); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an unknown type, got %v", err)
+	}
 }
 
 func TestStartFirmwareUpgrade(t *testing.T) {
@@ -345,21 +549,21 @@ func TestStartFirmwareUpgrade(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	uploadURI, delay, downtime, err := client.StartFirmwareUpgrade(ctx)
+	info, err := client.StartFirmwareUpgrade(ctx)
 	if err != nil {
 		t.Fatalf("StartFirmwareUpgrade failed: %v", err)
 	}
 
-	if uploadURI != "http://192.168.1.100/upload" {
-		t.Errorf("Expected upload URI http://192.168.1.100/upload, got %s", uploadURI)
+	if info.UploadURI != "http://192.168.1.100/upload" {
+		t.Errorf("Expected upload URI http://192.168.1.100/upload, got %s", info.UploadURI)
 	}
 
-	if delay != "PT5S" {
-		t.Errorf("Expected delay PT5S, got %s", delay)
+	if info.UploadDelay != 5*time.Second {
+		t.Errorf("Expected delay 5s, got %v", info.UploadDelay)
 	}
 
-	if downtime != "PT60S" {
-		t.Errorf("Expected downtime PT60S, got %s", downtime)
+	if info.ExpectedDownTime != time.Minute {
+		t.Errorf("Expected downtime 1m, got %v", info.ExpectedDownTime)
 	}
 }
 
@@ -412,3 +616,215 @@ func TestFactoryDefaultTypeConstants(t *testing.T) {
 		t.Errorf("FactoryDefaultSoft should be 'Soft', got %s", FactoryDefaultSoft)
 	}
 }
+
+func TestSystemBackupRoundTrip(t *testing.T) {
+	var restoreRequest string
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests++
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(string(body), "GetSystemBackup"):
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<tds:GetSystemBackupResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<tds:BackupFiles>
+				<tt:Name>config.bin</tt:Name>
+				<tt:Data xmime:contentType="application/octet-stream" xmlns:xmime="http://www.w3.org/2005/05/xmlmime">
+					aGVsbG8g
+					YmFja3Vw
+				</tt:Data>
+			</tds:BackupFiles>
+			<tds:BackupFiles>
+				<tt:Name>external.bin</tt:Name>
+				<tt:Data><xop:Include xmlns:xop="http://www.w3.org/2004/08/xop/include" href="cid:part1"/></tt:Data>
+			</tds:BackupFiles>
+		</tds:GetSystemBackupResponse>
+	</s:Body>
+</s:Envelope>`))
+		case strings.Contains(string(body), "RestoreSystem"):
+			restoreRequest = string(body)
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<tds:RestoreSystemResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+	</s:Body>
+</s:Envelope>`))
+		case strings.Contains(string(body), "GetSystemDateAndTime"):
+			if requests < 5 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+	</s:Body>
+</s:Envelope>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	files, err := client.GetSystemBackup(ctx)
+	if err != nil {
+		t.Fatalf("GetSystemBackup failed: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 backup files, got %d", len(files))
+	}
+
+	if files[0].Name != "config.bin" || string(files[0].Data.Data) != "hello backup" {
+		t.Errorf("Unexpected first backup file: %s %q", files[0].Name, files[0].Data.Data)
+	}
+
+	if files[0].Data.ContentType != "application/octet-stream" {
+		t.Errorf("Expected content type application/octet-stream, got %s", files[0].Data.ContentType)
+	}
+
+	if files[1].Data.Include == nil || files[1].Data.Include.Href != "cid:part1" {
+		t.Errorf("Expected XOP include cid:part1, got %+v", files[1].Data.Include)
+	}
+
+	large := &BackupFile{Name: "large.bin", Data: AttachmentData{Data: make([]byte, 10000)}}
+	for i := range large.Data.Data {
+		large.Data.Data[i] = byte(i)
+	}
+
+	if err := client.RestoreSystem(ctx, []*BackupFile{files[0], large}); err != nil {
+		t.Fatalf("RestoreSystem failed: %v", err)
+	}
+
+	if !strings.Contains(restoreRequest, `<tt:Data contentType="application/octet-stream">aGVsbG8gYmFja3Vw</tt:Data>`) {
+		t.Error("RestoreSystem request does not contain the encoded config.bin")
+	}
+
+	if !strings.Contains(restoreRequest, base64.StdEncoding.EncodeToString(large.Data.Data)) {
+		t.Error("RestoreSystem request does not contain the encoded large file")
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := client.WaitForReachable(waitCtx, time.Millisecond); err != nil {
+		t.Fatalf("WaitForReachable failed: %v", err)
+	}
+}
+
+func TestAttachmentDataDecodesPieces(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		// The comment splits the character data inside a base64 quantum.
+		{"split quantum", "aGVsb<!-- -->G8gYm\n Fja3Vw", "hello backup", false},
+		{"padding", "aGk=", "hi", false},
+		{"empty", " \n ", "", false},
+		{"truncated", "aGVsbG8", "", true},
+		{"content after padding", "aGk=aGk=", "", true},
+		{"invalid character", "aGV*bG8g", "", true},
+	}
+
+	for _, tt := range tests {
+		var data attachmentDataXML
+
+		err := xml.Unmarshal([]byte("<Data>"+tt.content+"</Data>"), &data)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidResponse) {
+				t.Errorf("%s: expected ErrInvalidResponse, got %v", tt.name, err)
+			}
+
+			continue
+		}
+
+		if err != nil || string(data.Data) != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, data.Data, err, tt.want)
+		}
+	}
+}
+
+func TestWaitForReachableHTTPError(t *testing.T) {
+	var polls atomic.Int32
+
+	// A device that is up but rejects the request without a SOAP body.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		polls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.WaitForReachable(ctx, time.Millisecond); err != nil {
+		t.Fatalf("WaitForReachable failed: %v", err)
+	}
+
+	if got := polls.Load(); got != 1 {
+		t.Errorf("Expected WaitForReachable to return on the first poll, got %d polls", got)
+	}
+}
+
+func TestWaitForReboot(t *testing.T) {
+	var polls atomic.Int32
+
+	// The device answers twice, is down for two polls, then answers again.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+
+		if n := polls.Add(1); n == 3 || n == 4 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+		<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.WaitForReboot(ctx, time.Millisecond); err != nil {
+		t.Fatalf("WaitForReboot failed: %v", err)
+	}
+
+	if got := polls.Load(); got != 5 {
+		t.Errorf("Expected WaitForReboot to return on the fifth poll, got %d polls", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Test device information from real camera:
@@ -410,9 +411,17 @@ func TestGetSystemDateAndTime_Bosch(t *testing.T) {
 		t.Fatalf("GetSystemDateAndTime() failed: %v", err)
 	}
 
-	// GetSystemDateAndTime returns interface{} - just verify no error
-	// The actual structure depends on the camera's response format
-	_ = dateTime // Acknowledge we received a response
+	if dateTime.DateTimeType != SetDateTimeManual || dateTime.TimeZone == nil || dateTime.TimeZone.TZ != "CST6CDT" {
+		t.Errorf("Unexpected date and time settings: %+v", dateTime)
+	}
+
+	if utc, ok := dateTime.UTC(); !ok || !utc.Equal(time.Date(2025, 12, 2, 4, 56, 14, 0, time.UTC)) {
+		t.Errorf("Expected 2025-12-02 04:56:14 UTC, got %v", utc)
+	}
+
+	if dateTime.LocalDateTime != nil {
+		t.Errorf("Expected no local date and time, got %+v", dateTime.LocalDateTime)
+	}
 }
 
 // TestGetHostname_Bosch tests GetHostname with real camera response.
@@ -531,6 +540,9 @@ func TestGetScopes_Bosch(t *testing.T) {
 	for _, scope := range scopes {
 		if strings.Contains(scope.ScopeItem, "hardware") {
 			foundHardware = true
+			if scope.ScopeDef != ScopeDefinitionFixed {
+				t.Errorf("Expected the hardware scope to be Fixed, got %q", scope.ScopeDef)
+			}
 			if !strings.Contains(scope.ScopeItem, "F000B543") {
 				t.Errorf("Expected hardware ID F000B543 in scope (Bosch FLEXIDOME), got %s", scope.ScopeItem)
 			}
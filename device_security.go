@@ -4,8 +4,9 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"net"
+	"net/url"
+	"slices"
 )
 
 // GetRemoteUser returns the configured remote user.
@@ -20,7 +21,7 @@ func (c *Client) GetRemoteUser(ctx context.Context) (*RemoteUser, error) {
 		RemoteUser *struct {
 			Username           string `xml:"Username"`
 			Password           string `xml:"Password"`
-			UseDerivedPassword bool   `xml:"UseDerivedPassword"`
+			UseDerivedPassword Bool   `xml:"UseDerivedPassword"`
 		} `xml:"RemoteUser"`
 	}
 
@@ -30,8 +31,7 @@ func (c *Client) GetRemoteUser(ctx context.Context) (*RemoteUser, error) {
 
 	var resp GetRemoteUserResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetRemoteUser failed: %w", err)
@@ -44,7 +44,7 @@ func (c *Client) GetRemoteUser(ctx context.Context) (*RemoteUser, error) {
 	return &RemoteUser{
 		Username:           resp.RemoteUser.Username,
 		Password:           resp.RemoteUser.Password,
-		UseDerivedPassword: resp.RemoteUser.UseDerivedPassword,
+		UseDerivedPassword: bool(resp.RemoteUser.UseDerivedPassword),
 	}, nil
 }
 
@@ -76,8 +76,7 @@ func (c *Client) SetRemoteUser(ctx context.Context, remoteUser *RemoteUser) erro
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetRemoteUser failed: %w", err)
@@ -114,8 +113,7 @@ func (c *Client) GetIPAddressFilter(ctx context.Context) (*IPAddressFilter, erro
 
 	var resp GetIPAddressFilterResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetIPAddressFilter failed: %w", err)
@@ -143,7 +141,13 @@ func (c *Client) GetIPAddressFilter(ctx context.Context) (*IPAddressFilter, erro
 }
 
 // SetIPAddressFilter sets the IP address filter settings on a device.
+// It returns ErrWouldLockOut without sending the request when the filter would
+// block the client's own source address, unless WithoutIPFilterLockOutCheck is used.
 func (c *Client) SetIPAddressFilter(ctx context.Context, filter *IPAddressFilter) error {
+	if err := c.checkIPFilterLockOut(ctx, filter); err != nil {
+		return err
+	}
+
 	type SetIPAddressFilter struct {
 		XMLName         xml.Name `xml:"tds:SetIPAddressFilter"`
 		Xmlns           string   `xml:"xmlns:tds,attr"`
@@ -185,8 +189,7 @@ func (c *Client) SetIPAddressFilter(ctx context.Context, filter *IPAddressFilter
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetIPAddressFilter failed: %w", err)
@@ -195,8 +198,160 @@ func (c *Client) SetIPAddressFilter(ctx context.Context, filter *IPAddressFilter
 	return nil
 }
 
+// checkIPFilterChangeLockOut reads the current IP address filter and returns
+// ErrWouldLockOut when the filter change would produce blocks the local
+// address the client uses to reach the device, as checkIPFilterLockOut does.
+// The check is skipped when the current filter cannot be read, and when it
+// already blocks the local address: the device then sees the client under
+// another address, e.g. behind NAT.
+func (c *Client) checkIPFilterChangeLockOut(
+	ctx context.Context, change func(current *IPAddressFilter) *IPAddressFilter,
+) error {
+	if c.skipLockOutCheck {
+		return nil
+	}
+
+	current, err := c.GetIPAddressFilter(ctx)
+	if err != nil {
+		c.skipCheck("IP filter lock-out check", err)
+
+		return nil
+	}
+
+	local, err := c.localAddr(ctx)
+	if err != nil {
+		c.skipCheck("IP filter lock-out check", err)
+
+		return nil
+	}
+
+	if current.blocks(local) {
+		return nil
+	}
+
+	if filter := change(current); filter.blocks(local) {
+		return fmt.Errorf("%w: resulting %s filter blocks client address %s", ErrWouldLockOut, filter.Type, local)
+	}
+
+	return nil
+}
+
+// checkIPFilterLockOut returns ErrWouldLockOut when filter would block the local
+// address the client uses to reach the device: an Allow list that does not
+// contain it, or a Deny list that does. An empty Allow list is not checked. The
+// check is skipped when the local address cannot be determined.
+func (c *Client) checkIPFilterLockOut(ctx context.Context, filter *IPAddressFilter) error {
+	if c.skipLockOutCheck {
+		return nil
+	}
+
+	local, err := c.localAddr(ctx)
+	if err != nil {
+		c.skipCheck("IP filter lock-out check", err)
+
+		return nil
+	}
+
+	if filter.blocks(local) {
+		return fmt.Errorf("%w: %s filter blocks client address %s", ErrWouldLockOut, filter.Type, local)
+	}
+
+	return nil
+}
+
+// blocks reports whether the filter blocks ip: an Allow list that does not
+// contain it, or a Deny list that does. An empty Allow list blocks nothing.
+func (f *IPAddressFilter) blocks(ip net.IP) bool {
+	if f.Type == IPFilterAllow && len(f.IPv4Address)+len(f.IPv6Address) == 0 {
+		return false
+	}
+
+	listed := f.contains(ip)
+
+	return (f.Type == IPFilterAllow && !listed) || (f.Type == IPFilterDeny && listed)
+}
+
+// localAddr returns the local IP address used to reach the device endpoint.
+// Dialing UDP only selects a route; no packets are sent.
+func (c *Client) localAddr(ctx context.Context) (net.IP, error) {
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEndpoint, err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected local address %v", ErrConnectionFailed, conn.LocalAddr())
+	}
+
+	return addr.IP, nil
+}
+
+// contains reports whether ip falls within one of the filter's address prefixes.
+func (f *IPAddressFilter) contains(ip net.IP) bool {
+	prefixes := make([]string, 0, len(f.IPv4Address)+len(f.IPv6Address))
+
+	for _, addr := range f.IPv4Address {
+		prefixes = append(prefixes, fmt.Sprintf("%s/%d", addr.Address, addr.PrefixLength))
+	}
+
+	for _, addr := range f.IPv6Address {
+		prefixes = append(prefixes, fmt.Sprintf("%s/%d", addr.Address, addr.PrefixLength))
+	}
+
+	for _, prefix := range prefixes {
+		if _, network, err := net.ParseCIDR(prefix); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AddIPAddressFilter adds an IP filter address to a device.
+// When the device advertises a maximum number of filter entries, the current
+// filter is read first and ErrIPFilterLimitReached is returned without sending
+// the request if the new entries would not fit. Like SetIPAddressFilter, it
+// returns ErrWouldLockOut when the resulting filter would block the client's
+// own source address, unless WithoutIPFilterLockOutCheck is used.
 func (c *Client) AddIPAddressFilter(ctx context.Context, filter *IPAddressFilter) error {
+	if err := c.checkIPFilterLimit(ctx, filter); err != nil {
+		return err
+	}
+
+	if err := c.checkIPFilterChangeLockOut(ctx, func(current *IPAddressFilter) *IPAddressFilter {
+		result := &IPAddressFilter{
+			Type:        current.Type,
+			IPv4Address: slices.Concat(current.IPv4Address, filter.IPv4Address),
+			IPv6Address: slices.Concat(current.IPv6Address, filter.IPv6Address),
+		}
+		if filter.Type != "" {
+			result.Type = filter.Type
+		}
+
+		return result
+	}); err != nil {
+		return err
+	}
+
 	type AddIPAddressFilter struct {
 		XMLName         xml.Name `xml:"tds:AddIPAddressFilter"`
 		Xmlns           string   `xml:"xmlns:tds,attr"`
@@ -238,8 +393,7 @@ func (c *Client) AddIPAddressFilter(ctx context.Context, filter *IPAddressFilter
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddIPAddressFilter failed: %w", err)
@@ -248,8 +402,58 @@ func (c *Client) AddIPAddressFilter(ctx context.Context, filter *IPAddressFilter
 	return nil
 }
 
-// RemoveIPAddressFilter deletes an IP filter address from a device.
+// checkIPFilterLimit returns ErrIPFilterLimitReached when adding filter to the
+// device's current IP address filter would exceed the advertised maximum. The
+// check is skipped when the limit or the current filter cannot be read.
+func (c *Client) checkIPFilterLimit(ctx context.Context, filter *IPAddressFilter) error {
+	caps, err := checkedCapabilities(ctx, c, deviceNamespace, c.GetServiceCapabilities)
+	if err != nil {
+		c.skipCheck("IP filter limit check", err)
+
+		return nil
+	}
+
+	if caps.Network == nil || caps.Network.MaxIPFilterEntries <= 0 {
+		return nil
+	}
+
+	current, err := c.GetIPAddressFilter(ctx)
+	if err != nil {
+		c.skipCheck("IP filter limit check", err)
+
+		return nil
+	}
+
+	existing := len(current.IPv4Address) + len(current.IPv6Address)
+	adding := len(filter.IPv4Address) + len(filter.IPv6Address)
+
+	if existing+adding > caps.Network.MaxIPFilterEntries {
+		return fmt.Errorf("%w: %d entries configured, adding %d, device maximum is %d",
+			ErrIPFilterLimitReached, existing, adding, caps.Network.MaxIPFilterEntries)
+	}
+
+	return nil
+}
+
+// RemoveIPAddressFilter deletes an IP filter address from a device. Like
+// SetIPAddressFilter, it returns ErrWouldLockOut when the resulting filter
+// would block the client's own source address, e.g. when the client's entry
+// is removed from an Allow list, unless WithoutIPFilterLockOutCheck is used.
 func (c *Client) RemoveIPAddressFilter(ctx context.Context, filter *IPAddressFilter) error {
+	if err := c.checkIPFilterChangeLockOut(ctx, func(current *IPAddressFilter) *IPAddressFilter {
+		return &IPAddressFilter{
+			Type: current.Type,
+			IPv4Address: slices.DeleteFunc(slices.Clone(current.IPv4Address), func(addr PrefixedIPv4Address) bool {
+				return slices.Contains(filter.IPv4Address, addr)
+			}),
+			IPv6Address: slices.DeleteFunc(slices.Clone(current.IPv6Address), func(addr PrefixedIPv6Address) bool {
+				return slices.Contains(filter.IPv6Address, addr)
+			}),
+		}
+	}); err != nil {
+		return err
+	}
+
 	type RemoveIPAddressFilter struct {
 		XMLName         xml.Name `xml:"tds:RemoveIPAddressFilter"`
 		Xmlns           string   `xml:"xmlns:tds,attr"`
@@ -291,8 +495,7 @@ func (c *Client) RemoveIPAddressFilter(ctx context.Context, filter *IPAddressFil
 		})
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveIPAddressFilter failed: %w", err)
@@ -312,7 +515,7 @@ func (c *Client) GetZeroConfiguration(ctx context.Context) (*NetworkZeroConfigur
 		XMLName           xml.Name `xml:"GetZeroConfigurationResponse"`
 		ZeroConfiguration struct {
 			InterfaceToken string   `xml:"InterfaceToken"`
-			Enabled        bool     `xml:"Enabled"`
+			Enabled        Bool     `xml:"Enabled"`
 			Addresses      []string `xml:"Addresses"`
 		} `xml:"ZeroConfiguration"`
 	}
@@ -323,8 +526,7 @@ func (c *Client) GetZeroConfiguration(ctx context.Context) (*NetworkZeroConfigur
 
 	var resp GetZeroConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetZeroConfiguration failed: %w", err)
@@ -332,7 +534,7 @@ func (c *Client) GetZeroConfiguration(ctx context.Context) (*NetworkZeroConfigur
 
 	return &NetworkZeroConfiguration{
 		InterfaceToken: resp.ZeroConfiguration.InterfaceToken,
-		Enabled:        resp.ZeroConfiguration.Enabled,
+		Enabled:        bool(resp.ZeroConfiguration.Enabled),
 		Addresses:      resp.ZeroConfiguration.Addresses,
 	}, nil
 }
@@ -352,8 +554,7 @@ func (c *Client) SetZeroConfiguration(ctx context.Context, interfaceToken string
 		Enabled:        enabled,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetZeroConfiguration failed: %w", err)
@@ -384,8 +585,7 @@ func (c *Client) GetDynamicDNS(ctx context.Context) (*DynamicDNSInformation, err
 
 	var resp GetDynamicDNSResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDynamicDNS failed: %w", err)
@@ -413,8 +613,7 @@ func (c *Client) SetDynamicDNS(ctx context.Context, dnsType DynamicDNSType, name
 		Name:  name,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetDynamicDNS failed: %w", err)
@@ -436,8 +635,8 @@ func (c *Client) GetPasswordComplexityConfiguration(ctx context.Context) (*Passw
 		Uppercase                 int      `xml:"Uppercase"`
 		Number                    int      `xml:"Number"`
 		SpecialChars              int      `xml:"SpecialChars"`
-		BlockUsernameOccurrence   bool     `xml:"BlockUsernameOccurrence"`
-		PolicyConfigurationLocked bool     `xml:"PolicyConfigurationLocked"`
+		BlockUsernameOccurrence   Bool     `xml:"BlockUsernameOccurrence"`
+		PolicyConfigurationLocked Bool     `xml:"PolicyConfigurationLocked"`
 	}
 
 	req := GetPasswordComplexityConfiguration{
@@ -446,8 +645,7 @@ func (c *Client) GetPasswordComplexityConfiguration(ctx context.Context) (*Passw
 
 	var resp GetPasswordComplexityConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetPasswordComplexityConfiguration failed: %w", err)
@@ -458,8 +656,8 @@ func (c *Client) GetPasswordComplexityConfiguration(ctx context.Context) (*Passw
 		Uppercase:                 resp.Uppercase,
 		Number:                    resp.Number,
 		SpecialChars:              resp.SpecialChars,
-		BlockUsernameOccurrence:   resp.BlockUsernameOccurrence,
-		PolicyConfigurationLocked: resp.PolicyConfigurationLocked,
+		BlockUsernameOccurrence:   bool(resp.BlockUsernameOccurrence),
+		PolicyConfigurationLocked: bool(resp.PolicyConfigurationLocked),
 	}, nil
 }
 
@@ -489,8 +687,7 @@ func (c *Client) SetPasswordComplexityConfiguration(
 		PolicyConfigurationLocked: config.PolicyConfigurationLocked,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetPasswordComplexityConfiguration failed: %w", err)
@@ -508,7 +705,7 @@ func (c *Client) GetPasswordHistoryConfiguration(ctx context.Context) (*Password
 
 	type GetPasswordHistoryConfigurationResponse struct {
 		XMLName xml.Name `xml:"GetPasswordHistoryConfigurationResponse"`
-		Enabled bool     `xml:"Enabled"`
+		Enabled Bool     `xml:"Enabled"`
 		Length  int      `xml:"Length"`
 	}
 
@@ -518,15 +715,14 @@ func (c *Client) GetPasswordHistoryConfiguration(ctx context.Context) (*Password
 
 	var resp GetPasswordHistoryConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetPasswordHistoryConfiguration failed: %w", err)
 	}
 
 	return &PasswordHistoryConfiguration{
-		Enabled: resp.Enabled,
+		Enabled: bool(resp.Enabled),
 		Length:  resp.Length,
 	}, nil
 }
@@ -546,8 +742,7 @@ func (c *Client) SetPasswordHistoryConfiguration(ctx context.Context, config *Pa
 		Length:  config.Length,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetPasswordHistoryConfiguration failed: %w", err)
@@ -565,7 +760,7 @@ func (c *Client) GetAuthFailureWarningConfiguration(ctx context.Context) (*AuthF
 
 	type GetAuthFailureWarningConfigurationResponse struct {
 		XMLName         xml.Name `xml:"GetAuthFailureWarningConfigurationResponse"`
-		Enabled         bool     `xml:"Enabled"`
+		Enabled         Bool     `xml:"Enabled"`
 		MonitorPeriod   int      `xml:"MonitorPeriod"`
 		MaxAuthFailures int      `xml:"MaxAuthFailures"`
 	}
@@ -576,15 +771,14 @@ func (c *Client) GetAuthFailureWarningConfiguration(ctx context.Context) (*AuthF
 
 	var resp GetAuthFailureWarningConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAuthFailureWarningConfiguration failed: %w", err)
 	}
 
 	return &AuthFailureWarningConfiguration{
-		Enabled:         resp.Enabled,
+		Enabled:         bool(resp.Enabled),
 		MonitorPeriod:   resp.MonitorPeriod,
 		MaxAuthFailures: resp.MaxAuthFailures,
 	}, nil
@@ -610,8 +804,7 @@ func (c *Client) SetAuthFailureWarningConfiguration(
 		MaxAuthFailures: config.MaxAuthFailures,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetAuthFailureWarningConfiguration failed: %w", err)
@@ -3,6 +3,8 @@ package onvif
 import (
 	"context"
 	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -253,6 +255,7 @@ func TestSetIPAddressFilter(t *testing.T) {
 		Type: IPAddressFilterAllow,
 		IPv4Address: []PrefixedIPv4Address{
 			{Address: "10.0.0.0", PrefixLength: 8},
+			{Address: "127.0.0.0", PrefixLength: 8},
 		},
 	}
 
@@ -262,6 +265,90 @@ func TestSetIPAddressFilter(t *testing.T) {
 	}
 }
 
+func TestSetIPAddressFilterLockOut(t *testing.T) {
+	var sets int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "SetIPAddressFilter") {
+			sets++
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<tds:SetIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+	</s:Body>
+</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		filter  *IPAddressFilter
+		opts    []ClientOption
+		lockOut bool
+	}{
+		{
+			name:    "allow list without client",
+			filter:  &IPAddressFilter{Type: IPFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 8}}},
+			lockOut: true,
+		},
+		{
+			name:   "allow list with client",
+			filter: &IPAddressFilter{Type: IPFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "127.0.0.1", PrefixLength: 32}}},
+		},
+		{
+			name:    "deny list with client",
+			filter:  &IPAddressFilter{Type: IPFilterDeny, IPv4Address: []PrefixedIPv4Address{{Address: "127.0.0.0", PrefixLength: 8}}},
+			lockOut: true,
+		},
+		{
+			name:   "deny list without client",
+			filter: &IPAddressFilter{Type: IPFilterDeny, IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 8}}},
+		},
+		{
+			name:   "check disabled",
+			filter: &IPAddressFilter{Type: IPFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 8}}},
+			opts:   []ClientOption{WithoutIPFilterLockOutCheck()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sets = 0
+
+			client, err := NewClient(server.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			err = client.SetIPAddressFilter(context.Background(), tt.filter)
+
+			if tt.lockOut {
+				if !errors.Is(err, ErrWouldLockOut) {
+					t.Fatalf("Expected ErrWouldLockOut, got %v", err)
+				}
+
+				if sets != 0 {
+					t.Errorf("Expected no SetIPAddressFilter request, got %d", sets)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetIPAddressFilter failed: %v", err)
+			}
+
+			if sets != 1 {
+				t.Errorf("Expected 1 SetIPAddressFilter request, got %d", sets)
+			}
+		})
+	}
+}
+
 func TestAddIPAddressFilter(t *testing.T) {
 	server := newMockDeviceSecurityServer()
 	defer server.Close()
@@ -285,6 +372,75 @@ func TestAddIPAddressFilter(t *testing.T) {
 	}
 }
 
+func TestAddIPAddressFilterLimit(t *testing.T) {
+	var added int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		var response string
+
+		switch {
+		case strings.Contains(bodyStr, "GetServiceCapabilities"):
+			response = `<tds:GetServiceCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Capabilities><tds:Network IPFilter="true" MaxIPFilterEntries="2"/></tds:Capabilities>
+			</tds:GetServiceCapabilitiesResponse>`
+		case strings.Contains(bodyStr, "GetIPAddressFilter"):
+			response = `<tds:GetIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:IPAddressFilter>
+					<tt:Type>Allow</tt:Type>
+					<tt:IPv4Address><tt:Address>192.168.1.0</tt:Address><tt:PrefixLength>24</tt:PrefixLength></tt:IPv4Address>
+				</tds:IPAddressFilter>
+			</tds:GetIPAddressFilterResponse>`
+		case strings.Contains(bodyStr, "AddIPAddressFilter"):
+			added++
+			response = `<tds:AddIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	err = client.AddIPAddressFilter(ctx, &IPAddressFilter{
+		Type: IPAddressFilterAllow,
+		IPv4Address: []PrefixedIPv4Address{
+			{Address: "172.16.0.0", PrefixLength: 12},
+			{Address: "10.0.0.0", PrefixLength: 8},
+		},
+	})
+	if !errors.Is(err, ErrIPFilterLimitReached) {
+		t.Fatalf("Expected ErrIPFilterLimitReached, got %v", err)
+	}
+
+	if added != 0 {
+		t.Fatalf("AddIPAddressFilter should not be sent when over the limit")
+	}
+
+	err = client.AddIPAddressFilter(ctx, &IPAddressFilter{
+		Type:        IPAddressFilterAllow,
+		IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 8}},
+	})
+	if err != nil {
+		t.Fatalf("AddIPAddressFilter failed: %v", err)
+	}
+
+	if added != 1 {
+		t.Errorf("Expected 1 AddIPAddressFilter request, got %d", added)
+	}
+}
+
 func TestRemoveIPAddressFilter(t *testing.T) {
 	server := newMockDeviceSecurityServer()
 	defer server.Close()
@@ -308,6 +464,72 @@ func TestRemoveIPAddressFilter(t *testing.T) {
 	}
 }
 
+func TestIPAddressFilterChangeLockOut(t *testing.T) {
+	var changes int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		var response string
+
+		switch {
+		case strings.Contains(bodyStr, "GetServiceCapabilities"):
+			response = `<tds:GetServiceCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Capabilities><tds:Network IPFilter="true"/></tds:Capabilities>
+			</tds:GetServiceCapabilitiesResponse>`
+		case strings.Contains(bodyStr, "GetIPAddressFilter"):
+			response = `<tds:GetIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:IPAddressFilter>
+					<tt:Type>Allow</tt:Type>
+					<tt:IPv4Address><tt:Address>127.0.0.1</tt:Address><tt:PrefixLength>32</tt:PrefixLength></tt:IPv4Address>
+					<tt:IPv4Address><tt:Address>10.0.0.0</tt:Address><tt:PrefixLength>8</tt:PrefixLength></tt:IPv4Address>
+				</tds:IPAddressFilter>
+			</tds:GetIPAddressFilterResponse>`
+		case strings.Contains(bodyStr, "AddIPAddressFilter"), strings.Contains(bodyStr, "RemoveIPAddressFilter"):
+			changes++
+			response = `<tds:AddIPAddressFilterResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>` + response + `</s:Body></s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	own := &IPAddressFilter{Type: IPFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "127.0.0.1", PrefixLength: 32}}}
+
+	if err := client.RemoveIPAddressFilter(ctx, own); !errors.Is(err, ErrWouldLockOut) {
+		t.Fatalf("Expected ErrWouldLockOut when removing the client's own entry, got %v", err)
+	}
+
+	deny := &IPAddressFilter{Type: IPFilterDeny, IPv4Address: []PrefixedIPv4Address{{Address: "127.0.0.0", PrefixLength: 8}}}
+	if err := client.AddIPAddressFilter(ctx, deny); !errors.Is(err, ErrWouldLockOut) {
+		t.Fatalf("Expected ErrWouldLockOut when turning the filter into a deny list, got %v", err)
+	}
+
+	if changes != 0 {
+		t.Fatalf("Expected no filter change to be sent, got %d", changes)
+	}
+
+	other := &IPAddressFilter{Type: IPFilterAllow, IPv4Address: []PrefixedIPv4Address{{Address: "10.0.0.0", PrefixLength: 8}}}
+	if err := client.RemoveIPAddressFilter(ctx, other); err != nil {
+		t.Fatalf("RemoveIPAddressFilter() failed: %v", err)
+	}
+
+	if changes != 1 {
+		t.Errorf("Expected the removal to be sent, got %d changes", changes)
+	}
+}
+
 func TestGetZeroConfiguration(t *testing.T) {
 	server := newMockDeviceSecurityServer()
 	defer server.Close()
@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // GetStorageConfigurations retrieves storage configurations. ONVIF Specification: GetStorageConfigurations operation.
@@ -25,8 +23,7 @@ func (c *Client) GetStorageConfigurations(ctx context.Context) ([]*StorageConfig
 	}
 	var response GetStorageConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetStorageConfigurations failed: %w", err)
@@ -54,8 +51,7 @@ func (c *Client) GetStorageConfiguration(ctx context.Context, token string) (*St
 	}
 	var response GetStorageConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetStorageConfiguration failed: %w", err)
@@ -84,8 +80,7 @@ func (c *Client) CreateStorageConfiguration(ctx context.Context, config *Storage
 	}
 	var response CreateStorageConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return "", fmt.Errorf("CreateStorageConfiguration failed: %w", err)
@@ -112,8 +107,7 @@ func (c *Client) SetStorageConfiguration(ctx context.Context, config *StorageCon
 	}
 	var response SetStorageConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetStorageConfiguration failed: %w", err)
@@ -141,8 +135,7 @@ func (c *Client) DeleteStorageConfiguration(ctx context.Context, token string) e
 	}
 	var response DeleteStorageConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("DeleteStorageConfiguration failed: %w", err)
@@ -169,8 +162,7 @@ func (c *Client) SetHashingAlgorithm(ctx context.Context, algorithm string) erro
 	}
 	var response SetHashingAlgorithmResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetHashingAlgorithm failed: %w", err)
@@ -3,8 +3,11 @@ package onvif
 import (
 	"context"
 	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -203,6 +206,52 @@ func TestSetHostname(t *testing.T) {
 	}
 }
 
+func TestSetHostnameValidation(t *testing.T) {
+	client, err := NewClient("http://192.0.2.1/onvif/device_service")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for _, name := range []string{"", "lobby_cam", "-lobby", "lobby-", "lobby..cam", strings.Repeat("a", 64)} {
+		if err := client.SetHostname(context.Background(), name); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("SetHostname(%q): expected ErrInvalidParameter, got %v", name, err)
+		}
+	}
+
+	if err := validateHostname("building-2.floor-1.lobby-cam"); err != nil {
+		t.Errorf("Expected a valid hostname, got %v", err)
+	}
+}
+
+func TestSetHostnameFromDHCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<tds:FromDHCP>true</tds:FromDHCP>") {
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:SetHostnameFromDHCPResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:RebootNeeded>true</tds:RebootNeeded>
+			</tds:SetHostnameFromDHCPResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	rebootNeeded, err := client.SetHostnameFromDHCP(context.Background(), true)
+	if err != nil {
+		t.Fatalf("SetHostnameFromDHCP() error = %v", err)
+	}
+
+	if !rebootNeeded {
+		t.Error("Expected RebootNeeded to be reported")
+	}
+}
+
 func TestGetDNS(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
@@ -317,6 +366,82 @@ func TestCreateUsers(t *testing.T) {
 	}
 }
 
+func TestCreateUsersUsernameClash(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<soap:Body>
+		<soap:Fault>
+			<soap:Code>
+				<soap:Value>soap:Sender</soap:Value>
+				<soap:Subcode><soap:Value>ter:OperationProhibited</soap:Value>
+					<soap:Subcode><soap:Value>ter:UsernameClash</soap:Value></soap:Subcode>
+				</soap:Subcode>
+			</soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">Username already exists</soap:Text></soap:Reason>
+		</soap:Fault>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.CreateUsers(context.Background(), []*User{{Username: "admin", Password: "secret", UserLevel: UserLevelAdministrator}})
+	if !errors.Is(err, ErrUsernameClash) {
+		t.Fatalf("Expected ErrUsernameClash, got %v", err)
+	}
+}
+
+func TestSetUser(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:SetUserResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl"/>
+		</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.SetUser(context.Background(),
+		&User{Username: "admin", Password: "rotated", UserLevel: UserLevelAdministrator},
+		&User{Username: "viewer", UserLevel: UserLevelUser})
+	if err != nil {
+		t.Fatalf("SetUser() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<tds:Username>admin</tds:Username>",
+		"<tds:Password>rotated</tds:Password>",
+		"<tds:Username>viewer</tds:Username>",
+		"<tds:UserLevel>User</tds:UserLevel>",
+	} {
+		if !strings.Contains(request, want) {
+			t.Errorf("Request missing %s: %s", want, request)
+		}
+	}
+
+	if n := strings.Count(request, "<tds:Password>"); n != 1 {
+		t.Errorf("Expected the password of the viewer to be left unchanged, got %d passwords", n)
+	}
+
+	if err := client.SetUser(context.Background()); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter without users, got %v", err)
+	}
+}
+
 func TestDeleteUsers(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
@@ -392,6 +517,144 @@ func TestGetNetworkInterfaces(t *testing.T) {
 	}
 }
 
+func TestGetNetworkInterfacesDHCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:GetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:NetworkInterfaces token="eth0">
+					<tt:Enabled>true</tt:Enabled>
+					<tt:Info><tt:Name>eth0</tt:Name><tt:HwAddress>00:11:22:33:44:55</tt:HwAddress><tt:MTU>1500</tt:MTU></tt:Info>
+					<tt:IPv4><tt:Enabled>true</tt:Enabled><tt:Config>
+						<tt:FromDHCP><tt:Address>10.0.0.23</tt:Address><tt:PrefixLength>16</tt:PrefixLength></tt:FromDHCP>
+						<tt:DHCP>true</tt:DHCP>
+					</tt:Config></tt:IPv4>
+				</tds:NetworkInterfaces>
+				<tds:NetworkInterfaces token="wlan0">
+					<tt:Enabled>false</tt:Enabled>
+					<tt:IPv4><tt:Enabled>false</tt:Enabled><tt:Config><tt:DHCP>false</tt:DHCP></tt:Config></tt:IPv4>
+				</tds:NetworkInterfaces>
+			</tds:GetNetworkInterfacesResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	interfaces, err := client.GetNetworkInterfaces(context.Background())
+	if err != nil {
+		t.Fatalf("GetNetworkInterfaces() error = %v", err)
+	}
+
+	if len(interfaces) != 2 {
+		t.Fatalf("Expected 2 interfaces, got %d", len(interfaces))
+	}
+
+	eth0 := interfaces[0]
+	if eth0.Token != "eth0" || !eth0.Enabled || eth0.Info.HwAddress != "00:11:22:33:44:55" || eth0.Info.MTU != 1500 {
+		t.Errorf("Unexpected interface %+v", eth0)
+	}
+
+	if eth0.IPv4 == nil || !eth0.IPv4.Config.DHCP || eth0.IPv4.Config.FromDHCP == nil ||
+		eth0.IPv4.Config.FromDHCP.Address != "10.0.0.23" || eth0.IPv4.Config.FromDHCP.PrefixLength != 16 {
+		t.Errorf("Expected the DHCP address 10.0.0.23/16, got %+v", eth0.IPv4)
+	}
+
+	if wlan0 := interfaces[1]; wlan0.IPv4 == nil || wlan0.IPv4.Enabled {
+		t.Errorf("Expected a disabled IPv4 configuration, got %+v", wlan0.IPv4)
+	}
+}
+
+func TestSetNetworkInterfaces(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:SetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:RebootNeeded>true</tds:RebootNeeded>
+			</tds:SetNetworkInterfacesResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	dhcp, static := true, false
+
+	rebootNeeded, err := client.SetNetworkInterfaces(ctx, "eth0", &NetworkInterfaceSetConfiguration{
+		IPv4: &IPv4NetworkInterfaceSetConfiguration{
+			Manual: []PrefixedIPv4Address{{Address: "192.168.1.100", PrefixLength: 24}},
+			DHCP:   &static,
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetNetworkInterfaces() error = %v", err)
+	}
+
+	if !rebootNeeded {
+		t.Error("Expected RebootNeeded to be reported")
+	}
+
+	if _, err := client.SetNetworkInterfaces(ctx, "eth0", &NetworkInterfaceSetConfiguration{
+		IPv4: &IPv4NetworkInterfaceSetConfiguration{DHCP: &dhcp},
+	}); err != nil {
+		t.Fatalf("SetNetworkInterfaces() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<tds:InterfaceToken>eth0</tds:InterfaceToken>",
+		"<tt:Address>192.168.1.100</tt:Address>",
+		"<tt:PrefixLength>24</tt:PrefixLength>",
+		"<tt:DHCP>false</tt:DHCP>",
+	} {
+		if !strings.Contains(requests[0], want) {
+			t.Errorf("Static request missing %s: %s", want, requests[0])
+		}
+	}
+
+	if !strings.Contains(requests[1], "<tt:DHCP>true</tt:DHCP>") || strings.Contains(requests[1], "tt:Manual") {
+		t.Errorf("Expected a DHCP request without manual addresses: %s", requests[1])
+	}
+}
+
+func TestSetNetworkInterfacesValidation(t *testing.T) {
+	client, err := NewClient("http://192.0.2.1/onvif/device_service")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	static := false
+
+	tests := []struct {
+		name string
+		cfg  *NetworkInterfaceSetConfiguration
+	}{
+		{"no configuration", nil},
+		{"static without address", &NetworkInterfaceSetConfiguration{
+			IPv4: &IPv4NetworkInterfaceSetConfiguration{DHCP: &static},
+		}},
+		{"IPv6 address", &NetworkInterfaceSetConfiguration{
+			IPv4: &IPv4NetworkInterfaceSetConfiguration{Manual: []PrefixedIPv4Address{{Address: "fe80::1", PrefixLength: 64}}},
+		}},
+		{"prefix too long", &NetworkInterfaceSetConfiguration{
+			IPv4: &IPv4NetworkInterfaceSetConfiguration{Manual: []PrefixedIPv4Address{{Address: "192.168.1.100", PrefixLength: 33}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		if _, err := client.SetNetworkInterfaces(context.Background(), "eth0", tt.cfg); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("%s: expected ErrInvalidParameter, got %v", tt.name, err)
+		}
+	}
+}
+
 func TestGetServices(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := `<?xml version="1.0" encoding="UTF-8"?>
@@ -440,9 +703,10 @@ func TestGetServiceCapabilities(t *testing.T) {
 			<s:Body>
 				<tds:GetServiceCapabilitiesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
 					<tds:Capabilities>
-						<tds:Network IPFilter="true" ZeroConfiguration="true"/>
-						<tds:Security TLS1.2="true"/>
-						<tds:System FirmwareUpgrade="true"/>
+						<tds:Network IPFilter="true" ZeroConfiguration="true" Dot11Configuration="true" NTP="2"/>
+						<tds:Security TLS1.2="true" X.509Token="true" MaxUsers="16"/>
+						<tds:System FirmwareUpgrade="true" HttpFirmwareUpgrade="true"/>
+						<tds:Misc AuxiliaryCommands="tt:Wiper|On tt:Wiper|Off"/>
 					</tds:Capabilities>
 				</tds:GetServiceCapabilitiesResponse>
 			</s:Body>
@@ -465,6 +729,22 @@ func TestGetServiceCapabilities(t *testing.T) {
 	if caps.Network == nil || !caps.Network.IPFilter {
 		t.Error("Expected Network.IPFilter to be true")
 	}
+
+	if !caps.Network.Dot11Configuration || caps.Network.NTP != 2 {
+		t.Errorf("Unexpected network capabilities %+v", caps.Network)
+	}
+
+	if !caps.Security.TLS12 || !caps.Security.X509Token || caps.Security.TLS10 || caps.Security.MaxUsers != 16 {
+		t.Errorf("Unexpected security capabilities %+v", caps.Security)
+	}
+
+	if !caps.System.FirmwareUpgrade || !caps.System.HTTPFirmwareUpgrade || caps.System.SystemBackup {
+		t.Errorf("Unexpected system capabilities %+v", caps.System)
+	}
+
+	if len(caps.Misc.AuxiliaryCommands) != 2 || caps.Misc.AuxiliaryCommands[1] != "tt:Wiper|Off" {
+		t.Errorf("AuxiliaryCommands = %v", caps.Misc.AuxiliaryCommands)
+	}
 }
 
 func TestGetDiscoveryMode(t *testing.T) {
@@ -710,3 +990,117 @@ func BenchmarkDeviceGetDeviceInformation(b *testing.B) {
 		_, _ = client.GetDeviceInformation(ctx)
 	}
 }
+
+func TestGetSystemDateAndTimeWithoutAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		requireAuth  bool
+		wantRequests []bool // whether each request carried a UsernameToken
+	}{
+		{name: "anonymous accepted", wantRequests: []bool{false}},
+		{name: "device demands auth", requireAuth: true, wantRequests: []bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var authenticated []bool
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				hasToken := strings.Contains(string(body), "UsernameToken")
+				authenticated = append(authenticated, hasToken)
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+
+				if tt.requireAuth && !hasToken {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><soap:Fault>
+						<soap:Code><soap:Value>soap:Sender</soap:Value>
+						<soap:Subcode><soap:Value>ter:NotAuthorized</soap:Value></soap:Subcode></soap:Code>
+						<soap:Reason><soap:Text>Sender not authorized</soap:Text></soap:Reason>
+					</soap:Fault></soap:Body></soap:Envelope>`))
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+					<GetSystemDateAndTimeResponse><SystemDateAndTime><DateTimeType>NTP</DateTimeType></SystemDateAndTime></GetSystemDateAndTimeResponse>
+				</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithCredentials("admin", "password"))
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			if _, err := client.GetSystemDateAndTime(context.Background()); err != nil {
+				t.Fatalf("GetSystemDateAndTime() failed: %v", err)
+			}
+
+			if len(authenticated) != len(tt.wantRequests) {
+				t.Fatalf("Expected %d requests, got %d", len(tt.wantRequests), len(authenticated))
+			}
+
+			for i, want := range tt.wantRequests {
+				if authenticated[i] != want {
+					t.Errorf("Request %d: expected UsernameToken %t, got %t", i, want, authenticated[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetDeviceInformationExtensions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+			<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:oem="http://example.com/oem">
+				<tds:Manufacturer>Acme</tds:Manufacturer>
+				<tds:Model>CAM-1</tds:Model>
+				<tds:FirmwareVersion>1.0</tds:FirmwareVersion>
+				<tds:SerialNumber>123</tds:SerialNumber>
+				<tds:HardwareId>HW1</tds:HardwareId>
+				<oem:Region> EU </oem:Region>
+				<tds:Extension>
+					<oem:BuildDate>2024-01-31</oem:BuildDate>
+					<oem:Chipset>X100</oem:Chipset>
+					<oem:Sensor><oem:Name>A</oem:Name></oem:Sensor>
+					<oem:Sensor><oem:Name>B</oem:Name></oem:Sensor>
+				</tds:Extension>
+			</tds:GetDeviceInformationResponse>
+		</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	info, err := client.GetDeviceInformation(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeviceInformation() failed: %v", err)
+	}
+
+	if info.Manufacturer != "Acme" || info.HardwareID != "HW1" {
+		t.Errorf("Unexpected standard fields: %+v", info)
+	}
+
+	want := map[string]string{
+		"Region":                  "EU",
+		"Extension/BuildDate":     "2024-01-31",
+		"Extension/Chipset":       "X100",
+		"Extension/Sensor/Name":   "A",
+		"Extension/Sensor/Name#2": "B",
+	}
+	if len(info.Extensions) != len(want) {
+		t.Fatalf("Expected %d extensions, got %v", len(want), info.Extensions)
+	}
+
+	for name, value := range want {
+		if info.Extensions[name] != value {
+			t.Errorf("Extension %s: expected %q, got %q", name, value, info.Extensions[name])
+		}
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // GetDot11Capabilities retrieves 802.11 capabilities. ONVIF Specification: GetDot11Capabilities operation.
@@ -25,8 +23,7 @@ func (c *Client) GetDot11Capabilities(ctx context.Context) (*Dot11Capabilities,
 	}
 	var response GetDot11CapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetDot11Capabilities failed: %w", err)
@@ -54,8 +51,7 @@ func (c *Client) GetDot11Status(ctx context.Context, interfaceToken string) (*Do
 	}
 	var response GetDot11StatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetDot11Status failed: %w", err)
@@ -83,8 +79,7 @@ func (c *Client) GetDot1XConfiguration(ctx context.Context, configToken string)
 	}
 	var response GetDot1XConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetDot1XConfiguration failed: %w", err)
@@ -110,8 +105,7 @@ func (c *Client) GetDot1XConfigurations(ctx context.Context) ([]*Dot1XConfigurat
 	}
 	var response GetDot1XConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("GetDot1XConfigurations failed: %w", err)
@@ -138,8 +132,7 @@ func (c *Client) SetDot1XConfiguration(ctx context.Context, config *Dot1XConfigu
 	}
 	var response SetDot1XConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("SetDot1XConfiguration failed: %w", err)
@@ -166,8 +159,7 @@ func (c *Client) CreateDot1XConfiguration(ctx context.Context, config *Dot1XConf
 	}
 	var response CreateDot1XConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("CreateDot1XConfiguration failed: %w", err)
@@ -194,8 +186,7 @@ func (c *Client) DeleteDot1XConfiguration(ctx context.Context, configToken strin
 	}
 	var response DeleteDot1XConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return fmt.Errorf("DeleteDot1XConfiguration failed: %w", err)
@@ -227,8 +218,7 @@ func (c *Client) ScanAvailableDot11Networks(
 	}
 	var response ScanAvailableDot11NetworksResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, c.endpoint, "", request, &response); err != nil {
 		return nil, fmt.Errorf("ScanAvailableDot11Networks failed: %w", err)
@@ -5,8 +5,6 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 // Device IO service namespace.
@@ -171,9 +169,13 @@ type RelayOutputOptions struct {
 	Discrete   bool
 }
 
-// getDeviceIOEndpoint returns the device IO endpoint.
+// getDeviceIOEndpoint returns the device IO endpoint, falling back to the
+// device endpoint, which most devices also serve device IO requests on.
 func (c *Client) getDeviceIOEndpoint() string {
-	// Device IO typically uses the main device endpoint.
+	if c.deviceIOEndpoint != "" {
+		return c.deviceIOEndpoint
+	}
+
 	return c.endpoint
 }
 
@@ -196,8 +198,8 @@ func (c *Client) GetDeviceIOServiceCapabilities(ctx context.Context) (*DeviceIOS
 			RelayOutputs            int  `xml:"RelayOutputs,attr"`
 			SerialPorts             int  `xml:"SerialPorts,attr"`
 			DigitalInputs           int  `xml:"DigitalInputs,attr"`
-			DigitalInputOptions     bool `xml:"DigitalInputOptions,attr"`
-			SerialPortConfiguration bool `xml:"SerialPortConfiguration,attr"`
+			DigitalInputOptions     Bool `xml:"DigitalInputOptions,attr"`
+			SerialPortConfiguration Bool `xml:"SerialPortConfiguration,attr"`
 		} `xml:"Capabilities"`
 	}
 
@@ -207,11 +209,12 @@ func (c *Client) GetDeviceIOServiceCapabilities(ctx context.Context) (*DeviceIOS
 
 	var resp GetServiceCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if !c.cachedCapabilities(deviceIONamespace, &resp.Capabilities) {
+		soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetDeviceIOServiceCapabilities failed: %w", err)
+		if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+			return nil, fmt.Errorf("GetDeviceIOServiceCapabilities failed: %w", err)
+		}
 	}
 
 	return &DeviceIOServiceCapabilities{
@@ -222,8 +225,8 @@ func (c *Client) GetDeviceIOServiceCapabilities(ctx context.Context) (*DeviceIOS
 		RelayOutputs:            resp.Capabilities.RelayOutputs,
 		SerialPorts:             resp.Capabilities.SerialPorts,
 		DigitalInputs:           resp.Capabilities.DigitalInputs,
-		DigitalInputOptions:     resp.Capabilities.DigitalInputOptions,
-		SerialPortConfiguration: resp.Capabilities.SerialPortConfiguration,
+		DigitalInputOptions:     bool(resp.Capabilities.DigitalInputOptions),
+		SerialPortConfiguration: bool(resp.Capabilities.SerialPortConfiguration),
 	}, nil
 }
 
@@ -250,8 +253,7 @@ func (c *Client) GetDigitalInputs(ctx context.Context) ([]*DigitalInput, error)
 
 	var resp GetDigitalInputsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDigitalInputs failed: %w", err)
@@ -296,8 +298,7 @@ func (c *Client) GetDigitalInputConfigurationOptions(ctx context.Context, token
 
 	var resp GetDigitalInputConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetDigitalInputConfigurationOptions failed: %w", err)
@@ -356,8 +357,7 @@ func (c *Client) SetDigitalInputConfigurations(ctx context.Context, inputs []*Di
 
 	var resp SetDigitalInputConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("SetDigitalInputConfigurations failed: %w", err)
@@ -380,14 +380,14 @@ func (c *Client) GetVideoOutputs(ctx context.Context) ([]*VideoOutput, error) {
 		VideoOutputs []struct {
 			Token  string `xml:"token,attr"`
 			Layout *struct {
+				PaneLayout []struct {
+					Pane string       `xml:"Pane"`
+					Area rectangleXML `xml:"Area"`
+				} `xml:"PaneLayout"`
+				// Pane is a non-standard form with the pane token as attribute
 				Pane []struct {
-					Pane string `xml:"Pane,attr"`
-					Area struct {
-						Bottom float64 `xml:"bottom,attr"`
-						Top    float64 `xml:"top,attr"`
-						Right  float64 `xml:"right,attr"`
-						Left   float64 `xml:"left,attr"`
-					} `xml:"Area"`
+					Pane string       `xml:"Pane,attr"`
+					Area rectangleXML `xml:"Area"`
 				} `xml:"Pane"`
 			} `xml:"Layout"`
 			Resolution *struct {
@@ -405,8 +405,7 @@ func (c *Client) GetVideoOutputs(ctx context.Context) ([]*VideoOutput, error) {
 
 	var resp GetVideoOutputsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoOutputs failed: %w", err)
@@ -429,19 +428,21 @@ func (c *Client) GetVideoOutputs(ctx context.Context) ([]*VideoOutput, error) {
 
 		if vo.Layout != nil {
 			output.Layout = &Layout{
-				Pane: make([]PaneLayout, len(vo.Layout.Pane)),
+				Pane: make([]PaneLayout, 0, len(vo.Layout.PaneLayout)+len(vo.Layout.Pane)),
 			}
 
-			for j, pane := range vo.Layout.Pane {
-				output.Layout.Pane[j] = PaneLayout{
+			for _, pane := range vo.Layout.PaneLayout {
+				output.Layout.Pane = append(output.Layout.Pane, PaneLayout{
 					Pane: pane.Pane,
-					Area: FloatRectangle{
-						Bottom: pane.Area.Bottom,
-						Top:    pane.Area.Top,
-						Right:  pane.Area.Right,
-						Left:   pane.Area.Left,
-					},
-				}
+					Area: pane.Area.toFloatRectangle(),
+				})
+			}
+
+			for _, pane := range vo.Layout.Pane {
+				output.Layout.Pane = append(output.Layout.Pane, PaneLayout{
+					Pane: pane.Pane,
+					Area: pane.Area.toFloatRectangle(),
+				})
 			}
 		}
 
@@ -474,8 +475,7 @@ func (c *Client) GetSerialPorts(ctx context.Context) ([]*SerialPort, error) {
 
 	var resp GetSerialPortsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSerialPorts failed: %w", err)
@@ -525,8 +525,7 @@ func (c *Client) GetSerialPortConfiguration(ctx context.Context, serialPortToken
 
 	var resp GetSerialPortConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSerialPortConfiguration failed: %w", err)
@@ -574,8 +573,7 @@ func (c *Client) GetSerialPortConfigurationOptions(ctx context.Context, serialPo
 
 	var resp GetSerialPortConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSerialPortConfigurationOptions failed: %w", err)
@@ -642,8 +640,7 @@ func (c *Client) SetSerialPortConfiguration(ctx context.Context, config *SerialP
 
 	var resp SetSerialPortConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("SetSerialPortConfiguration failed: %w", err)
@@ -701,8 +698,7 @@ func (c *Client) SendReceiveSerialCommand(ctx context.Context, serialPortToken s
 
 	var resp SendReceiveSerialCommandResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("SendReceiveSerialCommand failed: %w", err)
@@ -742,8 +738,7 @@ func (c *Client) GetVideoOutputConfiguration(ctx context.Context, videoOutputTok
 
 	var resp GetVideoOutputConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoOutputConfiguration failed: %w", err)
@@ -789,8 +784,7 @@ func (c *Client) GetVideoOutputConfigurationOptions(ctx context.Context, videoOu
 
 	var resp GetVideoOutputConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoOutputConfigurationOptions failed: %w", err)
@@ -805,7 +799,8 @@ func (c *Client) GetVideoOutputConfigurationOptions(ctx context.Context, videoOu
 	}, nil
 }
 
-// SetVideoOutputConfiguration sets a video output configuration.
+// SetVideoOutputConfiguration sets a video output configuration. The device's
+// own UseCount is sent in place of config.UseCount.
 func (c *Client) SetVideoOutputConfiguration(ctx context.Context, config *VideoOutputConfiguration) error {
 	if config == nil {
 		return ErrVideoOutputConfigNil
@@ -848,10 +843,12 @@ func (c *Client) SetVideoOutputConfiguration(ctx context.Context, config *VideoO
 		ForcePersistence: config.ForcePersistence,
 	}
 
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetVideoOutputConfiguration", config.OutputToken, config.UseCount,
+		c.GetVideoOutputConfiguration, func(current *VideoOutputConfiguration) int { return current.UseCount })
+
 	var resp SetVideoOutputConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("SetVideoOutputConfiguration failed: %w", err)
@@ -880,7 +877,7 @@ func (c *Client) GetRelayOutputOptions(ctx context.Context, relayOutputToken str
 			Token      string   `xml:"token,attr"`
 			Mode       []string `xml:"Mode"`
 			DelayTimes []string `xml:"DelayTimes"`
-			Discrete   bool     `xml:"Discrete"`
+			Discrete   Bool     `xml:"Discrete"`
 		} `xml:"RelayOutputOptions"`
 	}
 
@@ -891,8 +888,7 @@ func (c *Client) GetRelayOutputOptions(ctx context.Context, relayOutputToken str
 
 	var resp GetRelayOutputOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetRelayOutputOptions failed: %w", err)
@@ -907,6 +903,6 @@ func (c *Client) GetRelayOutputOptions(ctx context.Context, relayOutputToken str
 		Token:      resp.RelayOutputOptions.Token,
 		Mode:       modes,
 		DelayTimes: resp.RelayOutputOptions.DelayTimes,
-		Discrete:   resp.RelayOutputOptions.Discrete,
+		Discrete:   bool(resp.RelayOutputOptions.Discrete),
 	}, nil
 }
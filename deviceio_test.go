@@ -441,6 +441,51 @@ func TestGetVideoOutputs(t *testing.T) {
 	}
 }
 
+func TestGetVideoOutputsPaneLayout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/onvif/deviceio_service" {
+			t.Errorf("Expected request on the device IO endpoint, got %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(testDeviceIOXMLHeader + `
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope">
+  <SOAP-ENV:Body>
+    <tmd:GetVideoOutputsResponse xmlns:tmd="http://www.onvif.org/ver10/deviceIO/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+      <tmd:VideoOutputs token="video_out_001">
+        <tt:Layout>
+          <tt:PaneLayout><tt:Pane>pane_left</tt:Pane><tt:Area bottom="-1" top="1" right="0" left="-1"/></tt:PaneLayout>
+          <tt:PaneLayout><tt:Pane>pane_right</tt:Pane><tt:Area bottom="-1" top="1" right="1" left="0"/></tt:PaneLayout>
+        </tt:Layout>
+        <tt:RefreshRate>50</tt:RefreshRate>
+      </tmd:VideoOutputs>
+    </tmd:GetVideoOutputsResponse>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	client.deviceIOEndpoint = server.URL + "/onvif/deviceio_service"
+
+	outputs, err := client.GetVideoOutputs(context.Background())
+	if err != nil {
+		t.Fatalf("GetVideoOutputs failed: %v", err)
+	}
+
+	if len(outputs) != 1 || outputs[0].Layout == nil || len(outputs[0].Layout.Pane) != 2 {
+		t.Fatalf("Expected one video output with two panes, got %+v", outputs)
+	}
+
+	if pane := outputs[0].Layout.Pane[1]; pane.Pane != "pane_right" || pane.Area.Left != 0 || pane.Area.Right != 1 {
+		t.Errorf("Unexpected second pane: %+v", pane)
+	}
+}
+
 func TestGetSerialPorts(t *testing.T) {
 	server := newMockDeviceIOServer()
 	defer server.Close()
@@ -84,10 +84,18 @@ type DiscoverOptions struct {
 	// Examples: "eth0", "wlan0", "192.168.1.100"
 	NetworkInterface string
 
+	// Interface specifies the network interface to use for multicast and
+	// takes precedence over NetworkInterface. If both are empty, the socket
+	// is bound on all interfaces.
+	Interface *net.Interface
+
 	// Context and timeout are handled by the caller
 }
 
-// Discover performs ONVIF device discovery using WS-Discovery protocol.
+// Discover performs ONVIF device discovery using WS-Discovery protocol. A
+// Probe for NetworkVideoTransmitter devices is multicast and the ProbeMatch
+// responses are collected until timeout elapses or ctx is done. Devices that
+// answer more than once are returned once, see Device.UUID.
 // For advanced options like specifying a network interface, use DiscoverWithOptions.
 func Discover(ctx context.Context, timeout time.Duration) ([]*Device, error) {
 	return DiscoverWithOptions(ctx, timeout, &DiscoverOptions{})
@@ -108,8 +116,8 @@ func DiscoverWithOptions(ctx context.Context, timeout time.Duration, opts *Disco
 	}
 
 	// Get the network interface to use
-	var iface *net.Interface
-	if opts.NetworkInterface != "" {
+	iface := opts.Interface
+	if iface == nil && opts.NetworkInterface != "" {
 		iface, err = resolveNetworkInterface(opts.NetworkInterface)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve network interface: %w", err)
@@ -129,6 +137,12 @@ func DiscoverWithOptions(ctx context.Context, timeout time.Duration, opts *Disco
 		return nil, fmt.Errorf("failed to set read deadline: %w", err)
 	}
 
+	// Unblock a pending read when ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
 	// Generate message ID
 	messageID := generateUUID()
 
@@ -153,8 +167,8 @@ func DiscoverWithOptions(ctx context.Context, timeout time.Duration, opts *Disco
 			if err != nil {
 				var netErr net.Error
 				if errors.As(err, &netErr) && netErr.Timeout() {
-					// Timeout reached, return collected devices
-					return deviceMapToSlice(devices), nil
+					// Timeout reached or ctx done, return collected devices
+					return deviceMapToSlice(devices), ctx.Err()
 				}
 
 				return deviceMapToSlice(devices), fmt.Errorf("failed to read UDP response: %w", err)
@@ -167,9 +181,9 @@ func DiscoverWithOptions(ctx context.Context, timeout time.Duration, opts *Disco
 				continue
 			}
 
-			// Add to devices map (deduplicate by endpoint)
+			// Add to devices map (deduplicate by endpoint UUID)
 			if device != nil && device.EndpointRef != "" {
-				devices[device.EndpointRef] = device
+				devices[device.UUID()] = device
 			}
 		}
 	}
@@ -347,16 +361,40 @@ type NetworkInterface struct {
 	Multicast bool
 }
 
-// GetDeviceEndpoint extracts the primary device endpoint from XAddrs.
+// GetDeviceEndpoint extracts the primary device endpoint from XAddrs: the
+// first HTTP or HTTPS address, or the first address if there is none. It can
+// be passed to onvif.NewClient.
 func (d *Device) GetDeviceEndpoint() string {
 	if len(d.XAddrs) == 0 {
 		return ""
 	}
 
-	// Return the first XAddr
+	for _, xaddr := range d.XAddrs {
+		lower := strings.ToLower(xaddr)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			return xaddr
+		}
+	}
+
 	return d.XAddrs[0]
 }
 
+// UUID returns the device UUID from its endpoint reference, e.g.
+// "urn:uuid:1419d68a-1dd2-11b2-a105-0123456789ab", without the urn:uuid:
+// prefix and lower-cased. Endpoint references that are not UUIDs are
+// returned unchanged.
+func (d *Device) UUID() string {
+	ref := strings.TrimSpace(d.EndpointRef)
+
+	for _, prefix := range []string{"urn:uuid:", "uuid:"} {
+		if len(ref) > len(prefix) && strings.EqualFold(ref[:len(prefix)], prefix) {
+			return strings.ToLower(ref[len(prefix):])
+		}
+	}
+
+	return ref
+}
+
 // GetName extracts the device name from scopes.
 func (d *Device) GetName() string {
 	for _, scope := range d.Scopes {
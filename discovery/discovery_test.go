@@ -65,6 +65,16 @@ func TestDevice_GetDeviceEndpoint(t *testing.T) {
 			},
 			want: "http://192.168.1.100:80/onvif/device_service",
 		},
+		{
+			name: "device with non-HTTP XAddr first",
+			device: &Device{
+				XAddrs: []string{
+					"soap.udp://239.255.255.250:3702",
+					"https://192.168.1.100/onvif/device_service",
+				},
+			},
+			want: "https://192.168.1.100/onvif/device_service",
+		},
 		{
 			name:   "device with no XAddrs",
 			device: &Device{},
@@ -122,6 +132,45 @@ func TestDevice_GetLocation(t *testing.T) {
 	}
 }
 
+func TestDevice_UUID(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpointRef string
+		want        string
+	}{
+		{"urn uuid", "urn:uuid:1419D68A-1DD2-11B2-A105-0123456789AB", "1419d68a-1dd2-11b2-a105-0123456789ab"},
+		{"uuid", "uuid:1419d68a-1dd2-11b2-a105-0123456789ab", "1419d68a-1dd2-11b2-a105-0123456789ab"},
+		{"not a uuid", "http://192.168.1.100/device", "http://192.168.1.100/device"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := &Device{EndpointRef: tt.endpointRef}
+			if got := device.UUID(); got != tt.want {
+				t.Errorf("UUID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscover_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+
+	_, err := Discover(ctx, 5*time.Second)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Discover() returned after %v, expected prompt return on cancel", elapsed)
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.Logf("Discover returned error: %v (this is expected in test environment)", err)
+	}
+}
+
 func TestDiscover_WithTimeout(t *testing.T) {
 	// This test will timeout since there are likely no actual cameras on the test network
 	// It validates that the timeout mechanism works
@@ -0,0 +1,232 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// Display service namespace.
+const displayNamespace = "http://www.onvif.org/ver10/display/wsdl"
+
+// Display service errors.
+var (
+	// ErrLayoutNil is returned when layout is nil.
+	ErrLayoutNil = errors.New("layout cannot be nil")
+)
+
+// DisplayOptions lists the layouts a video output supports.
+type DisplayOptions struct {
+	// PaneLayoutOptions lists the supported layouts, each as the areas of its panes.
+	PaneLayoutOptions []*PaneLayoutOptions
+	// VideoDecodingEncodings lists the video encodings the device can decode,
+	// e.g. H264 or JPEG.
+	VideoDecodingEncodings []string
+}
+
+// PaneLayoutOptions describes one supported layout by the areas of its panes.
+// Areas are in normalized coordinates, where -1,-1 is the lower left and 1,1
+// the upper right corner of the video output.
+type PaneLayoutOptions struct {
+	Areas []FloatRectangle
+}
+
+// rectangleXML is the wire form of tt:Rectangle.
+type rectangleXML struct {
+	Bottom float64 `xml:"bottom,attr"`
+	Top    float64 `xml:"top,attr"`
+	Right  float64 `xml:"right,attr"`
+	Left   float64 `xml:"left,attr"`
+}
+
+func (r *rectangleXML) toFloatRectangle() FloatRectangle {
+	return FloatRectangle{Bottom: r.Bottom, Top: r.Top, Right: r.Right, Left: r.Left}
+}
+
+// getDisplayEndpoint returns the display endpoint, falling back to the device endpoint.
+func (c *Client) getDisplayEndpoint() string {
+	if c.displayEndpoint != "" {
+		return c.displayEndpoint
+	}
+
+	return c.endpoint
+}
+
+// GetLayout retrieves the pane layout of a video output.
+func (c *Client) GetLayout(ctx context.Context, videoOutputToken string) (*Layout, error) {
+	if videoOutputToken == "" {
+		return nil, ErrInvalidVideoOutputToken
+	}
+
+	endpoint := c.getDisplayEndpoint()
+
+	type GetLayout struct {
+		XMLName     xml.Name `xml:"tls:GetLayout"`
+		Xmlns       string   `xml:"xmlns:tls,attr"`
+		VideoOutput string   `xml:"tls:VideoOutput"`
+	}
+
+	type GetLayoutResponse struct {
+		XMLName xml.Name `xml:"GetLayoutResponse"`
+		Layout  struct {
+			PaneLayout []struct {
+				Pane string       `xml:"Pane"`
+				Area rectangleXML `xml:"Area"`
+			} `xml:"PaneLayout"`
+		} `xml:"Layout"`
+	}
+
+	req := GetLayout{
+		Xmlns:       displayNamespace,
+		VideoOutput: videoOutputToken,
+	}
+
+	var resp GetLayoutResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetLayout failed: %w", err)
+	}
+
+	layout := &Layout{
+		Pane: make([]PaneLayout, len(resp.Layout.PaneLayout)),
+	}
+
+	for i, pane := range resp.Layout.PaneLayout {
+		layout.Pane[i] = PaneLayout{
+			Pane: pane.Pane,
+			Area: pane.Area.toFloatRectangle(),
+		}
+	}
+
+	return layout, nil
+}
+
+// SetLayout changes the pane layout of a video output.
+func (c *Client) SetLayout(ctx context.Context, videoOutputToken string, layout *Layout) error {
+	if videoOutputToken == "" {
+		return ErrInvalidVideoOutputToken
+	}
+
+	if layout == nil {
+		return ErrLayoutNil
+	}
+
+	endpoint := c.getDisplayEndpoint()
+
+	type PaneLayoutXML struct {
+		Pane string       `xml:"tt:Pane"`
+		Area rectangleXML `xml:"tt:Area"`
+	}
+
+	type SetLayout struct {
+		XMLName     xml.Name `xml:"tls:SetLayout"`
+		Xmlns       string   `xml:"xmlns:tls,attr"`
+		XmlnsTT     string   `xml:"xmlns:tt,attr"`
+		VideoOutput string   `xml:"tls:VideoOutput"`
+		Layout      struct {
+			PaneLayout []PaneLayoutXML `xml:"tt:PaneLayout"`
+		} `xml:"tls:Layout"`
+	}
+
+	req := SetLayout{
+		Xmlns:       displayNamespace,
+		XmlnsTT:     "http://www.onvif.org/ver10/schema",
+		VideoOutput: videoOutputToken,
+	}
+
+	for _, pane := range layout.Pane {
+		req.Layout.PaneLayout = append(req.Layout.PaneLayout, PaneLayoutXML{
+			Pane: pane.Pane,
+			Area: rectangleXML{
+				Bottom: pane.Area.Bottom,
+				Top:    pane.Area.Top,
+				Right:  pane.Area.Right,
+				Left:   pane.Area.Left,
+			},
+		})
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetLayout failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetDisplayOptions retrieves the layouts and decoding capabilities of a video output.
+func (c *Client) GetDisplayOptions(ctx context.Context, videoOutputToken string) (*DisplayOptions, error) {
+	if videoOutputToken == "" {
+		return nil, ErrInvalidVideoOutputToken
+	}
+
+	endpoint := c.getDisplayEndpoint()
+
+	type GetDisplayOptions struct {
+		XMLName     xml.Name `xml:"tls:GetDisplayOptions"`
+		Xmlns       string   `xml:"xmlns:tls,attr"`
+		VideoOutput string   `xml:"tls:VideoOutput"`
+	}
+
+	type GetDisplayOptionsResponse struct {
+		XMLName       xml.Name `xml:"GetDisplayOptionsResponse"`
+		LayoutOptions struct {
+			PaneLayoutOptions []struct {
+				Area []rectangleXML `xml:"Area"`
+			} `xml:"PaneLayoutOptions"`
+		} `xml:"LayoutOptions"`
+		CodingCapabilities struct {
+			VideoDecodingCapabilities struct {
+				H264  *struct{} `xml:"H264"`
+				JPEG  *struct{} `xml:"JPEG"`
+				H265  *struct{} `xml:"H265"`
+				MPEG4 *struct{} `xml:"MPEG4"`
+			} `xml:"VideoDecodingCapabilities"`
+		} `xml:"CodingCapabilities"`
+	}
+
+	req := GetDisplayOptions{
+		Xmlns:       displayNamespace,
+		VideoOutput: videoOutputToken,
+	}
+
+	var resp GetDisplayOptionsResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetDisplayOptions failed: %w", err)
+	}
+
+	options := &DisplayOptions{}
+
+	for _, layout := range resp.LayoutOptions.PaneLayoutOptions {
+		paneOptions := &PaneLayoutOptions{}
+		for i := range layout.Area {
+			paneOptions.Areas = append(paneOptions.Areas, layout.Area[i].toFloatRectangle())
+		}
+
+		options.PaneLayoutOptions = append(options.PaneLayoutOptions, paneOptions)
+	}
+
+	decoding := resp.CodingCapabilities.VideoDecodingCapabilities
+	for _, codec := range []struct {
+		name      string
+		supported bool
+	}{
+		{"JPEG", decoding.JPEG != nil},
+		{"MPEG4", decoding.MPEG4 != nil},
+		{"H264", decoding.H264 != nil},
+		{"H265", decoding.H265 != nil},
+	} {
+		if codec.supported {
+			options.VideoDecodingEncodings = append(options.VideoDecodingEncodings, codec.name)
+		}
+	}
+
+	return options, nil
+}
@@ -0,0 +1,142 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockDisplayServer(t *testing.T, setRequest *string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetLayout"):
+			response = `<tls:GetLayoutResponse xmlns:tls="http://www.onvif.org/ver10/display/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tls:Layout>
+					<tt:PaneLayout><tt:Pane>Pane_1</tt:Pane><tt:Area bottom="0" top="1" right="0" left="-1"/></tt:PaneLayout>
+					<tt:PaneLayout><tt:Pane>Pane_2</tt:Pane><tt:Area bottom="0" top="1" right="1" left="0"/></tt:PaneLayout>
+				</tls:Layout>
+			</tls:GetLayoutResponse>`
+		case strings.Contains(string(body), "SetLayout"):
+			*setRequest = string(body)
+			response = `<tls:SetLayoutResponse xmlns:tls="http://www.onvif.org/ver10/display/wsdl"/>`
+		case strings.Contains(string(body), "GetDisplayOptions"):
+			response = `<tls:GetDisplayOptionsResponse xmlns:tls="http://www.onvif.org/ver10/display/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tls:LayoutOptions>
+					<tt:PaneLayoutOptions><tt:Area bottom="-1" top="1" right="1" left="-1"/></tt:PaneLayoutOptions>
+					<tt:PaneLayoutOptions>
+						<tt:Area bottom="-1" top="1" right="0" left="-1"/>
+						<tt:Area bottom="-1" top="1" right="1" left="0"/>
+					</tt:PaneLayoutOptions>
+				</tls:LayoutOptions>
+				<tls:CodingCapabilities>
+					<tt:VideoDecodingCapabilities><tt:H264/><tt:JPEG/></tt:VideoDecodingCapabilities>
+				</tls:CodingCapabilities>
+			</tls:GetDisplayOptionsResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestGetLayout(t *testing.T) {
+	var setRequest string
+
+	server := newMockDisplayServer(t, &setRequest)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	layout, err := client.GetLayout(context.Background(), "VideoOutput_1")
+	if err != nil {
+		t.Fatalf("GetLayout() failed: %v", err)
+	}
+
+	if len(layout.Pane) != 2 {
+		t.Fatalf("Expected 2 panes, got %d", len(layout.Pane))
+	}
+
+	if layout.Pane[1].Pane != "Pane_2" || layout.Pane[1].Area.Left != 0 || layout.Pane[1].Area.Right != 1 {
+		t.Errorf("Unexpected second pane: %+v", layout.Pane[1])
+	}
+
+	if _, err := client.GetLayout(context.Background(), ""); !errors.Is(err, ErrInvalidVideoOutputToken) {
+		t.Errorf("Expected ErrInvalidVideoOutputToken, got %v", err)
+	}
+}
+
+func TestSetLayout(t *testing.T) {
+	var setRequest string
+
+	server := newMockDisplayServer(t, &setRequest)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	layout := &Layout{
+		Pane: []PaneLayout{
+			{Pane: "Pane_1", Area: FloatRectangle{Bottom: -1, Top: 1, Right: 1, Left: -1}},
+		},
+	}
+
+	if err := client.SetLayout(context.Background(), "VideoOutput_1", layout); err != nil {
+		t.Fatalf("SetLayout() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"<tls:VideoOutput>VideoOutput_1</tls:VideoOutput>",
+		"<tt:Pane>Pane_1</tt:Pane>",
+		`<tt:Area bottom="-1" top="1" right="1" left="-1">`,
+	} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+
+	if err := client.SetLayout(context.Background(), "VideoOutput_1", nil); !errors.Is(err, ErrLayoutNil) {
+		t.Errorf("Expected ErrLayoutNil, got %v", err)
+	}
+}
+
+func TestGetDisplayOptions(t *testing.T) {
+	var setRequest string
+
+	server := newMockDisplayServer(t, &setRequest)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	options, err := client.GetDisplayOptions(context.Background(), "VideoOutput_1")
+	if err != nil {
+		t.Fatalf("GetDisplayOptions() failed: %v", err)
+	}
+
+	if len(options.PaneLayoutOptions) != 2 || len(options.PaneLayoutOptions[1].Areas) != 2 {
+		t.Fatalf("Unexpected layout options: %+v", options.PaneLayoutOptions)
+	}
+
+	if got := strings.Join(options.VideoDecodingEncodings, ","); got != "JPEG,H264" {
+		t.Errorf("Expected decodings JPEG,H264, got %s", got)
+	}
+}
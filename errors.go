@@ -3,6 +3,8 @@ package onvif
 import (
 	"errors"
 	"fmt"
+
+	"github.com/0x524a/onvif-go/internal/soap"
 )
 
 var (
@@ -40,14 +42,17 @@ var (
 	ErrNetworkInterfaceNotFound = errors.New("network interface not found")
 
 	// ErrHTTPRequestFailed is returned when an HTTP request fails.
-	ErrHTTPRequestFailed = errors.New("HTTP request failed")
+	ErrHTTPRequestFailed = soap.ErrHTTPRequestFailed
 
 	// ErrEmptyResponseBody is returned when a response body is empty.
-	ErrEmptyResponseBody = errors.New("received empty response body")
+	ErrEmptyResponseBody = soap.ErrEmptyResponseBody
 
 	// ErrVideoSourceNotFound is returned when a video source is not found.
 	ErrVideoSourceNotFound = errors.New("video source not found")
 
+	// ErrNoVideoSource is returned when a profile has no video source configuration.
+	ErrNoVideoSource = errors.New("profile has no video source")
+
 	// ErrProfileNotFound is returned when a profile is not found.
 	ErrProfileNotFound = errors.New("profile not found")
 
@@ -60,6 +65,80 @@ var (
 	// ErrPresetNotFound is returned when a preset is not found.
 	ErrPresetNotFound = errors.New("preset not found")
 
+	// ErrNotFound is returned when a requested item does not exist on the device.
+	// SOAP faults such as ter:NoEntity or ter:NoProfile and HTTP 404 match it.
+	ErrNotFound = soap.ErrNotFound
+
+	// ErrUnauthorized matches SOAP faults and HTTP errors reporting that the caller
+	// is not authorized, e.g. ter:NotAuthorized, ter:Unauthorized or HTTP 401.
+	ErrUnauthorized = soap.ErrUnauthorized
+
+	// ErrInvalidArgument matches SOAP faults reporting an invalid argument, e.g.
+	// ter:InvalidArgVal or ter:InvalidArgs.
+	ErrInvalidArgument = soap.ErrInvalidArgument
+
+	// ErrActionNotSupported matches SOAP faults and HTTP errors reporting that the
+	// operation is not supported, e.g. ter:ActionNotSupported or HTTP 501.
+	ErrActionNotSupported = soap.ErrActionNotSupported
+
+	// ErrOperationProhibited matches SOAP faults reporting that the operation is
+	// not allowed in the current configuration, e.g. ter:OperationProhibited.
+	ErrOperationProhibited = soap.ErrOperationProhibited
+
+	// ErrIPFilterLimitReached is returned when adding IP address filter entries would
+	// exceed the maximum number of entries advertised by the device.
+	ErrIPFilterLimitReached = errors.New("IP address filter limit reached")
+
+	// ErrVideoEncoderLimitReached is returned when a profile would use more video encoder
+	// instances than the device guarantees for its video source.
+	ErrVideoEncoderLimitReached = errors.New("guaranteed video encoder instances exhausted")
+
+	// ErrOSDImageNotSupported is returned when an image OSD is requested on a device
+	// that only supports text OSDs.
+	ErrOSDImageNotSupported = errors.New("image OSD not supported")
+
+	// ErrProfileTokenExists is returned by CreateProfile when the device already
+	// has a profile with the requested token.
+	ErrProfileTokenExists = errors.New("profile token already exists")
+
+	// ErrCannotOverwriteHome is returned by SetHomePosition when the device has
+	// a fixed home position.
+	ErrCannotOverwriteHome = errors.New("home position cannot be overwritten")
+
+	// ErrFixedScope is returned by SetScopes and RemoveScopes when the device
+	// refuses to change a Fixed scope.
+	ErrFixedScope = errors.New("scope is fixed")
+
+	// ErrUsernameClash is returned by CreateUsers when the device already has a
+	// user with one of the requested usernames.
+	ErrUsernameClash = errors.New("username already exists")
+
+	// ErrFocusModeUnsupported is returned by Move when GetMoveOptions does not
+	// list the requested focus move.
+	ErrFocusModeUnsupported = errors.New("focus move mode not supported")
+
+	// ErrVideoSourceModeUnsupported is returned when the media service does not
+	// report the VideoSourceMode capability.
+	ErrVideoSourceModeUnsupported = errors.New("video source modes not supported")
+
+	// ErrWouldLockOut is returned when an IP address filter would block the address
+	// the client itself uses to reach the device.
+	ErrWouldLockOut = errors.New("IP address filter would lock out this client")
+
+	// ErrNoSnapshotSupport is returned by GetThumbnail when the device offers no
+	// snapshot URI; a still image then has to be decoded from the RTSP stream.
+	ErrNoSnapshotSupport = errors.New("device does not support snapshots")
+
+	// ErrSecureStreamingUnsupported is returned by GetStreamURI when an
+	// encrypted stream is requested but the device does not offer one.
+	ErrSecureStreamingUnsupported = errors.New("secure streaming not supported")
+
+	// ErrStreamNotSustainable is returned by SetStreamParameters when the stream
+	// exceeds the frame rates the device offers at its resolution or the encoder
+	// instances the device guarantees next to the other streams of the video
+	// source.
+	ErrStreamNotSustainable = errors.New("stream not sustainable")
+
 	// ErrTestRequestFailed is returned when a test request fails.
 	ErrTestRequestFailed = errors.New("test request failed")
 
@@ -72,6 +151,9 @@ var (
 	// ErrTestRequestUnexpectedStatus is returned when a test request has unexpected status.
 	ErrTestRequestUnexpectedStatus = errors.New("test request unexpected status")
 
+	// ErrInvalidDuration is returned when an xs:duration value cannot be parsed.
+	ErrInvalidDuration = errors.New("invalid duration")
+
 	// ErrURLMissingHost is returned when a URL is missing a host.
 	ErrURLMissingHost = errors.New("URL missing host")
 
@@ -88,6 +170,53 @@ var (
 	ErrRegularError = errors.New("regular error")
 )
 
+// SOAPFault is the error returned when the device responds with a SOAP fault.
+// Use errors.As to inspect the fault code, subcodes and detail; errors.Is with
+// ErrUnauthorized, ErrInvalidArgument, ErrActionNotSupported or ErrNotFound
+// classifies it portably across vendors.
+type SOAPFault = soap.FaultError
+
+// HTTPError is the error returned when the device responds with a non-200
+// status that does not carry a SOAP fault.
+type HTTPError = soap.HTTPError
+
+// Optional calls fn for an operation the device may not implement. Errors
+// matching ErrActionNotSupported or ErrOperationProhibited, and bare HTTP 500
+// errors with WithLenientServerErrors, are reported as ok == false with a nil
+// error; any other error is returned as is.
+//
+//	osds, ok, err := onvif.Optional(func() ([]*onvif.OSDConfiguration, error) {
+//		return client.GetOSDs(ctx, "")
+//	})
+func Optional[T any](fn func() (T, error)) (result T, ok bool, err error) {
+	result, err = fn()
+	if err == nil {
+		return result, true, nil
+	}
+
+	if isUnsupportedError(err) || errors.Is(err, ErrOperationProhibited) {
+		var zero T
+
+		return zero, false, nil
+	}
+
+	return result, false, err
+}
+
+// isUnsupportedError reports whether err says that the device does not
+// implement an operation: it matches ErrActionNotSupported, or it is a bare
+// HTTP 500 and WithLenientServerErrors is set. Only calls probing for an
+// optional operation use it.
+func isUnsupportedError(err error) bool {
+	if errors.Is(err, ErrActionNotSupported) {
+		return true
+	}
+
+	var httpErr *soap.HTTPError
+
+	return errors.As(err, &httpErr) && httpErr.UnsupportedServerError()
+}
+
 // ONVIFError represents an ONVIF-specific error.
 type ONVIFError struct {
 	Code    string
@@ -0,0 +1,113 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOptional(t *testing.T) {
+	tests := []struct {
+		name    string
+		subcode string
+		wantOK  bool
+		wantErr error
+	}{
+		{"supported", "", true, nil},
+		{"action not supported", "ter:ActionNotSupported", false, nil},
+		{"operation prohibited", "ter:OperationProhibited", false, nil},
+		{"invalid argument", "ter:InvalidArgVal", false, ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/soap+xml")
+
+				if tt.subcode == "" {
+					_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+						<tds:GetHostnameResponse><tds:HostnameInformation><tt:Name>cam</tt:Name></tds:HostnameInformation></tds:GetHostnameResponse>
+					</soap:Body></soap:Envelope>`))
+
+					return
+				}
+
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<soap:Fault>
+		<soap:Code><soap:Value>soap:Sender</soap:Value><soap:Subcode><soap:Value>` + tt.subcode + `</soap:Value></soap:Subcode></soap:Code>
+		<soap:Reason><soap:Text xml:lang="en">Fault</soap:Text></soap:Reason>
+	</soap:Fault>
+</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			info, ok, err := Optional(func() (*HostnameInformation, error) {
+				return client.GetHostname(context.Background())
+			})
+
+			if ok != tt.wantOK || !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Optional() = %v, %v, want %v, %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+
+			if ok && info.Name != "cam" {
+				t.Errorf("Expected hostname cam, got %q", info.Name)
+			}
+
+			if !ok && info != nil {
+				t.Errorf("Expected zero result, got %+v", info)
+			}
+		})
+	}
+}
+
+// TestOptionalLenientServerErrors reproduces devices that answer unsupported
+// operations with an HTML error page and status 500 instead of a SOAP fault.
+func TestOptionalLenientServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<html><head><title>500 Internal Server Error</title></head>
+<body><h1>Internal Server Error</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	optional := func(opts ...ClientOption) (bool, error) {
+		client, err := NewClient(server.URL, opts...)
+		if err != nil {
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+
+		_, ok, err := Optional(func() ([]*OSDConfiguration, error) {
+			return client.GetOSDs(context.Background(), "")
+		})
+
+		return ok, err
+	}
+
+	if ok, err := optional(); ok || !errors.Is(err, ErrHTTPRequestFailed) || errors.Is(err, ErrActionNotSupported) {
+		t.Errorf("Expected a plain HTTP error by default, got %v, %v", ok, err)
+	}
+
+	if ok, err := optional(WithLenientServerErrors()); ok || err != nil {
+		t.Errorf("Expected the operation to be reported as unsupported, got %v, %v", ok, err)
+	}
+
+	// Calls outside Optional keep reporting the server error.
+	client, err := NewClient(server.URL, WithLenientServerErrors())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.GetOSDs(context.Background(), ""); errors.Is(err, ErrActionNotSupported) {
+		t.Errorf("Expected a plain HTTP error outside Optional, got %v", err)
+	}
+}
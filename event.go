@@ -5,6 +5,9 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/0x524a/onvif-go/internal/soap"
@@ -20,9 +23,9 @@ var (
 	// ErrInvalidTerminationTime is returned when termination time is invalid.
 	ErrInvalidTerminationTime = errors.New("invalid termination time")
 	// ErrInvalidMessageLimit is returned when message limit is invalid.
-	ErrInvalidMessageLimit = errors.New("invalid message limit: must be positive")
+	ErrInvalidMessageLimit = errors.New("invalid message limit: must not be negative")
 	// ErrInvalidTimeout is returned when timeout is invalid.
-	ErrInvalidTimeout = errors.New("invalid timeout: must be positive")
+	ErrInvalidTimeout = errors.New("invalid timeout: must not be negative")
 	// ErrInvalidFilter is returned when filter expression is invalid.
 	ErrInvalidFilter = errors.New("invalid filter expression")
 	// ErrInvalidEventBrokerAddress is returned when event broker address is empty.
@@ -31,8 +34,27 @@ var (
 	ErrPullPointNotSupported = errors.New("pull point subscription not supported")
 	// ErrEventBrokerConfigNil is returned when event broker config is nil.
 	ErrEventBrokerConfigNil = errors.New("event broker config cannot be nil")
+	// ErrSeekUnsupported is returned by Seek when the device does not store
+	// events for seeking.
+	ErrSeekUnsupported = errors.New("event seek not supported")
+	// ErrAuthFailureEventsUnsupported is returned by SubscribeAuthFailures when
+	// the device advertises no authentication failure event topic.
+	ErrAuthFailureEventsUnsupported = errors.New("authentication failure events not supported")
 )
 
+// Defaults used by PullMessages for a zero timeout or message limit.
+const (
+	// DefaultPullMessagesTimeout is a pull timeout accepted by most devices.
+	DefaultPullMessagesTimeout = 10 * time.Second
+	// DefaultPullMessagesLimit is a message limit accepted by most devices.
+	DefaultPullMessagesLimit = 100
+)
+
+// pullMessagesDeadlineMargin is added to the pull timeout to bound a
+// PullMessages call whose context has no deadline, leaving the device time to
+// answer once the long poll ends.
+const pullMessagesDeadlineMargin = 5 * time.Second
+
 // EventServiceCapabilities represents the capabilities of the event service.
 type EventServiceCapabilities struct {
 	WSSubscriptionPolicySupport                   bool
@@ -140,14 +162,14 @@ func (c *Client) GetEventServiceCapabilities(ctx context.Context) (*EventService
 	type GetServiceCapabilitiesResponse struct {
 		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
 		Capabilities struct {
-			WSSubscriptionPolicySupport                   bool   `xml:"WSSubscriptionPolicySupport,attr"`
-			WSPausableSubscriptionManagerInterfaceSupport bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport,attr"`
+			WSSubscriptionPolicySupport                   Bool   `xml:"WSSubscriptionPolicySupport,attr"`
+			WSPausableSubscriptionManagerInterfaceSupport Bool   `xml:"WSPausableSubscriptionManagerInterfaceSupport,attr"`
 			MaxNotificationProducers                      int    `xml:"MaxNotificationProducers,attr"`
 			MaxPullPoints                                 int    `xml:"MaxPullPoints,attr"`
-			PersistentNotificationStorage                 bool   `xml:"PersistentNotificationStorage,attr"`
+			PersistentNotificationStorage                 Bool   `xml:"PersistentNotificationStorage,attr"`
 			EventBrokerProtocols                          string `xml:"EventBrokerProtocols,attr"`
 			MaxEventBrokers                               int    `xml:"MaxEventBrokers,attr"`
-			MetadataOverMQTT                              bool   `xml:"MetadataOverMQTT,attr"`
+			MetadataOverMQTT                              Bool   `xml:"MetadataOverMQTT,attr"`
 		} `xml:"Capabilities"`
 	}
 
@@ -157,21 +179,22 @@ func (c *Client) GetEventServiceCapabilities(ctx context.Context) (*EventService
 
 	var resp GetServiceCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if !c.cachedCapabilities(eventNamespace, &resp.Capabilities) {
+		soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetEventServiceCapabilities failed: %w", err)
+		if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+			return nil, fmt.Errorf("GetEventServiceCapabilities failed: %w", err)
+		}
 	}
 
 	caps := &EventServiceCapabilities{
-		WSSubscriptionPolicySupport:                   resp.Capabilities.WSSubscriptionPolicySupport,
-		WSPausableSubscriptionManagerInterfaceSupport: resp.Capabilities.WSPausableSubscriptionManagerInterfaceSupport,
+		WSSubscriptionPolicySupport:                   bool(resp.Capabilities.WSSubscriptionPolicySupport),
+		WSPausableSubscriptionManagerInterfaceSupport: bool(resp.Capabilities.WSPausableSubscriptionManagerInterfaceSupport),
 		MaxNotificationProducers:                      resp.Capabilities.MaxNotificationProducers,
 		MaxPullPoints:                                 resp.Capabilities.MaxPullPoints,
-		PersistentNotificationStorage:                 resp.Capabilities.PersistentNotificationStorage,
+		PersistentNotificationStorage:                 bool(resp.Capabilities.PersistentNotificationStorage),
 		MaxEventBrokers:                               resp.Capabilities.MaxEventBrokers,
-		MetadataOverMQTT:                              resp.Capabilities.MetadataOverMQTT,
+		MetadataOverMQTT:                              bool(resp.Capabilities.MetadataOverMQTT),
 	}
 
 	// Parse event broker protocols from space-separated string.
@@ -237,8 +260,7 @@ func (c *Client) CreatePullPointSubscription(
 
 	var resp CreatePullPointSubscriptionResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("CreatePullPointSubscription failed: %w", err)
@@ -264,6 +286,10 @@ func (c *Client) CreatePullPointSubscription(
 }
 
 // PullMessages pulls notification messages from a pull point subscription.
+// A zero timeout or messageLimit selects DefaultPullMessagesTimeout or
+// DefaultPullMessagesLimit. Values outside the limits the device advertised
+// are clamped to them; negative values return ErrInvalidTimeout or
+// ErrInvalidMessageLimit.
 func (c *Client) PullMessages(
 	ctx context.Context,
 	subscriptionReference string,
@@ -274,14 +300,22 @@ func (c *Client) PullMessages(
 		return nil, ErrInvalidSubscriptionReference
 	}
 
-	if timeout <= 0 {
+	if timeout < 0 {
 		return nil, ErrInvalidTimeout
 	}
 
-	if messageLimit <= 0 {
+	if timeout == 0 {
+		timeout = DefaultPullMessagesTimeout
+	}
+
+	if messageLimit < 0 {
 		return nil, ErrInvalidMessageLimit
 	}
 
+	if messageLimit == 0 {
+		messageLimit = DefaultPullMessagesLimit
+	}
+
 	type PullMessages struct {
 		XMLName      xml.Name `xml:"tev:PullMessages"`
 		Xmlns        string   `xml:"xmlns:tev,attr"`
@@ -321,18 +355,30 @@ func (c *Client) PullMessages(
 		} `xml:"NotificationMessage"`
 	}
 
-	req := PullMessages{
-		Xmlns:        eventNamespace,
-		Timeout:      formatDuration(timeout),
-		MessageLimit: messageLimit,
-	}
-
 	var resp PullMessagesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
+
+	for attempt := 0; ; attempt++ {
+		timeout, messageLimit = c.clampPullMessages(timeout, messageLimit)
+
+		req := PullMessages{
+			Xmlns:        eventNamespace,
+			Timeout:      formatDuration(timeout),
+			MessageLimit: messageLimit,
+		}
+
+		err := c.pullMessages(ctx, soapClient, subscriptionReference, timeout, req, &resp)
+		if err == nil {
+			break
+		}
+
+		// A device rejecting the requested values advertises its limits in the
+		// fault; remember them and retry once within bounds.
+		if attempt == 0 && c.learnPullMessagesLimits(err) {
+			continue
+		}
 
-	if err := soapClient.Call(ctx, subscriptionReference, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("PullMessages failed: %w", err)
 	}
 
@@ -376,12 +422,58 @@ func (c *Client) PullMessages(
 	return messages, nil
 }
 
-// Seek seeks to a specific position in the event stream.
+// pullMessages makes one PullMessages call. A context without a deadline is
+// bounded by the pull timeout plus a margin rather than by
+// WithDefaultCallTimeout, which may be shorter than the long poll.
+func (c *Client) pullMessages(
+	ctx context.Context,
+	soapClient *soap.Client,
+	subscriptionReference string,
+	timeout time.Duration,
+	req, resp interface{},
+) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout+pullMessagesDeadlineMargin)
+		defer cancel()
+	}
+
+	return soapClient.Call(ctx, subscriptionReference, "", req, resp)
+}
+
+// SeekSupported reports whether the device stores events persistently, so
+// that pull-point subscriptions can seek in them, as advertised by the
+// PersistentNotificationStorage event service capability (Profile G). The
+// capabilities are cached like those of the other client-side checks, so
+// that Seek does not read them on every call.
+func (c *Client) SeekSupported(ctx context.Context) (bool, error) {
+	caps, err := checkedCapabilities(ctx, c, eventNamespace, c.GetEventServiceCapabilities)
+	if err != nil {
+		return false, err
+	}
+
+	return caps.PersistentNotificationStorage, nil
+}
+
+// Seek rewinds or fast-forwards a pull-point subscription to utcTime, so that
+// the following PullMessages calls return the stored events from that point,
+// in reverse order if reverse is set. It returns ErrSeekUnsupported if the
+// device does not advertise persistent notification storage or rejects the
+// request as not supported. If the capabilities cannot be read, the request
+// is sent anyway.
 func (c *Client) Seek(ctx context.Context, subscriptionReference string, utcTime time.Time, reverse bool) error {
 	if subscriptionReference == "" {
 		return ErrInvalidSubscriptionReference
 	}
 
+	supported, err := c.SeekSupported(ctx)
+	if err != nil {
+		c.skipCheck("seek capability check", err)
+	} else if !supported {
+		return fmt.Errorf("Seek failed: %w", ErrSeekUnsupported)
+	}
+
 	type Seek struct {
 		XMLName xml.Name `xml:"tev:Seek"`
 		Xmlns   string   `xml:"xmlns:tev,attr"`
@@ -395,16 +487,19 @@ func (c *Client) Seek(ctx context.Context, subscriptionReference string, utcTime
 
 	req := Seek{
 		Xmlns:   eventNamespace,
-		UtcTime: utcTime.Format(time.RFC3339),
+		UtcTime: utcTime.UTC().Format(time.RFC3339),
 		Reverse: reverse,
 	}
 
 	var resp SeekResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, subscriptionReference, "", req, &resp); err != nil {
+		if isUnsupportedError(err) {
+			return fmt.Errorf("Seek failed: %w: %w", ErrSeekUnsupported, err)
+		}
+
 		return fmt.Errorf("Seek failed: %w", err)
 	}
 
@@ -432,8 +527,7 @@ func (c *Client) SetEventSynchronizationPoint(ctx context.Context, subscriptionR
 
 	var resp SetSynchronizationPointResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, subscriptionReference, "", req, &resp); err != nil {
 		return fmt.Errorf("SetSynchronizationPoint failed: %w", err)
@@ -463,8 +557,7 @@ func (c *Client) Unsubscribe(ctx context.Context, subscriptionReference string)
 
 	var resp UnsubscribeResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, subscriptionReference, "", req, &resp); err != nil {
 		return fmt.Errorf("Unsubscribe failed: %w", err)
@@ -506,8 +599,7 @@ func (c *Client) RenewSubscription(
 
 	var resp RenewResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, subscriptionReference, "", req, &resp); err != nil {
 		return time.Time{}, time.Time{}, fmt.Errorf("RenewSubscription failed: %w", err)
@@ -542,7 +634,7 @@ func (c *Client) GetEventProperties(ctx context.Context) (*EventProperties, erro
 	type GetEventPropertiesResponse struct {
 		XMLName                         xml.Name `xml:"GetEventPropertiesResponse"`
 		TopicNamespaceLocation          []string `xml:"TopicNamespaceLocation"`
-		FixedTopicSet                   bool     `xml:"FixedTopicSet"`
+		FixedTopicSet                   Bool     `xml:"FixedTopicSet"`
 		TopicExpressionDialect          []string `xml:"TopicExpressionDialect"`
 		MessageContentFilterDialect     []string `xml:"MessageContentFilterDialect"`
 		ProducerPropertiesFilterDialect []string `xml:"ProducerPropertiesFilterDialect"`
@@ -555,8 +647,7 @@ func (c *Client) GetEventProperties(ctx context.Context) (*EventProperties, erro
 
 	var resp GetEventPropertiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetEventProperties failed: %w", err)
@@ -564,7 +655,7 @@ func (c *Client) GetEventProperties(ctx context.Context) (*EventProperties, erro
 
 	properties := &EventProperties{
 		TopicNamespaceLocation:           resp.TopicNamespaceLocation,
-		FixedTopicSet:                    resp.FixedTopicSet,
+		FixedTopicSet:                    bool(resp.FixedTopicSet),
 		TopicExpressionDialects:          resp.TopicExpressionDialect,
 		MessageContentFilterDialects:     resp.MessageContentFilterDialect,
 		ProducerPropertiesFilterDialects: resp.ProducerPropertiesFilterDialect,
@@ -625,8 +716,7 @@ func (c *Client) AddEventBroker(ctx context.Context, config *EventBrokerConfig)
 
 	var resp AddEventBrokerResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("AddEventBroker failed: %w", err)
@@ -660,8 +750,7 @@ func (c *Client) DeleteEventBroker(ctx context.Context, address string) error {
 
 	var resp DeleteEventBrokerResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return fmt.Errorf("DeleteEventBroker failed: %w", err)
@@ -690,7 +779,7 @@ func (c *Client) GetEventBrokers(ctx context.Context) ([]*EventBrokerConfig, err
 			PublishFilter      string `xml:"PublishFilter"`
 			QoS                int    `xml:"QoS"`
 			Status             string `xml:"Status"`
-			CertPathValidation bool   `xml:"CertPathValidation"`
+			CertPathValidation Bool   `xml:"CertPathValidation"`
 			MetadataFilter     string `xml:"MetadataFilter"`
 		} `xml:"EventBroker"`
 	}
@@ -701,8 +790,7 @@ func (c *Client) GetEventBrokers(ctx context.Context) ([]*EventBrokerConfig, err
 
 	var resp GetEventBrokersResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetEventBrokers failed: %w", err)
@@ -720,7 +808,7 @@ func (c *Client) GetEventBrokers(ctx context.Context) ([]*EventBrokerConfig, err
 			PublishFilter:      eb.PublishFilter,
 			QoS:                eb.QoS,
 			Status:             eb.Status,
-			CertPathValidation: eb.CertPathValidation,
+			CertPathValidation: bool(eb.CertPathValidation),
 			MetadataFilter:     eb.MetadataFilter,
 		}
 	}
@@ -745,6 +833,117 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("PT%dM%dS", minutes, seconds)
 }
 
+// clampPullMessages limits the pull timeout and message limit to the maximums
+// the device has advertised, logging any adjustment.
+func (c *Client) clampPullMessages(timeout time.Duration, messageLimit int) (time.Duration, int) {
+	c.mu.RLock()
+	maxTimeout, maxMessageLimit := c.pullMaxTimeout, c.pullMaxMessageLimit
+	c.mu.RUnlock()
+
+	if maxTimeout > 0 && timeout > maxTimeout {
+		c.logf("onvif: PullMessages timeout %s exceeds device maximum, using %s", timeout, maxTimeout)
+		timeout = maxTimeout
+	}
+
+	if maxMessageLimit > 0 && messageLimit > maxMessageLimit {
+		c.logf("onvif: PullMessages limit %d exceeds device maximum, using %d", messageLimit, maxMessageLimit)
+		messageLimit = maxMessageLimit
+	}
+
+	return timeout, messageLimit
+}
+
+// learnPullMessagesLimits records the limits carried by a PullMessagesFaultResponse.
+// It reports whether err was such a fault.
+func (c *Client) learnPullMessagesLimits(err error) bool {
+	var fault *soap.FaultError
+	if !errors.As(err, &fault) || fault.Detail == "" {
+		return false
+	}
+
+	var detail struct {
+		XMLName         xml.Name `xml:"PullMessagesFaultResponse"`
+		MaxTimeout      string   `xml:"MaxTimeout"`
+		MaxMessageLimit int      `xml:"MaxMessageLimit"`
+	}
+
+	if xml.Unmarshal([]byte(fault.Detail), &detail) != nil {
+		return false
+	}
+
+	maxTimeout, parseErr := parseDuration(detail.MaxTimeout)
+	if parseErr != nil && detail.MaxMessageLimit <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if parseErr == nil && maxTimeout > 0 {
+		c.pullMaxTimeout = maxTimeout
+	}
+
+	if detail.MaxMessageLimit > 0 {
+		c.pullMaxMessageLimit = detail.MaxMessageLimit
+	}
+
+	return true
+}
+
+// xsDurationPattern matches an xs:duration value such as "PT1M30S" or "P0Y0M0DT0H0M10.5S".
+var xsDurationPattern = regexp.MustCompile(
+	`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`,
+)
+
+// parseDuration parses an ISO 8601 xs:duration value. Years and months are
+// approximated as 365 and 30 days, which is sufficient for the timeouts ONVIF uses.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	m := xsDurationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+	}
+
+	units := []time.Duration{
+		365 * 24 * time.Hour, //nolint:mnd // days in a year
+		30 * 24 * time.Hour,  //nolint:mnd // days in a month
+		24 * time.Hour,       //nolint:mnd // hours in a day
+		time.Hour,
+		time.Minute,
+	}
+
+	var d time.Duration
+
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+
+		n, err := strconv.ParseInt(m[i+2], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+
+		d += time.Duration(n) * unit
+	}
+
+	if m[7] != "" {
+		secs, err := strconv.ParseFloat(m[7], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+
+		d += time.Duration(secs * float64(time.Second))
+	}
+
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
 // splitSpaceSeparated splits a space-separated string into a slice.
 func splitSpaceSeparated(s string) []string {
 	if s == "" {
@@ -0,0 +1,137 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// onvifTopicNamespace is the namespace of the ONVIF topic tree, bound to the
+// tns1 prefix in topic expressions.
+const onvifTopicNamespace = "http://www.onvif.org/ver10/topics"
+
+// authFailureEventBuffer is the number of events SubscribeAuthFailures queues
+// for a slow reader before pulling blocks.
+const authFailureEventBuffer = 16
+
+// AuthFailureEvent is an authentication failure warning raised by the device
+// according to its AuthFailureWarningConfiguration.
+type AuthFailureEvent struct {
+	// Time is the device time of the event.
+	Time time.Time
+	// Topic is the topic of the notification.
+	Topic string
+	// Address is the address of the client that failed to authenticate.
+	Address string
+	// User is the user name the client presented.
+	User string
+	// Count is the number of failures reported; zero if the device does not
+	// report one.
+	Count int
+	// Message is the notification the event was decoded from.
+	Message NotificationMessage
+}
+
+// SubscribeAuthFailures subscribes to the authentication failure warnings of
+// the device, e.g. to forward them to a SIEM. The pull point subscription is
+// filtered to the auth failure topic advertised in the event properties, and
+// is renewed and recreated as needed, see ManageSubscription. Events are
+// delivered on the returned channel, which is closed once the subscription
+// ends; call the returned function to unsubscribe. ErrAuthFailureEventsUnsupported
+// is returned if the device advertises no such topic.
+func (c *Client) SubscribeAuthFailures(ctx context.Context) (<-chan *AuthFailureEvent, func(), error) {
+	topic, err := c.authFailureTopic(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := SubscriptionConfig{
+		Key:               c.Endpoint() + "#" + topic,
+		Filter:            topic,
+		Store:             NewMemorySubscriptionStore(),
+		TerminationTime:   DefaultSubscriptionTermination,
+		PullTimeout:       DefaultPullMessagesTimeout,
+		MessageLimit:      DefaultPullMessagesLimit,
+		UnsubscribeOnExit: true,
+	}
+
+	// Create the subscription up front so that the caller sees the error;
+	// ManageSubscription resumes it from the store.
+	if _, err := c.createManagedSubscription(ctx, &config, 0); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan *AuthFailureEvent, authFailureEventBuffer)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(events)
+
+		err := c.ManageSubscription(ctx, config, func(msg NotificationMessage) {
+			select {
+			case events <- decodeAuthFailureEvent(msg):
+			case <-ctx.Done():
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			c.logf("onvif: auth failure subscription ended: %v", err)
+		}
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+	}
+
+	return events, stop, nil
+}
+
+// authFailureTopic returns the topic expression of the first ONVIF topic
+// advertised by the device whose name mentions authentication failures.
+func (c *Client) authFailureTopic(ctx context.Context) (string, error) {
+	topics, err := c.GetEventTopics(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, topic := range topics {
+		segments := strings.Split(topic.Path, "/")
+		if topic.Namespace == onvifTopicNamespace &&
+			strings.Contains(strings.ToLower(segments[len(segments)-1]), "authfailure") {
+			return "tns1:" + topic.Path, nil
+		}
+	}
+
+	return "", ErrAuthFailureEventsUnsupported
+}
+
+// decodeAuthFailureEvent extracts the client address, user name and failure
+// count from the source and data items of a notification. Devices name these
+// items differently, so the common spellings are accepted.
+func decodeAuthFailureEvent(msg NotificationMessage) *AuthFailureEvent {
+	event := &AuthFailureEvent{
+		Time:    msg.Message.UtcTime,
+		Topic:   msg.Topic,
+		Message: msg,
+	}
+
+	items := append(append([]SimpleItem{}, msg.Message.Source...), msg.Message.Data...)
+	for _, item := range items {
+		switch strings.ToLower(item.Name) {
+		case "address", "ipaddress", "ip", "sourceip", "sourceaddress", "clientaddress", "remoteaddress":
+			event.Address = item.Value
+		case "user", "username", "userid", "login":
+			event.User = item.Value
+		case "count", "failurecount", "failures", "attempts":
+			if n, err := strconv.Atoi(strings.TrimSpace(item.Value)); err == nil {
+				event.Count = n
+			}
+		}
+	}
+
+	return event
+}
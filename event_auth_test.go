@@ -0,0 +1,137 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newMockAuthFailureServer(t *testing.T, advertise bool, unsubscribed *atomic.Bool) *httptest.Server {
+	t.Helper()
+
+	topic := ""
+	if advertise {
+		topic = `<tns1:Monitoring><AuthFailureWarning wstop:topic="true"/></tns1:Monitoring>`
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		now := time.Now().UTC()
+		current := now.Format(time.RFC3339)
+		termination := now.Add(time.Hour).Format(time.RFC3339)
+
+		var response string
+
+		switch {
+		case strings.Contains(bodyStr, "GetEventProperties"):
+			response = `<tev:GetEventPropertiesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+				xmlns:wstop="http://docs.oasis-open.org/wsn/t-1" xmlns:tns1="http://www.onvif.org/ver10/topics">
+				<wstop:TopicSet><tns1:VideoSource><MotionAlarm wstop:topic="true"/></tns1:VideoSource>` + topic + `</wstop:TopicSet>
+			</tev:GetEventPropertiesResponse>`
+		case strings.Contains(bodyStr, "CreatePullPointSubscription"):
+			if !strings.Contains(bodyStr, "tns1:Monitoring/AuthFailureWarning") {
+				t.Errorf("Expected an auth failure topic filter, got %s", bodyStr)
+			}
+
+			response = `<tev:CreatePullPointSubscriptionResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+				<tev:SubscriptionReference><wsa:Address xmlns:wsa="http://www.w3.org/2005/08/addressing">` +
+				server.URL + `/subscription</wsa:Address></tev:SubscriptionReference>
+				<tev:CurrentTime>` + current + `</tev:CurrentTime>
+				<tev:TerminationTime>` + termination + `</tev:TerminationTime>
+			</tev:CreatePullPointSubscriptionResponse>`
+		case strings.Contains(bodyStr, "Unsubscribe"):
+			unsubscribed.Store(true)
+
+			response = `<wsnt:UnsubscribeResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"/>`
+		case strings.Contains(bodyStr, "Renew"):
+			response = `<wsnt:RenewResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">
+				<wsnt:CurrentTime>` + current + `</wsnt:CurrentTime>
+				<wsnt:TerminationTime>` + termination + `</wsnt:TerminationTime>
+			</wsnt:RenewResponse>`
+		case strings.Contains(bodyStr, "PullMessages"):
+			response = `<tev:PullMessagesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+				xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tev:CurrentTime>` + current + `</tev:CurrentTime>
+				<tev:TerminationTime>` + termination + `</tev:TerminationTime>
+				<wsnt:NotificationMessage>
+					<wsnt:Topic>tns1:Monitoring/AuthFailureWarning</wsnt:Topic>
+					<wsnt:Message UtcTime="2026-01-02T03:04:05Z">
+						<tt:Source><tt:SimpleItem Name="IPAddress" Value="192.0.2.7"/></tt:Source>
+						<tt:Data><tt:SimpleItem Name="User" Value="admin"/><tt:SimpleItem Name="Count" Value="5"/></tt:Data>
+					</wsnt:Message>
+				</wsnt:NotificationMessage>
+			</tev:PullMessagesResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+
+	return server
+}
+
+func TestSubscribeAuthFailures(t *testing.T) {
+	var unsubscribed atomic.Bool
+
+	server := newMockAuthFailureServer(t, true, &unsubscribed)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	events, stop, err := client.SubscribeAuthFailures(context.Background())
+	if err != nil {
+		t.Fatalf("SubscribeAuthFailures() failed: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Address != "192.0.2.7" || event.User != "admin" || event.Count != 5 {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+
+		if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !event.Time.Equal(want) {
+			t.Errorf("Time = %v, want %v", event.Time, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+
+	stop()
+
+	// The channel is closed once the subscription has ended.
+	for range events {
+	}
+
+	if !unsubscribed.Load() {
+		t.Error("Expected stop to unsubscribe")
+	}
+}
+
+func TestSubscribeAuthFailuresUnsupported(t *testing.T) {
+	var unsubscribed atomic.Bool
+
+	server := newMockAuthFailureServer(t, false, &unsubscribed)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, _, err := client.SubscribeAuthFailures(context.Background()); !errors.Is(err, ErrAuthFailureEventsUnsupported) {
+		t.Errorf("Expected ErrAuthFailureEventsUnsupported, got %v", err)
+	}
+}
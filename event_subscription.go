@@ -0,0 +1,405 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults used by ManageSubscription.
+const (
+	// DefaultSubscriptionTermination is the termination time requested for managed subscriptions.
+	DefaultSubscriptionTermination = time.Minute
+	// DefaultSubscriptionRetryDelay is the delay before recreating a failed
+	// subscription; it doubles with each further failure.
+	DefaultSubscriptionRetryDelay = time.Second
+	// DefaultSubscriptionMaxRetryDelay caps the delay between attempts to
+	// recreate a failed subscription.
+	DefaultSubscriptionMaxRetryDelay = time.Minute
+)
+
+// SubscriptionState is the persisted state of a pull point subscription.
+type SubscriptionState struct {
+	// Reference is the subscription manager address returned by the device.
+	Reference string
+	// Filter is the topic expression the subscription was created with.
+	Filter string
+	// TerminationTime is when the subscription expires, in local clock time.
+	TerminationTime time.Time
+	// Sequence is the number of notifications delivered to the handler under
+	// the subscription key. It carries over when the subscription is renewed
+	// or recreated, so that a resumed consumer can tell how far it got.
+	Sequence uint64
+}
+
+// SubscriptionStore persists subscription state so that event subscriptions can
+// be resumed after a process restart. Load returns ErrNotFound when no state is
+// stored under key.
+type SubscriptionStore interface {
+	Save(ctx context.Context, key string, state *SubscriptionState) error
+	Load(ctx context.Context, key string) (*SubscriptionState, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// MemorySubscriptionStore is an in-memory SubscriptionStore. It is the default
+// store of ManageSubscription and does not survive a process restart.
+type MemorySubscriptionStore struct {
+	mu     sync.Mutex
+	states map[string]SubscriptionState
+}
+
+// NewMemorySubscriptionStore creates an empty in-memory subscription store.
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{
+		states: make(map[string]SubscriptionState),
+	}
+}
+
+// Save stores the subscription state under key.
+func (s *MemorySubscriptionStore) Save(_ context.Context, key string, state *SubscriptionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = *state
+
+	return nil
+}
+
+// Load returns the subscription state stored under key.
+func (s *MemorySubscriptionStore) Load(_ context.Context, key string) (*SubscriptionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return nil, fmt.Errorf("subscription %q: %w", key, ErrNotFound)
+	}
+
+	return &state, nil
+}
+
+// Delete removes the subscription state stored under key.
+func (s *MemorySubscriptionStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+
+	return nil
+}
+
+// SubscriptionConfig configures ManageSubscription.
+type SubscriptionConfig struct {
+	// Key identifies the subscription in Store. Defaults to the device endpoint.
+	Key string
+	// Filter is an optional topic expression.
+	Filter string
+	// Store persists the subscription state. Defaults to an in-memory store.
+	Store SubscriptionStore
+	// TerminationTime is the subscription lifetime requested on create and renew.
+	// Defaults to DefaultSubscriptionTermination.
+	TerminationTime time.Duration
+	// PullTimeout defaults to DefaultPullMessagesTimeout.
+	PullTimeout time.Duration
+	// MessageLimit defaults to DefaultPullMessagesLimit.
+	MessageLimit int
+	// RetryDelay defaults to DefaultSubscriptionRetryDelay.
+	RetryDelay time.Duration
+	// MaxRetryDelay defaults to DefaultSubscriptionMaxRetryDelay.
+	MaxRetryDelay time.Duration
+	// UnsubscribeOnExit unsubscribes and deletes the stored state when the
+	// context is cancelled. By default the subscription is left active so a
+	// restarted process can resume it.
+	UnsubscribeOnExit bool
+}
+
+// ManageSubscription runs a pull point subscription until ctx is cancelled,
+// delivering every notification to handler. A subscription found in the store
+// is resumed, being renewed if it has not yet expired and recreated otherwise;
+// the subscription is renewed before it terminates and recreated if the device
+// drops it. A subscription that is replaced is unsubscribed on a best-effort
+// basis so that its pull point does not linger on the device. Failed attempts
+// are retried with an exponential backoff from RetryDelay up to MaxRetryDelay,
+// so it only returns once ctx is cancelled, with ctx.Err(), or when the
+// store fails. The state, including its Sequence, is saved whenever the
+// subscription changes and after every batch of delivered notifications.
+func (c *Client) ManageSubscription(
+	ctx context.Context,
+	config SubscriptionConfig,
+	handler func(NotificationMessage),
+) error {
+	if config.Key == "" {
+		config.Key = c.Endpoint()
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemorySubscriptionStore()
+	}
+
+	if config.TerminationTime <= 0 {
+		config.TerminationTime = DefaultSubscriptionTermination
+	}
+
+	if config.PullTimeout <= 0 {
+		config.PullTimeout = DefaultPullMessagesTimeout
+	}
+
+	if config.MessageLimit <= 0 {
+		config.MessageLimit = DefaultPullMessagesLimit
+	}
+
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultSubscriptionRetryDelay
+	}
+
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = DefaultSubscriptionMaxRetryDelay
+	}
+
+	config.MaxRetryDelay = max(config.MaxRetryDelay, config.RetryDelay)
+
+	backoff := &subscriptionBackoff{initial: config.RetryDelay, limit: config.MaxRetryDelay}
+	backoff.reset()
+
+	state, err := c.restoreSubscription(ctx, &config, backoff)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return c.exitSubscription(state, &config, ctx.Err())
+		}
+
+		// Renew once less than half the lifetime remains.
+		if time.Until(state.TerminationTime) < config.TerminationTime/2 { //nolint:mnd // renew at half-life
+			if state, err = c.renewOrRecreateSubscription(ctx, state, &config, backoff); err != nil {
+				return c.exitSubscription(state, &config, err)
+			}
+		}
+
+		messages, err := c.PullMessages(ctx, state.Reference, config.PullTimeout, config.MessageLimit)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.exitSubscription(state, &config, ctx.Err())
+			}
+
+			c.logf("onvif: PullMessages failed, recreating subscription: %v", err)
+
+			if err := backoff.wait(ctx); err != nil {
+				return c.exitSubscription(state, &config, err)
+			}
+
+			if state, err = c.recreateSubscription(ctx, state, &config, backoff); err != nil {
+				return c.exitSubscription(state, &config, err)
+			}
+
+			continue
+		}
+
+		backoff.reset()
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		for i := range messages {
+			handler(messages[i])
+		}
+
+		state.Sequence += uint64(len(messages))
+
+		// The notifications were delivered, so record them even if the
+		// handler cancelled ctx.
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.PullTimeout)
+		err = config.Store.Save(saveCtx, config.Key, state)
+
+		cancel()
+
+		if err != nil {
+			return c.exitSubscription(state, &config, fmt.Errorf("%w: %w", errSubscriptionStore, err))
+		}
+	}
+}
+
+// restoreSubscription resumes the stored subscription when possible and creates a new one otherwise.
+func (c *Client) restoreSubscription(
+	ctx context.Context,
+	config *SubscriptionConfig,
+	backoff *subscriptionBackoff,
+) (*SubscriptionState, error) {
+	state, err := config.Store.Load(ctx, config.Key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("failed to load subscription state: %w", err)
+		}
+
+		return c.recreateSubscription(ctx, nil, config, backoff)
+	}
+
+	if state.Filter != config.Filter || !time.Now().Before(state.TerminationTime) {
+		return c.recreateSubscription(ctx, state, config, backoff)
+	}
+
+	return c.renewOrRecreateSubscription(ctx, state, config, backoff)
+}
+
+// renewOrRecreateSubscription renews a subscription, creating a new one if the device rejects the renewal.
+func (c *Client) renewOrRecreateSubscription(
+	ctx context.Context,
+	state *SubscriptionState,
+	config *SubscriptionConfig,
+	backoff *subscriptionBackoff,
+) (*SubscriptionState, error) {
+	current, termination, err := c.RenewSubscription(ctx, state.Reference, config.TerminationTime)
+	if err != nil {
+		c.logf("onvif: RenewSubscription failed, recreating subscription: %v", err)
+
+		return c.recreateSubscription(ctx, state, config, backoff)
+	}
+
+	renewed := &SubscriptionState{
+		Reference:       state.Reference,
+		Filter:          config.Filter,
+		TerminationTime: localTerminationTime(current, termination, config.TerminationTime),
+		Sequence:        state.Sequence,
+	}
+
+	if err := config.Store.Save(ctx, config.Key, renewed); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSubscriptionStore, err)
+	}
+
+	return renewed, nil
+}
+
+// recreateSubscription unsubscribes stale, if any, on a best-effort basis and
+// creates a new subscription, retrying with backoff until it succeeds, ctx is
+// done or the store fails.
+func (c *Client) recreateSubscription(
+	ctx context.Context,
+	stale *SubscriptionState,
+	config *SubscriptionConfig,
+	backoff *subscriptionBackoff,
+) (*SubscriptionState, error) {
+	if stale != nil && stale.Reference != "" {
+		unsubscribeCtx, cancel := context.WithTimeout(ctx, config.PullTimeout)
+		if err := c.Unsubscribe(unsubscribeCtx, stale.Reference); err != nil {
+			c.logf("onvif: Unsubscribe of replaced subscription failed: %v", err)
+		}
+		cancel()
+	}
+
+	var sequence uint64
+	if stale != nil {
+		sequence = stale.Sequence
+	}
+
+	for {
+		state, err := c.createManagedSubscription(ctx, config, sequence)
+		if err == nil || errors.Is(err, errSubscriptionStore) {
+			return state, err
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		c.logf("onvif: CreatePullPointSubscription failed, retrying: %v", err)
+
+		if err := backoff.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// errSubscriptionStore marks errors of the subscription store, which are not retried.
+var errSubscriptionStore = errors.New("failed to save subscription state")
+
+// subscriptionBackoff is the capped exponential delay between attempts to
+// re-establish a managed subscription.
+type subscriptionBackoff struct {
+	initial time.Duration
+	limit   time.Duration
+	next    time.Duration
+}
+
+// wait sleeps for the current delay, then doubles it up to the limit. It
+// returns ctx.Err() if ctx is done first.
+func (b *subscriptionBackoff) wait(ctx context.Context) error {
+	timer := time.NewTimer(b.next)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	b.next = min(2*b.next, b.limit)
+
+	return nil
+}
+
+// reset restores the initial delay after a success.
+func (b *subscriptionBackoff) reset() {
+	b.next = b.initial
+}
+
+// createManagedSubscription creates a new pull point subscription and stores
+// its state, continuing from sequence.
+func (c *Client) createManagedSubscription(
+	ctx context.Context,
+	config *SubscriptionConfig,
+	sequence uint64,
+) (*SubscriptionState, error) {
+	termination := config.TerminationTime
+
+	sub, err := c.CreatePullPointSubscription(ctx, config.Filter, &termination, "")
+	if err != nil {
+		return nil, err
+	}
+
+	state := &SubscriptionState{
+		Reference:       sub.SubscriptionReference,
+		Filter:          config.Filter,
+		TerminationTime: localTerminationTime(sub.CurrentTime, sub.TerminationTime, config.TerminationTime),
+		Sequence:        sequence,
+	}
+
+	if err := config.Store.Save(ctx, config.Key, state); err != nil {
+		return nil, fmt.Errorf("%w: %w", errSubscriptionStore, err)
+	}
+
+	return state, nil
+}
+
+// exitSubscription cleans up according to config and returns cause.
+func (c *Client) exitSubscription(state *SubscriptionState, config *SubscriptionConfig, cause error) error {
+	if !config.UnsubscribeOnExit || state == nil {
+		return cause
+	}
+
+	// The caller's context is done, so clean up with a fresh one.
+	ctx, cancel := context.WithTimeout(context.Background(), config.PullTimeout)
+	defer cancel()
+
+	if err := c.Unsubscribe(ctx, state.Reference); err != nil {
+		c.logf("onvif: Unsubscribe failed: %v", err)
+	}
+
+	if err := config.Store.Delete(ctx, config.Key); err != nil {
+		c.logf("onvif: failed to delete subscription state: %v", err)
+	}
+
+	return cause
+}
+
+// localTerminationTime converts a device termination time to the local clock
+// using the device's current time, so that device clock skew does not matter.
+func localTerminationTime(deviceNow, deviceTermination time.Time, requested time.Duration) time.Time {
+	if deviceNow.IsZero() || deviceTermination.IsZero() {
+		return time.Now().Add(requested)
+	}
+
+	return time.Now().Add(deviceTermination.Sub(deviceNow))
+}
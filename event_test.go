@@ -3,9 +3,13 @@ package onvif
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -330,6 +334,26 @@ func TestPullMessages(t *testing.T) {
 	}
 }
 
+func TestPullMessagesOutlastsDefaultCallTimeout(t *testing.T) {
+	server := newMockEventServer()
+	defer server.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+
+	client, err := NewClient(slow.URL, WithDefaultCallTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.PullMessages(context.Background(), slow.URL+"/subscription/1", time.Second, 10); err != nil {
+		t.Errorf("Expected the long poll to outlast the default call timeout, got %v", err)
+	}
+}
+
 func TestPullMessagesValidation(t *testing.T) {
 	server := newMockEventServer()
 	defer server.Close()
@@ -348,18 +372,50 @@ func TestPullMessagesValidation(t *testing.T) {
 	}
 
 	// Test invalid timeout.
-	_, err = client.PullMessages(ctx, server.URL+"/subscription/1", 0, 10)
+	_, err = client.PullMessages(ctx, server.URL+"/subscription/1", -time.Second, 10)
 	if !errors.Is(err, ErrInvalidTimeout) {
 		t.Errorf("Expected ErrInvalidTimeout, got %v", err)
 	}
 
 	// Test invalid message limit.
-	_, err = client.PullMessages(ctx, server.URL+"/subscription/1", 30*time.Second, 0)
+	_, err = client.PullMessages(ctx, server.URL+"/subscription/1", 30*time.Second, -1)
 	if !errors.Is(err, ErrInvalidMessageLimit) {
 		t.Errorf("Expected ErrInvalidMessageLimit, got %v", err)
 	}
 }
 
+func TestPullMessagesZeroUsesDefaults(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+<tev:PullMessagesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+  <tev:CurrentTime>2025-01-15T10:30:00Z</tev:CurrentTime>
+  <tev:TerminationTime>2025-01-15T11:30:00Z</tev:TerminationTime>
+</tev:PullMessagesResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.PullMessages(context.Background(), server.URL+"/subscription/1", 0, 0); err != nil {
+		t.Fatalf("PullMessages failed: %v", err)
+	}
+
+	if !strings.Contains(body, "<tev:Timeout>PT10S</tev:Timeout>") {
+		t.Errorf("Expected default timeout in request, got %s", body)
+	}
+
+	if !strings.Contains(body, "<tev:MessageLimit>100</tev:MessageLimit>") {
+		t.Errorf("Expected default message limit in request, got %s", body)
+	}
+}
+
 func TestSeek(t *testing.T) {
 	server := newMockEventServer()
 	defer server.Close()
@@ -383,6 +439,103 @@ func TestSeek(t *testing.T) {
 	}
 }
 
+func TestSeekCachesCapabilities(t *testing.T) {
+	var capabilityRequests, seeks int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetServiceCapabilities"):
+			capabilityRequests++
+			response = `<tev:GetServiceCapabilitiesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+				<tev:Capabilities PersistentNotificationStorage="true"/></tev:GetServiceCapabilitiesResponse>`
+		case strings.Contains(string(body), "Seek"):
+			seeks++
+			response = `<tev:SeekResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for range 3 {
+		if err := client.Seek(context.Background(), server.URL+"/subscription/1", time.Now(), false); err != nil {
+			t.Fatalf("Seek failed: %v", err)
+		}
+	}
+
+	if capabilityRequests != 1 || seeks != 3 {
+		t.Errorf("Expected 1 capabilities request and 3 seeks, got %d and %d", capabilityRequests, seeks)
+	}
+}
+
+func TestSeekUnsupported(t *testing.T) {
+	var seeks int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetServiceCapabilities"):
+			response = `<tev:GetServiceCapabilitiesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+				<tev:Capabilities PersistentNotificationStorage="false"/></tev:GetServiceCapabilitiesResponse>`
+		case strings.Contains(string(body), "Seek"):
+			seeks++
+			response = `<tev:SeekResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"/>`
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.Seek(context.Background(), server.URL+"/subscription/1", time.Now(), false)
+	if !errors.Is(err, ErrSeekUnsupported) {
+		t.Errorf("Expected ErrSeekUnsupported, got %v", err)
+	}
+
+	if seeks != 0 {
+		t.Errorf("Expected no Seek request, got %d", seeks)
+	}
+}
+
+// TestSeekLenientServerError reproduces a device that answers both the
+// capabilities and Seek with a bare HTTP 500.
+func TestSeekLenientServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<html><body><h1>Internal Server Error</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithLenientServerErrors())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	err = client.Seek(context.Background(), server.URL+"/subscription/1", time.Now(), false)
+	if !errors.Is(err, ErrSeekUnsupported) {
+		t.Errorf("Expected ErrSeekUnsupported, got %v", err)
+	}
+}
+
 func TestSeekInvalidSubscriptionReference(t *testing.T) {
 	server := newMockEventServer()
 	defer server.Close()
@@ -690,6 +843,121 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"PT30S", 30 * time.Second, false},
+		{"PT1M30S", 90 * time.Second, false},
+		{"PT0.5S", 500 * time.Millisecond, false},
+		{"P1DT2H", 26 * time.Hour, false},
+		{"P0Y0M0DT0H1M0S", time.Minute, false},
+		{"-PT10S", -10 * time.Second, false},
+		{"", 0, true},
+		{"P", 0, true},
+		{"PT", 0, true},
+		{"30s", 0, true},
+	}
+
+	for _, tt := range tests {
+		result, err := parseDuration(tt.input)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidDuration) {
+				t.Errorf("parseDuration(%q) error = %v, expected ErrInvalidDuration", tt.input, err)
+			}
+
+			continue
+		}
+
+		if err != nil || result != tt.expected {
+			t.Errorf("parseDuration(%q) = %v, %v, expected %v", tt.input, result, err, tt.expected)
+		}
+	}
+}
+
+func TestPullMessagesClampsToDeviceLimits(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		if strings.Contains(string(body), "PT1M") {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(testEventXMLHeader + `
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope">
+  <SOAP-ENV:Body>
+    <SOAP-ENV:Fault>
+      <SOAP-ENV:Code><SOAP-ENV:Value>SOAP-ENV:Receiver</SOAP-ENV:Value></SOAP-ENV:Code>
+      <SOAP-ENV:Reason><SOAP-ENV:Text xml:lang="en">Timeout too large</SOAP-ENV:Text></SOAP-ENV:Reason>
+      <SOAP-ENV:Detail>
+        <tev:PullMessagesFaultResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+          <tev:MaxTimeout>PT30S</tev:MaxTimeout>
+          <tev:MaxMessageLimit>50</tev:MaxMessageLimit>
+        </tev:PullMessagesFaultResponse>
+      </SOAP-ENV:Detail>
+    </SOAP-ENV:Fault>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testEventXMLHeader + `
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope">
+  <SOAP-ENV:Body>
+    <tev:PullMessagesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+      <tev:CurrentTime>2024-01-01T00:00:00Z</tev:CurrentTime>
+      <tev:TerminationTime>2024-01-01T00:01:00Z</tev:TerminationTime>
+    </tev:PullMessagesResponse>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`))
+	}))
+	defer server.Close()
+
+	var logged []string
+
+	client, err := NewClient(server.URL, WithLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.PullMessages(ctx, server.URL+"/subscription/1", time.Minute, 200); err != nil {
+		t.Fatalf("PullMessages failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	if !strings.Contains(requests[1], "PT30S") || !strings.Contains(requests[1], ">50<") {
+		t.Errorf("Retry was not clamped to device limits: %s", requests[1])
+	}
+
+	if len(logged) == 0 {
+		t.Error("Expected the adjustment to be logged")
+	}
+
+	// Later pulls are clamped up front.
+	requests = nil
+
+	if _, err := client.PullMessages(ctx, server.URL+"/subscription/1", time.Minute, 10); err != nil {
+		t.Fatalf("PullMessages failed: %v", err)
+	}
+
+	if len(requests) != 1 || !strings.Contains(requests[0], "PT30S") {
+		t.Errorf("Expected a single clamped request, got %v", requests)
+	}
+}
+
 func TestSplitSpaceSeparated(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -736,3 +1004,432 @@ func TestSetEventEndpoint(t *testing.T) {
 		t.Errorf("Expected event endpoint %s, got %s", newEndpoint, endpoint)
 	}
 }
+
+func TestFriendlyTopicName(t *testing.T) {
+	tests := []struct {
+		topic    string
+		name     string
+		category EventCategory
+	}{
+		{"tns1:VideoSource/MotionAlarm", "Motion alarm", EventCategoryMotion},
+		{"tns1:RuleEngine/CellMotionDetector/Motion", "Motion detected", EventCategoryMotion},
+		{"tns1:RuleEngine/TamperDetector/Tamper", "Tamper detected", EventCategoryTamper},
+		{"tns1:Device/Trigger/DigitalInput", "Digital input", EventCategoryIO},
+		// Vendor namespaces stay distinct from the ONVIF topics of the same name.
+		{"tns1:Device/tnsvendor:Trigger/CustomInput", "CustomInput", EventCategorySystem},
+		{"tnsvendor:Device/Trigger/DigitalInput", "DigitalInput", EventCategoryOther},
+		{"tns1:RuleEngine/tnsvendor:TamperDetector/Tamper", "Tamper", EventCategoryTamper},
+		{"tns1:RuleEngine/LineDetector/Crossed//.", "Line crossed", EventCategoryAnalytics},
+		{"tns1:Monitoring/ProcessorUsage", "Processor usage", EventCategorySystem},
+		{"tnsvendor:Custom/SomethingHappened", "SomethingHappened", EventCategoryOther},
+		{"Standalone", "Standalone", EventCategoryOther},
+	}
+
+	for _, tt := range tests {
+		if got := FriendlyTopicName(tt.topic); got != tt.name {
+			t.Errorf("FriendlyTopicName(%q) = %q, expected %q", tt.topic, got, tt.name)
+		}
+
+		if got := TopicCategory(tt.topic); got != tt.category {
+			t.Errorf("TopicCategory(%q) = %q, expected %q", tt.topic, got, tt.category)
+		}
+	}
+}
+
+// newMockSubscriptionServer returns a server for managed subscription tests that
+// counts the operations it receives and answers the next failures[op] requests
+// for each operation with a fault.
+func newMockSubscriptionServer(
+	t *testing.T,
+	calls, failures map[string]int,
+	mu *sync.Mutex,
+) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		now := time.Now().UTC()
+		current := now.Format(time.RFC3339)
+		termination := now.Add(time.Hour).Format(time.RFC3339)
+
+		var op, response string
+
+		switch {
+		case strings.Contains(bodyStr, "CreatePullPointSubscription"):
+			op = "Create"
+			response = `<tev:CreatePullPointSubscriptionResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+      <tev:SubscriptionReference><wsa:Address xmlns:wsa="http://www.w3.org/2005/08/addressing">` +
+				server.URL + `/subscription/new</wsa:Address></tev:SubscriptionReference>
+      <tev:CurrentTime>` + current + `</tev:CurrentTime>
+      <tev:TerminationTime>` + termination + `</tev:TerminationTime>
+    </tev:CreatePullPointSubscriptionResponse>`
+		case strings.Contains(bodyStr, "Renew"):
+			op = "Renew"
+			response = `<wsnt:RenewResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">
+      <wsnt:CurrentTime>` + current + `</wsnt:CurrentTime>
+      <wsnt:TerminationTime>` + termination + `</wsnt:TerminationTime>
+    </wsnt:RenewResponse>`
+		case strings.Contains(bodyStr, "PullMessages"):
+			op = "Pull:" + r.URL.Path
+			response = `<tev:PullMessagesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl">
+      <tev:CurrentTime>` + current + `</tev:CurrentTime>
+      <tev:TerminationTime>` + termination + `</tev:TerminationTime>
+      <wsnt:NotificationMessage xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2">
+        <wsnt:Topic>tns1:VideoSource/MotionAlarm</wsnt:Topic>
+      </wsnt:NotificationMessage>
+    </tev:PullMessagesResponse>`
+		case strings.Contains(bodyStr, "Unsubscribe"):
+			op = "Unsubscribe:" + r.URL.Path
+			response = `<wsnt:UnsubscribeResponse xmlns:wsnt="http://docs.oasis-open.org/wsn/b-2"/>`
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		mu.Lock()
+		calls[op]++
+		fail := failures[op] > 0
+		if fail {
+			failures[op]--
+		}
+		mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(testEventXMLHeader + `
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope">
+  <SOAP-ENV:Body>
+    <SOAP-ENV:Fault>
+      <SOAP-ENV:Code><SOAP-ENV:Value>SOAP-ENV:Receiver</SOAP-ENV:Value></SOAP-ENV:Code>
+      <SOAP-ENV:Reason><SOAP-ENV:Text xml:lang="en">Out of resources</SOAP-ENV:Text></SOAP-ENV:Reason>
+    </SOAP-ENV:Fault>
+  </SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(testEventXMLHeader + `
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope">
+  <SOAP-ENV:Body>` + response + `</SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`))
+	}))
+
+	return server
+}
+
+func TestManageSubscriptionResumesStoredSubscription(t *testing.T) {
+	calls := make(map[string]int)
+
+	var mu sync.Mutex
+
+	server := newMockSubscriptionServer(t, calls, nil, &mu)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	store := NewMemorySubscriptionStore()
+	ctx := context.Background()
+
+	// A subscription left behind by a previous process that is still valid.
+	_ = store.Save(ctx, "camera", &SubscriptionState{
+		Reference:       server.URL + "/subscription/stored",
+		TerminationTime: time.Now().Add(10 * time.Second),
+		Sequence:        5,
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+
+	err = client.ManageSubscription(runCtx, SubscriptionConfig{Key: "camera", Store: store},
+		func(msg NotificationMessage) {
+			if msg.Topic != "tns1:VideoSource/MotionAlarm" {
+				t.Errorf("Unexpected topic %q", msg.Topic)
+			}
+
+			cancel()
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if calls["Create"] != 0 || calls["Renew"] != 1 || calls["Pull:/subscription/stored"] != 1 {
+		t.Errorf("Expected the stored subscription to be renewed and pulled, got %v", calls)
+	}
+
+	state, err := store.Load(ctx, "camera")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if time.Until(state.TerminationTime) < 30*time.Minute {
+		t.Errorf("Expected the renewed termination time to be stored, got %v", state.TerminationTime)
+	}
+
+	if state.Sequence != 6 {
+		t.Errorf("Expected the delivered notification to be counted, got sequence %d", state.Sequence)
+	}
+}
+
+func TestManageSubscriptionRecreatesExpiredSubscription(t *testing.T) {
+	calls := make(map[string]int)
+
+	var mu sync.Mutex
+
+	server := newMockSubscriptionServer(t, calls, nil, &mu)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	store := NewMemorySubscriptionStore()
+	ctx := context.Background()
+
+	_ = store.Save(ctx, "camera", &SubscriptionState{
+		Reference:       server.URL + "/subscription/stored",
+		TerminationTime: time.Now().Add(-time.Minute),
+		Sequence:        3,
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+
+	_ = client.ManageSubscription(runCtx, SubscriptionConfig{Key: "camera", Store: store},
+		func(NotificationMessage) { cancel() })
+
+	if calls["Create"] != 1 || calls["Renew"] != 0 || calls["Pull:/subscription/new"] != 1 ||
+		calls["Unsubscribe:/subscription/stored"] != 1 {
+		t.Errorf("Expected the stored subscription to be replaced and pulled, got %v", calls)
+	}
+
+	state, err := store.Load(ctx, "camera")
+	if err != nil || state.Reference != server.URL+"/subscription/new" || state.Sequence != 4 {
+		t.Errorf("Expected the new subscription to be stored with sequence 4, got %+v, %v", state, err)
+	}
+}
+
+func TestManageSubscriptionRetriesFailedRecreation(t *testing.T) {
+	calls := make(map[string]int)
+	failures := map[string]int{"Renew": 1, "Create": 2}
+
+	var mu sync.Mutex
+
+	server := newMockSubscriptionServer(t, calls, failures, &mu)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	store := NewMemorySubscriptionStore()
+	ctx := context.Background()
+
+	_ = store.Save(ctx, "camera", &SubscriptionState{
+		Reference:       server.URL + "/subscription/stored",
+		TerminationTime: time.Now().Add(time.Hour),
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+
+	err = client.ManageSubscription(runCtx,
+		SubscriptionConfig{Key: "camera", Store: store, RetryDelay: time.Millisecond},
+		func(NotificationMessage) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if calls["Renew"] != 1 || calls["Unsubscribe:/subscription/stored"] != 1 ||
+		calls["Create"] != 3 || calls["Pull:/subscription/new"] != 1 {
+		t.Errorf("Expected the stale subscription to be released and creation retried, got %v", calls)
+	}
+}
+
+func TestSubscriptionBackoff(t *testing.T) {
+	backoff := &subscriptionBackoff{initial: time.Millisecond, limit: 3 * time.Millisecond}
+	backoff.reset()
+
+	for _, expected := range []time.Duration{2, 3, 3} {
+		if err := backoff.wait(context.Background()); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+
+		if backoff.next != expected*time.Millisecond {
+			t.Errorf("Expected next delay %v, got %v", expected*time.Millisecond, backoff.next)
+		}
+	}
+
+	backoff.reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := backoff.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMemorySubscriptionStore(t *testing.T) {
+	store := NewMemorySubscriptionStore()
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	_ = store.Save(ctx, "key", &SubscriptionState{Reference: "ref"})
+
+	state, err := store.Load(ctx, "key")
+	if err != nil || state.Reference != "ref" {
+		t.Errorf("Load() = %+v, %v", state, err)
+	}
+
+	_ = store.Delete(ctx, "key")
+
+	if _, err := store.Load(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+}
+
+func TestWalkEventTopics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+	<env:Body>
+		<tev:GetEventPropertiesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+			xmlns:wstop="http://docs.oasis-open.org/wsn/t-1" xmlns:tns1="http://www.onvif.org/ver10/topics"
+			xmlns:tt="http://www.onvif.org/ver10/schema" xmlns:tnsvendor="http://vendor.example/topics">
+			<tev:TopicNamespaceLocation>http://www.onvif.org/onvif/ver10/topics/topicns.xml</tev:TopicNamespaceLocation>
+			<wstop:FixedTopicSet>true</wstop:FixedTopicSet>
+			<wstop:TopicSet>
+				<tns1:VideoSource>
+					<MotionAlarm wstop:topic="true">
+						<tt:MessageDescription IsProperty="true">
+							<tt:Source><tt:SimpleItemDescription Name="Source" Type="tt:ReferenceToken"/></tt:Source>
+						</tt:MessageDescription>
+					</MotionAlarm>
+				</tns1:VideoSource>
+				<tns1:Device>
+					<Trigger>
+						<DigitalInput wstop:topic="true"/>
+						<Relay wstop:topic="true"/>
+					</Trigger>
+				</tns1:Device>
+				<tnsvendor:Custom wstop:topic="true"/>
+			</wstop:TopicSet>
+		</tev:GetEventPropertiesResponse>
+	</env:Body>
+</env:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	topics, err := client.GetEventTopics(context.Background())
+	if err != nil {
+		t.Fatalf("GetEventTopics() failed: %v", err)
+	}
+
+	want := []EventTopic{
+		{Namespace: "http://www.onvif.org/ver10/topics", Path: "VideoSource/MotionAlarm"},
+		{Namespace: "http://www.onvif.org/ver10/topics", Path: "Device/Trigger/DigitalInput"},
+		{Namespace: "http://www.onvif.org/ver10/topics", Path: "Device/Trigger/Relay"},
+		{Namespace: "http://vendor.example/topics", Path: "Custom"},
+	}
+
+	if len(topics) != len(want) {
+		t.Fatalf("Expected %d topics, got %+v", len(want), topics)
+	}
+
+	for i := range want {
+		if topics[i] != want[i] {
+			t.Errorf("topics[%d] = %+v, want %+v", i, topics[i], want[i])
+		}
+	}
+
+	errStop := errors.New("stop")
+	visited := 0
+
+	err = client.WalkEventTopics(context.Background(), func(EventTopic) error {
+		visited++
+
+		return errStop
+	})
+	if !errors.Is(err, errStop) || visited != 1 {
+		t.Errorf("Expected walk to stop after the first topic with errStop, got %v after %d topics", err, visited)
+	}
+}
+
+func TestWalkEventTopicsStreams(t *testing.T) {
+	firstTopic := make(chan struct{})
+
+	var streamed atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+	<env:Body>
+		<tev:GetEventPropertiesResponse xmlns:tev="http://www.onvif.org/ver10/events/wsdl"
+			xmlns:wstop="http://docs.oasis-open.org/wsn/t-1" xmlns:tns1="http://www.onvif.org/ver10/topics">
+			<wstop:TopicSet>
+				<tns1:VideoSource><MotionAlarm wstop:topic="true"/></tns1:VideoSource>`))
+		w.(http.Flusher).Flush()
+
+		// The rest of the response is only sent once the first topic was walked.
+		select {
+		case <-firstTopic:
+			streamed.Store(true)
+		case <-time.After(5 * time.Second):
+		}
+
+		_, _ = w.Write([]byte(`
+				<tns1:Device><Trigger><Relay wstop:topic="true"/></Trigger></tns1:Device>
+			</wstop:TopicSet>
+		</tev:GetEventPropertiesResponse>
+	</env:Body>
+</env:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	var paths []string
+
+	err = client.WalkEventTopics(context.Background(), func(topic EventTopic) error {
+		if len(paths) == 0 {
+			close(firstTopic)
+		}
+
+		paths = append(paths, topic.Path)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkEventTopics() failed: %v", err)
+	}
+
+	if !streamed.Load() {
+		t.Error("Expected the first topic to be walked before the response was complete")
+	}
+
+	if strings.Join(paths, ",") != "VideoSource/MotionAlarm,Device/Trigger/Relay" {
+		t.Errorf("Unexpected topics: %v", paths)
+	}
+}
@@ -0,0 +1,283 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// EventCategory is a coarse classification of an event topic.
+type EventCategory string
+
+// Event categories returned by TopicCategory.
+const (
+	EventCategoryMotion    EventCategory = "Motion"
+	EventCategoryTamper    EventCategory = "Tamper"
+	EventCategoryIO        EventCategory = "IO"
+	EventCategoryAnalytics EventCategory = "Analytics"
+	EventCategorySystem    EventCategory = "System"
+	EventCategoryOther     EventCategory = "Other"
+)
+
+// topicInfo holds the friendly name and category of a known topic.
+type topicInfo struct {
+	name     string
+	category EventCategory
+}
+
+// knownTopics maps normalized ONVIF topics (without namespace prefixes) to their friendly names.
+var knownTopics = map[string]topicInfo{
+	"VideoSource/MotionAlarm":                        {"Motion alarm", EventCategoryMotion},
+	"RuleEngine/CellMotionDetector/Motion":           {"Motion detected", EventCategoryMotion},
+	"RuleEngine/MotionRegionDetector/Motion":         {"Motion in region", EventCategoryMotion},
+	"VideoSource/GlobalSceneChange/ImagingService":   {"Scene change", EventCategoryTamper},
+	"VideoSource/GlobalSceneChange/AnalyticsService": {"Scene change", EventCategoryTamper},
+	"VideoSource/GlobalSceneChange/RecordingService": {"Scene change", EventCategoryTamper},
+	"VideoSource/ImageTooBlurry/ImagingService":      {"Image too blurry", EventCategoryTamper},
+	"VideoSource/ImageTooBlurry/AnalyticsService":    {"Image too blurry", EventCategoryTamper},
+	"VideoSource/ImageTooDark/ImagingService":        {"Image too dark", EventCategoryTamper},
+	"VideoSource/ImageTooDark/AnalyticsService":      {"Image too dark", EventCategoryTamper},
+	"VideoSource/ImageTooBright/ImagingService":      {"Image too bright", EventCategoryTamper},
+	"VideoSource/ImageTooBright/AnalyticsService":    {"Image too bright", EventCategoryTamper},
+	"VideoSource/SignalLoss":                         {"Video signal loss", EventCategoryTamper},
+	"RuleEngine/TamperDetector/Tamper":               {"Tamper detected", EventCategoryTamper},
+	"Device/Trigger/DigitalInput":                    {"Digital input", EventCategoryIO},
+	"Device/Trigger/Relay":                           {"Relay output", EventCategoryIO},
+	"Device/IO/VirtualPort":                          {"Virtual input", EventCategoryIO},
+	"RuleEngine/LineDetector/Crossed":                {"Line crossed", EventCategoryAnalytics},
+	"RuleEngine/FieldDetector/ObjectsInside":         {"Object in field", EventCategoryAnalytics},
+	"RuleEngine/CountAggregation/Counter":            {"Object count", EventCategoryAnalytics},
+	"RuleEngine/LoiteringDetector/ObjectIsLoitering": {"Loitering", EventCategoryAnalytics},
+	"AudioAnalytics/Audio/DetectedSound":             {"Sound detected", EventCategoryAnalytics},
+	"Monitoring/ProcessorUsage":                      {"Processor usage", EventCategorySystem},
+	"Monitoring/OperatingTime/LastReboot":            {"Last reboot", EventCategorySystem},
+	"Monitoring/OperatingTime/LastReset":             {"Last reset", EventCategorySystem},
+	"Monitoring/OperatingTime/LastClockSynchronization": {
+		"Last clock synchronization", EventCategorySystem,
+	},
+	"Device/HardwareFailure/StorageFailure":      {"Storage failure", EventCategorySystem},
+	"Device/HardwareFailure/FanFailure":          {"Fan failure", EventCategorySystem},
+	"Device/HardwareFailure/PowerSupplyFailure":  {"Power supply failure", EventCategorySystem},
+	"Device/HardwareFailure/TemperatureCritical": {"Critical temperature", EventCategorySystem},
+}
+
+// categoryPrefixes classifies topics that are not listed in knownTopics, most specific first.
+var categoryPrefixes = []struct {
+	prefix   string
+	category EventCategory
+}{
+	{"Device/Trigger/", EventCategoryIO},
+	{"Device/IO/", EventCategoryIO},
+	{"Device/", EventCategorySystem},
+	{"Monitoring/", EventCategorySystem},
+	{"RuleEngine/", EventCategoryAnalytics},
+	{"VideoAnalytics/", EventCategoryAnalytics},
+	{"AudioAnalytics/", EventCategoryAnalytics},
+}
+
+// onvifTopicPrefix is the prefix devices bind to the ONVIF topic namespace,
+// http://www.onvif.org/ver10/topics.
+const onvifTopicPrefix = "tns1:"
+
+// normalizeTopic strips the ONVIF namespace prefix and topic expression
+// suffixes from a topic, e.g. "tns1:RuleEngine/tnsaxis:Foo//." becomes
+// "RuleEngine/tnsaxis:Foo". Vendor prefixes are kept, so that vendor topics
+// do not match the ONVIF topics of the same name.
+func normalizeTopic(topic string) string {
+	topic = strings.TrimSpace(topic)
+	topic = strings.TrimSuffix(topic, "//.")
+	topic = strings.Trim(topic, "/")
+
+	segments := strings.Split(topic, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimPrefix(segment, onvifTopicPrefix)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// FriendlyTopicName returns a human-readable name for an ONVIF event topic such
+// as "tns1:VideoSource/MotionAlarm". Unknown topics, including vendor topics
+// below ONVIF ones, return their last path segment without its prefix.
+func FriendlyTopicName(topic string) string {
+	normalized := normalizeTopic(topic)
+	if info, ok := knownTopics[normalized]; ok {
+		return info.name
+	}
+
+	name := normalized
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
+
+// TopicCategory classifies an ONVIF event topic into a coarse category.
+// Topics that cannot be classified return EventCategoryOther.
+func TopicCategory(topic string) EventCategory {
+	normalized := normalizeTopic(topic)
+	if info, ok := knownTopics[normalized]; ok {
+		return info.category
+	}
+
+	switch {
+	case strings.Contains(normalized, "Motion"):
+		return EventCategoryMotion
+	case strings.Contains(normalized, "Tamper"):
+		return EventCategoryTamper
+	}
+
+	for _, p := range categoryPrefixes {
+		if strings.HasPrefix(normalized, p.prefix) {
+			return p.category
+		}
+	}
+
+	return EventCategoryOther
+}
+
+// EventTopic is a topic advertised in the TopicSet of GetEventProperties.
+type EventTopic struct {
+	// Namespace is the namespace URI of the topic tree root, e.g.
+	// "http://www.onvif.org/ver10/topics" for ONVIF topics.
+	Namespace string
+	// Path is the topic path without namespace prefixes, e.g. "VideoSource/MotionAlarm".
+	Path string
+}
+
+// WalkEventTopics retrieves the event properties of the device and calls fn
+// for every topic of the TopicSet, in document order, as the response is read
+// from the connection, without buffering it or collecting the topics into a
+// tree or slice. Walking stops at the first error returned by fn, which
+// WalkEventTopics returns.
+func (c *Client) WalkEventTopics(ctx context.Context, fn func(EventTopic) error) error {
+	type GetEventProperties struct {
+		XMLName xml.Name `xml:"tev:GetEventProperties"`
+		Xmlns   string   `xml:"xmlns:tev,attr"`
+	}
+
+	req := GetEventProperties{
+		Xmlns: eventNamespace,
+	}
+
+	resp := &topicSetWalker{fn: fn}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.CallStream(ctx, c.getEventEndpoint(), "", req, resp); err != nil {
+		return fmt.Errorf("GetEventProperties failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetEventTopics returns all topics of the TopicSet of GetEventProperties.
+// See WalkEventTopics to process topics incrementally.
+func (c *Client) GetEventTopics(ctx context.Context) ([]EventTopic, error) {
+	var topics []EventTopic
+
+	err := c.WalkEventTopics(ctx, func(topic EventTopic) error {
+		topics = append(topics, topic)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return topics, nil
+}
+
+// topicSetWalker decodes a GetEventPropertiesResponse token by token, passing
+// the topics of its TopicSet to fn.
+type topicSetWalker struct {
+	fn func(EventTopic) error
+}
+
+// UnmarshalXML walks the response, descending into the TopicSet element.
+func (w *topicSetWalker) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "TopicSet" {
+				err = w.walkTopicSet(d)
+			} else {
+				err = d.Skip()
+			}
+
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// walkTopicSet emits the topics below the current TopicSet element. Elements
+// are topics when they carry wstop:topic="true"; their message descriptions
+// are skipped.
+func (w *topicSetWalker) walkTopicSet(d *xml.Decoder) error {
+	var (
+		path      []string
+		namespace string
+	)
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "MessageDescription" {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if len(path) == 0 {
+				namespace = t.Name.Space
+			}
+
+			path = append(path, t.Name.Local)
+
+			if isTopicElement(t) {
+				if err := w.fn(EventTopic{Namespace: namespace, Path: strings.Join(path, "/")}); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if len(path) == 0 {
+				return nil
+			}
+
+			path = path[:len(path)-1]
+		}
+	}
+}
+
+// isTopicElement reports whether a TopicSet element is marked as a topic.
+func isTopicElement(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "topic" && strings.TrimSpace(attr.Value) == "true" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -227,9 +227,10 @@ func main() {
 				fmt.Printf("  - IR Cut Filter: %s\n", *settings.IrCutFilter)
 			}
 			if settings.BacklightCompensation != nil {
-				fmt.Printf("  - Backlight Compensation: %s (Level: %.1f)\n",
-					settings.BacklightCompensation.Mode,
-					settings.BacklightCompensation.Level)
+				fmt.Printf("  - Backlight Compensation: %s\n", settings.BacklightCompensation.Mode)
+				if settings.BacklightCompensation.Level != nil {
+					fmt.Printf("    Level: %.1f\n", *settings.BacklightCompensation.Level)
+				}
 			}
 			if settings.Exposure != nil {
 				fmt.Printf("  - Exposure Mode: %s\n", settings.Exposure.Mode)
@@ -242,9 +243,10 @@ func main() {
 				fmt.Printf("  - White Balance Mode: %s\n", settings.WhiteBalance.Mode)
 			}
 			if settings.WideDynamicRange != nil {
-				fmt.Printf("  - Wide Dynamic Range: %s (Level: %.1f)\n",
-					settings.WideDynamicRange.Mode,
-					settings.WideDynamicRange.Level)
+				fmt.Printf("  - Wide Dynamic Range: %s\n", settings.WideDynamicRange.Mode)
+				if settings.WideDynamicRange.Level != nil {
+					fmt.Printf("    Level: %.1f\n", *settings.WideDynamicRange.Level)
+				}
 			}
 		}
 	}
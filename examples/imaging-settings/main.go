@@ -80,8 +80,12 @@ func main() {
 	if settings.Exposure != nil {
 		fmt.Printf("  Exposure Mode: %s\n", settings.Exposure.Mode)
 		if settings.Exposure.Mode == "MANUAL" {
-			fmt.Printf("    Exposure Time: %.2f\n", settings.Exposure.ExposureTime)
-			fmt.Printf("    Gain: %.2f\n", settings.Exposure.Gain)
+			if settings.Exposure.ExposureTime != nil {
+				fmt.Printf("    Exposure Time: %.2f\n", *settings.Exposure.ExposureTime)
+			}
+			if settings.Exposure.Gain != nil {
+				fmt.Printf("    Gain: %.2f\n", *settings.Exposure.Gain)
+			}
 		}
 	}
 
@@ -95,7 +99,9 @@ func main() {
 
 	if settings.WideDynamicRange != nil {
 		fmt.Printf("  WDR Mode: %s\n", settings.WideDynamicRange.Mode)
-		fmt.Printf("  WDR Level: %.2f\n", settings.WideDynamicRange.Level)
+		if settings.WideDynamicRange.Level != nil {
+			fmt.Printf("  WDR Level: %.2f\n", *settings.WideDynamicRange.Level)
+		}
 	}
 
 	// Modify some settings
@@ -0,0 +1,139 @@
+package onvif
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FingerprintDetails is the device identity and configuration hashed by
+// Fingerprint. Lists are sorted so that the order reported by the device does
+// not change the hash.
+type FingerprintDetails struct {
+	Manufacturer    string
+	Model           string
+	SerialNumber    string
+	HardwareID      string
+	FirmwareVersion string
+	// MACAddresses holds the lowercased hardware addresses of the network
+	// interfaces.
+	MACAddresses []string
+	Profiles     []FingerprintProfile
+}
+
+// FingerprintProfile is the key configuration of a media profile.
+type FingerprintProfile struct {
+	Token        string
+	Name         string
+	VideoSource  string `json:",omitempty"`
+	Encoding     string `json:",omitempty"`
+	Width        int    `json:",omitempty"`
+	Height       int    `json:",omitempty"`
+	FrameRate    int    `json:",omitempty"`
+	BitrateLimit int    `json:",omitempty"`
+	PTZ          bool   `json:",omitempty"`
+}
+
+// Fingerprint returns a hash of the device identity (manufacturer, model,
+// serial number, hardware ID, firmware version and MAC addresses) and of its
+// media profiles, with the details it was computed from. The hash is stable
+// across calls as long as none of these change, so comparing it over time
+// detects a replaced device or configuration drift. Volatile state such as
+// the device clock or uptime is not included.
+func (c *Client) Fingerprint(ctx context.Context) (string, *FingerprintDetails, error) {
+	info, err := c.GetDeviceInformation(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get device information: %w", err)
+	}
+
+	interfaces, err := c.GetNetworkInterfaces(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get network interfaces: %w", err)
+	}
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	details := buildFingerprintDetails(info, interfaces, profiles)
+
+	hash, err := details.Hash()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hash, details, nil
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the canonical JSON encoding of
+// the details.
+func (d *FingerprintDetails) Hash() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode fingerprint: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// buildFingerprintDetails collects the hashed fields in canonical order.
+func buildFingerprintDetails(
+	info *DeviceInformation, interfaces []*NetworkInterface, profiles []*Profile,
+) *FingerprintDetails {
+	details := &FingerprintDetails{
+		Manufacturer:    strings.TrimSpace(info.Manufacturer),
+		Model:           strings.TrimSpace(info.Model),
+		SerialNumber:    strings.TrimSpace(info.SerialNumber),
+		HardwareID:      strings.TrimSpace(info.HardwareID),
+		FirmwareVersion: strings.TrimSpace(info.FirmwareVersion),
+		MACAddresses:    []string{},
+		Profiles:        []FingerprintProfile{},
+	}
+
+	for _, iface := range interfaces {
+		if mac := strings.ToLower(strings.TrimSpace(iface.Info.HwAddress)); mac != "" {
+			details.MACAddresses = append(details.MACAddresses, mac)
+		}
+	}
+
+	sort.Strings(details.MACAddresses)
+
+	for _, profile := range profiles {
+		p := FingerprintProfile{
+			Token: profile.Token,
+			Name:  profile.Name,
+			PTZ:   profile.PTZConfiguration != nil,
+		}
+
+		if source := profile.VideoSourceConfiguration; source != nil {
+			p.VideoSource = source.SourceToken
+		}
+
+		if encoder := profile.VideoEncoderConfiguration; encoder != nil {
+			p.Encoding = encoder.Encoding
+
+			if encoder.Resolution != nil {
+				p.Width, p.Height = encoder.Resolution.Width, encoder.Resolution.Height
+			}
+
+			if encoder.RateControl != nil {
+				p.FrameRate, p.BitrateLimit = encoder.RateControl.FrameRateLimit, encoder.RateControl.BitrateLimit
+			}
+		}
+
+		details.Profiles = append(details.Profiles, p)
+	}
+
+	sort.Slice(details.Profiles, func(i, j int) bool {
+		return details.Profiles[i].Token < details.Profiles[j].Token
+	})
+
+	return details
+}
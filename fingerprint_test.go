@@ -0,0 +1,104 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockFingerprintServer(t *testing.T, firmware, profiles string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetDeviceInformation"):
+			response = `<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Manufacturer>Acme</tds:Manufacturer>
+				<tds:Model>Cam 1</tds:Model>
+				<tds:FirmwareVersion>` + firmware + `</tds:FirmwareVersion>
+				<tds:SerialNumber>SN123</tds:SerialNumber>
+				<tds:HardwareID>HW1</tds:HardwareID>
+			</tds:GetDeviceInformationResponse>`
+		case strings.Contains(string(body), "GetNetworkInterfaces"):
+			response = `<tds:GetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:NetworkInterfaces token="eth1"><tt:Enabled>true</tt:Enabled>
+					<tt:Info><tt:Name>eth1</tt:Name><tt:HwAddress>AA:BB:CC:00:00:02</tt:HwAddress></tt:Info></tds:NetworkInterfaces>
+				<tds:NetworkInterfaces token="eth0"><tt:Enabled>true</tt:Enabled>
+					<tt:Info><tt:Name>eth0</tt:Name><tt:HwAddress>AA:BB:CC:00:00:01</tt:HwAddress></tt:Info></tds:NetworkInterfaces>
+			</tds:GetNetworkInterfacesResponse>`
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+				profiles + `</trt:GetProfilesResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestFingerprint(t *testing.T) {
+	const (
+		main = `<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name>
+			<tt:VideoEncoderConfiguration token="VEC_1"><tt:Name>VEC</tt:Name><tt:Encoding>H264</tt:Encoding>
+				<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+			</tt:VideoEncoderConfiguration></trt:Profiles>`
+		sub = `<trt:Profiles token="Profile_2"><tt:Name>Sub</tt:Name></trt:Profiles>`
+	)
+
+	fingerprint := func(firmware, profiles string) (string, *FingerprintDetails) {
+		t.Helper()
+
+		server := newMockFingerprintServer(t, firmware, profiles)
+		defer server.Close()
+
+		client, err := NewClient(server.URL)
+		if err != nil {
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+
+		hash, details, err := client.Fingerprint(context.Background())
+		if err != nil {
+			t.Fatalf("Fingerprint() failed: %v", err)
+		}
+
+		return hash, details
+	}
+
+	hash, details := fingerprint("1.0", main+sub)
+
+	if len(hash) != 64 {
+		t.Errorf("Expected a SHA-256 hex hash, got %q", hash)
+	}
+
+	if details.SerialNumber != "SN123" || details.FirmwareVersion != "1.0" {
+		t.Errorf("Unexpected details: %+v", details)
+	}
+
+	if len(details.MACAddresses) != 2 || details.MACAddresses[0] != "aa:bb:cc:00:00:01" {
+		t.Errorf("Expected sorted, lowercased MAC addresses, got %v", details.MACAddresses)
+	}
+
+	if len(details.Profiles) != 2 || details.Profiles[0].Encoding != "H264" || details.Profiles[0].Width != 1920 {
+		t.Errorf("Unexpected profiles: %+v", details.Profiles)
+	}
+
+	if reordered, _ := fingerprint("1.0", sub+main); reordered != hash {
+		t.Error("Expected the hash not to depend on profile order")
+	}
+
+	if upgraded, _ := fingerprint("1.1", main+sub); upgraded == hash {
+		t.Error("Expected a firmware change to change the hash")
+	}
+
+	if removed, _ := fingerprint("1.0", main); removed == hash {
+		t.Error("Expected a removed profile to change the hash")
+	}
+}
@@ -0,0 +1,139 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxUploadErrorBody bounds the part of an error response kept in the error.
+const maxUploadErrorBody = 200
+
+// UploadFirmware posts a firmware image to the uploadURI returned by
+// StartFirmwareUpgrade, authenticating with the client's credentials. Unlike
+// other operations this is a plain HTTP POST of the image as
+// application/octet-stream rather than a SOAP call. The device installs the
+// firmware and restarts once the upload succeeds, see
+// FirmwareUpgradeInfo.ExpectedDownTime, after which callers should call
+// Initialize again.
+//
+// Since firmware is streamed and cannot be sent twice, the URI is first
+// requested without credentials to learn whether the device wants Basic or
+// Digest authentication. firmware is sent with a Content-Length if it is an
+// io.Seeker such as *os.File, otherwise chunked. The call is bounded by ctx
+// only, not by the client's HTTP timeout, since uploads may take minutes. A
+// rejected upload returns an HTTPError, so errors.Is with ErrUnauthorized
+// reports wrong credentials.
+func (c *Client) UploadFirmware(ctx context.Context, uploadURI string, firmware io.Reader) error {
+	if parsed, err := url.Parse(uploadURI); err != nil || parsed.Host == "" {
+		return fmt.Errorf("UploadFirmware failed: %w: upload URI %q", ErrInvalidParameter, uploadURI)
+	}
+
+	if firmware == nil {
+		return fmt.Errorf("UploadFirmware failed: %w: no firmware", ErrInvalidParameter)
+	}
+
+	// The client's timeout suits SOAP calls, not transfers of large images.
+	uploadClient := *c.httpClient
+	uploadClient.Timeout = 0
+
+	challenges, err := probeUploadAuth(ctx, &uploadClient, uploadURI)
+	if err != nil {
+		return fmt.Errorf("UploadFirmware failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURI, firmware)
+	if err != nil {
+		return fmt.Errorf("UploadFirmware failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("User-Agent", "onvif-go-client")
+
+	if seeker, ok := firmware.(io.Seeker); ok && req.ContentLength == 0 {
+		if size, err := remainingSize(seeker); err == nil {
+			req.ContentLength = size
+		}
+	}
+
+	username, password := c.GetCredentials()
+
+	switch challengeScheme(challenges) {
+	case httpAuthDigest:
+		if username == "" {
+			return fmt.Errorf("UploadFirmware failed: %w", ErrDigestAuthRequiresCredentials)
+		}
+
+		// The firmware is not covered by the digest, since it is streamed.
+		host, uri := req.URL.Host, req.URL.RequestURI()
+		c.digest.StoreChallenge(host, challenges)
+		req.Header.Set("Authorization", c.digest.Authorize(host, http.MethodPost, uri, username, password))
+	case httpAuthBasic, httpAuthNone, httpAuthUnknown:
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+	}
+
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("UploadFirmware failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxUploadErrorBody)) //nolint:errcheck // Error preview - ignore read errors
+
+		return fmt.Errorf("UploadFirmware failed: %w", &HTTPError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	c.dropRebootMediaURIs()
+
+	return nil
+}
+
+// probeUploadAuth requests uploadURI without credentials and returns the
+// WWW-Authenticate challenges of the response, if it asks for authentication.
+func probeUploadAuth(ctx context.Context, client *http.Client, uploadURI string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uploadURI, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "onvif-go-client")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, nil
+	}
+
+	return resp.Header.Values("WWW-Authenticate"), nil
+}
+
+// remainingSize returns the number of bytes from the current offset of
+// seeker to its end, leaving the offset unchanged.
+func remainingSize(seeker io.Seeker) (int64, error) {
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return end - current, nil
+}
@@ -0,0 +1,165 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // MD5 required for HTTP Digest authentication
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newMockFirmwareServer returns a server accepting firmware uploads with the
+// given authentication scheme; uploads holds the bodies it received and
+// lengths their Content-Length.
+func newMockFirmwareServer(t *testing.T, scheme string, uploads *[][]byte, lengths *[]int64) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+
+		if !strings.HasPrefix(auth, scheme+" ") {
+			w.Header().Set("WWW-Authenticate", scheme+` realm="camera", nonce="abc123", qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected an authenticated POST, got %s", r.Method)
+		}
+
+		if scheme == "Digest" && !strings.Contains(auth, `uri="/firmware"`) {
+			t.Errorf("Unexpected Authorization header %q", auth)
+		}
+
+		if r.Header.Get("Content-Type") != "application/octet-stream" {
+			t.Errorf("Unexpected Content-Type %q", r.Header.Get("Content-Type"))
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		*uploads = append(*uploads, body)
+		*lengths = append(*lengths, r.ContentLength)
+	}))
+}
+
+func TestUploadFirmware(t *testing.T) {
+	firmware := bytes.Repeat([]byte("firmware"), 1024)
+
+	for _, scheme := range []string{"Digest", "Basic"} {
+		t.Run(scheme, func(t *testing.T) {
+			var (
+				uploads [][]byte
+				lengths []int64
+			)
+
+			server := newMockFirmwareServer(t, scheme, &uploads, &lengths)
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithCredentials("admin", "secret"))
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			// A reader that is not an io.Seeker is sent chunked, a seeker with its length.
+			for _, r := range []io.Reader{
+				io.MultiReader(bytes.NewReader(firmware)),
+				io.NewSectionReader(bytes.NewReader(firmware), 0, int64(len(firmware))),
+			} {
+				if err := client.UploadFirmware(context.Background(), server.URL+"/firmware", r); err != nil {
+					t.Fatalf("UploadFirmware() failed: %v", err)
+				}
+			}
+
+			if len(uploads) != 2 || !bytes.Equal(uploads[0], firmware) || !bytes.Equal(uploads[1], firmware) {
+				t.Fatalf("Expected the firmware to be uploaded twice, got %d uploads", len(uploads))
+			}
+
+			if lengths[0] != -1 || lengths[1] != int64(len(firmware)) {
+				t.Errorf("Content-Length = %v, want [-1 %d]", lengths, len(firmware))
+			}
+		})
+	}
+}
+
+func TestUploadFirmwareRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="camera"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials("admin", "wrong"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	err = client.UploadFirmware(context.Background(), server.URL+"/firmware", strings.NewReader("firmware"))
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+
+	if err := client.UploadFirmware(context.Background(), "/firmware", strings.NewReader("firmware")); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for a relative URI, got %v", err)
+	}
+}
+
+func TestUploadFirmwareDigestQopList(t *testing.T) {
+	const (
+		realm = "camera"
+		nonce = "abc123"
+	)
+
+	h := func(s string) string {
+		sum := md5.Sum([]byte(s)) //nolint:gosec // MD5 required for HTTP Digest authentication
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", nonce="`+nonce+`", qop="auth-int,auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		params := make(map[string]string)
+
+		for _, field := range strings.Split(strings.TrimPrefix(auth, "Digest "), ", ") {
+			name, value, _ := strings.Cut(field, "=")
+			params[name] = strings.Trim(value, `"`)
+		}
+
+		if params["qop"] != "auth" {
+			t.Errorf("Expected qop=auth from a qop list, got %q", auth)
+		}
+
+		ha1 := h(testUsername + ":" + realm + ":password")
+		ha2 := h(http.MethodPost + ":" + params["uri"])
+		want := h(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+
+		if params["response"] != want {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithCredentials(testUsername, "password"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if err := client.UploadFirmware(context.Background(), server.URL+"/firmware?slot=1", strings.NewReader("image")); err != nil {
+		t.Fatalf("UploadFirmware() failed: %v", err)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"encoding/xml"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"slices"
+	"strings"
 )
 
 // Imaging service namespace.
@@ -30,41 +30,41 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 		XMLName         xml.Name `xml:"GetImagingSettingsResponse"`
 		ImagingSettings struct {
 			BacklightCompensation *struct {
-				Mode  string  `xml:"Mode"`
-				Level float64 `xml:"Level"`
+				Mode  string   `xml:"Mode"`
+				Level *float64 `xml:"Level"`
 			} `xml:"BacklightCompensation"`
 			Brightness      *float64 `xml:"Brightness"`
 			ColorSaturation *float64 `xml:"ColorSaturation"`
 			Contrast        *float64 `xml:"Contrast"`
 			Exposure        *struct {
-				Mode            string  `xml:"Mode"`
-				Priority        string  `xml:"Priority"`
-				MinExposureTime float64 `xml:"MinExposureTime"`
-				MaxExposureTime float64 `xml:"MaxExposureTime"`
-				MinGain         float64 `xml:"MinGain"`
-				MaxGain         float64 `xml:"MaxGain"`
-				MinIris         float64 `xml:"MinIris"`
-				MaxIris         float64 `xml:"MaxIris"`
-				ExposureTime    float64 `xml:"ExposureTime"`
-				Gain            float64 `xml:"Gain"`
-				Iris            float64 `xml:"Iris"`
+				Mode            string   `xml:"Mode"`
+				Priority        string   `xml:"Priority"`
+				MinExposureTime *float64 `xml:"MinExposureTime"`
+				MaxExposureTime *float64 `xml:"MaxExposureTime"`
+				MinGain         *float64 `xml:"MinGain"`
+				MaxGain         *float64 `xml:"MaxGain"`
+				MinIris         *float64 `xml:"MinIris"`
+				MaxIris         *float64 `xml:"MaxIris"`
+				ExposureTime    *float64 `xml:"ExposureTime"`
+				Gain            *float64 `xml:"Gain"`
+				Iris            *float64 `xml:"Iris"`
 			} `xml:"Exposure"`
 			Focus *struct {
-				AutoFocusMode string  `xml:"AutoFocusMode"`
-				DefaultSpeed  float64 `xml:"DefaultSpeed"`
-				NearLimit     float64 `xml:"NearLimit"`
-				FarLimit      float64 `xml:"FarLimit"`
+				AutoFocusMode string   `xml:"AutoFocusMode"`
+				DefaultSpeed  *float64 `xml:"DefaultSpeed"`
+				NearLimit     *float64 `xml:"NearLimit"`
+				FarLimit      *float64 `xml:"FarLimit"`
 			} `xml:"Focus"`
 			IrCutFilter      *string  `xml:"IrCutFilter"`
 			Sharpness        *float64 `xml:"Sharpness"`
 			WideDynamicRange *struct {
-				Mode  string  `xml:"Mode"`
-				Level float64 `xml:"Level"`
+				Mode  string   `xml:"Mode"`
+				Level *float64 `xml:"Level"`
 			} `xml:"WideDynamicRange"`
 			WhiteBalance *struct {
-				Mode   string  `xml:"Mode"`
-				CrGain float64 `xml:"CrGain"`
-				CbGain float64 `xml:"CbGain"`
+				Mode   string   `xml:"Mode"`
+				CrGain *float64 `xml:"CrGain"`
+				CbGain *float64 `xml:"CbGain"`
 			} `xml:"WhiteBalance"`
 		} `xml:"ImagingSettings"`
 	}
@@ -76,8 +76,7 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 
 	var resp GetImagingSettingsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetImagingSettings failed: %w", err)
@@ -142,6 +141,12 @@ func (c *Client) GetImagingSettings(ctx context.Context, videoSourceToken string
 }
 
 // SetImagingSettings sets imaging settings for a video source.
+// Nil settings, sub-blocks and sub-block values are omitted from the request,
+// so that only the values the caller sets are changed; a zero value, such as a
+// focus FarLimit of 0 for infinity, is sent. When the brightness, color saturation,
+// contrast, sharpness, IR cut filter, exposure or focus is set, the values are
+// first validated against GetOptions and ErrInvalidParameter is returned for
+// values the device does not support.
 //
 //nolint:funlen // SetImagingSettings has many statements due to building complex imaging settings request
 func (c *Client) SetImagingSettings(
@@ -152,54 +157,60 @@ func (c *Client) SetImagingSettings(
 		endpoint = c.endpoint
 	}
 
+	if err := c.validateImagingSettingsWithOptions(ctx, videoSourceToken, settings); err != nil {
+		return fmt.Errorf("SetImagingSettings failed: %w", err)
+	}
+
 	type SetImagingSettings struct {
 		XMLName          xml.Name `xml:"timg:SetImagingSettings"`
 		Xmlns            string   `xml:"xmlns:timg,attr"`
+		Xmlnst           string   `xml:"xmlns:tt,attr"`
 		VideoSourceToken string   `xml:"timg:VideoSourceToken"`
 		ImagingSettings  struct {
 			BacklightCompensation *struct {
-				Mode  string  `xml:"Mode"`
-				Level float64 `xml:"Level"`
-			} `xml:"BacklightCompensation,omitempty"`
-			Brightness      *float64 `xml:"Brightness,omitempty"`
-			ColorSaturation *float64 `xml:"ColorSaturation,omitempty"`
-			Contrast        *float64 `xml:"Contrast,omitempty"`
+				Mode  string   `xml:"tt:Mode"`
+				Level *float64 `xml:"tt:Level,omitempty"`
+			} `xml:"tt:BacklightCompensation,omitempty"`
+			Brightness      *float64 `xml:"tt:Brightness,omitempty"`
+			ColorSaturation *float64 `xml:"tt:ColorSaturation,omitempty"`
+			Contrast        *float64 `xml:"tt:Contrast,omitempty"`
 			Exposure        *struct {
-				Mode            string  `xml:"Mode"`
-				Priority        string  `xml:"Priority,omitempty"`
-				MinExposureTime float64 `xml:"MinExposureTime,omitempty"`
-				MaxExposureTime float64 `xml:"MaxExposureTime,omitempty"`
-				MinGain         float64 `xml:"MinGain,omitempty"`
-				MaxGain         float64 `xml:"MaxGain,omitempty"`
-				MinIris         float64 `xml:"MinIris,omitempty"`
-				MaxIris         float64 `xml:"MaxIris,omitempty"`
-				ExposureTime    float64 `xml:"ExposureTime,omitempty"`
-				Gain            float64 `xml:"Gain,omitempty"`
-				Iris            float64 `xml:"Iris,omitempty"`
-			} `xml:"Exposure,omitempty"`
+				Mode            string   `xml:"tt:Mode"`
+				Priority        string   `xml:"tt:Priority,omitempty"`
+				MinExposureTime *float64 `xml:"tt:MinExposureTime,omitempty"`
+				MaxExposureTime *float64 `xml:"tt:MaxExposureTime,omitempty"`
+				MinGain         *float64 `xml:"tt:MinGain,omitempty"`
+				MaxGain         *float64 `xml:"tt:MaxGain,omitempty"`
+				MinIris         *float64 `xml:"tt:MinIris,omitempty"`
+				MaxIris         *float64 `xml:"tt:MaxIris,omitempty"`
+				ExposureTime    *float64 `xml:"tt:ExposureTime,omitempty"`
+				Gain            *float64 `xml:"tt:Gain,omitempty"`
+				Iris            *float64 `xml:"tt:Iris,omitempty"`
+			} `xml:"tt:Exposure,omitempty"`
 			Focus *struct {
-				AutoFocusMode string  `xml:"AutoFocusMode"`
-				DefaultSpeed  float64 `xml:"DefaultSpeed,omitempty"`
-				NearLimit     float64 `xml:"NearLimit,omitempty"`
-				FarLimit      float64 `xml:"FarLimit,omitempty"`
-			} `xml:"Focus,omitempty"`
-			IrCutFilter      *string  `xml:"IrCutFilter,omitempty"`
-			Sharpness        *float64 `xml:"Sharpness,omitempty"`
+				AutoFocusMode string   `xml:"tt:AutoFocusMode"`
+				DefaultSpeed  *float64 `xml:"tt:DefaultSpeed,omitempty"`
+				NearLimit     *float64 `xml:"tt:NearLimit,omitempty"`
+				FarLimit      *float64 `xml:"tt:FarLimit,omitempty"`
+			} `xml:"tt:Focus,omitempty"`
+			IrCutFilter      *string  `xml:"tt:IrCutFilter,omitempty"`
+			Sharpness        *float64 `xml:"tt:Sharpness,omitempty"`
 			WideDynamicRange *struct {
-				Mode  string  `xml:"Mode"`
-				Level float64 `xml:"Level,omitempty"`
-			} `xml:"WideDynamicRange,omitempty"`
+				Mode  string   `xml:"tt:Mode"`
+				Level *float64 `xml:"tt:Level,omitempty"`
+			} `xml:"tt:WideDynamicRange,omitempty"`
 			WhiteBalance *struct {
-				Mode   string  `xml:"Mode"`
-				CrGain float64 `xml:"CrGain,omitempty"`
-				CbGain float64 `xml:"CbGain,omitempty"`
-			} `xml:"WhiteBalance,omitempty"`
+				Mode   string   `xml:"tt:Mode"`
+				CrGain *float64 `xml:"tt:CrGain,omitempty"`
+				CbGain *float64 `xml:"tt:CbGain,omitempty"`
+			} `xml:"tt:WhiteBalance,omitempty"`
 		} `xml:"timg:ImagingSettings"`
 		ForcePersistence bool `xml:"timg:ForcePersistence"`
 	}
 
 	req := SetImagingSettings{
 		Xmlns:            imagingNamespace,
+		Xmlnst:           "http://www.onvif.org/ver10/schema",
 		VideoSourceToken: videoSourceToken,
 		ForcePersistence: forcePersistence,
 	}
@@ -207,8 +218,8 @@ func (c *Client) SetImagingSettings(
 	// Map settings
 	if settings.BacklightCompensation != nil {
 		req.ImagingSettings.BacklightCompensation = &struct {
-			Mode  string  `xml:"Mode"`
-			Level float64 `xml:"Level"`
+			Mode  string   `xml:"tt:Mode"`
+			Level *float64 `xml:"tt:Level,omitempty"`
 		}{
 			Mode:  settings.BacklightCompensation.Mode,
 			Level: settings.BacklightCompensation.Level,
@@ -223,17 +234,17 @@ func (c *Client) SetImagingSettings(
 
 	if settings.Exposure != nil {
 		req.ImagingSettings.Exposure = &struct {
-			Mode            string  `xml:"Mode"`
-			Priority        string  `xml:"Priority,omitempty"`
-			MinExposureTime float64 `xml:"MinExposureTime,omitempty"`
-			MaxExposureTime float64 `xml:"MaxExposureTime,omitempty"`
-			MinGain         float64 `xml:"MinGain,omitempty"`
-			MaxGain         float64 `xml:"MaxGain,omitempty"`
-			MinIris         float64 `xml:"MinIris,omitempty"`
-			MaxIris         float64 `xml:"MaxIris,omitempty"`
-			ExposureTime    float64 `xml:"ExposureTime,omitempty"`
-			Gain            float64 `xml:"Gain,omitempty"`
-			Iris            float64 `xml:"Iris,omitempty"`
+			Mode            string   `xml:"tt:Mode"`
+			Priority        string   `xml:"tt:Priority,omitempty"`
+			MinExposureTime *float64 `xml:"tt:MinExposureTime,omitempty"`
+			MaxExposureTime *float64 `xml:"tt:MaxExposureTime,omitempty"`
+			MinGain         *float64 `xml:"tt:MinGain,omitempty"`
+			MaxGain         *float64 `xml:"tt:MaxGain,omitempty"`
+			MinIris         *float64 `xml:"tt:MinIris,omitempty"`
+			MaxIris         *float64 `xml:"tt:MaxIris,omitempty"`
+			ExposureTime    *float64 `xml:"tt:ExposureTime,omitempty"`
+			Gain            *float64 `xml:"tt:Gain,omitempty"`
+			Iris            *float64 `xml:"tt:Iris,omitempty"`
 		}{
 			Mode:            settings.Exposure.Mode,
 			Priority:        settings.Exposure.Priority,
@@ -251,10 +262,10 @@ func (c *Client) SetImagingSettings(
 
 	if settings.Focus != nil {
 		req.ImagingSettings.Focus = &struct {
-			AutoFocusMode string  `xml:"AutoFocusMode"`
-			DefaultSpeed  float64 `xml:"DefaultSpeed,omitempty"`
-			NearLimit     float64 `xml:"NearLimit,omitempty"`
-			FarLimit      float64 `xml:"FarLimit,omitempty"`
+			AutoFocusMode string   `xml:"tt:AutoFocusMode"`
+			DefaultSpeed  *float64 `xml:"tt:DefaultSpeed,omitempty"`
+			NearLimit     *float64 `xml:"tt:NearLimit,omitempty"`
+			FarLimit      *float64 `xml:"tt:FarLimit,omitempty"`
 		}{
 			AutoFocusMode: settings.Focus.AutoFocusMode,
 			DefaultSpeed:  settings.Focus.DefaultSpeed,
@@ -265,8 +276,8 @@ func (c *Client) SetImagingSettings(
 
 	if settings.WideDynamicRange != nil {
 		req.ImagingSettings.WideDynamicRange = &struct {
-			Mode  string  `xml:"Mode"`
-			Level float64 `xml:"Level,omitempty"`
+			Mode  string   `xml:"tt:Mode"`
+			Level *float64 `xml:"tt:Level,omitempty"`
 		}{
 			Mode:  settings.WideDynamicRange.Mode,
 			Level: settings.WideDynamicRange.Level,
@@ -275,9 +286,9 @@ func (c *Client) SetImagingSettings(
 
 	if settings.WhiteBalance != nil {
 		req.ImagingSettings.WhiteBalance = &struct {
-			Mode   string  `xml:"Mode"`
-			CrGain float64 `xml:"CrGain,omitempty"`
-			CbGain float64 `xml:"CbGain,omitempty"`
+			Mode   string   `xml:"tt:Mode"`
+			CrGain *float64 `xml:"tt:CrGain,omitempty"`
+			CbGain *float64 `xml:"tt:CbGain,omitempty"`
 		}{
 			Mode:   settings.WhiteBalance.Mode,
 			CrGain: settings.WhiteBalance.CrGain,
@@ -285,8 +296,7 @@ func (c *Client) SetImagingSettings(
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetImagingSettings failed: %w", err)
@@ -295,56 +305,84 @@ func (c *Client) SetImagingSettings(
 	return nil
 }
 
-// Move performs a focus move operation.
+// Move moves the focus lens of a video source. Exactly one of the Absolute,
+// Relative and Continuous moves of focus must be set, otherwise
+// ErrInvalidParameter is returned. A continuous move runs until StopFocus is
+// called.
+//
+// The move is first checked against GetMoveOptions, and ErrFocusModeUnsupported
+// naming the supported moves is returned when the device does not list it.
+// The options are cached per video source token until a call finds the device
+// unreachable, as it is while rebooting. The check is skipped, and logged,
+// when the options cannot be retrieved.
 func (c *Client) Move(ctx context.Context, videoSourceToken string, focus *FocusMove) error {
 	endpoint := c.imagingEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
+	if err := focus.validate(); err != nil {
+		return fmt.Errorf("Move failed: %w", err)
+	}
+
+	if options, err := c.cachedMoveOptions(ctx, videoSourceToken); err != nil {
+		c.skipCheck("focus move validation", err)
+	} else if err := focus.supportedBy(options); err != nil {
+		return fmt.Errorf("Move failed: %w", err)
+	}
+
 	type Move struct {
 		XMLName          xml.Name `xml:"timg:Move"`
 		Xmlns            string   `xml:"xmlns:timg,attr"`
+		XmlnsTT          string   `xml:"xmlns:tt,attr"`
 		VideoSourceToken string   `xml:"timg:VideoSourceToken"`
-		Focus            *struct {
+		Focus            struct {
 			Absolute *struct {
-				Position float64 `xml:"Position"`
-				Speed    float64 `xml:"Speed,omitempty"`
-			} `xml:"Absolute,omitempty"`
+				Position float64  `xml:"tt:Position"`
+				Speed    *float64 `xml:"tt:Speed,omitempty"`
+			} `xml:"tt:Absolute,omitempty"`
 			Relative *struct {
-				Distance float64 `xml:"Distance"`
-				Speed    float64 `xml:"Speed,omitempty"`
-			} `xml:"Relative,omitempty"`
+				Distance float64  `xml:"tt:Distance"`
+				Speed    *float64 `xml:"tt:Speed,omitempty"`
+			} `xml:"tt:Relative,omitempty"`
 			Continuous *struct {
-				Speed float64 `xml:"Speed"`
-			} `xml:"Continuous,omitempty"`
+				Speed float64 `xml:"tt:Speed"`
+			} `xml:"tt:Continuous,omitempty"`
 		} `xml:"timg:Focus"`
 	}
 
 	req := Move{
 		Xmlns:            imagingNamespace,
+		XmlnsTT:          "http://www.onvif.org/ver10/schema",
 		VideoSourceToken: videoSourceToken,
 	}
 
-	if focus != nil {
-		req.Focus = &struct {
-			Absolute *struct {
-				Position float64 `xml:"Position"`
-				Speed    float64 `xml:"Speed,omitempty"`
-			} `xml:"Absolute,omitempty"`
-			Relative *struct {
-				Distance float64 `xml:"Distance"`
-				Speed    float64 `xml:"Speed,omitempty"`
-			} `xml:"Relative,omitempty"`
-			Continuous *struct {
-				Speed float64 `xml:"Speed"`
-			} `xml:"Continuous,omitempty"`
-		}{}
-		// Implementation would add specific focus move types here
+	switch {
+	case focus.Absolute != nil:
+		req.Focus.Absolute = &struct {
+			Position float64  `xml:"tt:Position"`
+			Speed    *float64 `xml:"tt:Speed,omitempty"`
+		}{
+			Position: focus.Absolute.Position,
+			Speed:    focus.Absolute.Speed,
+		}
+	case focus.Relative != nil:
+		req.Focus.Relative = &struct {
+			Distance float64  `xml:"tt:Distance"`
+			Speed    *float64 `xml:"tt:Speed,omitempty"`
+		}{
+			Distance: focus.Relative.Distance,
+			Speed:    focus.Relative.Speed,
+		}
+	default:
+		req.Focus.Continuous = &struct {
+			Speed float64 `xml:"tt:Speed"`
+		}{
+			Speed: focus.Continuous.Speed,
+		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Move failed: %w", err)
@@ -353,12 +391,96 @@ func (c *Client) Move(ctx context.Context, videoSourceToken string, focus *Focus
 	return nil
 }
 
-// FocusMove represents a focus move operation (placeholder for focus move types).
+// FocusMove is a focus move of Move. Exactly one of the moves must be set.
 type FocusMove struct {
-	// Can be extended with Absolute, Relative, Continuous move types
+	Absolute   *AbsoluteFocusMove
+	Relative   *RelativeFocusMove
+	Continuous *ContinuousFocusMove
+}
+
+// AbsoluteFocusMove moves the focus to a position. A nil Speed uses the
+// default speed of the device.
+type AbsoluteFocusMove struct {
+	Position float64
+	Speed    *float64
+}
+
+// RelativeFocusMove moves the focus by a distance from its position. A nil
+// Speed uses the default speed of the device.
+type RelativeFocusMove struct {
+	Distance float64
+	Speed    *float64
+}
+
+// ContinuousFocusMove moves the focus at a speed until it is stopped. The sign
+// of Speed selects the direction.
+type ContinuousFocusMove struct {
+	Speed float64
+}
+
+// supportedBy checks that options list the move.
+func (f *FocusMove) supportedBy(options *MoveOptions) error {
+	var (
+		requested string
+		supported []string
+	)
+
+	for _, mode := range []struct {
+		name      string
+		requested bool
+		supported bool
+	}{
+		{"Absolute", f.Absolute != nil, options.Absolute != nil},
+		{"Relative", f.Relative != nil, options.Relative != nil},
+		{"Continuous", f.Continuous != nil, options.Continuous != nil},
+	} {
+		if mode.requested {
+			requested = mode.name
+		}
+
+		if mode.supported {
+			supported = append(supported, mode.name)
+		}
+	}
+
+	if slices.Contains(supported, requested) {
+		return nil
+	}
+
+	if len(supported) == 0 {
+		return fmt.Errorf("%w: %s focus move requested, device supports none", ErrFocusModeUnsupported, requested)
+	}
+
+	return fmt.Errorf("%w: %s focus move requested, device supports %s",
+		ErrFocusModeUnsupported, requested, strings.Join(supported, ", "))
+}
+
+// validate checks that exactly one move is set.
+func (f *FocusMove) validate() error {
+	if f == nil {
+		return fmt.Errorf("%w: no focus move", ErrInvalidParameter)
+	}
+
+	set := 0
+
+	for _, move := range []bool{f.Absolute != nil, f.Relative != nil, f.Continuous != nil} {
+		if move {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("%w: exactly one of the absolute, relative and continuous focus moves must be set, got %d",
+			ErrInvalidParameter, set)
+	}
+
+	return nil
 }
 
-// GetOptions retrieves imaging options for a video source.
+// GetOptions retrieves the imaging options of a video source: the ranges of
+// the numeric imaging settings and the modes the device accepts. Ranges and
+// modes the device does not report are nil. ValidateImagingSettings checks
+// settings against them before SetImagingSettings.
 func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*ImagingOptions, error) {
 	endpoint := c.imagingEndpoint
 	if endpoint == "" {
@@ -371,47 +493,51 @@ func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*Imag
 		VideoSourceToken string   `xml:"timg:VideoSourceToken"`
 	}
 
+	type rangeXML struct {
+		Min float64 `xml:"Min"`
+		Max float64 `xml:"Max"`
+	}
+
 	type GetOptionsResponse struct {
 		XMLName        xml.Name `xml:"GetOptionsResponse"`
 		ImagingOptions struct {
 			BacklightCompensation *struct {
-				Mode  []string `xml:"Mode"`
-				Level struct {
-					Min float64 `xml:"Min"`
-					Max float64 `xml:"Max"`
-				} `xml:"Level"`
+				Mode  []string  `xml:"Mode"`
+				Level *rangeXML `xml:"Level"`
 			} `xml:"BacklightCompensation"`
-			Brightness *struct {
-				Min float64 `xml:"Min"`
-				Max float64 `xml:"Max"`
-			} `xml:"Brightness"`
-			ColorSaturation *struct {
-				Min float64 `xml:"Min"`
-				Max float64 `xml:"Max"`
-			} `xml:"ColorSaturation"`
-			Contrast *struct {
-				Min float64 `xml:"Min"`
-				Max float64 `xml:"Max"`
-			} `xml:"Contrast"`
-			Exposure *struct {
-				Mode            []string `xml:"Mode"`
-				Priority        []string `xml:"Priority"`
-				MinExposureTime struct {
-					Min float64 `xml:"Min"`
-					Max float64 `xml:"Max"`
-				} `xml:"MinExposureTime"`
-				MaxExposureTime struct {
-					Min float64 `xml:"Min"`
-					Max float64 `xml:"Max"`
-				} `xml:"MaxExposureTime"`
+			Brightness      *rangeXML `xml:"Brightness"`
+			ColorSaturation *rangeXML `xml:"ColorSaturation"`
+			Contrast        *rangeXML `xml:"Contrast"`
+			Exposure        *struct {
+				Mode            []string  `xml:"Mode"`
+				Priority        []string  `xml:"Priority"`
+				MinExposureTime *rangeXML `xml:"MinExposureTime"`
+				MaxExposureTime *rangeXML `xml:"MaxExposureTime"`
+				MinGain         *rangeXML `xml:"MinGain"`
+				MaxGain         *rangeXML `xml:"MaxGain"`
+				MinIris         *rangeXML `xml:"MinIris"`
+				MaxIris         *rangeXML `xml:"MaxIris"`
+				ExposureTime    *rangeXML `xml:"ExposureTime"`
+				Gain            *rangeXML `xml:"Gain"`
+				Iris            *rangeXML `xml:"Iris"`
 			} `xml:"Exposure"`
 			Focus *struct {
-				AutoFocusModes []string `xml:"AutoFocusModes"`
-				DefaultSpeed   struct {
-					Min float64 `xml:"Min"`
-					Max float64 `xml:"Max"`
-				} `xml:"DefaultSpeed"`
+				AutoFocusModes []string  `xml:"AutoFocusModes"`
+				DefaultSpeed   *rangeXML `xml:"DefaultSpeed"`
+				NearLimit      *rangeXML `xml:"NearLimit"`
+				FarLimit       *rangeXML `xml:"FarLimit"`
 			} `xml:"Focus"`
+			IrCutFilterModes []string  `xml:"IrCutFilterModes"`
+			Sharpness        *rangeXML `xml:"Sharpness"`
+			WideDynamicRange *struct {
+				Mode  []string  `xml:"Mode"`
+				Level *rangeXML `xml:"Level"`
+			} `xml:"WideDynamicRange"`
+			WhiteBalance *struct {
+				Mode   []string  `xml:"Mode"`
+				YrGain *rangeXML `xml:"YrGain"`
+				YbGain *rangeXML `xml:"YbGain"`
+			} `xml:"WhiteBalance"`
 		} `xml:"ImagingOptions"`
 	}
 
@@ -422,39 +548,112 @@ func (c *Client) GetOptions(ctx context.Context, videoSourceToken string) (*Imag
 
 	var resp GetOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetOptions failed: %w", err)
 	}
 
-	options := &ImagingOptions{}
+	toFloatRange := func(r *rangeXML) *FloatRange {
+		if r == nil {
+			return nil
+		}
+
+		return &FloatRange{Min: r.Min, Max: r.Max}
+	}
+
+	options := &ImagingOptions{
+		Brightness:      toFloatRange(resp.ImagingOptions.Brightness),
+		ColorSaturation: toFloatRange(resp.ImagingOptions.ColorSaturation),
+		Contrast:        toFloatRange(resp.ImagingOptions.Contrast),
+		Sharpness:       toFloatRange(resp.ImagingOptions.Sharpness),
+	}
 
-	if resp.ImagingOptions.Brightness != nil {
-		options.Brightness = &FloatRange{
-			Min: resp.ImagingOptions.Brightness.Min,
-			Max: resp.ImagingOptions.Brightness.Max,
+	if backlight := resp.ImagingOptions.BacklightCompensation; backlight != nil {
+		options.BacklightCompensation = &BacklightCompensationOptions{
+			Mode:  backlight.Mode,
+			Level: toFloatRange(backlight.Level),
 		}
 	}
 
-	if resp.ImagingOptions.ColorSaturation != nil {
-		options.ColorSaturation = &FloatRange{
-			Min: resp.ImagingOptions.ColorSaturation.Min,
-			Max: resp.ImagingOptions.ColorSaturation.Max,
+	if exposure := resp.ImagingOptions.Exposure; exposure != nil {
+		options.Exposure = &ExposureOptions{
+			Mode:            exposure.Mode,
+			Priority:        exposure.Priority,
+			MinExposureTime: toFloatRange(exposure.MinExposureTime),
+			MaxExposureTime: toFloatRange(exposure.MaxExposureTime),
+			MinGain:         toFloatRange(exposure.MinGain),
+			MaxGain:         toFloatRange(exposure.MaxGain),
+			MinIris:         toFloatRange(exposure.MinIris),
+			MaxIris:         toFloatRange(exposure.MaxIris),
+			ExposureTime:    toFloatRange(exposure.ExposureTime),
+			Gain:            toFloatRange(exposure.Gain),
+			Iris:            toFloatRange(exposure.Iris),
 		}
 	}
 
-	if resp.ImagingOptions.Contrast != nil {
-		options.Contrast = &FloatRange{
-			Min: resp.ImagingOptions.Contrast.Min,
-			Max: resp.ImagingOptions.Contrast.Max,
+	if focus := resp.ImagingOptions.Focus; focus != nil {
+		options.Focus = &FocusOptions{
+			AutoFocusModes: focus.AutoFocusModes,
+			DefaultSpeed:   toFloatRange(focus.DefaultSpeed),
+			NearLimit:      toFloatRange(focus.NearLimit),
+			FarLimit:       toFloatRange(focus.FarLimit),
 		}
 	}
 
+	if wdr := resp.ImagingOptions.WideDynamicRange; wdr != nil {
+		options.WideDynamicRange = &WideDynamicRangeOptions{
+			Mode:  wdr.Mode,
+			Level: toFloatRange(wdr.Level),
+		}
+	}
+
+	if whiteBalance := resp.ImagingOptions.WhiteBalance; whiteBalance != nil {
+		options.WhiteBalance = &WhiteBalanceOptions{
+			Mode:   whiteBalance.Mode,
+			YrGain: toFloatRange(whiteBalance.YrGain),
+			YbGain: toFloatRange(whiteBalance.YbGain),
+		}
+	}
+
+	options.IrCutFilterModes = resp.ImagingOptions.IrCutFilterModes
+
+	return options, nil
+}
+
+// cachedMoveOptions returns the focus move options of a video source, reading
+// them with GetMoveOptions the first time. Failed lookups are not cached.
+func (c *Client) cachedMoveOptions(ctx context.Context, videoSourceToken string) (*MoveOptions, error) {
+	c.moveOptionsMu.Lock()
+	options, ok := c.moveOptions[videoSourceToken]
+	c.moveOptionsMu.Unlock()
+
+	if ok {
+		return options, nil
+	}
+
+	options, err := c.GetMoveOptions(ctx, videoSourceToken)
+	if err != nil {
+		return nil, err
+	}
+
+	c.moveOptionsMu.Lock()
+	if c.moveOptions == nil {
+		c.moveOptions = make(map[string]*MoveOptions)
+	}
+	c.moveOptions[videoSourceToken] = options
+	c.moveOptionsMu.Unlock()
+
 	return options, nil
 }
 
+// resetMoveOptions drops the cached focus move options of all video sources.
+func (c *Client) resetMoveOptions() {
+	c.moveOptionsMu.Lock()
+	c.moveOptions = nil
+	c.moveOptionsMu.Unlock()
+}
+
 // GetMoveOptions retrieves imaging move options for focus.
 func (c *Client) GetMoveOptions(ctx context.Context, videoSourceToken string) (*MoveOptions, error) {
 	endpoint := c.imagingEndpoint
@@ -507,8 +706,7 @@ func (c *Client) GetMoveOptions(ctx context.Context, videoSourceToken string) (*
 
 	var resp GetMoveOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetMoveOptions failed: %w", err)
@@ -572,8 +770,7 @@ func (c *Client) StopFocus(ctx context.Context, videoSourceToken string) error {
 		VideoSourceToken: videoSourceToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Stop failed: %w", err)
@@ -582,7 +779,9 @@ func (c *Client) StopFocus(ctx context.Context, videoSourceToken string) error {
 	return nil
 }
 
-// GetImagingStatus retrieves imaging status.
+// GetImagingStatus retrieves the imaging status of a video source: the focus
+// position and move status. FocusStatus is nil if the device reports none,
+// e.g. for a fixed lens.
 func (c *Client) GetImagingStatus(ctx context.Context, videoSourceToken string) (*ImagingStatus, error) {
 	endpoint := c.imagingEndpoint
 	if endpoint == "" {
@@ -595,14 +794,18 @@ func (c *Client) GetImagingStatus(ctx context.Context, videoSourceToken string)
 		VideoSourceToken string   `xml:"timg:VideoSourceToken"`
 	}
 
+	type focusStatus struct {
+		Position   float64 `xml:"Position"`
+		MoveStatus string  `xml:"MoveStatus"`
+		Error      string  `xml:"Error"`
+	}
+
 	type GetStatusResponse struct {
 		XMLName       xml.Name `xml:"GetStatusResponse"`
 		ImagingStatus struct {
-			FocusStatus struct {
-				Position   float64 `xml:"Position"`
-				MoveStatus string  `xml:"MoveStatus"`
-				Error      string  `xml:"Error"`
-			} `xml:"FocusStatus"`
+			FocusStatus20 *focusStatus `xml:"FocusStatus20"`
+			// FocusStatus is the element name of Imaging ver10, still sent by some devices.
+			FocusStatus *focusStatus `xml:"FocusStatus"`
 		} `xml:"Status"`
 	}
 
@@ -613,18 +816,193 @@ func (c *Client) GetImagingStatus(ctx context.Context, videoSourceToken string)
 
 	var resp GetStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStatus failed: %w", err)
 	}
 
-	return &ImagingStatus{
-		FocusStatus: &FocusStatus{
-			Position:   resp.ImagingStatus.FocusStatus.Position,
-			MoveStatus: resp.ImagingStatus.FocusStatus.MoveStatus,
-			Error:      resp.ImagingStatus.FocusStatus.Error,
-		},
-	}, nil
+	status := &ImagingStatus{}
+
+	focus := resp.ImagingStatus.FocusStatus20
+	if focus == nil {
+		focus = resp.ImagingStatus.FocusStatus
+	}
+
+	if focus != nil {
+		status.FocusStatus = &FocusStatus{
+			Position:   focus.Position,
+			MoveStatus: focus.MoveStatus,
+			Error:      focus.Error,
+		}
+	}
+
+	return status, nil
+}
+
+// IR cut filter modes used in ImagingSettings.IrCutFilter.
+const (
+	IrCutFilterOn   = "ON"
+	IrCutFilterOff  = "OFF"
+	IrCutFilterAuto = "AUTO"
+)
+
+// ValidateImagingSettings checks settings against the modes and ranges reported
+// by GetOptions. Values the options do not describe are not checked. The returned
+// error wraps ErrInvalidParameter and names the first unsupported value.
+func ValidateImagingSettings(settings *ImagingSettings, options *ImagingOptions) error {
+	if settings == nil || options == nil {
+		return nil
+	}
+
+	if settings.IrCutFilter != nil && len(options.IrCutFilterModes) > 0 &&
+		!containsFold(options.IrCutFilterModes, *settings.IrCutFilter) {
+		return fmt.Errorf("%w: IR cut filter mode %q not supported, device accepts %s",
+			ErrInvalidParameter, *settings.IrCutFilter, strings.Join(options.IrCutFilterModes, ", "))
+	}
+
+	levels := []struct {
+		name  string
+		value *float64
+		rng   *FloatRange
+	}{
+		{"brightness", settings.Brightness, options.Brightness},
+		{"color saturation", settings.ColorSaturation, options.ColorSaturation},
+		{"contrast", settings.Contrast, options.Contrast},
+		{"sharpness", settings.Sharpness, options.Sharpness},
+	}
+
+	for _, l := range levels {
+		if l.value == nil || l.rng == nil {
+			continue
+		}
+
+		if *l.value < l.rng.Min || *l.value > l.rng.Max {
+			return fmt.Errorf("%w: %s %g outside range [%g, %g]",
+				ErrInvalidParameter, l.name, *l.value, l.rng.Min, l.rng.Max)
+		}
+	}
+
+	if err := validateExposure(settings.Exposure, options.Exposure); err != nil {
+		return err
+	}
+
+	return validateFocus(settings.Focus, options.Focus)
+}
+
+// validateExposure checks exposure settings against the exposure options.
+// Nil values are not transmitted by SetImagingSettings and are therefore not checked.
+func validateExposure(exposure *Exposure, options *ExposureOptions) error {
+	if exposure == nil || options == nil {
+		return nil
+	}
+
+	if exposure.Mode != "" && len(options.Mode) > 0 && !containsFold(options.Mode, exposure.Mode) {
+		return fmt.Errorf("%w: exposure mode %q not supported, device accepts %s",
+			ErrInvalidParameter, exposure.Mode, strings.Join(options.Mode, ", "))
+	}
+
+	if exposure.Priority != "" && len(options.Priority) > 0 && !containsFold(options.Priority, exposure.Priority) {
+		return fmt.Errorf("%w: exposure priority %q not supported, device accepts %s",
+			ErrInvalidParameter, exposure.Priority, strings.Join(options.Priority, ", "))
+	}
+
+	values := []struct {
+		name  string
+		value *float64
+		rng   *FloatRange
+	}{
+		{"MinExposureTime", exposure.MinExposureTime, options.MinExposureTime},
+		{"MaxExposureTime", exposure.MaxExposureTime, options.MaxExposureTime},
+		{"MinGain", exposure.MinGain, options.MinGain},
+		{"MaxGain", exposure.MaxGain, options.MaxGain},
+		{"MinIris", exposure.MinIris, options.MinIris},
+		{"MaxIris", exposure.MaxIris, options.MaxIris},
+		{"ExposureTime", exposure.ExposureTime, options.ExposureTime},
+		{"Gain", exposure.Gain, options.Gain},
+		{"Iris", exposure.Iris, options.Iris},
+	}
+
+	for _, v := range values {
+		if v.value == nil || v.rng == nil {
+			continue
+		}
+
+		if *v.value < v.rng.Min || *v.value > v.rng.Max {
+			return fmt.Errorf("%w: exposure %s %g outside range [%g, %g]",
+				ErrInvalidParameter, v.name, *v.value, v.rng.Min, v.rng.Max)
+		}
+	}
+
+	return nil
+}
+
+// validateImagingSettingsWithOptions validates settings that depend on device
+// support against GetOptions. Validation is skipped, and logged, when the
+// options cannot be retrieved so that devices without GetOptions keep working.
+func (c *Client) validateImagingSettingsWithOptions(
+	ctx context.Context, videoSourceToken string, settings *ImagingSettings,
+) error {
+	if settings == nil || (settings.Brightness == nil && settings.ColorSaturation == nil && settings.Contrast == nil &&
+		settings.Sharpness == nil && settings.IrCutFilter == nil && settings.Exposure == nil && settings.Focus == nil) {
+		return nil
+	}
+
+	options, err := c.GetOptions(ctx, videoSourceToken)
+	if err != nil {
+		c.skipCheck("imaging settings validation", err)
+
+		return nil
+	}
+
+	return ValidateImagingSettings(settings, options)
+}
+
+// Auto focus modes used in FocusConfiguration.AutoFocusMode.
+const (
+	AutoFocusModeAuto   = "AUTO"
+	AutoFocusModeManual = "MANUAL"
+)
+
+// validateFocus checks focus settings against the focus options. Nil values
+// are not transmitted and are therefore not checked.
+func validateFocus(focus *FocusConfiguration, options *FocusOptions) error {
+	if focus == nil || options == nil {
+		return nil
+	}
+
+	if len(options.AutoFocusModes) > 0 && !containsFold(options.AutoFocusModes, focus.AutoFocusMode) {
+		return fmt.Errorf("%w: auto focus mode %q not supported, device accepts %s",
+			ErrInvalidParameter, focus.AutoFocusMode, strings.Join(options.AutoFocusModes, ", "))
+	}
+
+	values := []struct {
+		name  string
+		value *float64
+		rng   *FloatRange
+	}{
+		{"DefaultSpeed", focus.DefaultSpeed, options.DefaultSpeed},
+		{"NearLimit", focus.NearLimit, options.NearLimit},
+		{"FarLimit", focus.FarLimit, options.FarLimit},
+	}
+
+	for _, v := range values {
+		if v.value == nil || v.rng == nil {
+			continue
+		}
+
+		if *v.value < v.rng.Min || *v.value > v.rng.Max {
+			return fmt.Errorf("%w: focus %s %g outside range [%g, %g]",
+				ErrInvalidParameter, v.name, *v.value, v.rng.Min, v.rng.Max)
+		}
+	}
+
+	return nil
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, s)
+	})
 }
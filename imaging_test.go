@@ -0,0 +1,514 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testImagingOptionsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<timg:GetOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<timg:ImagingOptions>
+				<tt:Brightness><tt:Min>0</tt:Min><tt:Max>100</tt:Max></tt:Brightness>
+				<tt:Exposure>
+					<tt:Mode>AUTO</tt:Mode>
+					<tt:Mode>MANUAL</tt:Mode>
+					<tt:MinGain><tt:Min>0</tt:Min><tt:Max>30</tt:Max></tt:MinGain>
+					<tt:MaxGain><tt:Min>0</tt:Min><tt:Max>30</tt:Max></tt:MaxGain>
+					<tt:ExposureTime><tt:Min>10</tt:Min><tt:Max>40000</tt:Max></tt:ExposureTime>
+				</tt:Exposure>
+				<tt:Focus>
+					<tt:AutoFocusModes>AUTO</tt:AutoFocusModes>
+					<tt:AutoFocusModes>MANUAL</tt:AutoFocusModes>
+					<tt:DefaultSpeed><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:DefaultSpeed>
+					<tt:NearLimit><tt:Min>0.1</tt:Min><tt:Max>3</tt:Max></tt:NearLimit>
+					<tt:FarLimit><tt:Min>0</tt:Min><tt:Max>100</tt:Max></tt:FarLimit>
+				</tt:Focus>
+				<tt:IrCutFilterModes>ON</tt:IrCutFilterModes>
+				<tt:IrCutFilterModes>OFF</tt:IrCutFilterModes>
+				<tt:Sharpness><tt:Min>0</tt:Min><tt:Max>10</tt:Max></tt:Sharpness>
+				<tt:WideDynamicRange><tt:Mode>OFF</tt:Mode><tt:Mode>ON</tt:Mode><tt:Level><tt:Min>0</tt:Min><tt:Max>100</tt:Max></tt:Level></tt:WideDynamicRange>
+				<tt:WhiteBalance><tt:Mode>AUTO</tt:Mode><tt:YrGain><tt:Min>0</tt:Min><tt:Max>255</tt:Max></tt:YrGain></tt:WhiteBalance>
+			</timg:ImagingOptions>
+		</timg:GetOptionsResponse>
+	</soap:Body>
+</soap:Envelope>`
+
+// newMockImagingServer returns a server answering GetOptions and recording SetImagingSettings requests.
+func newMockImagingServer(t *testing.T, setRequests *[]string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(bodyStr, "GetOptions"):
+			_, _ = w.Write([]byte(testImagingOptionsResponse))
+		case strings.Contains(bodyStr, "SetImagingSettings"):
+			*setRequests = append(*setRequests, bodyStr)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><timg:SetImagingSettingsResponse/></soap:Body></soap:Envelope>`))
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+	}))
+}
+
+func TestGetOptionsExposureAndIrCutFilter(t *testing.T) {
+	var setRequests []string
+
+	server := newMockImagingServer(t, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.imagingEndpoint = server.URL + "/onvif/imaging_service"
+
+	options, err := client.GetOptions(context.Background(), "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetOptions() failed: %v", err)
+	}
+
+	if len(options.IrCutFilterModes) != 2 {
+		t.Errorf("Expected 2 IR cut filter modes, got %v", options.IrCutFilterModes)
+	}
+
+	if options.Exposure == nil || len(options.Exposure.Mode) != 2 {
+		t.Fatalf("Expected exposure modes, got %+v", options.Exposure)
+	}
+
+	if options.Exposure.ExposureTime == nil || options.Exposure.ExposureTime.Max != 40000 {
+		t.Errorf("Unexpected ExposureTime range: %+v", options.Exposure.ExposureTime)
+	}
+
+	if options.Exposure.Iris != nil {
+		t.Errorf("Expected no Iris range, got %+v", options.Exposure.Iris)
+	}
+}
+
+func TestGetOptionsRanges(t *testing.T) {
+	var setRequests []string
+
+	server := newMockImagingServer(t, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.imagingEndpoint = server.URL + "/onvif/imaging_service"
+	ctx := context.Background()
+
+	options, err := client.GetOptions(ctx, "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetOptions() failed: %v", err)
+	}
+
+	if options.Brightness == nil || options.Brightness.Max != 100 || options.Sharpness == nil || options.Sharpness.Max != 10 {
+		t.Errorf("Unexpected brightness or sharpness range: %+v %+v", options.Brightness, options.Sharpness)
+	}
+
+	if options.Contrast != nil || options.BacklightCompensation != nil {
+		t.Errorf("Expected no contrast or backlight options, got %+v %+v", options.Contrast, options.BacklightCompensation)
+	}
+
+	if wdr := options.WideDynamicRange; wdr == nil || len(wdr.Mode) != 2 || wdr.Level == nil || wdr.Level.Max != 100 {
+		t.Errorf("Unexpected WDR options: %+v", wdr)
+	}
+
+	if wb := options.WhiteBalance; wb == nil || wb.YrGain == nil || wb.YrGain.Max != 255 || wb.YbGain != nil {
+		t.Errorf("Unexpected white balance options: %+v", wb)
+	}
+
+	sharpness, contrast := 11.0, 500.0
+
+	err = client.SetImagingSettings(ctx, "VideoSource_1", &ImagingSettings{Sharpness: &sharpness}, false)
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for sharpness out of range, got %v", err)
+	}
+
+	// The device reports no contrast range, so any contrast is sent.
+	if err := client.SetImagingSettings(ctx, "VideoSource_1", &ImagingSettings{Contrast: &contrast}, false); err != nil {
+		t.Errorf("SetImagingSettings() failed: %v", err)
+	}
+
+	if len(setRequests) != 1 {
+		t.Errorf("Expected only the valid settings to be sent, got %d requests", len(setRequests))
+	}
+}
+
+func TestGetImagingSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+	<timg:ImagingSettings>
+		<tt:BacklightCompensation><tt:Mode>ON</tt:Mode><tt:Level>10</tt:Level></tt:BacklightCompensation>
+		<tt:Brightness>0</tt:Brightness>
+		<tt:Contrast>60</tt:Contrast>
+		<tt:Exposure><tt:Mode>MANUAL</tt:Mode><tt:ExposureTime>8000</tt:ExposureTime><tt:Gain>0</tt:Gain></tt:Exposure>
+		<tt:Focus><tt:AutoFocusMode>AUTO</tt:AutoFocusMode></tt:Focus>
+		<tt:WideDynamicRange><tt:Mode>OFF</tt:Mode></tt:WideDynamicRange>
+	</timg:ImagingSettings>
+</timg:GetImagingSettingsResponse>
+</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	settings, err := client.GetImagingSettings(context.Background(), "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetImagingSettings() failed: %v", err)
+	}
+
+	if settings.Brightness == nil || *settings.Brightness != 0 {
+		t.Errorf("Expected a reported zero brightness, got %v", settings.Brightness)
+	}
+
+	if settings.ColorSaturation != nil || settings.Sharpness != nil {
+		t.Errorf("Expected unreported values to be nil, got %v %v", settings.ColorSaturation, settings.Sharpness)
+	}
+
+	if settings.Contrast == nil || *settings.Contrast != 60 {
+		t.Errorf("Unexpected contrast: %v", settings.Contrast)
+	}
+
+	if b := settings.BacklightCompensation; b == nil || b.Mode != "ON" || b.Level == nil || *b.Level != 10 {
+		t.Errorf("Unexpected backlight compensation: %+v", b)
+	}
+
+	if e := settings.Exposure; e == nil || e.Mode != "MANUAL" || e.ExposureTime == nil || *e.ExposureTime != 8000 {
+		t.Errorf("Unexpected exposure: %+v", e)
+	}
+
+	if settings.Focus == nil || settings.Focus.AutoFocusMode != "AUTO" {
+		t.Errorf("Unexpected focus: %+v", settings.Focus)
+	}
+
+	if settings.WideDynamicRange == nil || settings.WideDynamicRange.Mode != "OFF" || settings.WhiteBalance != nil {
+		t.Errorf("Unexpected WDR or white balance: %+v %+v", settings.WideDynamicRange, settings.WhiteBalance)
+	}
+}
+
+func TestSetImagingSettingsOmitsUnsetValues(t *testing.T) {
+	var setRequests []string
+
+	server := newMockImagingServer(t, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	brightness := 0.0
+	settings := &ImagingSettings{
+		Brightness:            &brightness,
+		BacklightCompensation: &BacklightCompensation{Mode: "ON"},
+	}
+
+	if err := client.SetImagingSettings(context.Background(), "VideoSource_1", settings, false); err != nil {
+		t.Fatalf("SetImagingSettings() failed: %v", err)
+	}
+
+	if len(setRequests) != 1 {
+		t.Fatalf("Expected 1 SetImagingSettings request, got %d", len(setRequests))
+	}
+
+	request := setRequests[0]
+
+	if !strings.Contains(request, "<tt:Brightness>0</tt:Brightness>") {
+		t.Errorf("Expected an explicit zero brightness: %s", request)
+	}
+
+	for _, element := range []string{"tt:Level", "tt:Contrast", "tt:Sharpness", "tt:Exposure", "tt:Focus", "tt:WhiteBalance"} {
+		if strings.Contains(request, "<"+element) {
+			t.Errorf("Expected no %s: %s", element, request)
+		}
+	}
+
+	settings = &ImagingSettings{
+		BacklightCompensation: &BacklightCompensation{Mode: "ON", Level: float64Ptr(0)},
+		Exposure:              &Exposure{Mode: "MANUAL", Gain: float64Ptr(0)},
+		Focus:                 &FocusConfiguration{AutoFocusMode: AutoFocusModeManual, FarLimit: float64Ptr(0)},
+		WideDynamicRange:      &WideDynamicRange{Mode: "ON", Level: float64Ptr(0)},
+	}
+
+	if err := client.SetImagingSettings(context.Background(), "VideoSource_1", settings, false); err != nil {
+		t.Fatalf("SetImagingSettings() failed: %v", err)
+	}
+
+	request = setRequests[len(setRequests)-1]
+
+	for _, want := range []string{
+		"<tt:Gain>0</tt:Gain>",
+		"<tt:FarLimit>0</tt:FarLimit>",
+	} {
+		if !strings.Contains(request, want) {
+			t.Errorf("Expected an explicit zero %s: %s", want, request)
+		}
+	}
+
+	if n := strings.Count(request, "<tt:Level>0</tt:Level>"); n != 2 {
+		t.Errorf("Expected explicit zero backlight compensation and WDR levels, got %d: %s", n, request)
+	}
+
+	if strings.Contains(request, "<tt:NearLimit") || strings.Contains(request, "<tt:ExposureTime") {
+		t.Errorf("Expected unset sub-block values to be omitted: %s", request)
+	}
+}
+
+// float64Ptr returns a pointer to v.
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestSetImagingSettingsIrCutFilterAndExposure(t *testing.T) {
+	var setRequests []string
+
+	server := newMockImagingServer(t, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.imagingEndpoint = server.URL + "/onvif/imaging_service"
+	ctx := context.Background()
+
+	irCut := IrCutFilterOff
+	settings := &ImagingSettings{
+		IrCutFilter: &irCut,
+		Exposure: &Exposure{
+			Mode:         "MANUAL",
+			ExposureTime: float64Ptr(20000),
+			Gain:         float64Ptr(12),
+		},
+	}
+
+	if err := client.SetImagingSettings(ctx, "VideoSource_1", settings, false); err != nil {
+		t.Fatalf("SetImagingSettings() failed: %v", err)
+	}
+
+	if len(setRequests) != 1 {
+		t.Fatalf("Expected 1 SetImagingSettings request, got %d", len(setRequests))
+	}
+
+	for _, want := range []string{
+		"<tt:IrCutFilter>OFF</tt:IrCutFilter>",
+		"<tt:Mode>MANUAL</tt:Mode>",
+		"<tt:ExposureTime>20000</tt:ExposureTime>",
+	} {
+		if !strings.Contains(setRequests[0], want) {
+			t.Errorf("Request missing %s: %s", want, setRequests[0])
+		}
+	}
+
+	if strings.Contains(setRequests[0], "Focus") || strings.Contains(setRequests[0], "WhiteBalance") {
+		t.Errorf("Request should omit nil sub-blocks: %s", setRequests[0])
+	}
+
+	autoIrCut := IrCutFilterAuto
+	tests := []struct {
+		name     string
+		settings *ImagingSettings
+	}{
+		{"unsupported IR cut filter mode", &ImagingSettings{IrCutFilter: &autoIrCut}},
+		{"unsupported exposure mode", &ImagingSettings{Exposure: &Exposure{Mode: "SHUTTER"}}},
+		{"exposure time out of range", &ImagingSettings{Exposure: &Exposure{Mode: "MANUAL", ExposureTime: float64Ptr(50000)}}},
+	}
+
+	for _, tt := range tests {
+		err := client.SetImagingSettings(ctx, "VideoSource_1", tt.settings, false)
+		if !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("%s: expected ErrInvalidParameter, got %v", tt.name, err)
+		}
+	}
+
+	if len(setRequests) != 1 {
+		t.Errorf("Invalid settings should not be sent, got %d requests", len(setRequests))
+	}
+}
+
+func TestSetImagingSettingsFocus(t *testing.T) {
+	var setRequests []string
+
+	server := newMockImagingServer(t, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.imagingEndpoint = server.URL + "/onvif/imaging_service"
+	ctx := context.Background()
+
+	options, err := client.GetOptions(ctx, "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetOptions() failed: %v", err)
+	}
+
+	if options.Focus == nil || len(options.Focus.AutoFocusModes) != 2 || options.Focus.NearLimit.Max != 3 {
+		t.Fatalf("Unexpected focus options: %+v", options.Focus)
+	}
+
+	settings := &ImagingSettings{
+		Focus: &FocusConfiguration{AutoFocusMode: AutoFocusModeManual, NearLimit: float64Ptr(1.5), FarLimit: float64Ptr(50)},
+	}
+
+	if err := client.SetImagingSettings(ctx, "VideoSource_1", settings, true); err != nil {
+		t.Fatalf("SetImagingSettings() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"<tt:AutoFocusMode>MANUAL</tt:AutoFocusMode>",
+		"<tt:NearLimit>1.5</tt:NearLimit>",
+		"<tt:FarLimit>50</tt:FarLimit>",
+	} {
+		if !strings.Contains(setRequests[0], want) {
+			t.Errorf("Request missing %s: %s", want, setRequests[0])
+		}
+	}
+
+	for _, focus := range []*FocusConfiguration{
+		{AutoFocusMode: "ONESHOT"},
+		{AutoFocusMode: AutoFocusModeAuto, NearLimit: float64Ptr(5)},
+		{AutoFocusMode: AutoFocusModeAuto, DefaultSpeed: float64Ptr(2)},
+	} {
+		err := client.SetImagingSettings(ctx, "VideoSource_1", &ImagingSettings{Focus: focus}, false)
+		if !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Focus %+v: expected ErrInvalidParameter, got %v", focus, err)
+		}
+	}
+
+	if len(setRequests) != 1 {
+		t.Errorf("Invalid focus settings should not be sent, got %d requests", len(setRequests))
+	}
+}
+
+func TestFocusMoveStopAndStatus(t *testing.T) {
+	var (
+		requests       []string
+		optionsQueries int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetMoveOptions"):
+			optionsQueries++
+			response = `<timg:GetMoveOptionsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<timg:MoveOptions>
+					<tt:Absolute><tt:Position><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:Position><tt:Speed><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:Speed></tt:Absolute>
+					<tt:Continuous><tt:Speed><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:Speed></tt:Continuous>
+				</timg:MoveOptions>
+			</timg:GetMoveOptionsResponse>`
+		case strings.Contains(string(body), "GetStatus"):
+			response = `<timg:GetStatusResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<timg:Status><tt:FocusStatus20><tt:Position>0.4</tt:Position><tt:MoveStatus>MOVING</tt:MoveStatus></tt:FocusStatus20></timg:Status>
+			</timg:GetStatusResponse>`
+		case strings.Contains(string(body), "timg:Move"):
+			requests = append(requests, string(body))
+			response = `<timg:MoveResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+		case strings.Contains(string(body), "timg:Stop"):
+			requests = append(requests, string(body))
+			response = `<timg:StopResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.imagingEndpoint = server.URL + "/onvif/imaging_service"
+	ctx := context.Background()
+
+	if err := client.Move(ctx, "VideoSource_1", &FocusMove{Continuous: &ContinuousFocusMove{Speed: -0.5}}); err != nil {
+		t.Fatalf("Move() failed: %v", err)
+	}
+
+	if !strings.Contains(requests[0], "<tt:Speed>-0.5</tt:Speed>") || strings.Contains(requests[0], "tt:Absolute") {
+		t.Errorf("Unexpected continuous move request: %s", requests[0])
+	}
+
+	if err := client.StopFocus(ctx, "VideoSource_1"); err != nil {
+		t.Fatalf("StopFocus() failed: %v", err)
+	}
+
+	if err := client.Move(ctx, "VideoSource_1", &FocusMove{Absolute: &AbsoluteFocusMove{Position: 0.25}}); err != nil {
+		t.Fatalf("Move() failed: %v", err)
+	}
+
+	if !strings.Contains(requests[2], "<tt:Position>0.25</tt:Position>") || strings.Contains(requests[2], "tt:Speed") {
+		t.Errorf("Unexpected absolute move request: %s", requests[2])
+	}
+
+	for _, focus := range []*FocusMove{
+		nil,
+		{},
+		{Absolute: &AbsoluteFocusMove{}, Continuous: &ContinuousFocusMove{Speed: 1}},
+	} {
+		if err := client.Move(ctx, "VideoSource_1", focus); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Move(%+v): expected ErrInvalidParameter, got %v", focus, err)
+		}
+	}
+
+	err = client.Move(ctx, "VideoSource_1", &FocusMove{Relative: &RelativeFocusMove{Distance: 0.1}})
+	if !errors.Is(err, ErrFocusModeUnsupported) || !strings.Contains(err.Error(), "Absolute, Continuous") {
+		t.Errorf("Expected ErrFocusModeUnsupported naming the supported moves, got %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Errorf("Invalid moves should not be sent, got %d requests", len(requests))
+	}
+
+	if optionsQueries != 1 {
+		t.Errorf("Expected the move options to be read once, got %d queries", optionsQueries)
+	}
+
+	// A device that dropped off, e.g. to reboot, is asked again.
+	client.observeCall(ctx, io.ErrUnexpectedEOF)
+
+	if err := client.Move(ctx, "VideoSource_1", &FocusMove{Absolute: &AbsoluteFocusMove{Position: 0.5}}); err != nil {
+		t.Fatalf("Move() failed: %v", err)
+	}
+
+	if optionsQueries != 2 {
+		t.Errorf("Expected the move options to be read again after an outage, got %d queries", optionsQueries)
+	}
+
+	status, err := client.GetImagingStatus(ctx, "VideoSource_1")
+	if err != nil {
+		t.Fatalf("GetImagingStatus() failed: %v", err)
+	}
+
+	if status.FocusStatus == nil || status.FocusStatus.Position != 0.4 || status.FocusStatus.MoveStatus != "MOVING" {
+		t.Errorf("Unexpected focus status: %+v", status.FocusStatus)
+	}
+}
@@ -0,0 +1,201 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlNamespaceURI is the namespace bound to the reserved xml prefix.
+const xmlNamespaceURI = "http://www.w3.org/XML/1998/namespace"
+
+// innerXML is the content of an element, kept as XML text that can be sent
+// back on its own. Unlike an innerxml field, which copies the text verbatim,
+// it declares the namespaces that the content inherits from its ancestors,
+// e.g. a vendor prefix declared on the SOAP envelope.
+type innerXML struct {
+	XML string
+}
+
+// UnmarshalXML implements xml.Unmarshaler.
+func (x *innerXML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	content, err := decodeInnerXML(d, start)
+	if err != nil {
+		return err
+	}
+
+	x.XML = content
+
+	return nil
+}
+
+// decodeInnerXML reads the content of start from d and re-encodes it with
+// the namespace declarations it depends on. Prefixes declared within the
+// content are kept; namespaces declared outside of it get a prefix of their
+// own, tt for the ONVIF schema.
+func decodeInnerXML(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var (
+		b     strings.Builder
+		names []string
+		// scopes holds the prefixes declared on each open element.
+		scopes []map[string]string
+	)
+
+	lookup := func(uri string, attr bool) (string, bool) {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			for prefix, bound := range scopes[i] {
+				if bound == uri && (prefix != "" || !attr) && resolves(scopes, prefix, uri) {
+					return prefix, true
+				}
+			}
+		}
+
+		return "", false
+	}
+
+	generated := 0
+	newPrefix := func(uri string) string {
+		if uri == "http://www.onvif.org/ver10/schema" {
+			if _, taken := prefixURI(scopes, "tt"); !taken {
+				return "tt"
+			}
+		}
+
+		for {
+			generated++
+
+			prefix := fmt.Sprintf("ns%d", generated)
+			if _, taken := prefixURI(scopes, prefix); !taken {
+				return prefix
+			}
+		}
+	}
+
+	// qualify returns the prefixed form of name, declaring its namespace on
+	// the current element when it is not in scope.
+	qualify := func(name xml.Name, attr bool, decls *[]xml.Attr) string {
+		switch {
+		case name.Space == "":
+			return name.Local
+		case name.Space == xmlNamespaceURI:
+			return "xml:" + name.Local
+		}
+
+		prefix, ok := lookup(name.Space, attr)
+		if !ok {
+			prefix = newPrefix(name.Space)
+			scopes[len(scopes)-1][prefix] = name.Space
+			*decls = append(*decls, xml.Attr{Name: xml.Name{Space: "xmlns", Local: prefix}, Value: name.Space})
+		}
+
+		if prefix == "" {
+			return name.Local
+		}
+
+		return prefix + ":" + name.Local
+	}
+
+	for {
+		token, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("unexpected end of element <%s>", start.Name.Local)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			scope := make(map[string]string)
+
+			var decls, attrs []xml.Attr
+
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					scope[attr.Name.Local] = attr.Value
+					decls = append(decls, attr)
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					scope[""] = attr.Value
+					decls = append(decls, attr)
+				default:
+					attrs = append(attrs, attr)
+				}
+			}
+
+			scopes = append(scopes, scope)
+
+			// Content in no namespace must not fall into an inherited default one.
+			if t.Name.Space == "" {
+				if uri, _ := prefixURI(scopes, ""); uri != "" {
+					scope[""] = ""
+					decls = append(decls, xml.Attr{Name: xml.Name{Local: "xmlns"}})
+				}
+			}
+
+			name := qualify(t.Name, false, &decls)
+
+			b.WriteString("<" + name)
+
+			for _, attr := range attrs {
+				writeAttr(&b, qualify(attr.Name, true, &decls), attr.Value)
+			}
+
+			for _, decl := range decls {
+				if decl.Name.Space == "xmlns" {
+					writeAttr(&b, "xmlns:"+decl.Name.Local, decl.Value)
+				} else {
+					writeAttr(&b, "xmlns", decl.Value)
+				}
+			}
+
+			b.WriteString(">")
+
+			names = append(names, name)
+		case xml.EndElement:
+			if len(names) == 0 {
+				return strings.TrimSpace(b.String()), nil
+			}
+
+			b.WriteString("</" + names[len(names)-1] + ">")
+
+			names = names[:len(names)-1]
+			scopes = scopes[:len(scopes)-1]
+		case xml.CharData:
+			if err := xml.EscapeText(&b, t); err != nil {
+				return "", err
+			}
+		case xml.Comment:
+			b.WriteString("<!--" + string(t) + "-->")
+		}
+	}
+}
+
+// prefixURI returns the namespace bound to prefix in scopes.
+func prefixURI(scopes []map[string]string, prefix string) (string, bool) {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if uri, ok := scopes[i][prefix]; ok {
+			return uri, true
+		}
+	}
+
+	return "", false
+}
+
+// resolves reports whether prefix is bound to uri in scopes, that is, not
+// redeclared by a nested element.
+func resolves(scopes []map[string]string, prefix, uri string) bool {
+	bound, _ := prefixURI(scopes, prefix)
+
+	return bound == uri
+}
+
+// writeAttr writes an attribute with an escaped value.
+func writeAttr(b *strings.Builder, name, value string) {
+	b.WriteString(" " + name + `="`)
+	_ = xml.EscapeText(b, []byte(value))
+	b.WriteString(`"`)
+}
@@ -0,0 +1,231 @@
+package soap
+
+import (
+	"crypto/md5" //nolint:gosec // MD5 required for HTTP Digest authentication
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// DigestSession caches the HTTP Digest challenges of the hosts a client talks
+// to, so that calls after the first authenticate without another round trip.
+// It is safe for concurrent use and is meant to be shared by the clients of
+// one device, see Client.SetDigestSession.
+type DigestSession struct {
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// digestChallenge is a parsed WWW-Authenticate Digest challenge and the nonce
+// count used with its nonce.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	stale     bool
+	nc        uint32
+}
+
+// NewDigestSession creates an empty DigestSession.
+func NewDigestSession() *DigestSession {
+	return &DigestSession{challenges: make(map[string]*digestChallenge)}
+}
+
+// setChallenge stores the challenge of host, restarting the nonce count.
+func (s *DigestSession) setChallenge(host string, challenge *digestChallenge) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.challenges[host] = challenge
+}
+
+// StoreChallenge keeps the first Digest challenge among the values of
+// WWW-Authenticate headers as the challenge of host, for HTTP requests made
+// outside SOAP calls such as downloads. It reports whether there was one.
+func (s *DigestSession) StoreChallenge(host string, headers []string) bool {
+	challenge := parseDigestChallenge(headers)
+	if challenge == nil {
+		return false
+	}
+
+	s.setChallenge(host, challenge)
+
+	return true
+}
+
+// Authorize returns the Authorization header answering the cached challenge of
+// host for a request whose body is not covered by the digest, such as a
+// download or a streamed upload, or an empty string if host sent no challenge.
+// uri is the request URI, with its query.
+func (s *DigestSession) Authorize(host, method, uri, username, password string) string {
+	header, _ := s.authorize(host, method, uri, username, password, nil)
+
+	return header
+}
+
+// authorize returns the Authorization header answering the cached challenge
+// of host, and the nonce it uses. Both are empty if host sent no challenge.
+func (s *DigestSession) authorize(host, method, uri, username, password string, body []byte) (string, string) {
+	s.mu.Lock()
+
+	challenge, ok := s.challenges[host]
+	if !ok {
+		s.mu.Unlock()
+
+		return "", ""
+	}
+
+	challenge.nc++
+	nc := challenge.nc
+	c := *challenge
+
+	s.mu.Unlock()
+
+	newHash := md5.New
+	if strings.HasPrefix(strings.ToUpper(c.algorithm), "SHA-256") {
+		newHash = sha256.New
+	}
+
+	h := func(s string) string {
+		return hashHex(newHash(), s)
+	}
+
+	cnonce := digestCnonce()
+	ncStr := fmt.Sprintf("%08x", nc)
+
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(strings.ToLower(c.algorithm), "-sess") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(method + ":" + uri)
+	if c.qop == "auth-int" {
+		ha2 = h(method + ":" + uri + ":" + h(string(body)))
+	}
+
+	var response string
+	if c.qop != "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ncStr + ":" + cnonce + ":" + c.qop + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username=%q, realm=%q, nonce=%q, uri=%q, response=%q`,
+		username, c.realm, c.nonce, uri, response)
+
+	if c.algorithm != "" {
+		header += ", algorithm=" + c.algorithm
+	}
+
+	if c.opaque != "" {
+		header += fmt.Sprintf(", opaque=%q", c.opaque)
+	}
+
+	if c.qop != "" {
+		header += fmt.Sprintf(`, qop=%s, nc=%s, cnonce=%q`, c.qop, ncStr, cnonce)
+	}
+
+	return header, c.nonce
+}
+
+// parseDigestChallenge returns the first Digest challenge among the values of
+// WWW-Authenticate headers, or nil if there is none.
+func parseDigestChallenge(headers []string) *digestChallenge {
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		params := parseAuthParams(rest)
+
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			stale:     strings.EqualFold(params["stale"], "true"),
+		}
+
+		// Prefer auth over auth-int, whose digest covers the body.
+		for _, qop := range strings.Split(params["qop"], ",") {
+			switch strings.TrimSpace(qop) {
+			case "auth":
+				challenge.qop = "auth"
+			case "auth-int":
+				if challenge.qop == "" {
+					challenge.qop = "auth-int"
+				}
+			}
+		}
+
+		if challenge.nonce != "" {
+			return challenge
+		}
+	}
+
+	return nil
+}
+
+// parseAuthParams parses the comma-separated name=value parameters of an
+// authentication challenge. Values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, ", ") {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		rest = strings.TrimSpace(rest)
+
+		var value string
+
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+
+				b.WriteByte(rest[i])
+			}
+
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+
+		params[name] = value
+	}
+
+	return params
+}
+
+// hashHex returns the hex-encoded hash of s.
+func hashHex(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// digestCnonce returns a random client nonce.
+func digestCnonce() string {
+	const cnonceSize = 16
+
+	b := make([]byte, cnonceSize)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
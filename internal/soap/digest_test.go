@@ -0,0 +1,129 @@
+package soap
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // MD5 required for HTTP Digest authentication
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s)) //nolint:gosec // MD5 required for HTTP Digest authentication
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestClientCallDigestAuth(t *testing.T) {
+	nonce := "n1"
+
+	var (
+		requests int
+		ncs      []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		challenge := func(stale bool) {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Digest realm="cam", qop="auth,auth-int", nonce=%q, opaque="op", stale=%t`, nonce, stale))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+
+		params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+		if len(params) == 0 {
+			challenge(false)
+
+			return
+		}
+
+		if params["nonce"] != nonce {
+			challenge(true)
+
+			return
+		}
+
+		ha1 := md5Hex("admin:cam:secret")
+		ha2 := md5Hex("POST:" + r.URL.RequestURI())
+		want := md5Hex(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+
+		if params["response"] != want || params["opaque"] != "op" || params["uri"] != r.URL.RequestURI() {
+			challenge(false)
+
+			return
+		}
+
+		ncs = append(ncs, params["nc"])
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><Envelope><Body><TestResponse><Value>ok</Value></TestResponse></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	type testRequest struct {
+		Value string `xml:"Value"`
+	}
+
+	type testResponse struct {
+		Value string `xml:"Value"`
+	}
+
+	session := NewDigestSession()
+
+	call := func(password string) error {
+		client := NewClient(&http.Client{}, "admin", password)
+		client.SetDigestSession(session)
+
+		var resp testResponse
+
+		return client.Call(context.Background(), server.URL+"/onvif/device_service", "", &testRequest{Value: "v"}, &resp)
+	}
+
+	// The first call answers the challenge, the second authenticates directly.
+	for range 2 {
+		if err := call("secret"); err != nil {
+			t.Fatalf("Call() failed: %v", err)
+		}
+	}
+
+	if requests != 3 || len(ncs) != 2 || ncs[0] != "00000001" || ncs[1] != "00000002" {
+		t.Errorf("Unexpected exchange: %d requests, nonce counts %v", requests, ncs)
+	}
+
+	// A stale nonce is replaced and the nonce count restarts.
+	nonce, requests, ncs = "n2", 0, nil
+
+	if err := call("secret"); err != nil {
+		t.Fatalf("Call() with stale nonce failed: %v", err)
+	}
+
+	if requests != 2 || len(ncs) != 1 || ncs[0] != "00000001" {
+		t.Errorf("Unexpected exchange: %d requests, nonce counts %v", requests, ncs)
+	}
+
+	// Wrong credentials are not retried with the same nonce.
+	requests = 0
+
+	if err := call("wrong"); err == nil || requests != 1 {
+		t.Errorf("Expected one rejected request, got %v after %d requests", err, requests)
+	}
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	challenge := parseDigestChallenge([]string{
+		`Basic realm="cam"`,
+		`Digest realm="a \"b\"", nonce="xyz", algorithm=MD5-sess, qop="auth-int"`,
+	})
+
+	if challenge == nil || challenge.realm != `a "b"` || challenge.nonce != "xyz" ||
+		challenge.algorithm != "MD5-sess" || challenge.qop != "auth-int" {
+		t.Errorf("Unexpected challenge: %+v", challenge)
+	}
+
+	if parseDigestChallenge([]string{`Basic realm="cam"`}) != nil {
+		t.Error("Expected no challenge without Digest")
+	}
+}
@@ -1,6 +1,11 @@
 package soap
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 var (
 	// ErrHTTPRequestFailed is returned when an HTTP request fails.
@@ -8,4 +13,174 @@ var (
 
 	// ErrEmptyResponseBody is returned when a response body is empty.
 	ErrEmptyResponseBody = errors.New("received empty response body")
+
+	// ErrUnauthorized matches faults and HTTP errors reporting that the caller is
+	// not authorized, whatever subcode or status the device uses for it.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrInvalidArgument matches faults reporting an invalid argument.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrActionNotSupported matches faults and HTTP errors reporting that the
+	// operation is not supported by the device.
+	ErrActionNotSupported = errors.New("action not supported")
+
+	// ErrOperationProhibited matches faults reporting that the operation is
+	// supported but not allowed in the device's current configuration.
+	ErrOperationProhibited = errors.New("operation prohibited")
+
+	// ErrNotFound matches faults and HTTP errors reporting that a referenced
+	// item does not exist.
+	ErrNotFound = errors.New("not found")
 )
+
+// faultSubcodeErrors maps the local names of fault subcodes used by ONVIF and
+// by vendors to the sentinel errors they denote.
+var faultSubcodeErrors = map[string]error{
+	"NotAuthorized":        ErrUnauthorized,
+	"NotAuthorised":        ErrUnauthorized,
+	"Unauthorized":         ErrUnauthorized,
+	"SenderNotAuthorized":  ErrUnauthorized,
+	"FailedAuthentication": ErrUnauthorized,
+	"AuthenticationFailed": ErrUnauthorized,
+	"InvalidArgVal":        ErrInvalidArgument,
+	"InvalidArgs":          ErrInvalidArgument,
+	"InvalidArg":           ErrInvalidArgument,
+	"InvalidArgument":      ErrInvalidArgument,
+	"ActionNotSupported":   ErrActionNotSupported,
+	"NotSupported":         ErrActionNotSupported,
+	"NotImplemented":       ErrActionNotSupported,
+	"OperationProhibited":  ErrOperationProhibited,
+	"NoEntity":             ErrNotFound,
+	"NoProfile":            ErrNotFound,
+	"NoConfig":             ErrNotFound,
+	"NoSource":             ErrNotFound,
+	"NoToken":              ErrNotFound,
+	"NoSuchService":        ErrNotFound,
+	"NoScope":              ErrNotFound,
+}
+
+// statusError returns the sentinel error denoted by an HTTP status code, or nil.
+func statusError(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotImplemented:
+		return ErrActionNotSupported
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// HTTPError is returned by Call when the device responds with a non-200 status
+// that does not carry a SOAP fault.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+
+	// serverErrorUnsupported records SetServerErrorsUnsupported, see UnsupportedServerError
+	serverErrorUnsupported bool
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s with status %d: %s", ErrHTTPRequestFailed, e.StatusCode, e.Body)
+}
+
+// Unwrap returns ErrHTTPRequestFailed.
+func (e *HTTPError) Unwrap() error {
+	return ErrHTTPRequestFailed
+}
+
+// Is reports whether the status code denotes target, one of ErrUnauthorized,
+// ErrActionNotSupported or ErrNotFound.
+func (e *HTTPError) Is(target error) bool {
+	err := statusError(e.StatusCode)
+
+	return err != nil && err == target
+}
+
+// UnsupportedServerError reports whether the error is a 500 status returned
+// by a client set up with SetServerErrorsUnsupported. Callers probing for an
+// optional operation may take it as ErrActionNotSupported; Is does not, so
+// that genuine server errors of other calls are not mistaken for it.
+func (e *HTTPError) UnsupportedServerError() bool {
+	return e.serverErrorUnsupported && e.StatusCode == http.StatusInternalServerError
+}
+
+// FaultError is returned by Call when the device responds with a SOAP fault.
+type FaultError struct {
+	// StatusCode is the HTTP status code the fault was delivered with.
+	StatusCode int
+	// Code is the fault code value, e.g. "env:Sender".
+	Code string
+	// Subcodes holds the nested subcode values from outermost to innermost, e.g. "ter:InvalidArgVal".
+	Subcodes []string
+	// Reason is the human-readable fault reason.
+	Reason string
+	// Detail is the raw inner XML of the fault detail element.
+	Detail string
+}
+
+// Error implements the error interface.
+func (e *FaultError) Error() string {
+	code := e.Code
+	if len(e.Subcodes) > 0 {
+		code += "/" + strings.Join(e.Subcodes, "/")
+	}
+
+	if e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("%s with status %d: SOAP fault [%s]: %s", ErrHTTPRequestFailed, e.StatusCode, code, e.Reason)
+	}
+
+	return fmt.Sprintf("SOAP fault [%s]: %s", code, e.Reason)
+}
+
+// Unwrap returns ErrHTTPRequestFailed for faults delivered with a non-200 status.
+func (e *FaultError) Unwrap() error {
+	if e.StatusCode != http.StatusOK {
+		return ErrHTTPRequestFailed
+	}
+
+	return nil
+}
+
+// Is reports whether the fault denotes target, one of ErrUnauthorized,
+// ErrInvalidArgument, ErrActionNotSupported, ErrOperationProhibited or
+// ErrNotFound. Any of the fault subcodes or the HTTP status the fault was
+// delivered with may match, since vendors report the same condition in
+// different ways.
+func (e *FaultError) Is(target error) bool {
+	for _, subcode := range e.Subcodes {
+		if err, ok := faultSubcodeErrors[localName(subcode)]; ok && err == target {
+			return true
+		}
+	}
+
+	err := statusError(e.StatusCode)
+
+	return err != nil && err == target
+}
+
+// HasSubcode reports whether the fault carries the given subcode. The namespace
+// prefix is ignored, so "InvalidArgVal" matches "ter:InvalidArgVal".
+func (e *FaultError) HasSubcode(subcode string) bool {
+	for _, s := range e.Subcodes {
+		if localName(s) == localName(subcode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// localName strips the namespace prefix from a qualified name.
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+
+	return name
+}
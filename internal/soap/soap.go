@@ -8,17 +8,24 @@ import (
 	"crypto/sha1" //nolint:gosec // SHA1 used for ONVIF digest authentication
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Envelope represents a SOAP envelope.
 type Envelope struct {
 	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
-	Header  *Header  `xml:"http://www.w3.org/2003/05/soap-envelope Header,omitempty"`
-	Body    Body     `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+	// Attrs holds extra attributes of the Envelope element, such as the
+	// namespace declarations of SetExtraNamespaces.
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Header *Header    `xml:"http://www.w3.org/2003/05/soap-envelope Header,omitempty"`
+	Body   Body       `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
 }
 
 // Header represents a SOAP header.
@@ -75,6 +82,61 @@ type Client struct {
 	password   string
 	debug      bool
 	logger     func(format string, args ...interface{})
+
+	// defaultTimeout bounds calls whose context has no deadline; zero disables it
+	defaultTimeout time.Duration
+
+	// namespaceStyle selects how request elements are namespaced, see SetNamespaceStyle
+	namespaceStyle NamespaceStyle
+
+	// timeOffset is added to the local clock for the WS-Security Created time, see SetTimeOffset
+	timeOffset time.Duration
+
+	// digest caches HTTP Digest challenges, see SetDigestSession
+	digest *DigestSession
+
+	// namespaceFallback receives the style the device accepted, see SetNamespaceFallback
+	namespaceFallback func(NamespaceStyle)
+
+	// unmappedElements receives the response elements dropped by decoding, see SetUnmappedElements
+	unmappedElements func(paths []string)
+
+	// extraNamespaces are declared on the Envelope of every request, see SetExtraNamespaces
+	extraNamespaces []xml.Attr
+
+	// callObserver is told the outcome of every call, see SetCallObserver
+	callObserver func(ctx context.Context, err error)
+
+	// serverErrorsUnsupported marks HTTP 500 errors as unsupported operations, see SetServerErrorsUnsupported
+	serverErrorsUnsupported bool
+
+	// streamResponse decodes successful responses as they are read, see CallStream
+	streamResponse bool
+}
+
+// NamespaceStyle selects how the elements of a request are namespaced.
+type NamespaceStyle int
+
+const (
+	// NamespacePrefixed sends elements with the prefixes of the request
+	// types, e.g. <trt:GetProfiles xmlns:trt="...">.
+	NamespacePrefixed NamespaceStyle = iota
+	// NamespaceDefault sends unprefixed elements with default namespace
+	// declarations, e.g. <GetProfiles xmlns="...">, for devices whose SOAP
+	// parser does not resolve prefixes.
+	NamespaceDefault
+)
+
+// String returns the name of the style.
+func (s NamespaceStyle) String() string {
+	switch s {
+	case NamespacePrefixed:
+		return "prefixed"
+	case NamespaceDefault:
+		return "default"
+	default:
+		return fmt.Sprintf("NamespaceStyle(%d)", int(s))
+	}
 }
 
 // NewClient creates a new SOAP client.
@@ -85,15 +147,84 @@ func NewClient(httpClient *http.Client, username, password string) *Client {
 		password:   password,
 		debug:      false,
 		logger:     nil,
+		digest:     NewDigestSession(),
 	}
 }
 
+// SetDigestSession shares the HTTP Digest challenges of session with the
+// client. Calls answer a 401 Digest challenge automatically; a session shared
+// by the clients of one device saves the extra round trip on later calls.
+func (c *Client) SetDigestSession(session *DigestSession) {
+	c.digest = session
+}
+
 // SetDebug enables debug logging with a custom logger.
 func (c *Client) SetDebug(enabled bool, logger func(format string, args ...interface{})) {
 	c.debug = enabled
 	c.logger = logger
 }
 
+// SetDefaultTimeout bounds each call whose context carries no deadline to the
+// given duration. Calls with a context deadline are not affected. Zero disables it.
+func (c *Client) SetDefaultTimeout(timeout time.Duration) {
+	c.defaultTimeout = timeout
+}
+
+// SetTimeOffset sets the offset of the device clock from the local clock. It
+// is added to the local time for the Created timestamp of the WS-Security
+// header, so that devices whose clock is off do not reject requests as
+// expired or not yet valid.
+func (c *Client) SetTimeOffset(offset time.Duration) {
+	c.timeOffset = offset
+}
+
+// SetNamespaceStyle selects how the elements of requests are namespaced. The
+// default is NamespacePrefixed.
+func (c *Client) SetNamespaceStyle(style NamespaceStyle) {
+	c.namespaceStyle = style
+}
+
+// SetNamespaceFallback makes calls send requests in NamespacePrefixed style and
+// retry them in NamespaceDefault style when the device rejects the prefixed
+// form as malformed, with a Sender fault that carries no ONVIF subcode or an
+// HTTP 400 error without a fault. record is called with the
+// style of each successful call. A nil record disables the fallback.
+func (c *Client) SetNamespaceFallback(record func(NamespaceStyle)) {
+	c.namespaceFallback = record
+}
+
+// SetExtraNamespaces declares the namespaces of prefixes, keyed by prefix, on
+// the Envelope element of every request, e.g. {"tas": "http://www.onvif.org/ver10/advancedsecurity/wsdl"}.
+// Declarations are emitted in prefix order. A nil map removes them.
+func (c *Client) SetExtraNamespaces(prefixes map[string]string) {
+	names := make([]string, 0, len(prefixes))
+	for prefix := range prefixes {
+		names = append(names, prefix)
+	}
+
+	sort.Strings(names)
+
+	c.extraNamespaces = nil
+	for _, prefix := range names {
+		c.extraNamespaces = append(c.extraNamespaces,
+			xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: prefixes[prefix]})
+	}
+}
+
+// SetCallObserver makes observe be called after every call with the context
+// of the call and its error, nil if it succeeded. A nil observe removes it.
+func (c *Client) SetCallObserver(observe func(ctx context.Context, err error)) {
+	c.callObserver = observe
+}
+
+// SetServerErrorsUnsupported marks the HTTPError of a response with status 500
+// and no SOAP fault as a possibly unsupported operation, see
+// HTTPError.UnsupportedServerError, for devices that answer operations they do
+// not implement that way instead of with a fault.
+func (c *Client) SetServerErrorsUnsupported(unsupported bool) {
+	c.serverErrorsUnsupported = unsupported
+}
+
 // logDebugf logs debug information if debug mode is enabled.
 func (c *Client) logDebugf(format string, args ...interface{}) {
 	if c.debug && c.logger != nil {
@@ -101,10 +232,207 @@ func (c *Client) logDebugf(format string, args ...interface{}) {
 	}
 }
 
+// credentialsKey is the context key of credentials set with WithCredentials.
+type credentialsKey struct{}
+
+// credentials is a username and password carried in a context.
+type credentials struct {
+	username string
+	password string
+}
+
+// WithCredentials returns a context whose calls authenticate with the given
+// credentials instead of the ones the client was created with.
+func WithCredentials(ctx context.Context, username, password string) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials{username: username, password: password})
+}
+
+// CredentialsFromContext returns the credentials set on ctx with
+// WithCredentials or WithoutAuth; ok is false if there are none.
+func CredentialsFromContext(ctx context.Context) (username, password string, ok bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(credentials)
+
+	return creds.username, creds.password, ok
+}
+
+// WithoutAuth returns a context whose calls are sent without a WS-Security
+// header, for operations the ONVIF specification allows before
+// authentication, such as GetSystemDateAndTime.
+func WithoutAuth(ctx context.Context) context.Context {
+	return WithCredentials(ctx, "", "")
+}
+
 // Call makes a SOAP call to the specified endpoint.
 func (c *Client) Call(ctx context.Context, endpoint, action string, request, response interface{}) error {
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		clone := *c
+		clone.username, clone.password = creds.username, creds.password
+		c = &clone
+	}
+
+	err := c.callWithFallback(ctx, endpoint, action, request, response)
+
+	if c.callObserver != nil {
+		c.callObserver(ctx, err)
+	}
+
+	return err
+}
+
+// CallStream is like Call, but decodes a successful response while it is read
+// from the connection instead of reading the body in full first. Together with
+// a response type that decodes incrementally, e.g. with its own UnmarshalXML,
+// large responses are processed without being held in memory. Streamed
+// responses are neither logged in debug mode nor checked for unmapped
+// elements.
+func (c *Client) CallStream(ctx context.Context, endpoint, action string, request, response interface{}) error {
+	clone := *c
+	clone.streamResponse = true
+
+	return clone.Call(ctx, endpoint, action, request, response)
+}
+
+// callWithFallback makes a call with the default timeout and the namespace
+// fallback of SetNamespaceFallback applied.
+func (c *Client) callWithFallback(ctx context.Context, endpoint, action string, request, response interface{}) error {
+	if _, ok := ctx.Deadline(); !ok && c.defaultTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.defaultTimeout)
+		defer cancel()
+	}
+
+	if c.namespaceFallback == nil {
+		return c.call(ctx, endpoint, action, request, response, c.namespaceStyle)
+	}
+
+	err := c.call(ctx, endpoint, action, request, response, NamespacePrefixed)
+	if err == nil {
+		c.namespaceFallback(NamespacePrefixed)
+
+		return nil
+	}
+
+	if !rejectsRequest(err) {
+		return err
+	}
+
+	c.logDebugf("Request rejected in prefixed form, retrying with default namespaces: %v\n", err)
+
+	if retryErr := c.call(ctx, endpoint, action, request, response, NamespaceDefault); retryErr != nil {
+		return errors.Join(err, fmt.Errorf("retry with default namespaces: %w", retryErr))
+	}
+
+	c.namespaceFallback(NamespaceDefault)
+
+	return nil
+}
+
+// post sends a SOAP request. If the device answers with an HTTP Digest
+// challenge, the request is sent again with an Authorization header, and the
+// challenge is kept for later calls in the digest session.
+func (c *Client) post(ctx context.Context, endpoint, action string, body []byte) (*http.Response, error) {
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+		if action != "" {
+			req.Header.Set("SOAPAction", action)
+		}
+
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+		}
+
+		return resp, nil
+	}
+
+	if c.username == "" || c.password == "" {
+		return send("")
+	}
+
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	host, uri := target.Host, target.RequestURI()
+
+	session := c.digest
+	if session == nil {
+		session = NewDigestSession()
+	}
+
+	authorization, nonce := session.authorize(host, "POST", uri, c.username, c.password, body)
+
+	resp, err := send(authorization)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// A challenge for the nonce just answered means the credentials were
+	// rejected, unless the device reports the nonce as stale.
+	challenge := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil || (challenge.nonce == nonce && !challenge.stale) {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	c.logDebugf("HTTP Digest challenge from %s, retrying with authorization\n", host)
+
+	session.setChallenge(host, challenge)
+	authorization, _ = session.authorize(host, "POST", uri, c.username, c.password, body)
+
+	return send(authorization)
+}
+
+// rejectsRequest reports whether err is a device rejecting the form of a
+// request rather than its content or the caller: a Sender fault without an
+// ONVIF subcode, as devices report XML they cannot parse, or an HTTP 400
+// without a SOAP fault. Other faults and statuses are not retried, since the
+// device may have carried out a request that is not idempotent.
+func rejectsRequest(err error) bool {
+	if errors.Is(err, ErrUnauthorized) {
+		return false
+	}
+
+	var fault *FaultError
+	if errors.As(err, &fault) {
+		if code := localName(fault.Code); code != "Sender" && code != "Client" {
+			return false
+		}
+
+		for _, subcode := range fault.Subcodes {
+			if _, known := faultSubcodeErrors[localName(subcode)]; known || strings.HasPrefix(subcode, "ter:") {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var httpErr *HTTPError
+
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusBadRequest
+}
+
+// call makes one SOAP call with request elements namespaced in style.
+func (c *Client) call(
+	ctx context.Context, endpoint, action string, request, response interface{}, style NamespaceStyle,
+) error {
 	// Build SOAP envelope
 	envelope := &Envelope{
+		Attrs: c.extraNamespaces,
 		Body: Body{
 			Content: request,
 		},
@@ -123,33 +451,34 @@ func (c *Client) Call(ctx context.Context, endpoint, action string, request, res
 		return fmt.Errorf("failed to marshal SOAP envelope: %w", err)
 	}
 
+	if style == NamespaceDefault {
+		if body, err = defaultNamespaces(body); err != nil {
+			return fmt.Errorf("failed to rewrite SOAP envelope namespaces: %w", err)
+		}
+	}
+
 	// Add XML declaration
 	xmlBody := append([]byte(xml.Header), body...)
 
 	// Log request if debug is enabled
 	c.logDebugf("=== SOAP Request ===\nEndpoint: %s\nAction: %s\n%s\n", endpoint, action, string(xmlBody))
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(xmlBody))
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
-	if action != "" {
-		req.Header.Set("SOAPAction", action)
-	}
-
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.post(ctx, endpoint, action, xmlBody)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %w", err)
+		return err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	// Decode a successful response as it arrives, see CallStream
+	if c.streamResponse && response != nil && resp.StatusCode == http.StatusOK {
+		c.logDebugf("=== SOAP Response ===\nStatus: %d\n(streamed)\n", resp.StatusCode)
+
+		return decodeResponse(xml.NewDecoder(resp.Body), response, resp.StatusCode)
+	}
+
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -159,9 +488,18 @@ func (c *Client) Call(ctx context.Context, endpoint, action string, request, res
 	// Log response if debug is enabled
 	c.logDebugf("=== SOAP Response ===\nStatus: %d\n%s\n", resp.StatusCode, string(respBody))
 
+	// Faults are reported as *FaultError regardless of the HTTP status
+	if fault := parseFault(respBody, resp.StatusCode); fault != nil {
+		return fault
+	}
+
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w with status %d: %s", ErrHTTPRequestFailed, resp.StatusCode, string(respBody))
+		return &HTTPError{
+			StatusCode:             resp.StatusCode,
+			Body:                   string(respBody),
+			serverErrorUnsupported: c.serverErrorsUnsupported,
+		}
 	}
 
 	// If response is empty, return immediately
@@ -171,24 +509,264 @@ func (c *Client) Call(ctx context.Context, endpoint, action string, request, res
 
 	// Unmarshal response content if response is provided
 	if response != nil {
-		// Create a flexible envelope structure for parsing responses
-		var envelope struct {
-			Body struct {
-				Content []byte `xml:",innerxml"`
-			} `xml:"Body"`
+		if err := decodeResponse(xml.NewDecoder(bytes.NewReader(respBody)), response, resp.StatusCode); err != nil {
+			return err
 		}
 
-		if err := xml.Unmarshal(respBody, &envelope); err != nil {
-			return fmt.Errorf("failed to unmarshal SOAP envelope: %w", err)
+		if c.unmappedElements != nil {
+			var envelope struct {
+				Body struct {
+					Content []byte `xml:",innerxml"`
+				} `xml:"Body"`
+			}
+
+			if err := xml.Unmarshal(respBody, &envelope); err == nil {
+				if paths, err := unmappedElements(envelope.Body.Content, response); err == nil && len(paths) > 0 {
+					c.unmappedElements(paths)
+				}
+			}
 		}
+	}
+
+	return nil
+}
 
-		// Unmarshal the body content into the response
-		if err := xml.Unmarshal(envelope.Body.Content, response); err != nil {
+// decodeResponse decodes the first element in the SOAP Body read by decoder
+// into response, or returns it as a *FaultError if it is a fault. The element
+// is decoded in place rather than from a copy of the Body's content, so that
+// namespaces declared on the Envelope or Body stay in scope for content kept
+// as XML.
+func decodeResponse(decoder *xml.Decoder, response interface{}, statusCode int) error {
+	inBody := false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
 			return fmt.Errorf("failed to unmarshal response: %w", err)
 		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if !inBody {
+				inBody = t.Name.Local == "Body"
+
+				continue
+			}
+
+			if t.Name.Local == "Fault" {
+				var fault faultElement
+				if err := decoder.DecodeElement(&fault, &t); err != nil {
+					return fmt.Errorf("failed to unmarshal SOAP fault: %w", err)
+				}
+
+				return fault.toFaultError(statusCode)
+			}
+
+			if err := decoder.DecodeElement(response, &t); err != nil {
+				return fmt.Errorf("failed to unmarshal response: %w", err)
+			}
+
+			return nil
+		case xml.EndElement:
+			if inBody {
+				return fmt.Errorf("failed to unmarshal response: %w", io.EOF)
+			}
+		}
 	}
+}
 
-	return nil
+// namespaceScope is the namespace state of an element in defaultNamespaces.
+type namespaceScope struct {
+	// prefixes holds the prefix declarations of the element
+	prefixes map[string]string
+	// original is the default namespace in scope in the input
+	original string
+	// emitted is the default namespace in scope in the output
+	emitted string
+}
+
+// defaultNamespaces rewrites the prefixed elements of an XML document, such as
+// <trt:GetProfiles xmlns:trt="...">, to unprefixed elements with a default
+// namespace declaration, e.g. <GetProfiles xmlns="...">. Unprefixed elements
+// keep their namespace. Prefix declarations and prefixed attributes are kept,
+// since attribute and text values, such as topic expressions, may use them.
+func defaultNamespaces(doc []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	stack := []namespaceScope{{}}
+
+	lookup := func(prefix string) (string, bool) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if uri, ok := stack[i].prefixes[prefix]; ok {
+				return uri, true
+			}
+		}
+
+		return "", false
+	}
+
+	var out bytes.Buffer
+
+	for {
+		tok, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := stack[len(stack)-1]
+			scope := namespaceScope{original: parent.original, emitted: parent.emitted}
+
+			attrs := make([]xml.Attr, 0, len(t.Attr))
+
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					scope.original = attr.Value
+				case attr.Name.Space == "xmlns":
+					if scope.prefixes == nil {
+						scope.prefixes = make(map[string]string)
+					}
+
+					scope.prefixes[attr.Name.Local] = attr.Value
+					attrs = append(attrs, attr)
+				default:
+					attrs = append(attrs, attr)
+				}
+			}
+
+			stack = append(stack, scope)
+
+			namespace := scope.original
+			if t.Name.Space != "" {
+				uri, ok := lookup(t.Name.Space)
+				if !ok {
+					return nil, fmt.Errorf("undeclared namespace prefix %q", t.Name.Space)
+				}
+
+				namespace = uri
+			}
+
+			out.WriteString("<" + t.Name.Local)
+
+			if namespace != scope.emitted {
+				stack[len(stack)-1].emitted = namespace
+				writeAttr(&out, "xmlns", namespace)
+			}
+
+			for _, attr := range attrs {
+				name := attr.Name.Local
+				if attr.Name.Space != "" {
+					name = attr.Name.Space + ":" + name
+				}
+
+				writeAttr(&out, name, attr.Value)
+			}
+
+			out.WriteString(">")
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			out.WriteString("</" + t.Name.Local + ">")
+		case xml.CharData:
+			if err := xml.EscapeText(&out, t); err != nil {
+				return nil, err
+			}
+		case xml.ProcInst:
+			out.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Comment, xml.Directive:
+			// Dropped; marshaled requests contain none.
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// writeAttr writes an attribute with an escaped value.
+func writeAttr(out *bytes.Buffer, name, value string) {
+	out.WriteString(" " + name + `="`)
+	_ = xml.EscapeText(out, []byte(value))
+	out.WriteString(`"`)
+}
+
+// faultSubcode represents a (possibly nested) SOAP 1.2 fault subcode.
+type faultSubcode struct {
+	Value   string        `xml:"Value"`
+	Subcode *faultSubcode `xml:"Subcode"`
+}
+
+// parseFault returns the SOAP fault contained in a response body, or nil if there is none.
+// Both SOAP 1.2 (Code/Reason/Detail) and SOAP 1.1 (faultcode/faultstring/detail) faults are recognized.
+func parseFault(body []byte, statusCode int) *FaultError {
+	if !bytes.Contains(body, []byte("Fault")) {
+		return nil
+	}
+
+	var envelope struct {
+		Body struct {
+			Fault *faultElement `xml:"Fault"`
+		} `xml:"Body"`
+	}
+
+	if err := xml.Unmarshal(body, &envelope); err != nil || envelope.Body.Fault == nil {
+		return nil
+	}
+
+	return envelope.Body.Fault.toFaultError(statusCode)
+}
+
+// faultElement is the wire form of a SOAP 1.2 or SOAP 1.1 Fault element.
+type faultElement struct {
+	Code struct {
+		Value   string        `xml:"Value"`
+		Subcode *faultSubcode `xml:"Subcode"`
+	} `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Detail struct {
+		Content string `xml:",innerxml"`
+	} `xml:"Detail"`
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	FaultDetail struct {
+		Content string `xml:",innerxml"`
+	} `xml:"detail"`
+}
+
+// toFaultError converts a decoded fault received with statusCode.
+func (f *faultElement) toFaultError(statusCode int) *FaultError {
+	fault := &FaultError{
+		StatusCode: statusCode,
+		Code:       strings.TrimSpace(f.Code.Value),
+		Detail:     strings.TrimSpace(f.Detail.Content),
+	}
+
+	for sub := f.Code.Subcode; sub != nil; sub = sub.Subcode {
+		fault.Subcodes = append(fault.Subcodes, strings.TrimSpace(sub.Value))
+	}
+
+	if len(f.Reason.Text) > 0 {
+		fault.Reason = strings.TrimSpace(f.Reason.Text[0])
+	}
+
+	// SOAP 1.1 fault fields
+	if fault.Code == "" {
+		fault.Code = strings.TrimSpace(f.FaultCode)
+	}
+
+	if fault.Reason == "" {
+		fault.Reason = strings.TrimSpace(f.FaultString)
+	}
+
+	if fault.Detail == "" {
+		fault.Detail = strings.TrimSpace(f.FaultDetail.Content)
+	}
+
+	return fault
 }
 
 // createSecurityHeader creates a WS-Security header with username token digest.
@@ -200,8 +778,8 @@ func (c *Client) createSecurityHeader() *Security {
 	_, _ = rand.Read(nonceBytes)
 	nonce := base64.StdEncoding.EncodeToString(nonceBytes)
 
-	// Get current timestamp
-	created := time.Now().UTC().Format(time.RFC3339)
+	// Get current timestamp on the device clock
+	created := time.Now().Add(c.timeOffset).UTC().Format(time.RFC3339)
 
 	// Calculate password digest: Base64(SHA1(nonce + created + password))
 	hash := sha1.New() //nolint:gosec // SHA1 required for ONVIF digest auth
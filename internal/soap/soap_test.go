@@ -2,8 +2,13 @@ package soap
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -193,6 +198,278 @@ func TestClientCall(t *testing.T) {
 	}
 }
 
+func TestDefaultNamespaces(t *testing.T) {
+	in := `<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Body>` +
+		`<trt:GetProfile xmlns:trt="urn:media" xmlns:tt="urn:schema"><trt:ProfileToken>a&amp;b</trt:ProfileToken>` +
+		`<tt:Name tt:kind="x">n</tt:Name><Plain>p</Plain></trt:GetProfile></Body></Envelope>`
+
+	out, err := defaultNamespaces([]byte(in))
+	if err != nil {
+		t.Fatalf("defaultNamespaces() failed: %v", err)
+	}
+
+	want := `<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope"><Body>` +
+		`<GetProfile xmlns="urn:media" xmlns:trt="urn:media" xmlns:tt="urn:schema"><ProfileToken>a&amp;b</ProfileToken>` +
+		`<Name xmlns="urn:schema" tt:kind="x">n</Name>` +
+		`<Plain xmlns="http://www.w3.org/2003/05/soap-envelope">p</Plain></GetProfile></Body></Envelope>`
+	if string(out) != want {
+		t.Errorf("defaultNamespaces() =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, err := defaultNamespaces([]byte(`<x:A></x:A>`)); err == nil {
+		t.Error("Expected error for undeclared prefix")
+	}
+}
+
+func TestUnmappedElements(t *testing.T) {
+	type item struct {
+		Token string `xml:"token,attr"`
+		Name  string `xml:"Name"`
+	}
+
+	type opaque struct {
+		XML string `xml:",innerxml"`
+	}
+
+	type response struct {
+		XMLName xml.Name `xml:"GetItemsResponse"`
+		Items   []*item  `xml:"Items"`
+		Limit   int      `xml:"Limits>Max"`
+		Vendor  opaque   `xml:"Vendor"`
+		Plain   string   `xml:"Plain"`
+	}
+
+	doc := `<tr:GetItemsResponse xmlns:tr="urn:x">` +
+		`<tr:Items token="1"><tr:Name>a</tr:Name><tr:Extension><tr:Foo/></tr:Extension></tr:Items>` +
+		`<tr:Items token="2"><tr:Name>b</tr:Name><tr:Extension/></tr:Items>` +
+		`<tr:Limits><tr:Max>3</tr:Max><tr:Min>1</tr:Min></tr:Limits>` +
+		`<tr:Vendor><tr:Anything><tr:Deep/></tr:Anything></tr:Vendor>` +
+		`<tr:Plain>p<tr:Child/></tr:Plain>` +
+		`<tr:Extra/></tr:GetItemsResponse>`
+
+	paths, err := unmappedElements([]byte(doc), &response{})
+	if err != nil {
+		t.Fatalf("unmappedElements() failed: %v", err)
+	}
+
+	want := []string{
+		"GetItemsResponse/Items/Extension",
+		"GetItemsResponse/Limits/Min",
+		"GetItemsResponse/Plain/Child",
+		"GetItemsResponse/Extra",
+	}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("unmappedElements() = %v, want %v", paths, want)
+	}
+}
+
+func TestClientCallUnmappedElements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><Envelope><Body>` +
+			`<TestResponse><Value>ok</Value><Unknown>x</Unknown></TestResponse></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "", "")
+
+	var reported []string
+
+	client.SetUnmappedElements(func(paths []string) {
+		reported = append(reported, paths...)
+	})
+
+	var resp struct {
+		XMLName xml.Name `xml:"TestResponse"`
+		Value   string   `xml:"Value"`
+	}
+
+	if err := client.Call(context.Background(), server.URL, "", struct{}{}, &resp); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	if resp.Value != "ok" {
+		t.Errorf("Value = %q, want ok", resp.Value)
+	}
+
+	if len(reported) != 1 || reported[0] != "TestResponse/Unknown" {
+		t.Errorf("Unexpected unmapped elements: %v", reported)
+	}
+}
+
+func TestClientCallExtraNamespaces(t *testing.T) {
+	var body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><Envelope><Body><VendorResponse/></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, "", "")
+	client.SetExtraNamespaces(map[string]string{
+		"tmd": "http://www.onvif.org/ver10/deviceIO/wsdl",
+		"acm": "http://example.com/acme",
+	})
+
+	request := struct {
+		XMLName xml.Name `xml:"acm:Vendor"`
+		Value   string   `xml:"acm:Value"`
+	}{Value: "x"}
+
+	if err := client.Call(context.Background(), server.URL, "", request, nil); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	want := `<Envelope xmlns="http://www.w3.org/2003/05/soap-envelope" xmlns:acm="http://example.com/acme" ` +
+		`xmlns:tmd="http://www.onvif.org/ver10/deviceIO/wsdl">`
+	if !strings.Contains(body, want) {
+		t.Errorf("Expected the namespaces declared on the envelope: %s", body)
+	}
+
+	client.SetNamespaceStyle(NamespaceDefault)
+
+	if err := client.Call(context.Background(), server.URL, "", request, nil); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	if !strings.Contains(body, `<Vendor xmlns="http://example.com/acme">`) {
+		t.Errorf("Expected the envelope declarations to resolve prefixes: %s", body)
+	}
+
+	client.SetExtraNamespaces(nil)
+	client.SetNamespaceStyle(NamespacePrefixed)
+
+	if err := client.Call(context.Background(), server.URL, "", request, nil); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	if strings.Contains(body, "xmlns:acm") {
+		t.Errorf("Expected no extra namespaces: %s", body)
+	}
+}
+
+func TestClientCallNamespaceFallback(t *testing.T) {
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if strings.Contains(string(body), "<trt:") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("Bad Request"))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><Envelope><Body><TestResponse><Value>ok</Value></TestResponse></Body></Envelope>`))
+	}))
+	defer server.Close()
+
+	type testRequest struct {
+		XMLName xml.Name `xml:"trt:Test"`
+		Xmlns   string   `xml:"xmlns:trt,attr"`
+		Value   string   `xml:"trt:Value"`
+	}
+
+	type testResponse struct {
+		Value string `xml:"Value"`
+	}
+
+	var recorded []NamespaceStyle
+
+	client := NewClient(&http.Client{}, "admin", "password")
+	client.SetNamespaceFallback(func(style NamespaceStyle) { recorded = append(recorded, style) })
+
+	var resp testResponse
+
+	req := &testRequest{Xmlns: "urn:media", Value: "v"}
+	if err := client.Call(context.Background(), server.URL, "", req, &resp); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	if resp.Value != "ok" || len(bodies) != 2 || !strings.Contains(bodies[1], `<Test xmlns="urn:media"`) {
+		t.Errorf("Unexpected exchange: %q, response %+v", bodies, resp)
+	}
+
+	if len(recorded) != 1 || recorded[0] != NamespaceDefault {
+		t.Errorf("Expected NamespaceDefault to be recorded, got %v", recorded)
+	}
+
+	// Without the fallback, the prefixed form is sent once.
+	bodies = nil
+
+	client.SetNamespaceFallback(nil)
+
+	if err := client.Call(context.Background(), server.URL, "", req, &resp); err == nil || len(bodies) != 1 {
+		t.Errorf("Expected one rejected call, got %v after %d requests", err, len(bodies))
+	}
+}
+
+func TestClientCallNamespaceFallbackRetries(t *testing.T) {
+	fault := func(code, subcode string) string {
+		if subcode != "" {
+			subcode = `<soap:Subcode><soap:Value>` + subcode + `</soap:Value></soap:Subcode>`
+		}
+
+		return `<?xml version="1.0"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><soap:Fault>` +
+			`<soap:Code><soap:Value>` + code + `</soap:Value>` + subcode + `</soap:Code>` +
+			`<soap:Reason><soap:Text>Rejected</soap:Text></soap:Reason></soap:Fault></soap:Body></soap:Envelope>`
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		requests int
+	}{
+		{"sender fault without subcode", http.StatusBadRequest, fault("soap:Sender", ""), 2},
+		{"sender fault with vendor subcode", http.StatusInternalServerError, fault("soap:Sender", "xyz:WellFormed"), 2},
+		{"bad request", http.StatusBadRequest, "Bad Request", 2},
+		{"invalid argument", http.StatusBadRequest, fault("soap:Sender", "ter:InvalidArgVal"), 1},
+		{"action not supported", http.StatusInternalServerError, fault("soap:Receiver", "ter:ActionNotSupported"), 1},
+		{"receiver fault", http.StatusInternalServerError, fault("soap:Receiver", ""), 1},
+		{"internal server error", http.StatusInternalServerError, "Internal Server Error", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			type testRequest struct {
+				XMLName xml.Name `xml:"trt:Test"`
+				Xmlns   string   `xml:"xmlns:trt,attr"`
+			}
+
+			client := NewClient(&http.Client{}, "", "")
+			client.SetNamespaceFallback(func(NamespaceStyle) {})
+
+			err := client.Call(context.Background(), server.URL, "", &testRequest{Xmlns: "urn:media"}, nil)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			if requests != tt.requests {
+				t.Errorf("Expected %d requests, got %d", tt.requests, requests)
+			}
+
+			if tt.requests == 2 && !strings.Contains(err.Error(), "retry with default namespaces") {
+				t.Errorf("Expected the retry error to be reported, got %v", err)
+			}
+		})
+	}
+}
+
 func TestClientCallWithTimeout(t *testing.T) {
 	// Server that delays response
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -221,6 +498,228 @@ func TestClientCallWithTimeout(t *testing.T) {
 	}
 }
 
+func TestClientCallObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><Response/></soap:Body></soap:Envelope>`))
+	}))
+
+	client := NewClient(&http.Client{}, "", "")
+
+	var observed []error
+
+	client.SetCallObserver(func(_ context.Context, err error) {
+		observed = append(observed, err)
+	})
+
+	type testRequest struct {
+		Value string `xml:"Value"`
+	}
+
+	var resp interface{}
+
+	if err := client.Call(context.Background(), server.URL, "", &testRequest{}, &resp); err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+
+	server.Close()
+
+	if err := client.Call(context.Background(), server.URL, "", &testRequest{}, &resp); err == nil {
+		t.Fatal("Expected Call() to fail after the server closed")
+	}
+
+	if len(observed) != 2 || observed[0] != nil || observed[1] == nil {
+		t.Errorf("Expected a success and a failure to be observed, got %v", observed)
+	}
+}
+
+func TestClientCallServerErrorsUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	type testRequest struct {
+		Value string `xml:"Value"`
+	}
+
+	for _, unsupported := range []bool{false, true} {
+		client := NewClient(&http.Client{}, "", "")
+		client.SetServerErrorsUnsupported(unsupported)
+
+		var resp interface{}
+
+		err := client.Call(context.Background(), server.URL, "", &testRequest{}, &resp)
+
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("Expected an HTTPError with status 500, got %v", err)
+		}
+
+		if httpErr.UnsupportedServerError() != unsupported {
+			t.Errorf("SetServerErrorsUnsupported(%v): UnsupportedServerError() = %v", unsupported, !unsupported)
+		}
+
+		// Only callers probing for optional operations take the 500 as unsupported.
+		if errors.Is(err, ErrActionNotSupported) {
+			t.Errorf("SetServerErrorsUnsupported(%v): expected the error not to match ErrActionNotSupported", unsupported)
+		}
+	}
+}
+
+func TestClientCallFault(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantCode   string
+		wantSub    string
+		wantReason string
+		wantHTTP   bool
+	}{
+		{
+			name:       "SOAP 1.2 fault with HTTP 500",
+			statusCode: http.StatusInternalServerError,
+			body: `<?xml version="1.0"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<env:Body>
+		<env:Fault>
+			<env:Code>
+				<env:Value>env:Sender</env:Value>
+				<env:Subcode>
+					<env:Value>ter:InvalidArgVal</env:Value>
+					<env:Subcode><env:Value>ter:NoProfile</env:Value></env:Subcode>
+				</env:Subcode>
+			</env:Code>
+			<env:Reason><env:Text xml:lang="en">Profile does not exist</env:Text></env:Reason>
+		</env:Fault>
+	</env:Body>
+</env:Envelope>`,
+			wantCode:   "env:Sender",
+			wantSub:    "NoProfile",
+			wantReason: "Profile does not exist",
+			wantHTTP:   true,
+		},
+		{
+			name:       "SOAP 1.1 fault with HTTP 200",
+			statusCode: http.StatusOK,
+			body: `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">
+	<s:Body>
+		<s:Fault>
+			<faultcode>s:Client</faultcode>
+			<faultstring>Not authorized</faultstring>
+		</s:Fault>
+	</s:Body>
+</s:Envelope>`,
+			wantCode:   "s:Client",
+			wantReason: "Not authorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(&http.Client{}, "", "")
+
+			// Faults are recognized whether or not the response is streamed.
+			for _, call := range []func(context.Context, string, string, interface{}, interface{}) error{
+				client.Call, client.CallStream,
+			} {
+				var resp struct{}
+
+				err := call(context.Background(), server.URL, "", struct{}{}, &resp)
+
+				var fault *FaultError
+				if !errors.As(err, &fault) {
+					t.Fatalf("Expected *FaultError, got %v", err)
+				}
+
+				if fault.Code != tt.wantCode || fault.Reason != tt.wantReason {
+					t.Errorf("Fault = %q/%q, want %q/%q", fault.Code, fault.Reason, tt.wantCode, tt.wantReason)
+				}
+
+				if tt.wantSub != "" && !fault.HasSubcode(tt.wantSub) {
+					t.Errorf("Expected subcode %q in %v", tt.wantSub, fault.Subcodes)
+				}
+
+				if errors.Is(err, ErrHTTPRequestFailed) != tt.wantHTTP {
+					t.Errorf("errors.Is(err, ErrHTTPRequestFailed) = %v, want %v", !tt.wantHTTP, tt.wantHTTP)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []error
+	}{
+		{
+			name: "NotAuthorized subcode",
+			err:  &FaultError{StatusCode: http.StatusBadRequest, Code: "env:Sender", Subcodes: []string{"ter:NotAuthorized"}},
+			want: []error{ErrUnauthorized},
+		},
+		{
+			name: "vendor Unauthorized subcode",
+			err:  &FaultError{StatusCode: http.StatusOK, Code: "env:Sender", Subcodes: []string{"Unauthorized"}},
+			want: []error{ErrUnauthorized},
+		},
+		{
+			name: "fault with HTTP 401",
+			err:  &FaultError{StatusCode: http.StatusUnauthorized, Code: "env:Sender"},
+			want: []error{ErrUnauthorized},
+		},
+		{
+			name: "HTTP 401 without fault",
+			err:  &HTTPError{StatusCode: http.StatusUnauthorized},
+			want: []error{ErrUnauthorized},
+		},
+		{
+			name: "nested NoProfile",
+			err:  &FaultError{StatusCode: http.StatusBadRequest, Code: "env:Sender", Subcodes: []string{"ter:InvalidArgVal", "ter:NoProfile"}},
+			want: []error{ErrInvalidArgument, ErrNotFound},
+		},
+		{
+			name: "ActionNotSupported",
+			err:  &FaultError{StatusCode: http.StatusInternalServerError, Code: "env:Receiver", Subcodes: []string{"ter:ActionNotSupported"}},
+			want: []error{ErrActionNotSupported},
+		},
+		{
+			name: "HTTP 503 without fault",
+			err:  &HTTPError{StatusCode: http.StatusServiceUnavailable},
+		},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrInvalidArgument, ErrActionNotSupported, ErrNotFound}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("GetProfile failed: %w", tt.err)
+
+			for _, sentinel := range sentinels {
+				want := false
+
+				for _, w := range tt.want {
+					if w == sentinel {
+						want = true
+					}
+				}
+
+				if got := errors.Is(err, sentinel); got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
 func TestSecurityHeaderCreation(t *testing.T) {
 	httpClient := &http.Client{}
 	client := NewClient(httpClient, "testuser", "testpass")
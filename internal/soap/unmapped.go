@@ -0,0 +1,209 @@
+package soap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// SetUnmappedElements makes calls report the elements of a response that the
+// response value has no field for, and so drops when decoding. report is
+// called after each call whose response has such elements, with their paths
+// of local names from the response element, e.g.
+// "GetProfilesResponse/Profiles/Extension". Every path is reported once per
+// call. A nil report disables the check.
+func (c *Client) SetUnmappedElements(report func(paths []string)) {
+	c.unmappedElements = report
+}
+
+// xmlCandidate is a field that an element may decode into: the remaining
+// element names of its tag path and the type of the field.
+type xmlCandidate struct {
+	path []string
+	typ  reflect.Type
+}
+
+// xmlScope is the decoding state of an element in unmappedElements.
+type xmlScope struct {
+	// path is the path of the element
+	path string
+	// candidates are the fields that children of the element decode into
+	candidates []xmlCandidate
+	// captured is set when every child is kept, e.g. by an innerxml field
+	captured bool
+}
+
+var (
+	xmlNameType         = reflect.TypeOf(xml.Name{})
+	xmlUnmarshalerType  = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unmappedElements returns the paths of the elements of doc that are dropped
+// when doc is decoded into v, in document order and without duplicates. The
+// root element of doc is taken to match v. Types with their own UnmarshalXML
+// method are assumed to keep all of their content.
+func unmappedElements(doc []byte, v interface{}) ([]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+
+	var (
+		stack []xmlScope
+		paths []string
+	)
+
+	seen := make(map[string]bool)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return paths, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+
+			if len(stack) == 0 {
+				stack = append(stack, scopeOf(name, reflect.TypeOf(v)))
+
+				continue
+			}
+
+			parent := &stack[len(stack)-1]
+			path := parent.path + "/" + name
+
+			scope, ok := childScope(parent, path, name)
+			if !ok {
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+
+			stack = append(stack, scope)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+			if len(stack) == 0 {
+				return paths, nil
+			}
+		}
+	}
+}
+
+// childScope returns the scope of a child element of parent, and false if
+// no field of parent keeps it.
+func childScope(parent *xmlScope, path, name string) (xmlScope, bool) {
+	if parent.captured {
+		return xmlScope{path: path, captured: true}, true
+	}
+
+	var nested []xmlCandidate
+
+	for _, candidate := range parent.candidates {
+		if candidate.path[0] != name {
+			continue
+		}
+
+		if len(candidate.path) == 1 {
+			return scopeOf(path, candidate.typ), true
+		}
+
+		nested = append(nested, xmlCandidate{path: candidate.path[1:], typ: candidate.typ})
+	}
+
+	if len(nested) == 0 {
+		return xmlScope{}, false
+	}
+
+	return xmlScope{path: path, candidates: nested}, true
+}
+
+// scopeOf returns the scope of an element decoded into a value of type t.
+func scopeOf(path string, t reflect.Type) xmlScope {
+	scope := xmlScope{path: path}
+
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8) {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == nil, t.Kind() == reflect.Interface,
+		reflect.PointerTo(t).Implements(xmlUnmarshalerType):
+		scope.captured = true
+	case t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType):
+		addFields(&scope, t)
+	}
+
+	return scope
+}
+
+// addFields adds the element fields of struct type t to scope, including
+// those of embedded structs.
+func addFields(scope *xmlScope, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type == xmlNameType {
+			continue
+		}
+
+		tag := field.Tag.Get("xml")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		switch {
+		case strings.Contains(","+options+",", ",innerxml,"), strings.Contains(","+options+",", ",any,"):
+			scope.captured = true
+
+			continue
+		case options != "" && options != "omitempty":
+			// Attributes, character data and comments.
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				addFields(scope, embedded)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		// Drop the namespace of "namespace-URL name" tags.
+		if i := strings.LastIndex(name, " "); i >= 0 {
+			name = name[i+1:]
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		scope.candidates = append(scope.candidates, xmlCandidate{path: strings.Split(name, ">"), typ: field.Type})
+	}
+}
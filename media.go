@@ -3,9 +3,10 @@ package onvif
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"slices"
+	"strings"
 )
 
 // Media service namespace.
@@ -20,17 +21,15 @@ func (c *Client) getMediaEndpoint() string {
 	return c.endpoint
 }
 
-// getMediaSoapClient creates a new SOAP client for media operations.
-func (c *Client) getMediaSoapClient() *soap.Client {
-	username, password := c.GetCredentials()
-
-	return soap.NewClient(c.httpClient, username, password)
+// GetProfiles retrieves all media profiles. When the device advertises Media2,
+// profiles are read from it, so that H.265 profiles are included, falling back
+// to Media (ver10) if the Media2 request fails; see WithMediaVersion.
+func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
+	return singleFlight(ctx, c, "GetProfiles", c.getProfiles)
 }
 
-// GetProfiles retrieves all media profiles.
-//
-//nolint:funlen // GetProfiles has many statements due to parsing complex profile structures
-func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
+// getProfilesMedia1 retrieves all media profiles from the Media (ver10) service.
+func (c *Client) getProfilesMedia1(ctx context.Context) ([]*Profile, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -42,45 +41,8 @@ func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
 	}
 
 	type GetProfilesResponse struct {
-		XMLName  xml.Name `xml:"GetProfilesResponse"`
-		Profiles []struct {
-			Token                    string `xml:"token,attr"`
-			Name                     string `xml:"Name"`
-			VideoSourceConfiguration *struct {
-				Token       string `xml:"token,attr"`
-				Name        string `xml:"Name"`
-				UseCount    int    `xml:"UseCount"`
-				SourceToken string `xml:"SourceToken"`
-				Bounds      *struct {
-					X      int `xml:"x,attr"`
-					Y      int `xml:"y,attr"`
-					Width  int `xml:"width,attr"`
-					Height int `xml:"height,attr"`
-				} `xml:"Bounds"`
-			} `xml:"VideoSourceConfiguration"`
-			VideoEncoderConfiguration *struct {
-				Token      string `xml:"token,attr"`
-				Name       string `xml:"Name"`
-				UseCount   int    `xml:"UseCount"`
-				Encoding   string `xml:"Encoding"`
-				Resolution *struct {
-					Width  int `xml:"Width"`
-					Height int `xml:"Height"`
-				} `xml:"Resolution"`
-				Quality     float64 `xml:"Quality"`
-				RateControl *struct {
-					FrameRateLimit   int `xml:"FrameRateLimit"`
-					EncodingInterval int `xml:"EncodingInterval"`
-					BitrateLimit     int `xml:"BitrateLimit"`
-				} `xml:"RateControl"`
-			} `xml:"VideoEncoderConfiguration"`
-			PTZConfiguration *struct {
-				Token     string `xml:"token,attr"`
-				Name      string `xml:"Name"`
-				UseCount  int    `xml:"UseCount"`
-				NodeToken string `xml:"NodeToken"`
-			} `xml:"PTZConfiguration"`
-		} `xml:"Profiles"`
+		XMLName  xml.Name          `xml:"GetProfilesResponse"`
+		Profiles []profileResponse `xml:"Profiles"`
 	}
 
 	req := GetProfiles{
@@ -89,77 +51,218 @@ func (c *Client) GetProfiles(ctx context.Context) ([]*Profile, error) {
 
 	var resp GetProfilesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetProfiles failed: %w", err)
 	}
 
 	profiles := make([]*Profile, len(resp.Profiles))
-	for i, p := range resp.Profiles {
-		profile := &Profile{
-			Token: p.Token,
-			Name:  p.Name,
-		}
-
-		if p.VideoSourceConfiguration != nil {
-			profile.VideoSourceConfiguration = &VideoSourceConfiguration{
-				Token:       p.VideoSourceConfiguration.Token,
-				Name:        p.VideoSourceConfiguration.Name,
-				UseCount:    p.VideoSourceConfiguration.UseCount,
-				SourceToken: p.VideoSourceConfiguration.SourceToken,
-			}
-			if p.VideoSourceConfiguration.Bounds != nil {
-				profile.VideoSourceConfiguration.Bounds = &IntRectangle{
-					X:      p.VideoSourceConfiguration.Bounds.X,
-					Y:      p.VideoSourceConfiguration.Bounds.Y,
-					Width:  p.VideoSourceConfiguration.Bounds.Width,
-					Height: p.VideoSourceConfiguration.Bounds.Height,
-				}
+	for i := range resp.Profiles {
+		profiles[i] = resp.Profiles[i].toProfile()
+	}
+
+	return profiles, nil
+}
+
+// profileResponse is the wire form of a Media (ver10) profile received from the device.
+type profileResponse struct {
+	Token                    string `xml:"token,attr"`
+	Name                     string `xml:"Name"`
+	VideoSourceConfiguration *struct {
+		Token       string `xml:"token,attr"`
+		Name        string `xml:"Name"`
+		UseCount    int    `xml:"UseCount"`
+		SourceToken string `xml:"SourceToken"`
+		Bounds      *struct {
+			X      int `xml:"x,attr"`
+			Y      int `xml:"y,attr"`
+			Width  int `xml:"width,attr"`
+			Height int `xml:"height,attr"`
+		} `xml:"Bounds"`
+	} `xml:"VideoSourceConfiguration"`
+	VideoEncoderConfiguration *struct {
+		Token      string `xml:"token,attr"`
+		Name       string `xml:"Name"`
+		UseCount   int    `xml:"UseCount"`
+		Encoding   string `xml:"Encoding"`
+		Resolution *struct {
+			Width  int `xml:"Width"`
+			Height int `xml:"Height"`
+		} `xml:"Resolution"`
+		Quality     float64 `xml:"Quality"`
+		RateControl *struct {
+			FrameRateLimit   int `xml:"FrameRateLimit"`
+			EncodingInterval int `xml:"EncodingInterval"`
+			BitrateLimit     int `xml:"BitrateLimit"`
+		} `xml:"RateControl"`
+	} `xml:"VideoEncoderConfiguration"`
+	AudioSourceConfiguration *struct {
+		Token       string `xml:"token,attr"`
+		Name        string `xml:"Name"`
+		UseCount    int    `xml:"UseCount"`
+		SourceToken string `xml:"SourceToken"`
+	} `xml:"AudioSourceConfiguration"`
+	AudioEncoderConfiguration *struct {
+		Token      string `xml:"token,attr"`
+		Name       string `xml:"Name"`
+		UseCount   int    `xml:"UseCount"`
+		Encoding   string `xml:"Encoding"`
+		Bitrate    int    `xml:"Bitrate"`
+		SampleRate int    `xml:"SampleRate"`
+	} `xml:"AudioEncoderConfiguration"`
+	PTZConfiguration      *ptzConfigurationResponse      `xml:"PTZConfiguration"`
+	MetadataConfiguration *metadataConfigurationResponse `xml:"MetadataConfiguration"`
+}
+
+// toProfile converts a decoded profile.
+//
+//nolint:funlen // toProfile has many statements due to converting complex profile structures
+func (r *profileResponse) toProfile() *Profile {
+	profile := &Profile{
+		Token: r.Token,
+		Name:  r.Name,
+	}
+
+	if r.VideoSourceConfiguration != nil {
+		profile.VideoSourceConfiguration = &VideoSourceConfiguration{
+			Token:       r.VideoSourceConfiguration.Token,
+			Name:        r.VideoSourceConfiguration.Name,
+			UseCount:    r.VideoSourceConfiguration.UseCount,
+			SourceToken: r.VideoSourceConfiguration.SourceToken,
+		}
+		if r.VideoSourceConfiguration.Bounds != nil {
+			profile.VideoSourceConfiguration.Bounds = &IntRectangle{
+				X:      r.VideoSourceConfiguration.Bounds.X,
+				Y:      r.VideoSourceConfiguration.Bounds.Y,
+				Width:  r.VideoSourceConfiguration.Bounds.Width,
+				Height: r.VideoSourceConfiguration.Bounds.Height,
 			}
 		}
+	}
 
-		if p.VideoEncoderConfiguration != nil {
-			profile.VideoEncoderConfiguration = &VideoEncoderConfiguration{
-				Token:    p.VideoEncoderConfiguration.Token,
-				Name:     p.VideoEncoderConfiguration.Name,
-				UseCount: p.VideoEncoderConfiguration.UseCount,
-				Encoding: p.VideoEncoderConfiguration.Encoding,
-				Quality:  p.VideoEncoderConfiguration.Quality,
-			}
-			if p.VideoEncoderConfiguration.Resolution != nil {
-				profile.VideoEncoderConfiguration.Resolution = &VideoResolution{
-					Width:  p.VideoEncoderConfiguration.Resolution.Width,
-					Height: p.VideoEncoderConfiguration.Resolution.Height,
-				}
+	if r.VideoEncoderConfiguration != nil {
+		profile.VideoEncoderConfiguration = &VideoEncoderConfiguration{
+			Token:    r.VideoEncoderConfiguration.Token,
+			Name:     r.VideoEncoderConfiguration.Name,
+			UseCount: r.VideoEncoderConfiguration.UseCount,
+			Encoding: r.VideoEncoderConfiguration.Encoding,
+			Quality:  r.VideoEncoderConfiguration.Quality,
+		}
+		if r.VideoEncoderConfiguration.Resolution != nil {
+			profile.VideoEncoderConfiguration.Resolution = &VideoResolution{
+				Width:  r.VideoEncoderConfiguration.Resolution.Width,
+				Height: r.VideoEncoderConfiguration.Resolution.Height,
 			}
-			if p.VideoEncoderConfiguration.RateControl != nil {
-				profile.VideoEncoderConfiguration.RateControl = &VideoRateControl{
-					FrameRateLimit:   p.VideoEncoderConfiguration.RateControl.FrameRateLimit,
-					EncodingInterval: p.VideoEncoderConfiguration.RateControl.EncodingInterval,
-					BitrateLimit:     p.VideoEncoderConfiguration.RateControl.BitrateLimit,
-				}
+		}
+		if r.VideoEncoderConfiguration.RateControl != nil {
+			profile.VideoEncoderConfiguration.RateControl = &VideoRateControl{
+				FrameRateLimit:   r.VideoEncoderConfiguration.RateControl.FrameRateLimit,
+				EncodingInterval: r.VideoEncoderConfiguration.RateControl.EncodingInterval,
+				BitrateLimit:     r.VideoEncoderConfiguration.RateControl.BitrateLimit,
 			}
 		}
+	}
 
-		if p.PTZConfiguration != nil {
-			profile.PTZConfiguration = &PTZConfiguration{
-				Token:     p.PTZConfiguration.Token,
-				Name:      p.PTZConfiguration.Name,
-				UseCount:  p.PTZConfiguration.UseCount,
-				NodeToken: p.PTZConfiguration.NodeToken,
-			}
+	if r.AudioSourceConfiguration != nil {
+		profile.AudioSourceConfiguration = &AudioSourceConfiguration{
+			Token:       r.AudioSourceConfiguration.Token,
+			Name:        r.AudioSourceConfiguration.Name,
+			UseCount:    r.AudioSourceConfiguration.UseCount,
+			SourceToken: r.AudioSourceConfiguration.SourceToken,
 		}
+	}
 
-		profiles[i] = profile
+	if r.AudioEncoderConfiguration != nil {
+		profile.AudioEncoderConfiguration = &AudioEncoderConfiguration{
+			Token:      r.AudioEncoderConfiguration.Token,
+			Name:       r.AudioEncoderConfiguration.Name,
+			UseCount:   r.AudioEncoderConfiguration.UseCount,
+			Encoding:   r.AudioEncoderConfiguration.Encoding,
+			Bitrate:    r.AudioEncoderConfiguration.Bitrate,
+			SampleRate: r.AudioEncoderConfiguration.SampleRate,
+		}
 	}
 
-	return profiles, nil
+	if r.PTZConfiguration != nil {
+		profile.PTZConfiguration = r.PTZConfiguration.toPTZConfiguration()
+	}
+
+	if r.MetadataConfiguration != nil {
+		profile.MetadataConfiguration = r.MetadataConfiguration.toMetadataConfiguration()
+	}
+
+	return profile
 }
 
-// GetStreamURI retrieves the stream URI for a profile.
-func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*MediaURI, error) {
+// StreamProtocol selects the transport of the URI returned by GetStreamURI.
+type StreamProtocol string
+
+// Stream protocols accepted by WithStreamProtocol.
+const (
+	// StreamProtocolRTSP is plain RTSP, the default.
+	StreamProtocolRTSP StreamProtocol = "RTSP"
+	// StreamProtocolRTSPOverHTTPS tunnels RTSP through HTTPS; the URI is https://.
+	StreamProtocolRTSPOverHTTPS StreamProtocol = "RtspOverHttps"
+	// StreamProtocolRTSPSTCP is RTSP over TLS with the media interleaved on the
+	// TLS connection; the URI is rtsps://. It requires Media2 and the
+	// SecureRTSPStreaming capability.
+	StreamProtocolRTSPSTCP StreamProtocol = "RtspsTcp"
+)
+
+// StreamURIOption configures GetStreamURI.
+type StreamURIOption func(*streamURIConfig)
+
+// streamURIConfig holds the settings applied by StreamURIOptions.
+type streamURIConfig struct {
+	protocol StreamProtocol
+}
+
+// WithStreamProtocol selects the stream protocol. For the encrypted protocols
+// GetStreamURI returns ErrSecureStreamingUnsupported when the device does not
+// advertise secure streaming or returns an unencrypted URI, rather than
+// falling back to plain RTSP.
+func WithStreamProtocol(protocol StreamProtocol) StreamURIOption {
+	return func(cfg *streamURIConfig) {
+		cfg.protocol = protocol
+	}
+}
+
+// GetStreamURI retrieves the stream URI for a profile from the Media (ver10)
+// service, or from Media2 when pinned with WithMediaVersion or when the device
+// has no Media service. The URI is for plain RTSP unless another protocol is
+// selected with WithStreamProtocol.
+func (c *Client) GetStreamURI(ctx context.Context, profileToken string, opts ...StreamURIOption) (*MediaURI, error) {
+	cfg := streamURIConfig{protocol: StreamProtocolRTSP}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	switch cfg.protocol {
+	case StreamProtocolRTSP:
+		key := "GetStreamURI/" + profileToken
+
+		return c.cachedMediaURI(key, func() (*MediaURI, error) {
+			return singleFlight(ctx, c, key, func(ctx context.Context) (*MediaURI, error) {
+				return c.getStreamURI(ctx, profileToken)
+			})
+		})
+	case StreamProtocolRTSPOverHTTPS, StreamProtocolRTSPSTCP:
+		key := "GetStreamURI/" + string(cfg.protocol) + "/" + profileToken
+
+		return c.cachedMediaURI(key, func() (*MediaURI, error) {
+			return singleFlight(ctx, c, key, func(ctx context.Context) (*MediaURI, error) {
+				return c.getSecureStreamURI(ctx, profileToken, cfg.protocol)
+			})
+		})
+	default:
+		return nil, fmt.Errorf("GetStreamURI failed: %w: unknown stream protocol %q", ErrInvalidParameter, cfg.protocol)
+	}
+}
+
+// getStreamURIMedia1 retrieves the stream URI for a profile from the Media
+// (ver10) service with the given transport protocol, e.g. RTSP or HTTP.
+func (c *Client) getStreamURIMedia1(ctx context.Context, profileToken, protocol string) (*MediaURI, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -182,8 +285,8 @@ func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*MediaU
 		XMLName  xml.Name `xml:"GetStreamUriResponse"`
 		MediaURI struct {
 			URI                 string `xml:"Uri"`
-			InvalidAfterConnect bool   `xml:"InvalidAfterConnect"`
-			InvalidAfterReboot  bool   `xml:"InvalidAfterReboot"`
+			InvalidAfterConnect Bool   `xml:"InvalidAfterConnect"`
+			InvalidAfterReboot  Bool   `xml:"InvalidAfterReboot"`
 			Timeout             string `xml:"Timeout"`
 		} `xml:"MediaUri"`
 	}
@@ -194,26 +297,41 @@ func (c *Client) GetStreamURI(ctx context.Context, profileToken string) (*MediaU
 		ProfileToken: profileToken,
 	}
 	req.StreamSetup.Stream = "RTP-Unicast"
-	req.StreamSetup.Transport.Protocol = "RTSP"
+	req.StreamSetup.Transport.Protocol = protocol
 
 	var resp GetStreamURIResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStreamURI failed: %w", err)
 	}
 
-	return &MediaURI{
+	uri := &MediaURI{
 		URI:                 resp.MediaURI.URI,
-		InvalidAfterConnect: resp.MediaURI.InvalidAfterConnect,
-		InvalidAfterReboot:  resp.MediaURI.InvalidAfterReboot,
-	}, nil
+		InvalidAfterConnect: bool(resp.MediaURI.InvalidAfterConnect),
+		InvalidAfterReboot:  bool(resp.MediaURI.InvalidAfterReboot),
+	}
+
+	// An unparsable timeout is left at zero, meaning none.
+	uri.Timeout, _ = parseDuration(resp.MediaURI.Timeout)
+
+	return uri, nil
 }
 
 // GetSnapshotURI retrieves the snapshot URI for a profile.
 func (c *Client) GetSnapshotURI(ctx context.Context, profileToken string) (*MediaURI, error) {
+	key := "GetSnapshotURI/" + profileToken
+
+	return c.cachedMediaURI(key, func() (*MediaURI, error) {
+		return singleFlight(ctx, c, key, func(ctx context.Context) (*MediaURI, error) {
+			return c.getSnapshotURI(ctx, profileToken)
+		})
+	})
+}
+
+// getSnapshotURI implements GetSnapshotURI.
+func (c *Client) getSnapshotURI(ctx context.Context, profileToken string) (*MediaURI, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -229,8 +347,8 @@ func (c *Client) GetSnapshotURI(ctx context.Context, profileToken string) (*Medi
 		XMLName  xml.Name `xml:"GetSnapshotUriResponse"`
 		MediaURI struct {
 			URI                 string `xml:"Uri"`
-			InvalidAfterConnect bool   `xml:"InvalidAfterConnect"`
-			InvalidAfterReboot  bool   `xml:"InvalidAfterReboot"`
+			InvalidAfterConnect Bool   `xml:"InvalidAfterConnect"`
+			InvalidAfterReboot  Bool   `xml:"InvalidAfterReboot"`
 			Timeout             string `xml:"Timeout"`
 		} `xml:"MediaUri"`
 	}
@@ -242,18 +360,22 @@ func (c *Client) GetSnapshotURI(ctx context.Context, profileToken string) (*Medi
 
 	var resp GetSnapshotURIResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetSnapshotURI failed: %w", err)
 	}
 
-	return &MediaURI{
+	uri := &MediaURI{
 		URI:                 resp.MediaURI.URI,
-		InvalidAfterConnect: resp.MediaURI.InvalidAfterConnect,
-		InvalidAfterReboot:  resp.MediaURI.InvalidAfterReboot,
-	}, nil
+		InvalidAfterConnect: bool(resp.MediaURI.InvalidAfterConnect),
+		InvalidAfterReboot:  bool(resp.MediaURI.InvalidAfterReboot),
+	}
+
+	// An unparsable timeout is left at zero, meaning none.
+	uri.Timeout, _ = parseDuration(resp.MediaURI.Timeout)
+
+	return uri, nil
 }
 
 // GetVideoEncoderConfiguration retrieves video encoder configuration.
@@ -289,6 +411,25 @@ func (c *Client) GetVideoEncoderConfiguration(
 				EncodingInterval int `xml:"EncodingInterval"`
 				BitrateLimit     int `xml:"BitrateLimit"`
 			} `xml:"RateControl"`
+			MPEG4 *struct {
+				GovLength    int    `xml:"GovLength"`
+				MPEG4Profile string `xml:"Mpeg4Profile"`
+			} `xml:"MPEG4"`
+			H264 *struct {
+				GovLength   int    `xml:"GovLength"`
+				H264Profile string `xml:"H264Profile"`
+			} `xml:"H264"`
+			Multicast *struct {
+				Address *struct {
+					Type        string `xml:"Type"`
+					IPv4Address string `xml:"IPv4Address"`
+					IPv6Address string `xml:"IPv6Address"`
+				} `xml:"Address"`
+				Port      int  `xml:"Port"`
+				TTL       int  `xml:"TTL"`
+				AutoStart Bool `xml:"AutoStart"`
+			} `xml:"Multicast"`
+			SessionTimeout string `xml:"SessionTimeout"`
 		} `xml:"Configuration"`
 	}
 
@@ -299,8 +440,7 @@ func (c *Client) GetVideoEncoderConfiguration(
 
 	var resp GetVideoEncoderConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoEncoderConfiguration failed: %w", err)
@@ -329,11 +469,47 @@ func (c *Client) GetVideoEncoderConfiguration(
 		}
 	}
 
+	if resp.Configuration.MPEG4 != nil {
+		config.MPEG4 = &MPEG4Configuration{
+			GovLength:    resp.Configuration.MPEG4.GovLength,
+			MPEG4Profile: resp.Configuration.MPEG4.MPEG4Profile,
+		}
+	}
+
+	if resp.Configuration.H264 != nil {
+		config.H264 = &H264Configuration{
+			GovLength:   resp.Configuration.H264.GovLength,
+			H264Profile: resp.Configuration.H264.H264Profile,
+		}
+	}
+
+	config.SessionTimeout, _ = parseDuration(resp.Configuration.SessionTimeout)
+
+	if resp.Configuration.Multicast != nil {
+		config.Multicast = &MulticastConfiguration{
+			Port:      resp.Configuration.Multicast.Port,
+			TTL:       resp.Configuration.Multicast.TTL,
+			AutoStart: bool(resp.Configuration.Multicast.AutoStart),
+		}
+		if resp.Configuration.Multicast.Address != nil {
+			config.Multicast.Address = &IPAddress{
+				Type:        resp.Configuration.Multicast.Address.Type,
+				IPv4Address: resp.Configuration.Multicast.Address.IPv4Address,
+				IPv6Address: resp.Configuration.Multicast.Address.IPv6Address,
+			}
+		}
+	}
+
 	return config, nil
 }
 
 // GetVideoSources retrieves all video sources.
 func (c *Client) GetVideoSources(ctx context.Context) ([]*VideoSource, error) {
+	return singleFlight(ctx, c, "GetVideoSources", c.getVideoSources)
+}
+
+// getVideoSources implements GetVideoSources.
+func (c *Client) getVideoSources(ctx context.Context) ([]*VideoSource, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -362,8 +538,7 @@ func (c *Client) GetVideoSources(ctx context.Context) ([]*VideoSource, error) {
 
 	var resp GetVideoSourcesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSources failed: %w", err)
@@ -410,8 +585,7 @@ func (c *Client) GetAudioSources(ctx context.Context) ([]*AudioSource, error) {
 
 	var resp GetAudioSourcesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioSources failed: %w", err)
@@ -453,8 +627,7 @@ func (c *Client) GetAudioOutputs(ctx context.Context) ([]*AudioOutput, error) {
 
 	var resp GetAudioOutputsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioOutputs failed: %w", err)
@@ -471,6 +644,8 @@ func (c *Client) GetAudioOutputs(ctx context.Context) ([]*AudioOutput, error) {
 }
 
 // CreateProfile creates a new media profile.
+// ErrProfileTokenExists is returned when the device reports that a profile
+// with token already exists, so provisioning code can reuse that profile.
 func (c *Client) CreateProfile(ctx context.Context, name, token string) (*Profile, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -502,10 +677,14 @@ func (c *Client) CreateProfile(ctx context.Context, name, token string) (*Profil
 
 	var resp CreateProfileResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		var fault *SOAPFault
+		if errors.As(err, &fault) && fault.HasSubcode("ProfileExists") {
+			return nil, fmt.Errorf("CreateProfile failed: %w: %q: %w", ErrProfileTokenExists, token, err)
+		}
+
 		return nil, fmt.Errorf("CreateProfile failed: %w", err)
 	}
 
@@ -533,17 +712,64 @@ func (c *Client) DeleteProfile(ctx context.Context, profileToken string) error {
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("DeleteProfile failed: %w", err)
 	}
 
+	c.resetPTZSpaces(profileToken)
+
 	return nil
 }
 
+// freshUseCountKey marks a context whose caller sends configurations it has just
+// read from the device.
+type freshUseCountKey struct{}
+
+// withFreshUseCount returns ctx marked so that the Set*Configuration calls made
+// with it send the caller's UseCount without reading it from the device again.
+// It is for callers that hold a configuration read moments before.
+func withFreshUseCount(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshUseCountKey{}, true)
+}
+
+// deviceUseCount returns the UseCount for the setters to send in place of the
+// caller's value: the count is managed by the device, and one left stale by a
+// read-modify-write confuses some devices. The configuration token is read
+// with get unless ctx is marked by withFreshUseCount. The caller's value is
+// kept, and a warning logged, when the configuration cannot be read.
+func deviceUseCount[T any](
+	ctx context.Context,
+	c *Client,
+	op, token string,
+	callerCount int,
+	get func(context.Context, string) (T, error),
+	useCount func(T) int,
+) int {
+	if fresh, _ := ctx.Value(freshUseCountKey{}).(bool); fresh {
+		return callerCount
+	}
+
+	current, err := get(ctx, token)
+	if err != nil {
+		c.logf("onvif: %s: cannot read the current UseCount, sending the caller's: %v", op, err)
+
+		return callerCount
+	}
+
+	return useCount(current)
+}
+
 // SetVideoEncoderConfiguration sets video encoder configuration.
+//
+// The Media (ver10) specification declares ForcePersistence obsolete and to be
+// assumed true, and no service capability advertises whether a device honors
+// forcePersistence=false. Many devices persist every change, so callers must not
+// rely on a non-persistent change being reverted by a reboot.
+//
+// UseCount is managed by the device, so config.UseCount is ignored: the
+// current count is read from the device and sent back unchanged.
 func (c *Client) SetVideoEncoderConfiguration(
 	ctx context.Context,
 	config *VideoEncoderConfiguration,
@@ -573,6 +799,25 @@ func (c *Client) SetVideoEncoderConfiguration(
 				EncodingInterval int `xml:"tt:EncodingInterval"`
 				BitrateLimit     int `xml:"tt:BitrateLimit"`
 			} `xml:"tt:RateControl,omitempty"`
+			MPEG4 *struct {
+				GovLength    int    `xml:"tt:GovLength"`
+				MPEG4Profile string `xml:"tt:Mpeg4Profile"`
+			} `xml:"tt:MPEG4,omitempty"`
+			H264 *struct {
+				GovLength   int    `xml:"tt:GovLength"`
+				H264Profile string `xml:"tt:H264Profile"`
+			} `xml:"tt:H264,omitempty"`
+			Multicast *struct {
+				Address *struct {
+					Type        string `xml:"tt:Type"`
+					IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+					IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+				} `xml:"tt:Address,omitempty"`
+				Port      int  `xml:"tt:Port"`
+				TTL       int  `xml:"tt:TTL"`
+				AutoStart bool `xml:"tt:AutoStart"`
+			} `xml:"tt:Multicast,omitempty"`
+			SessionTimeout string `xml:"tt:SessionTimeout,omitempty"`
 		} `xml:"trt:Configuration"`
 		ForcePersistence bool `xml:"trt:ForcePersistence"`
 	}
@@ -585,7 +830,8 @@ func (c *Client) SetVideoEncoderConfiguration(
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetVideoEncoderConfiguration", config.Token, config.UseCount,
+		c.GetVideoEncoderConfiguration, func(current *VideoEncoderConfiguration) int { return current.UseCount })
 	req.Configuration.Encoding = config.Encoding
 
 	if config.Resolution != nil {
@@ -614,8 +860,59 @@ func (c *Client) SetVideoEncoderConfiguration(
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if config.MPEG4 != nil {
+		req.Configuration.MPEG4 = &struct {
+			GovLength    int    `xml:"tt:GovLength"`
+			MPEG4Profile string `xml:"tt:Mpeg4Profile"`
+		}{
+			GovLength:    config.MPEG4.GovLength,
+			MPEG4Profile: config.MPEG4.MPEG4Profile,
+		}
+	}
+
+	if config.H264 != nil {
+		req.Configuration.H264 = &struct {
+			GovLength   int    `xml:"tt:GovLength"`
+			H264Profile string `xml:"tt:H264Profile"`
+		}{
+			GovLength:   config.H264.GovLength,
+			H264Profile: config.H264.H264Profile,
+		}
+	}
+
+	if config.Multicast != nil {
+		req.Configuration.Multicast = &struct {
+			Address *struct {
+				Type        string `xml:"tt:Type"`
+				IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+				IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+			} `xml:"tt:Address,omitempty"`
+			Port      int  `xml:"tt:Port"`
+			TTL       int  `xml:"tt:TTL"`
+			AutoStart bool `xml:"tt:AutoStart"`
+		}{
+			Port:      config.Multicast.Port,
+			TTL:       config.Multicast.TTL,
+			AutoStart: config.Multicast.AutoStart,
+		}
+		if config.Multicast.Address != nil {
+			req.Configuration.Multicast.Address = &struct {
+				Type        string `xml:"tt:Type"`
+				IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+				IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+			}{
+				Type:        config.Multicast.Address.Type,
+				IPv4Address: config.Multicast.Address.IPv4Address,
+				IPv6Address: config.Multicast.Address.IPv6Address,
+			}
+		}
+	}
+
+	if config.SessionTimeout > 0 {
+		req.Configuration.SessionTimeout = formatDuration(config.SessionTimeout)
+	}
+
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetVideoEncoderConfiguration failed: %w", err)
@@ -639,19 +936,19 @@ func (c *Client) GetMediaServiceCapabilities(ctx context.Context) (*MediaService
 	type GetServiceCapabilitiesResponse struct {
 		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
 		Capabilities struct {
-			SnapshotURI         bool `xml:"SnapshotUri,attr"`
-			Rotation            bool `xml:"Rotation,attr"`
-			VideoSourceMode     bool `xml:"VideoSourceMode,attr"`
-			OSD                 bool `xml:"OSD,attr"`
-			TemporaryOSDText    bool `xml:"TemporaryOSDText,attr"`
-			EXICompression      bool `xml:"EXICompression,attr"`
+			SnapshotURI         Bool `xml:"SnapshotUri,attr"`
+			Rotation            Bool `xml:"Rotation,attr"`
+			VideoSourceMode     Bool `xml:"VideoSourceMode,attr"`
+			OSD                 Bool `xml:"OSD,attr"`
+			TemporaryOSDText    Bool `xml:"TemporaryOSDText,attr"`
+			EXICompression      Bool `xml:"EXICompression,attr"`
 			ProfileCapabilities *struct {
 				MaximumNumberOfProfiles int `xml:"MaximumNumberOfProfiles,attr"`
 			} `xml:"ProfileCapabilities"`
 			StreamingCapabilities *struct {
-				RTPMulticast bool `xml:"RTPMulticast,attr"`
-				RTPTCP       bool `xml:"RTP_TCP,attr"`
-				RTPRTSPTCP   bool `xml:"RTP_RTSP_TCP,attr"`
+				RTPMulticast Bool `xml:"RTPMulticast,attr"`
+				RTPTCP       Bool `xml:"RTP_TCP,attr"`
+				RTPRTSPTCP   Bool `xml:"RTP_RTSP_TCP,attr"`
 			} `xml:"StreamingCapabilities"`
 		} `xml:"Capabilities"`
 	}
@@ -662,20 +959,21 @@ func (c *Client) GetMediaServiceCapabilities(ctx context.Context) (*MediaService
 
 	var resp GetServiceCapabilitiesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	if !c.cachedCapabilities(mediaNamespace, &resp.Capabilities) {
+		soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
-		return nil, fmt.Errorf("GetMediaServiceCapabilities failed: %w", err)
+		if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+			return nil, fmt.Errorf("GetMediaServiceCapabilities failed: %w", err)
+		}
 	}
 
 	caps := &MediaServiceCapabilities{
-		SnapshotURI:      resp.Capabilities.SnapshotURI,
-		Rotation:         resp.Capabilities.Rotation,
-		VideoSourceMode:  resp.Capabilities.VideoSourceMode,
-		OSD:              resp.Capabilities.OSD,
-		TemporaryOSDText: resp.Capabilities.TemporaryOSDText,
-		EXICompression:   resp.Capabilities.EXICompression,
+		SnapshotURI:      bool(resp.Capabilities.SnapshotURI),
+		Rotation:         bool(resp.Capabilities.Rotation),
+		VideoSourceMode:  bool(resp.Capabilities.VideoSourceMode),
+		OSD:              bool(resp.Capabilities.OSD),
+		TemporaryOSDText: bool(resp.Capabilities.TemporaryOSDText),
+		EXICompression:   bool(resp.Capabilities.EXICompression),
 	}
 
 	if resp.Capabilities.ProfileCapabilities != nil {
@@ -683,9 +981,9 @@ func (c *Client) GetMediaServiceCapabilities(ctx context.Context) (*MediaService
 	}
 
 	if resp.Capabilities.StreamingCapabilities != nil {
-		caps.RTPMulticast = resp.Capabilities.StreamingCapabilities.RTPMulticast
-		caps.RTPTCP = resp.Capabilities.StreamingCapabilities.RTPTCP
-		caps.RTPRTSPTCP = resp.Capabilities.StreamingCapabilities.RTPRTSPTCP
+		caps.RTPMulticast = bool(resp.Capabilities.StreamingCapabilities.RTPMulticast)
+		caps.RTPTCP = bool(resp.Capabilities.StreamingCapabilities.RTPTCP)
+		caps.RTPRTSPTCP = bool(resp.Capabilities.StreamingCapabilities.RTPRTSPTCP)
 	}
 
 	return caps, nil
@@ -762,8 +1060,7 @@ func (c *Client) GetVideoEncoderConfigurationOptions(
 
 	var resp GetVideoEncoderConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoEncoderConfigurationOptions failed: %w", err)
@@ -867,7 +1164,7 @@ func (c *Client) GetAudioEncoderConfiguration(
 				} `xml:"Address"`
 				Port      int  `xml:"Port"`
 				TTL       int  `xml:"TTL"`
-				AutoStart bool `xml:"AutoStart"`
+				AutoStart Bool `xml:"AutoStart"`
 			} `xml:"Multicast"`
 			SessionTimeout string `xml:"SessionTimeout"`
 		} `xml:"Configuration"`
@@ -880,8 +1177,7 @@ func (c *Client) GetAudioEncoderConfiguration(
 
 	var resp GetAudioEncoderConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioEncoderConfiguration failed: %w", err)
@@ -895,12 +1191,13 @@ func (c *Client) GetAudioEncoderConfiguration(
 		Bitrate:    resp.Configuration.Bitrate,
 		SampleRate: resp.Configuration.SampleRate,
 	}
+	config.SessionTimeout, _ = parseDuration(resp.Configuration.SessionTimeout)
 
 	if resp.Configuration.Multicast != nil {
 		config.Multicast = &MulticastConfiguration{
 			Port:      resp.Configuration.Multicast.Port,
 			TTL:       resp.Configuration.Multicast.TTL,
-			AutoStart: resp.Configuration.Multicast.AutoStart,
+			AutoStart: bool(resp.Configuration.Multicast.AutoStart),
 		}
 		if resp.Configuration.Multicast.Address != nil {
 			config.Multicast.Address = &IPAddress{
@@ -914,7 +1211,9 @@ func (c *Client) GetAudioEncoderConfiguration(
 	return config, nil
 }
 
-// SetAudioEncoderConfiguration sets audio encoder configuration.
+// SetAudioEncoderConfiguration sets audio encoder configuration. As with
+// SetVideoEncoderConfiguration, devices may persist the change even when
+// forcePersistence is false, and the device's own UseCount is sent.
 func (c *Client) SetAudioEncoderConfiguration(
 	ctx context.Context,
 	config *AudioEncoderConfiguration,
@@ -959,7 +1258,8 @@ func (c *Client) SetAudioEncoderConfiguration(
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetAudioEncoderConfiguration", config.Token, config.UseCount,
+		c.GetAudioEncoderConfiguration, func(current *AudioEncoderConfiguration) int { return current.UseCount })
 	req.Configuration.Encoding = config.Encoding
 	if config.Bitrate > 0 {
 		req.Configuration.Bitrate = config.Bitrate
@@ -967,6 +1267,9 @@ func (c *Client) SetAudioEncoderConfiguration(
 	if config.SampleRate > 0 {
 		req.Configuration.SampleRate = config.SampleRate
 	}
+	if config.SessionTimeout > 0 {
+		req.Configuration.SessionTimeout = formatDuration(config.SessionTimeout)
+	}
 
 	if config.Multicast != nil {
 		req.Configuration.Multicast = &struct {
@@ -996,8 +1299,7 @@ func (c *Client) SetAudioEncoderConfiguration(
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetAudioEncoderConfiguration failed: %w", err)
@@ -1006,6 +1308,87 @@ func (c *Client) SetAudioEncoderConfiguration(
 	return nil
 }
 
+// metadataConfigurationResponse is the wire form of a metadata configuration received from the device.
+type metadataConfigurationResponse struct {
+	Token           string `xml:"token,attr"`
+	CompressionType string `xml:"CompressionType,attr"`
+	Name            string `xml:"Name"`
+	UseCount        int    `xml:"UseCount"`
+	PTZStatus       *struct {
+		Status   Bool `xml:"Status"`
+		Position Bool `xml:"Position"`
+	} `xml:"PTZStatus"`
+	Events    *struct{} `xml:"Events"`
+	Analytics Bool      `xml:"Analytics"`
+	Multicast *struct {
+		Address *struct {
+			Type        string `xml:"Type"`
+			IPv4Address string `xml:"IPv4Address"`
+			IPv6Address string `xml:"IPv6Address"`
+		} `xml:"Address"`
+		Port      int  `xml:"Port"`
+		TTL       int  `xml:"TTL"`
+		AutoStart Bool `xml:"AutoStart"`
+	} `xml:"Multicast"`
+	SessionTimeout               string `xml:"SessionTimeout"`
+	AnalyticsEngineConfiguration *struct {
+		AnalyticsModule []configResponse `xml:"AnalyticsModule"`
+	} `xml:"AnalyticsEngineConfiguration"`
+	Extension *innerXML `xml:"Extension"`
+}
+
+// toMetadataConfiguration converts a decoded metadata configuration.
+func (r *metadataConfigurationResponse) toMetadataConfiguration() *MetadataConfiguration {
+	config := &MetadataConfiguration{
+		Token:           r.Token,
+		Name:            r.Name,
+		UseCount:        r.UseCount,
+		Analytics:       bool(r.Analytics),
+		CompressionType: r.CompressionType,
+	}
+	config.SessionTimeout, _ = parseDuration(r.SessionTimeout)
+
+	if r.PTZStatus != nil {
+		config.PTZStatus = &PTZFilter{
+			Status:   bool(r.PTZStatus.Status),
+			Position: bool(r.PTZStatus.Position),
+		}
+	}
+
+	if r.Events != nil {
+		config.Events = &EventSubscription{}
+	}
+
+	if r.Multicast != nil {
+		config.Multicast = &MulticastConfiguration{
+			Port:      r.Multicast.Port,
+			TTL:       r.Multicast.TTL,
+			AutoStart: bool(r.Multicast.AutoStart),
+		}
+		if r.Multicast.Address != nil {
+			config.Multicast.Address = &IPAddress{
+				Type:        r.Multicast.Address.Type,
+				IPv4Address: r.Multicast.Address.IPv4Address,
+				IPv6Address: r.Multicast.Address.IPv6Address,
+			}
+		}
+	}
+
+	if r.AnalyticsEngineConfiguration != nil {
+		config.AnalyticsEngineConfiguration = &AnalyticsEngineConfiguration{}
+		for i := range r.AnalyticsEngineConfiguration.AnalyticsModule {
+			config.AnalyticsEngineConfiguration.AnalyticsModules = append(config.AnalyticsEngineConfiguration.AnalyticsModules,
+				r.AnalyticsEngineConfiguration.AnalyticsModule[i].toConfig())
+		}
+	}
+
+	if r.Extension != nil {
+		config.ExtensionXML = r.Extension.XML
+	}
+
+	return config
+}
+
 // GetMetadataConfiguration retrieves metadata configuration.
 func (c *Client) GetMetadataConfiguration(
 	ctx context.Context,
@@ -1023,29 +1406,8 @@ func (c *Client) GetMetadataConfiguration(
 	}
 
 	type GetMetadataConfigurationResponse struct {
-		XMLName       xml.Name `xml:"GetMetadataConfigurationResponse"`
-		Configuration struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			UseCount  int    `xml:"UseCount"`
-			PTZStatus *struct {
-				Status   bool `xml:"Status"`
-				Position bool `xml:"Position"`
-			} `xml:"PTZStatus"`
-			Events    *struct{} `xml:"Events"`
-			Analytics bool      `xml:"Analytics"`
-			Multicast *struct {
-				Address *struct {
-					Type        string `xml:"Type"`
-					IPv4Address string `xml:"IPv4Address"`
-					IPv6Address string `xml:"IPv6Address"`
-				} `xml:"Address"`
-				Port      int  `xml:"Port"`
-				TTL       int  `xml:"TTL"`
-				AutoStart bool `xml:"AutoStart"`
-			} `xml:"Multicast"`
-			SessionTimeout string `xml:"SessionTimeout"`
-		} `xml:"Configuration"`
+		XMLName       xml.Name                      `xml:"GetMetadataConfigurationResponse"`
+		Configuration metadataConfigurationResponse `xml:"Configuration"`
 	}
 
 	req := GetMetadataConfiguration{
@@ -1055,50 +1417,18 @@ func (c *Client) GetMetadataConfiguration(
 
 	var resp GetMetadataConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetMetadataConfiguration failed: %w", err)
 	}
 
-	config := &MetadataConfiguration{
-		Token:     resp.Configuration.Token,
-		Name:      resp.Configuration.Name,
-		UseCount:  resp.Configuration.UseCount,
-		Analytics: resp.Configuration.Analytics,
-	}
-
-	if resp.Configuration.PTZStatus != nil {
-		config.PTZStatus = &PTZFilter{
-			Status:   resp.Configuration.PTZStatus.Status,
-			Position: resp.Configuration.PTZStatus.Position,
-		}
-	}
-
-	if resp.Configuration.Events != nil {
-		config.Events = &EventSubscription{}
-	}
-
-	if resp.Configuration.Multicast != nil {
-		config.Multicast = &MulticastConfiguration{
-			Port:      resp.Configuration.Multicast.Port,
-			TTL:       resp.Configuration.Multicast.TTL,
-			AutoStart: resp.Configuration.Multicast.AutoStart,
-		}
-		if resp.Configuration.Multicast.Address != nil {
-			config.Multicast.Address = &IPAddress{
-				Type:        resp.Configuration.Multicast.Address.Type,
-				IPv4Address: resp.Configuration.Multicast.Address.IPv4Address,
-				IPv6Address: resp.Configuration.Multicast.Address.IPv6Address,
-			}
-		}
-	}
-
-	return config, nil
+	return resp.Configuration.toMetadataConfiguration(), nil
 }
 
-// SetMetadataConfiguration sets metadata configuration.
+// SetMetadataConfiguration sets metadata configuration. As with
+// SetVideoEncoderConfiguration, devices may persist the change even when
+// forcePersistence is false, and the device's own UseCount is sent.
 func (c *Client) SetMetadataConfiguration(
 	ctx context.Context,
 	config *MetadataConfiguration,
@@ -1114,10 +1444,11 @@ func (c *Client) SetMetadataConfiguration(
 		Xmlns         string   `xml:"xmlns:trt,attr"`
 		Xmlnst        string   `xml:"xmlns:tt,attr"`
 		Configuration struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"tt:Name"`
-			UseCount  int    `xml:"tt:UseCount"`
-			PTZStatus *struct {
+			Token           string `xml:"token,attr"`
+			CompressionType string `xml:"CompressionType,attr,omitempty"`
+			Name            string `xml:"tt:Name"`
+			UseCount        int    `xml:"tt:UseCount"`
+			PTZStatus       *struct {
 				Status   bool `xml:"tt:Status"`
 				Position bool `xml:"tt:Position"`
 			} `xml:"tt:PTZStatus,omitempty"`
@@ -1133,7 +1464,13 @@ func (c *Client) SetMetadataConfiguration(
 				TTL       int  `xml:"tt:TTL,omitempty"`
 				AutoStart bool `xml:"tt:AutoStart,omitempty"`
 			} `xml:"tt:Multicast,omitempty"`
-			SessionTimeout string `xml:"tt:SessionTimeout,omitempty"`
+			SessionTimeout               string `xml:"tt:SessionTimeout,omitempty"`
+			AnalyticsEngineConfiguration *struct {
+				AnalyticsModule []configRequest `xml:"tt:AnalyticsModule"`
+			} `xml:"tt:AnalyticsEngineConfiguration,omitempty"`
+			Extension *struct {
+				XML string `xml:",innerxml"`
+			} `xml:"tt:Extension,omitempty"`
 		} `xml:"trt:Configuration"`
 		ForcePersistence bool `xml:"trt:ForcePersistence"`
 	}
@@ -1146,8 +1483,30 @@ func (c *Client) SetMetadataConfiguration(
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetMetadataConfiguration", config.Token, config.UseCount,
+		c.GetMetadataConfiguration, func(current *MetadataConfiguration) int { return current.UseCount })
 	req.Configuration.Analytics = config.Analytics
+	req.Configuration.CompressionType = config.CompressionType
+
+	if config.SessionTimeout > 0 {
+		req.Configuration.SessionTimeout = formatDuration(config.SessionTimeout)
+	}
+
+	if config.AnalyticsEngineConfiguration != nil {
+		req.Configuration.AnalyticsEngineConfiguration = &struct {
+			AnalyticsModule []configRequest `xml:"tt:AnalyticsModule"`
+		}{}
+		for _, module := range config.AnalyticsEngineConfiguration.AnalyticsModules {
+			req.Configuration.AnalyticsEngineConfiguration.AnalyticsModule = append(
+				req.Configuration.AnalyticsEngineConfiguration.AnalyticsModule, newConfigRequest(module))
+		}
+	}
+
+	if config.ExtensionXML != "" {
+		req.Configuration.Extension = &struct {
+			XML string `xml:",innerxml"`
+		}{XML: config.ExtensionXML}
+	}
 
 	if config.PTZStatus != nil {
 		req.Configuration.PTZStatus = &struct {
@@ -1191,8 +1550,7 @@ func (c *Client) SetMetadataConfiguration(
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetMetadataConfiguration failed: %w", err)
@@ -1202,12 +1560,18 @@ func (c *Client) SetMetadataConfiguration(
 }
 
 // GetVideoSourceModes retrieves available video source modes.
+// ErrVideoSourceModeUnsupported is returned without sending the request when
+// the media service does not report the VideoSourceMode capability.
 func (c *Client) GetVideoSourceModes(ctx context.Context, videoSourceToken string) ([]*VideoSourceMode, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
+	if err := c.checkVideoSourceModeSupport(ctx); err != nil {
+		return nil, fmt.Errorf("GetVideoSourceModes failed: %w", err)
+	}
+
 	type GetVideoSourceModes struct {
 		XMLName          xml.Name `xml:"trt:GetVideoSourceModes"`
 		Xmlns            string   `xml:"xmlns:trt,attr"`
@@ -1218,7 +1582,7 @@ func (c *Client) GetVideoSourceModes(ctx context.Context, videoSourceToken strin
 		XMLName          xml.Name `xml:"GetVideoSourceModesResponse"`
 		VideoSourceModes []struct {
 			Token      string `xml:"token,attr"`
-			Enabled    bool   `xml:"Enabled"`
+			Enabled    Bool   `xml:"Enabled"`
 			Resolution struct {
 				Width  int `xml:"Width"`
 				Height int `xml:"Height"`
@@ -1233,8 +1597,7 @@ func (c *Client) GetVideoSourceModes(ctx context.Context, videoSourceToken strin
 
 	var resp GetVideoSourceModesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSourceModes failed: %w", err)
@@ -1244,7 +1607,7 @@ func (c *Client) GetVideoSourceModes(ctx context.Context, videoSourceToken strin
 	for i, m := range resp.VideoSourceModes {
 		modes[i] = &VideoSourceMode{
 			Token:   m.Token,
-			Enabled: m.Enabled,
+			Enabled: bool(m.Enabled),
 			Resolution: &VideoResolution{
 				Width:  m.Resolution.Width,
 				Height: m.Resolution.Height,
@@ -1256,58 +1619,313 @@ func (c *Client) GetVideoSourceModes(ctx context.Context, videoSourceToken strin
 }
 
 // SetVideoSourceMode sets the video source mode.
+// ErrVideoSourceModeUnsupported is returned without sending the request when
+// the media service does not report the VideoSourceMode capability.
+//
+// Switching modes is disruptive: the device may reboot, and the resolutions
+// and frame rates available to encoders change with the mode, so existing
+// profiles and encoder configurations may become invalid and need to be
+// re-validated afterwards.
 func (c *Client) SetVideoSourceMode(ctx context.Context, videoSourceToken, modeToken string) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
-	type SetVideoSourceMode struct {
-		XMLName          xml.Name `xml:"trt:SetVideoSourceMode"`
-		Xmlns            string   `xml:"xmlns:trt,attr"`
-		VideoSourceToken string   `xml:"trt:VideoSourceToken"`
-		ModeToken        string   `xml:"trt:ModeToken"`
+	if err := c.checkVideoSourceModeSupport(ctx); err != nil {
+		return fmt.Errorf("SetVideoSourceMode failed: %w", err)
+	}
+
+	type SetVideoSourceMode struct {
+		XMLName          xml.Name `xml:"trt:SetVideoSourceMode"`
+		Xmlns            string   `xml:"xmlns:trt,attr"`
+		VideoSourceToken string   `xml:"trt:VideoSourceToken"`
+		ModeToken        string   `xml:"trt:ModeToken"`
+	}
+
+	req := SetVideoSourceMode{
+		Xmlns:            mediaNamespace,
+		VideoSourceToken: videoSourceToken,
+		ModeToken:        modeToken,
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetVideoSourceMode failed: %w", err)
+	}
+
+	return nil
+}
+
+// checkVideoSourceModeSupport returns ErrVideoSourceModeUnsupported when the
+// media service capabilities do not include VideoSourceMode. The check is
+// skipped when the capabilities cannot be read.
+func (c *Client) checkVideoSourceModeSupport(ctx context.Context) error {
+	caps, err := checkedCapabilities(ctx, c, mediaNamespace, c.GetMediaServiceCapabilities)
+	if err != nil {
+		c.skipCheck("video source mode capability check", err)
+
+		return nil
+	}
+
+	if !caps.VideoSourceMode {
+		return ErrVideoSourceModeUnsupported
+	}
+
+	return nil
+}
+
+// SetSynchronizationPoint sets a synchronization point for the stream.
+func (c *Client) SetSynchronizationPoint(ctx context.Context, profileToken string) error {
+	endpoint := c.mediaEndpoint
+	if endpoint == "" {
+		endpoint = c.endpoint
+	}
+
+	type SetSynchronizationPoint struct {
+		XMLName      xml.Name `xml:"trt:SetSynchronizationPoint"`
+		Xmlns        string   `xml:"xmlns:trt,attr"`
+		ProfileToken string   `xml:"trt:ProfileToken"`
+	}
+
+	req := SetSynchronizationPoint{
+		Xmlns:        mediaNamespace,
+		ProfileToken: profileToken,
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetSynchronizationPoint failed: %w", err)
+	}
+
+	return nil
+}
+
+// osdConfigurationRequest is the wire form of an OSD configuration sent to the device.
+type osdConfigurationRequest struct {
+	Token                         string           `xml:"token,attr,omitempty"`
+	VideoSourceConfigurationToken string           `xml:"tt:VideoSourceConfigurationToken,omitempty"`
+	Type                          string           `xml:"tt:Type,omitempty"`
+	Position                      *osdPosRequest   `xml:"tt:Position,omitempty"`
+	TextString                    *osdTextRequest  `xml:"tt:TextString,omitempty"`
+	Image                         *osdImageRequest `xml:"tt:Image,omitempty"`
+}
+
+type osdPosRequest struct {
+	Type string `xml:"tt:Type"`
+	Pos  *struct {
+		X float64 `xml:"x,attr"`
+		Y float64 `xml:"y,attr"`
+	} `xml:"tt:Pos,omitempty"`
+}
+
+type osdImageRequest struct {
+	ImgPath string `xml:"tt:ImgPath"`
+}
+
+type osdTextRequest struct {
+	IsPersistentText *bool  `xml:"IsPersistentText,attr,omitempty"`
+	Type             string `xml:"tt:Type"`
+	DateFormat       string `xml:"tt:DateFormat,omitempty"`
+	TimeFormat       string `xml:"tt:TimeFormat,omitempty"`
+	FontSize         int    `xml:"tt:FontSize,omitempty"`
+	PlainText        string `xml:"tt:PlainText,omitempty"`
+}
+
+// osdConfigurationResponse is the wire form of an OSD configuration returned by the device.
+type osdConfigurationResponse struct {
+	Token                         string `xml:"token,attr"`
+	VideoSourceConfigurationToken string `xml:"VideoSourceConfigurationToken"`
+	Type                          string `xml:"Type"`
+	Position                      *struct {
+		Type string `xml:"Type"`
+		Pos  *struct {
+			X float64 `xml:"x,attr"`
+			Y float64 `xml:"y,attr"`
+		} `xml:"Pos"`
+	} `xml:"Position"`
+	TextString *struct {
+		IsPersistentText *Bool  `xml:"IsPersistentText,attr"`
+		Type             string `xml:"Type"`
+		DateFormat       string `xml:"DateFormat"`
+		TimeFormat       string `xml:"TimeFormat"`
+		FontSize         int    `xml:"FontSize"`
+		PlainText        string `xml:"PlainText"`
+	} `xml:"TextString"`
+	Image *struct {
+		ImgPath string `xml:"ImgPath"`
+	} `xml:"Image"`
+}
+
+// newOSDConfigurationRequest converts an OSD configuration to its wire form.
+func newOSDConfigurationRequest(osd *OSDConfiguration) osdConfigurationRequest {
+	req := osdConfigurationRequest{
+		Token:                         osd.Token,
+		VideoSourceConfigurationToken: osd.VideoSourceConfigurationToken,
+		Type:                          osd.Type,
+	}
+
+	if osd.Position != nil {
+		req.Position = &osdPosRequest{Type: osd.Position.Type}
+		if osd.Position.Pos != nil {
+			req.Position.Pos = &struct {
+				X float64 `xml:"x,attr"`
+				Y float64 `xml:"y,attr"`
+			}{X: osd.Position.Pos.X, Y: osd.Position.Pos.Y}
+		}
+	}
+
+	if osd.TextString != nil {
+		req.TextString = &osdTextRequest{
+			Type:       osd.TextString.Type,
+			DateFormat: osd.TextString.DateFormat,
+			TimeFormat: osd.TextString.TimeFormat,
+			FontSize:   osd.TextString.FontSize,
+			PlainText:  osd.TextString.PlainText,
+		}
+		req.TextString.IsPersistentText = osd.TextString.IsPersistentText
+	}
+
+	if osd.Image != nil {
+		req.Image = &osdImageRequest{ImgPath: osd.Image.ImagePath}
+	}
+
+	return req
+}
+
+// toOSDConfiguration converts the wire form of an OSD configuration.
+func (o *osdConfigurationResponse) toOSDConfiguration() *OSDConfiguration {
+	osd := &OSDConfiguration{
+		Token:                         o.Token,
+		VideoSourceConfigurationToken: o.VideoSourceConfigurationToken,
+		Type:                          o.Type,
+	}
+
+	if o.Position != nil {
+		osd.Position = &OSDPosConfiguration{Type: o.Position.Type}
+		if o.Position.Pos != nil {
+			osd.Position.Pos = &Vector2D{X: o.Position.Pos.X, Y: o.Position.Pos.Y}
+		}
+	}
+
+	if o.TextString != nil {
+		osd.TextString = &OSDTextConfiguration{
+			Type:       o.TextString.Type,
+			DateFormat: o.TextString.DateFormat,
+			TimeFormat: o.TextString.TimeFormat,
+			FontSize:   o.TextString.FontSize,
+			PlainText:  o.TextString.PlainText,
+		}
+
+		if o.TextString.IsPersistentText != nil {
+			persistent := bool(*o.TextString.IsPersistentText)
+			osd.TextString.IsPersistentText = &persistent
+		}
+	}
+
+	if o.Image != nil {
+		osd.Image = &OSDImageConfiguration{ImagePath: o.Image.ImgPath}
+	}
+
+	return osd
+}
+
+// validateOSD checks an OSD against the device's GetOSDOptions before it is
+// sent: image OSDs are rejected with ErrOSDImageNotSupported on devices that
+// only support text, image paths must be among those the device lists, and
+// date and time formats of text OSDs must be among the advertised formats.
+// Devices that list no paths or formats are not validated on them, and the
+// check is skipped when the options cannot be read. Temporary text is
+// rejected unless the device reports TemporaryOSDText.
+func (c *Client) validateOSD(ctx context.Context, osd *OSDConfiguration) error {
+	isImage := strings.EqualFold(osd.Type, "Image") || osd.Image != nil
+
+	text := osd.TextString
+
+	if err := c.validateOSDPersistence(ctx, text); err != nil {
+		return err
+	}
+
+	hasFormats := text != nil && (text.DateFormat != "" || text.TimeFormat != "")
+
+	if !isImage && !hasFormats {
+		return nil
+	}
+
+	options, err := c.GetOSDOptions(ctx, osd.VideoSourceConfigurationToken)
+	if err != nil {
+		c.skipCheck("OSD options check", err)
+
+		return nil
+	}
+
+	if isImage {
+		if err := validateOSDImage(osd, options); err != nil {
+			return err
+		}
 	}
 
-	req := SetVideoSourceMode{
-		Xmlns:            mediaNamespace,
-		VideoSourceToken: videoSourceToken,
-		ModeToken:        modeToken,
+	if !hasFormats || options.TextOption == nil {
+		return nil
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	usesDate := text.Type == "Date" || text.Type == "DateAndTime"
+	if usesDate && text.DateFormat != "" && len(options.TextOption.DateFormats) > 0 &&
+		!slices.Contains(options.TextOption.DateFormats, text.DateFormat) {
+		return fmt.Errorf("%w: OSD date format %q not supported, device accepts %s",
+			ErrInvalidParameter, text.DateFormat, strings.Join(options.TextOption.DateFormats, ", "))
+	}
 
-	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
-		return fmt.Errorf("SetVideoSourceMode failed: %w", err)
+	usesTime := text.Type == "Time" || text.Type == "DateAndTime"
+	if usesTime && text.TimeFormat != "" && len(options.TextOption.TimeFormats) > 0 &&
+		!slices.Contains(options.TextOption.TimeFormats, text.TimeFormat) {
+		return fmt.Errorf("%w: OSD time format %q not supported, device accepts %s",
+			ErrInvalidParameter, text.TimeFormat, strings.Join(options.TextOption.TimeFormats, ", "))
 	}
 
 	return nil
 }
 
-// SetSynchronizationPoint sets a synchronization point for the stream.
-func (c *Client) SetSynchronizationPoint(ctx context.Context, profileToken string) error {
-	endpoint := c.mediaEndpoint
-	if endpoint == "" {
-		endpoint = c.endpoint
+// validateOSDPersistence returns ErrInvalidParameter when text asks for
+// temporary OSD text on a device that does not report the TemporaryOSDText
+// capability. The check is skipped when the capabilities cannot be read.
+func (c *Client) validateOSDPersistence(ctx context.Context, text *OSDTextConfiguration) error {
+	if text == nil || text.IsPersistentText == nil || *text.IsPersistentText {
+		return nil
 	}
 
-	type SetSynchronizationPoint struct {
-		XMLName      xml.Name `xml:"trt:SetSynchronizationPoint"`
-		Xmlns        string   `xml:"xmlns:trt,attr"`
-		ProfileToken string   `xml:"trt:ProfileToken"`
+	caps, err := checkedCapabilities(ctx, c, mediaNamespace, c.GetMediaServiceCapabilities)
+	if err != nil {
+		c.skipCheck("OSD persistence check", err)
+
+		return nil
 	}
 
-	req := SetSynchronizationPoint{
-		Xmlns:        mediaNamespace,
-		ProfileToken: profileToken,
+	if !caps.TemporaryOSDText {
+		return fmt.Errorf("%w: device does not support temporary OSD text, OSDs always persist across reboot",
+			ErrInvalidParameter)
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	return nil
+}
 
-	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
-		return fmt.Errorf("SetSynchronizationPoint failed: %w", err)
+// validateOSDImage checks an image OSD against the device's OSD options.
+func validateOSDImage(osd *OSDConfiguration, options *OSDConfigurationOptions) error {
+	if !options.SupportsImage() {
+		return fmt.Errorf("%w: device supports %s OSDs only",
+			ErrOSDImageNotSupported, strings.Join(options.Types, ", "))
+	}
+
+	if osd.Image == nil || osd.Image.ImagePath == "" {
+		return fmt.Errorf("%w: image OSD requires an image path", ErrInvalidParameter)
+	}
+
+	if options.ImageOption != nil && len(options.ImageOption.ImagePaths) > 0 &&
+		!slices.Contains(options.ImageOption.ImagePaths, osd.Image.ImagePath) {
+		return fmt.Errorf("%w: OSD image %q not available, device provides %s",
+			ErrInvalidParameter, osd.Image.ImagePath, strings.Join(options.ImageOption.ImagePaths, ", "))
 	}
 
 	return nil
@@ -1327,10 +1945,8 @@ func (c *Client) GetOSDs(ctx context.Context, configurationToken string) ([]*OSD
 	}
 
 	type GetOSDsResponse struct {
-		XMLName xml.Name `xml:"GetOSDsResponse"`
-		OSDs    []struct {
-			Token string `xml:"token,attr"`
-		} `xml:"OSDs"`
+		XMLName xml.Name                   `xml:"GetOSDsResponse"`
+		OSDs    []osdConfigurationResponse `xml:"OSDs"`
 	}
 
 	req := GetOSDs{
@@ -1342,18 +1958,15 @@ func (c *Client) GetOSDs(ctx context.Context, configurationToken string) ([]*OSD
 
 	var resp GetOSDsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetOSDs failed: %w", err)
 	}
 
 	osds := make([]*OSDConfiguration, len(resp.OSDs))
-	for i, o := range resp.OSDs {
-		osds[i] = &OSDConfiguration{
-			Token: o.Token,
-		}
+	for i := range resp.OSDs {
+		osds[i] = resp.OSDs[i].toOSDConfiguration()
 	}
 
 	return osds, nil
@@ -1373,10 +1986,8 @@ func (c *Client) GetOSD(ctx context.Context, osdToken string) (*OSDConfiguration
 	}
 
 	type GetOSDResponse struct {
-		XMLName xml.Name `xml:"GetOSDResponse"`
-		OSD     struct {
-			Token string `xml:"token,attr"`
-		} `xml:"OSD"`
+		XMLName xml.Name                 `xml:"GetOSDResponse"`
+		OSD     osdConfigurationResponse `xml:"OSD"`
 	}
 
 	req := GetOSD{
@@ -1386,42 +1997,48 @@ func (c *Client) GetOSD(ctx context.Context, osdToken string) (*OSDConfiguration
 
 	var resp GetOSDResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetOSD failed: %w", err)
 	}
 
-	return &OSDConfiguration{
-		Token: resp.OSD.Token,
-	}, nil
+	return resp.OSD.toOSDConfiguration(), nil
 }
 
 // SetOSD sets OSD configuration.
+// The OSD is validated against GetOSDOptions before the request is sent:
+// ErrOSDImageNotSupported is returned for image OSDs on text-only devices and
+// ErrInvalidParameter for image paths or date and time formats the device
+// does not accept.
+//
+// OSDs are stored persistently and survive a reboot. Plain text can be made
+// temporary with OSDTextConfiguration.IsPersistentText on devices reporting
+// the TemporaryOSDText capability; such text is lost on reboot.
 func (c *Client) SetOSD(ctx context.Context, osd *OSDConfiguration) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
 	}
 
+	if err := c.validateOSD(ctx, osd); err != nil {
+		return fmt.Errorf("SetOSD failed: %w", err)
+	}
+
 	type SetOSD struct {
-		XMLName xml.Name `xml:"trt:SetOSD"`
-		Xmlns   string   `xml:"xmlns:trt,attr"`
-		Xmlnst  string   `xml:"xmlns:tt,attr"`
-		OSD     struct {
-			Token string `xml:"token,attr"`
-		} `xml:"trt:OSD"`
+		XMLName xml.Name                `xml:"trt:SetOSD"`
+		Xmlns   string                  `xml:"xmlns:trt,attr"`
+		Xmlnst  string                  `xml:"xmlns:tt,attr"`
+		OSD     osdConfigurationRequest `xml:"trt:OSD"`
 	}
 
 	req := SetOSD{
 		Xmlns:  mediaNamespace,
 		Xmlnst: "http://www.onvif.org/ver10/schema",
+		OSD:    newOSDConfigurationRequest(osd),
 	}
-	req.OSD.Token = osd.Token
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetOSD failed: %w", err)
@@ -1431,6 +2048,8 @@ func (c *Client) SetOSD(ctx context.Context, osd *OSDConfiguration) error {
 }
 
 // CreateOSD creates a new OSD configuration.
+// The OSD is validated the same way as in SetOSD and persists across reboot
+// unless its text is marked temporary, see SetOSD.
 func (c *Client) CreateOSD(
 	ctx context.Context,
 	videoSourceConfigurationToken string,
@@ -1442,20 +2061,16 @@ func (c *Client) CreateOSD(
 	}
 
 	type CreateOSD struct {
-		XMLName                       xml.Name `xml:"trt:CreateOSD"`
-		Xmlns                         string   `xml:"xmlns:trt,attr"`
-		Xmlnst                        string   `xml:"xmlns:tt,attr"`
-		VideoSourceConfigurationToken string   `xml:"trt:VideoSourceConfigurationToken"`
-		OSD                           struct {
-			Token string `xml:"token,attr,omitempty"`
-		} `xml:"trt:OSD"`
+		XMLName                       xml.Name                `xml:"trt:CreateOSD"`
+		Xmlns                         string                  `xml:"xmlns:trt,attr"`
+		Xmlnst                        string                  `xml:"xmlns:tt,attr"`
+		VideoSourceConfigurationToken string                  `xml:"trt:VideoSourceConfigurationToken"`
+		OSD                           osdConfigurationRequest `xml:"trt:OSD"`
 	}
 
 	type CreateOSDResponse struct {
-		XMLName xml.Name `xml:"CreateOSDResponse"`
-		OSD     struct {
-			Token string `xml:"token,attr"`
-		} `xml:"OSD"`
+		XMLName xml.Name                 `xml:"CreateOSDResponse"`
+		OSD     osdConfigurationResponse `xml:"OSD"`
 	}
 
 	req := CreateOSD{
@@ -1463,22 +2078,29 @@ func (c *Client) CreateOSD(
 		Xmlnst:                        "http://www.onvif.org/ver10/schema",
 		VideoSourceConfigurationToken: videoSourceConfigurationToken,
 	}
-	if osd != nil && osd.Token != "" {
-		req.OSD.Token = osd.Token
+	if osd != nil {
+		if osd.VideoSourceConfigurationToken == "" {
+			cfg := *osd
+			cfg.VideoSourceConfigurationToken = videoSourceConfigurationToken
+			osd = &cfg
+		}
+
+		if err := c.validateOSD(ctx, osd); err != nil {
+			return nil, fmt.Errorf("CreateOSD failed: %w", err)
+		}
+
+		req.OSD = newOSDConfigurationRequest(osd)
 	}
 
 	var resp CreateOSDResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("CreateOSD failed: %w", err)
 	}
 
-	return &OSDConfiguration{
-		Token: resp.OSD.Token,
-	}, nil
+	return resp.OSD.toOSDConfiguration(), nil
 }
 
 // DeleteOSD deletes an OSD configuration.
@@ -1499,8 +2121,7 @@ func (c *Client) DeleteOSD(ctx context.Context, osdToken string) error {
 		OSDToken: osdToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("DeleteOSD failed: %w", err)
@@ -1527,8 +2148,7 @@ func (c *Client) StartMulticastStreaming(ctx context.Context, profileToken strin
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("StartMulticastStreaming failed: %w", err)
@@ -1555,8 +2175,7 @@ func (c *Client) StopMulticastStreaming(ctx context.Context, profileToken string
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("StopMulticastStreaming failed: %w", err)
@@ -1565,7 +2184,8 @@ func (c *Client) StopMulticastStreaming(ctx context.Context, profileToken string
 	return nil
 }
 
-// GetProfile retrieves a specific media profile.
+// GetProfile retrieves a specific media profile with its configurations,
+// decoded like the profiles returned by GetProfiles from Media (ver10).
 func (c *Client) GetProfile(ctx context.Context, profileToken string) (*Profile, error) {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -1579,11 +2199,8 @@ func (c *Client) GetProfile(ctx context.Context, profileToken string) (*Profile,
 	}
 
 	type GetProfileResponse struct {
-		XMLName xml.Name `xml:"GetProfileResponse"`
-		Profile struct {
-			Token string `xml:"token,attr"`
-			Name  string `xml:"Name"`
-		} `xml:"Profile"`
+		XMLName xml.Name        `xml:"GetProfileResponse"`
+		Profile profileResponse `xml:"Profile"`
 	}
 
 	req := GetProfile{
@@ -1593,21 +2210,73 @@ func (c *Client) GetProfile(ctx context.Context, profileToken string) (*Profile,
 
 	var resp GetProfileResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetProfile failed: %w", err)
 	}
 
-	return &Profile{
-		Token: resp.Profile.Token,
-		Name:  resp.Profile.Name,
-	}, nil
+	return resp.Profile.toProfile(), nil
+}
+
+// VideoSourceTokenForProfile returns the token of the video source used by a
+// profile, i.e. the SourceToken of its video source configuration. Imaging
+// operations are keyed by video source rather than by profile. The profile is
+// looked up in GetProfiles, so that it is read from the same media service as
+// the profile tokens, see WithMediaVersion. It returns ErrNotFound when there
+// is no such profile and ErrNoVideoSource when the profile has no video source
+// configuration.
+func (c *Client) VideoSourceTokenForProfile(ctx context.Context, profileToken string) (string, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return "", fmt.Errorf("VideoSourceTokenForProfile failed: %w", err)
+	}
+
+	i := slices.IndexFunc(profiles, func(p *Profile) bool { return p.Token == profileToken })
+	if i < 0 {
+		return "", fmt.Errorf("VideoSourceTokenForProfile failed: %w: profile %s", ErrNotFound, profileToken)
+	}
+
+	source := profiles[i].VideoSourceConfiguration
+	if source == nil || source.SourceToken == "" {
+		return "", fmt.Errorf("VideoSourceTokenForProfile failed: %w: profile %s", ErrNoVideoSource, profileToken)
+	}
+
+	return source.SourceToken, nil
 }
 
-// SetProfile sets profile configuration.
+// SetProfile sets the name of a profile. Only Token and Name are sent: the
+// configurations attached to the profile are left as they are on the device
+// and cannot be changed through SetProfile; use the Add/Remove*Configuration
+// operations for that. A warning is logged when profile carries configurations,
+// since they are not applied. Prefer RenameProfile, which makes the scope explicit.
 func (c *Client) SetProfile(ctx context.Context, profile *Profile) error {
+	if profileHasConfigurations(profile) {
+		c.logf("onvif: SetProfile: configurations of profile %s are not sent; only the name is updated", profile.Token)
+	}
+
+	if err := c.setProfileName(ctx, profile.Token, profile.Name); err != nil {
+		return fmt.Errorf("SetProfile failed: %w", err)
+	}
+
+	return nil
+}
+
+// RenameProfile changes the name of a profile without touching its configurations.
+func (c *Client) RenameProfile(ctx context.Context, profileToken, newName string) error {
+	if profileToken == "" || newName == "" {
+		return fmt.Errorf("%w: profile token and name are required", ErrInvalidParameter)
+	}
+
+	if err := c.setProfileName(ctx, profileToken, newName); err != nil {
+		return fmt.Errorf("RenameProfile failed: %w", err)
+	}
+
+	return nil
+}
+
+// setProfileName sends a SetProfile request carrying only the token and name.
+func (c *Client) setProfileName(ctx context.Context, profileToken, name string) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
 		endpoint = c.endpoint
@@ -1627,20 +2296,27 @@ func (c *Client) SetProfile(ctx context.Context, profile *Profile) error {
 		Xmlns:  mediaNamespace,
 		Xmlnst: "http://www.onvif.org/ver10/schema",
 	}
-	req.Profile.Token = profile.Token
-	req.Profile.Name = profile.Name
+	req.Profile.Token = profileToken
+	req.Profile.Name = name
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
-	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
-		return fmt.Errorf("SetProfile failed: %w", err)
-	}
+	return soapClient.Call(ctx, endpoint, "", req, nil)
+}
 
-	return nil
+// profileHasConfigurations reports whether any configuration is set on profile.
+func profileHasConfigurations(profile *Profile) bool {
+	return profile.VideoSourceConfiguration != nil ||
+		profile.AudioSourceConfiguration != nil ||
+		profile.VideoEncoderConfiguration != nil ||
+		profile.AudioEncoderConfiguration != nil ||
+		profile.PTZConfiguration != nil ||
+		profile.MetadataConfiguration != nil
 }
 
 // AddVideoEncoderConfiguration adds video encoder configuration to a profile.
+// With WithVideoEncoderInstanceCheck the guaranteed number of encoder instances
+// is checked first, see CanAddVideoEncoder.
 func (c *Client) AddVideoEncoderConfiguration(ctx context.Context, profileToken, configurationToken string) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -1654,14 +2330,19 @@ func (c *Client) AddVideoEncoderConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken string   `xml:"trt:ConfigurationToken"`
 	}
 
+	if c.checkEncoderInstances {
+		if err := c.checkVideoEncoderInstances(ctx, profileToken, configurationToken); err != nil {
+			return err
+		}
+	}
+
 	req := AddVideoEncoderConfiguration{
 		Xmlns:              mediaNamespace,
 		ProfileToken:       profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddVideoEncoderConfiguration failed: %w", err)
@@ -1688,8 +2369,7 @@ func (c *Client) RemoveVideoEncoderConfiguration(ctx context.Context, profileTok
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveVideoEncoderConfiguration failed: %w", err)
@@ -1718,8 +2398,7 @@ func (c *Client) AddAudioEncoderConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddAudioEncoderConfiguration failed: %w", err)
@@ -1746,8 +2425,7 @@ func (c *Client) RemoveAudioEncoderConfiguration(ctx context.Context, profileTok
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveAudioEncoderConfiguration failed: %w", err)
@@ -1776,8 +2454,7 @@ func (c *Client) AddAudioSourceConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddAudioSourceConfiguration failed: %w", err)
@@ -1804,8 +2481,7 @@ func (c *Client) RemoveAudioSourceConfiguration(ctx context.Context, profileToke
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveAudioSourceConfiguration failed: %w", err)
@@ -1834,8 +2510,7 @@ func (c *Client) AddVideoSourceConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddVideoSourceConfiguration failed: %w", err)
@@ -1862,8 +2537,7 @@ func (c *Client) RemoveVideoSourceConfiguration(ctx context.Context, profileToke
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveVideoSourceConfiguration failed: %w", err)
@@ -1892,13 +2566,14 @@ func (c *Client) AddPTZConfiguration(ctx context.Context, profileToken, configur
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddPTZConfiguration failed: %w", err)
 	}
 
+	c.resetPTZSpaces(profileToken)
+
 	return nil
 }
 
@@ -1920,13 +2595,14 @@ func (c *Client) RemovePTZConfiguration(ctx context.Context, profileToken string
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemovePTZConfiguration failed: %w", err)
 	}
 
+	c.resetPTZSpaces(profileToken)
+
 	return nil
 }
 
@@ -1950,8 +2626,7 @@ func (c *Client) AddMetadataConfiguration(ctx context.Context, profileToken, con
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddMetadataConfiguration failed: %w", err)
@@ -1978,8 +2653,7 @@ func (c *Client) RemoveMetadataConfiguration(ctx context.Context, profileToken s
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveMetadataConfiguration failed: %w", err)
@@ -2026,8 +2700,7 @@ func (c *Client) GetAudioEncoderConfigurationOptions(
 
 	var resp GetAudioEncoderConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioEncoderConfigurationOptions failed: %w", err)
@@ -2061,8 +2734,8 @@ func (c *Client) GetMetadataConfigurationOptions(
 		XMLName xml.Name `xml:"GetMetadataConfigurationOptionsResponse"`
 		Options struct {
 			PTZStatusFilterOptions *struct {
-				Status   bool `xml:"Status"`
-				Position bool `xml:"Position"`
+				Status   Bool `xml:"Status"`
+				Position Bool `xml:"Position"`
 			} `xml:"PTZStatusFilterOptions"`
 			Extension struct{} `xml:"Extension"`
 		} `xml:"Options"`
@@ -2080,8 +2753,7 @@ func (c *Client) GetMetadataConfigurationOptions(
 
 	var resp GetMetadataConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetMetadataConfigurationOptions failed: %w", err)
@@ -2090,8 +2762,8 @@ func (c *Client) GetMetadataConfigurationOptions(
 	options := &MetadataConfigurationOptions{}
 	if resp.Options.PTZStatusFilterOptions != nil {
 		options.PTZStatusFilterOptions = &PTZFilter{
-			Status:   resp.Options.PTZStatusFilterOptions.Status,
-			Position: resp.Options.PTZStatusFilterOptions.Position,
+			Status:   bool(resp.Options.PTZStatusFilterOptions.Status),
+			Position: bool(resp.Options.PTZStatusFilterOptions.Position),
 		}
 	}
 
@@ -2124,7 +2796,7 @@ func (c *Client) GetAudioOutputConfiguration(ctx context.Context, configurationT
 	}
 
 	var resp GetAudioOutputConfigurationResponse
-	soapClient := c.getMediaSoapClient()
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioOutputConfiguration failed: %w", err)
@@ -2138,7 +2810,8 @@ func (c *Client) GetAudioOutputConfiguration(ctx context.Context, configurationT
 	}, nil
 }
 
-// SetAudioOutputConfiguration sets audio output configuration.
+// SetAudioOutputConfiguration sets audio output configuration. The device's
+// own UseCount is sent in place of config.UseCount.
 func (c *Client) SetAudioOutputConfiguration(ctx context.Context, config *AudioOutputConfiguration, forcePersistence bool) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -2166,11 +2839,11 @@ func (c *Client) SetAudioOutputConfiguration(ctx context.Context, config *AudioO
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetAudioOutputConfiguration", config.Token, config.UseCount,
+		c.GetAudioOutputConfiguration, func(current *AudioOutputConfiguration) int { return current.UseCount })
 	req.Configuration.OutputToken = config.OutputToken
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetAudioOutputConfiguration failed: %w", err)
@@ -2211,8 +2884,7 @@ func (c *Client) GetAudioOutputConfigurationOptions(
 
 	var resp GetAudioOutputConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioOutputConfigurationOptions failed: %w", err)
@@ -2264,8 +2936,7 @@ func (c *Client) GetAudioDecoderConfigurationOptions(
 
 	var resp GetAudioDecoderConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioDecoderConfigurationOptions failed: %w", err)
@@ -2323,8 +2994,7 @@ func (c *Client) GetGuaranteedNumberOfVideoEncoderInstances(
 
 	var resp GetGuaranteedNumberOfVideoEncoderInstancesResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetGuaranteedNumberOfVideoEncoderInstances failed: %w", err)
@@ -2338,6 +3008,109 @@ func (c *Client) GetGuaranteedNumberOfVideoEncoderInstances(
 	}, nil
 }
 
+// CanAddVideoEncoder reports whether the device guarantees another video
+// encoder instance with the given encoding (JPEG, H264 or MPEG4) for the video
+// source configuration configToken. Encoders already in use are counted from
+// the profiles referencing configToken and compared with both the per-codec
+// and the total guaranteed number of instances. Limits the device reports as
+// zero are treated as unknown and not enforced.
+func (c *Client) CanAddVideoEncoder(ctx context.Context, configToken, encoding string) (bool, error) {
+	guaranteed, err := c.GetGuaranteedNumberOfVideoEncoderInstances(ctx, configToken)
+	if err != nil {
+		return false, err
+	}
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return videoEncoderAvailable(guaranteed, profiles, configToken, encoding, ""), nil
+}
+
+// videoEncoderAvailable reports whether guaranteed leaves room for another encoder
+// on configToken, ignoring the encoder of excludeProfile.
+func videoEncoderAvailable(
+	guaranteed *GuaranteedNumberOfVideoEncoderInstances,
+	profiles []*Profile,
+	configToken, encoding, excludeProfile string,
+) bool {
+	total := 0
+	inUse := make(map[string]int)
+
+	for _, profile := range profiles {
+		if profile.Token == excludeProfile || profile.VideoEncoderConfiguration == nil ||
+			profile.VideoSourceConfiguration == nil || profile.VideoSourceConfiguration.Token != configToken {
+			continue
+		}
+
+		total++
+		inUse[strings.ToUpper(profile.VideoEncoderConfiguration.Encoding)]++
+	}
+
+	if guaranteed.TotalNumber > 0 && total >= guaranteed.TotalNumber {
+		return false
+	}
+
+	limits := map[string]int{
+		"JPEG":  guaranteed.JPEG,
+		"H264":  guaranteed.H264,
+		"MPEG4": guaranteed.MPEG4,
+	}
+
+	encoding = strings.ToUpper(encoding)
+	if limit := limits[encoding]; limit > 0 && inUse[encoding] >= limit {
+		return false
+	}
+
+	return true
+}
+
+// checkVideoEncoderInstances returns ErrVideoEncoderLimitReached when adding the
+// encoder configuration to the profile exceeds the guaranteed encoder instances.
+// The profile's current encoder is not counted because it is replaced.
+func (c *Client) checkVideoEncoderInstances(ctx context.Context, profileToken, configurationToken string) error {
+	config, err := c.GetVideoEncoderConfiguration(ctx, configurationToken)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	var sourceToken string
+
+	for _, profile := range profiles {
+		if profile.Token == profileToken && profile.VideoSourceConfiguration != nil {
+			sourceToken = profile.VideoSourceConfiguration.Token
+		}
+	}
+
+	if sourceToken == "" {
+		// Without a video source the device decides.
+		return nil
+	}
+
+	guaranteed, err := c.GetGuaranteedNumberOfVideoEncoderInstances(ctx, sourceToken)
+	if err != nil {
+		return err
+	}
+
+	if !videoEncoderAvailable(guaranteed, profiles, sourceToken, config.Encoding, profileToken) {
+		return fmt.Errorf("%w: %s encoder for video source configuration %q",
+			ErrVideoEncoderLimitReached, config.Encoding, sourceToken)
+	}
+
+	return nil
+}
+
+// SupportsImage reports whether the device supports image OSDs such as logo overlays.
+func (o *OSDConfigurationOptions) SupportsImage() bool {
+	return containsFold(o.Types, "Image") || o.ImageOption != nil
+}
+
 // GetOSDOptions retrieves available options for OSD configuration.
 func (c *Client) GetOSDOptions(ctx context.Context, configurationToken string) (*OSDConfigurationOptions, error) {
 	endpoint := c.mediaEndpoint
@@ -2351,11 +3124,37 @@ func (c *Client) GetOSDOptions(ctx context.Context, configurationToken string) (
 		ConfigurationToken string   `xml:"trt:ConfigurationToken,omitempty"`
 	}
 
+	type osdOptions struct {
+		MaximumNumberOfOSDs struct {
+			Value int `xml:",chardata"`
+			Total int `xml:"Total,attr"`
+			Image int `xml:"Image,attr"`
+		} `xml:"MaximumNumberOfOSDs"`
+		Type           []string `xml:"Type"`
+		PositionOption []string `xml:"PositionOption"`
+		TextOption     *struct {
+			Type          []string `xml:"Type"`
+			FontSizeRange *struct {
+				Min int `xml:"Min"`
+				Max int `xml:"Max"`
+			} `xml:"FontSizeRange"`
+			DateFormat []string `xml:"DateFormat"`
+			TimeFormat []string `xml:"TimeFormat"`
+		} `xml:"TextOption"`
+		ImageOption *struct {
+			FormatsSupported string   `xml:"FormatsSupported,attr"`
+			MaxSize          int      `xml:"MaxSize,attr"`
+			MaxWidth         int      `xml:"MaxWidth,attr"`
+			MaxHeight        int      `xml:"MaxHeight,attr"`
+			ImagePath        []string `xml:"ImagePath"`
+		} `xml:"ImageOption"`
+	}
+
+	// The schema names the element OSDOptions, but some firmware responds with Options.
 	type GetOSDOptionsResponse struct {
-		XMLName xml.Name `xml:"GetOSDOptionsResponse"`
-		Options struct {
-			MaximumNumberOfOSDs int `xml:"MaximumNumberOfOSDs"`
-		} `xml:"Options"`
+		XMLName    xml.Name    `xml:"GetOSDOptionsResponse"`
+		OSDOptions *osdOptions `xml:"OSDOptions"`
+		Options    *osdOptions `xml:"Options"`
 	}
 
 	req := GetOSDOptions{
@@ -2367,16 +3166,59 @@ func (c *Client) GetOSDOptions(ctx context.Context, configurationToken string) (
 
 	var resp GetOSDOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetOSDOptions failed: %w", err)
 	}
 
-	return &OSDConfigurationOptions{
-		MaximumNumberOfOSDs: resp.Options.MaximumNumberOfOSDs,
-	}, nil
+	opts := resp.OSDOptions
+	if opts == nil {
+		opts = resp.Options
+	}
+
+	if opts == nil {
+		return &OSDConfigurationOptions{}, nil
+	}
+
+	// The schema carries the limit in the Total attribute; older firmware puts it in the element text.
+	maxOSDs := opts.MaximumNumberOfOSDs.Total
+	if maxOSDs == 0 {
+		maxOSDs = opts.MaximumNumberOfOSDs.Value
+	}
+
+	options := &OSDConfigurationOptions{
+		MaximumNumberOfOSDs:      maxOSDs,
+		Types:                    opts.Type,
+		PositionOptions:          opts.PositionOption,
+		MaximumNumberOfImageOSDs: opts.MaximumNumberOfOSDs.Image,
+	}
+
+	if image := opts.ImageOption; image != nil {
+		options.ImageOption = &OSDImageOptions{
+			ImagePaths:       image.ImagePath,
+			FormatsSupported: strings.Fields(image.FormatsSupported),
+			MaxSize:          image.MaxSize,
+			MaxWidth:         image.MaxWidth,
+			MaxHeight:        image.MaxHeight,
+		}
+	}
+
+	if text := opts.TextOption; text != nil {
+		options.TextOption = &OSDTextOptions{
+			Types:       text.Type,
+			DateFormats: text.DateFormat,
+			TimeFormats: text.TimeFormat,
+		}
+		if text.FontSizeRange != nil {
+			options.TextOption.FontSizeRange = &IntRange{
+				Min: text.FontSizeRange.Min,
+				Max: text.FontSizeRange.Max,
+			}
+		}
+	}
+
+	return options, nil
 }
 
 // GetVideoSourceConfigurations retrieves all video source configurations.
@@ -2413,8 +3255,7 @@ func (c *Client) GetVideoSourceConfigurations(ctx context.Context) ([]*VideoSour
 
 	var resp GetVideoSourceConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSourceConfigurations failed: %w", err)
@@ -2470,8 +3311,7 @@ func (c *Client) GetAudioSourceConfigurations(ctx context.Context) ([]*AudioSour
 
 	var resp GetAudioSourceConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioSourceConfigurations failed: %w", err)
@@ -2535,7 +3375,7 @@ func (c *Client) GetVideoEncoderConfigurations(ctx context.Context) ([]*VideoEnc
 				} `xml:"Address"`
 				Port      int  `xml:"Port"`
 				TTL       int  `xml:"TTL"`
-				AutoStart bool `xml:"AutoStart"`
+				AutoStart Bool `xml:"AutoStart"`
 			} `xml:"Multicast"`
 			SessionTimeout string `xml:"SessionTimeout"`
 		} `xml:"Configurations"`
@@ -2547,8 +3387,7 @@ func (c *Client) GetVideoEncoderConfigurations(ctx context.Context) ([]*VideoEnc
 
 	var resp GetVideoEncoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoEncoderConfigurations failed: %w", err)
@@ -2597,7 +3436,7 @@ func (c *Client) GetVideoEncoderConfigurations(ctx context.Context) ([]*VideoEnc
 			config.Multicast = &MulticastConfiguration{
 				Port:      cfg.Multicast.Port,
 				TTL:       cfg.Multicast.TTL,
-				AutoStart: cfg.Multicast.AutoStart,
+				AutoStart: bool(cfg.Multicast.AutoStart),
 			}
 			if cfg.Multicast.Address != nil {
 				config.Multicast.Address = &IPAddress{
@@ -2643,7 +3482,7 @@ func (c *Client) GetAudioEncoderConfigurations(ctx context.Context) ([]*AudioEnc
 				} `xml:"Address"`
 				Port      int  `xml:"Port"`
 				TTL       int  `xml:"TTL"`
-				AutoStart bool `xml:"AutoStart"`
+				AutoStart Bool `xml:"AutoStart"`
 			} `xml:"Multicast"`
 			SessionTimeout string `xml:"SessionTimeout"`
 		} `xml:"Configurations"`
@@ -2655,8 +3494,7 @@ func (c *Client) GetAudioEncoderConfigurations(ctx context.Context) ([]*AudioEnc
 
 	var resp GetAudioEncoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioEncoderConfigurations failed: %w", err)
@@ -2672,12 +3510,13 @@ func (c *Client) GetAudioEncoderConfigurations(ctx context.Context) ([]*AudioEnc
 			Bitrate:    cfg.Bitrate,
 			SampleRate: cfg.SampleRate,
 		}
+		config.SessionTimeout, _ = parseDuration(cfg.SessionTimeout)
 
 		if cfg.Multicast != nil {
 			config.Multicast = &MulticastConfiguration{
 				Port:      cfg.Multicast.Port,
 				TTL:       cfg.Multicast.TTL,
-				AutoStart: cfg.Multicast.AutoStart,
+				AutoStart: bool(cfg.Multicast.AutoStart),
 			}
 			if cfg.Multicast.Address != nil {
 				config.Multicast.Address = &IPAddress{
@@ -2733,8 +3572,7 @@ func (c *Client) GetVideoSourceConfiguration(
 
 	var resp GetVideoSourceConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSourceConfiguration failed: %w", err)
@@ -2785,7 +3623,7 @@ func (c *Client) GetAudioSourceConfiguration(ctx context.Context, configurationT
 	}
 
 	var resp GetAudioSourceConfigurationResponse
-	soapClient := c.getMediaSoapClient()
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioSourceConfiguration failed: %w", err)
@@ -2841,8 +3679,7 @@ func (c *Client) GetVideoSourceConfigurationOptions(
 
 	var resp GetVideoSourceConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoSourceConfigurationOptions failed: %w", err)
@@ -2898,8 +3735,7 @@ func (c *Client) GetAudioSourceConfigurationOptions(
 
 	var resp GetAudioSourceConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioSourceConfigurationOptions failed: %w", err)
@@ -2910,7 +3746,8 @@ func (c *Client) GetAudioSourceConfigurationOptions(
 	}, nil
 }
 
-// SetVideoSourceConfiguration sets video source configuration.
+// SetVideoSourceConfiguration sets video source configuration. The device's
+// own UseCount is sent in place of config.UseCount.
 func (c *Client) SetVideoSourceConfiguration(
 	ctx context.Context,
 	config *VideoSourceConfiguration,
@@ -2948,7 +3785,8 @@ func (c *Client) SetVideoSourceConfiguration(
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetVideoSourceConfiguration", config.Token, config.UseCount,
+		c.GetVideoSourceConfiguration, func(current *VideoSourceConfiguration) int { return current.UseCount })
 	req.Configuration.SourceToken = config.SourceToken
 
 	if config.Bounds != nil {
@@ -2965,8 +3803,7 @@ func (c *Client) SetVideoSourceConfiguration(
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetVideoSourceConfiguration failed: %w", err)
@@ -2975,7 +3812,43 @@ func (c *Client) SetVideoSourceConfiguration(
 	return nil
 }
 
-// SetAudioSourceConfiguration sets audio source configuration.
+// RetargetVideoSourceConfiguration links a video source configuration to a
+// different physical video source, e.g. another sensor of a multi-sensor
+// camera. The source token is checked against GetVideoSources first; all other
+// settings of the configuration are kept. The change is persisted.
+func (c *Client) RetargetVideoSourceConfiguration(ctx context.Context, configToken, sourceToken string) error {
+	if configToken == "" || sourceToken == "" {
+		return fmt.Errorf("%w: configuration and source tokens are required", ErrInvalidParameter)
+	}
+
+	sources, err := c.GetVideoSources(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get video sources: %w", err)
+	}
+
+	found := slices.ContainsFunc(sources, func(source *VideoSource) bool {
+		return source.Token == sourceToken
+	})
+	if !found {
+		return fmt.Errorf("%w: %s", ErrVideoSourceNotFound, sourceToken)
+	}
+
+	config, err := c.GetVideoSourceConfiguration(ctx, configToken)
+	if err != nil {
+		return err
+	}
+
+	if config.SourceToken == sourceToken {
+		return nil
+	}
+
+	config.SourceToken = sourceToken
+
+	return c.SetVideoSourceConfiguration(ctx, config, true)
+}
+
+// SetAudioSourceConfiguration sets audio source configuration. The device's
+// own UseCount is sent in place of config.UseCount.
 func (c *Client) SetAudioSourceConfiguration(ctx context.Context, config *AudioSourceConfiguration, forcePersistence bool) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -3003,11 +3876,11 @@ func (c *Client) SetAudioSourceConfiguration(ctx context.Context, config *AudioS
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetAudioSourceConfiguration", config.Token, config.UseCount,
+		c.GetAudioSourceConfiguration, func(current *AudioSourceConfiguration) int { return current.UseCount })
 	req.Configuration.SourceToken = config.SourceToken
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetAudioSourceConfiguration failed: %w", err)
@@ -3059,8 +3932,7 @@ func (c *Client) GetCompatibleVideoEncoderConfigurations(
 
 	var resp GetCompatibleVideoEncoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleVideoEncoderConfigurations failed: %w", err)
@@ -3136,8 +4008,7 @@ func (c *Client) GetCompatibleVideoSourceConfigurations(
 
 	var resp GetCompatibleVideoSourceConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleVideoSourceConfigurations failed: %w", err)
@@ -3200,8 +4071,7 @@ func (c *Client) GetCompatibleAudioEncoderConfigurations(
 
 	var resp GetCompatibleAudioEncoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleAudioEncoderConfigurations failed: %w", err)
@@ -3252,8 +4122,7 @@ func (c *Client) GetCompatibleAudioSourceConfigurations(ctx context.Context, pro
 
 	var resp GetCompatibleAudioSourceConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleAudioSourceConfigurations failed: %w", err)
@@ -3302,8 +4171,7 @@ func (c *Client) GetCompatiblePTZConfigurations(ctx context.Context, profileToke
 
 	var resp GetCompatiblePTZConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatiblePTZConfigurations failed: %w", err)
@@ -3336,13 +4204,8 @@ func (c *Client) GetCompatibleMetadataConfigurations(ctx context.Context, profil
 	}
 
 	type GetCompatibleMetadataConfigurationsResponse struct {
-		XMLName        xml.Name `xml:"GetCompatibleMetadataConfigurationsResponse"`
-		Configurations []struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			UseCount  int    `xml:"UseCount"`
-			Analytics bool   `xml:"Analytics"`
-		} `xml:"Configurations"`
+		XMLName        xml.Name                        `xml:"GetCompatibleMetadataConfigurationsResponse"`
+		Configurations []metadataConfigurationResponse `xml:"Configurations"`
 	}
 
 	req := GetCompatibleMetadataConfigurations{
@@ -3352,21 +4215,15 @@ func (c *Client) GetCompatibleMetadataConfigurations(ctx context.Context, profil
 
 	var resp GetCompatibleMetadataConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleMetadataConfigurations failed: %w", err)
 	}
 
 	configs := make([]*MetadataConfiguration, len(resp.Configurations))
-	for i, cfg := range resp.Configurations {
-		configs[i] = &MetadataConfiguration{
-			Token:     cfg.Token,
-			Name:      cfg.Name,
-			UseCount:  cfg.UseCount,
-			Analytics: cfg.Analytics,
-		}
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toMetadataConfiguration()
 	}
 
 	return configs, nil
@@ -3402,8 +4259,7 @@ func (c *Client) GetCompatibleAudioOutputConfigurations(ctx context.Context, pro
 
 	var resp GetCompatibleAudioOutputConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleAudioOutputConfigurations failed: %w", err)
@@ -3451,8 +4307,7 @@ func (c *Client) GetCompatibleAudioDecoderConfigurations(ctx context.Context, pr
 
 	var resp GetCompatibleAudioDecoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleAudioDecoderConfigurations failed: %w", err)
@@ -3483,13 +4338,8 @@ func (c *Client) GetMetadataConfigurations(ctx context.Context) ([]*MetadataConf
 	}
 
 	type GetMetadataConfigurationsResponse struct {
-		XMLName        xml.Name `xml:"GetMetadataConfigurationsResponse"`
-		Configurations []struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			UseCount  int    `xml:"UseCount"`
-			Analytics bool   `xml:"Analytics"`
-		} `xml:"Configurations"`
+		XMLName        xml.Name                        `xml:"GetMetadataConfigurationsResponse"`
+		Configurations []metadataConfigurationResponse `xml:"Configurations"`
 	}
 
 	req := GetMetadataConfigurations{
@@ -3498,21 +4348,15 @@ func (c *Client) GetMetadataConfigurations(ctx context.Context) ([]*MetadataConf
 
 	var resp GetMetadataConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetMetadataConfigurations failed: %w", err)
 	}
 
 	configs := make([]*MetadataConfiguration, len(resp.Configurations))
-	for i, cfg := range resp.Configurations {
-		configs[i] = &MetadataConfiguration{
-			Token:     cfg.Token,
-			Name:      cfg.Name,
-			UseCount:  cfg.UseCount,
-			Analytics: cfg.Analytics,
-		}
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toMetadataConfiguration()
 	}
 
 	return configs, nil
@@ -3546,8 +4390,7 @@ func (c *Client) GetAudioOutputConfigurations(ctx context.Context) ([]*AudioOutp
 
 	var resp GetAudioOutputConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioOutputConfigurations failed: %w", err)
@@ -3566,6 +4409,27 @@ func (c *Client) GetAudioOutputConfigurations(ctx context.Context) ([]*AudioOutp
 	return configs, nil
 }
 
+// AudioOutputConfigurationForOutput returns the audio output configuration that
+// references the given physical audio output. It returns ErrNotFound when no
+// configuration is linked to the output.
+func (c *Client) AudioOutputConfigurationForOutput(
+	ctx context.Context,
+	outputToken string,
+) (*AudioOutputConfiguration, error) {
+	configs, err := c.GetAudioOutputConfigurations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cfg := range configs {
+		if cfg.OutputToken == outputToken {
+			return cfg, nil
+		}
+	}
+
+	return nil, fmt.Errorf("audio output configuration for output %q: %w", outputToken, ErrNotFound)
+}
+
 // GetAudioDecoderConfigurations retrieves all audio decoder configurations.
 func (c *Client) GetAudioDecoderConfigurations(ctx context.Context) ([]*AudioDecoderConfiguration, error) {
 	endpoint := c.mediaEndpoint
@@ -3593,8 +4457,7 @@ func (c *Client) GetAudioDecoderConfigurations(ctx context.Context) ([]*AudioDec
 
 	var resp GetAudioDecoderConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioDecoderConfigurations failed: %w", err)
@@ -3644,8 +4507,7 @@ func (c *Client) GetAudioDecoderConfiguration(
 
 	var resp GetAudioDecoderConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetAudioDecoderConfiguration failed: %w", err)
@@ -3658,7 +4520,8 @@ func (c *Client) GetAudioDecoderConfiguration(
 	}, nil
 }
 
-// SetAudioDecoderConfiguration sets audio decoder configuration.
+// SetAudioDecoderConfiguration sets audio decoder configuration. The device's
+// own UseCount is sent in place of config.UseCount.
 func (c *Client) SetAudioDecoderConfiguration(ctx context.Context, config *AudioDecoderConfiguration, forcePersistence bool) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -3685,10 +4548,10 @@ func (c *Client) SetAudioDecoderConfiguration(ctx context.Context, config *Audio
 
 	req.Configuration.Token = config.Token
 	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetAudioDecoderConfiguration", config.Token, config.UseCount,
+		c.GetAudioDecoderConfiguration, func(current *AudioDecoderConfiguration) int { return current.UseCount })
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetAudioDecoderConfiguration failed: %w", err)
@@ -3697,6 +4560,83 @@ func (c *Client) SetAudioDecoderConfiguration(ctx context.Context, config *Audio
 	return nil
 }
 
+// videoAnalyticsConfigurationResponse is the wire form of a video analytics configuration received from the device.
+type videoAnalyticsConfigurationResponse struct {
+	Token                        string `xml:"token,attr"`
+	Name                         string `xml:"Name"`
+	UseCount                     int    `xml:"UseCount"`
+	AnalyticsEngineConfiguration *struct {
+		AnalyticsModule []configResponse `xml:"AnalyticsModule"`
+	} `xml:"AnalyticsEngineConfiguration"`
+	RuleEngineConfiguration *struct {
+		Rule []configResponse `xml:"Rule"`
+	} `xml:"RuleEngineConfiguration"`
+}
+
+// videoAnalyticsConfigurationRequest is the wire form of a video analytics configuration sent to the device.
+type videoAnalyticsConfigurationRequest struct {
+	Token                        string `xml:"token,attr"`
+	Name                         string `xml:"tt:Name"`
+	UseCount                     int    `xml:"tt:UseCount"`
+	AnalyticsEngineConfiguration struct {
+		AnalyticsModule []configRequest `xml:"tt:AnalyticsModule"`
+	} `xml:"tt:AnalyticsEngineConfiguration"`
+	RuleEngineConfiguration struct {
+		Rule []configRequest `xml:"tt:Rule"`
+	} `xml:"tt:RuleEngineConfiguration"`
+}
+
+// toVideoAnalyticsConfiguration converts a decoded video analytics configuration.
+func (r *videoAnalyticsConfigurationResponse) toVideoAnalyticsConfiguration() *VideoAnalyticsConfiguration {
+	config := &VideoAnalyticsConfiguration{
+		Token:    r.Token,
+		Name:     r.Name,
+		UseCount: r.UseCount,
+	}
+
+	if r.AnalyticsEngineConfiguration != nil {
+		config.AnalyticsEngineConfiguration = &AnalyticsEngineConfiguration{}
+		for i := range r.AnalyticsEngineConfiguration.AnalyticsModule {
+			config.AnalyticsEngineConfiguration.AnalyticsModules = append(config.AnalyticsEngineConfiguration.AnalyticsModules,
+				r.AnalyticsEngineConfiguration.AnalyticsModule[i].toConfig())
+		}
+	}
+
+	if r.RuleEngineConfiguration != nil {
+		config.RuleEngineConfiguration = &RuleEngineConfiguration{}
+		for i := range r.RuleEngineConfiguration.Rule {
+			config.RuleEngineConfiguration.Rules = append(config.RuleEngineConfiguration.Rules,
+				r.RuleEngineConfiguration.Rule[i].toConfig())
+		}
+	}
+
+	return config
+}
+
+// newVideoAnalyticsConfigurationRequest converts a video analytics configuration to its wire form.
+func newVideoAnalyticsConfigurationRequest(config *VideoAnalyticsConfiguration) videoAnalyticsConfigurationRequest {
+	req := videoAnalyticsConfigurationRequest{
+		Token:    config.Token,
+		Name:     config.Name,
+		UseCount: config.UseCount,
+	}
+
+	if config.AnalyticsEngineConfiguration != nil {
+		for _, module := range config.AnalyticsEngineConfiguration.AnalyticsModules {
+			req.AnalyticsEngineConfiguration.AnalyticsModule = append(req.AnalyticsEngineConfiguration.AnalyticsModule,
+				newConfigRequest(module))
+		}
+	}
+
+	if config.RuleEngineConfiguration != nil {
+		for _, rule := range config.RuleEngineConfiguration.Rules {
+			req.RuleEngineConfiguration.Rule = append(req.RuleEngineConfiguration.Rule, newConfigRequest(rule))
+		}
+	}
+
+	return req
+}
+
 // GetVideoAnalyticsConfigurations retrieves all video analytics configurations.
 func (c *Client) GetVideoAnalyticsConfigurations(ctx context.Context) ([]*VideoAnalyticsConfiguration, error) {
 	endpoint := c.mediaEndpoint
@@ -3710,12 +4650,8 @@ func (c *Client) GetVideoAnalyticsConfigurations(ctx context.Context) ([]*VideoA
 	}
 
 	type GetVideoAnalyticsConfigurationsResponse struct {
-		XMLName        xml.Name `xml:"GetVideoAnalyticsConfigurationsResponse"`
-		Configurations []struct {
-			Token    string `xml:"token,attr"`
-			Name     string `xml:"Name"`
-			UseCount int    `xml:"UseCount"`
-		} `xml:"Configurations"`
+		XMLName        xml.Name                              `xml:"GetVideoAnalyticsConfigurationsResponse"`
+		Configurations []videoAnalyticsConfigurationResponse `xml:"Configurations"`
 	}
 
 	req := GetVideoAnalyticsConfigurations{
@@ -3724,20 +4660,15 @@ func (c *Client) GetVideoAnalyticsConfigurations(ctx context.Context) ([]*VideoA
 
 	var resp GetVideoAnalyticsConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoAnalyticsConfigurations failed: %w", err)
 	}
 
 	configs := make([]*VideoAnalyticsConfiguration, len(resp.Configurations))
-	for i, cfg := range resp.Configurations {
-		configs[i] = &VideoAnalyticsConfiguration{
-			Token:    cfg.Token,
-			Name:     cfg.Name,
-			UseCount: cfg.UseCount,
-		}
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toVideoAnalyticsConfiguration()
 	}
 
 	return configs, nil
@@ -3760,12 +4691,8 @@ func (c *Client) GetVideoAnalyticsConfiguration(
 	}
 
 	type GetVideoAnalyticsConfigurationResponse struct {
-		XMLName       xml.Name `xml:"GetVideoAnalyticsConfigurationResponse"`
-		Configuration struct {
-			Token    string `xml:"token,attr"`
-			Name     string `xml:"Name"`
-			UseCount int    `xml:"UseCount"`
-		} `xml:"Configuration"`
+		XMLName       xml.Name                            `xml:"GetVideoAnalyticsConfigurationResponse"`
+		Configuration videoAnalyticsConfigurationResponse `xml:"Configuration"`
 	}
 
 	req := GetVideoAnalyticsConfiguration{
@@ -3775,18 +4702,13 @@ func (c *Client) GetVideoAnalyticsConfiguration(
 
 	var resp GetVideoAnalyticsConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoAnalyticsConfiguration failed: %w", err)
 	}
 
-	return &VideoAnalyticsConfiguration{
-		Token:    resp.Configuration.Token,
-		Name:     resp.Configuration.Name,
-		UseCount: resp.Configuration.UseCount,
-	}, nil
+	return resp.Configuration.toVideoAnalyticsConfiguration(), nil
 }
 
 // GetCompatibleVideoAnalyticsConfigurations retrieves compatible video analytics configurations for a profile.
@@ -3803,12 +4725,8 @@ func (c *Client) GetCompatibleVideoAnalyticsConfigurations(ctx context.Context,
 	}
 
 	type GetCompatibleVideoAnalyticsConfigurationsResponse struct {
-		XMLName        xml.Name `xml:"GetCompatibleVideoAnalyticsConfigurationsResponse"`
-		Configurations []struct {
-			Token    string `xml:"token,attr"`
-			Name     string `xml:"Name"`
-			UseCount int    `xml:"UseCount"`
-		} `xml:"Configurations"`
+		XMLName        xml.Name                              `xml:"GetCompatibleVideoAnalyticsConfigurationsResponse"`
+		Configurations []videoAnalyticsConfigurationResponse `xml:"Configurations"`
 	}
 
 	req := GetCompatibleVideoAnalyticsConfigurations{
@@ -3818,26 +4736,22 @@ func (c *Client) GetCompatibleVideoAnalyticsConfigurations(ctx context.Context,
 
 	var resp GetCompatibleVideoAnalyticsConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetCompatibleVideoAnalyticsConfigurations failed: %w", err)
 	}
 
 	configs := make([]*VideoAnalyticsConfiguration, len(resp.Configurations))
-	for i, cfg := range resp.Configurations {
-		configs[i] = &VideoAnalyticsConfiguration{
-			Token:    cfg.Token,
-			Name:     cfg.Name,
-			UseCount: cfg.UseCount,
-		}
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toVideoAnalyticsConfiguration()
 	}
 
 	return configs, nil
 }
 
-// SetVideoAnalyticsConfiguration sets video analytics configuration.
+// SetVideoAnalyticsConfiguration sets video analytics configuration. The
+// device's own UseCount is sent in place of config.UseCount.
 func (c *Client) SetVideoAnalyticsConfiguration(ctx context.Context, config *VideoAnalyticsConfiguration, forcePersistence bool) error {
 	endpoint := c.mediaEndpoint
 	if endpoint == "" {
@@ -3845,29 +4759,24 @@ func (c *Client) SetVideoAnalyticsConfiguration(ctx context.Context, config *Vid
 	}
 
 	type SetVideoAnalyticsConfiguration struct {
-		XMLName       xml.Name `xml:"trt:SetVideoAnalyticsConfiguration"`
-		Xmlns         string   `xml:"xmlns:trt,attr"`
-		Xmlnst        string   `xml:"xmlns:tt,attr"`
-		Configuration struct {
-			Token    string `xml:"token,attr"`
-			Name     string `xml:"tt:Name"`
-			UseCount int    `xml:"tt:UseCount"`
-		} `xml:"trt:Configuration"`
-		ForcePersistence bool `xml:"trt:ForcePersistence"`
+		XMLName          xml.Name                           `xml:"trt:SetVideoAnalyticsConfiguration"`
+		Xmlns            string                             `xml:"xmlns:trt,attr"`
+		Xmlnst           string                             `xml:"xmlns:tt,attr"`
+		Configuration    videoAnalyticsConfigurationRequest `xml:"trt:Configuration"`
+		ForcePersistence bool                               `xml:"trt:ForcePersistence"`
 	}
 
 	req := SetVideoAnalyticsConfiguration{
 		Xmlns:            mediaNamespace,
 		Xmlnst:           "http://www.onvif.org/ver10/schema",
+		Configuration:    newVideoAnalyticsConfigurationRequest(config),
 		ForcePersistence: forcePersistence,
 	}
 
-	req.Configuration.Token = config.Token
-	req.Configuration.Name = config.Name
-	req.Configuration.UseCount = config.UseCount
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetVideoAnalyticsConfiguration", config.Token, config.UseCount,
+		c.GetVideoAnalyticsConfiguration, func(current *VideoAnalyticsConfiguration) int { return current.UseCount })
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("SetVideoAnalyticsConfiguration failed: %w", err)
@@ -3910,8 +4819,7 @@ func (c *Client) GetVideoAnalyticsConfigurationOptions(
 
 	var resp GetVideoAnalyticsConfigurationOptionsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetVideoAnalyticsConfigurationOptions failed: %w", err)
@@ -3940,8 +4848,7 @@ func (c *Client) AddVideoAnalyticsConfiguration(ctx context.Context, profileToke
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddVideoAnalyticsConfiguration failed: %w", err)
@@ -3968,8 +4875,7 @@ func (c *Client) RemoveVideoAnalyticsConfiguration(ctx context.Context, profileT
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveVideoAnalyticsConfiguration failed: %w", err)
@@ -3998,8 +4904,7 @@ func (c *Client) AddAudioOutputConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddAudioOutputConfiguration failed: %w", err)
@@ -4026,8 +4931,7 @@ func (c *Client) RemoveAudioOutputConfiguration(ctx context.Context, profileToke
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveAudioOutputConfiguration failed: %w", err)
@@ -4056,8 +4960,7 @@ func (c *Client) AddAudioDecoderConfiguration(ctx context.Context, profileToken,
 		ConfigurationToken: configurationToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AddAudioDecoderConfiguration failed: %w", err)
@@ -4084,8 +4987,7 @@ func (c *Client) RemoveAudioDecoderConfiguration(ctx context.Context, profileTok
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemoveAudioDecoderConfiguration failed: %w", err)
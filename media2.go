@@ -0,0 +1,922 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Media2 service namespace.
+const media2Namespace = "http://www.onvif.org/ver20/media/wsdl"
+
+// MediaVersion selects the media service version used by GetProfiles and GetStreamURI.
+type MediaVersion int
+
+// Media service versions.
+const (
+	// MediaVersionAuto reads profiles from Media2 when the device advertises
+	// it, falling back to Media (ver10) if Media2 is missing or the Media2
+	// request fails. Stream URIs are read from Media unless the device only
+	// has Media2.
+	MediaVersionAuto MediaVersion = iota
+	// MediaVersion1 always uses the Media (ver10) service.
+	MediaVersion1
+	// MediaVersion2 always uses the Media2 (ver20) service.
+	MediaVersion2
+)
+
+// WithMediaVersion pins the media service version used by GetProfiles and
+// GetStreamURI. The default, MediaVersionAuto, reads profiles from Media2,
+// which also lists H.265 encoder configurations, and stream URIs from Media. Media2 profiles have no encoding
+// interval and no encoder session timeout; pin MediaVersion1 where those are
+// needed.
+func WithMediaVersion(version MediaVersion) ClientOption {
+	return func(c *Client) {
+		c.mediaVersion = version
+	}
+}
+
+// getProfiles implements GetProfiles, routing to Media2 or Media according to
+// the advertised services and WithMediaVersion.
+func (c *Client) getProfiles(ctx context.Context) ([]*Profile, error) {
+	switch c.mediaVersion {
+	case MediaVersion1:
+		return c.getProfilesMedia1(ctx)
+	case MediaVersion2:
+		if c.media2Endpoint == "" {
+			return nil, fmt.Errorf("GetProfiles failed: Media2 %w", ErrServiceNotSupported)
+		}
+
+		return c.getProfilesMedia2(ctx)
+	case MediaVersionAuto:
+	}
+
+	if c.media2Endpoint == "" {
+		return c.getProfilesMedia1(ctx)
+	}
+
+	profiles, err := c.getProfilesMedia2(ctx)
+	if err != nil && ctx.Err() == nil {
+		c.logf("onvif: Media2 GetProfiles failed, falling back to Media: %v", err)
+
+		return c.getProfilesMedia1(ctx)
+	}
+
+	return profiles, err
+}
+
+// getStreamURI implements GetStreamURI. Unlike getProfiles it keeps to Media
+// (ver10) in MediaVersionAuto, because only Media reports how long a URI
+// stays valid; Media2 is used when pinned or when the device has no Media.
+func (c *Client) getStreamURI(ctx context.Context, profileToken string) (*MediaURI, error) {
+	switch c.mediaVersion {
+	case MediaVersion1:
+		return c.getStreamURIMedia1(ctx, profileToken, "RTSP")
+	case MediaVersion2:
+		if c.media2Endpoint == "" {
+			return nil, fmt.Errorf("GetStreamURI failed: Media2 %w", ErrServiceNotSupported)
+		}
+
+		return c.getStreamURIMedia2(ctx, profileToken, "RTSP")
+	case MediaVersionAuto:
+	}
+
+	if c.media2Endpoint != "" && c.mediaEndpoint == "" {
+		return c.getStreamURIMedia2(ctx, profileToken, "RTSP")
+	}
+
+	return c.getStreamURIMedia1(ctx, profileToken, "RTSP")
+}
+
+// getStreamURIMedia2 retrieves the stream URI for a profile from the Media2
+// service with the given protocol, e.g. RTSP or RtspsUnicast. Media2 returns
+// the bare URI, so it is marked InvalidAfterReboot to be safe.
+func (c *Client) getStreamURIMedia2(ctx context.Context, profileToken, protocol string) (*MediaURI, error) {
+	type GetStreamURI struct {
+		XMLName      xml.Name `xml:"tr2:GetStreamUri"`
+		Xmlns        string   `xml:"xmlns:tr2,attr"`
+		Protocol     string   `xml:"tr2:Protocol"`
+		ProfileToken string   `xml:"tr2:ProfileToken"`
+	}
+
+	type GetStreamURIResponse struct {
+		XMLName xml.Name `xml:"GetStreamUriResponse"`
+		URI     string   `xml:"Uri"`
+	}
+
+	req := GetStreamURI{
+		Xmlns:        media2Namespace,
+		Protocol:     protocol,
+		ProfileToken: profileToken,
+	}
+
+	var resp GetStreamURIResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetStreamURI failed: %w", err)
+	}
+
+	return &MediaURI{URI: resp.URI, InvalidAfterReboot: true}, nil
+}
+
+// getSecureStreamURI implements GetStreamURI for the encrypted protocols.
+// RTSPS is only defined by Media2; RTSP over HTTPS uses Media2 when available
+// and the HTTP transport of Media otherwise. The scheme of the returned URI
+// is checked, since devices without TLS answer with a plain URI.
+func (c *Client) getSecureStreamURI(ctx context.Context, profileToken string, protocol StreamProtocol) (*MediaURI, error) {
+	useMedia2 := c.media2Endpoint != "" && c.mediaVersion != MediaVersion1
+
+	var (
+		uri    *MediaURI
+		err    error
+		scheme string
+	)
+
+	switch protocol {
+	case StreamProtocolRTSPSTCP:
+		scheme = "rtsps"
+
+		if !useMedia2 {
+			return nil, fmt.Errorf("GetStreamURI failed: %w: RTSPS requires Media2", ErrSecureStreamingUnsupported)
+		}
+
+		secure, err := checkedCapabilities(ctx, c, media2Namespace, c.media2SecureRTSPStreaming)
+		if err != nil {
+			c.skipCheck("secure streaming capability check", err)
+		} else if !secure {
+			return nil, fmt.Errorf("GetStreamURI failed: %w: SecureRTSPStreaming capability not advertised",
+				ErrSecureStreamingUnsupported)
+		}
+
+		uri, err = c.getStreamURIMedia2(ctx, profileToken, "RtspsUnicast")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		scheme = "https"
+
+		if useMedia2 {
+			uri, err = c.getStreamURIMedia2(ctx, profileToken, "RtspOverHttp")
+		} else {
+			uri, err = c.getStreamURIMedia1(ctx, profileToken, "HTTP")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if u, err := url.Parse(uri.URI); err != nil || !strings.EqualFold(u.Scheme, scheme) {
+		return nil, fmt.Errorf("GetStreamURI failed: %w: device returned %q", ErrSecureStreamingUnsupported, uri.URI)
+	}
+
+	return uri, nil
+}
+
+// media2SecureRTSPStreaming reports whether the Media2 service advertises the
+// SecureRTSPStreaming capability.
+func (c *Client) media2SecureRTSPStreaming(ctx context.Context) (bool, error) {
+	type GetServiceCapabilities struct {
+		XMLName xml.Name `xml:"tr2:GetServiceCapabilities"`
+		Xmlns   string   `xml:"xmlns:tr2,attr"`
+	}
+
+	type GetServiceCapabilitiesResponse struct {
+		XMLName      xml.Name `xml:"GetServiceCapabilitiesResponse"`
+		Capabilities struct {
+			StreamingCapabilities *struct {
+				SecureRTSPStreaming Bool `xml:"SecureRTSPStreaming,attr"`
+			} `xml:"StreamingCapabilities"`
+		} `xml:"Capabilities"`
+	}
+
+	req := GetServiceCapabilities{
+		Xmlns: media2Namespace,
+	}
+
+	var resp GetServiceCapabilitiesResponse
+
+	if !c.cachedCapabilities(media2Namespace, &resp.Capabilities) {
+		soapClient := c.newSOAPClient()
+
+		if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+			return false, fmt.Errorf("GetServiceCapabilities failed: %w", err)
+		}
+	}
+
+	return resp.Capabilities.StreamingCapabilities != nil &&
+		bool(resp.Capabilities.StreamingCapabilities.SecureRTSPStreaming), nil
+}
+
+// getProfilesMedia2 retrieves all media profiles from the Media2 service.
+func (c *Client) getProfilesMedia2(ctx context.Context) ([]*Profile, error) {
+	type GetProfiles struct {
+		XMLName xml.Name `xml:"tr2:GetProfiles"`
+		Xmlns   string   `xml:"xmlns:tr2,attr"`
+		Type    []string `xml:"tr2:Type"`
+	}
+
+	type GetProfilesResponse struct {
+		XMLName  xml.Name `xml:"GetProfilesResponse"`
+		Profiles []struct {
+			Token          string `xml:"token,attr"`
+			Name           string `xml:"Name"`
+			Configurations struct {
+				VideoSource *struct {
+					Token       string `xml:"token,attr"`
+					Name        string `xml:"Name"`
+					UseCount    int    `xml:"UseCount"`
+					SourceToken string `xml:"SourceToken"`
+					Bounds      *struct {
+						X      int `xml:"x,attr"`
+						Y      int `xml:"y,attr"`
+						Width  int `xml:"width,attr"`
+						Height int `xml:"height,attr"`
+					} `xml:"Bounds"`
+				} `xml:"VideoSource"`
+				VideoEncoder *media2VideoEncoderResponse `xml:"VideoEncoder"`
+				AudioSource  *struct {
+					Token       string `xml:"token,attr"`
+					Name        string `xml:"Name"`
+					UseCount    int    `xml:"UseCount"`
+					SourceToken string `xml:"SourceToken"`
+				} `xml:"AudioSource"`
+				AudioEncoder *struct {
+					Token      string `xml:"token,attr"`
+					Name       string `xml:"Name"`
+					UseCount   int    `xml:"UseCount"`
+					Encoding   string `xml:"Encoding"`
+					Bitrate    int    `xml:"Bitrate"`
+					SampleRate int    `xml:"SampleRate"`
+				} `xml:"AudioEncoder"`
+				PTZ      *ptzConfigurationResponse      `xml:"PTZ"`
+				Metadata *metadataConfigurationResponse `xml:"Metadata"`
+			} `xml:"Configurations"`
+		} `xml:"Profiles"`
+	}
+
+	req := GetProfiles{
+		Xmlns: media2Namespace,
+		Type:  []string{"All"},
+	}
+
+	var resp GetProfilesResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetProfiles failed: %w", err)
+	}
+
+	profiles := make([]*Profile, len(resp.Profiles))
+	for i, p := range resp.Profiles {
+		profile := &Profile{
+			Token: p.Token,
+			Name:  p.Name,
+		}
+
+		if vs := p.Configurations.VideoSource; vs != nil {
+			profile.VideoSourceConfiguration = &VideoSourceConfiguration{
+				Token:       vs.Token,
+				Name:        vs.Name,
+				UseCount:    vs.UseCount,
+				SourceToken: vs.SourceToken,
+			}
+			if vs.Bounds != nil {
+				profile.VideoSourceConfiguration.Bounds = &IntRectangle{
+					X:      vs.Bounds.X,
+					Y:      vs.Bounds.Y,
+					Width:  vs.Bounds.Width,
+					Height: vs.Bounds.Height,
+				}
+			}
+		}
+
+		if ve := p.Configurations.VideoEncoder; ve != nil {
+			profile.VideoEncoderConfiguration = ve.toVideoEncoderConfiguration()
+		}
+
+		if as := p.Configurations.AudioSource; as != nil {
+			profile.AudioSourceConfiguration = &AudioSourceConfiguration{
+				Token:       as.Token,
+				Name:        as.Name,
+				UseCount:    as.UseCount,
+				SourceToken: as.SourceToken,
+			}
+		}
+
+		if ae := p.Configurations.AudioEncoder; ae != nil {
+			profile.AudioEncoderConfiguration = &AudioEncoderConfiguration{
+				Token:      ae.Token,
+				Name:       ae.Name,
+				UseCount:   ae.UseCount,
+				Encoding:   normalizeMedia2AudioEncoding(ae.Encoding),
+				Bitrate:    ae.Bitrate,
+				SampleRate: ae.SampleRate,
+			}
+		}
+
+		if ptz := p.Configurations.PTZ; ptz != nil {
+			profile.PTZConfiguration = ptz.toPTZConfiguration()
+		}
+
+		if metadata := p.Configurations.Metadata; metadata != nil {
+			profile.MetadataConfiguration = metadata.toMetadataConfiguration()
+		}
+
+		profiles[i] = profile
+	}
+
+	return profiles, nil
+}
+
+// GetProfiles2 retrieves all media profiles from the Media2 (ver20) service,
+// without falling back to Media (ver10). Encodings are reported under their
+// Media (ver10) names, as by GetProfiles. It returns ErrServiceNotSupported if
+// Initialize found no Media2 service.
+func (c *Client) GetProfiles2(ctx context.Context) ([]*Profile, error) {
+	if c.media2Endpoint == "" {
+		return nil, fmt.Errorf("GetProfiles failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	return c.getProfilesMedia2(ctx)
+}
+
+// GetStreamURI2 retrieves the URI of a profile's stream from the Media2
+// (ver20) service. Protocol is a Media2 transport protocol, e.g. RTSP,
+// RtspUnicast, RtspMulticast or RtspOverHttp. It returns
+// ErrServiceNotSupported if Initialize found no Media2 service.
+func (c *Client) GetStreamURI2(ctx context.Context, protocol, profileToken string) (string, error) {
+	if c.media2Endpoint == "" {
+		return "", fmt.Errorf("GetStreamURI failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	uri, err := c.getStreamURIMedia2(ctx, profileToken, protocol)
+	if err != nil {
+		return "", err
+	}
+
+	return uri.URI, nil
+}
+
+// GetVideoEncoderConfigurations2 retrieves all video encoder configurations
+// from the Media2 (ver20) service, which also lists H.265 configurations.
+// It returns ErrServiceNotSupported if Initialize found no Media2 service.
+func (c *Client) GetVideoEncoderConfigurations2(ctx context.Context) ([]*VideoEncoderConfiguration, error) {
+	if c.media2Endpoint == "" {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurations failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	type GetVideoEncoderConfigurations struct {
+		XMLName xml.Name `xml:"tr2:GetVideoEncoderConfigurations"`
+		Xmlns   string   `xml:"xmlns:tr2,attr"`
+	}
+
+	type GetVideoEncoderConfigurationsResponse struct {
+		XMLName        xml.Name                     `xml:"GetVideoEncoderConfigurationsResponse"`
+		Configurations []media2VideoEncoderResponse `xml:"Configurations"`
+	}
+
+	req := GetVideoEncoderConfigurations{
+		Xmlns: media2Namespace,
+	}
+
+	var resp GetVideoEncoderConfigurationsResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurations failed: %w", err)
+	}
+
+	configs := make([]*VideoEncoderConfiguration, len(resp.Configurations))
+	for i := range resp.Configurations {
+		configs[i] = resp.Configurations[i].toVideoEncoderConfiguration()
+	}
+
+	return configs, nil
+}
+
+// videoEncoderConfiguration2 returns the Media2 video encoder configuration
+// with the given token.
+func (c *Client) videoEncoderConfiguration2(ctx context.Context, token string) (*VideoEncoderConfiguration, error) {
+	configs, err := c.GetVideoEncoderConfigurations2(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		if config.Token == token {
+			return config, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: video encoder configuration %s", ErrNotFound, token)
+}
+
+// SetVideoEncoderConfiguration2 sets a video encoder configuration with the
+// Media2 (ver20) service, which also accepts H.265 configurations and the
+// ConstantBitRate and TargetBitrate rate control settings. The GovLength and
+// profile are taken from config.H264, config.H265 or config.MPEG4. The frame
+// rate is taken from RateControl.FrameRate as long as FrameRateLimit still
+// rounds to it, so fractional rates read from Media2 are written back as they
+// were. Media2 has no ForcePersistence, changes always persist. It returns
+// ErrServiceNotSupported if Initialize found no Media2 service.
+//
+// UseCount is managed by the device, so config.UseCount is ignored: the
+// current count is read from the device and sent back unchanged.
+func (c *Client) SetVideoEncoderConfiguration2(ctx context.Context, config *VideoEncoderConfiguration) error {
+	if c.media2Endpoint == "" {
+		return fmt.Errorf("SetVideoEncoderConfiguration failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	type SetVideoEncoderConfiguration struct {
+		XMLName       xml.Name `xml:"tr2:SetVideoEncoderConfiguration"`
+		Xmlns         string   `xml:"xmlns:tr2,attr"`
+		Xmlnst        string   `xml:"xmlns:tt,attr"`
+		Configuration struct {
+			Token      string `xml:"token,attr"`
+			GovLength  int    `xml:"GovLength,attr,omitempty"`
+			Profile    string `xml:"Profile,attr,omitempty"`
+			Name       string `xml:"tt:Name"`
+			UseCount   int    `xml:"tt:UseCount"`
+			Encoding   string `xml:"tt:Encoding"`
+			Resolution *struct {
+				Width  int `xml:"tt:Width"`
+				Height int `xml:"tt:Height"`
+			} `xml:"tt:Resolution,omitempty"`
+			RateControl *struct {
+				ConstantBitRate *bool   `xml:"ConstantBitRate,attr,omitempty"`
+				FrameRateLimit  float64 `xml:"tt:FrameRateLimit"`
+				BitrateLimit    int     `xml:"tt:BitrateLimit"`
+				TargetBitrate   int     `xml:"tt:TargetBitrate,omitempty"`
+			} `xml:"tt:RateControl,omitempty"`
+			Multicast *struct {
+				Address *struct {
+					Type        string `xml:"tt:Type"`
+					IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+					IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+				} `xml:"tt:Address,omitempty"`
+				Port      int  `xml:"tt:Port"`
+				TTL       int  `xml:"tt:TTL"`
+				AutoStart bool `xml:"tt:AutoStart"`
+			} `xml:"tt:Multicast,omitempty"`
+			Quality float64 `xml:"tt:Quality"`
+		} `xml:"tr2:Configuration"`
+	}
+
+	req := SetVideoEncoderConfiguration{
+		Xmlns:  media2Namespace,
+		Xmlnst: "http://www.onvif.org/ver10/schema",
+	}
+
+	req.Configuration.Token = config.Token
+	req.Configuration.Name = config.Name
+	req.Configuration.UseCount = deviceUseCount(ctx, c, "SetVideoEncoderConfiguration", config.Token, config.UseCount,
+		c.videoEncoderConfiguration2, func(current *VideoEncoderConfiguration) int { return current.UseCount })
+	req.Configuration.Encoding = media2Encoding(config.Encoding)
+	req.Configuration.Quality = config.Quality
+
+	switch {
+	case config.H264 != nil:
+		req.Configuration.GovLength = config.H264.GovLength
+		req.Configuration.Profile = config.H264.H264Profile
+	case config.H265 != nil:
+		req.Configuration.GovLength = config.H265.GovLength
+		req.Configuration.Profile = config.H265.H265Profile
+	case config.MPEG4 != nil:
+		req.Configuration.GovLength = config.MPEG4.GovLength
+		req.Configuration.Profile = config.MPEG4.MPEG4Profile
+	}
+
+	if config.Resolution != nil {
+		req.Configuration.Resolution = &struct {
+			Width  int `xml:"tt:Width"`
+			Height int `xml:"tt:Height"`
+		}{
+			Width:  config.Resolution.Width,
+			Height: config.Resolution.Height,
+		}
+	}
+
+	if config.RateControl != nil {
+		req.Configuration.RateControl = &struct {
+			ConstantBitRate *bool   `xml:"ConstantBitRate,attr,omitempty"`
+			FrameRateLimit  float64 `xml:"tt:FrameRateLimit"`
+			BitrateLimit    int     `xml:"tt:BitrateLimit"`
+			TargetBitrate   int     `xml:"tt:TargetBitrate,omitempty"`
+		}{
+			ConstantBitRate: config.RateControl.ConstantBitRate,
+			FrameRateLimit:  float64(config.RateControl.FrameRateLimit),
+			BitrateLimit:    config.RateControl.BitrateLimit,
+			TargetBitrate:   config.RateControl.TargetBitrate,
+		}
+
+		if frameRate := config.RateControl.FrameRate; frameRate > 0 && int(math.Round(frameRate)) == config.RateControl.FrameRateLimit {
+			req.Configuration.RateControl.FrameRateLimit = frameRate
+		}
+	}
+
+	if config.Multicast != nil {
+		req.Configuration.Multicast = &struct {
+			Address *struct {
+				Type        string `xml:"tt:Type"`
+				IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+				IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+			} `xml:"tt:Address,omitempty"`
+			Port      int  `xml:"tt:Port"`
+			TTL       int  `xml:"tt:TTL"`
+			AutoStart bool `xml:"tt:AutoStart"`
+		}{
+			Port:      config.Multicast.Port,
+			TTL:       config.Multicast.TTL,
+			AutoStart: config.Multicast.AutoStart,
+		}
+		if config.Multicast.Address != nil {
+			req.Configuration.Multicast.Address = &struct {
+				Type        string `xml:"tt:Type"`
+				IPv4Address string `xml:"tt:IPv4Address,omitempty"`
+				IPv6Address string `xml:"tt:IPv6Address,omitempty"`
+			}{
+				Type:        config.Multicast.Address.Type,
+				IPv4Address: config.Multicast.Address.IPv4Address,
+				IPv6Address: config.Multicast.Address.IPv6Address,
+			}
+		}
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetVideoEncoderConfiguration failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetVideoEncoderConfigurationOptions2 retrieves the video encoder options
+// from the Media2 (ver20) service for a configuration, a profile, or both; an
+// empty token is left out. Devices may report several options per encoding,
+// e.g. lower frame rates for higher resolutions. It returns
+// ErrServiceNotSupported if Initialize found no Media2 service.
+func (c *Client) GetVideoEncoderConfigurationOptions2(
+	ctx context.Context, configurationToken, profileToken string,
+) ([]*VideoEncoder2ConfigurationOptions, error) {
+	if c.media2Endpoint == "" {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurationOptions failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	type GetVideoEncoderConfigurationOptions struct {
+		XMLName            xml.Name `xml:"tr2:GetVideoEncoderConfigurationOptions"`
+		Xmlns              string   `xml:"xmlns:tr2,attr"`
+		ConfigurationToken string   `xml:"tr2:ConfigurationToken,omitempty"`
+		ProfileToken       string   `xml:"tr2:ProfileToken,omitempty"`
+	}
+
+	type GetVideoEncoderConfigurationOptionsResponse struct {
+		XMLName xml.Name `xml:"GetVideoEncoderConfigurationOptionsResponse"`
+		Options []struct {
+			GovLengthRange           string `xml:"GovLengthRange,attr"`
+			FrameRatesSupported      string `xml:"FrameRatesSupported,attr"`
+			ProfilesSupported        string `xml:"ProfilesSupported,attr"`
+			ConstantBitRateSupported Bool   `xml:"ConstantBitRateSupported,attr"`
+			Encoding                 string `xml:"Encoding"`
+			QualityRange             *struct {
+				Min float64 `xml:"Min"`
+				Max float64 `xml:"Max"`
+			} `xml:"QualityRange"`
+			ResolutionsAvailable []struct {
+				Width  int `xml:"Width"`
+				Height int `xml:"Height"`
+			} `xml:"ResolutionsAvailable"`
+			BitrateRange *struct {
+				Min int `xml:"Min"`
+				Max int `xml:"Max"`
+			} `xml:"BitrateRange"`
+		} `xml:"Options"`
+	}
+
+	req := GetVideoEncoderConfigurationOptions{
+		Xmlns:              media2Namespace,
+		ConfigurationToken: configurationToken,
+		ProfileToken:       profileToken,
+	}
+
+	var resp GetVideoEncoderConfigurationOptionsResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetVideoEncoderConfigurationOptions failed: %w", err)
+	}
+
+	options := make([]*VideoEncoder2ConfigurationOptions, len(resp.Options))
+	for i, o := range resp.Options {
+		option := &VideoEncoder2ConfigurationOptions{
+			Encoding:                 normalizeMedia2Encoding(o.Encoding),
+			ProfilesSupported:        strings.Fields(o.ProfilesSupported),
+			ConstantBitRateSupported: bool(o.ConstantBitRateSupported),
+		}
+
+		if o.QualityRange != nil {
+			option.QualityRange = &FloatRange{Min: o.QualityRange.Min, Max: o.QualityRange.Max}
+		}
+
+		for _, res := range o.ResolutionsAvailable {
+			option.ResolutionsAvailable = append(option.ResolutionsAvailable,
+				&VideoResolution{Width: res.Width, Height: res.Height})
+		}
+
+		if o.BitrateRange != nil {
+			option.BitrateRange = &IntRange{Min: o.BitrateRange.Min, Max: o.BitrateRange.Max}
+		}
+
+		// Unparsable list entries are skipped.
+		if govLengths := strings.Fields(o.GovLengthRange); len(govLengths) == 2 {
+			minGov, minErr := strconv.Atoi(govLengths[0])
+			maxGov, maxErr := strconv.Atoi(govLengths[1])
+
+			if minErr == nil && maxErr == nil {
+				option.GovLengthRange = &IntRange{Min: minGov, Max: maxGov}
+			}
+		}
+
+		for _, rate := range strings.Fields(o.FrameRatesSupported) {
+			if fps, err := strconv.ParseFloat(rate, 64); err == nil {
+				option.FrameRatesSupported = append(option.FrameRatesSupported, fps)
+			}
+		}
+
+		options[i] = option
+	}
+
+	return options, nil
+}
+
+// ConfigurationType is a kind of media configuration, named as in the Media2
+// ConfigurationEnumeration.
+type ConfigurationType string
+
+// Configuration types accepted by CreateProfile2.
+const (
+	ConfigurationVideoSource  ConfigurationType = "VideoSource"
+	ConfigurationVideoEncoder ConfigurationType = "VideoEncoder"
+	ConfigurationAudioSource  ConfigurationType = "AudioSource"
+	ConfigurationAudioEncoder ConfigurationType = "AudioEncoder"
+	ConfigurationAudioOutput  ConfigurationType = "AudioOutput"
+	ConfigurationAudioDecoder ConfigurationType = "AudioDecoder"
+	ConfigurationMetadata     ConfigurationType = "Metadata"
+	ConfigurationAnalytics    ConfigurationType = "Analytics"
+	ConfigurationPTZ          ConfigurationType = "PTZ"
+)
+
+// ConfigurationRef references a configuration to add to a profile.
+type ConfigurationRef struct {
+	Type  ConfigurationType
+	Token string
+}
+
+// CreateProfile2 creates a profile with the given configurations. On Media2
+// devices the profile is created in one CreateProfile call, so a rejected
+// configuration leaves no profile behind. Devices without Media2, or clients
+// pinned to MediaVersion1, create the profile with the Media (ver10)
+// CreateProfile and add the configurations one by one; if adding one fails,
+// the profile is deleted again. The returned profile holds the token and name.
+func (c *Client) CreateProfile2(ctx context.Context, name string, configs []ConfigurationRef) (*Profile, error) {
+	for _, config := range configs {
+		if c.addConfigurationFunc(config.Type) == nil {
+			return nil, fmt.Errorf("CreateProfile failed: %w: unknown configuration type %q", ErrInvalidParameter, config.Type)
+		}
+	}
+
+	if c.media2Endpoint == "" || c.mediaVersion == MediaVersion1 {
+		return c.createProfileMedia1(ctx, name, configs)
+	}
+
+	type Configuration struct {
+		Type  string `xml:"tr2:Type"`
+		Token string `xml:"tr2:Token,omitempty"`
+	}
+
+	type CreateProfile struct {
+		XMLName        xml.Name        `xml:"tr2:CreateProfile"`
+		Xmlns          string          `xml:"xmlns:tr2,attr"`
+		Name           string          `xml:"tr2:Name"`
+		Configurations []Configuration `xml:"tr2:Configuration"`
+	}
+
+	type CreateProfileResponse struct {
+		XMLName xml.Name `xml:"CreateProfileResponse"`
+		Token   string   `xml:"Token"`
+	}
+
+	req := CreateProfile{
+		Xmlns: media2Namespace,
+		Name:  name,
+	}
+
+	for _, config := range configs {
+		req.Configurations = append(req.Configurations, Configuration{Type: string(config.Type), Token: config.Token})
+	}
+
+	var resp CreateProfileResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, c.media2Endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("CreateProfile failed: %w", err)
+	}
+
+	return &Profile{Token: resp.Token, Name: name}, nil
+}
+
+// createProfileMedia1 implements CreateProfile2 with the Media (ver10) service.
+func (c *Client) createProfileMedia1(ctx context.Context, name string, configs []ConfigurationRef) (*Profile, error) {
+	profile, err := c.CreateProfile(ctx, name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		if err := c.addConfigurationFunc(config.Type)(ctx, profile.Token, config.Token); err != nil {
+			if deleteErr := c.DeleteProfile(ctx, profile.Token); deleteErr != nil {
+				c.logf("onvif: CreateProfile2: failed to delete partial profile %s: %v", profile.Token, deleteErr)
+			}
+
+			return nil, fmt.Errorf("failed to add %s configuration %q: %w", config.Type, config.Token, err)
+		}
+	}
+
+	return profile, nil
+}
+
+// addConfigurationFunc returns the Media (ver10) operation adding a
+// configuration of type t to a profile, or nil for an unknown type.
+func (c *Client) addConfigurationFunc(t ConfigurationType) func(ctx context.Context, profileToken, token string) error {
+	switch t {
+	case ConfigurationVideoSource:
+		return c.AddVideoSourceConfiguration
+	case ConfigurationVideoEncoder:
+		return c.AddVideoEncoderConfiguration
+	case ConfigurationAudioSource:
+		return c.AddAudioSourceConfiguration
+	case ConfigurationAudioEncoder:
+		return c.AddAudioEncoderConfiguration
+	case ConfigurationAudioOutput:
+		return c.AddAudioOutputConfiguration
+	case ConfigurationAudioDecoder:
+		return c.AddAudioDecoderConfiguration
+	case ConfigurationMetadata:
+		return c.AddMetadataConfiguration
+	case ConfigurationAnalytics:
+		return c.AddVideoAnalyticsConfiguration
+	case ConfigurationPTZ:
+		return c.AddPTZConfiguration
+	default:
+		return nil
+	}
+}
+
+// media2VideoEncoderResponse is the wire form of a Media2 video encoder
+// configuration received from the device.
+type media2VideoEncoderResponse struct {
+	Token      string `xml:"token,attr"`
+	GovLength  int    `xml:"GovLength,attr"`
+	Profile    string `xml:"Profile,attr"`
+	Name       string `xml:"Name"`
+	UseCount   int    `xml:"UseCount"`
+	Encoding   string `xml:"Encoding"`
+	Resolution *struct {
+		Width  int `xml:"Width"`
+		Height int `xml:"Height"`
+	} `xml:"Resolution"`
+	Quality     float64 `xml:"Quality"`
+	RateControl *struct {
+		ConstantBitRate *Bool   `xml:"ConstantBitRate,attr"`
+		FrameRateLimit  float64 `xml:"FrameRateLimit"`
+		BitrateLimit    int     `xml:"BitrateLimit"`
+		TargetBitrate   int     `xml:"TargetBitrate"`
+	} `xml:"RateControl"`
+	Multicast *struct {
+		Address *struct {
+			Type        string `xml:"Type"`
+			IPv4Address string `xml:"IPv4Address"`
+			IPv6Address string `xml:"IPv6Address"`
+		} `xml:"Address"`
+		Port      int  `xml:"Port"`
+		TTL       int  `xml:"TTL"`
+		AutoStart Bool `xml:"AutoStart"`
+	} `xml:"Multicast"`
+}
+
+// toVideoEncoderConfiguration converts a decoded configuration, mapping the
+// encoding to its Media (ver10) name.
+func (r *media2VideoEncoderResponse) toVideoEncoderConfiguration() *VideoEncoderConfiguration {
+	config := &VideoEncoderConfiguration{
+		Token:    r.Token,
+		Name:     r.Name,
+		UseCount: r.UseCount,
+		Encoding: normalizeMedia2Encoding(r.Encoding),
+		Quality:  r.Quality,
+	}
+
+	if r.Resolution != nil {
+		config.Resolution = &VideoResolution{
+			Width:  r.Resolution.Width,
+			Height: r.Resolution.Height,
+		}
+	}
+
+	if r.RateControl != nil {
+		rateControl := &VideoRateControl{
+			FrameRateLimit: int(math.Round(r.RateControl.FrameRateLimit)),
+			BitrateLimit:   r.RateControl.BitrateLimit,
+			TargetBitrate:  r.RateControl.TargetBitrate,
+			FrameRate:      r.RateControl.FrameRateLimit,
+		}
+		if r.RateControl.ConstantBitRate != nil {
+			constant := bool(*r.RateControl.ConstantBitRate)
+			rateControl.ConstantBitRate = &constant
+		}
+		config.RateControl = rateControl
+	}
+
+	if r.Multicast != nil {
+		config.Multicast = &MulticastConfiguration{
+			Port:      r.Multicast.Port,
+			TTL:       r.Multicast.TTL,
+			AutoStart: bool(r.Multicast.AutoStart),
+		}
+		if r.Multicast.Address != nil {
+			config.Multicast.Address = &IPAddress{
+				Type:        r.Multicast.Address.Type,
+				IPv4Address: r.Multicast.Address.IPv4Address,
+				IPv6Address: r.Multicast.Address.IPv6Address,
+			}
+		}
+	}
+
+	hasGov := r.GovLength != 0 || r.Profile != ""
+
+	switch {
+	case config.Encoding == "H264" && hasGov:
+		config.H264 = &H264Configuration{
+			GovLength:   r.GovLength,
+			H264Profile: r.Profile,
+		}
+	case config.Encoding == "H265" && hasGov:
+		config.H265 = &H265Configuration{
+			GovLength:   r.GovLength,
+			H265Profile: r.Profile,
+		}
+	case config.Encoding == "MPEG4" && hasGov:
+		config.MPEG4 = &MPEG4Configuration{
+			GovLength:    r.GovLength,
+			MPEG4Profile: r.Profile,
+		}
+	}
+
+	return config
+}
+
+// normalizeMedia2AudioEncoding maps Media2 audio encoding names, which are
+// MIME subtypes, to the names used by the Media (ver10) service.
+func normalizeMedia2AudioEncoding(encoding string) string {
+	switch strings.ToUpper(encoding) {
+	case "PCMU":
+		return "G711"
+	case "MP4A-LATM", "MPEG4-GENERIC":
+		return "AAC"
+	default:
+		return encoding
+	}
+}
+
+// media2Encoding maps Media (ver10) encoding names to their Media2 names, the
+// inverse of normalizeMedia2Encoding.
+func media2Encoding(encoding string) string {
+	switch encoding {
+	case "MPEG4":
+		return "MPV4-ES"
+	default:
+		return encoding
+	}
+}
+
+// normalizeMedia2Encoding maps Media2 encoding names, which are MIME subtypes,
+// to the names used by the Media (ver10) service. H265 has no ver10 name and
+// is kept as is.
+func normalizeMedia2Encoding(encoding string) string {
+	switch encoding {
+	case "MPV4-ES":
+		return "MPEG4"
+	default:
+		return encoding
+	}
+}
@@ -0,0 +1,665 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newMockMedia2Server serves Media2 on /media2 and Media on /media. When
+// media2Fault is set, Media2 requests are answered with a SOAP fault.
+func newMockMedia2Server(t *testing.T, media2Fault bool) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		var response string
+
+		switch {
+		case r.URL.Path == "/media2" && media2Fault:
+			w.WriteHeader(http.StatusBadRequest)
+			response = `<env:Fault><env:Code><env:Value>env:Receiver</env:Value>
+				<env:Subcode><env:Value>ter:ActionNotSupported</env:Value></env:Subcode></env:Code>
+				<env:Reason><env:Text>Not supported</env:Text></env:Reason></env:Fault>`
+		case r.URL.Path == "/media2" && strings.Contains(string(body), "tr2:GetProfiles"):
+			response = `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Profiles token="Profile_1" fixed="true"><tr2:Name>Main</tr2:Name><tr2:Configurations>
+					<tr2:VideoSource token="VSC_1"><tt:Name>VS</tt:Name><tt:SourceToken>VS_1</tt:SourceToken></tr2:VideoSource>
+					<tr2:VideoEncoder token="VEC_1" GovLength="50" Profile="Main"><tt:Name>H265</tt:Name><tt:Encoding>H265</tt:Encoding>
+						<tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution>
+						<tt:RateControl ConstantBitRate="1"><tt:FrameRateLimit>25.0</tt:FrameRateLimit><tt:BitrateLimit>8192</tt:BitrateLimit></tt:RateControl>
+					</tr2:VideoEncoder>
+					<tr2:AudioEncoder token="AEC_1"><tt:Name>Audio</tt:Name><tt:Encoding>MP4A-LATM</tt:Encoding>
+						<tt:Bitrate>64</tt:Bitrate><tt:SampleRate>16</tt:SampleRate></tr2:AudioEncoder>
+				</tr2:Configurations></tr2:Profiles>
+			</tr2:GetProfilesResponse>`
+		case r.URL.Path == "/media2" && strings.Contains(string(body), "tr2:GetVideoEncoderConfigurations"):
+			response = `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Configurations token="VEC_1"><tt:Name>H265</tt:Name><tt:Encoding>H265</tt:Encoding>
+					<tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution></tr2:Configurations>
+				<tr2:Configurations token="VEC_2" GovLength="30" Profile="High"><tt:Name>H264</tt:Name><tt:Encoding>H264</tt:Encoding></tr2:Configurations>
+			</tr2:GetVideoEncoderConfigurationsResponse>`
+		case r.URL.Path == "/media2" && strings.Contains(string(body), "tr2:GetStreamUri"):
+			response = `<tr2:GetStreamUriResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+				<tr2:Uri>rtsp://camera/media2</tr2:Uri></tr2:GetStreamUriResponse>`
+		case r.URL.Path == "/media" && strings.Contains(string(body), "trt:GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+				testStreamProfile("Profile_2", "Sub", "640", "360") + `</trt:GetProfilesResponse>`
+		case r.URL.Path == "/media" && strings.Contains(string(body), "trt:GetStreamUri"):
+			response = `<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri>rtsp://camera/media1</tt:Uri></trt:MediaUri></trt:GetStreamUriResponse>`
+		default:
+			t.Errorf("Unexpected request to %s: %s", r.URL.Path, body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error"><env:Body>` +
+			response + `</env:Body></env:Envelope>`))
+	}))
+}
+
+func TestGetProfilesMediaVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     MediaVersion
+		media2Fault bool
+		noMedia     bool
+		wantToken   string
+		wantURI     string
+	}{
+		{name: "auto prefers Media2 for profiles", version: MediaVersionAuto, wantToken: "Profile_1", wantURI: "rtsp://camera/media1"},
+		{name: "auto falls back to Media", version: MediaVersionAuto, media2Fault: true, wantToken: "Profile_2", wantURI: "rtsp://camera/media1"},
+		{name: "pinned to Media", version: MediaVersion1, wantToken: "Profile_2", wantURI: "rtsp://camera/media1"},
+		{name: "pinned to Media2", version: MediaVersion2, wantToken: "Profile_1", wantURI: "rtsp://camera/media2"},
+		{name: "auto without Media", version: MediaVersionAuto, noMedia: true, wantToken: "Profile_1", wantURI: "rtsp://camera/media2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockMedia2Server(t, tt.media2Fault)
+			defer server.Close()
+
+			client, err := NewClient(server.URL, WithMediaVersion(tt.version))
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			if !tt.noMedia {
+				client.mediaEndpoint = server.URL + "/media"
+			}
+
+			client.media2Endpoint = server.URL + "/media2"
+
+			ctx := context.Background()
+
+			profiles, err := client.GetProfiles(ctx)
+			if err != nil {
+				t.Fatalf("GetProfiles() failed: %v", err)
+			}
+
+			if len(profiles) != 1 || profiles[0].Token != tt.wantToken {
+				t.Fatalf("Unexpected profiles: %+v", profiles)
+			}
+
+			// Only the Media2 profile has a video source configuration.
+			source, err := client.VideoSourceTokenForProfile(ctx, tt.wantToken)
+			if tt.wantToken == "Profile_1" && (err != nil || source != "VS_1") {
+				t.Errorf("VideoSourceTokenForProfile() = %q, %v, want VS_1", source, err)
+			}
+
+			if tt.wantToken == "Profile_2" && !errors.Is(err, ErrNoVideoSource) {
+				t.Errorf("VideoSourceTokenForProfile() = %q, %v, want ErrNoVideoSource", source, err)
+			}
+
+			uri, err := client.GetStreamURI(ctx, tt.wantToken)
+			if err != nil {
+				t.Fatalf("GetStreamURI() failed: %v", err)
+			}
+
+			if uri.URI != tt.wantURI {
+				t.Errorf("URI = %s, want %s", uri.URI, tt.wantURI)
+			}
+
+			// Media2 does not report the URI validity.
+			if want := tt.wantURI == "rtsp://camera/media2"; uri.InvalidAfterReboot != want {
+				t.Errorf("InvalidAfterReboot = %v, want %v", uri.InvalidAfterReboot, want)
+			}
+		})
+	}
+}
+
+func TestGetProfilesMedia2Normalization(t *testing.T) {
+	server := newMockMedia2Server(t, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion2))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.GetProfiles(context.Background()); err == nil {
+		t.Fatal("Expected an error when Media2 is pinned but not advertised")
+	}
+
+	client.media2Endpoint = server.URL + "/media2"
+
+	profiles, err := client.GetProfiles(context.Background())
+	if err != nil {
+		t.Fatalf("GetProfiles() failed: %v", err)
+	}
+
+	encoder := profiles[0].VideoEncoderConfiguration
+	if encoder == nil || encoder.Encoding != "H265" || encoder.Resolution.Width != 3840 {
+		t.Fatalf("Unexpected video encoder: %+v", encoder)
+	}
+
+	if encoder.RateControl.FrameRateLimit != 25 || encoder.RateControl.BitrateLimit != 8192 {
+		t.Errorf("Unexpected rate control: %+v", encoder.RateControl)
+	}
+
+	if encoder.RateControl.ConstantBitRate == nil || !*encoder.RateControl.ConstantBitRate {
+		t.Error("Expected ConstantBitRate to be set")
+	}
+
+	if profiles[0].VideoSourceConfiguration == nil || profiles[0].VideoSourceConfiguration.SourceToken != "VS_1" {
+		t.Errorf("Unexpected video source: %+v", profiles[0].VideoSourceConfiguration)
+	}
+
+	if audio := profiles[0].AudioEncoderConfiguration; audio == nil || audio.Token != "AEC_1" ||
+		audio.Encoding != "AAC" || audio.Bitrate != 64 || audio.SampleRate != 16 {
+		t.Errorf("Unexpected audio encoder: %+v", audio)
+	}
+
+	if profiles[0].AudioSourceConfiguration != nil {
+		t.Errorf("Expected no audio source, got %+v", profiles[0].AudioSourceConfiguration)
+	}
+}
+
+func TestGetStreamURISecureProtocols(t *testing.T) {
+	tests := []struct {
+		name         string
+		protocol     StreamProtocol
+		media2       bool
+		secureCap    bool
+		uri          string
+		wantProtocol string
+		wantErr      error
+	}{
+		{name: "RTSPS", protocol: StreamProtocolRTSPSTCP, media2: true, secureCap: true,
+			uri: "rtsps://camera/secure", wantProtocol: "<tr2:Protocol>RtspsUnicast</tr2:Protocol>"},
+		{name: "RTSPS not advertised", protocol: StreamProtocolRTSPSTCP, media2: true, wantErr: ErrSecureStreamingUnsupported},
+		{name: "RTSPS without Media2", protocol: StreamProtocolRTSPSTCP, wantErr: ErrSecureStreamingUnsupported},
+		{name: "RTSP over HTTPS with Media2", protocol: StreamProtocolRTSPOverHTTPS, media2: true,
+			uri: "https://camera/tunnel", wantProtocol: "<tr2:Protocol>RtspOverHttp</tr2:Protocol>"},
+		{name: "RTSP over HTTPS with Media", protocol: StreamProtocolRTSPOverHTTPS,
+			uri: "https://camera/tunnel", wantProtocol: "<tt:Protocol>HTTP</tt:Protocol>"},
+		{name: "plain URI returned", protocol: StreamProtocolRTSPOverHTTPS,
+			uri: "http://camera/tunnel", wantErr: ErrSecureStreamingUnsupported},
+		{name: "unknown protocol", protocol: "Carrier pigeon", wantErr: ErrInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var streamRequest string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				var response string
+
+				switch {
+				case strings.Contains(string(body), "GetServiceCapabilities"):
+					response = `<tr2:GetServiceCapabilitiesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+						<tr2:Capabilities><tr2:StreamingCapabilities RTSPStreaming="true" SecureRTSPStreaming="` +
+						map[bool]string{true: "true", false: "false"}[tt.secureCap] + `"/></tr2:Capabilities>
+					</tr2:GetServiceCapabilitiesResponse>`
+				case strings.Contains(string(body), "tr2:GetStreamUri"):
+					streamRequest = string(body)
+					response = `<tr2:GetStreamUriResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:Uri>` +
+						tt.uri + `</tr2:Uri></tr2:GetStreamUriResponse>`
+				case strings.Contains(string(body), "trt:GetStreamUri"):
+					streamRequest = string(body)
+					response = `<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+						<trt:MediaUri><tt:Uri>` + tt.uri + `</tt:Uri></trt:MediaUri></trt:GetStreamUriResponse>`
+				default:
+					t.Errorf("Unexpected request: %s", body)
+				}
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+				_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			if tt.media2 {
+				client.media2Endpoint = server.URL + "/media2"
+			}
+
+			uri, err := client.GetStreamURI(context.Background(), "Profile_1", WithStreamProtocol(tt.protocol))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("GetStreamURI() failed: %v", err)
+			}
+
+			if uri.URI != tt.uri {
+				t.Errorf("Expected URI %s, got %s", tt.uri, uri.URI)
+			}
+
+			if !strings.Contains(streamRequest, tt.wantProtocol) {
+				t.Errorf("Request missing %s: %s", tt.wantProtocol, streamRequest)
+			}
+		})
+	}
+}
+
+func TestGetProfilesMedia1AndMedia2Agree(t *testing.T) {
+	// The same profile as reported by the Media and the Media2 service.
+	const (
+		videoSource = `<tt:Name>VS</tt:Name><tt:UseCount>2</tt:UseCount><tt:SourceToken>VS_1</tt:SourceToken>
+			<tt:Bounds x="0" y="0" width="1920" height="1080"/>`
+		videoEncoder = `<tt:Name>Main</tt:Name><tt:UseCount>1</tt:UseCount>
+			<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>`
+		audioEncoder = `<tt:Name>Audio</tt:Name><tt:UseCount>1</tt:UseCount><tt:Bitrate>64</tt:Bitrate><tt:SampleRate>8</tt:SampleRate>`
+		ptz          = `<tt:Name>PTZ</tt:Name><tt:UseCount>1</tt:UseCount><tt:NodeToken>Node_1</tt:NodeToken>
+			<tt:DefaultPTZTimeout>PT5S</tt:DefaultPTZTimeout>`
+		metadata = `<tt:Name>Metadata</tt:Name><tt:UseCount>1</tt:UseCount>
+			<tt:PTZStatus><tt:Status>true</tt:Status><tt:Position>true</tt:Position></tt:PTZStatus>
+			<tt:Analytics>true</tt:Analytics>
+			<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>239.0.0.1</tt:IPv4Address></tt:Address>
+				<tt:Port>5000</tt:Port><tt:TTL>1</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>
+			<tt:SessionTimeout>PT60S</tt:SessionTimeout>`
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "trt:GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1">` + videoSource + `</tt:VideoSourceConfiguration>
+					<tt:VideoEncoderConfiguration token="VEC_1">` + videoEncoder + `<tt:Encoding>MPEG4</tt:Encoding>
+						<tt:Quality>5</tt:Quality>
+						<tt:RateControl><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:EncodingInterval>1</tt:EncodingInterval>
+							<tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+					<tt:AudioEncoderConfiguration token="AEC_1">` + audioEncoder + `<tt:Encoding>G711</tt:Encoding></tt:AudioEncoderConfiguration>
+					<tt:PTZConfiguration token="PTZ_1">` + ptz + `</tt:PTZConfiguration>
+					<tt:MetadataConfiguration token="MD_1">` + metadata + `</tt:MetadataConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "tr2:GetProfiles"):
+			response = `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Profiles token="Profile_1"><tr2:Name>Main</tr2:Name><tr2:Configurations>
+					<tr2:VideoSource token="VSC_1">` + videoSource + `</tr2:VideoSource>
+					<tr2:VideoEncoder token="VEC_1">` + videoEncoder + `<tt:Encoding>MPV4-ES</tt:Encoding>
+						<tt:RateControl><tt:FrameRateLimit>25.0</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+						<tt:Quality>5</tt:Quality></tr2:VideoEncoder>
+					<tr2:AudioEncoder token="AEC_1">` + audioEncoder + `<tt:Encoding>PCMU</tt:Encoding></tr2:AudioEncoder>
+					<tr2:PTZ token="PTZ_1">` + ptz + `</tr2:PTZ>
+					<tr2:Metadata token="MD_1">` + metadata + `</tr2:Metadata>
+				</tr2:Configurations></tr2:Profiles>
+			</tr2:GetProfilesResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.mediaEndpoint = server.URL + "/media"
+	client.media2Endpoint = server.URL + "/media2"
+
+	ctx := context.Background()
+
+	media1, err := client.getProfilesMedia1(ctx)
+	if err != nil {
+		t.Fatalf("getProfilesMedia1() failed: %v", err)
+	}
+
+	media2, err := client.GetProfiles2(ctx)
+	if err != nil {
+		t.Fatalf("GetProfiles2() failed: %v", err)
+	}
+
+	if len(media1) != 1 || len(media2) != 1 {
+		t.Fatalf("Expected one profile from each service, got %d and %d", len(media1), len(media2))
+	}
+
+	// Media2 has no encoding interval.
+	if media1[0].VideoEncoderConfiguration.RateControl.EncodingInterval != 1 {
+		t.Errorf("Expected the Media encoding interval, got %+v", media1[0].VideoEncoderConfiguration.RateControl)
+	}
+
+	// Media has no fractional frame rate.
+	if media2[0].VideoEncoderConfiguration.RateControl.FrameRate != 25 {
+		t.Errorf("Expected the Media2 frame rate, got %+v", media2[0].VideoEncoderConfiguration.RateControl)
+	}
+
+	media1[0].VideoEncoderConfiguration.RateControl.EncodingInterval = 0
+	media2[0].VideoEncoderConfiguration.RateControl.FrameRate = 0
+
+	if !reflect.DeepEqual(media1[0], media2[0]) {
+		t.Errorf("Media and Media2 profiles differ:\nMedia:  %+v\nMedia2: %+v", media1[0], media2[0])
+	}
+
+	if media2[0].MetadataConfiguration == nil || media2[0].MetadataConfiguration.Multicast == nil {
+		t.Errorf("Expected the Media2 metadata configuration, got %+v", media2[0].MetadataConfiguration)
+	}
+}
+
+func TestMedia2Methods(t *testing.T) {
+	server := newMockMedia2Server(t, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.GetProfiles2(ctx); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported without Media2, got %v", err)
+	}
+
+	client.media2Endpoint = server.URL + "/media2"
+
+	profiles, err := client.GetProfiles2(ctx)
+	if err != nil {
+		t.Fatalf("GetProfiles2() failed: %v", err)
+	}
+
+	if len(profiles) != 1 || profiles[0].Token != "Profile_1" {
+		t.Errorf("Unexpected profiles: %+v", profiles)
+	}
+
+	uri, err := client.GetStreamURI2(ctx, "RtspUnicast", "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI2() failed: %v", err)
+	}
+
+	if uri != "rtsp://camera/media2" {
+		t.Errorf("URI = %s, want rtsp://camera/media2", uri)
+	}
+
+	configs, err := client.GetVideoEncoderConfigurations2(ctx)
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurations2() failed: %v", err)
+	}
+
+	if len(configs) != 2 || configs[0].Encoding != "H265" || configs[0].Resolution == nil ||
+		configs[0].Resolution.Width != 3840 {
+		t.Fatalf("Unexpected configurations: %+v", configs)
+	}
+
+	if configs[1].H264 == nil || configs[1].H264.GovLength != 30 || configs[1].H264.H264Profile != "High" {
+		t.Errorf("Unexpected H264 settings: %+v", configs[1].H264)
+	}
+}
+
+func TestVideoEncoderConfiguration2RateControl(t *testing.T) {
+	var setBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurations"):
+			response = `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Configurations token="VEC_1" GovLength="50" Profile="High"><tt:Name>Main</tt:Name><tt:UseCount>2</tt:UseCount><tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+					<tt:RateControl ConstantBitRate="false"><tt:FrameRateLimit>25</tt:FrameRateLimit>
+						<tt:BitrateLimit>4096</tt:BitrateLimit><tt:TargetBitrate>2048</tt:TargetBitrate></tt:RateControl>
+					<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>239.0.0.2</tt:IPv4Address></tt:Address>
+						<tt:Port>6000</tt:Port><tt:TTL>2</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>
+					<tt:Quality>5</tt:Quality></tr2:Configurations>
+			</tr2:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "tr2:SetVideoEncoderConfiguration"):
+			setBody = string(body)
+			response = `<tr2:SetVideoEncoderConfigurationResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := client.SetVideoEncoderConfiguration2(ctx, &VideoEncoderConfiguration{}); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("Expected ErrServiceNotSupported without Media2, got %v", err)
+	}
+
+	client.media2Endpoint = server.URL + "/media2"
+
+	configs, err := client.GetVideoEncoderConfigurations2(ctx)
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurations2() failed: %v", err)
+	}
+
+	rateControl := configs[0].RateControl
+	if rateControl == nil || rateControl.ConstantBitRate == nil || *rateControl.ConstantBitRate ||
+		rateControl.BitrateLimit != 4096 || rateControl.TargetBitrate != 2048 {
+		t.Fatalf("Unexpected rate control: %+v", rateControl)
+	}
+
+	if m := configs[0].Multicast; m == nil || m.Address == nil || m.Address.IPv4Address != "239.0.0.2" || m.Port != 6000 {
+		t.Fatalf("Unexpected multicast: %+v", configs[0].Multicast)
+	}
+
+	if err := client.SetVideoEncoderConfiguration2(ctx, configs[0]); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration2() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<tt:IPv4Address>239.0.0.2</tt:IPv4Address>`,
+		`<tt:Port>6000</tt:Port>`,
+		`<tt:AutoStart>false</tt:AutoStart>`,
+		`<tr2:Configuration token="VEC_1" GovLength="50" Profile="High">`,
+		`<tt:UseCount>2</tt:UseCount>`,
+		`<tt:RateControl ConstantBitRate="false">`,
+		`<tt:FrameRateLimit>25</tt:FrameRateLimit>`,
+		`<tt:BitrateLimit>4096</tt:BitrateLimit>`,
+		`<tt:TargetBitrate>2048</tt:TargetBitrate>`,
+		`<tt:Quality>5</tt:Quality>`,
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("SetVideoEncoderConfiguration request missing %s: %s", want, setBody)
+		}
+	}
+
+	constant := true
+	configs[0].RateControl.ConstantBitRate = &constant
+	configs[0].RateControl.TargetBitrate = 0
+
+	if err := client.SetVideoEncoderConfiguration2(ctx, configs[0]); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration2() failed: %v", err)
+	}
+
+	if !strings.Contains(setBody, `<tt:RateControl ConstantBitRate="true">`) || strings.Contains(setBody, "TargetBitrate") {
+		t.Errorf("Unexpected rate control in request: %s", setBody)
+	}
+}
+
+func TestVideoEncoderConfiguration2H265RoundTrip(t *testing.T) {
+	var setBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurations"):
+			response = `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Configurations token="VEC_1" GovLength="25" Profile="Main10"><tt:Name>Main</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H265</tt:Encoding>
+					<tt:Resolution><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:Resolution>
+					<tt:RateControl><tt:FrameRateLimit>12.5</tt:FrameRateLimit><tt:BitrateLimit>8192</tt:BitrateLimit></tt:RateControl>
+					<tt:Quality>4</tt:Quality></tr2:Configurations>
+			</tr2:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "tr2:SetVideoEncoderConfiguration"):
+			setBody = string(body)
+			response = `<tr2:SetVideoEncoderConfigurationResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.media2Endpoint = server.URL + "/media2"
+
+	ctx := context.Background()
+
+	configs, err := client.GetVideoEncoderConfigurations2(ctx)
+	if err != nil {
+		t.Fatalf("GetVideoEncoderConfigurations2() failed: %v", err)
+	}
+
+	config := configs[0]
+	if config.H265 == nil || config.H265.GovLength != 25 || config.H265.H265Profile != "Main10" {
+		t.Fatalf("Unexpected H265 configuration: %+v", config.H265)
+	}
+
+	if config.RateControl.FrameRate != 12.5 || config.RateControl.FrameRateLimit != 13 {
+		t.Fatalf("Unexpected rate control: %+v", config.RateControl)
+	}
+
+	if err := client.SetVideoEncoderConfiguration2(ctx, config); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration2() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<tr2:Configuration token="VEC_1" GovLength="25" Profile="Main10">`,
+		`<tt:Encoding>H265</tt:Encoding>`,
+		`<tt:FrameRateLimit>12.5</tt:FrameRateLimit>`,
+	} {
+		if !strings.Contains(setBody, want) {
+			t.Errorf("SetVideoEncoderConfiguration request missing %s: %s", want, setBody)
+		}
+	}
+
+	// A changed FrameRateLimit takes precedence over the decoded frame rate.
+	config.RateControl.FrameRateLimit = 10
+
+	if err := client.SetVideoEncoderConfiguration2(ctx, config); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration2() failed: %v", err)
+	}
+
+	if !strings.Contains(setBody, `<tt:FrameRateLimit>10</tt:FrameRateLimit>`) {
+		t.Errorf("Expected the changed frame rate limit: %s", setBody)
+	}
+}
+func TestCreateProfile2(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path+" "+string(body))
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "tr2:CreateProfile"):
+			response = `<tr2:CreateProfileResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"><tr2:Token>Profile_9</tr2:Token></tr2:CreateProfileResponse>`
+		case strings.Contains(string(body), "trt:CreateProfile"):
+			response = `<trt:CreateProfileResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profile token="Profile_1"><tt:Name>New</tt:Name></trt:Profile></trt:CreateProfileResponse>`
+		case strings.Contains(string(body), "AddVideoEncoderConfiguration"):
+			w.WriteHeader(http.StatusInternalServerError)
+			response = `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:ConfigurationConflict</soap:Value></soap:Subcode></soap:Code>
+				<soap:Reason><soap:Text>Conflict</soap:Text></soap:Reason></soap:Fault>`
+		case strings.Contains(string(body), "AddVideoSourceConfiguration"),
+			strings.Contains(string(body), "DeleteProfile"):
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	configs := []ConfigurationRef{
+		{Type: ConfigurationVideoSource, Token: "VSC_1"},
+		{Type: ConfigurationVideoEncoder, Token: "VEC_1"},
+	}
+
+	if _, err := client.CreateProfile2(ctx, "New", []ConfigurationRef{{Type: "Bogus"}}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an unknown type, got %v", err)
+	}
+
+	// Without Media2 the profile is built with ver10 and removed on failure.
+	if _, err := client.CreateProfile2(ctx, "New", configs); err == nil {
+		t.Fatal("Expected CreateProfile2 to fail")
+	}
+
+	if len(requests) != 4 || !strings.Contains(requests[3], "DeleteProfile") || !strings.Contains(requests[3], "Profile_1") {
+		t.Errorf("Expected the partial profile to be deleted, got %d requests", len(requests))
+	}
+
+	requests = nil
+	client.media2Endpoint = server.URL + "/media2"
+
+	profile, err := client.CreateProfile2(ctx, "New", configs)
+	if err != nil {
+		t.Fatalf("CreateProfile2() failed: %v", err)
+	}
+
+	if profile.Token != "Profile_9" || profile.Name != "New" {
+		t.Errorf("Unexpected profile: %+v", profile)
+	}
+
+	if len(requests) != 1 || !strings.HasPrefix(requests[0], "/media2 ") ||
+		strings.Count(requests[0], "<tr2:Configuration>") != 2 ||
+		!strings.Contains(requests[0], "<tr2:Type>VideoEncoder</tr2:Type>") {
+		t.Errorf("Unexpected Media2 requests: %v", requests)
+	}
+}
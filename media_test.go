@@ -2,10 +2,14 @@ package onvif
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestGetProfiles tests GetProfiles operation.
@@ -25,6 +29,19 @@ func TestGetProfiles(t *testing.T) {
 					</tt:Resolution>
 					<tt:Quality>5.0</tt:Quality>
 				</tt:VideoEncoderConfiguration>
+				<tt:AudioSourceConfiguration xmlns:tt="http://www.onvif.org/ver10/schema" token="AudioSrc1">
+					<tt:Name>Mic</tt:Name>
+					<tt:SourceToken>AudioIn1</tt:SourceToken>
+				</tt:AudioSourceConfiguration>
+				<tt:AudioEncoderConfiguration xmlns:tt="http://www.onvif.org/ver10/schema" token="AudioEnc1">
+					<tt:Name>Audio</tt:Name>
+					<tt:Encoding>G711</tt:Encoding>
+					<tt:Bitrate>64</tt:Bitrate>
+					<tt:SampleRate>8</tt:SampleRate>
+				</tt:AudioEncoderConfiguration>
+			</trt:Profiles>
+			<trt:Profiles token="Profile2">
+				<tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">Silent Profile</tt:Name>
 			</trt:Profiles>
 		</trt:GetProfilesResponse>
 	</soap:Body>
@@ -46,8 +63,8 @@ func TestGetProfiles(t *testing.T) {
 		t.Fatalf("GetProfiles() failed: %v", err)
 	}
 
-	if len(profiles) != 1 {
-		t.Errorf("Expected 1 profile, got %d", len(profiles))
+	if len(profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, got %d", len(profiles))
 	}
 
 	if profiles[0].Token != "Profile1" {
@@ -57,6 +74,20 @@ func TestGetProfiles(t *testing.T) {
 	if profiles[0].Name != "Main Profile" {
 		t.Errorf("Expected name 'Main Profile', got %s", profiles[0].Name)
 	}
+
+	if source := profiles[0].AudioSourceConfiguration; source == nil || source.Token != "AudioSrc1" ||
+		source.SourceToken != "AudioIn1" {
+		t.Errorf("Unexpected audio source: %+v", source)
+	}
+
+	if encoder := profiles[0].AudioEncoderConfiguration; encoder == nil || encoder.Token != "AudioEnc1" ||
+		encoder.Name != "Audio" || encoder.Encoding != "G711" || encoder.Bitrate != 64 || encoder.SampleRate != 8 {
+		t.Errorf("Unexpected audio encoder: %+v", encoder)
+	}
+
+	if profiles[1].AudioSourceConfiguration != nil || profiles[1].AudioEncoderConfiguration != nil {
+		t.Error("Expected no audio configurations for a profile without audio")
+	}
 }
 
 // TestGetProfile tests GetProfile operation.
@@ -66,8 +97,23 @@ func TestGetProfile(t *testing.T) {
 <soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
 	<soap:Body>
 		<trt:GetProfileResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
-			<trt:Profile token="Profile1">
-				<tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">Main Profile</tt:Name>
+			<trt:Profile token="Profile1" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tt:Name>Main Profile</tt:Name>
+				<tt:VideoSourceConfiguration token="VSC1">
+					<tt:Name>Source</tt:Name>
+					<tt:SourceToken>VS1</tt:SourceToken>
+					<tt:Bounds x="0" y="0" width="1920" height="1080"/>
+				</tt:VideoSourceConfiguration>
+				<tt:AudioSourceConfiguration token="ASC1"><tt:Name>Mic</tt:Name><tt:SourceToken>AS1</tt:SourceToken></tt:AudioSourceConfiguration>
+				<tt:VideoEncoderConfiguration token="VEC1">
+					<tt:Name>Encoder</tt:Name>
+					<tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+					<tt:RateControl><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+				</tt:VideoEncoderConfiguration>
+				<tt:AudioEncoderConfiguration token="AEC1"><tt:Name>Audio</tt:Name><tt:Encoding>G711</tt:Encoding></tt:AudioEncoderConfiguration>
+				<tt:PTZConfiguration token="PTZ1"><tt:Name>PTZ</tt:Name><tt:NodeToken>Node1</tt:NodeToken></tt:PTZConfiguration>
+				<tt:MetadataConfiguration token="MD1"><tt:Name>Metadata</tt:Name><tt:Analytics>true</tt:Analytics></tt:MetadataConfiguration>
 			</trt:Profile>
 		</trt:GetProfileResponse>
 	</soap:Body>
@@ -92,6 +138,74 @@ func TestGetProfile(t *testing.T) {
 	if profile.Token != "Profile1" {
 		t.Errorf("Expected token Profile1, got %s", profile.Token)
 	}
+
+	if source := profile.VideoSourceConfiguration; source == nil || source.SourceToken != "VS1" ||
+		source.Bounds == nil || source.Bounds.Width != 1920 {
+		t.Errorf("Unexpected video source configuration: %+v", source)
+	}
+
+	if encoder := profile.VideoEncoderConfiguration; encoder == nil || encoder.Encoding != "H264" ||
+		encoder.Resolution == nil || encoder.RateControl == nil || encoder.RateControl.FrameRateLimit != 25 {
+		t.Errorf("Unexpected video encoder configuration: %+v", encoder)
+	}
+
+	if profile.AudioSourceConfiguration == nil || profile.AudioEncoderConfiguration == nil ||
+		profile.AudioEncoderConfiguration.Encoding != "G711" {
+		t.Errorf("Unexpected audio configurations: %+v %+v", profile.AudioSourceConfiguration, profile.AudioEncoderConfiguration)
+	}
+
+	if profile.PTZConfiguration == nil || profile.PTZConfiguration.NodeToken != "Node1" {
+		t.Errorf("Unexpected PTZ configuration: %+v", profile.PTZConfiguration)
+	}
+
+	if profile.MetadataConfiguration == nil || profile.MetadataConfiguration.Token != "MD1" ||
+		!profile.MetadataConfiguration.Analytics {
+		t.Errorf("Unexpected metadata configuration: %+v", profile.MetadataConfiguration)
+	}
+}
+
+// TestVideoSourceTokenForProfile tests the profile to video source mapping.
+func TestVideoSourceTokenForProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if !strings.Contains(string(body), "GetProfiles") {
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` +
+			`<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+			`<trt:Profiles token="Profile1"><tt:Name>Main</tt:Name>` +
+			`<tt:VideoSourceConfiguration token="VSC1"><tt:Name>Source</tt:Name>` +
+			`<tt:SourceToken>VS1</tt:SourceToken></tt:VideoSourceConfiguration></trt:Profiles>` +
+			`<trt:Profiles token="AudioOnly"><tt:Name>Audio</tt:Name></trt:Profiles>` +
+			`</trt:GetProfilesResponse></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	token, err := client.VideoSourceTokenForProfile(ctx, "Profile1")
+	if err != nil {
+		t.Fatalf("VideoSourceTokenForProfile() failed: %v", err)
+	}
+
+	if token != "VS1" {
+		t.Errorf("Expected video source VS1, got %s", token)
+	}
+
+	if _, err := client.VideoSourceTokenForProfile(ctx, "AudioOnly"); !errors.Is(err, ErrNoVideoSource) {
+		t.Errorf("Expected ErrNoVideoSource, got %v", err)
+	}
+
+	if _, err := client.VideoSourceTokenForProfile(ctx, "Missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
 }
 
 // TestSetProfile tests SetProfile operation.
@@ -120,6 +234,65 @@ func TestSetProfile(t *testing.T) {
 	}
 }
 
+// TestRenameProfile tests that RenameProfile and SetProfile only send the name.
+func TestRenameProfile(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><trt:SetProfileResponse/></soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	var logged []string
+
+	client, err := NewClient(server.URL, WithLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := client.RenameProfile(ctx, "Profile1", "Lobby"); err != nil {
+		t.Fatalf("RenameProfile() failed: %v", err)
+	}
+
+	if !strings.Contains(requests[0], `<trt:Profile token="Profile1">`) || !strings.Contains(requests[0], "<tt:Name>Lobby</tt:Name>") {
+		t.Errorf("Unexpected RenameProfile request: %s", requests[0])
+	}
+
+	if err := client.RenameProfile(ctx, "Profile1", ""); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for empty name, got %v", err)
+	}
+
+	if len(logged) != 0 {
+		t.Errorf("Expected no warnings, got %v", logged)
+	}
+
+	profile := &Profile{
+		Token:                     "Profile1",
+		Name:                      "Lobby",
+		VideoEncoderConfiguration: &VideoEncoderConfiguration{Token: "VEC_1"},
+	}
+
+	if err := client.SetProfile(ctx, profile); err != nil {
+		t.Fatalf("SetProfile() failed: %v", err)
+	}
+
+	if strings.Contains(requests[len(requests)-1], "VEC_1") {
+		t.Error("SetProfile must not send configurations")
+	}
+
+	if len(logged) != 1 {
+		t.Errorf("Expected a warning about unsent configurations, got %v", logged)
+	}
+}
+
 // TestGetStreamURI tests GetStreamURI operation.
 func TestGetStreamURI(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -338,6 +511,42 @@ func TestCreateProfile(t *testing.T) {
 	}
 }
 
+func TestCreateProfileTokenExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<soap:Body>
+		<soap:Fault>
+			<soap:Code>
+				<soap:Value>soap:Sender</soap:Value>
+				<soap:Subcode><soap:Value>ter:InvalidArgVal</soap:Value>
+					<soap:Subcode><soap:Value>ter:ProfileExists</soap:Value></soap:Subcode>
+				</soap:Subcode>
+			</soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">Profile token already exists</soap:Text></soap:Reason>
+		</soap:Fault>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	_, err = client.CreateProfile(context.Background(), "Provisioned", "Profile_1")
+	if !errors.Is(err, ErrProfileTokenExists) {
+		t.Fatalf("Expected ErrProfileTokenExists, got %v", err)
+	}
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected the fault classification to be kept, got %v", err)
+	}
+}
+
 // TestDeleteProfile tests DeleteProfile operation.
 func TestDeleteProfile(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -436,6 +645,90 @@ func TestSetVideoEncoderConfiguration(t *testing.T) {
 	}
 }
 
+// TestSetConfigurationUseCount tests that setters send the device's UseCount.
+func TestSetConfigurationUseCount(t *testing.T) {
+	var request string
+
+	getFails := false
+	gets := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetVideoEncoderConfiguration") && getFails:
+			gets++
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.Contains(string(body), "GetVideoEncoderConfiguration"):
+			gets++
+			response = `<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VideoEnc1"><tt:Name>H264 Config</tt:Name><tt:UseCount>3</tt:UseCount>
+					<tt:Encoding>H264</tt:Encoding></trt:Configuration>
+			</trt:GetVideoEncoderConfigurationResponse>`
+		case strings.Contains(string(body), "SetVideoEncoderConfiguration"):
+			request = string(body)
+			response = `<trt:SetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	var logs []string
+
+	client, err := NewClient(server.URL+"/onvif/media_service", WithLogger(func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	config := &VideoEncoderConfiguration{Token: "VideoEnc1", Name: "H264 Config", UseCount: 7, Encoding: "H264"}
+
+	if err := client.SetVideoEncoderConfiguration(ctx, config, true); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() failed: %v", err)
+	}
+
+	if !strings.Contains(request, "<tt:UseCount>3</tt:UseCount>") {
+		t.Errorf("Expected the device's UseCount: %s", request)
+	}
+
+	if config.UseCount != 7 {
+		t.Errorf("Expected the caller's configuration to be left alone, got UseCount %d", config.UseCount)
+	}
+
+	getFails = true
+
+	if err := client.SetVideoEncoderConfiguration(ctx, config, true); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() failed: %v", err)
+	}
+
+	if !strings.Contains(request, "<tt:UseCount>7</tt:UseCount>") {
+		t.Errorf("Expected the caller's UseCount when the configuration cannot be read: %s", request)
+	}
+
+	if len(logs) == 0 {
+		t.Error("Expected a warning when the configuration cannot be read")
+	}
+
+	gets = 0
+
+	if err := client.SetVideoEncoderConfiguration(withFreshUseCount(ctx), config, true); err != nil {
+		t.Fatalf("SetVideoEncoderConfiguration() failed: %v", err)
+	}
+
+	if gets != 0 {
+		t.Errorf("Expected no read of a freshly read configuration, got %d", gets)
+	}
+
+	if !strings.Contains(request, "<tt:UseCount>7</tt:UseCount>") {
+		t.Errorf("Expected the caller's fresh UseCount: %s", request)
+	}
+}
+
 // TestGetMediaServiceCapabilities tests GetMediaServiceCapabilities operation.
 func TestGetMediaServiceCapabilities(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -476,6 +769,40 @@ func TestGetMediaServiceCapabilities(t *testing.T) {
 	}
 }
 
+// TestGetMediaServiceCapabilitiesNumericBooleans tests capabilities reported as 1/0 and yes/no.
+func TestGetMediaServiceCapabilitiesNumericBooleans(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:Capabilities SnapshotUri="1" Rotation="0" OSD="yes" TemporaryOSDText="No">
+				<trt:StreamingCapabilities RTPMulticast="1" RTP_TCP="YES" RTP_RTSP_TCP="0"/>
+			</trt:Capabilities>
+		</trt:GetServiceCapabilitiesResponse>
+	</soap:Body>
+</soap:Envelope>`
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	caps, err := client.GetMediaServiceCapabilities(context.Background())
+	if err != nil {
+		t.Fatalf("GetMediaServiceCapabilities() failed: %v", err)
+	}
+
+	want := MediaServiceCapabilities{SnapshotURI: true, OSD: true, RTPMulticast: true, RTPTCP: true}
+	if *caps != want {
+		t.Errorf("GetMediaServiceCapabilities() = %+v, want %+v", *caps, want)
+	}
+}
+
 // TestGetVideoEncoderConfigurationOptions tests GetVideoEncoderConfigurationOptions operation.
 func TestGetVideoEncoderConfigurationOptions(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -671,6 +998,81 @@ func TestSetMetadataConfiguration(t *testing.T) {
 	}
 }
 
+// TestMetadataConfigurationRoundTrip tests that Profile M analytics and extension content survive a read-modify-write.
+func TestMetadataConfigurationRoundTrip(t *testing.T) {
+	var setRequest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		response := `<trt:SetMetadataConfigurationResponse/>`
+		if strings.Contains(string(body), "GetMetadataConfiguration") {
+			response = `<trt:GetMetadataConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="Metadata1" CompressionType="GZIP">
+					<tt:Name>Metadata Config</tt:Name>
+					<tt:UseCount>1</tt:UseCount>
+					<tt:Analytics>true</tt:Analytics>
+					<tt:AnalyticsEngineConfiguration>
+						<tt:AnalyticsModule Name="Motion" Type="tt:CellMotionEngine">
+							<tt:Parameters><tt:SimpleItem Name="Sensitivity" Value="60"/></tt:Parameters>
+						</tt:AnalyticsModule>
+					</tt:AnalyticsEngineConfiguration>
+					<tt:Extension><tt:SampleFrequency>5</tt:SampleFrequency><acme:Overlay acme:Mode="full">on</acme:Overlay></tt:Extension>
+				</trt:Configuration>
+			</trt:GetMetadataConfigurationResponse>`
+		} else {
+			setRequest = string(body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		// The vendor prefix is declared on the envelope, outside of the response element.
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope xmlns:acme="http://www.acme.example/onvif"><soap:Body>` +
+			response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	config, err := client.GetMetadataConfiguration(ctx, "Metadata1")
+	if err != nil {
+		t.Fatalf("GetMetadataConfiguration() failed: %v", err)
+	}
+
+	// Namespaces declared on ancestors of Extension are declared within it.
+	extension := `<tt:SampleFrequency xmlns:tt="http://www.onvif.org/ver10/schema">5</tt:SampleFrequency>` +
+		`<ns1:Overlay ns1:Mode="full" xmlns:ns1="http://www.acme.example/onvif">on</ns1:Overlay>`
+	if config.CompressionType != "GZIP" || config.ExtensionXML != extension {
+		t.Errorf("Unexpected Profile M fields: %q %q", config.CompressionType, config.ExtensionXML)
+	}
+
+	if config.AnalyticsEngineConfiguration == nil || len(config.AnalyticsEngineConfiguration.AnalyticsModules) != 1 {
+		t.Fatalf("Expected 1 analytics module, got %+v", config.AnalyticsEngineConfiguration)
+	}
+
+	config.Name = "Renamed"
+
+	if err := client.SetMetadataConfiguration(ctx, config, true); err != nil {
+		t.Fatalf("SetMetadataConfiguration() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`CompressionType="GZIP"`,
+		`<tt:Name>Renamed</tt:Name>`,
+		`<tt:AnalyticsModule Name="Motion" Type="tt:CellMotionEngine">`,
+		`<tt:SimpleItem Name="Sensitivity" Value="60">`,
+		`<tt:Extension>` + extension + `</tt:Extension>`,
+	} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+}
+
 // TestGetVideoSourceModes tests GetVideoSourceModes operation.
 func TestGetVideoSourceModes(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -735,6 +1137,47 @@ func TestSetVideoSourceMode(t *testing.T) {
 	}
 }
 
+func TestVideoSourceModeCapabilityGate(t *testing.T) {
+	var modeRequests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		if strings.Contains(string(body), "GetServiceCapabilities") {
+			response = `<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Capabilities SnapshotUri="true" VideoSourceMode="false"/>
+			</trt:GetServiceCapabilitiesResponse>`
+		} else {
+			modeRequests++
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.GetVideoSourceModes(ctx, "VideoSource1"); !errors.Is(err, ErrVideoSourceModeUnsupported) {
+		t.Errorf("Expected ErrVideoSourceModeUnsupported from GetVideoSourceModes, got %v", err)
+	}
+
+	if err := client.SetVideoSourceMode(ctx, "VideoSource1", "Mode1"); !errors.Is(err, ErrVideoSourceModeUnsupported) {
+		t.Errorf("Expected ErrVideoSourceModeUnsupported from SetVideoSourceMode, got %v", err)
+	}
+
+	if modeRequests != 0 {
+		t.Errorf("Expected no video source mode requests, got %d", modeRequests)
+	}
+}
+
 // TestSetSynchronizationPoint tests SetSynchronizationPoint operation.
 func TestSetSynchronizationPoint(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1091,6 +1534,143 @@ func TestAddVideoSourceConfiguration(t *testing.T) {
 	}
 }
 
+// TestRetargetVideoSourceConfiguration tests RetargetVideoSourceConfiguration operation.
+func TestRetargetVideoSourceConfiguration(t *testing.T) {
+	var setRequest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetVideoSources"):
+			response = `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:VideoSources token="Sensor1"/><trt:VideoSources token="Sensor2"/>
+			</trt:GetVideoSourcesResponse>`
+		case strings.Contains(string(body), "GetVideoSourceConfiguration"):
+			response = `<trt:GetVideoSourceConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VSC_1"><tt:Name>Lens A</tt:Name><tt:UseCount>2</tt:UseCount>
+					<tt:SourceToken>Sensor1</tt:SourceToken><tt:Bounds x="0" y="0" width="1920" height="1080"/>
+				</trt:Configuration>
+			</trt:GetVideoSourceConfigurationResponse>`
+		case strings.Contains(string(body), "SetVideoSourceConfiguration"):
+			setRequest = string(body)
+			response = `<trt:SetVideoSourceConfigurationResponse/>`
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := client.RetargetVideoSourceConfiguration(ctx, "VSC_1", "Sensor3"); !errors.Is(err, ErrVideoSourceNotFound) {
+		t.Errorf("Expected ErrVideoSourceNotFound, got %v", err)
+	}
+
+	if setRequest != "" {
+		t.Fatal("Configuration must not be changed for an unknown source")
+	}
+
+	if err := client.RetargetVideoSourceConfiguration(ctx, "VSC_1", "Sensor2"); err != nil {
+		t.Fatalf("RetargetVideoSourceConfiguration() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<trt:Configuration token="VSC_1">`,
+		"<tt:Name>Lens A</tt:Name>",
+		"<tt:SourceToken>Sensor2</tt:SourceToken>",
+		`width="1920"`,
+	} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+}
+
+func TestSessionTimeoutRoundTrip(t *testing.T) {
+	var setRequests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetAudioEncoderConfiguration"):
+			response = `<trt:GetAudioEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="AEC_1"><tt:Name>Audio</tt:Name><tt:Encoding>G711</tt:Encoding>
+					<tt:SessionTimeout>PT90S</tt:SessionTimeout>
+				</trt:Configuration>
+			</trt:GetAudioEncoderConfigurationResponse>`
+		case strings.Contains(string(body), "GetMetadataConfiguration"):
+			response = `<trt:GetMetadataConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="MC_1"><tt:Name>Metadata</tt:Name>
+					<tt:SessionTimeout>PT1M</tt:SessionTimeout>
+				</trt:Configuration>
+			</trt:GetMetadataConfigurationResponse>`
+		case strings.Contains(string(body), "SetAudioEncoderConfiguration"),
+			strings.Contains(string(body), "SetMetadataConfiguration"):
+			setRequests = append(setRequests, string(body))
+			response = `<trt:SetConfigurationResponse/>`
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	audio, err := client.GetAudioEncoderConfiguration(ctx, "AEC_1")
+	if err != nil {
+		t.Fatalf("GetAudioEncoderConfiguration() failed: %v", err)
+	}
+
+	if audio.SessionTimeout != 90*time.Second {
+		t.Errorf("Expected audio session timeout 90s, got %v", audio.SessionTimeout)
+	}
+
+	metadata, err := client.GetMetadataConfiguration(ctx, "MC_1")
+	if err != nil {
+		t.Fatalf("GetMetadataConfiguration() failed: %v", err)
+	}
+
+	if metadata.SessionTimeout != time.Minute {
+		t.Errorf("Expected metadata session timeout 1m, got %v", metadata.SessionTimeout)
+	}
+
+	if err := client.SetAudioEncoderConfiguration(ctx, audio, false); err != nil {
+		t.Fatalf("SetAudioEncoderConfiguration() failed: %v", err)
+	}
+
+	if err := client.SetMetadataConfiguration(ctx, metadata, false); err != nil {
+		t.Fatalf("SetMetadataConfiguration() failed: %v", err)
+	}
+
+	if len(setRequests) != 2 {
+		t.Fatalf("Expected 2 set requests, got %d", len(setRequests))
+	}
+
+	for i, want := range []string{"<tt:SessionTimeout>PT1M30S</tt:SessionTimeout>", "<tt:SessionTimeout>PT1M</tt:SessionTimeout>"} {
+		if !strings.Contains(setRequests[i], want) {
+			t.Errorf("Request missing %s: %s", want, setRequests[i])
+		}
+	}
+}
+
 // TestRemoveVideoSourceConfiguration tests RemoveVideoSourceConfiguration operation.
 func TestRemoveVideoSourceConfiguration(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1487,3 +2067,441 @@ func TestGetOSDOptions(t *testing.T) {
 		t.Errorf("Expected MaximumNumberOfOSDs 10, got %d", options.MaximumNumberOfOSDs)
 	}
 }
+
+// TestAudioOutputConfigurationForOutput tests AudioOutputConfigurationForOutput lookup.
+func TestAudioOutputConfigurationForOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<trt:GetAudioOutputConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+			<trt:Configurations token="AudioOutputConfig1">
+				<tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">Front Speaker</tt:Name>
+				<tt:OutputToken xmlns:tt="http://www.onvif.org/ver10/schema">AudioOutput1</tt:OutputToken>
+			</trt:Configurations>
+			<trt:Configurations token="AudioOutputConfig2">
+				<tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">Rear Speaker</tt:Name>
+				<tt:OutputToken xmlns:tt="http://www.onvif.org/ver10/schema">AudioOutput2</tt:OutputToken>
+			</trt:Configurations>
+		</trt:GetAudioOutputConfigurationsResponse>
+	</soap:Body>
+</soap:Envelope>`
+		w.Header().Set("Content-Type", "application/soap+xml")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	config, err := client.AudioOutputConfigurationForOutput(ctx, "AudioOutput2")
+	if err != nil {
+		t.Fatalf("AudioOutputConfigurationForOutput() failed: %v", err)
+	}
+
+	if config.Token != "AudioOutputConfig2" {
+		t.Errorf("Expected token AudioOutputConfig2, got %s", config.Token)
+	}
+
+	_, err = client.AudioOutputConfigurationForOutput(ctx, "AudioOutput3")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestSetOSDValidatesDateTimeFormats tests that SetOSD rejects formats missing from GetOSDOptions.
+func TestSetOSDValidatesDateTimeFormats(t *testing.T) {
+	var setCalls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		if strings.Contains(string(body), "SetOSD") {
+			setCalls++
+
+			if !strings.Contains(string(body), "<tt:DateFormat>yyyy-MM-dd</tt:DateFormat>") {
+				t.Errorf("SetOSD request missing date format: %s", body)
+			}
+
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><trt:SetOSDResponse/></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<trt:GetOSDOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<trt:OSDOptions>
+				<tt:MaximumNumberOfOSDs Total="4" Date="1"/>
+				<tt:Type>Text</tt:Type>
+				<tt:PositionOption>UpperLeft</tt:PositionOption>
+				<tt:TextOption>
+					<tt:Type>Date</tt:Type>
+					<tt:Type>DateAndTime</tt:Type>
+					<tt:FontSizeRange><tt:Min>16</tt:Min><tt:Max>64</tt:Max></tt:FontSizeRange>
+					<tt:DateFormat>MM/dd/yyyy</tt:DateFormat>
+					<tt:DateFormat>yyyy-MM-dd</tt:DateFormat>
+					<tt:TimeFormat>HH:mm:ss</tt:TimeFormat>
+				</tt:TextOption>
+			</trt:OSDOptions>
+		</trt:GetOSDOptionsResponse>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	osd := &OSDConfiguration{
+		Token:                         "OSD1",
+		VideoSourceConfigurationToken: "VideoSourceConfig1",
+		Type:                          "Text",
+		Position:                      &OSDPosConfiguration{Type: "UpperLeft"},
+		TextString: &OSDTextConfiguration{
+			Type:       "DateAndTime",
+			DateFormat: "dd.MM.yyyy",
+			TimeFormat: "HH:mm:ss",
+		},
+	}
+
+	if err := client.SetOSD(ctx, osd); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for unsupported date format, got %v", err)
+	}
+
+	if setCalls != 0 {
+		t.Errorf("Expected no SetOSD request for an invalid format, got %d", setCalls)
+	}
+
+	osd.TextString.DateFormat = "yyyy-MM-dd"
+	if err := client.SetOSD(ctx, osd); err != nil {
+		t.Fatalf("SetOSD() failed: %v", err)
+	}
+
+	if setCalls != 1 {
+		t.Errorf("Expected 1 SetOSD request, got %d", setCalls)
+	}
+}
+
+func TestSetOSDSkipsValidationWhenOptionsFail(t *testing.T) {
+	var setCalls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		if strings.Contains(string(body), "SetOSD") {
+			setCalls++
+
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><trt:SetOSDResponse/></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<soap:Fault>
+			<soap:Code>
+				<soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:ActionNotSupported</soap:Value></soap:Subcode>
+			</soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">GetOSDOptions not supported</soap:Text></soap:Reason>
+		</soap:Fault>
+	</soap:Body>
+</soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	osd := &OSDConfiguration{
+		Token:                         "OSD1",
+		VideoSourceConfigurationToken: "VideoSourceConfig1",
+		Type:                          "Text",
+		Position:                      &OSDPosConfiguration{Type: "UpperLeft"},
+		TextString: &OSDTextConfiguration{
+			Type:       "DateAndTime",
+			DateFormat: "dd.MM.yyyy",
+			TimeFormat: "HH:mm:ss",
+		},
+	}
+
+	if err := client.SetOSD(context.Background(), osd); err != nil {
+		t.Fatalf("SetOSD() failed: %v", err)
+	}
+
+	if setCalls != 1 {
+		t.Errorf("Expected 1 SetOSD request, got %d", setCalls)
+	}
+}
+
+func TestCanAddVideoEncoder(t *testing.T) {
+	var added []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		var response string
+
+		switch {
+		case strings.Contains(bodyStr, "GetGuaranteedNumberOfVideoEncoderInstances"):
+			response = `<trt:GetGuaranteedNumberOfVideoEncoderInstancesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:TotalNumber>3</trt:TotalNumber>
+				<trt:JPEG>2</trt:JPEG>
+				<trt:H264>1</trt:H264>
+			</trt:GetGuaranteedNumberOfVideoEncoderInstancesResponse>`
+		case strings.Contains(bodyStr, "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1">
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:SourceToken>VideoSource_1</tt:SourceToken></tt:VideoSourceConfiguration>
+					<tt:VideoEncoderConfiguration token="VEC_H264"><tt:Encoding>H264</tt:Encoding></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Profile_2">
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:SourceToken>VideoSource_1</tt:SourceToken></tt:VideoSourceConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(bodyStr, "GetVideoEncoderConfiguration"):
+			response = `<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VEC_H264_2"><tt:Encoding>H264</tt:Encoding></trt:Configuration>
+			</trt:GetVideoEncoderConfigurationResponse>`
+		case strings.Contains(bodyStr, "AddVideoEncoderConfiguration"):
+			added = append(added, bodyStr)
+			response = `<trt:AddVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithVideoEncoderInstanceCheck())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	for encoding, want := range map[string]bool{"H264": false, "jpeg": true, "MPEG4": true} {
+		ok, err := client.CanAddVideoEncoder(ctx, "VSC_1", encoding)
+		if err != nil {
+			t.Fatalf("CanAddVideoEncoder(%s) failed: %v", encoding, err)
+		}
+
+		if ok != want {
+			t.Errorf("CanAddVideoEncoder(%s) = %v, want %v", encoding, ok, want)
+		}
+	}
+
+	err = client.AddVideoEncoderConfiguration(ctx, "Profile_2", "VEC_H264_2")
+	if !errors.Is(err, ErrVideoEncoderLimitReached) {
+		t.Errorf("Expected ErrVideoEncoderLimitReached, got %v", err)
+	}
+
+	if len(added) != 0 {
+		t.Fatalf("AddVideoEncoderConfiguration should not be sent over the limit")
+	}
+
+	// Replacing the encoder of the profile that holds the only H264 instance is allowed.
+	if err := client.AddVideoEncoderConfiguration(ctx, "Profile_1", "VEC_H264_2"); err != nil {
+		t.Errorf("AddVideoEncoderConfiguration() failed: %v", err)
+	}
+
+	if len(added) != 1 {
+		t.Errorf("Expected 1 AddVideoEncoderConfiguration request, got %d", len(added))
+	}
+}
+
+func TestOSDTextPersistence(t *testing.T) {
+	for _, temporarySupported := range []bool{false, true} {
+		t.Run(fmt.Sprintf("TemporaryOSDText=%v", temporarySupported), func(t *testing.T) {
+			var created int
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+
+				var response string
+
+				switch {
+				case strings.Contains(string(body), "GetServiceCapabilities"):
+					response = fmt.Sprintf(`<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Capabilities OSD="true" TemporaryOSDText="%v"/>
+			</trt:GetServiceCapabilitiesResponse>`, temporarySupported)
+				case strings.Contains(string(body), "CreateOSD"):
+					created++
+
+					if !strings.Contains(string(body), `<tt:TextString IsPersistentText="false">`) {
+						t.Errorf("CreateOSD request missing IsPersistentText: %s", body)
+					}
+
+					response = `<trt:CreateOSDResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:OSD token="OSD1">
+					<tt:Type>Text</tt:Type>
+					<tt:TextString IsPersistentText="false"><tt:Type>Plain</tt:Type><tt:PlainText>Gate</tt:PlainText></tt:TextString>
+				</trt:OSD>
+			</trt:CreateOSDResponse>`
+				default:
+					t.Errorf("Unexpected request: %s", body)
+				}
+
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL + "/onvif/media_service")
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			persistent := false
+			osd, err := client.CreateOSD(context.Background(), "VideoSourceConfig1", &OSDConfiguration{
+				Type:       "Text",
+				TextString: &OSDTextConfiguration{Type: "Plain", PlainText: "Gate", IsPersistentText: &persistent},
+			})
+
+			if !temporarySupported {
+				if !errors.Is(err, ErrInvalidParameter) {
+					t.Fatalf("Expected ErrInvalidParameter for temporary text, got %v", err)
+				}
+
+				if created != 0 {
+					t.Errorf("Expected no CreateOSD request, got %d", created)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CreateOSD() failed: %v", err)
+			}
+
+			if osd.TextString == nil || osd.TextString.IsPersistentText == nil || *osd.TextString.IsPersistentText {
+				t.Errorf("Expected temporary OSD text, got %+v", osd.TextString)
+			}
+		})
+	}
+}
+
+func TestOSDImageSupport(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		osd     *OSDConfiguration
+		wantErr error
+	}{
+		{
+			name:    "text-only device",
+			options: `<tt:MaximumNumberOfOSDs Total="2"/><tt:Type>Text</tt:Type>`,
+			osd:     &OSDConfiguration{Type: "Image", Image: &OSDImageConfiguration{ImagePath: "/images/logo.png"}},
+			wantErr: ErrOSDImageNotSupported,
+		},
+		{
+			name: "unknown image path",
+			options: `<tt:MaximumNumberOfOSDs Total="4" Image="1"/><tt:Type>Text</tt:Type><tt:Type>Image</tt:Type>
+				<tt:ImageOption FormatsSupported="png bmp" MaxSize="65536" MaxWidth="256" MaxHeight="128">
+					<tt:ImagePath>/images/logo.png</tt:ImagePath>
+				</tt:ImageOption>`,
+			osd:     &OSDConfiguration{Type: "Image", Image: &OSDImageConfiguration{ImagePath: "/images/other.png"}},
+			wantErr: ErrInvalidParameter,
+		},
+		{
+			name: "supported image",
+			options: `<tt:MaximumNumberOfOSDs Total="4" Image="1"/><tt:Type>Text</tt:Type><tt:Type>Image</tt:Type>
+				<tt:ImageOption FormatsSupported="png bmp" MaxSize="65536" MaxWidth="256" MaxHeight="128">
+					<tt:ImagePath>/images/logo.png</tt:ImagePath>
+				</tt:ImageOption>`,
+			osd: &OSDConfiguration{Type: "Image", Image: &OSDImageConfiguration{ImagePath: "/images/logo.png"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var setRequests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+
+				if strings.Contains(string(body), "SetOSD") {
+					setRequests = append(setRequests, string(body))
+					_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><trt:SetOSDResponse/></soap:Body></soap:Envelope>`))
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<trt:GetOSDOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<trt:OSDOptions>` + tt.options + `</trt:OSDOptions>
+		</trt:GetOSDOptionsResponse>
+	</soap:Body>
+</soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			tt.osd.Token = "OSD1"
+			tt.osd.VideoSourceConfigurationToken = "VideoSourceConfig1"
+
+			err = client.SetOSD(context.Background(), tt.osd)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("SetOSD() error = %v, want %v", err, tt.wantErr)
+				}
+
+				if len(setRequests) != 0 {
+					t.Errorf("Invalid OSD should not be sent")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SetOSD() failed: %v", err)
+			}
+
+			if len(setRequests) != 1 || !strings.Contains(setRequests[0], "<tt:ImgPath>/images/logo.png</tt:ImgPath>") {
+				t.Errorf("Unexpected SetOSD requests: %v", setRequests)
+			}
+
+			options, err := client.GetOSDOptions(context.Background(), "VideoSourceConfig1")
+			if err != nil {
+				t.Fatalf("GetOSDOptions() failed: %v", err)
+			}
+
+			image := options.ImageOption
+			if !options.SupportsImage() || options.MaximumNumberOfImageOSDs != 1 || image == nil ||
+				image.MaxWidth != 256 || image.MaxHeight != 128 || image.MaxSize != 65536 || len(image.FormatsSupported) != 2 {
+				t.Errorf("Unexpected image options: %+v %+v", options, image)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	// maxMulticastPort is the largest UDP port.
+	maxMulticastPort = 65535
+	// maxMulticastTTL is the largest IP time to live.
+	maxMulticastTTL = 255
+)
+
+// EnableMulticast configures the multicast group of the video encoder of a
+// profile and starts multicast streaming of the profile, as one operation.
+// mcast is validated first: it must name an IPv4 or IPv6 multicast address, a
+// port and a TTL from 1 to 255. If the device then refuses to start
+// streaming, the previous multicast configuration of the encoder is restored,
+// or a disabled one (the unspecified address, port 0) if the encoder reported
+// none, and the returned error wraps both failures. The configuration is set
+// with forcePersistence, see SetVideoEncoderConfiguration.
+func (c *Client) EnableMulticast(ctx context.Context, profileToken string, mcast *MulticastConfiguration) error {
+	multicast, err := validateMulticastConfiguration(mcast)
+	if err != nil {
+		return fmt.Errorf("EnableMulticast failed: %w", err)
+	}
+
+	profile, err := c.GetProfile(ctx, profileToken)
+	if err != nil {
+		return fmt.Errorf("EnableMulticast failed: %w", err)
+	}
+
+	if profile.VideoEncoderConfiguration == nil {
+		return fmt.Errorf("EnableMulticast failed: %w: profile %q has no video encoder configuration",
+			ErrInvalidParameter, profileToken)
+	}
+
+	// The profile may carry a summary of the encoder, so the configuration
+	// sent back is read in full.
+	config, err := c.GetVideoEncoderConfiguration(ctx, profile.VideoEncoderConfiguration.Token)
+	if err != nil {
+		return fmt.Errorf("EnableMulticast failed: %w", err)
+	}
+
+	previous := config.Multicast
+	config.Multicast = multicast
+
+	// The configuration was read above, so its UseCount is current.
+	ctx = withFreshUseCount(ctx)
+
+	if err := c.SetVideoEncoderConfiguration(ctx, config, true); err != nil {
+		return fmt.Errorf("EnableMulticast failed: %w", err)
+	}
+
+	startErr := c.StartMulticastStreaming(ctx, profileToken)
+	if startErr == nil {
+		return nil
+	}
+
+	config.Multicast = previous
+	if previous == nil {
+		config.Multicast = disabledMulticastConfiguration(multicast)
+	}
+
+	if err := c.SetVideoEncoderConfiguration(ctx, config, true); err != nil {
+		return fmt.Errorf("EnableMulticast failed: %w, and restoring the multicast configuration failed: %w",
+			startErr, err)
+	}
+
+	return fmt.Errorf("EnableMulticast failed: %w", startErr)
+}
+
+// disabledMulticastConfiguration returns a multicast configuration that sends
+// to no group, with the unspecified address of the address family of mcast.
+func disabledMulticastConfiguration(mcast *MulticastConfiguration) *MulticastConfiguration {
+	address := &IPAddress{Type: "IPv4", IPv4Address: net.IPv4zero.String()}
+	if mcast.Address.Type == "IPv6" {
+		address = &IPAddress{Type: "IPv6", IPv6Address: net.IPv6unspecified.String()}
+	}
+
+	return &MulticastConfiguration{Address: address, TTL: 1}
+}
+
+// validateMulticastConfiguration checks a multicast configuration and returns
+// a copy with the address type set from the address.
+func validateMulticastConfiguration(mcast *MulticastConfiguration) (*MulticastConfiguration, error) {
+	if mcast == nil || mcast.Address == nil {
+		return nil, fmt.Errorf("%w: multicast address required", ErrInvalidParameter)
+	}
+
+	address := mcast.Address.IPv4Address
+	if address == "" {
+		address = mcast.Address.IPv6Address
+	}
+
+	if address == "" {
+		address = mcast.Address.Address
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil || !ip.IsMulticast() {
+		return nil, fmt.Errorf("%w: %q is not a multicast address", ErrInvalidParameter, address)
+	}
+
+	if mcast.Port <= 0 || mcast.Port > maxMulticastPort {
+		return nil, fmt.Errorf("%w: multicast port %d out of range [1, %d]", ErrInvalidParameter, mcast.Port, maxMulticastPort)
+	}
+
+	if mcast.TTL <= 0 || mcast.TTL > maxMulticastTTL {
+		return nil, fmt.Errorf("%w: multicast TTL %d out of range [1, %d]", ErrInvalidParameter, mcast.TTL, maxMulticastTTL)
+	}
+
+	multicast := *mcast
+	multicast.Address = &IPAddress{Type: "IPv6", IPv6Address: ip.String()}
+
+	if ip.To4() != nil {
+		multicast.Address = &IPAddress{Type: "IPv4", IPv4Address: ip.String()}
+	}
+
+	if mcast.Address.Type != "" && !strings.EqualFold(mcast.Address.Type, multicast.Address.Type) {
+		return nil, fmt.Errorf("%w: %q is not an %s address", ErrInvalidParameter, address, mcast.Address.Type)
+	}
+
+	return &multicast, nil
+}
@@ -0,0 +1,182 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockMulticastServer(t *testing.T, startFault, noMulticast bool, setRequests *[]string) *httptest.Server {
+	t.Helper()
+
+	multicast := `<tt:Multicast><tt:Address><tt:Type>IPv4</tt:Type><tt:IPv4Address>0.0.0.0</tt:IPv4Address></tt:Address>
+		<tt:Port>0</tt:Port><tt:TTL>1</tt:TTL><tt:AutoStart>false</tt:AutoStart></tt:Multicast>`
+	if noMulticast {
+		multicast = ""
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+
+		var response string
+
+		switch {
+		case strings.Contains(bodyStr, "GetProfile"):
+			response = `<trt:GetProfileResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profile token="Profile_1"><tt:Name>Main</tt:Name>
+					<tt:VideoEncoderConfiguration token="VEC_1"><tt:Name>VEC</tt:Name><tt:Encoding>H264</tt:Encoding></tt:VideoEncoderConfiguration>
+				</trt:Profile></trt:GetProfileResponse>`
+		case strings.Contains(bodyStr, "GetVideoEncoderConfiguration"):
+			response = `<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VEC_1"><tt:Name>VEC</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H264</tt:Encoding>
+					<tt:H264><tt:GovLength>30</tt:GovLength><tt:H264Profile>Main</tt:H264Profile></tt:H264>
+					` + multicast + `
+					<tt:SessionTimeout>PT60S</tt:SessionTimeout>
+				</trt:Configuration></trt:GetVideoEncoderConfigurationResponse>`
+		case strings.Contains(bodyStr, "SetVideoEncoderConfiguration"):
+			*setRequests = append(*setRequests, bodyStr)
+			response = `<trt:SetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+		case strings.Contains(bodyStr, "StartMulticastStreaming"):
+			if startFault {
+				w.WriteHeader(http.StatusInternalServerError)
+				response = `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value></soap:Code>
+					<soap:Reason><soap:Text>Multicast unavailable</soap:Text></soap:Reason></soap:Fault>`
+			} else {
+				response = `<trt:StartMulticastStreamingResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl"/>`
+			}
+		default:
+			t.Errorf("Unexpected request: %s", bodyStr)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestEnableMulticast(t *testing.T) {
+	var setRequests []string
+
+	server := newMockMulticastServer(t, false, false, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	mcast := &MulticastConfiguration{Address: &IPAddress{IPv4Address: "239.0.0.10"}, Port: 5000, TTL: 4}
+
+	if err := client.EnableMulticast(context.Background(), "Profile_1", mcast); err != nil {
+		t.Fatalf("EnableMulticast() failed: %v", err)
+	}
+
+	if len(setRequests) != 1 {
+		t.Fatalf("Expected 1 SetVideoEncoderConfiguration request, got %d", len(setRequests))
+	}
+
+	for _, want := range []string{
+		"<tt:UseCount>1</tt:UseCount>",
+		"<tt:Type>IPv4</tt:Type>",
+		"<tt:IPv4Address>239.0.0.10</tt:IPv4Address>",
+		"<tt:Port>5000</tt:Port>",
+		"<tt:TTL>4</tt:TTL>",
+		"<tt:AutoStart>false</tt:AutoStart>",
+		"<tt:H264Profile>Main</tt:H264Profile>",
+		"<tt:SessionTimeout>PT1M</tt:SessionTimeout>",
+	} {
+		if !strings.Contains(setRequests[0], want) {
+			t.Errorf("Request missing %s: %s", want, setRequests[0])
+		}
+	}
+
+	if mcast.Address.Type != "" {
+		t.Errorf("Expected the caller's configuration to be left unchanged, got %+v", mcast.Address)
+	}
+}
+
+func TestEnableMulticastRollsBack(t *testing.T) {
+	var setRequests []string
+
+	server := newMockMulticastServer(t, true, false, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	mcast := &MulticastConfiguration{Address: &IPAddress{IPv4Address: "239.0.0.10"}, Port: 5000, TTL: 4}
+
+	if err := client.EnableMulticast(context.Background(), "Profile_1", mcast); err == nil {
+		t.Fatal("Expected EnableMulticast() to fail")
+	}
+
+	if len(setRequests) != 2 {
+		t.Fatalf("Expected the configuration to be set and restored, got %d requests", len(setRequests))
+	}
+
+	if !strings.Contains(setRequests[1], "<tt:IPv4Address>0.0.0.0</tt:IPv4Address>") {
+		t.Errorf("Expected the previous multicast address to be restored: %s", setRequests[1])
+	}
+}
+
+func TestEnableMulticastRollsBackWithoutPrevious(t *testing.T) {
+	var setRequests []string
+
+	server := newMockMulticastServer(t, true, true, &setRequests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	mcast := &MulticastConfiguration{Address: &IPAddress{IPv4Address: "239.0.0.10"}, Port: 5000, TTL: 4}
+
+	if err := client.EnableMulticast(context.Background(), "Profile_1", mcast); err == nil {
+		t.Fatal("Expected EnableMulticast() to fail")
+	}
+
+	if len(setRequests) != 2 {
+		t.Fatalf("Expected the configuration to be set and restored, got %d requests", len(setRequests))
+	}
+
+	for _, want := range []string{
+		"<tt:IPv4Address>0.0.0.0</tt:IPv4Address>",
+		"<tt:Port>0</tt:Port>",
+		"<tt:AutoStart>false</tt:AutoStart>",
+	} {
+		if !strings.Contains(setRequests[1], want) {
+			t.Errorf("Expected a disabled multicast configuration to be restored, missing %s: %s", want, setRequests[1])
+		}
+	}
+}
+
+func TestEnableMulticastValidation(t *testing.T) {
+	client, err := NewClient("http://192.0.2.1/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		mcast *MulticastConfiguration
+	}{
+		{"no address", &MulticastConfiguration{Port: 5000, TTL: 4}},
+		{"unicast address", &MulticastConfiguration{Address: &IPAddress{IPv4Address: "192.168.1.10"}, Port: 5000, TTL: 4}},
+		{"mismatched type", &MulticastConfiguration{Address: &IPAddress{Type: "IPv6", IPv4Address: "239.0.0.10"}, Port: 5000, TTL: 4}},
+		{"no port", &MulticastConfiguration{Address: &IPAddress{IPv6Address: "ff15::1"}, TTL: 4}},
+		{"TTL too large", &MulticastConfiguration{Address: &IPAddress{IPv4Address: "239.0.0.10"}, Port: 5000, TTL: 256}},
+	}
+
+	for _, tt := range tests {
+		err := client.EnableMulticast(context.Background(), "Profile_1", tt.mcast)
+		if !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("%s: expected ErrInvalidParameter, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+)
+
+// DefaultProvisionReconnectTimeout is how long Provision waits for the device
+// to answer again after a network change, unless
+// ProvisioningNetwork.ReconnectTimeout is set.
+const DefaultProvisionReconnectTimeout = 3 * time.Minute
+
+// Provisioning steps reported in ProvisioningStepResult.Step, in the order
+// Provision applies them.
+const (
+	ProvisionStepHostname = "Hostname"
+	ProvisionStepDNS      = "DNS"
+	ProvisionStepNTP      = "NTP"
+	ProvisionStepStreams  = "Streams"
+	ProvisionStepNetwork  = "Network"
+	ProvisionStepUsers    = "Users"
+)
+
+// ProvisioningSpec describes the settings applied by Provision. Empty or nil
+// fields are left unchanged.
+type ProvisioningSpec struct {
+	Hostname string
+	DNS      *ProvisioningDNS
+	NTP      *ProvisioningNTP
+	// Streams maps profile tokens to the stream parameters applied with
+	// SetStreamParameters.
+	Streams map[string]StreamParams
+	Network *ProvisioningNetwork
+	// Users are updated with SetUser when they exist and created with
+	// CreateUsers otherwise.
+	Users []*User
+}
+
+// ProvisioningDNS holds the DNS settings applied with SetDNS.
+type ProvisioningDNS struct {
+	FromDHCP     bool
+	SearchDomain []string
+	Servers      []IPAddress
+}
+
+// ProvisioningNTP holds the NTP settings applied with SetNTP.
+type ProvisioningNTP struct {
+	FromDHCP bool
+	Servers  []NetworkHost
+}
+
+// ProvisioningNetwork holds the network interface settings.
+type ProvisioningNetwork struct {
+	// InterfaceToken selects the interface to change; empty selects the first
+	// interface reported by GetNetworkInterfaces.
+	InterfaceToken string
+	Config         *NetworkInterfaceSetConfiguration
+	// Gateway is applied with SetNetworkDefaultGateway before the interface
+	// is changed, while the device is still reachable at its old address.
+	Gateway *NetworkGateway
+	// ReconnectTimeout bounds the wait for the device after the change;
+	// zero uses DefaultProvisionReconnectTimeout.
+	ReconnectTimeout time.Duration
+	// PollInterval is passed to WaitForReachable.
+	PollInterval time.Duration
+}
+
+// ProvisioningStepResult describes the outcome of one provisioning step.
+type ProvisioningStepResult struct {
+	Step string
+	// Skipped is set when the step was not attempted or the device does not
+	// support it; Reason says why.
+	Skipped bool
+	Reason  string
+	Err     error
+}
+
+// ProvisioningReport describes the outcome of Provision.
+type ProvisioningReport struct {
+	// Steps holds one result per configured step, in the order applied.
+	Steps []*ProvisioningStepResult
+	// Rebooted is set when the network change required a reboot.
+	Rebooted bool
+	// Endpoint is the device endpoint after provisioning.
+	Endpoint string
+}
+
+// Err returns the errors of the failed steps joined, or nil when no step failed.
+func (r *ProvisioningReport) Err() error {
+	var errs []error
+
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", step.Step, step.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Provision applies spec to the device in an order that keeps the device
+// reachable for as long as possible: hostname, DNS, NTP and stream settings
+// first, then the network interface, and the users last so that a changed
+// password of the client's own account does not break the earlier steps.
+//
+// A failing step does not stop the others. Steps the device does not
+// support, according to GetCapabilities, GetServiceCapabilities or an
+// ActionNotSupported fault, are reported as skipped. When the network change
+// turns DHCP off and gives the interface a new static address, the client
+// endpoint and the service endpoints on the same host are moved to it, also
+// when the device drops the connection instead of answering the change but
+// answers at the new address. When the device asks for a reboot,
+// SystemReboot is called. Provision then waits with WaitForReachable, or
+// WaitForReboot after a reboot, before continuing and skips the remaining
+// steps if the device does not come back. When the own account's password is
+// changed, the client credentials are updated.
+//
+// The client must not be used concurrently while Provision runs. The error
+// is non-nil when a step failed; the report is returned in either case.
+func (c *Client) Provision(ctx context.Context, spec *ProvisioningSpec) (*ProvisioningReport, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("Provision failed: %w: spec is required", ErrInvalidParameter)
+	}
+
+	report := &ProvisioningReport{}
+
+	caps, err := c.GetCapabilities(ctx)
+	if err != nil {
+		c.skipCheck("provisioning capability check", err)
+	}
+
+	var network *NetworkCapabilities
+
+	if spec.DNS != nil || spec.NTP != nil || (spec.Network != nil && spec.Network.Config != nil) {
+		if deviceCaps, err := checkedCapabilities(ctx, c, deviceNamespace, c.GetServiceCapabilities); err != nil {
+			c.skipCheck("provisioning network capability check", err)
+		} else {
+			network = deviceCaps.Network
+		}
+	}
+
+	reachable := true
+
+	run := func(step string, configured bool, unsupported string, fn func() error) {
+		if !configured {
+			return
+		}
+
+		result := &ProvisioningStepResult{Step: step}
+		report.Steps = append(report.Steps, result)
+
+		switch {
+		case !reachable:
+			result.Skipped, result.Reason = true, "device not reachable"
+		case unsupported != "":
+			result.Skipped, result.Reason = true, unsupported
+		default:
+			result.Err = fn()
+			if errors.Is(result.Err, ErrActionNotSupported) {
+				result.Skipped, result.Reason, result.Err = true, "not supported by device", nil
+			}
+		}
+	}
+
+	run(ProvisionStepHostname, spec.Hostname != "", "", func() error {
+		return c.SetHostname(ctx, spec.Hostname)
+	})
+
+	run(ProvisionStepDNS, spec.DNS != nil, unsupportedDNS(network, spec.DNS), func() error {
+		return c.SetDNS(ctx, spec.DNS.FromDHCP, spec.DNS.SearchDomain, spec.DNS.Servers)
+	})
+
+	run(ProvisionStepNTP, spec.NTP != nil, unsupportedNTP(network, spec.NTP), func() error {
+		return c.SetNTP(ctx, spec.NTP.FromDHCP, spec.NTP.Servers)
+	})
+
+	var noMedia string
+	if caps != nil && caps.Media == nil {
+		noMedia = "device has no media service"
+	}
+
+	run(ProvisionStepStreams, len(spec.Streams) > 0, noMedia, func() error {
+		return c.provisionStreams(ctx, spec.Streams)
+	})
+
+	run(ProvisionStepNetwork, spec.Network != nil && spec.Network.Config != nil, unsupportedNetwork(network, spec.Network), func() error {
+		err := c.provisionNetwork(ctx, spec.Network, report)
+		if errors.Is(err, errDeviceUnreachable) {
+			reachable = false
+		}
+
+		return err
+	})
+
+	run(ProvisionStepUsers, len(spec.Users) > 0, "", func() error {
+		return c.provisionUsers(ctx, spec.Users)
+	})
+
+	report.Endpoint = c.Endpoint()
+
+	if err := report.Err(); err != nil {
+		return report, fmt.Errorf("Provision failed: %w", err)
+	}
+
+	return report, nil
+}
+
+// unsupportedDNS returns why the device cannot apply dns, or "" when it can or
+// its network capabilities are unknown.
+func unsupportedDNS(network *NetworkCapabilities, dns *ProvisioningDNS) string {
+	if network == nil || dns == nil || network.IPVersion6 {
+		return ""
+	}
+
+	for _, server := range dns.Servers {
+		if server.Type == "IPv6" {
+			return "device does not support IPv6"
+		}
+	}
+
+	return ""
+}
+
+// unsupportedNTP returns why the device cannot apply ntp, or "" when it can or
+// its network capabilities are unknown.
+func unsupportedNTP(network *NetworkCapabilities, ntp *ProvisioningNTP) string {
+	if network == nil || ntp == nil {
+		return ""
+	}
+
+	if network.NTP > 0 && len(ntp.Servers) > network.NTP {
+		return fmt.Sprintf("device supports at most %d NTP servers", network.NTP)
+	}
+
+	if !network.IPVersion6 && slices.ContainsFunc(ntp.Servers, func(h NetworkHost) bool { return h.Type == NetworkHostIPv6 }) {
+		return "device does not support IPv6"
+	}
+
+	return ""
+}
+
+// unsupportedNetwork returns why the device cannot apply spec, or "" when it
+// can or its network capabilities are unknown.
+func unsupportedNetwork(network *NetworkCapabilities, spec *ProvisioningNetwork) string {
+	if network == nil || spec == nil || spec.Gateway == nil {
+		return ""
+	}
+
+	if !network.IPVersion6 && len(spec.Gateway.IPv6Address) > 0 {
+		return "device does not support IPv6"
+	}
+
+	return ""
+}
+
+// errDeviceUnreachable marks a network step after which the device did not
+// answer again.
+var errDeviceUnreachable = errors.New("device not reachable after network change")
+
+// provisionStreams applies the stream parameters in profile token order.
+func (c *Client) provisionStreams(ctx context.Context, streams map[string]StreamParams) error {
+	tokens := make([]string, 0, len(streams))
+	for token := range streams {
+		tokens = append(tokens, token)
+	}
+
+	sort.Strings(tokens)
+
+	var errs []error
+
+	for _, token := range tokens {
+		if err := c.SetStreamParameters(ctx, token, streams[token]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// provisionNetwork applies the network settings and reconnects to the device.
+func (c *Client) provisionNetwork(ctx context.Context, spec *ProvisioningNetwork, report *ProvisioningReport) error {
+	token := spec.InterfaceToken
+	if token == "" {
+		interfaces, err := c.GetNetworkInterfaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(interfaces) == 0 {
+			return ErrNetworkInterfaceNotFound
+		}
+
+		token = interfaces[0].Token
+	}
+
+	newEndpoint := c.Endpoint()
+
+	if ipv4 := spec.Config.IPv4; ipv4 != nil && ipv4.DHCP != nil && !*ipv4.DHCP && len(ipv4.Manual) > 0 {
+		endpoint, err := replaceURLHost(newEndpoint, ipv4.Manual[0].Address)
+		if err != nil {
+			return err
+		}
+
+		newEndpoint = endpoint
+	}
+
+	if spec.Gateway != nil {
+		if err := c.SetNetworkDefaultGateway(ctx, spec.Gateway); err != nil {
+			return err
+		}
+	}
+
+	timeout := spec.ReconnectTimeout
+	if timeout <= 0 {
+		timeout = DefaultProvisionReconnectTimeout
+	}
+
+	rebootNeeded, err := c.SetNetworkInterfaces(ctx, token, spec.Config)
+	if err != nil {
+		return c.probeMovedEndpoint(ctx, newEndpoint, timeout, spec.PollInterval, err)
+	}
+
+	if rebootNeeded {
+		if _, err := c.SystemReboot(ctx); err != nil {
+			return err
+		}
+
+		report.Rebooted = true
+	}
+
+	c.moveEndpoint(newEndpoint)
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Wait for the device to go down first after a reboot, so that the old
+	// instance is not mistaken for the rebooted one.
+	wait := c.WaitForReachable
+	if rebootNeeded {
+		wait = c.WaitForReboot
+	}
+
+	if err := wait(waitCtx, spec.PollInterval); err != nil {
+		return fmt.Errorf("%w: %s: %w", errDeviceUnreachable, newEndpoint, err)
+	}
+
+	return nil
+}
+
+// probeMovedEndpoint handles a SetNetworkInterfaces call that failed with
+// callErr. A device that applies a new address at once may drop the
+// connection before answering, so when no response was received and the
+// address changes, the device is looked for at newEndpoint. If it answers
+// there, the change is taken as applied; otherwise the client stays on its
+// old endpoint and callErr is returned.
+func (c *Client) probeMovedEndpoint(
+	ctx context.Context,
+	newEndpoint string,
+	timeout, interval time.Duration,
+	callErr error,
+) error {
+	oldEndpoint := c.Endpoint()
+
+	var (
+		fault   *soap.FaultError
+		httpErr *soap.HTTPError
+	)
+
+	if newEndpoint == oldEndpoint || ctx.Err() != nil || errors.As(callErr, &fault) || errors.As(callErr, &httpErr) {
+		return callErr
+	}
+
+	c.moveEndpoint(newEndpoint)
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := c.WaitForReachable(waitCtx, interval); err != nil {
+		c.moveEndpoint(oldEndpoint)
+
+		return callErr
+	}
+
+	return nil
+}
+
+// provisionUsers creates the missing users and updates the existing ones.
+func (c *Client) provisionUsers(ctx context.Context, users []*User) error {
+	existing, err := c.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var create, update []*User
+
+	for _, user := range users {
+		if slices.ContainsFunc(existing, func(u *User) bool { return u.Username == user.Username }) {
+			update = append(update, user)
+		} else {
+			create = append(create, user)
+		}
+	}
+
+	if len(create) > 0 {
+		if err := c.CreateUsers(ctx, create); err != nil {
+			return err
+		}
+	}
+
+	username, _ := c.GetCredentials()
+
+	for _, user := range update {
+		if err := c.SetUser(ctx, user); err != nil {
+			return err
+		}
+
+		if user.Username == username && user.Password != "" {
+			c.SetCredentials(username, user.Password)
+		}
+	}
+
+	return nil
+}
+
+// moveEndpoint changes the device endpoint to endpoint, moving the service
+// endpoints that were on the old host along with it. Cached media URIs point
+// at the old address and are dropped.
+func (c *Client) moveEndpoint(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if endpoint == c.endpoint {
+		return
+	}
+
+	c.dropMediaURIs(func(*MediaURI) bool { return true })
+	c.resetCheckedCapabilities()
+
+	oldURL, err := url.Parse(c.endpoint)
+	if err != nil {
+		c.endpoint = endpoint
+
+		return
+	}
+
+	newURL, err := url.Parse(endpoint)
+	if err != nil {
+		return
+	}
+
+	move := func(addr string) string {
+		serviceURL, err := url.Parse(addr)
+		if addr == "" || err != nil || serviceURL.Hostname() != oldURL.Hostname() {
+			return addr
+		}
+
+		if moved, err := replaceURLHost(addr, newURL.Hostname()); err == nil {
+			return moved
+		}
+
+		return addr
+	}
+
+	for _, addr := range c.serviceEndpointFields() {
+		*addr = move(*addr)
+	}
+
+	for namespace, addr := range c.serviceEndpoints {
+		c.serviceEndpoints[namespace] = move(addr)
+	}
+
+	c.endpoint = endpoint
+}
+
+// replaceURLHost returns rawURL with its host replaced by host, keeping the port.
+func replaceURLHost(rawURL, host string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidEndpoint, err)
+	}
+
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	return u.String(), nil
+}
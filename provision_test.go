@@ -0,0 +1,355 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newMockProvisionServer(t *testing.T, mu *sync.Mutex, requests *[]string, faults map[string]bool) *httptest.Server {
+	t.Helper()
+
+	operations := []string{
+		"GetCapabilities", "GetServiceCapabilities", "SetHostname", "SetDNS", "SetNTP", "SetNetworkDefaultGateway",
+		"SetNetworkInterfaces", "GetSystemDateAndTime", "GetUsers", "CreateUsers", "SetUser",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		operation := ""
+		for _, op := range operations {
+			if strings.Contains(string(body), "<tds:"+op) {
+				operation = op
+
+				break
+			}
+		}
+
+		mu.Lock()
+		*requests = append(*requests, operation)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		if faults[operation] {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><soap:Fault>
+				<soap:Code><soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:ActionNotSupported</soap:Value></soap:Subcode></soap:Code>
+				<soap:Reason><soap:Text>Not supported</soap:Text></soap:Reason>
+			</soap:Fault></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		var response string
+
+		switch operation {
+		case "GetCapabilities":
+			response = `<GetCapabilitiesResponse><Capabilities><Device><XAddr>` + r.Host + `</XAddr></Device></Capabilities></GetCapabilitiesResponse>`
+		case "GetServiceCapabilities":
+			response = `<GetServiceCapabilitiesResponse><Capabilities><Network NTP="1" IPVersion6="false"/></Capabilities></GetServiceCapabilitiesResponse>`
+		case "SetNetworkInterfaces":
+			response = `<SetNetworkInterfacesResponse><RebootNeeded>false</RebootNeeded></SetNetworkInterfacesResponse>`
+		case "GetSystemDateAndTime":
+			response = `<GetSystemDateAndTimeResponse><SystemDateAndTime><DateTimeType>NTP</DateTimeType></SystemDateAndTime></GetSystemDateAndTimeResponse>`
+		case "GetUsers":
+			response = `<GetUsersResponse><User><Username>admin</Username><UserLevel>Administrator</UserLevel></User></GetUsersResponse>`
+		case "":
+			t.Errorf("Unexpected request: %s", body)
+		default:
+			response = `<` + operation + `Response/>`
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestProvision(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := newMockProvisionServer(t, &mu, &requests, map[string]bool{"SetNTP": true})
+	defer server.Close()
+
+	// Start on localhost so that the static address 127.0.0.1 is a new
+	// endpoint that still reaches the mock server.
+	endpoint := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/onvif/device_service"
+
+	client, err := NewClient(endpoint, WithCredentials("admin", "old"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = strings.Replace(endpoint, "device_service", "ptz_service", 1)
+
+	dhcp := false
+
+	report, err := client.Provision(context.Background(), &ProvisioningSpec{
+		Hostname: "camera-1",
+		DNS:      &ProvisioningDNS{Servers: []IPAddress{{Type: "IPv4", IPv4Address: "192.168.1.1"}}},
+		NTP:      &ProvisioningNTP{Servers: []NetworkHost{{Type: "DNS", DNSname: "pool.ntp.org"}}},
+		Network: &ProvisioningNetwork{
+			InterfaceToken: "eth0",
+			Config: &NetworkInterfaceSetConfiguration{
+				IPv4: &IPv4NetworkInterfaceSetConfiguration{
+					DHCP:   &dhcp,
+					Manual: []PrefixedIPv4Address{{Address: "127.0.0.1", PrefixLength: 8}},
+				},
+			},
+			Gateway:          &NetworkGateway{IPv4Address: []string{"127.0.0.254"}},
+			ReconnectTimeout: time.Second,
+			PollInterval:     10 * time.Millisecond,
+		},
+		Users: []*User{
+			{Username: "admin", Password: "new", UserLevel: "Administrator"},
+			{Username: "viewer", Password: "secret", UserLevel: "User"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision() failed: %v", err)
+	}
+
+	want := []string{
+		"GetCapabilities", "GetServiceCapabilities", "SetHostname", "SetDNS", "SetNTP", "SetNetworkDefaultGateway",
+		"SetNetworkInterfaces", "GetSystemDateAndTime", "GetUsers", "CreateUsers", "SetUser",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected requests %v, got %v", want, requests)
+	}
+
+	steps := make(map[string]*ProvisioningStepResult)
+	for _, step := range report.Steps {
+		steps[step.Step] = step
+	}
+
+	if len(report.Steps) != 5 || !steps[ProvisionStepNTP].Skipped || steps[ProvisionStepNetwork].Err != nil {
+		t.Errorf("Unexpected steps: %+v", report.Steps)
+	}
+
+	wantEndpoint := server.URL + "/onvif/device_service"
+	if report.Endpoint != wantEndpoint || client.Endpoint() != wantEndpoint {
+		t.Errorf("Expected endpoint %s, got %s", wantEndpoint, report.Endpoint)
+	}
+
+	if client.ptzEndpoint != server.URL+"/onvif/ptz_service" {
+		t.Errorf("Expected PTZ endpoint to move, got %s", client.ptzEndpoint)
+	}
+
+	if _, password := client.GetCredentials(); password != "new" {
+		t.Errorf("Expected client password to be updated, got %s", password)
+	}
+}
+
+func TestProvisionUnreachableAfterNetworkChange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := newMockProvisionServer(t, &mu, &requests, nil)
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	dhcp := false
+
+	report, err := client.Provision(context.Background(), &ProvisioningSpec{
+		Network: &ProvisioningNetwork{
+			InterfaceToken: "eth0",
+			Config: &NetworkInterfaceSetConfiguration{
+				IPv4: &IPv4NetworkInterfaceSetConfiguration{
+					DHCP:   &dhcp,
+					Manual: []PrefixedIPv4Address{{Address: "127.0.0.2", PrefixLength: 8}},
+				},
+			},
+			ReconnectTimeout: 100 * time.Millisecond,
+			PollInterval:     10 * time.Millisecond,
+		},
+		Users: []*User{{Username: "viewer", Password: "secret", UserLevel: "User"}},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	if len(report.Steps) != 2 || report.Steps[0].Err == nil || !report.Steps[1].Skipped {
+		t.Errorf("Unexpected steps: %+v", report.Steps)
+	}
+
+	if !strings.Contains(report.Endpoint, "127.0.0.2") {
+		t.Errorf("Expected endpoint on the new address, got %s", report.Endpoint)
+	}
+
+	if _, err := client.Provision(context.Background(), nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter, got %v", err)
+	}
+}
+
+func TestProvisionChecksNetworkCapabilities(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := newMockProvisionServer(t, &mu, &requests, nil)
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/device_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	report, err := client.Provision(context.Background(), &ProvisioningSpec{
+		DNS: &ProvisioningDNS{Servers: []IPAddress{{Type: "IPv6", IPv6Address: "2001:db8::1"}}},
+		NTP: &ProvisioningNTP{Servers: []NetworkHost{
+			{Type: NetworkHostDNS, DNSname: "0.pool.ntp.org"},
+			{Type: NetworkHostDNS, DNSname: "1.pool.ntp.org"},
+		}},
+		Network: &ProvisioningNetwork{
+			InterfaceToken: "eth0",
+			Config:         &NetworkInterfaceSetConfiguration{MTU: 1500},
+			Gateway:        &NetworkGateway{IPv6Address: []string{"2001:db8::fe"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision() failed: %v", err)
+	}
+
+	for _, step := range report.Steps {
+		if !step.Skipped || step.Reason == "" {
+			t.Errorf("Expected step %s to be skipped, got %+v", step.Step, step)
+		}
+	}
+
+	want := []string{"GetCapabilities", "GetServiceCapabilities"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestProvisionKeepsEndpointWithoutDHCPChange(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	server := newMockProvisionServer(t, &mu, &requests, nil)
+	defer server.Close()
+
+	endpoint := server.URL + "/onvif/device_service"
+
+	client, err := NewClient(endpoint)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	report, err := client.Provision(context.Background(), &ProvisioningSpec{
+		Network: &ProvisioningNetwork{
+			InterfaceToken: "eth0",
+			Config: &NetworkInterfaceSetConfiguration{
+				IPv4: &IPv4NetworkInterfaceSetConfiguration{
+					Manual: []PrefixedIPv4Address{{Address: "127.0.0.2", PrefixLength: 8}},
+				},
+			},
+			ReconnectTimeout: time.Second,
+			PollInterval:     10 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision() failed: %v", err)
+	}
+
+	if report.Endpoint != endpoint {
+		t.Errorf("Expected endpoint to stay %s without DHCP turned off, got %s", endpoint, report.Endpoint)
+	}
+}
+
+func TestProvisionProbesNewAddressAfterLostResponse(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	mock := newMockProvisionServer(t, &mu, &requests, nil)
+	defer mock.Close()
+
+	// The device applies the new address at once and drops the connection
+	// instead of answering SetNetworkInterfaces.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "<tds:SetNetworkInterfaces") {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+
+			return
+		}
+
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		mock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	endpoint := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/onvif/device_service"
+
+	client, err := NewClient(endpoint)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	dhcp := false
+
+	report, err := client.Provision(context.Background(), &ProvisioningSpec{
+		Network: &ProvisioningNetwork{
+			InterfaceToken: "eth0",
+			Config: &NetworkInterfaceSetConfiguration{
+				IPv4: &IPv4NetworkInterfaceSetConfiguration{
+					DHCP:   &dhcp,
+					Manual: []PrefixedIPv4Address{{Address: "127.0.0.1", PrefixLength: 8}},
+				},
+			},
+			ReconnectTimeout: time.Second,
+			PollInterval:     10 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Provision() failed: %v", err)
+	}
+
+	if wantEndpoint := server.URL + "/onvif/device_service"; report.Endpoint != wantEndpoint {
+		t.Errorf("Expected endpoint %s, got %s", wantEndpoint, report.Endpoint)
+	}
+}
+
+func TestMoveEndpointDropsMediaURIs(t *testing.T) {
+	client, err := NewClient("http://192.168.1.10/onvif/device_service", WithMediaURICache())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.mediaEndpoint = "http://192.168.1.10/onvif/media_service"
+	client.mediaURIs.entries["GetStreamURI/Profile_1"] = mediaURIEntry{uri: &MediaURI{URI: "rtsp://192.168.1.10/stream"}}
+
+	client.moveEndpoint("http://192.168.1.20/onvif/device_service")
+
+	if client.mediaEndpoint != "http://192.168.1.20/onvif/media_service" {
+		t.Errorf("Expected the media endpoint to move, got %s", client.mediaEndpoint)
+	}
+
+	if len(client.mediaURIs.entries) != 0 {
+		t.Errorf("Expected the cached URIs of the old address to be dropped, got %v", client.mediaURIs.entries)
+	}
+}
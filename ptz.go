@@ -3,83 +3,103 @@ package onvif
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-
-	"github.com/0x524a/onvif-go/internal/soap"
+	"strings"
+	"time"
 )
 
 // PTZ service namespace.
 const ptzNamespace = "http://www.onvif.org/ver20/ptz/wsdl"
 
-// ContinuousMove starts continuous PTZ movement.
+// ContinuousMove starts continuous PTZ movement. Velocity components without a
+// space are sent in the default velocity space of the profile's PTZ
+// configuration, or the generic velocity space, and values are clamped to the
+// range of their space; see GetPTZSpaces. When the node advertises neither,
+// ErrInvalidParameter is returned. If the spaces cannot be read, values in the
+// generic velocity space are limited to [-1, 1]. A velocity with neither
+// pan/tilt nor zoom returns ErrInvalidParameter. Timeout is an xs:duration,
+// e.g. "PT5S".
 func (c *Client) ContinuousMove(ctx context.Context, profileToken string, velocity *PTZSpeed, timeout *string) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
 		return ErrServiceNotSupported
 	}
 
+	if velocity.empty() {
+		return fmt.Errorf("ContinuousMove failed: %w: velocity has no pan/tilt or zoom", ErrInvalidParameter)
+	}
+
 	type ContinuousMove struct {
 		XMLName      xml.Name `xml:"tptz:ContinuousMove"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		XmlnsTT      string   `xml:"xmlns:tt,attr"`
 		ProfileToken string   `xml:"tptz:ProfileToken"`
 		Velocity     *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Velocity"`
 		Timeout *string `xml:"tptz:Timeout,omitempty"`
 	}
 
+	if spaces, config := c.moveSpaces(ctx, "ContinuousMove", profileToken); spaces != nil {
+		var err error
+		if velocity, err = velocity.clampVelocity(spaces, config); err != nil {
+			return fmt.Errorf("ContinuousMove failed: %w", err)
+		}
+	} else {
+		velocity = velocity.normalizeVelocity()
+	}
+
 	req := ContinuousMove{
 		Xmlns:        ptzNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 		Timeout:      timeout,
 	}
 
-	if velocity != nil {
-		req.Velocity = &struct {
-			PanTilt *struct {
-				X     float64 `xml:"x,attr"`
-				Y     float64 `xml:"y,attr"`
-				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
-			Zoom *struct {
-				X     float64 `xml:"x,attr"`
-				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
-		}{}
-
-		if velocity.PanTilt != nil {
-			req.Velocity.PanTilt = &struct {
-				X     float64 `xml:"x,attr"`
-				Y     float64 `xml:"y,attr"`
-				Space string  `xml:"space,attr,omitempty"`
-			}{
-				X:     velocity.PanTilt.X,
-				Y:     velocity.PanTilt.Y,
-				Space: velocity.PanTilt.Space,
-			}
+	req.Velocity = &struct {
+		PanTilt *struct {
+			X     float64 `xml:"x,attr"`
+			Y     float64 `xml:"y,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"tt:PanTilt,omitempty"`
+		Zoom *struct {
+			X     float64 `xml:"x,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		} `xml:"tt:Zoom,omitempty"`
+	}{}
+
+	if velocity.PanTilt != nil {
+		req.Velocity.PanTilt = &struct {
+			X     float64 `xml:"x,attr"`
+			Y     float64 `xml:"y,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		}{
+			X:     velocity.PanTilt.X,
+			Y:     velocity.PanTilt.Y,
+			Space: velocity.PanTilt.Space,
 		}
+	}
 
-		if velocity.Zoom != nil {
-			req.Velocity.Zoom = &struct {
-				X     float64 `xml:"x,attr"`
-				Space string  `xml:"space,attr,omitempty"`
-			}{
-				X:     velocity.Zoom.X,
-				Space: velocity.Zoom.Space,
-			}
+	if velocity.Zoom != nil {
+		req.Velocity.Zoom = &struct {
+			X     float64 `xml:"x,attr"`
+			Space string  `xml:"space,attr,omitempty"`
+		}{
+			X:     velocity.Zoom.X,
+			Space: velocity.Zoom.Space,
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("ContinuousMove failed: %w", err)
@@ -88,43 +108,66 @@ func (c *Client) ContinuousMove(ctx context.Context, profileToken string, veloci
 	return nil
 }
 
-// AbsoluteMove moves PTZ to an absolute position.
+// AbsoluteMove moves PTZ to an absolute position. Position and speed are
+// clamped to their spaces like in ContinuousMove, see PTZVector.Clamp and
+// PTZSpeed.Clamp. A nil PanTilt or Zoom is left out of the request, so that
+// pan/tilt-only and zoom-only devices can be moved; a position with neither
+// returns ErrInvalidParameter.
 func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position *PTZVector, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
 		return ErrServiceNotSupported
 	}
 
+	if position.empty() {
+		return fmt.Errorf("AbsoluteMove failed: %w: position has no pan/tilt or zoom", ErrInvalidParameter)
+	}
+
 	type AbsoluteMove struct {
 		XMLName      xml.Name `xml:"tptz:AbsoluteMove"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		XmlnsTT      string   `xml:"xmlns:tt,attr"`
 		ProfileToken string   `xml:"tptz:ProfileToken"`
 		Position     *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Position"`
 		Speed *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Speed,omitempty"`
 	}
 
+	if position != nil || speed != nil {
+		if spaces, config := c.moveSpaces(ctx, "AbsoluteMove", profileToken); spaces != nil {
+			var positionErr, speedErr error
+
+			position, positionErr = position.clampPosition(spaces, config)
+			speed, speedErr = speed.clampSpeed(spaces, config)
+
+			if err := errors.Join(positionErr, speedErr); err != nil {
+				return fmt.Errorf("AbsoluteMove failed: %w", err)
+			}
+		}
+	}
+
 	req := AbsoluteMove{
 		Xmlns:        ptzNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 	}
 
@@ -134,11 +177,11 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if position.PanTilt != nil {
@@ -164,17 +207,17 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 		}
 	}
 
-	if speed != nil {
+	if !speed.empty() {
 		req.Speed = &struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if speed.PanTilt != nil {
@@ -200,8 +243,7 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("AbsoluteMove failed: %w", err)
@@ -210,43 +252,65 @@ func (c *Client) AbsoluteMove(ctx context.Context, profileToken string, position
 	return nil
 }
 
-// RelativeMove moves PTZ relative to current position.
+// RelativeMove moves PTZ relative to current position. Components of the
+// translation without a space are sent in the generic translation space, and
+// translation and speed are clamped to their spaces like in ContinuousMove.
+// Like in AbsoluteMove, a nil PanTilt or Zoom is left out of the request.
 func (c *Client) RelativeMove(ctx context.Context, profileToken string, translation *PTZVector, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
 		return ErrServiceNotSupported
 	}
 
+	if translation.empty() {
+		return fmt.Errorf("RelativeMove failed: %w: translation has no pan/tilt or zoom", ErrInvalidParameter)
+	}
+
 	type RelativeMove struct {
 		XMLName      xml.Name `xml:"tptz:RelativeMove"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		XmlnsTT      string   `xml:"xmlns:tt,attr"`
 		ProfileToken string   `xml:"tptz:ProfileToken"`
 		Translation  *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Translation"`
 		Speed *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Speed,omitempty"`
 	}
 
+	if translation != nil || speed != nil {
+		if spaces, config := c.moveSpaces(ctx, "RelativeMove", profileToken); spaces != nil {
+			var translationErr, speedErr error
+
+			translation, translationErr = translation.clampTranslation(spaces, config)
+			speed, speedErr = speed.clampSpeed(spaces, config)
+
+			if err := errors.Join(translationErr, speedErr); err != nil {
+				return fmt.Errorf("RelativeMove failed: %w", err)
+			}
+		}
+	}
+
 	req := RelativeMove{
 		Xmlns:        ptzNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 	}
 
@@ -256,11 +320,11 @@ func (c *Client) RelativeMove(ctx context.Context, profileToken string, translat
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if translation.PanTilt != nil {
@@ -286,17 +350,17 @@ func (c *Client) RelativeMove(ctx context.Context, profileToken string, translat
 		}
 	}
 
-	if speed != nil {
+	if !speed.empty() {
 		req.Speed = &struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if speed.PanTilt != nil {
@@ -322,8 +386,7 @@ func (c *Client) RelativeMove(ctx context.Context, profileToken string, translat
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RelativeMove failed: %w", err)
@@ -359,8 +422,7 @@ func (c *Client) Stop(ctx context.Context, profileToken string, panTilt, zoom bo
 		req.Zoom = &zoom
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("Stop failed: %w", err)
@@ -369,7 +431,10 @@ func (c *Client) Stop(ctx context.Context, profileToken string, panTilt, zoom bo
 	return nil
 }
 
-// GetStatus retrieves PTZ status.
+// GetStatus retrieves the PTZ status of a profile: the position, the move
+// status of pan/tilt and zoom, the error and the device time. Position and
+// MoveStatus are nil when the device omits them, as some do while moving.
+// The single move status of PTZ 1.0 devices applies to pan/tilt and zoom.
 func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus, error) {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -399,6 +464,8 @@ func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus
 			MoveStatus *struct {
 				PanTilt string `xml:"PanTilt"`
 				Zoom    string `xml:"Zoom"`
+				// Status is the single move status of PTZ 1.0 devices.
+				Status string `xml:",chardata"`
 			} `xml:"MoveStatus"`
 			Error   string `xml:"Error"`
 			UTCTime string `xml:"UtcTime"`
@@ -412,8 +479,7 @@ func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus
 
 	var resp GetStatusResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetStatus failed: %w", err)
@@ -440,10 +506,21 @@ func (c *Client) GetStatus(ctx context.Context, profileToken string) (*PTZStatus
 		}
 	}
 
-	if resp.PTZStatus.MoveStatus != nil {
+	if moveStatus := resp.PTZStatus.MoveStatus; moveStatus != nil {
 		status.MoveStatus = &PTZMoveStatus{
-			PanTilt: resp.PTZStatus.MoveStatus.PanTilt,
-			Zoom:    resp.PTZStatus.MoveStatus.Zoom,
+			PanTilt: strings.TrimSpace(moveStatus.PanTilt),
+			Zoom:    strings.TrimSpace(moveStatus.Zoom),
+		}
+
+		if legacy := strings.TrimSpace(moveStatus.Status); legacy != "" &&
+			status.MoveStatus.PanTilt == "" && status.MoveStatus.Zoom == "" {
+			status.MoveStatus.PanTilt, status.MoveStatus.Zoom = legacy, legacy
+		}
+	}
+
+	if resp.PTZStatus.UTCTime != "" {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(resp.PTZStatus.UTCTime)); err == nil {
+			status.UTCTime = t
 		}
 	}
 
@@ -489,8 +566,7 @@ func (c *Client) GetPresets(ctx context.Context, profileToken string) ([]*PTZPre
 
 	var resp GetPresetsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetPresets failed: %w", err)
@@ -526,7 +602,9 @@ func (c *Client) GetPresets(ctx context.Context, profileToken string) ([]*PTZPre
 	return presets, nil
 }
 
-// GotoPreset moves PTZ to a preset position.
+// GotoPreset moves PTZ to a preset position. The speed is clamped to the
+// speed spaces of the profile's PTZ node, see PTZSpeed.Clamp; a nil or empty
+// speed leaves the device default.
 func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken string, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -536,6 +614,7 @@ func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken strin
 	type GotoPreset struct {
 		XMLName      xml.Name `xml:"tptz:GotoPreset"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		XmlnsTT      string   `xml:"xmlns:tt,attr"`
 		ProfileToken string   `xml:"tptz:ProfileToken"`
 		PresetToken  string   `xml:"tptz:PresetToken"`
 		Speed        *struct {
@@ -543,31 +622,41 @@ func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken strin
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Speed,omitempty"`
 	}
 
+	if speed != nil {
+		if spaces, config := c.moveSpaces(ctx, "GotoPreset", profileToken); spaces != nil {
+			var err error
+			if speed, err = speed.clampSpeed(spaces, config); err != nil {
+				return fmt.Errorf("GotoPreset failed: %w", err)
+			}
+		}
+	}
+
 	req := GotoPreset{
 		Xmlns:        ptzNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 		PresetToken:  presetToken,
 	}
 
-	if speed != nil {
+	if !speed.empty() {
 		req.Speed = &struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if speed.PanTilt != nil {
@@ -593,8 +682,7 @@ func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken strin
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("GotoPreset failed: %w", err)
@@ -603,7 +691,10 @@ func (c *Client) GotoPreset(ctx context.Context, profileToken, presetToken strin
 	return nil
 }
 
-// SetPreset sets a preset position.
+// SetPreset stores the current position as a preset and returns its token.
+// With an empty presetToken a new preset is created and the device assigns
+// the token; otherwise the preset with that token is overwritten. An empty
+// presetName lets the device choose the name.
 func (c *Client) SetPreset(ctx context.Context, profileToken, presetName, presetToken string) (string, error) {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -631,19 +722,24 @@ func (c *Client) SetPreset(ctx context.Context, profileToken, presetName, preset
 	if presetName != "" {
 		req.PresetName = &presetName
 	}
+
 	if presetToken != "" {
 		req.PresetToken = &presetToken
 	}
 
 	var resp SetPresetResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return "", fmt.Errorf("SetPreset failed: %w", err)
 	}
 
+	// Some devices leave the token out when overwriting an existing preset.
+	if resp.PresetToken == "" {
+		return presetToken, nil
+	}
+
 	return resp.PresetToken, nil
 }
 
@@ -667,8 +763,7 @@ func (c *Client) RemovePreset(ctx context.Context, profileToken, presetToken str
 		PresetToken:  presetToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("RemovePreset failed: %w", err)
@@ -677,7 +772,8 @@ func (c *Client) RemovePreset(ctx context.Context, profileToken, presetToken str
 	return nil
 }
 
-// GotoHomePosition moves PTZ to home position.
+// GotoHomePosition moves PTZ to the home position. The speed is clamped like
+// the one of GotoPreset; a nil or empty speed leaves the device default.
 func (c *Client) GotoHomePosition(ctx context.Context, profileToken string, speed *PTZSpeed) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -687,36 +783,47 @@ func (c *Client) GotoHomePosition(ctx context.Context, profileToken string, spee
 	type GotoHomePosition struct {
 		XMLName      xml.Name `xml:"tptz:GotoHomePosition"`
 		Xmlns        string   `xml:"xmlns:tptz,attr"`
+		XmlnsTT      string   `xml:"xmlns:tt,attr"`
 		ProfileToken string   `xml:"tptz:ProfileToken"`
 		Speed        *struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		} `xml:"tptz:Speed,omitempty"`
 	}
 
+	if speed != nil {
+		if spaces, config := c.moveSpaces(ctx, "GotoHomePosition", profileToken); spaces != nil {
+			var err error
+			if speed, err = speed.clampSpeed(spaces, config); err != nil {
+				return fmt.Errorf("GotoHomePosition failed: %w", err)
+			}
+		}
+	}
+
 	req := GotoHomePosition{
 		Xmlns:        ptzNamespace,
+		XmlnsTT:      "http://www.onvif.org/ver10/schema",
 		ProfileToken: profileToken,
 	}
 
-	if speed != nil {
+	if !speed.empty() {
 		req.Speed = &struct {
 			PanTilt *struct {
 				X     float64 `xml:"x,attr"`
 				Y     float64 `xml:"y,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"PanTilt,omitempty"`
+			} `xml:"tt:PanTilt,omitempty"`
 			Zoom *struct {
 				X     float64 `xml:"x,attr"`
 				Space string  `xml:"space,attr,omitempty"`
-			} `xml:"Zoom,omitempty"`
+			} `xml:"tt:Zoom,omitempty"`
 		}{}
 
 		if speed.PanTilt != nil {
@@ -742,8 +849,7 @@ func (c *Client) GotoHomePosition(ctx context.Context, profileToken string, spee
 		}
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
 		return fmt.Errorf("GotoHomePosition failed: %w", err)
@@ -752,7 +858,9 @@ func (c *Client) GotoHomePosition(ctx context.Context, profileToken string, spee
 	return nil
 }
 
-// SetHomePosition sets the current position as home position.
+// SetHomePosition sets the current position as home position. Devices with a
+// fixed home position fault with ter:CannotOverwriteHome; the returned error
+// then matches ErrCannotOverwriteHome and still carries the SOAPFault.
 func (c *Client) SetHomePosition(ctx context.Context, profileToken string) error {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -770,17 +878,22 @@ func (c *Client) SetHomePosition(ctx context.Context, profileToken string) error
 		ProfileToken: profileToken,
 	}
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		var fault *SOAPFault
+		if errors.As(err, &fault) && fault.HasSubcode("CannotOverwriteHome") {
+			return fmt.Errorf("SetHomePosition failed: %w: %w", ErrCannotOverwriteHome, err)
+		}
+
 		return fmt.Errorf("SetHomePosition failed: %w", err)
 	}
 
 	return nil
 }
 
-// GetConfiguration retrieves PTZ configuration.
+// GetConfiguration retrieves a PTZ configuration, including its default
+// spaces, speed and timeout and its pan/tilt and zoom limits.
 func (c *Client) GetConfiguration(ctx context.Context, configurationToken string) (*PTZConfiguration, error) {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -794,13 +907,8 @@ func (c *Client) GetConfiguration(ctx context.Context, configurationToken string
 	}
 
 	type GetConfigurationResponse struct {
-		XMLName          xml.Name `xml:"GetConfigurationResponse"`
-		PTZConfiguration struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			UseCount  int    `xml:"UseCount"`
-			NodeToken string `xml:"NodeToken"`
-		} `xml:"PTZConfiguration"`
+		XMLName          xml.Name                 `xml:"GetConfigurationResponse"`
+		PTZConfiguration ptzConfigurationResponse `xml:"PTZConfiguration"`
 	}
 
 	req := GetConfiguration{
@@ -810,22 +918,18 @@ func (c *Client) GetConfiguration(ctx context.Context, configurationToken string
 
 	var resp GetConfigurationResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetConfiguration failed: %w", err)
 	}
 
-	return &PTZConfiguration{
-		Token:     resp.PTZConfiguration.Token,
-		Name:      resp.PTZConfiguration.Name,
-		UseCount:  resp.PTZConfiguration.UseCount,
-		NodeToken: resp.PTZConfiguration.NodeToken,
-	}, nil
+	return resp.PTZConfiguration.toPTZConfiguration(), nil
 }
 
-// GetConfigurations retrieves all PTZ configurations.
+// GetConfigurations retrieves all PTZ configurations, decoded like the one of
+// GetConfiguration. The spaces and speed ranges the node supports are
+// reported by GetConfigurationOptions.
 func (c *Client) GetConfigurations(ctx context.Context) ([]*PTZConfiguration, error) {
 	endpoint := c.ptzEndpoint
 	if endpoint == "" {
@@ -838,13 +942,8 @@ func (c *Client) GetConfigurations(ctx context.Context) ([]*PTZConfiguration, er
 	}
 
 	type GetConfigurationsResponse struct {
-		XMLName          xml.Name `xml:"GetConfigurationsResponse"`
-		PTZConfiguration []struct {
-			Token     string `xml:"token,attr"`
-			Name      string `xml:"Name"`
-			UseCount  int    `xml:"UseCount"`
-			NodeToken string `xml:"NodeToken"`
-		} `xml:"PTZConfiguration"`
+		XMLName          xml.Name                   `xml:"GetConfigurationsResponse"`
+		PTZConfiguration []ptzConfigurationResponse `xml:"PTZConfiguration"`
 	}
 
 	req := GetConfigurations{
@@ -853,22 +952,371 @@ func (c *Client) GetConfigurations(ctx context.Context) ([]*PTZConfiguration, er
 
 	var resp GetConfigurationsResponse
 
-	username, password := c.GetCredentials()
-	soapClient := soap.NewClient(c.httpClient, username, password)
+	soapClient := c.newSOAPClient()
 
 	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
 		return nil, fmt.Errorf("GetConfigurations failed: %w", err)
 	}
 
 	configs := make([]*PTZConfiguration, len(resp.PTZConfiguration))
-	for i, cfg := range resp.PTZConfiguration {
-		configs[i] = &PTZConfiguration{
-			Token:     cfg.Token,
-			Name:      cfg.Name,
-			UseCount:  cfg.UseCount,
-			NodeToken: cfg.NodeToken,
-		}
+	for i := range resp.PTZConfiguration {
+		configs[i] = resp.PTZConfiguration[i].toPTZConfiguration()
 	}
 
 	return configs, nil
 }
+
+// Generic PTZ spaces defined by the ONVIF PTZ specification.
+const (
+	PanTiltPositionGenericSpace    = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace"
+	ZoomPositionGenericSpace       = "http://www.onvif.org/ver10/tptz/ZoomSpaces/PositionGenericSpace"
+	PanTiltTranslationGenericSpace = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationGenericSpace"
+	ZoomTranslationGenericSpace    = "http://www.onvif.org/ver10/tptz/ZoomSpaces/TranslationGenericSpace"
+	PanTiltVelocityGenericSpace    = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace"
+	ZoomVelocityGenericSpace       = "http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace"
+	PanTiltGenericSpeedSpace       = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/GenericSpeedSpace"
+	ZoomGenericSpeedSpace          = "http://www.onvif.org/ver10/tptz/ZoomSpaces/ZoomGenericSpeedSpace"
+)
+
+// floatRangeXML is the wire form of tt:FloatRange.
+type floatRangeXML struct {
+	Min float64 `xml:"Min"`
+	Max float64 `xml:"Max"`
+}
+
+// space2DXML is the wire form of tt:Space2DDescription.
+type space2DXML struct {
+	URI    string        `xml:"URI"`
+	XRange floatRangeXML `xml:"XRange"`
+	YRange floatRangeXML `xml:"YRange"`
+}
+
+// space1DXML is the wire form of tt:Space1DDescription.
+type space1DXML struct {
+	URI    string        `xml:"URI"`
+	XRange floatRangeXML `xml:"XRange"`
+}
+
+// toSpaces2D converts 2D space descriptions from their wire form.
+func toSpaces2D(spaces []space2DXML) []*Space2DDescription {
+	result := make([]*Space2DDescription, len(spaces))
+	for i, s := range spaces {
+		result[i] = &Space2DDescription{
+			URI:    s.URI,
+			XRange: &FloatRange{Min: s.XRange.Min, Max: s.XRange.Max},
+			YRange: &FloatRange{Min: s.YRange.Min, Max: s.YRange.Max},
+		}
+	}
+
+	return result
+}
+
+// toSpaces1D converts 1D space descriptions from their wire form.
+func toSpaces1D(spaces []space1DXML) []*Space1DDescription {
+	result := make([]*Space1DDescription, len(spaces))
+	for i, s := range spaces {
+		result[i] = &Space1DDescription{
+			URI:    s.URI,
+			XRange: &FloatRange{Min: s.XRange.Min, Max: s.XRange.Max},
+		}
+	}
+
+	return result
+}
+
+// ptzConfigurationResponse is the wire form of tt:PTZConfiguration.
+type ptzConfigurationResponse struct {
+	Token                                  string `xml:"token,attr"`
+	Name                                   string `xml:"Name"`
+	UseCount                               int    `xml:"UseCount"`
+	NodeToken                              string `xml:"NodeToken"`
+	DefaultAbsolutePantTiltPositionSpace   string `xml:"DefaultAbsolutePantTiltPositionSpace"`
+	DefaultAbsoluteZoomPositionSpace       string `xml:"DefaultAbsoluteZoomPositionSpace"`
+	DefaultRelativePanTiltTranslationSpace string `xml:"DefaultRelativePanTiltTranslationSpace"`
+	DefaultRelativeZoomTranslationSpace    string `xml:"DefaultRelativeZoomTranslationSpace"`
+	DefaultContinuousPanTiltVelocitySpace  string `xml:"DefaultContinuousPanTiltVelocitySpace"`
+	DefaultContinuousZoomVelocitySpace     string `xml:"DefaultContinuousZoomVelocitySpace"`
+	DefaultPTZSpeed                        *struct {
+		PanTilt *struct {
+			X     float64 `xml:"x,attr"`
+			Y     float64 `xml:"y,attr"`
+			Space string  `xml:"space,attr"`
+		} `xml:"PanTilt"`
+		Zoom *struct {
+			X     float64 `xml:"x,attr"`
+			Space string  `xml:"space,attr"`
+		} `xml:"Zoom"`
+	} `xml:"DefaultPTZSpeed"`
+	DefaultPTZTimeout string `xml:"DefaultPTZTimeout"`
+	PanTiltLimits     *struct {
+		Range space2DXML `xml:"Range"`
+	} `xml:"PanTiltLimits"`
+	ZoomLimits *struct {
+		Range space1DXML `xml:"Range"`
+	} `xml:"ZoomLimits"`
+}
+
+// toPTZConfiguration converts a decoded PTZ configuration. An unparsable
+// default timeout is left at zero, meaning unknown.
+func (r *ptzConfigurationResponse) toPTZConfiguration() *PTZConfiguration {
+	config := &PTZConfiguration{
+		Token:                                  r.Token,
+		Name:                                   r.Name,
+		UseCount:                               r.UseCount,
+		NodeToken:                              r.NodeToken,
+		DefaultAbsolutePantTiltPositionSpace:   r.DefaultAbsolutePantTiltPositionSpace,
+		DefaultAbsoluteZoomPositionSpace:       r.DefaultAbsoluteZoomPositionSpace,
+		DefaultRelativePanTiltTranslationSpace: r.DefaultRelativePanTiltTranslationSpace,
+		DefaultRelativeZoomTranslationSpace:    r.DefaultRelativeZoomTranslationSpace,
+		DefaultContinuousPanTiltVelocitySpace:  r.DefaultContinuousPanTiltVelocitySpace,
+		DefaultContinuousZoomVelocitySpace:     r.DefaultContinuousZoomVelocitySpace,
+	}
+
+	if speed := r.DefaultPTZSpeed; speed != nil {
+		config.DefaultPTZSpeed = &PTZSpeed{}
+
+		if speed.PanTilt != nil {
+			config.DefaultPTZSpeed.PanTilt = &Vector2D{X: speed.PanTilt.X, Y: speed.PanTilt.Y, Space: speed.PanTilt.Space}
+		}
+
+		if speed.Zoom != nil {
+			config.DefaultPTZSpeed.Zoom = &Vector1D{X: speed.Zoom.X, Space: speed.Zoom.Space}
+		}
+	}
+
+	if r.DefaultPTZTimeout != "" {
+		config.DefaultPTZTimeout, _ = parseDuration(r.DefaultPTZTimeout)
+	}
+
+	if r.PanTiltLimits != nil {
+		config.PanTiltLimits = &PanTiltLimits{Range: toSpaces2D([]space2DXML{r.PanTiltLimits.Range})[0]}
+	}
+
+	if r.ZoomLimits != nil {
+		config.ZoomLimits = &ZoomLimits{Range: toSpaces1D([]space1DXML{r.ZoomLimits.Range})[0]}
+	}
+
+	return config
+}
+
+// GetConfigurationOptions retrieves the options of a PTZ configuration,
+// including the coordinate and speed spaces supported by its PTZ node.
+func (c *Client) GetConfigurationOptions(ctx context.Context, configurationToken string) (*PTZConfigurationOptions, error) {
+	endpoint := c.ptzEndpoint
+	if endpoint == "" {
+		return nil, ErrServiceNotSupported
+	}
+
+	type GetConfigurationOptions struct {
+		XMLName            xml.Name `xml:"tptz:GetConfigurationOptions"`
+		Xmlns              string   `xml:"xmlns:tptz,attr"`
+		ConfigurationToken string   `xml:"tptz:ConfigurationToken"`
+	}
+
+	type GetConfigurationOptionsResponse struct {
+		XMLName                 xml.Name `xml:"GetConfigurationOptionsResponse"`
+		PTZConfigurationOptions struct {
+			Spaces *struct {
+				AbsolutePanTiltPositionSpace    []space2DXML `xml:"AbsolutePanTiltPositionSpace"`
+				AbsoluteZoomPositionSpace       []space1DXML `xml:"AbsoluteZoomPositionSpace"`
+				RelativePanTiltTranslationSpace []space2DXML `xml:"RelativePanTiltTranslationSpace"`
+				RelativeZoomTranslationSpace    []space1DXML `xml:"RelativeZoomTranslationSpace"`
+				ContinuousPanTiltVelocitySpace  []space2DXML `xml:"ContinuousPanTiltVelocitySpace"`
+				ContinuousZoomVelocitySpace     []space1DXML `xml:"ContinuousZoomVelocitySpace"`
+				PanTiltSpeedSpace               []space1DXML `xml:"PanTiltSpeedSpace"`
+				ZoomSpeedSpace                  []space1DXML `xml:"ZoomSpeedSpace"`
+			} `xml:"Spaces"`
+			PTZTimeout *struct {
+				Min string `xml:"Min"`
+				Max string `xml:"Max"`
+			} `xml:"PTZTimeout"`
+		} `xml:"PTZConfigurationOptions"`
+	}
+
+	req := GetConfigurationOptions{
+		Xmlns:              ptzNamespace,
+		ConfigurationToken: configurationToken,
+	}
+
+	var resp GetConfigurationOptionsResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetConfigurationOptions failed: %w", err)
+	}
+
+	options := &PTZConfigurationOptions{}
+
+	if s := resp.PTZConfigurationOptions.Spaces; s != nil {
+		options.Spaces = &PTZSpaces{
+			AbsolutePanTiltPositionSpace:    toSpaces2D(s.AbsolutePanTiltPositionSpace),
+			AbsoluteZoomPositionSpace:       toSpaces1D(s.AbsoluteZoomPositionSpace),
+			RelativePanTiltTranslationSpace: toSpaces2D(s.RelativePanTiltTranslationSpace),
+			RelativeZoomTranslationSpace:    toSpaces1D(s.RelativeZoomTranslationSpace),
+			ContinuousPanTiltVelocitySpace:  toSpaces2D(s.ContinuousPanTiltVelocitySpace),
+			ContinuousZoomVelocitySpace:     toSpaces1D(s.ContinuousZoomVelocitySpace),
+			PanTiltSpeedSpace:               toSpaces1D(s.PanTiltSpeedSpace),
+			ZoomSpeedSpace:                  toSpaces1D(s.ZoomSpeedSpace),
+		}
+	}
+
+	if t := resp.PTZConfigurationOptions.PTZTimeout; t != nil {
+		// Unparsable timeouts are left at zero, meaning unknown.
+		options.PTZTimeoutMin, _ = parseDuration(t.Min)
+		options.PTZTimeoutMax, _ = parseDuration(t.Max)
+	}
+
+	return options, nil
+}
+
+// GetPTZSpaces returns the coordinate and speed spaces supported by the PTZ
+// configuration of a media profile. The result is cached per profile token,
+// and so is a lookup that failed definitively, with ErrNotFound or
+// ErrActionNotSupported, or an HTTP 500 with WithLenientServerErrors, so that
+// moves on a device without PTZ configuration options do not query it again on
+// every call. Other errors, such as timeouts, authentication failures or server
+// errors, are returned without being cached.
+// AddPTZConfiguration, RemovePTZConfiguration and DeleteProfile reset the
+// cache of their profile.
+func (c *Client) GetPTZSpaces(ctx context.Context, profileToken string) (*PTZSpaces, error) {
+	result := c.ptzSpacesOf(ctx, profileToken)
+
+	return result.spaces, result.err
+}
+
+// ptzSpacesOf returns the PTZ spaces of a profile together with its PTZ
+// configuration, whose default spaces place moves without a space, caching
+// them as described for GetPTZSpaces.
+func (c *Client) ptzSpacesOf(ctx context.Context, profileToken string) ptzSpacesResult {
+	c.ptzSpacesMu.Lock()
+	cached, ok := c.ptzSpaces[profileToken]
+	c.ptzSpacesMu.Unlock()
+
+	if ok {
+		return cached
+	}
+
+	spaces, config, err := c.lookupPTZSpaces(ctx, profileToken)
+	if err != nil && !errors.Is(err, ErrNotFound) && !isUnsupportedError(err) {
+		return ptzSpacesResult{err: err}
+	}
+
+	result := ptzSpacesResult{spaces: spaces, config: config, err: err}
+
+	c.ptzSpacesMu.Lock()
+	if c.ptzSpaces == nil {
+		c.ptzSpaces = make(map[string]ptzSpacesResult)
+	}
+	c.ptzSpaces[profileToken] = result
+	c.ptzSpacesMu.Unlock()
+
+	return result
+}
+
+// ptzSpacesResult is a cached GetPTZSpaces result.
+type ptzSpacesResult struct {
+	spaces *PTZSpaces
+	config *PTZConfiguration
+	err    error
+}
+
+// resetPTZSpaces drops the cached PTZ spaces of a profile.
+func (c *Client) resetPTZSpaces(profileToken string) {
+	c.ptzSpacesMu.Lock()
+	delete(c.ptzSpaces, profileToken)
+	c.ptzSpacesMu.Unlock()
+}
+
+// lookupPTZSpaces reads the PTZ spaces and the PTZ configuration of a profile
+// from the device.
+func (c *Client) lookupPTZSpaces(ctx context.Context, profileToken string) (*PTZSpaces, *PTZConfiguration, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var config *PTZConfiguration
+
+	for _, profile := range profiles {
+		if profile.Token == profileToken && profile.PTZConfiguration != nil {
+			config = profile.PTZConfiguration
+		}
+	}
+
+	if config == nil || config.Token == "" {
+		return nil, nil, fmt.Errorf("%w: no PTZ configuration in profile %s", ErrNotFound, profileToken)
+	}
+
+	options, err := c.GetConfigurationOptions(ctx, config.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if options.Spaces == nil {
+		return &PTZSpaces{}, config, nil
+	}
+
+	return options.Spaces, config, nil
+}
+
+// moveSpaces returns the PTZ spaces and the PTZ configuration of the profile
+// for clamping the arguments of op, or nil spaces when they cannot be
+// retrieved.
+func (c *Client) moveSpaces(ctx context.Context, op, profileToken string) (*PTZSpaces, *PTZConfiguration) {
+	result := c.ptzSpacesOf(ctx, profileToken)
+	if result.err != nil {
+		c.logf("onvif: %s: PTZ spaces not checked: %v", op, result.err)
+
+		return nil, nil
+	}
+
+	return result.spaces, result.config
+}
+
+// findSpace2D returns the space with the given URI, or nil if it is not
+// advertised. Without a URI, the first of the preferred spaces that is
+// advertised is returned, or nil if there is none.
+func findSpace2D(spaces []*Space2DDescription, uri string, preferred ...string) *Space2DDescription {
+	candidates := preferred
+	if uri != "" {
+		candidates = []string{uri}
+	}
+
+	for _, candidate := range candidates {
+		for _, space := range spaces {
+			if candidate != "" && space.URI == candidate {
+				return space
+			}
+		}
+	}
+
+	return nil
+}
+
+// findSpace1D is the 1D variant of findSpace2D.
+func findSpace1D(spaces []*Space1DDescription, uri string, preferred ...string) *Space1DDescription {
+	candidates := preferred
+	if uri != "" {
+		candidates = []string{uri}
+	}
+
+	for _, candidate := range candidates {
+		for _, space := range spaces {
+			if candidate != "" && space.URI == candidate {
+				return space
+			}
+		}
+	}
+
+	return nil
+}
+
+// clamp limits v to the range. A nil or empty range leaves v unchanged.
+func (r *FloatRange) clamp(v float64) float64 {
+	if r == nil || r.Min > r.Max || (r.Min == 0 && r.Max == 0) {
+		return v
+	}
+
+	return min(max(v, r.Min), r.Max)
+}
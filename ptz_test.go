@@ -0,0 +1,828 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPTZConfigurationOptionsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+	<soap:Body>
+		<tptz:GetConfigurationOptionsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+			<tptz:PTZConfigurationOptions>
+				<tt:Spaces>
+					<tt:ContinuousPanTiltVelocitySpace>
+						<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace</tt:URI>
+						<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+						<tt:YRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:YRange>
+					</tt:ContinuousPanTiltVelocitySpace>
+					<tt:ContinuousPanTiltVelocitySpace>
+						<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees</tt:URI>
+						<tt:XRange><tt:Min>-120</tt:Min><tt:Max>120</tt:Max></tt:XRange>
+						<tt:YRange><tt:Min>-60</tt:Min><tt:Max>60</tt:Max></tt:YRange>
+					</tt:ContinuousPanTiltVelocitySpace>
+					<tt:ContinuousZoomVelocitySpace>
+						<tt:URI>http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace</tt:URI>
+						<tt:XRange><tt:Min>-1</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+					</tt:ContinuousZoomVelocitySpace>
+					<tt:PanTiltSpeedSpace>
+						<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/GenericSpeedSpace</tt:URI>
+						<tt:XRange><tt:Min>0</tt:Min><tt:Max>1</tt:Max></tt:XRange>
+					</tt:PanTiltSpeedSpace>
+				</tt:Spaces>
+				<tt:PTZTimeout><tt:Min>PT1S</tt:Min><tt:Max>PT1M</tt:Max></tt:PTZTimeout>
+			</tptz:PTZConfigurationOptions>
+		</tptz:GetConfigurationOptionsResponse>
+	</soap:Body>
+</soap:Envelope>`
+
+func TestContinuousMoveVelocitySpace(t *testing.T) {
+	var moveRequests []string
+
+	optionsRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+		<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name>
+			<tt:PTZConfiguration token="PTZ_1"><tt:Name>PTZ</tt:Name><tt:NodeToken>Node_1</tt:NodeToken></tt:PTZConfiguration>
+		</trt:Profiles>
+	</trt:GetProfilesResponse>
+</soap:Body></soap:Envelope>`))
+		case strings.Contains(string(body), "GetConfigurationOptions"):
+			optionsRequests++
+
+			if !strings.Contains(string(body), "<tptz:ConfigurationToken>PTZ_1</tptz:ConfigurationToken>") {
+				t.Errorf("Unexpected GetConfigurationOptions request: %s", body)
+			}
+
+			_, _ = w.Write([]byte(testPTZConfigurationOptionsResponse))
+		case strings.Contains(string(body), "ContinuousMove"):
+			moveRequests = append(moveRequests, string(body))
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><tptz:ContinuousMoveResponse/></soap:Body></soap:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	ctx := context.Background()
+
+	spaces, err := client.GetPTZSpaces(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetPTZSpaces() failed: %v", err)
+	}
+
+	if len(spaces.ContinuousPanTiltVelocitySpace) != 2 || spaces.ContinuousPanTiltVelocitySpace[1].XRange.Max != 120 {
+		t.Errorf("Unexpected pan/tilt velocity spaces: %+v", spaces.ContinuousPanTiltVelocitySpace)
+	}
+
+	velocity := &PTZSpeed{
+		PanTilt: &Vector2D{X: 5, Y: -0.5},
+		Zoom:    &Vector1D{X: -3},
+	}
+
+	if err := client.ContinuousMove(ctx, "Profile_1", velocity, nil); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`x="1" y="-0.5" space="http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace"`,
+		`x="-1" space="http://www.onvif.org/ver10/tptz/ZoomSpaces/VelocityGenericSpace"`,
+	} {
+		if !strings.Contains(moveRequests[0], want) {
+			t.Errorf("Request missing %s: %s", want, moveRequests[0])
+		}
+	}
+
+	if velocity.PanTilt.X != 5 || velocity.PanTilt.Space != "" {
+		t.Error("ContinuousMove must not modify the caller's velocity")
+	}
+
+	degrees := &PTZSpeed{PanTilt: &Vector2D{X: 90, Y: 90, Space: "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees"}}
+	if err := client.ContinuousMove(ctx, "Profile_1", degrees, nil); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	if !strings.Contains(moveRequests[1], `x="90" y="60"`) {
+		t.Errorf("Expected velocity clamped to the degrees space: %s", moveRequests[1])
+	}
+
+	if optionsRequests != 1 {
+		t.Errorf("Expected PTZ spaces to be cached, got %d GetConfigurationOptions requests", optionsRequests)
+	}
+}
+
+func TestContinuousMoveWithoutSpaces(t *testing.T) {
+	var (
+		requests []string
+		lookups  int
+		subcode  string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			lookups++
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><soap:Fault>` +
+				`<soap:Code><soap:Value>soap:Receiver</soap:Value>` + subcode + `</soap:Code>` +
+				`<soap:Reason><soap:Text>Unavailable</soap:Text></soap:Reason></soap:Fault></soap:Body></soap:Envelope>`))
+		default:
+			requests = append(requests, string(body))
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body/></soap:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	ctx := context.Background()
+
+	velocity := &PTZSpeed{
+		PanTilt: &Vector2D{X: 2, Y: -0.25},
+		Zoom:    &Vector1D{X: 0.5, Space: "http://example.com/ZoomVelocity"},
+	}
+	timeout := "PT2S"
+
+	if err := client.ContinuousMove(ctx, "Profile_1", velocity, &timeout); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	if err := client.Stop(ctx, "Profile_1", true, false); err != nil {
+		t.Fatalf("Stop() failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	for _, want := range []string{
+		`<tt:PanTilt x="1" y="-0.25"></tt:PanTilt>`,
+		`<tt:Zoom x="0.5" space="http://example.com/ZoomVelocity"></tt:Zoom>`,
+		`<tptz:Timeout>PT2S</tptz:Timeout>`,
+	} {
+		if !strings.Contains(requests[0], want) {
+			t.Errorf("ContinuousMove request missing %s: %s", want, requests[0])
+		}
+	}
+
+	if !strings.Contains(requests[1], "<tptz:PanTilt>true</tptz:PanTilt>") || strings.Contains(requests[1], "<tptz:Zoom>") {
+		t.Errorf("Unexpected Stop request: %s", requests[1])
+	}
+
+	// A transient failure is not cached.
+	if err := client.ContinuousMove(ctx, "Profile_1", velocity, nil); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	if lookups != 2 {
+		t.Errorf("Expected 2 PTZ spaces lookups, got %d", lookups)
+	}
+
+	// A definitive failure is cached until the PTZ configuration of the profile changes.
+	subcode = `<soap:Subcode><soap:Value>ter:ActionNotSupported</soap:Value></soap:Subcode>`
+	lookups = 0
+
+	for range 2 {
+		if err := client.ContinuousMove(ctx, "Profile_1", velocity, nil); err != nil {
+			t.Fatalf("ContinuousMove() failed: %v", err)
+		}
+	}
+
+	if lookups != 1 {
+		t.Errorf("Expected 1 PTZ spaces lookup, got %d", lookups)
+	}
+
+	if err := client.AddPTZConfiguration(ctx, "Profile_1", "PTZConfig_1"); err != nil {
+		t.Fatalf("AddPTZConfiguration() failed: %v", err)
+	}
+
+	if err := client.ContinuousMove(ctx, "Profile_1", velocity, nil); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	if lookups != 2 {
+		t.Errorf("Expected PTZ spaces lookup after AddPTZConfiguration, got %d lookups", lookups)
+	}
+
+	sent := len(requests)
+	if err := client.ContinuousMove(ctx, "Profile_1", nil, nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for nil velocity, got %v", err)
+	}
+
+	if len(requests) != sent {
+		t.Errorf("ContinuousMove with nil velocity should not be sent")
+	}
+}
+
+// TestPTZSpacesLenientServerError tests that a bare HTTP 500, taken as an
+// unsupported operation with WithLenientServerErrors, is cached like
+// ActionNotSupported.
+func TestPTZSpacesLenientServerError(t *testing.T) {
+	lookups := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		lookups++
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`<html><body><h1>Internal Server Error</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithLenientServerErrors())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+
+	for range 2 {
+		if _, err := client.GetPTZSpaces(context.Background(), "Profile_1"); err == nil {
+			t.Error("Expected GetPTZSpaces() to fail")
+		}
+	}
+
+	if lookups != 1 {
+		t.Errorf("Expected 1 PTZ spaces lookup, got %d", lookups)
+	}
+}
+
+func TestMoveOmitsMissingComponents(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if strings.Contains(string(body), "GetProfiles") {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><soap:Fault>` +
+				`<soap:Code><soap:Value>soap:Receiver</soap:Value></soap:Code>` +
+				`<soap:Reason><soap:Text>Unavailable</soap:Text></soap:Reason></soap:Fault></soap:Body></soap:Envelope>`))
+
+			return
+		}
+
+		requests = append(requests, string(body))
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body/></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	ctx := context.Background()
+
+	if err := client.AbsoluteMove(ctx, "Profile_1", &PTZVector{Zoom: &Vector1D{X: 0.5}}, &PTZSpeed{}); err != nil {
+		t.Fatalf("AbsoluteMove() failed: %v", err)
+	}
+
+	speed := &PTZSpeed{PanTilt: &Vector2D{X: 0.2, Y: 0.2}}
+	if err := client.RelativeMove(ctx, "Profile_1", &PTZVector{PanTilt: &Vector2D{X: 0.1, Y: -0.1}}, speed); err != nil {
+		t.Fatalf("RelativeMove() failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	if !strings.Contains(requests[0], `<tt:Zoom x="0.5"></tt:Zoom>`) ||
+		strings.Contains(requests[0], "PanTilt") || strings.Contains(requests[0], "Speed") {
+		t.Errorf("Unexpected AbsoluteMove request: %s", requests[0])
+	}
+
+	if !strings.Contains(requests[1], `<tt:PanTilt x="0.1" y="-0.1"></tt:PanTilt>`) ||
+		!strings.Contains(requests[1], `<tt:PanTilt x="0.2" y="0.2"></tt:PanTilt>`) ||
+		strings.Contains(requests[1], "Zoom") {
+		t.Errorf("Unexpected RelativeMove request: %s", requests[1])
+	}
+
+	if err := client.AbsoluteMove(ctx, "Profile_1", &PTZVector{}, nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an empty position, got %v", err)
+	}
+
+	if err := client.RelativeMove(ctx, "Profile_1", nil, nil); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for a missing translation, got %v", err)
+	}
+}
+
+func TestPTZVectorClamp(t *testing.T) {
+	const degrees = "http://www.onvif.org/ver10/tptz/PanTiltSpaces/TranslationSpaceDegrees"
+
+	options := &PTZConfigurationOptions{Spaces: &PTZSpaces{
+		AbsolutePanTiltPositionSpace: []*Space2DDescription{
+			{URI: PanTiltPositionGenericSpace, XRange: &FloatRange{Min: -1, Max: 1}, YRange: &FloatRange{Min: -1, Max: 1}},
+		},
+		AbsoluteZoomPositionSpace: []*Space1DDescription{
+			{URI: ZoomPositionGenericSpace, XRange: &FloatRange{Min: 0, Max: 1}},
+		},
+		RelativePanTiltTranslationSpace: []*Space2DDescription{
+			{URI: degrees, XRange: &FloatRange{Min: -180, Max: 180}, YRange: &FloatRange{Min: -90, Max: 90}},
+		},
+		PanTiltSpeedSpace: []*Space1DDescription{
+			{URI: PanTiltGenericSpeedSpace, XRange: &FloatRange{Min: 0, Max: 1}},
+		},
+	}}
+
+	position := &PTZVector{PanTilt: &Vector2D{X: 2, Y: -0.5}, Zoom: &Vector1D{X: -1}}
+
+	got := position.Clamp(options)
+	if got.PanTilt.X != 1 || got.PanTilt.Y != -0.5 || got.PanTilt.Space != PanTiltPositionGenericSpace {
+		t.Errorf("Unexpected pan/tilt position: %+v", got.PanTilt)
+	}
+
+	if got.Zoom.X != 0 || got.Zoom.Space != ZoomPositionGenericSpace {
+		t.Errorf("Unexpected zoom position: %+v", got.Zoom)
+	}
+
+	if position.PanTilt.X != 2 || position.PanTilt.Space != "" {
+		t.Error("Clamp must not modify the vector")
+	}
+
+	translation := (&PTZVector{PanTilt: &Vector2D{X: 200, Y: 45, Space: degrees}}).Clamp(options)
+	if translation.PanTilt.X != 180 || translation.PanTilt.Y != 45 {
+		t.Errorf("Expected translation clamped to the degrees space, got %+v", translation.PanTilt)
+	}
+
+	speed := (&PTZSpeed{PanTilt: &Vector2D{X: 1.5, Y: -0.2}}).Clamp(options)
+	if speed.PanTilt.X != 1 || speed.PanTilt.Y != 0 || speed.PanTilt.Space != PanTiltGenericSpeedSpace {
+		t.Errorf("Unexpected speed: %+v", speed.PanTilt)
+	}
+
+	if (*PTZVector)(nil).Clamp(options) != nil || (*PTZSpeed)(nil).Clamp(nil) != nil {
+		t.Error("Clamp of nil must return nil")
+	}
+}
+
+func TestPTZNormalize(t *testing.T) {
+	vector := (&PTZVector{
+		PanTilt: &Vector2D{X: -3, Y: math.NaN(), Space: PanTiltPositionGenericSpace},
+		Zoom:    &Vector1D{X: math.Inf(1)},
+	}).Normalize()
+
+	if vector.PanTilt.X != -1 || vector.PanTilt.Y != 0 || vector.PanTilt.Space != PanTiltPositionGenericSpace {
+		t.Errorf("Unexpected pan/tilt: %+v", vector.PanTilt)
+	}
+
+	if vector.Zoom.X != 0 {
+		t.Errorf("Expected infinite zoom normalized to 0, got %v", vector.Zoom.X)
+	}
+
+	speed := (&PTZSpeed{Zoom: &Vector1D{X: 0.5}}).Normalize()
+	if speed.PanTilt != nil || speed.Zoom.X != 0.5 {
+		t.Errorf("Unexpected speed: %+v", speed)
+	}
+}
+
+func TestGotoPresetClampsSpeed(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+		<trt:Profiles token="Profile_1"><tt:PTZConfiguration token="PTZ_1"/></trt:Profiles>
+	</trt:GetProfilesResponse>
+</soap:Body></soap:Envelope>`))
+		case strings.Contains(string(body), "GetConfigurationOptions"):
+			_, _ = w.Write([]byte(testPTZConfigurationOptionsResponse))
+		case strings.Contains(string(body), "GotoPreset"):
+			request = string(body)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><tptz:GotoPresetResponse/></soap:Body></soap:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+
+	speed := &PTZSpeed{PanTilt: &Vector2D{X: 4, Y: 0.5}}
+	if err := client.GotoPreset(context.Background(), "Profile_1", "Preset_1", speed); err != nil {
+		t.Fatalf("GotoPreset() failed: %v", err)
+	}
+
+	if !strings.Contains(request, `<tt:PanTilt x="1" y="0.5" space="http://www.onvif.org/ver10/tptz/PanTiltSpaces/GenericSpeedSpace"`) {
+		t.Errorf("Expected speed clamped to the generic speed space: %s", request)
+	}
+}
+
+func TestContinuousMoveUsesDefaultSpace(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>
+	<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+		<trt:Profiles token="Profile_1"><tt:PTZConfiguration token="PTZ_1">
+			<tt:DefaultContinuousPanTiltVelocitySpace>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees</tt:DefaultContinuousPanTiltVelocitySpace>
+		</tt:PTZConfiguration></trt:Profiles>
+	</trt:GetProfilesResponse>
+</soap:Body></soap:Envelope>`))
+		case strings.Contains(string(body), "GetConfigurationOptions"):
+			_, _ = w.Write([]byte(testPTZConfigurationOptionsResponse))
+		case strings.Contains(string(body), "ContinuousMove"):
+			request = string(body)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><tptz:ContinuousMoveResponse/></soap:Body></soap:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+
+	velocity := &PTZSpeed{PanTilt: &Vector2D{X: 200, Y: 30}}
+	if err := client.ContinuousMove(context.Background(), "Profile_1", velocity, nil); err != nil {
+		t.Fatalf("ContinuousMove() failed: %v", err)
+	}
+
+	if !strings.Contains(request, `x="120" y="30" space="http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees"`) {
+		t.Errorf("Expected velocity in the configuration's default space: %s", request)
+	}
+}
+
+func TestClampWithoutDefaultOrGenericSpace(t *testing.T) {
+	spaces := &PTZSpaces{
+		ContinuousPanTiltVelocitySpace: []*Space2DDescription{
+			{URI: "http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocitySpaceDegrees"},
+		},
+		PanTiltSpeedSpace: []*Space1DDescription{
+			{URI: "http://www.onvif.org/ver10/tptz/PanTiltSpaces/SpeedSpaceDegrees", XRange: &FloatRange{Min: 0, Max: 90}},
+		},
+	}
+
+	velocity := &PTZSpeed{PanTilt: &Vector2D{X: 0.5}}
+	if _, err := velocity.clampVelocity(spaces, &PTZConfiguration{}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter instead of an arbitrary space, got %v", err)
+	}
+
+	if got := velocity.Clamp(&PTZConfigurationOptions{Spaces: spaces}); got.PanTilt.Space != "" || got.PanTilt.X != 0.5 {
+		t.Errorf("Expected the velocity left as it is, got %+v", got.PanTilt)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetPresets"):
+			response = `<tptz:GetPresetsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tptz:Preset token="1"><tt:Name>Door</tt:Name>
+					<tt:PTZPosition><tt:PanTilt x="0.25" y="-0.5"/><tt:Zoom x="0.75"/></tt:PTZPosition></tptz:Preset>
+				<tptz:Preset token="2"><tt:Name>Unset</tt:Name></tptz:Preset>
+			</tptz:GetPresetsResponse>`
+		case strings.Contains(string(body), "<tptz:PresetToken>2</tptz:PresetToken>") && strings.Contains(string(body), "SetPreset"):
+			// Overwriting an existing preset; the device omits the token.
+			response = `<tptz:SetPresetResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		case strings.Contains(string(body), "SetPreset"):
+			response = `<tptz:SetPresetResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl">
+				<tptz:PresetToken>3</tptz:PresetToken></tptz:SetPresetResponse>`
+		case strings.Contains(string(body), "GotoPreset"):
+			response = `<tptz:GotoPresetResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		case strings.Contains(string(body), "RemovePreset"):
+			response = `<tptz:RemovePresetResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	ctx := context.Background()
+
+	presets, err := client.GetPresets(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetPresets() failed: %v", err)
+	}
+
+	if len(presets) != 2 {
+		t.Fatalf("Expected 2 presets, got %d", len(presets))
+	}
+
+	if p := presets[0].PTZPosition; presets[0].Name != "Door" || p == nil || p.PanTilt == nil || p.PanTilt.Y != -0.5 ||
+		p.Zoom == nil || p.Zoom.X != 0.75 {
+		t.Errorf("Unexpected preset: %+v", presets[0])
+	}
+
+	if presets[1].Token != "2" || presets[1].PTZPosition != nil {
+		t.Errorf("Expected a preset without position, got %+v", presets[1])
+	}
+
+	token, err := client.SetPreset(ctx, "Profile_1", "Gate", "")
+	if err != nil {
+		t.Fatalf("SetPreset() failed: %v", err)
+	}
+
+	if token != "3" {
+		t.Errorf("Expected the assigned token 3, got %q", token)
+	}
+
+	if strings.Contains(requests[len(requests)-1], "PresetToken") {
+		t.Errorf("Expected no preset token when creating a preset: %s", requests[len(requests)-1])
+	}
+
+	token, err = client.SetPreset(ctx, "Profile_1", "", "2")
+	if err != nil {
+		t.Fatalf("SetPreset() failed: %v", err)
+	}
+
+	if token != "2" {
+		t.Errorf("Expected the overwritten token 2, got %q", token)
+	}
+
+	if strings.Contains(requests[len(requests)-1], "PresetName") {
+		t.Errorf("Expected no preset name: %s", requests[len(requests)-1])
+	}
+
+	if err := client.GotoPreset(ctx, "Profile_1", "3", nil); err != nil {
+		t.Fatalf("GotoPreset() failed: %v", err)
+	}
+
+	if strings.Contains(requests[len(requests)-1], "Speed") {
+		t.Errorf("Expected no speed: %s", requests[len(requests)-1])
+	}
+
+	if err := client.RemovePreset(ctx, "Profile_1", "3"); err != nil {
+		t.Fatalf("RemovePreset() failed: %v", err)
+	}
+
+	if !strings.Contains(requests[len(requests)-1], "<tptz:PresetToken>3</tptz:PresetToken>") {
+		t.Errorf("Expected the preset token: %s", requests[len(requests)-1])
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		wantPosition bool
+		wantPanTilt  string
+		wantZoom     string
+	}{
+		{
+			name: "idle with position",
+			status: `<tt:Position><tt:PanTilt x="0.5" y="-0.25" space="http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace"/>
+				<tt:Zoom x="0.1"/></tt:Position>
+				<tt:MoveStatus><tt:PanTilt>IDLE</tt:PanTilt><tt:Zoom>IDLE</tt:Zoom></tt:MoveStatus>`,
+			wantPosition: true,
+			wantPanTilt:  "IDLE",
+			wantZoom:     "IDLE",
+		},
+		{
+			name:        "moving without position",
+			status:      `<tt:MoveStatus><tt:PanTilt>MOVING</tt:PanTilt><tt:Zoom>IDLE</tt:Zoom></tt:MoveStatus>`,
+			wantPanTilt: "MOVING",
+			wantZoom:    "IDLE",
+		},
+		{
+			name:        "PTZ 1.0 move status",
+			status:      `<tt:MoveStatus>MOVING</tt:MoveStatus>`,
+			wantPanTilt: "MOVING",
+			wantZoom:    "MOVING",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` +
+					`<tptz:GetStatusResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+					`<tptz:PTZStatus>` + tt.status + `<tt:UtcTime>2026-03-04T05:06:07Z</tt:UtcTime></tptz:PTZStatus>` +
+					`</tptz:GetStatusResponse></soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			client.ptzEndpoint = server.URL
+
+			status, err := client.GetStatus(context.Background(), "Profile_1")
+			if err != nil {
+				t.Fatalf("GetStatus() failed: %v", err)
+			}
+
+			if (status.Position != nil) != tt.wantPosition {
+				t.Fatalf("Position = %+v, want present: %v", status.Position, tt.wantPosition)
+			}
+
+			if tt.wantPosition && (status.Position.PanTilt == nil || status.Position.PanTilt.X != 0.5 ||
+				status.Position.Zoom == nil || status.Position.Zoom.X != 0.1) {
+				t.Errorf("Unexpected position: %+v", status.Position)
+			}
+
+			if status.MoveStatus == nil || status.MoveStatus.PanTilt != tt.wantPanTilt || status.MoveStatus.Zoom != tt.wantZoom {
+				t.Errorf("Unexpected move status: %+v", status.MoveStatus)
+			}
+
+			if want := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC); !status.UTCTime.Equal(want) {
+				t.Errorf("UTCTime = %v, want %v", status.UTCTime, want)
+			}
+		})
+	}
+}
+
+func TestHomePosition(t *testing.T) {
+	var request string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+	<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+		<trt:Profiles token="Profile_1"><tt:PTZConfiguration token="PTZ_1"/></trt:Profiles>
+	</trt:GetProfilesResponse>
+</soap:Body></soap:Envelope>`))
+		case strings.Contains(string(body), "GetConfigurationOptions"):
+			_, _ = w.Write([]byte(testPTZConfigurationOptionsResponse))
+		case strings.Contains(string(body), "GotoHomePosition"):
+			request = string(body)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body><tptz:GotoHomePositionResponse/></soap:Body></soap:Envelope>`))
+		case strings.Contains(string(body), "SetHomePosition"):
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error">
+	<soap:Body>
+		<soap:Fault>
+			<soap:Code>
+				<soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:Action</soap:Value>
+					<soap:Subcode><soap:Value>ter:CannotOverwriteHome</soap:Value></soap:Subcode>
+				</soap:Subcode>
+			</soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">Home position is fixed</soap:Text></soap:Reason>
+		</soap:Fault>
+	</soap:Body>
+</soap:Envelope>`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+	ctx := context.Background()
+
+	speed := &PTZSpeed{PanTilt: &Vector2D{X: 2, Y: 0.5}}
+	if err := client.GotoHomePosition(ctx, "Profile_1", speed); err != nil {
+		t.Fatalf("GotoHomePosition() failed: %v", err)
+	}
+
+	if !strings.Contains(request, `<tt:PanTilt x="1" y="0.5"`) {
+		t.Errorf("Expected a clamped tt:PanTilt speed: %s", request)
+	}
+
+	if err := client.GotoHomePosition(ctx, "Profile_1", nil); err != nil {
+		t.Fatalf("GotoHomePosition() failed: %v", err)
+	}
+
+	if strings.Contains(request, "Speed") {
+		t.Errorf("Expected no speed: %s", request)
+	}
+
+	err = client.SetHomePosition(ctx, "Profile_1")
+	if !errors.Is(err, ErrCannotOverwriteHome) {
+		t.Fatalf("Expected ErrCannotOverwriteHome, got %v", err)
+	}
+
+	var fault *SOAPFault
+	if !errors.As(err, &fault) || !fault.HasSubcode("CannotOverwriteHome") {
+		t.Errorf("Expected the SOAP fault, got %v", err)
+	}
+}
+
+func TestGetConfigurations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>
+<tptz:GetConfigurationsResponse xmlns:tptz="http://www.onvif.org/ver20/ptz/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+	<tptz:PTZConfiguration token="PTZ_1">
+		<tt:Name>PTZ</tt:Name>
+		<tt:UseCount>2</tt:UseCount>
+		<tt:NodeToken>Node_1</tt:NodeToken>
+		<tt:DefaultAbsolutePantTiltPositionSpace>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:DefaultAbsolutePantTiltPositionSpace>
+		<tt:DefaultContinuousPanTiltVelocitySpace>http://www.onvif.org/ver10/tptz/PanTiltSpaces/VelocityGenericSpace</tt:DefaultContinuousPanTiltVelocitySpace>
+		<tt:DefaultPTZSpeed><tt:PanTilt x="0.5" y="0.5"/><tt:Zoom x="1"/></tt:DefaultPTZSpeed>
+		<tt:DefaultPTZTimeout>PT5S</tt:DefaultPTZTimeout>
+		<tt:PanTiltLimits><tt:Range>
+			<tt:URI>http://www.onvif.org/ver10/tptz/PanTiltSpaces/PositionGenericSpace</tt:URI>
+			<tt:XRange><tt:Min>-0.5</tt:Min><tt:Max>0.5</tt:Max></tt:XRange>
+			<tt:YRange><tt:Min>-1</tt:Min><tt:Max>0</tt:Max></tt:YRange>
+		</tt:Range></tt:PanTiltLimits>
+	</tptz:PTZConfiguration>
+	<tptz:PTZConfiguration token="PTZ_2"><tt:Name>Fixed</tt:Name><tt:NodeToken>Node_2</tt:NodeToken></tptz:PTZConfiguration>
+</tptz:GetConfigurationsResponse>
+</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.ptzEndpoint = server.URL
+
+	configs, err := client.GetConfigurations(context.Background())
+	if err != nil {
+		t.Fatalf("GetConfigurations() failed: %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configurations, got %d", len(configs))
+	}
+
+	config := configs[0]
+	if config.NodeToken != "Node_1" || config.UseCount != 2 ||
+		config.DefaultContinuousPanTiltVelocitySpace != PanTiltVelocityGenericSpace {
+		t.Errorf("Unexpected configuration: %+v", config)
+	}
+
+	if speed := config.DefaultPTZSpeed; speed == nil || speed.PanTilt == nil || speed.PanTilt.X != 0.5 ||
+		speed.Zoom == nil || speed.Zoom.X != 1 {
+		t.Errorf("Unexpected default speed: %+v", speed)
+	}
+
+	if config.DefaultPTZTimeout != 5*time.Second {
+		t.Errorf("DefaultPTZTimeout = %v, want 5s", config.DefaultPTZTimeout)
+	}
+
+	if limits := config.PanTiltLimits; limits == nil || limits.Range.XRange.Min != -0.5 || limits.Range.YRange.Max != 0 {
+		t.Errorf("Unexpected pan/tilt limits: %+v", limits)
+	}
+
+	if config.ZoomLimits != nil {
+		t.Errorf("Expected no zoom limits, got %+v", config.ZoomLimits)
+	}
+
+	if fixed := configs[1]; fixed.DefaultPTZSpeed != nil || fixed.PanTiltLimits != nil || fixed.DefaultPTZTimeout != 0 {
+		t.Errorf("Expected no defaults or limits, got %+v", fixed)
+	}
+}
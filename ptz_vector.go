@@ -0,0 +1,327 @@
+package onvif
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Clamp returns a copy of v with each component limited to the range of its
+// space in options. Components without a space are placed in the generic
+// absolute position space. A vector whose space is a relative translation
+// space is clamped as a translation. Components with a space the node does
+// not advertise, or without a space when the node does not advertise the
+// generic one, are left as they are.
+func (v *PTZVector) Clamp(options *PTZConfigurationOptions) *PTZVector {
+	if v == nil || options == nil || options.Spaces == nil {
+		return v.clone()
+	}
+
+	spaces := options.Spaces
+
+	if (v.PanTilt != nil && v.PanTilt.Space != "" &&
+		findSpace2D(spaces.RelativePanTiltTranslationSpace, v.PanTilt.Space) != nil) ||
+		(v.Zoom != nil && v.Zoom.Space != "" && findSpace1D(spaces.RelativeZoomTranslationSpace, v.Zoom.Space) != nil) {
+		result, _ := v.clampTranslation(spaces, nil)
+
+		return result
+	}
+
+	result, _ := v.clampPosition(spaces, nil)
+
+	return result
+}
+
+// Normalize returns a copy of v with each value limited to [-1, 1], the outer
+// bound of the generic spaces. NaN and infinite values become 0. Spaces are
+// kept; use Clamp for the exact ranges a device advertises.
+func (v *PTZVector) Normalize() *PTZVector {
+	if v == nil {
+		return nil
+	}
+
+	return &PTZVector{PanTilt: v.PanTilt.normalize(), Zoom: v.Zoom.normalize()}
+}
+
+// clampPosition clamps v as an absolute position. Components without a space
+// are placed in the default space of config, if set, or the generic space.
+func (v *PTZVector) clampPosition(spaces *PTZSpaces, config *PTZConfiguration) (*PTZVector, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var panTiltDefault, zoomDefault string
+	if config != nil {
+		panTiltDefault, zoomDefault = config.DefaultAbsolutePantTiltPositionSpace, config.DefaultAbsoluteZoomPositionSpace
+	}
+
+	panTilt, panTiltErr := v.PanTilt.clamp(spaces.AbsolutePanTiltPositionSpace, panTiltDefault, PanTiltPositionGenericSpace)
+	zoom, zoomErr := v.Zoom.clamp(spaces.AbsoluteZoomPositionSpace, zoomDefault, ZoomPositionGenericSpace)
+
+	return &PTZVector{PanTilt: panTilt, Zoom: zoom}, errors.Join(panTiltErr, zoomErr)
+}
+
+// clampTranslation clamps v as a relative translation, placing components
+// without a space as clampPosition does.
+func (v *PTZVector) clampTranslation(spaces *PTZSpaces, config *PTZConfiguration) (*PTZVector, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var panTiltDefault, zoomDefault string
+	if config != nil {
+		panTiltDefault, zoomDefault = config.DefaultRelativePanTiltTranslationSpace, config.DefaultRelativeZoomTranslationSpace
+	}
+
+	panTilt, panTiltErr := v.PanTilt.clamp(spaces.RelativePanTiltTranslationSpace, panTiltDefault, PanTiltTranslationGenericSpace)
+	zoom, zoomErr := v.Zoom.clamp(spaces.RelativeZoomTranslationSpace, zoomDefault, ZoomTranslationGenericSpace)
+
+	return &PTZVector{PanTilt: panTilt, Zoom: zoom}, errors.Join(panTiltErr, zoomErr)
+}
+
+// empty reports whether v has neither pan/tilt nor zoom.
+func (v *PTZVector) empty() bool {
+	return v == nil || v.PanTilt == nil && v.Zoom == nil
+}
+
+func (v *PTZVector) clone() *PTZVector {
+	if v == nil {
+		return nil
+	}
+
+	return &PTZVector{PanTilt: v.PanTilt.clone(), Zoom: v.Zoom.clone()}
+}
+
+// Clamp returns a copy of s with each component limited to the range of its
+// space in options. Components without a space are placed in the generic
+// speed space. A speed whose space is a continuous velocity space is clamped
+// as a velocity, as used by ContinuousMove. Components with a space the node
+// does not advertise, or without a space when the node does not advertise the
+// generic one, are left as they are.
+func (s *PTZSpeed) Clamp(options *PTZConfigurationOptions) *PTZSpeed {
+	if s == nil || options == nil || options.Spaces == nil {
+		return s.clone()
+	}
+
+	spaces := options.Spaces
+
+	if (s.PanTilt != nil && s.PanTilt.Space != "" &&
+		findSpace2D(spaces.ContinuousPanTiltVelocitySpace, s.PanTilt.Space) != nil) ||
+		(s.Zoom != nil && s.Zoom.Space != "" && findSpace1D(spaces.ContinuousZoomVelocitySpace, s.Zoom.Space) != nil) {
+		result, _ := s.clampVelocity(spaces, nil)
+
+		return result
+	}
+
+	result, _ := s.clampSpeed(spaces, nil)
+
+	return result
+}
+
+// Normalize returns a copy of s with each value limited to [-1, 1], the outer
+// bound of the generic spaces. NaN and infinite values become 0. Spaces are
+// kept; use Clamp for the exact ranges a device advertises.
+func (s *PTZSpeed) Normalize() *PTZSpeed {
+	if s == nil {
+		return nil
+	}
+
+	return &PTZSpeed{PanTilt: s.PanTilt.normalize(), Zoom: s.Zoom.normalize()}
+}
+
+// clampSpeed clamps s as the speed of a move. Components without a space are
+// placed in the space of the default speed of config, if set, or the generic
+// space. Pan/tilt speed spaces are one-dimensional; their range applies to
+// both x and y.
+func (s *PTZSpeed) clampSpeed(spaces *PTZSpaces, config *PTZConfiguration) (*PTZSpeed, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var panTiltDefault, zoomDefault string
+	if config != nil && config.DefaultPTZSpeed != nil {
+		if config.DefaultPTZSpeed.PanTilt != nil {
+			panTiltDefault = config.DefaultPTZSpeed.PanTilt.Space
+		}
+
+		if config.DefaultPTZSpeed.Zoom != nil {
+			zoomDefault = config.DefaultPTZSpeed.Zoom.Space
+		}
+	}
+
+	zoom, err := s.Zoom.clamp(spaces.ZoomSpeedSpace, zoomDefault, ZoomGenericSpeedSpace)
+	result := &PTZSpeed{Zoom: zoom}
+
+	if s.PanTilt != nil {
+		panTilt := *s.PanTilt
+
+		space := findSpace1D(spaces.PanTiltSpeedSpace, panTilt.Space, panTiltDefault, PanTiltGenericSpeedSpace)
+		switch {
+		case space != nil:
+			panTilt.Space = space.URI
+			panTilt.X = space.XRange.clamp(panTilt.X)
+			panTilt.Y = space.XRange.clamp(panTilt.Y)
+		case panTilt.Space == "" && len(spaces.PanTiltSpeedSpace) > 0:
+			err = errors.Join(err, errNoSpace(PanTiltGenericSpeedSpace))
+		}
+
+		result.PanTilt = &panTilt
+	}
+
+	return result, err
+}
+
+// clampVelocity clamps s as the velocity of a continuous move, placing
+// components without a space in the default velocity space of config, if
+// set, or the generic space.
+func (s *PTZSpeed) clampVelocity(spaces *PTZSpaces, config *PTZConfiguration) (*PTZSpeed, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var panTiltDefault, zoomDefault string
+	if config != nil {
+		panTiltDefault, zoomDefault = config.DefaultContinuousPanTiltVelocitySpace, config.DefaultContinuousZoomVelocitySpace
+	}
+
+	panTilt, panTiltErr := s.PanTilt.clamp(spaces.ContinuousPanTiltVelocitySpace, panTiltDefault, PanTiltVelocityGenericSpace)
+	zoom, zoomErr := s.Zoom.clamp(spaces.ContinuousZoomVelocitySpace, zoomDefault, ZoomVelocityGenericSpace)
+
+	return &PTZSpeed{PanTilt: panTilt, Zoom: zoom}, errors.Join(panTiltErr, zoomErr)
+}
+
+// normalizeVelocity limits the components of s that are in the generic
+// velocity spaces, or have no space, to [-1, 1]. It stands in for
+// clampVelocity when the spaces of the PTZ node are unknown.
+func (s *PTZSpeed) normalizeVelocity() *PTZSpeed {
+	if s == nil {
+		return nil
+	}
+
+	result := s.clone()
+
+	if result.PanTilt != nil && (result.PanTilt.Space == "" || result.PanTilt.Space == PanTiltVelocityGenericSpace) {
+		result.PanTilt = result.PanTilt.normalize()
+	}
+
+	if result.Zoom != nil && (result.Zoom.Space == "" || result.Zoom.Space == ZoomVelocityGenericSpace) {
+		result.Zoom = result.Zoom.normalize()
+	}
+
+	return result
+}
+
+// empty reports whether s has neither pan/tilt nor zoom.
+func (s *PTZSpeed) empty() bool {
+	return s == nil || s.PanTilt == nil && s.Zoom == nil
+}
+
+func (s *PTZSpeed) clone() *PTZSpeed {
+	if s == nil {
+		return nil
+	}
+
+	return &PTZSpeed{PanTilt: s.PanTilt.clone(), Zoom: s.Zoom.clone()}
+}
+
+// clamp returns a copy of v in the space found by findSpace2D, with its values
+// clamped to the ranges of that space. A vector without a space is returned
+// unchanged, with an error, when the node advertises spaces but none of the
+// preferred ones, rather than being moved in an arbitrary space.
+func (v *Vector2D) clamp(spaces []*Space2DDescription, preferred ...string) (*Vector2D, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	result := *v
+
+	space := findSpace2D(spaces, result.Space, preferred...)
+	if space == nil {
+		if result.Space == "" && len(spaces) > 0 {
+			return &result, errNoSpace(preferred[len(preferred)-1])
+		}
+
+		return &result, nil
+	}
+
+	result.Space = space.URI
+	result.X = space.XRange.clamp(result.X)
+	result.Y = space.YRange.clamp(result.Y)
+
+	return &result, nil
+}
+
+func (v *Vector2D) normalize() *Vector2D {
+	if v == nil {
+		return nil
+	}
+
+	return &Vector2D{X: normalizeValue(v.X), Y: normalizeValue(v.Y), Space: v.Space}
+}
+
+func (v *Vector2D) clone() *Vector2D {
+	if v == nil {
+		return nil
+	}
+
+	result := *v
+
+	return &result
+}
+
+// clamp is the 1D variant of Vector2D.clamp.
+func (v *Vector1D) clamp(spaces []*Space1DDescription, preferred ...string) (*Vector1D, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	result := *v
+
+	space := findSpace1D(spaces, result.Space, preferred...)
+	if space == nil {
+		if result.Space == "" && len(spaces) > 0 {
+			return &result, errNoSpace(preferred[len(preferred)-1])
+		}
+
+		return &result, nil
+	}
+
+	result.Space = space.URI
+	result.X = space.XRange.clamp(result.X)
+
+	return &result, nil
+}
+
+// errNoSpace is the error for a vector without a space on a node that
+// advertises neither the configuration's default space nor generic.
+func errNoSpace(generic string) error {
+	return fmt.Errorf("%w: vector has no space and neither the default space nor %s is advertised",
+		ErrInvalidParameter, generic)
+}
+
+func (v *Vector1D) normalize() *Vector1D {
+	if v == nil {
+		return nil
+	}
+
+	return &Vector1D{X: normalizeValue(v.X), Space: v.Space}
+}
+
+func (v *Vector1D) clone() *Vector1D {
+	if v == nil {
+		return nil
+	}
+
+	result := *v
+
+	return &result
+}
+
+// normalizeValue limits x to [-1, 1], mapping NaN and infinities to 0.
+func normalizeValue(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0
+	}
+
+	return min(max(x, -1), 1)
+}
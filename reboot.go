@@ -0,0 +1,148 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"time"
+)
+
+// rebootClockJump is how far the device clock must move relative to the local
+// clock, across a period in which the device was unreachable, for the outage
+// to be taken as a reboot. Devices boot with the time of their RTC or a fixed
+// default and are corrected by NTP later, so their clock rarely survives a
+// reboot in step.
+const rebootClockJump = 30 * time.Second
+
+// rebootCheckTimeout bounds the clock read of a reboot check when
+// WithDefaultCallTimeout is not set.
+const rebootCheckTimeout = 10 * time.Second
+
+// OnReboot registers fn to be called when the client detects that the device
+// rebooted, e.g. to reconnect players or renew event subscriptions. ONVIF
+// reports no uptime, so a reboot is inferred: when a call succeeds after
+// calls failed because the device was unreachable, the device clock is read
+// with GetSystemDateAndTime, and a reboot is reported if its offset from the
+// local clock moved by more than 30 seconds since it was last read. The first
+// successful call after OnReboot reads the clock to start from.
+//
+// A detected reboot also drops the URIs the device marked InvalidAfterReboot
+// from the cache of WithMediaURICache, as SystemReboot and UploadFirmware do,
+// and updates the clock offset of WithClockSync. Reboots that keep the clock in
+// step go unnoticed. The clock is read in the background, so that the call
+// that found the device reachable again is not delayed, and fn is called on
+// that background goroutine.
+func (c *Client) OnReboot(fn func()) {
+	c.rebootMu.Lock()
+	defer c.rebootMu.Unlock()
+
+	c.rebootHandlers = append(c.rebootHandlers, fn)
+}
+
+// observeCall is the call observer of the SOAP client. It tracks whether the
+// device is reachable and checks for a reboot when it becomes reachable again.
+func (c *Client) observeCall(ctx context.Context, err error) {
+	// The focus move options and service capabilities may change across a
+	// reboot, and every reboot starts with the device becoming unreachable.
+	if err != nil && isUnreachableError(err) {
+		c.resetMoveOptions()
+		c.resetCheckedCapabilities()
+	}
+
+	c.rebootMu.Lock()
+
+	if c.rebootChecking || (len(c.rebootHandlers) == 0 && c.mediaURIs == nil) {
+		c.rebootMu.Unlock()
+
+		return
+	}
+
+	if err != nil {
+		if isUnreachableError(err) {
+			c.deviceUnreachable = true
+		}
+
+		c.rebootMu.Unlock()
+
+		return
+	}
+
+	if c.rebootBaselineKnown && !c.deviceUnreachable {
+		c.rebootMu.Unlock()
+
+		return
+	}
+
+	c.rebootChecking = true
+	c.rebootMu.Unlock()
+
+	// The check outlives the call, and must not be cut short by its
+	// cancellation, but keeps its values such as per-call credentials.
+	timeout := rebootCheckTimeout
+	if c.defaultCallTimeout > 0 {
+		timeout = c.defaultCallTimeout
+	}
+
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+
+	c.rebootChecks.Add(1)
+
+	go func() {
+		defer c.rebootChecks.Done()
+		defer cancel()
+
+		c.checkReboot(checkCtx)
+	}()
+}
+
+// checkReboot reads the device clock and reports a reboot if its offset moved
+// by more than rebootClockJump since it was last read.
+func (c *Client) checkReboot(ctx context.Context) {
+	offset, err := c.measureClockOffset(ctx)
+
+	c.rebootMu.Lock()
+	c.rebootChecking = false
+
+	if err != nil {
+		c.rebootMu.Unlock()
+		c.logf("onvif: reboot check failed: %v", err)
+
+		return
+	}
+
+	jump := offset - c.rebootBaseline
+	rebooted := c.rebootBaselineKnown && (jump > rebootClockJump || jump < -rebootClockJump)
+	c.rebootBaseline, c.rebootBaselineKnown, c.deviceUnreachable = offset, true, false
+	handlers := slices.Clone(c.rebootHandlers)
+	c.rebootMu.Unlock()
+
+	if !rebooted {
+		return
+	}
+
+	c.logf("onvif: device reboot detected, device clock moved by %v", jump)
+
+	c.dropRebootMediaURIs()
+
+	if _, ok := c.ClockOffset(); ok {
+		c.SetClockOffset(offset)
+	}
+
+	for _, fn := range handlers {
+		fn()
+	}
+}
+
+// isUnreachableError reports whether a call failed because the device could
+// not be reached, rather than because the device or the caller rejected it.
+func isUnreachableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
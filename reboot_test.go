@@ -0,0 +1,339 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockRebootDevice is a device whose clock and reachability tests control.
+type mockRebootDevice struct {
+	mu         sync.Mutex
+	clock      time.Duration
+	down       bool
+	streamURIs int
+	// validAfterReboot marks the Media1 stream URIs as valid after a reboot
+	validAfterReboot bool
+}
+
+func (d *mockRebootDevice) set(clock time.Duration, down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.clock, d.down = clock, down
+}
+
+func newMockRebootServer(t *testing.T, device *mockRebootDevice, invalidAfterConnect bool) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		device.mu.Lock()
+		defer device.mu.Unlock()
+
+		if device.down {
+			// Drop the connection, as a device that is rebooting does.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+
+			return
+		}
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetSystemDateAndTime"):
+			now := time.Now().UTC().Add(device.clock)
+			response = fmt.Sprintf(`<tds:GetSystemDateAndTimeResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:SystemDateAndTime><tt:UTCDateTime xmlns:tt="http://www.onvif.org/ver10/schema">
+					<tt:Time><tt:Hour>%d</tt:Hour><tt:Minute>%d</tt:Minute><tt:Second>%d</tt:Second></tt:Time>
+					<tt:Date><tt:Year>%d</tt:Year><tt:Month>%d</tt:Month><tt:Day>%d</tt:Day></tt:Date>
+				</tt:UTCDateTime></tds:SystemDateAndTime></tds:GetSystemDateAndTimeResponse>`,
+				now.Hour(), now.Minute(), now.Second(), now.Year(), int(now.Month()), now.Day())
+		case strings.Contains(string(body), "tr2:GetStreamUri"):
+			device.streamURIs++
+			response = fmt.Sprintf(`<tr2:GetStreamUriResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl">
+				<tr2:Uri>rtsp://cam/media2?session=%d</tr2:Uri></tr2:GetStreamUriResponse>`, device.streamURIs)
+		case strings.Contains(string(body), "GetStreamUri"):
+			device.streamURIs++
+			response = fmt.Sprintf(`<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri>rtsp://cam/stream?session=%d</tt:Uri>
+					<tt:InvalidAfterConnect>%t</tt:InvalidAfterConnect>
+					<tt:InvalidAfterReboot>%t</tt:InvalidAfterReboot>
+					<tt:Timeout>PT0S</tt:Timeout></trt:MediaUri>
+			</trt:GetStreamUriResponse>`, device.streamURIs, invalidAfterConnect, !device.validAfterReboot)
+		case strings.Contains(string(body), "SystemReboot"):
+			response = `<tds:SystemRebootResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Message>Rebooting in 5 seconds</tds:Message>
+			</tds:SystemRebootResponse>`
+		case strings.Contains(string(body), "GetHostname"):
+			response = `<tds:GetHostnameResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:HostnameInformation><tt:Name xmlns:tt="http://www.onvif.org/ver10/schema">cam</tt:Name></tds:HostnameInformation>
+			</tds:GetHostnameResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestOnReboot(t *testing.T) {
+	device := &mockRebootDevice{}
+
+	server := newMockRebootServer(t, device, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaURICache())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	reboots := 0
+
+	client.OnReboot(func() { reboots++ })
+
+	ctx := context.Background()
+
+	first, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	if cached, _ := client.GetStreamURI(ctx, "Profile_1"); cached.URI != first.URI || device.streamURIs != 1 {
+		t.Errorf("Expected the cached URI %q, got %q after %d requests", first.URI, cached.URI, device.streamURIs)
+	}
+
+	// An outage after which the clock is still in step is not a reboot.
+	device.set(0, true)
+
+	if _, err := client.GetHostname(ctx); err == nil {
+		t.Fatal("Expected GetHostname() to fail while the device is down")
+	}
+
+	device.set(0, false)
+
+	if _, err := client.GetHostname(ctx); err != nil {
+		t.Fatalf("GetHostname() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	if reboots != 0 {
+		t.Errorf("Expected no reboot for a clock in step, got %d", reboots)
+	}
+
+	// A clock jump across an outage is.
+	device.set(0, true)
+	_, _ = client.GetHostname(ctx)
+	device.set(-time.Hour, false)
+
+	if _, err := client.GetHostname(ctx); err != nil {
+		t.Fatalf("GetHostname() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	if reboots != 1 {
+		t.Fatalf("Expected one reboot, got %d", reboots)
+	}
+
+	refreshed, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	if refreshed.URI == first.URI || device.streamURIs != 2 {
+		t.Errorf("Expected the URI to be refreshed after the reboot, got %q", refreshed.URI)
+	}
+}
+
+func TestOnRebootDropsMedia2URIs(t *testing.T) {
+	device := &mockRebootDevice{}
+
+	server := newMockRebootServer(t, device, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaURICache(), WithMediaVersion(MediaVersion2))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.media2Endpoint = server.URL
+
+	ctx := context.Background()
+
+	// Start the reboot check from a known clock.
+	if _, err := client.GetHostname(ctx); err != nil {
+		t.Fatalf("GetHostname() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	first, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	if !first.InvalidAfterReboot {
+		t.Error("Expected a Media2 URI to be marked InvalidAfterReboot")
+	}
+
+	device.set(0, true)
+	_, _ = client.GetHostname(ctx)
+	device.set(time.Hour, false)
+
+	if _, err := client.GetHostname(ctx); err != nil {
+		t.Fatalf("GetHostname() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	refreshed, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	if refreshed.URI == first.URI || device.streamURIs != 2 {
+		t.Errorf("Expected the Media2 URI to be refreshed after the reboot, got %q", refreshed.URI)
+	}
+}
+
+func TestRebootCheckKeepsValidURIsAndOutlivesCall(t *testing.T) {
+	device := &mockRebootDevice{validAfterReboot: true}
+
+	server := newMockRebootServer(t, device, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaURICache())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	reboots := make(chan struct{}, 1)
+
+	client.OnReboot(func() { reboots <- struct{}{} })
+
+	ctx := context.Background()
+
+	first, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	client.rebootChecks.Wait()
+
+	device.set(0, true)
+	_, _ = client.GetHostname(ctx)
+	device.set(time.Hour, false)
+
+	// The reboot check is not tied to the call that found the device again.
+	callCtx, cancel := context.WithCancel(ctx)
+
+	_, err = client.GetHostname(callCtx)
+
+	cancel()
+
+	if err != nil {
+		t.Fatalf("GetHostname() failed: %v", err)
+	}
+
+	select {
+	case <-reboots:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the reboot to be detected after the caller's context was canceled")
+	}
+
+	cached, err := client.GetStreamURI(ctx, "Profile_1")
+	if err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	if cached.URI != first.URI || device.streamURIs != 1 {
+		t.Errorf("Expected a URI valid after reboot to stay cached, got %q after %d requests",
+			cached.URI, device.streamURIs)
+	}
+}
+
+func TestSystemRebootDropsMediaURIs(t *testing.T) {
+	device := &mockRebootDevice{}
+
+	server := newMockRebootServer(t, device, false)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaURICache())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := client.GetStreamURI(ctx, "Profile_1"); err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	message, err := client.SystemReboot(ctx)
+	if err != nil {
+		t.Fatalf("SystemReboot() failed: %v", err)
+	}
+
+	if message != "Rebooting in 5 seconds" {
+		t.Errorf("Unexpected message %q", message)
+	}
+
+	if _, err := client.GetStreamURI(ctx, "Profile_1"); err != nil {
+		t.Fatalf("GetStreamURI() failed: %v", err)
+	}
+
+	if device.streamURIs != 2 {
+		t.Errorf("Expected the URI to be requested again after SystemReboot, got %d requests", device.streamURIs)
+	}
+}
+
+func TestMediaURICacheInvalidAfterConnect(t *testing.T) {
+	device := &mockRebootDevice{}
+
+	server := newMockRebootServer(t, device, true)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaURICache())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	for range 2 {
+		if _, err := client.GetStreamURI(context.Background(), "Profile_1"); err != nil {
+			t.Fatalf("GetStreamURI() failed: %v", err)
+		}
+	}
+
+	if device.streamURIs != 2 {
+		t.Errorf("Expected URIs marked InvalidAfterConnect not to be cached, got %d requests", device.streamURIs)
+	}
+}
+
+func TestIsUnreachableError(t *testing.T) {
+	if isUnreachableError(context.DeadlineExceeded) {
+		t.Error("Expected a deadline not to count as unreachable")
+	}
+
+	if !isUnreachableError(fmt.Errorf("read: %w", io.EOF)) {
+		t.Error("Expected a dropped connection to count as unreachable")
+	}
+
+	if isUnreachableError(errors.New("SOAP fault")) {
+		t.Error("Expected a fault not to count as unreachable")
+	}
+}
@@ -0,0 +1,284 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// Receiver service namespace.
+const receiverNamespace = "http://www.onvif.org/ver10/receiver/wsdl"
+
+// Receiver service errors.
+var (
+	// ErrInvalidReceiverToken is returned when receiver token is invalid.
+	ErrInvalidReceiverToken = errors.New("invalid receiver token: cannot be empty")
+	// ErrReceiverConfigNil is returned when receiver config is nil.
+	ErrReceiverConfigNil = errors.New("receiver configuration cannot be nil")
+)
+
+// ReceiverMode controls when a receiver connects to its media source.
+type ReceiverMode string
+
+// Receiver mode constants.
+const (
+	// ReceiverModeAutoConnect connects on demand, e.g. when the receiver is shown on a display.
+	ReceiverModeAutoConnect ReceiverMode = "AutoConnect"
+	// ReceiverModeAlwaysConnect keeps the connection to the source open.
+	ReceiverModeAlwaysConnect ReceiverMode = "AlwaysConnect"
+	// ReceiverModeNeverConnect never connects.
+	ReceiverModeNeverConnect ReceiverMode = "NeverConnect"
+)
+
+// validateReceiverMode checks that mode is one of the receiver modes.
+func validateReceiverMode(mode ReceiverMode) error {
+	switch mode {
+	case ReceiverModeAutoConnect, ReceiverModeAlwaysConnect, ReceiverModeNeverConnect:
+		return nil
+	default:
+		return fmt.Errorf("%w: receiver mode %q", ErrInvalidParameter, mode)
+	}
+}
+
+// Receiver is a device-side endpoint that pulls a stream from a media source.
+type Receiver struct {
+	Token         string
+	Configuration *ReceiverConfiguration
+}
+
+// ReceiverConfiguration describes which stream a receiver pulls and how.
+type ReceiverConfiguration struct {
+	Mode ReceiverMode
+	// MediaURI is the RTSP URI of the stream to receive.
+	MediaURI    string
+	StreamSetup *StreamSetup
+}
+
+// ReceiverState is the connection state of a receiver.
+type ReceiverState struct {
+	State       string // NotConnected, Connecting, Connected or Unknown
+	AutoCreated bool
+}
+
+// receiverConfigurationXML is the wire form of tt:ReceiverConfiguration returned by the device.
+type receiverConfigurationXML struct {
+	Mode        string `xml:"Mode"`
+	MediaURI    string `xml:"MediaUri"`
+	StreamSetup *struct {
+		Stream    string `xml:"Stream"`
+		Transport *struct {
+			Protocol string `xml:"Protocol"`
+		} `xml:"Transport"`
+	} `xml:"StreamSetup"`
+}
+
+// receiverXML is the wire form of tt:Receiver returned by the device.
+type receiverXML struct {
+	Token         string                   `xml:"Token"`
+	Configuration receiverConfigurationXML `xml:"Configuration"`
+}
+
+func (r *receiverXML) toReceiver() *Receiver {
+	config := &ReceiverConfiguration{
+		Mode:     ReceiverMode(r.Configuration.Mode),
+		MediaURI: r.Configuration.MediaURI,
+	}
+
+	if setup := r.Configuration.StreamSetup; setup != nil {
+		config.StreamSetup = &StreamSetup{Stream: setup.Stream}
+		if setup.Transport != nil {
+			config.StreamSetup.Transport = &Transport{Protocol: setup.Transport.Protocol}
+		}
+	}
+
+	return &Receiver{Token: r.Token, Configuration: config}
+}
+
+// getReceiverEndpoint returns the receiver endpoint, falling back to the device endpoint.
+func (c *Client) getReceiverEndpoint() string {
+	if c.receiverEndpoint != "" {
+		return c.receiverEndpoint
+	}
+
+	return c.endpoint
+}
+
+// GetReceivers retrieves all receivers of the device.
+func (c *Client) GetReceivers(ctx context.Context) ([]*Receiver, error) {
+	endpoint := c.getReceiverEndpoint()
+
+	type GetReceivers struct {
+		XMLName xml.Name `xml:"trv:GetReceivers"`
+		Xmlns   string   `xml:"xmlns:trv,attr"`
+	}
+
+	type GetReceiversResponse struct {
+		XMLName   xml.Name      `xml:"GetReceiversResponse"`
+		Receivers []receiverXML `xml:"Receivers"`
+	}
+
+	req := GetReceivers{
+		Xmlns: receiverNamespace,
+	}
+
+	var resp GetReceiversResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetReceivers failed: %w", err)
+	}
+
+	receivers := make([]*Receiver, len(resp.Receivers))
+	for i := range resp.Receivers {
+		receivers[i] = resp.Receivers[i].toReceiver()
+	}
+
+	return receivers, nil
+}
+
+// CreateReceiver creates a receiver that pulls the stream described by cfg.
+// When cfg carries no mode, ReceiverModeAutoConnect is used, and when it
+// carries no stream setup, RTP unicast over RTSP is requested.
+func (c *Client) CreateReceiver(ctx context.Context, cfg *ReceiverConfiguration) (*Receiver, error) {
+	if cfg == nil {
+		return nil, ErrReceiverConfigNil
+	}
+
+	mode := cfg.Mode
+	if mode == "" {
+		mode = ReceiverModeAutoConnect
+	}
+
+	if err := validateReceiverMode(mode); err != nil {
+		return nil, err
+	}
+
+	endpoint := c.getReceiverEndpoint()
+
+	type CreateReceiver struct {
+		XMLName       xml.Name `xml:"trv:CreateReceiver"`
+		Xmlns         string   `xml:"xmlns:trv,attr"`
+		XmlnsTT       string   `xml:"xmlns:tt,attr"`
+		Configuration struct {
+			Mode        string `xml:"tt:Mode"`
+			MediaURI    string `xml:"tt:MediaUri"`
+			StreamSetup struct {
+				Stream    string `xml:"tt:Stream"`
+				Transport struct {
+					Protocol string `xml:"tt:Protocol"`
+				} `xml:"tt:Transport"`
+			} `xml:"tt:StreamSetup"`
+		} `xml:"trv:Configuration"`
+	}
+
+	type CreateReceiverResponse struct {
+		XMLName  xml.Name    `xml:"CreateReceiverResponse"`
+		Receiver receiverXML `xml:"Receiver"`
+	}
+
+	req := CreateReceiver{
+		Xmlns:   receiverNamespace,
+		XmlnsTT: "http://www.onvif.org/ver10/schema",
+	}
+	req.Configuration.Mode = string(mode)
+	req.Configuration.MediaURI = cfg.MediaURI
+	req.Configuration.StreamSetup.Stream = "RTP-Unicast"
+	req.Configuration.StreamSetup.Transport.Protocol = "RTSP"
+
+	if cfg.StreamSetup != nil {
+		if cfg.StreamSetup.Stream != "" {
+			req.Configuration.StreamSetup.Stream = cfg.StreamSetup.Stream
+		}
+
+		if cfg.StreamSetup.Transport != nil && cfg.StreamSetup.Transport.Protocol != "" {
+			req.Configuration.StreamSetup.Transport.Protocol = cfg.StreamSetup.Transport.Protocol
+		}
+	}
+
+	var resp CreateReceiverResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("CreateReceiver failed: %w", err)
+	}
+
+	return resp.Receiver.toReceiver(), nil
+}
+
+// SetReceiverMode changes when a receiver connects to its media source.
+func (c *Client) SetReceiverMode(ctx context.Context, receiverToken string, mode ReceiverMode) error {
+	if receiverToken == "" {
+		return ErrInvalidReceiverToken
+	}
+
+	if err := validateReceiverMode(mode); err != nil {
+		return err
+	}
+
+	endpoint := c.getReceiverEndpoint()
+
+	type SetReceiverMode struct {
+		XMLName       xml.Name `xml:"trv:SetReceiverMode"`
+		Xmlns         string   `xml:"xmlns:trv,attr"`
+		ReceiverToken string   `xml:"trv:ReceiverToken"`
+		Mode          string   `xml:"trv:Mode"`
+	}
+
+	req := SetReceiverMode{
+		Xmlns:         receiverNamespace,
+		ReceiverToken: receiverToken,
+		Mode:          string(mode),
+	}
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, nil); err != nil {
+		return fmt.Errorf("SetReceiverMode failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetReceiverState retrieves the connection state of a receiver.
+func (c *Client) GetReceiverState(ctx context.Context, receiverToken string) (*ReceiverState, error) {
+	if receiverToken == "" {
+		return nil, ErrInvalidReceiverToken
+	}
+
+	endpoint := c.getReceiverEndpoint()
+
+	type GetReceiverState struct {
+		XMLName       xml.Name `xml:"trv:GetReceiverState"`
+		Xmlns         string   `xml:"xmlns:trv,attr"`
+		ReceiverToken string   `xml:"trv:ReceiverToken"`
+	}
+
+	type GetReceiverStateResponse struct {
+		XMLName       xml.Name `xml:"GetReceiverStateResponse"`
+		ReceiverState struct {
+			State       string `xml:"State"`
+			AutoCreated Bool   `xml:"AutoCreated"`
+		} `xml:"ReceiverState"`
+	}
+
+	req := GetReceiverState{
+		Xmlns:         receiverNamespace,
+		ReceiverToken: receiverToken,
+	}
+
+	var resp GetReceiverStateResponse
+
+	soapClient := c.newSOAPClient()
+
+	if err := soapClient.Call(ctx, endpoint, "", req, &resp); err != nil {
+		return nil, fmt.Errorf("GetReceiverState failed: %w", err)
+	}
+
+	return &ReceiverState{
+		State:       resp.ReceiverState.State,
+		AutoCreated: bool(resp.ReceiverState.AutoCreated),
+	}, nil
+}
@@ -0,0 +1,174 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockReceiverServer(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, string(body))
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetReceivers"):
+			response = `<trv:GetReceiversResponse xmlns:trv="http://www.onvif.org/ver10/receiver/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trv:Receivers>
+					<tt:Token>Receiver_1</tt:Token>
+					<tt:Configuration>
+						<tt:Mode>AlwaysConnect</tt:Mode>
+						<tt:MediaUri>rtsp://192.168.1.10/stream1</tt:MediaUri>
+						<tt:StreamSetup><tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport></tt:StreamSetup>
+					</tt:Configuration>
+				</trv:Receivers>
+			</trv:GetReceiversResponse>`
+		case strings.Contains(string(body), "CreateReceiver"):
+			response = `<trv:CreateReceiverResponse xmlns:trv="http://www.onvif.org/ver10/receiver/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trv:Receiver>
+					<tt:Token>Receiver_2</tt:Token>
+					<tt:Configuration><tt:Mode>AutoConnect</tt:Mode><tt:MediaUri>rtsp://192.168.1.11/stream1</tt:MediaUri></tt:Configuration>
+				</trv:Receiver>
+			</trv:CreateReceiverResponse>`
+		case strings.Contains(string(body), "SetReceiverMode"):
+			response = `<trv:SetReceiverModeResponse xmlns:trv="http://www.onvif.org/ver10/receiver/wsdl"/>`
+		case strings.Contains(string(body), "GetReceiverState"):
+			response = `<trv:GetReceiverStateResponse xmlns:trv="http://www.onvif.org/ver10/receiver/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trv:ReceiverState><tt:State>Connected</tt:State><tt:AutoCreated>false</tt:AutoCreated></trv:ReceiverState>
+			</trv:GetReceiverStateResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+func TestGetReceivers(t *testing.T) {
+	var requests []string
+
+	server := newMockReceiverServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	receivers, err := client.GetReceivers(context.Background())
+	if err != nil {
+		t.Fatalf("GetReceivers() failed: %v", err)
+	}
+
+	if len(receivers) != 1 {
+		t.Fatalf("Expected 1 receiver, got %d", len(receivers))
+	}
+
+	cfg := receivers[0].Configuration
+	if receivers[0].Token != "Receiver_1" || cfg.Mode != ReceiverModeAlwaysConnect ||
+		cfg.MediaURI != "rtsp://192.168.1.10/stream1" {
+		t.Errorf("Unexpected receiver: %+v %+v", receivers[0], cfg)
+	}
+
+	if cfg.StreamSetup == nil || cfg.StreamSetup.Transport == nil || cfg.StreamSetup.Transport.Protocol != "RTSP" {
+		t.Errorf("Unexpected stream setup: %+v", cfg.StreamSetup)
+	}
+}
+
+func TestCreateReceiver(t *testing.T) {
+	var requests []string
+
+	server := newMockReceiverServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// Without a mode, AutoConnect is requested.
+	receiver, err := client.CreateReceiver(context.Background(), &ReceiverConfiguration{
+		MediaURI: "rtsp://192.168.1.11/stream1",
+	})
+	if err != nil {
+		t.Fatalf("CreateReceiver() failed: %v", err)
+	}
+
+	if receiver.Token != "Receiver_2" {
+		t.Errorf("Expected token Receiver_2, got %s", receiver.Token)
+	}
+
+	for _, want := range []string{
+		"<tt:Mode>AutoConnect</tt:Mode>",
+		"<tt:MediaUri>rtsp://192.168.1.11/stream1</tt:MediaUri>",
+		"<tt:Stream>RTP-Unicast</tt:Stream>",
+		"<tt:Protocol>RTSP</tt:Protocol>",
+	} {
+		if !strings.Contains(requests[0], want) {
+			t.Errorf("Request missing %s: %s", want, requests[0])
+		}
+	}
+
+	if _, err := client.CreateReceiver(context.Background(), nil); !errors.Is(err, ErrReceiverConfigNil) {
+		t.Errorf("Expected ErrReceiverConfigNil, got %v", err)
+	}
+
+	_, err = client.CreateReceiver(context.Background(), &ReceiverConfiguration{Mode: "Sometimes"})
+	if !errors.Is(err, ErrInvalidParameter) || len(requests) != 1 {
+		t.Errorf("Expected ErrInvalidParameter without a request, got %v after %d requests", err, len(requests))
+	}
+}
+
+func TestSetReceiverModeAndState(t *testing.T) {
+	var requests []string
+
+	server := newMockReceiverServer(t, &requests)
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := client.SetReceiverMode(ctx, "Receiver_1", ReceiverModeNeverConnect); err != nil {
+		t.Fatalf("SetReceiverMode() failed: %v", err)
+	}
+
+	if !strings.Contains(requests[0], "<trv:Mode>NeverConnect</trv:Mode>") {
+		t.Errorf("Request missing mode: %s", requests[0])
+	}
+
+	state, err := client.GetReceiverState(ctx, "Receiver_1")
+	if err != nil {
+		t.Fatalf("GetReceiverState() failed: %v", err)
+	}
+
+	if state.State != "Connected" || state.AutoCreated {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	if err := client.SetReceiverMode(ctx, "", ReceiverModeAutoConnect); !errors.Is(err, ErrInvalidReceiverToken) {
+		t.Errorf("Expected ErrInvalidReceiverToken, got %v", err)
+	}
+
+	for _, mode := range []ReceiverMode{"", "Sometimes"} {
+		if err := client.SetReceiverMode(ctx, "Receiver_1", mode); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("SetReceiverMode(%q): expected ErrInvalidParameter, got %v", mode, err)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("Expected invalid modes not to be sent, got %d requests", len(requests))
+	}
+}
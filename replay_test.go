@@ -0,0 +1,80 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayRangeHeader(t *testing.T) {
+	start := time.Date(2024, 3, 5, 14, 30, 15, 500_000_000, time.UTC)
+	cest := time.FixedZone("CEST", 2*60*60)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  string
+	}{
+		{"closed range", start, start.Add(90 * time.Minute), "clock=20240305T143015Z-20240305T160015Z"},
+		{"open-ended range", start, time.Time{}, "clock=20240305T143015Z-"},
+		{"local times converted to UTC", start.In(cest), start.Add(time.Hour).In(cest), "clock=20240305T143015Z-20240305T153015Z"},
+	}
+
+	for _, tt := range tests {
+		if got := ReplayRangeHeader(tt.start, tt.end); got != tt.want {
+			t.Errorf("%s: ReplayRangeHeader() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetReplayUriWithRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "<trp:RecordingToken>Recording_1</trp:RecordingToken>") {
+			t.Errorf("Request missing recording token: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+	<s:Body>
+		<trp:GetReplayUriResponse xmlns:trp="http://www.onvif.org/ver10/replay/wsdl">
+			<trp:Uri>rtsp://192.168.1.100/replay/Recording_1</trp:Uri>
+		</trp:GetReplayUriResponse>
+	</s:Body>
+</s:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	start := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+
+	uri, header, err := client.GetReplayUriWithRange(ctx, "Recording_1", start, time.Time{})
+	if err != nil {
+		t.Fatalf("GetReplayUriWithRange() failed: %v", err)
+	}
+
+	if uri.URI != "rtsp://192.168.1.100/replay/Recording_1" {
+		t.Errorf("Unexpected URI: %s", uri.URI)
+	}
+
+	if header != "clock=20240305T143000Z-" {
+		t.Errorf("Unexpected range header: %s", header)
+	}
+
+	_, _, err = client.GetReplayUriWithRange(ctx, "Recording_1", start, start.Add(-time.Minute))
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for inverted range, got %v", err)
+	}
+}
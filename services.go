@@ -0,0 +1,81 @@
+package onvif
+
+import "context"
+
+// DeviceService is the device management surface of the client.
+// Code that depends on DeviceService rather than *Client can be tested with a fake.
+type DeviceService interface {
+	GetDeviceInformation(ctx context.Context) (*DeviceInformation, error)
+	GetCapabilities(ctx context.Context) (*Capabilities, error)
+	GetServices(ctx context.Context, includeCapability bool) ([]*Service, error)
+	GetServiceCapabilities(ctx context.Context) (*DeviceServiceCapabilities, error)
+	SystemReboot(ctx context.Context) (string, error)
+	GetHostname(ctx context.Context) (*HostnameInformation, error)
+	SetHostname(ctx context.Context, name string) error
+	GetDNS(ctx context.Context) (*DNSInformation, error)
+	GetNTP(ctx context.Context) (*NTPInformation, error)
+	GetNetworkInterfaces(ctx context.Context) ([]*NetworkInterface, error)
+	GetScopes(ctx context.Context) ([]*Scope, error)
+	GetUsers(ctx context.Context) ([]*User, error)
+	CreateUsers(ctx context.Context, users []*User) error
+	DeleteUsers(ctx context.Context, usernames []string) error
+	SetUser(ctx context.Context, users ...*User) error
+}
+
+// MediaService is the media (ver10) surface of the client.
+// Code that depends on MediaService rather than *Client can be tested with a fake.
+type MediaService interface {
+	GetMediaServiceCapabilities(ctx context.Context) (*MediaServiceCapabilities, error)
+	GetProfiles(ctx context.Context) ([]*Profile, error)
+	GetProfile(ctx context.Context, profileToken string) (*Profile, error)
+	CreateProfile(ctx context.Context, name, token string) (*Profile, error)
+	DeleteProfile(ctx context.Context, profileToken string) error
+	GetStreamURI(ctx context.Context, profileToken string, opts ...StreamURIOption) (*MediaURI, error)
+	GetSnapshotURI(ctx context.Context, profileToken string) (*MediaURI, error)
+	GetVideoSources(ctx context.Context) ([]*VideoSource, error)
+	GetAudioSources(ctx context.Context) ([]*AudioSource, error)
+	GetVideoSourceConfigurations(ctx context.Context) ([]*VideoSourceConfiguration, error)
+	GetVideoEncoderConfigurations(ctx context.Context) ([]*VideoEncoderConfiguration, error)
+	GetVideoEncoderConfiguration(ctx context.Context, configurationToken string) (*VideoEncoderConfiguration, error)
+	SetVideoEncoderConfiguration(ctx context.Context, config *VideoEncoderConfiguration, forcePersistence bool) error
+}
+
+// PTZService is the PTZ surface of the client.
+// Code that depends on PTZService rather than *Client can be tested with a fake.
+type PTZService interface {
+	ContinuousMove(ctx context.Context, profileToken string, velocity *PTZSpeed, timeout *string) error
+	AbsoluteMove(ctx context.Context, profileToken string, position *PTZVector, speed *PTZSpeed) error
+	RelativeMove(ctx context.Context, profileToken string, translation *PTZVector, speed *PTZSpeed) error
+	Stop(ctx context.Context, profileToken string, panTilt, zoom bool) error
+	GetStatus(ctx context.Context, profileToken string) (*PTZStatus, error)
+	GetPresets(ctx context.Context, profileToken string) ([]*PTZPreset, error)
+	GotoPreset(ctx context.Context, profileToken, presetToken string, speed *PTZSpeed) error
+	SetPreset(ctx context.Context, profileToken, presetName, presetToken string) (string, error)
+	RemovePreset(ctx context.Context, profileToken, presetToken string) error
+	GotoHomePosition(ctx context.Context, profileToken string, speed *PTZSpeed) error
+	SetHomePosition(ctx context.Context, profileToken string) error
+	GetConfiguration(ctx context.Context, configurationToken string) (*PTZConfiguration, error)
+	GetConfigurations(ctx context.Context) ([]*PTZConfiguration, error)
+}
+
+// ImagingService is the imaging surface of the client.
+// Code that depends on ImagingService rather than *Client can be tested with a fake.
+type ImagingService interface {
+	GetImagingSettings(ctx context.Context, videoSourceToken string) (*ImagingSettings, error)
+	SetImagingSettings(
+		ctx context.Context, videoSourceToken string, settings *ImagingSettings, forcePersistence bool,
+	) error
+	GetOptions(ctx context.Context, videoSourceToken string) (*ImagingOptions, error)
+	GetMoveOptions(ctx context.Context, videoSourceToken string) (*MoveOptions, error)
+	Move(ctx context.Context, videoSourceToken string, focus *FocusMove) error
+	StopFocus(ctx context.Context, videoSourceToken string) error
+	GetImagingStatus(ctx context.Context, videoSourceToken string) (*ImagingStatus, error)
+}
+
+// Compile-time checks that *Client implements the service interfaces.
+var (
+	_ DeviceService  = (*Client)(nil)
+	_ MediaService   = (*Client)(nil)
+	_ PTZService     = (*Client)(nil)
+	_ ImagingService = (*Client)(nil)
+)
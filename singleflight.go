@@ -0,0 +1,170 @@
+package onvif
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/0x524a/onvif-go/internal/soap"
+)
+
+// flightGroup de-duplicates concurrent identical calls, see WithSingleFlight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is an in-flight or completed call shared by its callers.
+type flightCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+
+	// The fields below are guarded by flightGroup.mu. timer cancels the call at
+	// deadline; it is nil once a caller without a bound has joined.
+	timer    *time.Timer
+	deadline time.Time
+	expired  bool
+}
+
+// WithSingleFlight collapses concurrent identical read operations, such as
+// GetProfiles or GetStreamURI for the same token, into a single device call
+// whose result is returned to every caller. Only calls that overlap in time
+// are shared; nothing is cached once the device has answered. Callers
+// receiving a shared result must treat it as read-only.
+func WithSingleFlight() ClientOption {
+	return func(c *Client) {
+		c.flights = &flightGroup{
+			calls: make(map[string]*flightCall),
+		}
+	}
+}
+
+// singleFlight runs fn, sharing its result with concurrent callers using the
+// same key and credentials when single-flight de-duplication is enabled. The
+// shared call is detached from the cancellation of the caller that started it
+// so that one caller giving up does not fail the others. It is bounded by the
+// latest deadline among the callers sharing it, or the WithDefaultCallTimeout
+// bound for a caller without one, and unbounded once a caller with neither
+// joins; each caller still returns as soon as its own context is done.
+func singleFlight[T any](
+	ctx context.Context,
+	c *Client,
+	key string,
+	fn func(context.Context) (T, error),
+) (T, error) {
+	if c.flights == nil {
+		return fn(ctx)
+	}
+
+	// Different credentials may see different results, including those a
+	// context carries for a single call or batch. They are hashed so that the
+	// password is not kept in the key.
+	username, password := c.GetCredentials()
+	if u, p, ok := soap.CredentialsFromContext(ctx); ok {
+		username, password = u, p
+	}
+
+	credentials := sha256.Sum256([]byte(username + "\x00" + password))
+	key = string(credentials[:]) + "\x00" + key
+
+	deadline, bounded := c.flightDeadline(ctx)
+
+	g := c.flights
+
+	g.mu.Lock()
+
+	call, ok := g.calls[key]
+	if ok && !call.expired {
+		call.extend(deadline, bounded)
+	} else {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+
+		shared, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if bounded {
+			call.deadline = deadline
+			call.timer = time.AfterFunc(time.Until(deadline), func() {
+				g.mu.Lock()
+				defer g.mu.Unlock()
+
+				// A caller may have extended or removed the deadline while the
+				// timer was firing.
+				if call.timer == nil || time.Now().Before(call.deadline) {
+					return
+				}
+
+				call.expired = true
+				cancel()
+			})
+		}
+
+		go func() {
+			defer cancel()
+
+			call.val, call.err = fn(shared)
+
+			g.mu.Lock()
+			if call.timer != nil {
+				call.timer.Stop()
+			}
+
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			close(call.done)
+		}()
+	}
+
+	g.mu.Unlock()
+
+	var zero T
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return zero, call.err
+		}
+
+		return call.val.(T), nil //nolint:forcetypeassert // only singleFlight[T] stores under key
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// flightDeadline returns the deadline of ctx, or the WithDefaultCallTimeout
+// bound if it has none. bounded is false when there is neither.
+func (c *Client) flightDeadline(ctx context.Context) (deadline time.Time, bounded bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline, true
+	}
+
+	if c.defaultCallTimeout > 0 {
+		return time.Now().Add(c.defaultCallTimeout), true
+	}
+
+	return time.Time{}, false
+}
+
+// extend moves the deadline of a shared call out to that of a joining caller,
+// or removes it for a caller without one. The caller must hold flightGroup.mu.
+func (call *flightCall) extend(deadline time.Time, bounded bool) {
+	if call.timer == nil {
+		return
+	}
+
+	if !bounded {
+		call.timer.Stop()
+		call.timer = nil
+
+		return
+	}
+
+	if deadline.After(call.deadline) {
+		call.deadline = deadline
+		call.timer.Reset(time.Until(deadline))
+	}
+}
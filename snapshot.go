@@ -0,0 +1,408 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigSnapshot is a device configuration compared by DiffSnapshots.
+type ConfigSnapshot struct {
+	DeviceInformation          *DeviceInformation
+	Profiles                   []*Profile
+	VideoEncoderConfigurations []*VideoEncoderConfiguration
+	OSDs                       []*OSDConfiguration
+	NetworkInterfaces          []*NetworkInterface
+	// ImagingSettings holds the imaging settings keyed by video source token.
+	ImagingSettings map[string]*ImagingSettings
+}
+
+// Snapshot sections reported in SnapshotChange.Section.
+const (
+	SnapshotSectionDevice   = "DeviceInformation"
+	SnapshotSectionProfiles = "Profiles"
+	SnapshotSectionEncoders = "VideoEncoderConfigurations"
+	SnapshotSectionOSDs     = "OSDs"
+	SnapshotSectionNetwork  = "NetworkInterfaces"
+	SnapshotSectionImaging  = "ImagingSettings"
+)
+
+// SnapshotChangeKind is the kind of a SnapshotChange.
+type SnapshotChangeKind string
+
+// Snapshot change kinds.
+const (
+	SnapshotAdded   SnapshotChangeKind = "Added"
+	SnapshotRemoved SnapshotChangeKind = "Removed"
+	SnapshotChanged SnapshotChangeKind = "Changed"
+)
+
+// SnapshotChange is one difference between two snapshots.
+type SnapshotChange struct {
+	Section string
+	// Key identifies the item within the section, e.g. the profile name.
+	Key  string
+	Kind SnapshotChangeKind
+	// Field is the path of the changed field, e.g. "Resolution.Width"; it is
+	// empty for added and removed items.
+	Field string
+	// A and B are the values in the first and second snapshot: the field
+	// values for changes, and the item for removed (A) and added (B) items.
+	A, B any
+}
+
+// String describes the change, e.g. "Profiles[Main].Resolution.Width: 1920 -> 1280".
+func (c SnapshotChange) String() string {
+	item := c.Section
+	if c.Key != "" {
+		item += "[" + c.Key + "]"
+	}
+
+	switch c.Kind {
+	case SnapshotAdded, SnapshotRemoved:
+		return fmt.Sprintf("%s: %s", item, strings.ToLower(string(c.Kind)))
+	default:
+		return fmt.Sprintf("%s.%s: %v -> %v", item, c.Field, snapshotValue(c.A), snapshotValue(c.B))
+	}
+}
+
+// SnapshotDiff lists the differences between two snapshots, see DiffSnapshots.
+type SnapshotDiff struct {
+	Changes []SnapshotChange
+}
+
+// Empty reports whether the snapshots match.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// snapshotIgnoredFields lists the fields that are inherently per device and
+// never compared, by section and field path. Fields named UseCount or ending
+// in Token are ignored at any depth.
+var snapshotIgnoredFields = map[string]bool{
+	SnapshotSectionDevice + ".SerialNumber":        true,
+	SnapshotSectionDevice + ".HardwareID":          true,
+	SnapshotSectionNetwork + ".Info.HwAddress":     true,
+	SnapshotSectionNetwork + ".IPv4.Config.Manual": true,
+	SnapshotSectionNetwork + ".IPv6.Config.Manual": true,
+	// The address DHCP assigned; whether DHCP is used is still compared.
+	SnapshotSectionNetwork + ".IPv4.Config.FromDHCP": true,
+}
+
+// snapshotIgnored reports whether the field at path, within section, is
+// never compared.
+func snapshotIgnored(section, path, name string) bool {
+	return snapshotIgnoredFields[section+"."+path] || name == "UseCount" || strings.HasSuffix(name, "Token")
+}
+
+// snapshotTimeType is compared with time.Time.Equal rather than field by field.
+var snapshotTimeType = reflect.TypeFor[time.Time]()
+
+// CaptureSnapshot reads the device information, media profiles, video
+// encoder configurations, OSDs, network interfaces and the imaging settings
+// of every video source into a ConfigSnapshot, e.g. to keep a golden
+// configuration or compare the device against one with DiffSnapshots. OSDs
+// and imaging settings are left empty if the device does not support them.
+func (c *Client) CaptureSnapshot(ctx context.Context) (*ConfigSnapshot, error) {
+	snapshot := &ConfigSnapshot{}
+
+	var err error
+
+	if snapshot.DeviceInformation, err = c.GetDeviceInformation(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get device information: %w", err)
+	}
+
+	if snapshot.Profiles, err = c.GetProfiles(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	if snapshot.VideoEncoderConfigurations, err = c.GetVideoEncoderConfigurations(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get video encoder configurations: %w", err)
+	}
+
+	if snapshot.NetworkInterfaces, err = c.GetNetworkInterfaces(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
+	}
+
+	if snapshot.OSDs, err = c.GetOSDs(ctx, ""); err != nil {
+		if !isUnsupportedError(err) {
+			return nil, fmt.Errorf("failed to get OSDs: %w", err)
+		}
+
+		snapshot.OSDs = nil
+	}
+
+	sources, err := c.GetVideoSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video sources: %w", err)
+	}
+
+	snapshot.ImagingSettings = make(map[string]*ImagingSettings, len(sources))
+
+	for _, source := range sources {
+		settings, err := c.GetImagingSettings(ctx, source.Token)
+		if err != nil {
+			if isUnsupportedError(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to get imaging settings of %s: %w", source.Token, err)
+		}
+
+		snapshot.ImagingSettings[source.Token] = settings
+	}
+
+	return snapshot, nil
+}
+
+// DiffSnapshots reports the items added, removed and changed from a to b,
+// e.g. to audit a camera against a golden configuration. Per-device fields,
+// such as the serial number, MAC address, IP addresses and tokens, are
+// ignored. Profiles and encoder configurations are matched by name, network
+// interfaces by interface name, OSDs by position and imaging settings by
+// video source token. A nil snapshot is treated as empty.
+func DiffSnapshots(a, b *ConfigSnapshot) *SnapshotDiff {
+	if a == nil {
+		a = &ConfigSnapshot{}
+	}
+
+	if b == nil {
+		b = &ConfigSnapshot{}
+	}
+
+	diff := &SnapshotDiff{}
+
+	if a.DeviceInformation != nil || b.DeviceInformation != nil {
+		diffItems(diff, SnapshotSectionDevice,
+			nonNil([]*DeviceInformation{a.DeviceInformation}), nonNil([]*DeviceInformation{b.DeviceInformation}),
+			func(int, *DeviceInformation) string { return "" })
+	}
+
+	diffItems(diff, SnapshotSectionProfiles, a.Profiles, b.Profiles,
+		func(_ int, p *Profile) string { return p.Name })
+	diffItems(diff, SnapshotSectionEncoders, a.VideoEncoderConfigurations, b.VideoEncoderConfigurations,
+		func(_ int, c *VideoEncoderConfiguration) string { return c.Name })
+	diffItems(diff, SnapshotSectionOSDs, a.OSDs, b.OSDs,
+		func(i int, _ *OSDConfiguration) string { return strconv.Itoa(i) })
+	diffItems(diff, SnapshotSectionNetwork, a.NetworkInterfaces, b.NetworkInterfaces,
+		func(_ int, n *NetworkInterface) string { return n.Info.Name })
+
+	aSources, aImaging := imagingItems(a.ImagingSettings)
+	bSources, bImaging := imagingItems(b.ImagingSettings)
+
+	// Both key functions see their own snapshot's items, which are in key order.
+	diffImaging := func(sources []string) func(int, *ImagingSettings) string {
+		return func(i int, _ *ImagingSettings) string { return sources[i] }
+	}
+
+	diffKeyedItems(diff, SnapshotSectionImaging, aImaging, bImaging, diffImaging(aSources), diffImaging(bSources))
+
+	return diff
+}
+
+// imagingItems returns the video source tokens of a snapshot's imaging
+// settings in order, and the settings in the same order.
+func imagingItems(settings map[string]*ImagingSettings) ([]string, []*ImagingSettings) {
+	sources := make([]string, 0, len(settings))
+	for source, s := range settings {
+		if s != nil {
+			sources = append(sources, source)
+		}
+	}
+
+	sort.Strings(sources)
+
+	items := make([]*ImagingSettings, len(sources))
+	for i, source := range sources {
+		items[i] = settings[source]
+	}
+
+	return sources, items
+}
+
+// nonNil returns items without nil entries.
+func nonNil[T any](items []*T) []*T {
+	out := items[:0:0]
+	for _, item := range items {
+		if item != nil {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// diffItems matches the items of a and b by key and records the added,
+// removed and changed ones. Repeated keys are matched in order.
+func diffItems[T any](diff *SnapshotDiff, section string, a, b []*T, key func(int, *T) string) {
+	diffKeyedItems(diff, section, a, b, key, key)
+}
+
+// diffKeyedItems is diffItems with separate key functions for a and b.
+func diffKeyedItems[T any](diff *SnapshotDiff, section string, a, b []*T, aKey, bKey func(int, *T) string) {
+	index := func(items []*T, key func(int, *T) string) ([]string, map[string]*T) {
+		keys := make([]string, 0, len(items))
+		byKey := make(map[string]*T, len(items))
+		seen := make(map[string]int)
+
+		for i, item := range items {
+			if item == nil {
+				continue
+			}
+
+			k := key(i, item)
+
+			seen[k]++
+			if n := seen[k]; n > 1 {
+				k += "#" + strconv.Itoa(n)
+			}
+
+			keys = append(keys, k)
+			byKey[k] = item
+		}
+
+		return keys, byKey
+	}
+
+	aKeys, aItems := index(a, aKey)
+	bKeys, bItems := index(b, bKey)
+
+	for _, k := range aKeys {
+		bItem, ok := bItems[k]
+		if !ok {
+			diff.Changes = append(diff.Changes, SnapshotChange{Section: section, Key: k, Kind: SnapshotRemoved, A: aItems[k]})
+
+			continue
+		}
+
+		var fields []SnapshotChange
+
+		diffValues(&fields, section, "", reflect.ValueOf(aItems[k]), reflect.ValueOf(bItem))
+
+		for _, field := range fields {
+			field.Section, field.Key = section, k
+			diff.Changes = append(diff.Changes, field)
+		}
+	}
+
+	for _, k := range bKeys {
+		if _, ok := aItems[k]; !ok {
+			diff.Changes = append(diff.Changes, SnapshotChange{Section: section, Key: k, Kind: SnapshotAdded, B: bItems[k]})
+		}
+	}
+}
+
+// diffValues records the fields that differ between a and b below path
+// within section.
+func diffValues(out *[]SnapshotChange, section, path string, a, b reflect.Value) {
+	changed := func() {
+		*out = append(*out, SnapshotChange{Kind: SnapshotChanged, Field: path, A: snapshotInterface(a), B: snapshotInterface(b)})
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				changed()
+			}
+
+			return
+		}
+
+		diffValues(out, section, path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		if a.Type() == snapshotTimeType {
+			if !a.Interface().(time.Time).Equal(b.Interface().(time.Time)) {
+				changed()
+			}
+
+			return
+		}
+
+		for i := range a.NumField() {
+			field := a.Type().Field(i)
+
+			name := field.Name
+			if path != "" {
+				name = path + "." + name
+			}
+
+			if !field.IsExported() || snapshotIgnored(section, name, field.Name) {
+				continue
+			}
+
+			diffValues(out, section, name, a.Field(i), b.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			changed()
+
+			return
+		}
+
+		for i := range a.Len() {
+			diffValues(out, section, fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, k := range append(a.MapKeys(), b.MapKeys()...) {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			av, bv := a.MapIndex(keys[name]), b.MapIndex(keys[name])
+			if !av.IsValid() || !bv.IsValid() {
+				*out = append(*out, SnapshotChange{
+					Kind: SnapshotChanged, Field: path + "[" + name + "]",
+					A: snapshotInterface(av), B: snapshotInterface(bv),
+				})
+
+				continue
+			}
+
+			diffValues(out, section, path+"["+name+"]", av, bv)
+		}
+	default:
+		if a.CanInterface() && !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			changed()
+		}
+	}
+}
+
+// snapshotInterface returns the value of v, or nil if v is invalid.
+func snapshotInterface(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// snapshotValue dereferences pointers for display.
+func snapshotValue(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return nil
+	}
+
+	return rv.Interface()
+}
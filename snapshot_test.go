@@ -0,0 +1,175 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	brightness := func(v float64) *float64 { return &v }
+
+	golden := &ConfigSnapshot{
+		DeviceInformation: &DeviceInformation{Model: "X1", FirmwareVersion: "2.0", SerialNumber: "A"},
+		Profiles: []*Profile{
+			{Token: "P1", Name: "Main", VideoEncoderConfiguration: &VideoEncoderConfiguration{
+				Token: "V1", Name: "Enc", Resolution: &VideoResolution{Width: 1920, Height: 1080},
+			}},
+			{Token: "P2", Name: "Sub"},
+		},
+		NetworkInterfaces: []*NetworkInterface{{
+			Token: "eth0", Info: NetworkInterfaceInfo{Name: "eth0", HwAddress: "00:11", MTU: 1500},
+			IPv4: &IPv4NetworkInterface{Enabled: true, Config: IPv4Configuration{
+				DHCP: false, Manual: []PrefixedIPv4Address{{Address: "10.0.0.1", PrefixLength: 24}},
+			}},
+		}},
+		ImagingSettings: map[string]*ImagingSettings{"VS1": {Brightness: brightness(50)}},
+	}
+
+	camera := &ConfigSnapshot{
+		DeviceInformation: &DeviceInformation{Model: "X1", FirmwareVersion: "1.9", SerialNumber: "B"},
+		Profiles: []*Profile{
+			{Token: "other_1", Name: "Main", VideoEncoderConfiguration: &VideoEncoderConfiguration{
+				Token: "other_v", Name: "Enc", Resolution: &VideoResolution{Width: 1280, Height: 1080},
+			}},
+			{Token: "other_3", Name: "Third"},
+		},
+		NetworkInterfaces: []*NetworkInterface{{
+			Token: "eth0", Info: NetworkInterfaceInfo{Name: "eth0", HwAddress: "00:22", MTU: 1500},
+			IPv4: &IPv4NetworkInterface{Enabled: true, Config: IPv4Configuration{
+				DHCP: false, Manual: []PrefixedIPv4Address{{Address: "10.0.0.2", PrefixLength: 24}},
+			}},
+		}},
+		ImagingSettings: map[string]*ImagingSettings{"VS1": {Brightness: brightness(60)}},
+	}
+
+	diff := DiffSnapshots(golden, camera)
+
+	want := []string{
+		"DeviceInformation.FirmwareVersion: 2.0 -> 1.9",
+		"Profiles[Main].VideoEncoderConfiguration.Resolution.Width: 1920 -> 1280",
+		"Profiles[Sub]: removed",
+		"Profiles[Third]: added",
+		"ImagingSettings[VS1].Brightness: 50 -> 60",
+	}
+
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %v", len(want), diff.Changes)
+	}
+
+	for i, change := range diff.Changes {
+		if got := change.String(); got != want[i] {
+			t.Errorf("Change %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	if !DiffSnapshots(golden, golden).Empty() {
+		t.Error("Expected a snapshot to match itself")
+	}
+
+	if diff := DiffSnapshots(nil, golden); len(diff.Changes) != 5 || diff.Changes[0].Kind != SnapshotAdded {
+		t.Errorf("Expected every item to be added, got %v", diff.Changes)
+	}
+}
+
+func TestDiffSnapshotsIgnoredPathsAndTimes(t *testing.T) {
+	type schedule struct {
+		Manual []string
+		Start  time.Time
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	a := schedule{Manual: []string{"a"}, Start: start}
+	b := schedule{Manual: []string{"b"}, Start: start.In(time.FixedZone("CET", 3600))}
+
+	var changes []SnapshotChange
+
+	// Manual is only ignored below the network interface IP configuration.
+	diffValues(&changes, SnapshotSectionProfiles, "", reflect.ValueOf(a), reflect.ValueOf(b))
+
+	if len(changes) != 1 || changes[0].Field != "Manual[0]" {
+		t.Fatalf("Expected only Manual to change, got %v", changes)
+	}
+
+	changes = nil
+	b.Manual, b.Start = a.Manual, start.Add(time.Second)
+
+	diffValues(&changes, SnapshotSectionProfiles, "", reflect.ValueOf(a), reflect.ValueOf(b))
+
+	if len(changes) != 1 || changes[0].Field != "Start" {
+		t.Fatalf("Expected Start to change, got %v", changes)
+	}
+}
+
+func TestCaptureSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetDeviceInformation"):
+			response = `<tds:GetDeviceInformationResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl">
+				<tds:Model>X1</tds:Model><tds:SerialNumber>A</tds:SerialNumber></tds:GetDeviceInformationResponse>`
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="P1"><tt:Name>Main</tt:Name></trt:Profiles></trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfigurations"):
+			response = `<trt:GetVideoEncoderConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configurations token="V1"><tt:Name>Enc</tt:Name></trt:Configurations></trt:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "GetNetworkInterfaces"):
+			response = `<tds:GetNetworkInterfacesResponse xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tds:NetworkInterfaces token="eth0"><tt:Enabled>true</tt:Enabled>
+				<tt:Info><tt:Name>eth0</tt:Name></tt:Info></tds:NetworkInterfaces></tds:GetNetworkInterfacesResponse>`
+		case strings.Contains(string(body), "GetOSDs"):
+			w.WriteHeader(http.StatusInternalServerError)
+			response = `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value>
+				<soap:Subcode><soap:Value>ter:ActionNotSupported</soap:Value></soap:Subcode></soap:Code>
+				<soap:Reason><soap:Text>No OSD</soap:Text></soap:Reason></soap:Fault>`
+		case strings.Contains(string(body), "GetVideoSources"):
+			response = `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:VideoSources token="VS1"><trt:Framerate>25</trt:Framerate></trt:VideoSources></trt:GetVideoSourcesResponse>`
+		case strings.Contains(string(body), "GetImagingSettings"):
+			response = `<timg:GetImagingSettingsResponse xmlns:timg="http://www.onvif.org/ver20/imaging/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<timg:ImagingSettings><tt:Brightness>50</tt:Brightness></timg:ImagingSettings></timg:GetImagingSettingsResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	snapshot, err := client.CaptureSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("CaptureSnapshot() failed: %v", err)
+	}
+
+	if snapshot.DeviceInformation.Model != "X1" || len(snapshot.Profiles) != 1 ||
+		len(snapshot.VideoEncoderConfigurations) != 1 || len(snapshot.NetworkInterfaces) != 1 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	if snapshot.OSDs != nil {
+		t.Errorf("Expected no OSDs, got %v", snapshot.OSDs)
+	}
+
+	if settings := snapshot.ImagingSettings["VS1"]; settings == nil || settings.Brightness == nil || *settings.Brightness != 50 {
+		t.Errorf("Unexpected imaging settings: %+v", snapshot.ImagingSettings)
+	}
+
+	if !DiffSnapshots(snapshot, snapshot).Empty() {
+		t.Error("Expected a captured snapshot to match itself")
+	}
+}
@@ -0,0 +1,488 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StreamParams describes a video stream in the terms users usually think in,
+// for example 1920x1080, 25 fps, 4000 kbps, H264. Zero values keep the
+// encoder's current setting.
+type StreamParams struct {
+	Encoding    string // JPEG or H264
+	Width       int
+	Height      int
+	FPS         int
+	BitrateKbps int
+	GovLength   int // H264 only
+	Quality     float64
+}
+
+// SetStreamParameters applies p to the video encoder configuration of the
+// profile. The profile and its encoder configuration are read and written
+// through the media service GetProfiles uses, Media2 or Media, see
+// WithMediaVersion. The parameters are validated against the video encoder
+// configuration options first; ErrInvalidParameter is returned, listing the
+// valid resolutions or ranges, when the device does not support them. The
+// resulting stream is then cross-checked against the frame rates Media2
+// reports per resolution and the guaranteed number of encoder instances of
+// the video source, see checkStreamSustainable; a stream the device may not
+// sustain is logged as a warning, or rejected with ErrStreamNotSustainable
+// with WithVideoEncoderInstanceCheck. The change is applied with
+// SetVideoEncoderConfiguration and persisted, or SetVideoEncoderConfiguration2
+// on Media2.
+func (c *Client) SetStreamParameters(ctx context.Context, profileToken string, p StreamParams) error {
+	if profileToken == "" {
+		return fmt.Errorf("SetStreamParameters failed: %w: profile token is required", ErrInvalidParameter)
+	}
+
+	profiles, media2, err := c.streamProfiles(ctx)
+	if err != nil {
+		return fmt.Errorf("SetStreamParameters failed: %w", err)
+	}
+
+	idx := slices.IndexFunc(profiles, func(profile *Profile) bool { return profile.Token == profileToken })
+	if idx < 0 {
+		return fmt.Errorf("SetStreamParameters failed: %w: profile %q", ErrNotFound, profileToken)
+	}
+
+	profile := profiles[idx]
+	if profile.VideoEncoderConfiguration == nil || profile.VideoEncoderConfiguration.Token == "" {
+		return fmt.Errorf("SetStreamParameters failed: %w: profile %q has no video encoder configuration",
+			ErrInvalidParameter, profileToken)
+	}
+
+	configToken := profile.VideoEncoderConfiguration.Token
+
+	config, options, modes, err := c.streamEncoder(ctx, media2, configToken)
+	if err != nil {
+		return fmt.Errorf("SetStreamParameters failed: %w", err)
+	}
+
+	updated, err := applyStreamParams(config, options, p)
+	if err != nil {
+		return fmt.Errorf("SetStreamParameters failed: %w", err)
+	}
+
+	if err := c.checkStreamSustainable(ctx, profiles, profile, updated, modes); err != nil {
+		if c.checkEncoderInstances || !errors.Is(err, ErrStreamNotSustainable) {
+			return fmt.Errorf("SetStreamParameters failed: %w", err)
+		}
+
+		c.logf("onvif: warning: %v", err)
+	}
+
+	// The configuration was read above, so its UseCount is current.
+	ctx = withFreshUseCount(ctx)
+
+	if media2 {
+		return c.SetVideoEncoderConfiguration2(ctx, updated)
+	}
+
+	return c.SetVideoEncoderConfiguration(ctx, updated, true)
+}
+
+// streamProfiles reads the profiles for SetStreamParameters, routed like
+// GetProfiles, and reports whether they came from Media2.
+func (c *Client) streamProfiles(ctx context.Context) ([]*Profile, bool, error) {
+	switch {
+	case c.mediaVersion == MediaVersion1, c.mediaVersion == MediaVersionAuto && c.media2Endpoint == "":
+		profiles, err := c.getProfilesMedia1(ctx)
+
+		return profiles, false, err
+	case c.media2Endpoint == "":
+		return nil, false, fmt.Errorf("GetProfiles failed: Media2 %w", ErrServiceNotSupported)
+	}
+
+	profiles, err := c.getProfilesMedia2(ctx)
+	if err != nil && c.mediaVersion == MediaVersionAuto && ctx.Err() == nil {
+		c.logf("onvif: Media2 GetProfiles failed, falling back to Media: %v", err)
+
+		profiles, err = c.getProfilesMedia1(ctx)
+
+		return profiles, false, err
+	}
+
+	return profiles, true, err
+}
+
+// streamEncoder reads the video encoder configuration with the given token
+// and its options from Media2 or Media. From Media2 it also returns the
+// options as reported, which tie frame rates to resolutions.
+func (c *Client) streamEncoder(ctx context.Context, media2 bool, configToken string) (
+	*VideoEncoderConfiguration, *VideoEncoderConfigurationOptions, []*VideoEncoder2ConfigurationOptions, error,
+) {
+	if !media2 {
+		config, err := c.GetVideoEncoderConfiguration(ctx, configToken)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		options, err := c.GetVideoEncoderConfigurationOptions(ctx, configToken)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		return config, options, nil, nil
+	}
+
+	configs, err := c.GetVideoEncoderConfigurations2(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	idx := slices.IndexFunc(configs, func(config *VideoEncoderConfiguration) bool { return config.Token == configToken })
+	if idx < 0 {
+		return nil, nil, nil, fmt.Errorf("%w: video encoder configuration %q", ErrNotFound, configToken)
+	}
+
+	options, err := c.GetVideoEncoderConfigurationOptions2(ctx, configToken, "")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return configs[idx], mergeEncoderOptions(options), options, nil
+}
+
+// mergeEncoderOptions combines Media2 encoder options into the Media (ver10)
+// form, per encoding: the resolutions are joined and the ranges widened to
+// cover every option.
+func mergeEncoderOptions(options []*VideoEncoder2ConfigurationOptions) *VideoEncoderConfigurationOptions {
+	merged := &VideoEncoderConfigurationOptions{}
+
+	for _, option := range options {
+		merged.QualityRange = widenFloatRange(merged.QualityRange, option.QualityRange)
+
+		var frameRates *FloatRange
+		if len(option.FrameRatesSupported) > 0 {
+			frameRates = &FloatRange{Min: slices.Min(option.FrameRatesSupported), Max: slices.Max(option.FrameRatesSupported)}
+		}
+
+		switch option.Encoding {
+		case "JPEG":
+			if merged.JPEG == nil {
+				merged.JPEG = &JPEGOptions{}
+			}
+
+			merged.JPEG.ResolutionsAvailable = appendResolutions(merged.JPEG.ResolutionsAvailable, option.ResolutionsAvailable)
+			merged.JPEG.FrameRateRange = widenFloatRange(merged.JPEG.FrameRateRange, frameRates)
+		case "H264":
+			if merged.H264 == nil {
+				merged.H264 = &H264Options{}
+			}
+
+			merged.H264.ResolutionsAvailable = appendResolutions(merged.H264.ResolutionsAvailable, option.ResolutionsAvailable)
+			merged.H264.FrameRateRange = widenFloatRange(merged.H264.FrameRateRange, frameRates)
+
+			if r := option.GovLengthRange; r != nil {
+				if merged.H264.GovLengthRange == nil {
+					merged.H264.GovLengthRange = &IntRange{Min: r.Min, Max: r.Max}
+				} else {
+					merged.H264.GovLengthRange.Min = min(merged.H264.GovLengthRange.Min, r.Min)
+					merged.H264.GovLengthRange.Max = max(merged.H264.GovLengthRange.Max, r.Max)
+				}
+			}
+
+			for _, profile := range option.ProfilesSupported {
+				if !slices.Contains(merged.H264.H264ProfilesSupported, profile) {
+					merged.H264.H264ProfilesSupported = append(merged.H264.H264ProfilesSupported, profile)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
+// widenFloatRange returns a range covering both a and b; nil ranges are
+// ignored.
+func widenFloatRange(a, b *FloatRange) *FloatRange {
+	switch {
+	case b == nil:
+		return a
+	case a == nil:
+		return &FloatRange{Min: b.Min, Max: b.Max}
+	default:
+		return &FloatRange{Min: min(a.Min, b.Min), Max: max(a.Max, b.Max)}
+	}
+}
+
+// appendResolutions appends the resolutions of add that resolutions lacks.
+func appendResolutions(resolutions, add []*VideoResolution) []*VideoResolution {
+	for _, res := range add {
+		if !hasResolution(resolutions, res.Width, res.Height) {
+			resolutions = append(resolutions, res)
+		}
+	}
+
+	return resolutions
+}
+
+// applyStreamParams returns a copy of config with p applied, after checking p
+// against the encoder options of the resulting encoding.
+func applyStreamParams(
+	config *VideoEncoderConfiguration, options *VideoEncoderConfigurationOptions, p StreamParams,
+) (*VideoEncoderConfiguration, error) {
+	updated := *config
+
+	updated.Encoding = strings.ToUpper(config.Encoding)
+	if p.Encoding != "" {
+		updated.Encoding = strings.ToUpper(p.Encoding)
+	}
+
+	// Only the settings block of the resulting encoding is kept.
+	if updated.Encoding != "H264" {
+		updated.H264 = nil
+	}
+
+	if updated.Encoding != "H265" {
+		updated.H265 = nil
+	}
+
+	if updated.Encoding != "MPEG4" {
+		updated.MPEG4 = nil
+	}
+
+	resolutions, frameRates, govLengths, ok := encodingOptions(options, updated.Encoding)
+	if !ok {
+		return nil, fmt.Errorf("%w: encoding %q not supported by the encoder, device offers %s",
+			ErrInvalidParameter, updated.Encoding, strings.Join(encoderEncodings(options), ", "))
+	}
+
+	if p.Width > 0 || p.Height > 0 {
+		if !hasResolution(resolutions, p.Width, p.Height) {
+			return nil, fmt.Errorf("%w: resolution %dx%d not supported for %s, valid resolutions: %s",
+				ErrInvalidParameter, p.Width, p.Height, updated.Encoding, formatResolutions(resolutions))
+		}
+
+		updated.Resolution = &VideoResolution{Width: p.Width, Height: p.Height}
+	}
+
+	if p.Quality > 0 {
+		if r := options.QualityRange; r != nil && (p.Quality < r.Min || p.Quality > r.Max) {
+			return nil, fmt.Errorf("%w: quality %g outside %g-%g", ErrInvalidParameter, p.Quality, r.Min, r.Max)
+		}
+
+		updated.Quality = p.Quality
+	}
+
+	if p.FPS > 0 || p.BitrateKbps > 0 {
+		rateControl := VideoRateControl{}
+		if config.RateControl != nil {
+			rateControl = *config.RateControl
+		}
+
+		if p.FPS > 0 {
+			if frameRates != nil && (float64(p.FPS) < frameRates.Min || float64(p.FPS) > frameRates.Max) {
+				return nil, fmt.Errorf("%w: frame rate %d outside %g-%g for %s",
+					ErrInvalidParameter, p.FPS, frameRates.Min, frameRates.Max, updated.Encoding)
+			}
+
+			rateControl.FrameRateLimit, rateControl.FrameRate = p.FPS, 0
+		}
+
+		if p.BitrateKbps > 0 {
+			rateControl.BitrateLimit = p.BitrateKbps
+		}
+
+		updated.RateControl = &rateControl
+	}
+
+	if p.GovLength > 0 {
+		if updated.Encoding != "H264" {
+			return nil, fmt.Errorf("%w: GOV length only applies to H264", ErrInvalidParameter)
+		}
+
+		if govLengths != nil && (p.GovLength < govLengths.Min || p.GovLength > govLengths.Max) {
+			return nil, fmt.Errorf("%w: GOV length %d outside %d-%d",
+				ErrInvalidParameter, p.GovLength, govLengths.Min, govLengths.Max)
+		}
+
+		h264 := H264Configuration{}
+		if config.H264 != nil {
+			h264 = *config.H264
+		}
+
+		h264.GovLength = p.GovLength
+		updated.H264 = &h264
+	}
+
+	return &updated, nil
+}
+
+// encodingOptions returns the resolutions, frame rate range and GOV length
+// range options offers for encoding. ok is false if it offers none.
+func encodingOptions(
+	options *VideoEncoderConfigurationOptions, encoding string,
+) (resolutions []*VideoResolution, frameRates *FloatRange, govLengths *IntRange, ok bool) {
+	switch {
+	case encoding == "JPEG" && options.JPEG != nil:
+		return options.JPEG.ResolutionsAvailable, options.JPEG.FrameRateRange, nil, true
+	case encoding == "H264" && options.H264 != nil:
+		return options.H264.ResolutionsAvailable, options.H264.FrameRateRange, options.H264.GovLengthRange, true
+	default:
+		return nil, nil, nil, false
+	}
+}
+
+// checkStreamSustainable returns ErrStreamNotSustainable when config, the
+// updated encoder of profile, asks for a frame rate that none of the encoder
+// options offering its resolution reaches, see checkResolutionFrameRate, or
+// when the device does not guarantee an instance of the codec next to the
+// other encoders on the profile's video source, see
+// GetGuaranteedNumberOfVideoEncoderInstances and CanAddVideoEncoder.
+func (c *Client) checkStreamSustainable(
+	ctx context.Context, profiles []*Profile, profile *Profile,
+	config *VideoEncoderConfiguration, modes []*VideoEncoder2ConfigurationOptions,
+) error {
+	if err := checkResolutionFrameRate(config, modes); err != nil {
+		return err
+	}
+
+	if profile.VideoSourceConfiguration == nil {
+		return nil
+	}
+
+	sourceToken := profile.VideoSourceConfiguration.Token
+
+	guaranteed, err := c.GetGuaranteedNumberOfVideoEncoderInstances(ctx, sourceToken)
+	if err != nil {
+		c.skipCheck("guaranteed encoder instance check", err)
+
+		return nil
+	}
+
+	// The updated encoder counts once, however many profiles share it.
+	others := slices.DeleteFunc(slices.Clone(profiles), func(other *Profile) bool {
+		return other.VideoEncoderConfiguration != nil && other.VideoEncoderConfiguration.Token == config.Token
+	})
+
+	if !videoEncoderAvailable(guaranteed, others, sourceToken, config.Encoding, profile.Token) {
+		return fmt.Errorf("%w: video source configuration %q guarantees %d %s and %d encoder instances in total,"+
+			" taken by the other streams", ErrStreamNotSustainable, sourceToken,
+			guaranteedInstances(guaranteed, config.Encoding), config.Encoding, guaranteed.TotalNumber)
+	}
+
+	return nil
+}
+
+// checkResolutionFrameRate returns ErrStreamNotSustainable when modes, the
+// encoder options as Media2 reports them, offer the resolution of config only
+// at lower frame rates than it asks for, e.g. 3840x2160 up to 15 fps next to
+// 1920x1080 up to 30 fps. The error names the nearest sustainable
+// combinations: the resolution at its highest frame rate, and the largest
+// smaller resolution at the requested frame rate. Without options listing
+// the resolution and its frame rates, the check is skipped.
+func checkResolutionFrameRate(config *VideoEncoderConfiguration, modes []*VideoEncoder2ConfigurationOptions) error {
+	if config.Resolution == nil || config.RateControl == nil || config.RateControl.FrameRateLimit <= 0 {
+		return nil
+	}
+
+	width, height, fps := config.Resolution.Width, config.Resolution.Height, config.RateControl.FrameRateLimit
+
+	var (
+		listed     bool
+		topRate    float64
+		downscaled *VideoResolution
+	)
+
+	for _, mode := range modes {
+		if mode.Encoding != config.Encoding || len(mode.FrameRatesSupported) == 0 {
+			continue
+		}
+
+		modeTop := slices.Max(mode.FrameRatesSupported)
+
+		if hasResolution(mode.ResolutionsAvailable, width, height) {
+			if modeTop >= float64(fps) {
+				return nil
+			}
+
+			listed, topRate = true, max(topRate, modeTop)
+		}
+
+		if modeTop < float64(fps) {
+			continue
+		}
+
+		for _, res := range mode.ResolutionsAvailable {
+			pixels := res.Width * res.Height
+			if pixels < width*height && (downscaled == nil || pixels > downscaled.Width*downscaled.Height) {
+				downscaled = res
+			}
+		}
+	}
+
+	if !listed {
+		return nil
+	}
+
+	sustainable := fmt.Sprintf("%dx%d at %g fps", width, height, topRate)
+	if downscaled != nil {
+		sustainable += fmt.Sprintf(" or %dx%d at %d fps", downscaled.Width, downscaled.Height, fps)
+	}
+
+	return fmt.Errorf("%w: %s %dx%d at %d fps exceeds the frame rates offered at that resolution, sustainable: %s",
+		ErrStreamNotSustainable, config.Encoding, width, height, fps, sustainable)
+}
+
+// guaranteedInstances returns the guaranteed number of instances of encoding.
+func guaranteedInstances(guaranteed *GuaranteedNumberOfVideoEncoderInstances, encoding string) int {
+	switch strings.ToUpper(encoding) {
+	case "JPEG":
+		return guaranteed.JPEG
+	case "H264":
+		return guaranteed.H264
+	case "MPEG4":
+		return guaranteed.MPEG4
+	default:
+		return 0
+	}
+}
+
+// encoderEncodings lists the encodings for which options contains settings.
+func encoderEncodings(options *VideoEncoderConfigurationOptions) []string {
+	var encodings []string
+
+	if options.JPEG != nil {
+		encodings = append(encodings, "JPEG")
+	}
+
+	if options.H264 != nil {
+		encodings = append(encodings, "H264")
+	}
+
+	if len(encodings) == 0 {
+		encodings = append(encodings, "none")
+	}
+
+	return encodings
+}
+
+// hasResolution reports whether resolutions contains width x height.
+func hasResolution(resolutions []*VideoResolution, width, height int) bool {
+	for _, res := range resolutions {
+		if res.Width == width && res.Height == height {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatResolutions renders resolutions as a comma separated WxH list.
+func formatResolutions(resolutions []*VideoResolution) string {
+	if len(resolutions) == 0 {
+		return "none"
+	}
+
+	formatted := make([]string, len(resolutions))
+	for i, res := range resolutions {
+		formatted[i] = fmt.Sprintf("%dx%d", res.Width, res.Height)
+	}
+
+	return strings.Join(formatted, ", ")
+}
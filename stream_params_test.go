@@ -0,0 +1,354 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetStreamParameters(t *testing.T) {
+	var setRequest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name>
+					<tt:VideoEncoderConfiguration token="VEC_1"><tt:Name>Main</tt:Name><tt:Encoding>H264</tt:Encoding></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfigurationOptions"):
+			response = `<trt:GetVideoEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Options>
+					<tt:QualityRange><tt:Min>0</tt:Min><tt:Max>10</tt:Max></tt:QualityRange>
+					<tt:H264>
+						<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+						<tt:ResolutionsAvailable><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:ResolutionsAvailable>
+						<tt:GovLengthRange><tt:Min>1</tt:Min><tt:Max>100</tt:Max></tt:GovLengthRange>
+						<tt:FrameRateRange><tt:Min>1</tt:Min><tt:Max>30</tt:Max></tt:FrameRateRange>
+					</tt:H264>
+				</trt:Options>
+			</trt:GetVideoEncoderConfigurationOptionsResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfiguration"):
+			response = `<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VEC_1"><tt:Name>Main</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:Resolution>
+					<tt:Quality>5</tt:Quality>
+					<tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit><tt:EncodingInterval>1</tt:EncodingInterval><tt:BitrateLimit>2048</tt:BitrateLimit></tt:RateControl>
+				</trt:Configuration>
+			</trt:GetVideoEncoderConfigurationResponse>`
+		case strings.Contains(string(body), "SetVideoEncoderConfiguration"):
+			setRequest = string(body)
+			response = `<trt:SetVideoEncoderConfigurationResponse/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion1))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	err = client.SetStreamParameters(ctx, "Profile_1", StreamParams{Width: 3840, Height: 2160})
+	if !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("Expected ErrInvalidParameter for unsupported resolution, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "1920x1080, 1280x720") {
+		t.Errorf("Expected error to list valid resolutions, got %v", err)
+	}
+
+	if setRequest != "" {
+		t.Fatal("Configuration must not be changed for invalid parameters")
+	}
+
+	err = client.SetStreamParameters(ctx, "Profile_1", StreamParams{
+		Encoding:    "h264",
+		Width:       1920,
+		Height:      1080,
+		FPS:         25,
+		BitrateKbps: 4000,
+		GovLength:   50,
+	})
+	if err != nil {
+		t.Fatalf("SetStreamParameters() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<trt:Configuration token="VEC_1">`,
+		"<tt:Encoding>H264</tt:Encoding>",
+		"<tt:Width>1920</tt:Width>",
+		"<tt:Quality>5</tt:Quality>",
+		"<tt:FrameRateLimit>25</tt:FrameRateLimit>",
+		"<tt:BitrateLimit>4000</tt:BitrateLimit>",
+		"<tt:GovLength>50</tt:GovLength>",
+		"<trt:ForcePersistence>true</trt:ForcePersistence>",
+	} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+}
+
+func TestSetStreamParametersSustainability(t *testing.T) {
+	var sets int
+
+	// A 1080p30 main stream and a VGA sub stream on one video source.
+	guaranteed := `<trt:TotalNumber>2</trt:TotalNumber><trt:H264>2</trt:H264>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Profile_1"><tt:Name>Main</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:Name>VSC</tt:Name></tt:VideoSourceConfiguration>
+					<tt:VideoEncoderConfiguration token="VEC_1"><tt:Name>Main</tt:Name><tt:Encoding>H264</tt:Encoding></tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Profile_2"><tt:Name>Sub</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:Name>VSC</tt:Name></tt:VideoSourceConfiguration>
+					<tt:VideoEncoderConfiguration token="VEC_2"><tt:Name>Sub</tt:Name><tt:Encoding>H264</tt:Encoding>
+						<tt:Resolution><tt:Width>640</tt:Width><tt:Height>480</tt:Height></tt:Resolution>
+						<tt:RateControl><tt:FrameRateLimit>30</tt:FrameRateLimit></tt:RateControl>
+					</tt:VideoEncoderConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfigurationOptions"):
+			response = `<trt:GetVideoEncoderConfigurationOptionsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Options><tt:H264>
+					<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+					<tt:ResolutionsAvailable><tt:Width>640</tt:Width><tt:Height>480</tt:Height></tt:ResolutionsAvailable>
+					<tt:FrameRateRange><tt:Min>1</tt:Min><tt:Max>30</tt:Max></tt:FrameRateRange>
+				</tt:H264></trt:Options>
+			</trt:GetVideoEncoderConfigurationOptionsResponse>`
+		case strings.Contains(string(body), "GetVideoEncoderConfiguration"):
+			response = `<trt:GetVideoEncoderConfigurationResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configuration token="VEC_1"><tt:Name>Main</tt:Name><tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+					<tt:RateControl><tt:FrameRateLimit>30</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+				</trt:Configuration>
+			</trt:GetVideoEncoderConfigurationResponse>`
+		case strings.Contains(string(body), "GetGuaranteedNumberOfVideoEncoderInstances"):
+			response = `<trt:GetGuaranteedNumberOfVideoEncoderInstancesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">` +
+				guaranteed + `</trt:GetGuaranteedNumberOfVideoEncoderInstancesResponse>`
+		case strings.Contains(string(body), "SetVideoEncoderConfiguration"):
+			sets++
+			response = `<trt:SetVideoEncoderConfigurationResponse/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion1), WithVideoEncoderInstanceCheck())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// The dual-stream camera sustains 1080p30 next to the sub stream.
+	if err := client.SetStreamParameters(ctx, "Profile_1", StreamParams{Width: 1920, Height: 1080, FPS: 30}); err != nil {
+		t.Fatalf("SetStreamParameters() failed: %v", err)
+	}
+
+	if err := client.SetStreamParameters(ctx, "Profile_1", StreamParams{BitrateKbps: 6000}); err != nil {
+		t.Fatalf("SetStreamParameters() failed for a bitrate change: %v", err)
+	}
+
+	if sets != 2 {
+		t.Fatalf("Expected both changes to be applied, got %d", sets)
+	}
+
+	// Only one H264 instance is guaranteed, and the sub stream holds it.
+	guaranteed = `<trt:TotalNumber>2</trt:TotalNumber><trt:H264>1</trt:H264>`
+
+	err = client.SetStreamParameters(ctx, "Profile_1", StreamParams{BitrateKbps: 6000})
+	if !errors.Is(err, ErrStreamNotSustainable) || !strings.Contains(err.Error(), "guarantees 1 H264") {
+		t.Fatalf("Expected ErrStreamNotSustainable naming the guaranteed H264 instances, got %v", err)
+	}
+
+	if sets != 2 {
+		t.Fatal("Configuration must not be changed for an unsustainable stream")
+	}
+
+	// Without the check, the stream is applied with a warning.
+	var warnings []string
+
+	client, err = NewClient(server.URL, WithMediaVersion(MediaVersion1),
+		WithLogger(func(format string, args ...interface{}) { warnings = append(warnings, format) }))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if err := client.SetStreamParameters(ctx, "Profile_1", StreamParams{BitrateKbps: 6000}); err != nil {
+		t.Fatalf("SetStreamParameters() failed: %v", err)
+	}
+
+	if sets != 3 || len(warnings) != 1 {
+		t.Errorf("Expected the stream to be applied with a warning, got %d sets and warnings %v", sets, warnings)
+	}
+}
+
+func TestSetStreamParametersMedia2(t *testing.T) {
+	var setRequest string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "tr2:GetProfiles"):
+			response = `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Profiles token="Profile_2"><tr2:Name>Main</tr2:Name><tr2:Configurations>
+					<tr2:VideoEncoder token="VEC_2"><tt:Name>Main</tt:Name><tt:Encoding>H264</tt:Encoding></tr2:VideoEncoder>
+				</tr2:Configurations></tr2:Profiles>
+			</tr2:GetProfilesResponse>`
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurationOptions"):
+			response = `<tr2:GetVideoEncoderConfigurationOptionsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Options GovLengthRange="1 60" FrameRatesSupported="1 15 25"><tt:Encoding>H264</tt:Encoding>
+					<tt:QualityRange><tt:Min>0</tt:Min><tt:Max>10</tt:Max></tt:QualityRange>
+					<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+				</tr2:Options>
+				<tr2:Options FrameRatesSupported="1 10"><tt:Encoding>JPEG</tt:Encoding>
+					<tt:ResolutionsAvailable><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:ResolutionsAvailable>
+				</tr2:Options>
+			</tr2:GetVideoEncoderConfigurationOptionsResponse>`
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurations"):
+			response = `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Configurations token="VEC_2" GovLength="30" Profile="High"><tt:Name>Main</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+					<tt:RateControl><tt:FrameRateLimit>25</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+				</tr2:Configurations>
+			</tr2:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "tr2:SetVideoEncoderConfiguration"):
+			setRequest = string(body)
+			response = `<tr2:SetVideoEncoderConfigurationResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.media2Endpoint = server.URL
+
+	err = client.SetStreamParameters(context.Background(), "Profile_2", StreamParams{Encoding: "JPEG", Width: 1280, Height: 720, FPS: 10})
+	if err != nil {
+		t.Fatalf("SetStreamParameters() failed: %v", err)
+	}
+
+	for _, want := range []string{`<tt:Encoding>JPEG</tt:Encoding>`, "<tt:Width>1280</tt:Width>", "<tt:FrameRateLimit>10</tt:FrameRateLimit>"} {
+		if !strings.Contains(setRequest, want) {
+			t.Errorf("Request missing %s: %s", want, setRequest)
+		}
+	}
+
+	if strings.Contains(setRequest, "GovLength") || strings.Contains(setRequest, "Profile=") {
+		t.Errorf("Expected the H264 settings dropped for JPEG: %s", setRequest)
+	}
+}
+
+func TestSetStreamParametersResolutionFrameRate(t *testing.T) {
+	var sets int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "tr2:GetProfiles"):
+			response = `<tr2:GetProfilesResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Profiles token="Profile_1"><tr2:Name>Main</tr2:Name><tr2:Configurations>
+					<tr2:VideoEncoder token="VEC_1"><tt:Name>Main</tt:Name><tt:Encoding>H264</tt:Encoding></tr2:VideoEncoder>
+				</tr2:Configurations></tr2:Profiles>
+			</tr2:GetProfilesResponse>`
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurationOptions"):
+			// 4K is only offered up to 15 fps, 1080p and below up to 30 fps.
+			response = `<tr2:GetVideoEncoderConfigurationOptionsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Options FrameRatesSupported="15 10 5"><tt:Encoding>H264</tt:Encoding>
+					<tt:ResolutionsAvailable><tt:Width>3840</tt:Width><tt:Height>2160</tt:Height></tt:ResolutionsAvailable>
+				</tr2:Options>
+				<tr2:Options FrameRatesSupported="30 25 15"><tt:Encoding>H264</tt:Encoding>
+					<tt:ResolutionsAvailable><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:ResolutionsAvailable>
+					<tt:ResolutionsAvailable><tt:Width>1280</tt:Width><tt:Height>720</tt:Height></tt:ResolutionsAvailable>
+				</tr2:Options>
+			</tr2:GetVideoEncoderConfigurationOptionsResponse>`
+		case strings.Contains(string(body), "tr2:GetVideoEncoderConfigurations"):
+			response = `<tr2:GetVideoEncoderConfigurationsResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<tr2:Configurations token="VEC_1"><tt:Name>Main</tt:Name><tt:UseCount>1</tt:UseCount><tt:Encoding>H264</tt:Encoding>
+					<tt:Resolution><tt:Width>1920</tt:Width><tt:Height>1080</tt:Height></tt:Resolution>
+					<tt:RateControl><tt:FrameRateLimit>30</tt:FrameRateLimit><tt:BitrateLimit>4096</tt:BitrateLimit></tt:RateControl>
+				</tr2:Configurations>
+			</tr2:GetVideoEncoderConfigurationsResponse>`
+		case strings.Contains(string(body), "tr2:SetVideoEncoderConfiguration"):
+			sets++
+			response = `<tr2:SetVideoEncoderConfigurationResponse xmlns:tr2="http://www.onvif.org/ver20/media/wsdl"/>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion2), WithVideoEncoderInstanceCheck())
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	client.media2Endpoint = server.URL
+
+	ctx := context.Background()
+
+	err = client.SetStreamParameters(ctx, "Profile_1", StreamParams{Width: 3840, Height: 2160, FPS: 30})
+	if !errors.Is(err, ErrStreamNotSustainable) {
+		t.Fatalf("Expected ErrStreamNotSustainable for 4K at 30 fps, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "3840x2160 at 15 fps or 1920x1080 at 30 fps") {
+		t.Errorf("Expected the error to name the sustainable combinations, got %v", err)
+	}
+
+	if sets != 0 {
+		t.Fatal("Configuration must not be changed for an unsustainable stream")
+	}
+
+	if err := client.SetStreamParameters(ctx, "Profile_1", StreamParams{Width: 3840, Height: 2160, FPS: 15}); err != nil {
+		t.Fatalf("SetStreamParameters() failed for 4K at 15 fps: %v", err)
+	}
+
+	if sets != 1 {
+		t.Errorf("Expected 4K at 15 fps to be applied, got %d sets", sets)
+	}
+}
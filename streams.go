@@ -0,0 +1,220 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StreamRole is the role of a stream as classified by ListStreams.
+type StreamRole string
+
+// Stream roles.
+const (
+	StreamRoleMain  StreamRole = "main"
+	StreamRoleSub   StreamRole = "sub"
+	StreamRoleExtra StreamRole = "extra"
+)
+
+// defaultStreamNameRoles maps lower-cased profile names that vendors commonly
+// use to the stream role they denote.
+var defaultStreamNameRoles = map[string]StreamRole{
+	"main":        StreamRoleMain,
+	"mainstream":  StreamRoleMain,
+	"main stream": StreamRoleMain,
+	"main_stream": StreamRoleMain,
+	"sub":         StreamRoleSub,
+	"substream":   StreamRoleSub,
+	"sub stream":  StreamRoleSub,
+	"sub_stream":  StreamRoleSub,
+	"thirdstream": StreamRoleExtra,
+}
+
+// StreamInfo describes a video stream of a media profile.
+type StreamInfo struct {
+	ProfileToken string
+	Name         string
+	Encoding     string
+	Width        int
+	Height       int
+	// URI is the RTSP stream URI of the profile.
+	URI  string
+	Role StreamRole
+}
+
+// WithStreamNameRoles adds profile names to recognise when classifying
+// streams, e.g. {"HD": StreamRoleMain, "SD": StreamRoleSub}. Names are
+// matched case-insensitively against the profile name and token and take
+// precedence over the built-in vendor names.
+func WithStreamNameRoles(roles map[string]StreamRole) ClientOption {
+	return func(c *Client) {
+		if c.streamNameRoles == nil {
+			c.streamNameRoles = make(map[string]StreamRole, len(roles))
+		}
+
+		for name, role := range roles {
+			c.streamNameRoles[strings.ToLower(name)] = role
+		}
+	}
+}
+
+// ListStreams returns the video streams of all media profiles with their
+// stream URIs, classified into one main stream, at most one sub stream and
+// extras. Profiles without a video encoder are skipped. Transient failures of
+// the individual requests are retried within a shared retry budget, see
+// WithRetryBudget. The credentials are read once at the start; a concurrent
+// SetCredentials takes effect with the next batch.
+func (c *Client) ListStreams(ctx context.Context, opts ...BatchOption) ([]*StreamInfo, error) {
+	ctx = c.withCredentialSnapshot(ctx)
+	cfg := newBatchConfig(opts)
+
+	profiles, err := batchCall(ctx, cfg, c.GetProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	var streams []*StreamInfo
+
+	for _, profile := range profiles {
+		encoder := profile.VideoEncoderConfiguration
+		if encoder == nil {
+			continue
+		}
+
+		stream := &StreamInfo{
+			ProfileToken: profile.Token,
+			Name:         profile.Name,
+			Encoding:     encoder.Encoding,
+		}
+
+		if encoder.Resolution != nil {
+			stream.Width = encoder.Resolution.Width
+			stream.Height = encoder.Resolution.Height
+		}
+
+		uri, err := batchCall(ctx, cfg, func(ctx context.Context) (*MediaURI, error) {
+			return c.GetStreamURI(ctx, profile.Token)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stream URI for profile %s: %w", profile.Token, err)
+		}
+
+		stream.URI = uri.URI
+		streams = append(streams, stream)
+	}
+
+	c.classifyStreams(streams)
+
+	return streams, nil
+}
+
+// ClassifyStreams returns the main and sub streams of the device. Profiles
+// named after a known convention (see WithStreamNameRoles) are used first;
+// otherwise the highest-resolution stream is the main stream and the
+// highest-resolution stream below it is the sub stream. sub is nil when the
+// device has a single resolution. Use ListStreams to also get the extras.
+func (c *Client) ClassifyStreams(ctx context.Context) (main, sub *StreamInfo, err error) {
+	streams, err := c.ListStreams(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(streams) == 0 {
+		return nil, nil, fmt.Errorf("%w: no video streams", ErrNotFound)
+	}
+
+	for _, stream := range streams {
+		switch stream.Role {
+		case StreamRoleMain:
+			main = stream
+		case StreamRoleSub:
+			sub = stream
+		case StreamRoleExtra:
+		}
+	}
+
+	return main, sub, nil
+}
+
+// classifyStreams assigns a role to every stream.
+func (c *Client) classifyStreams(streams []*StreamInfo) {
+	if len(streams) == 0 {
+		return
+	}
+
+	var main, sub *StreamInfo
+
+	// Streams named as extras are never picked by resolution.
+	extra := make(map[*StreamInfo]bool)
+
+	for _, stream := range streams {
+		switch c.streamNameRole(stream) {
+		case StreamRoleMain:
+			if main == nil {
+				main = stream
+			}
+		case StreamRoleSub:
+			if sub == nil {
+				sub = stream
+			}
+		case StreamRoleExtra:
+			extra[stream] = true
+		}
+	}
+
+	// Highest resolution first; the sort is stable so profile order breaks ties.
+	byPixels := make([]*StreamInfo, len(streams))
+	copy(byPixels, streams)
+	sort.SliceStable(byPixels, func(i, j int) bool {
+		return byPixels[i].Width*byPixels[i].Height > byPixels[j].Width*byPixels[j].Height
+	})
+
+	if main == nil {
+		for _, stream := range byPixels {
+			if stream != sub && !extra[stream] {
+				main = stream
+
+				break
+			}
+		}
+	}
+
+	if sub == nil && main != nil {
+		for _, stream := range byPixels {
+			if !extra[stream] && stream.Width*stream.Height < main.Width*main.Height {
+				sub = stream
+
+				break
+			}
+		}
+	}
+
+	for _, stream := range streams {
+		switch stream {
+		case main:
+			stream.Role = StreamRoleMain
+		case sub:
+			stream.Role = StreamRoleSub
+		default:
+			stream.Role = StreamRoleExtra
+		}
+	}
+}
+
+// streamNameRole returns the role implied by the stream's profile name or token, if any.
+func (c *Client) streamNameRole(stream *StreamInfo) StreamRole {
+	for _, name := range []string{stream.Name, stream.ProfileToken} {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if role, ok := c.streamNameRoles[name]; ok {
+			return role
+		}
+
+		if role, ok := defaultStreamNameRoles[name]; ok {
+			return role
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,115 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// newMockStreamsServer returns a server answering GetProfiles with profiles and
+// GetStreamUri with a URI ending in the requested profile token.
+func newMockStreamsServer(t *testing.T, profiles string) *httptest.Server {
+	t.Helper()
+
+	tokenPattern := regexp.MustCompile(`<trt:ProfileToken>([^<]*)</trt:ProfileToken>`)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+		if match := tokenPattern.FindSubmatch(body); match != nil {
+			response = `<trt:GetStreamUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri>rtsp://camera/` + string(match[1]) + `</tt:Uri></trt:MediaUri>
+			</trt:GetStreamUriResponse>`
+		} else {
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">` +
+				profiles + `</trt:GetProfilesResponse>`
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+}
+
+// testStreamProfile returns a profile with a video encoder of the given resolution.
+func testStreamProfile(token, name, width, height string) string {
+	return `<trt:Profiles token="` + token + `"><tt:Name>` + name + `</tt:Name>
+		<tt:VideoEncoderConfiguration token="VEC_` + token + `"><tt:Encoding>H264</tt:Encoding>
+			<tt:Resolution><tt:Width>` + width + `</tt:Width><tt:Height>` + height + `</tt:Height></tt:Resolution>
+		</tt:VideoEncoderConfiguration></trt:Profiles>`
+}
+
+func TestClassifyStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles string
+		opts     []ClientOption
+		wantMain string
+		wantSub  string
+	}{
+		{
+			name: "by resolution",
+			profiles: testStreamProfile("Profile_2", "Low", "640", "360") +
+				testStreamProfile("Profile_1", "High", "1920", "1080") +
+				testStreamProfile("Profile_3", "Medium", "1280", "720") +
+				`<trt:Profiles token="Metadata"><tt:Name>Metadata</tt:Name></trt:Profiles>`,
+			wantMain: "Profile_1",
+			wantSub:  "Profile_3",
+		},
+		{
+			name: "by vendor naming",
+			profiles: testStreamProfile("101", "mainStream", "1280", "720") +
+				testStreamProfile("102", "subStream", "640", "360") +
+				testStreamProfile("103", "thirdStream", "1920", "1080"),
+			wantMain: "101",
+			wantSub:  "102",
+		},
+		{
+			name: "by override",
+			profiles: testStreamProfile("Profile_A", "HD", "1920", "1080") +
+				testStreamProfile("Profile_B", "SD", "1920", "1080"),
+			opts:     []ClientOption{WithStreamNameRoles(map[string]StreamRole{"sd": StreamRoleSub})},
+			wantMain: "Profile_A",
+			wantSub:  "Profile_B",
+		},
+		{
+			name:     "single resolution",
+			profiles: testStreamProfile("Profile_1", "Only", "1920", "1080"),
+			wantMain: "Profile_1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockStreamsServer(t, tt.profiles)
+			defer server.Close()
+
+			client, err := NewClient(server.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			main, sub, err := client.ClassifyStreams(context.Background())
+			if err != nil {
+				t.Fatalf("ClassifyStreams() failed: %v", err)
+			}
+
+			if main == nil || main.ProfileToken != tt.wantMain {
+				t.Errorf("main = %+v, want %s", main, tt.wantMain)
+			} else if main.URI != "rtsp://camera/"+tt.wantMain {
+				t.Errorf("main URI = %s", main.URI)
+			}
+
+			switch {
+			case tt.wantSub == "" && sub != nil:
+				t.Errorf("sub = %+v, want none", sub)
+			case tt.wantSub != "" && (sub == nil || sub.ProfileToken != tt.wantSub):
+				t.Errorf("sub = %+v, want %s", sub, tt.wantSub)
+			}
+		})
+	}
+}
@@ -170,7 +170,7 @@ func TestEnhancedDeviceFeatures(t *testing.T) {
 		}
 
 		// Verify user levels
-		userLevels := make(map[string]int)
+		userLevels := make(map[UserLevel]int)
 		for _, user := range users {
 			if user.Username == "" {
 				t.Error("Expected user to have username")
@@ -0,0 +1,130 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// maxConcurrentSnapshotURIRequests bounds the GetSnapshotUri requests that
+// ListSnapshotURIs has in flight, since embedded devices handle few
+// concurrent requests.
+const maxConcurrentSnapshotURIRequests = 4
+
+// GetThumbnail returns a still image of the profile's stream and its content
+// type, e.g. image/jpeg. The image is downloaded from the snapshot URI when the
+// media service reports the SnapshotUri capability. Otherwise
+// ErrNoSnapshotSupport is returned; callers then have to grab a keyframe from
+// the stream URI (see GetStreamURI) with an RTSP client such as ffmpeg.
+func (c *Client) GetThumbnail(ctx context.Context, profileToken string) ([]byte, string, error) {
+	if profileToken == "" {
+		return nil, "", fmt.Errorf("GetThumbnail failed: %w: profile token is required", ErrInvalidParameter)
+	}
+
+	caps, err := checkedCapabilities(ctx, c, mediaNamespace, c.GetMediaServiceCapabilities)
+	if err != nil {
+		c.skipCheck("snapshot capability check", err)
+	} else if !caps.SnapshotURI {
+		return nil, "", fmt.Errorf("GetThumbnail failed: %w: grab a keyframe from the stream URI instead",
+			ErrNoSnapshotSupport)
+	}
+
+	uri, err := c.GetSnapshotURI(ctx, profileToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetThumbnail failed: %w", err)
+	}
+
+	if uri.URI == "" {
+		return nil, "", fmt.Errorf("GetThumbnail failed: %w: device returned an empty snapshot URI",
+			ErrNoSnapshotSupport)
+	}
+
+	data, err := c.DownloadFile(ctx, uri.URI)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetThumbnail failed: %w", err)
+	}
+
+	return data, http.DetectContentType(data), nil
+}
+
+// ListSnapshotURIs returns the snapshot URIs of all media profiles, keyed by
+// profile token, e.g. for the thumbnails of a camera wall. The URIs are
+// requested concurrently and transient failures are retried like in
+// ListStreams, see WithRetryBudget. Profiles for which the device reports no
+// snapshot support, with an ActionNotSupported fault, a bare HTTP 500 under
+// WithLenientServerErrors or an empty URI, are left out; so are all profiles when the media service reports no SnapshotUri
+// capability. When other requests fail, the URIs that were resolved are
+// returned together with the per-profile errors joined.
+func (c *Client) ListSnapshotURIs(ctx context.Context, opts ...BatchOption) (map[string]*MediaURI, error) {
+	ctx = c.withCredentialSnapshot(ctx)
+	cfg := newBatchConfig(opts)
+
+	caps, err := checkedCapabilities(ctx, c, mediaNamespace, c.GetMediaServiceCapabilities)
+	if err != nil {
+		c.skipCheck("snapshot capability check", err)
+	} else if !caps.SnapshotURI {
+		return map[string]*MediaURI{}, nil
+	}
+
+	profiles, err := batchCall(ctx, cfg, c.GetProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		uris   = make(map[string]*MediaURI, len(profiles))
+		failed = make(map[string]error)
+		slots  = make(chan struct{}, maxConcurrentSnapshotURIRequests)
+	)
+
+	for _, profile := range profiles {
+		wg.Add(1)
+
+		go func(token string) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			uri, err := batchCall(ctx, cfg, func(ctx context.Context) (*MediaURI, error) {
+				return c.GetSnapshotURI(ctx, token)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case isUnsupportedError(err):
+			case err != nil:
+				failed[token] = err
+			case uri.URI != "":
+				uris[token] = uri
+			}
+		}(profile.Token)
+	}
+
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return uris, nil
+	}
+
+	tokens := make([]string, 0, len(failed))
+	for token := range failed {
+		tokens = append(tokens, token)
+	}
+
+	sort.Strings(tokens)
+
+	errs := make([]error, len(tokens))
+	for i, token := range tokens {
+		errs[i] = fmt.Errorf("failed to get snapshot URI for profile %s: %w", token, failed[token])
+	}
+
+	return uris, errors.Join(errs...)
+}
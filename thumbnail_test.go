@@ -0,0 +1,158 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetThumbnail(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00")
+
+	for _, snapshotSupported := range []bool{true, false} {
+		t.Run(fmt.Sprintf("SnapshotUri=%v", snapshotSupported), func(t *testing.T) {
+			var server *httptest.Server
+
+			capabilityRequests := 0
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/snapshot.jpg" {
+					_, _ = w.Write(jpeg)
+
+					return
+				}
+
+				body, _ := io.ReadAll(r.Body)
+
+				var response string
+
+				switch {
+				case strings.Contains(string(body), "GetServiceCapabilities"):
+					capabilityRequests++
+					response = fmt.Sprintf(`<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Capabilities SnapshotUri="%v"/>
+			</trt:GetServiceCapabilitiesResponse>`, snapshotSupported)
+				case strings.Contains(string(body), "GetSnapshotUri"):
+					response = `<trt:GetSnapshotUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri>` + server.URL + `/snapshot.jpg</tt:Uri></trt:MediaUri>
+			</trt:GetSnapshotUriResponse>`
+				default:
+					t.Errorf("Unexpected request: %s", body)
+				}
+
+				w.Header().Set("Content-Type", "application/soap+xml")
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(server.URL + "/onvif/media_service")
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			data, contentType, err := client.GetThumbnail(context.Background(), "Profile_1")
+
+			if !snapshotSupported {
+				if !errors.Is(err, ErrNoSnapshotSupport) {
+					t.Errorf("Expected ErrNoSnapshotSupport, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("GetThumbnail() failed: %v", err)
+			}
+
+			if string(data) != string(jpeg) || contentType != "image/jpeg" {
+				t.Errorf("Unexpected thumbnail: %q (%s)", data, contentType)
+			}
+
+			// The capabilities are cached until the credentials change.
+			if _, _, err := client.GetThumbnail(context.Background(), "Profile_1"); err != nil {
+				t.Fatalf("GetThumbnail() failed: %v", err)
+			}
+
+			if capabilityRequests != 1 {
+				t.Errorf("Expected 1 capabilities request, got %d", capabilityRequests)
+			}
+
+			client.SetCredentials("operator", "secret")
+
+			if _, _, err := client.GetThumbnail(context.Background(), "Profile_1"); err != nil {
+				t.Fatalf("GetThumbnail() failed: %v", err)
+			}
+
+			if capabilityRequests != 2 {
+				t.Errorf("Expected the capabilities to be read again after SetCredentials, got %d requests",
+					capabilityRequests)
+			}
+		})
+	}
+}
+
+func TestListSnapshotURIs(t *testing.T) {
+	fault := func(subcode string) string {
+		return `<soap:Fault><soap:Code><soap:Value>soap:Receiver</soap:Value>
+			<soap:Subcode><soap:Value>ter:` + subcode + `</soap:Value></soap:Subcode></soap:Code>
+			<soap:Reason><soap:Text xml:lang="en">` + subcode + `</soap:Text></soap:Reason></soap:Fault>`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetServiceCapabilities"):
+			response = `<trt:GetServiceCapabilitiesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:Capabilities SnapshotUri="true"/></trt:GetServiceCapabilitiesResponse>`
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="P1"><tt:Name>Main</tt:Name></trt:Profiles>
+				<trt:Profiles token="P2"><tt:Name>Audio</tt:Name></trt:Profiles>
+				<trt:Profiles token="P3"><tt:Name>Empty</tt:Name></trt:Profiles>
+				<trt:Profiles token="P4"><tt:Name>Broken</tt:Name></trt:Profiles>
+			</trt:GetProfilesResponse>`
+		case strings.Contains(string(body), "<trt:ProfileToken>P1</trt:ProfileToken>"):
+			response = `<trt:GetSnapshotUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri>http://camera/snapshot/1.jpg</tt:Uri></trt:MediaUri></trt:GetSnapshotUriResponse>`
+		case strings.Contains(string(body), "<trt:ProfileToken>P2</trt:ProfileToken>"):
+			w.WriteHeader(http.StatusInternalServerError)
+			response = fault("ActionNotSupported")
+		case strings.Contains(string(body), "<trt:ProfileToken>P3</trt:ProfileToken>"):
+			response = `<trt:GetSnapshotUriResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:MediaUri><tt:Uri></tt:Uri></trt:MediaUri></trt:GetSnapshotUriResponse>`
+		case strings.Contains(string(body), "<trt:ProfileToken>P4</trt:ProfileToken>"):
+			w.WriteHeader(http.StatusBadRequest)
+			response = fault("InvalidArgVal")
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:ter="http://www.onvif.org/ver10/error"><soap:Body>` +
+			response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/onvif/media_service")
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	uris, err := client.ListSnapshotURIs(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "profile P4") || !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected the error of profile P4, got %v", err)
+	}
+
+	if len(uris) != 1 || uris["P1"] == nil || uris["P1"].URI != "http://camera/snapshot/1.jpg" {
+		t.Errorf("Unexpected snapshot URIs: %v", uris)
+	}
+}
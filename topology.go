@@ -0,0 +1,85 @@
+package onvif
+
+import (
+	"context"
+	"fmt"
+)
+
+// VideoSourceNode is a physical video source with the video source
+// configurations that use it.
+type VideoSourceNode struct {
+	Source         *VideoSource
+	Configurations []*VideoSourceConfigurationNode
+}
+
+// VideoSourceConfigurationNode is a video source configuration with the
+// media profiles that reference it.
+type VideoSourceConfigurationNode struct {
+	Configuration *VideoSourceConfiguration
+	Profiles      []*Profile
+}
+
+// GetVideoSourceTopology returns which video source feeds which profiles, as
+// a tree of video sources, their video source configurations and the profiles
+// referencing each configuration. Sources are returned in the order reported
+// by GetVideoSources. A configuration whose source is not reported gets a node
+// of its own with only the source token set.
+func (c *Client) GetVideoSourceTopology(ctx context.Context) ([]*VideoSourceNode, error) {
+	sources, err := c.GetVideoSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video sources: %w", err)
+	}
+
+	configs, err := c.GetVideoSourceConfigurations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video source configurations: %w", err)
+	}
+
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profiles: %w", err)
+	}
+
+	return buildVideoSourceTopology(sources, configs, profiles), nil
+}
+
+// buildVideoSourceTopology links sources, configurations and profiles by token.
+func buildVideoSourceTopology(
+	sources []*VideoSource, configs []*VideoSourceConfiguration, profiles []*Profile,
+) []*VideoSourceNode {
+	nodes := make([]*VideoSourceNode, 0, len(sources))
+	bySource := make(map[string]*VideoSourceNode, len(sources))
+
+	for _, source := range sources {
+		node := &VideoSourceNode{Source: source}
+		nodes = append(nodes, node)
+		bySource[source.Token] = node
+	}
+
+	byConfig := make(map[string]*VideoSourceConfigurationNode, len(configs))
+
+	for _, config := range configs {
+		node, ok := bySource[config.SourceToken]
+		if !ok {
+			node = &VideoSourceNode{Source: &VideoSource{Token: config.SourceToken}}
+			nodes = append(nodes, node)
+			bySource[config.SourceToken] = node
+		}
+
+		configNode := &VideoSourceConfigurationNode{Configuration: config}
+		node.Configurations = append(node.Configurations, configNode)
+		byConfig[config.Token] = configNode
+	}
+
+	for _, profile := range profiles {
+		if profile.VideoSourceConfiguration == nil {
+			continue
+		}
+
+		if configNode, ok := byConfig[profile.VideoSourceConfiguration.Token]; ok {
+			configNode.Profiles = append(configNode.Profiles, profile)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,79 @@
+package onvif
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetVideoSourceTopology(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var response string
+
+		switch {
+		case strings.Contains(string(body), "GetVideoSources"):
+			response = `<trt:GetVideoSourcesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl">
+				<trt:VideoSources token="Sensor1"/><trt:VideoSources token="Sensor2"/>
+			</trt:GetVideoSourcesResponse>`
+		case strings.Contains(string(body), "GetVideoSourceConfigurations"):
+			response = `<trt:GetVideoSourceConfigurationsResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Configurations token="VSC_1"><tt:Name>Wide</tt:Name><tt:SourceToken>Sensor1</tt:SourceToken></trt:Configurations>
+				<trt:Configurations token="VSC_2"><tt:Name>Tele</tt:Name><tt:SourceToken>Sensor2</tt:SourceToken></trt:Configurations>
+				<trt:Configurations token="VSC_3"><tt:Name>Crop</tt:Name><tt:SourceToken>Sensor1</tt:SourceToken></trt:Configurations>
+				<trt:Configurations token="VSC_4"><tt:Name>Orphan</tt:Name><tt:SourceToken>Sensor9</tt:SourceToken></trt:Configurations>
+			</trt:GetVideoSourceConfigurationsResponse>`
+		case strings.Contains(string(body), "GetProfiles"):
+			response = `<trt:GetProfilesResponse xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+				<trt:Profiles token="Main"><tt:Name>Main</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:SourceToken>Sensor1</tt:SourceToken></tt:VideoSourceConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Sub"><tt:Name>Sub</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_1"><tt:SourceToken>Sensor1</tt:SourceToken></tt:VideoSourceConfiguration>
+				</trt:Profiles>
+				<trt:Profiles token="Zoom"><tt:Name>Zoom</tt:Name>
+					<tt:VideoSourceConfiguration token="VSC_2"><tt:SourceToken>Sensor2</tt:SourceToken></tt:VideoSourceConfiguration>
+				</trt:Profiles>
+			</trt:GetProfilesResponse>`
+		default:
+			t.Errorf("Unexpected request: %s", body)
+		}
+
+		w.Header().Set("Content-Type", "application/soap+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><soap:Envelope><soap:Body>` + response + `</soap:Body></soap:Envelope>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, WithMediaVersion(MediaVersion1))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	nodes, err := client.GetVideoSourceTopology(context.Background())
+	if err != nil {
+		t.Fatalf("GetVideoSourceTopology() failed: %v", err)
+	}
+
+	// Render the tree as source/config[profiles] for comparison.
+	var got []string
+
+	for _, node := range nodes {
+		for _, config := range node.Configurations {
+			var profiles []string
+			for _, profile := range config.Profiles {
+				profiles = append(profiles, profile.Token)
+			}
+
+			got = append(got, node.Source.Token+"/"+config.Configuration.Token+"["+strings.Join(profiles, ",")+"]")
+		}
+	}
+
+	want := "Sensor1/VSC_1[Main,Sub] Sensor1/VSC_3[] Sensor2/VSC_2[Zoom] Sensor9/VSC_4[]"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected topology %q, got %q", want, strings.Join(got, " "))
+	}
+}
@@ -9,6 +9,12 @@ type DeviceInformation struct {
 	FirmwareVersion string
 	SerialNumber    string
 	HardwareID      string
+	// Extensions holds OEM elements returned beyond the standard fields,
+	// e.g. a region or build date, keyed by the path of local element names,
+	// e.g. "Region" or "Extension/BuildDate". Repeated elements are numbered
+	// from the second one on, e.g. "Extension/Item#2". It is nil when the
+	// device returns none.
+	Extensions map[string]string
 }
 
 // Capabilities represents the device capabilities.
@@ -68,7 +74,16 @@ type NetworkCapabilities struct {
 	ZeroConfiguration bool
 	IPVersion6        bool
 	DynDNS            bool
-	Extension         *NetworkCapabilitiesExtension
+	// MaxIPFilterEntries is the maximum number of IP address filter entries,
+	// or 0 when the device does not advertise a limit.
+	MaxIPFilterEntries int
+	// The following are only reported by GetServiceCapabilities.
+	Dot11Configuration bool
+	HostnameFromDHCP   bool
+	DHCPv6             bool
+	// NTP is the maximum number of NTP servers the device supports.
+	NTP       int
+	Extension *NetworkCapabilitiesExtension
 }
 
 // SystemCapabilities represents system capabilities.
@@ -80,7 +95,13 @@ type SystemCapabilities struct {
 	SystemLogging     bool
 	FirmwareUpgrade   bool
 	SupportedVersions []string
-	Extension         *SystemCapabilitiesExtension
+	// The following are only reported by GetServiceCapabilities.
+	HTTPFirmwareUpgrade    bool
+	HTTPSystemBackup       bool
+	HTTPSystemLogging      bool
+	HTTPSupportInformation bool
+	StorageConfiguration   bool
+	Extension              *SystemCapabilitiesExtension
 }
 
 // IOCapabilities represents I/O capabilities.
@@ -100,7 +121,16 @@ type SecurityCapabilities struct {
 	SAMLToken            bool
 	KerberosToken        bool
 	RELToken             bool
-	Extension            *SecurityCapabilitiesExtension
+	// The following are only reported by GetServiceCapabilities.
+	TLS10               bool
+	DefaultAccessPolicy bool
+	Dot1X               bool
+	RemoteUserHandling  bool
+	UsernameToken       bool
+	HTTPDigest          bool
+	// MaxUsers is the maximum number of users, or 0 when not advertised.
+	MaxUsers  int
+	Extension *SecurityCapabilitiesExtension
 }
 
 // StreamingCapabilities represents streaming capabilities.
@@ -154,12 +184,13 @@ type VideoEncoderConfiguration struct {
 	Token          string
 	Name           string
 	UseCount       int
-	Encoding       string // JPEG, MPEG4, H264
+	Encoding       string // JPEG, MPEG4, H264, H265 (Media2 only)
 	Resolution     *VideoResolution
 	Quality        float64
 	RateControl    *VideoRateControl
 	MPEG4          *MPEG4Configuration
 	H264           *H264Configuration
+	H265           *H265Configuration // Media2 only
 	Multicast      *MulticastConfiguration
 	SessionTimeout time.Duration
 }
@@ -194,6 +225,25 @@ type PTZConfiguration struct {
 	ZoomLimits                             *ZoomLimits
 }
 
+// PTZConfigurationOptions represents the options of a PTZ configuration.
+type PTZConfigurationOptions struct {
+	Spaces        *PTZSpaces
+	PTZTimeoutMin time.Duration
+	PTZTimeoutMax time.Duration
+}
+
+// PTZSpaces lists the coordinate spaces supported by a PTZ node.
+type PTZSpaces struct {
+	AbsolutePanTiltPositionSpace    []*Space2DDescription
+	AbsoluteZoomPositionSpace       []*Space1DDescription
+	RelativePanTiltTranslationSpace []*Space2DDescription
+	RelativeZoomTranslationSpace    []*Space1DDescription
+	ContinuousPanTiltVelocitySpace  []*Space2DDescription
+	ContinuousZoomVelocitySpace     []*Space1DDescription
+	PanTiltSpeedSpace               []*Space1DDescription
+	ZoomSpeedSpace                  []*Space1DDescription
+}
+
 // MetadataConfiguration represents metadata configuration.
 type MetadataConfiguration struct {
 	Token          string
@@ -204,6 +254,18 @@ type MetadataConfiguration struct {
 	Analytics      bool
 	Multicast      *MulticastConfiguration
 	SessionTimeout time.Duration
+	// CompressionType is the Profile M metadata compression, e.g. None, GZIP
+	// or EXI; empty when the device does not report one.
+	CompressionType string
+	// AnalyticsEngineConfiguration holds the analytics modules whose output
+	// is included in the metadata stream (Profile M).
+	AnalyticsEngineConfiguration *AnalyticsEngineConfiguration
+	// ExtensionXML is the XML content of the configuration's Extension
+	// element, re-sent by SetMetadataConfiguration. As decoded, it declares
+	// the namespaces it inherits from the response, so vendor prefixes
+	// survive the round trip; prefixes other than tt in content set by hand
+	// must be declared within it.
+	ExtensionXML string
 }
 
 // VideoResolution represents video resolution.
@@ -213,10 +275,18 @@ type VideoResolution struct {
 }
 
 // VideoRateControl represents video rate control.
+// ConstantBitRate and TargetBitrate are only defined by the Media2 (ver20)
+// schema and are left unset for ver10 media configurations. They are read by
+// GetVideoEncoderConfigurations2 and sent by SetVideoEncoderConfiguration2.
 type VideoRateControl struct {
 	FrameRateLimit   int
 	EncodingInterval int
 	BitrateLimit     int
+	ConstantBitRate  *bool
+	TargetBitrate    int
+	// FrameRate is the frame rate limit as reported by Media2, which may be
+	// fractional, e.g. 12.5. It is zero for Media configurations.
+	FrameRate float64
 }
 
 // MPEG4Configuration represents MPEG4 configuration.
@@ -231,6 +301,12 @@ type H264Configuration struct {
 	H264Profile string
 }
 
+// H265Configuration represents H265 configuration, which only Media2 reports.
+type H265Configuration struct {
+	GovLength   int
+	H265Profile string
+}
+
 // MulticastConfiguration represents multicast configuration.
 type MulticastConfiguration struct {
 	Address   *IPAddress
@@ -343,6 +419,19 @@ type VideoEncoderConfigurationOptions struct {
 	H264         *H264Options
 }
 
+// VideoEncoder2ConfigurationOptions represents the Media2 options of one
+// encoding. The encoding uses the Media (ver10) names, e.g. MPEG4.
+type VideoEncoder2ConfigurationOptions struct {
+	Encoding                 string
+	QualityRange             *FloatRange
+	ResolutionsAvailable     []*VideoResolution
+	BitrateRange             *IntRange
+	GovLengthRange           *IntRange
+	FrameRatesSupported      []float64
+	ProfilesSupported        []string
+	ConstantBitRateSupported bool
+}
+
 // JPEGOptions represents JPEG encoder options.
 type JPEGOptions struct {
 	ResolutionsAvailable  []*VideoResolution
@@ -368,8 +457,38 @@ type VideoSourceMode struct {
 
 // OSDConfiguration represents OSD (On-Screen Display) configuration.
 type OSDConfiguration struct {
-	Token string
-	// Additional fields can be added based on ONVIF spec
+	Token                         string
+	VideoSourceConfigurationToken string
+	Type                          string // Text, Image or Extended
+	Position                      *OSDPosConfiguration
+	TextString                    *OSDTextConfiguration
+	Image                         *OSDImageConfiguration
+}
+
+// OSDImageConfiguration represents the image of an image OSD.
+type OSDImageConfiguration struct {
+	// ImagePath is the URI of the image on the device.
+	ImagePath string
+}
+
+// OSDPosConfiguration represents the position of an OSD.
+type OSDPosConfiguration struct {
+	Type string // UpperLeft, UpperRight, LowerLeft, LowerRight or Custom
+	Pos  *Vector2D
+}
+
+// OSDTextConfiguration represents the text of an OSD.
+type OSDTextConfiguration struct {
+	Type       string // Plain, Date, Time or DateAndTime
+	DateFormat string
+	TimeFormat string
+	FontSize   int
+	PlainText  string
+
+	// IsPersistentText selects whether plain text survives a device reboot.
+	// Nil leaves the device default, which is persistent. Temporary text
+	// (false) requires the TemporaryOSDText media service capability.
+	IsPersistentText *bool
 }
 
 // AudioEncoderConfigurationOptions represents available options for audio encoder configuration.
@@ -421,6 +540,33 @@ type GuaranteedNumberOfVideoEncoderInstances struct {
 // OSDConfigurationOptions represents available options for OSD configuration.
 type OSDConfigurationOptions struct {
 	MaximumNumberOfOSDs int
+	Types               []string
+	PositionOptions     []string
+	TextOption          *OSDTextOptions
+	// MaximumNumberOfImageOSDs is the maximum number of image OSDs, or 0 if not reported.
+	MaximumNumberOfImageOSDs int
+	ImageOption              *OSDImageOptions
+}
+
+// OSDImageOptions represents available options for image OSDs.
+type OSDImageOptions struct {
+	// ImagePaths lists the images available on the device.
+	ImagePaths []string
+	// FormatsSupported lists the supported image formats, e.g. png or bmp.
+	FormatsSupported []string
+	// MaxSize is the maximum image size in bytes, or 0 if not reported.
+	MaxSize int
+	// MaxWidth and MaxHeight are the maximum image dimensions, or 0 if not reported.
+	MaxWidth  int
+	MaxHeight int
+}
+
+// OSDTextOptions represents available options for text OSDs.
+type OSDTextOptions struct {
+	Types         []string
+	FontSizeRange *IntRange
+	DateFormats   []string
+	TimeFormats   []string
 }
 
 // VideoSourceConfigurationOptions represents available options for video source configuration.
@@ -460,17 +606,26 @@ type VideoAnalyticsConfiguration struct {
 
 // AnalyticsEngineConfiguration represents analytics engine configuration.
 type AnalyticsEngineConfiguration struct {
+	// AnalyticsModules are the configured analytics modules.
+	AnalyticsModules []*Config
+	// Deprecated: use AnalyticsModules. It is not populated.
 	AnalyticsEngine *Config
-	Parameters      *ItemList
+	// Deprecated: parameters belong to each module. It is not populated.
+	Parameters *ItemList
 }
 
 // RuleEngineConfiguration represents rule engine configuration.
 type RuleEngineConfiguration struct {
+	// Rules are the configured rules.
+	Rules []*Config
+	// Deprecated: use Rules. It is not populated.
 	Rule *Config
 }
 
-// Config represents a generic configuration.
+// Config represents an analytics module or rule configuration.
 type Config struct {
+	Name       string
+	Type       string
 	Parameters *ItemList
 }
 
@@ -489,6 +644,10 @@ type SimpleItem struct {
 // ElementItem represents an element configuration item.
 type ElementItem struct {
 	Name string
+	// XML is the XML content of the item. As decoded, it declares the
+	// namespaces it inherits from the response, so that it can be sent back
+	// as is; prefixes used in content set by hand must be declared within it.
+	XML string
 }
 
 // VideoAnalyticsConfigurationOptions represents available options for video analytics configuration.
@@ -546,7 +705,8 @@ type PTZPreset struct {
 	PTZPosition *PTZVector
 }
 
-// ImagingSettings represents imaging settings.
+// ImagingSettings represents imaging settings. Nil values, including those of
+// the sub-blocks, are not sent by SetImagingSettings.
 type ImagingSettings struct {
 	BacklightCompensation *BacklightCompensation
 	Brightness            *float64
@@ -564,43 +724,43 @@ type ImagingSettings struct {
 // BacklightCompensation represents backlight compensation.
 type BacklightCompensation struct {
 	Mode  string // OFF, ON
-	Level float64
+	Level *float64
 }
 
 // Exposure represents exposure settings.
 type Exposure struct {
 	Mode            string // AUTO, MANUAL
 	Priority        string // LowNoise, FrameRate
-	MinExposureTime float64
-	MaxExposureTime float64
-	MinGain         float64
-	MaxGain         float64
-	MinIris         float64
-	MaxIris         float64
-	ExposureTime    float64
-	Gain            float64
-	Iris            float64
+	MinExposureTime *float64
+	MaxExposureTime *float64
+	MinGain         *float64
+	MaxGain         *float64
+	MinIris         *float64
+	MaxIris         *float64
+	ExposureTime    *float64
+	Gain            *float64
+	Iris            *float64
 }
 
 // FocusConfiguration represents focus configuration.
 type FocusConfiguration struct {
-	AutoFocusMode string // AUTO, MANUAL
-	DefaultSpeed  float64
-	NearLimit     float64
-	FarLimit      float64
+	AutoFocusMode string // AUTO, MANUAL, see AutoFocusModeAuto
+	DefaultSpeed  *float64
+	NearLimit     *float64
+	FarLimit      *float64
 }
 
 // WideDynamicRange represents WDR settings.
 type WideDynamicRange struct {
 	Mode  string // OFF, ON
-	Level float64
+	Level *float64
 }
 
 // WhiteBalance represents white balance settings.
 type WhiteBalance struct {
 	Mode   string // AUTO, MANUAL
-	CrGain float64
-	CbGain float64
+	CrGain *float64
+	CbGain *float64
 }
 
 // ImagingSettingsExtension represents imaging settings extension.
@@ -629,12 +789,19 @@ type NTPInformation struct {
 
 // NetworkHost represents a network host.
 type NetworkHost struct {
-	Type        string // IPv4, IPv6, DNS
+	Type        string // NetworkHostIPv4, NetworkHostIPv6 or NetworkHostDNS
 	IPv4Address string
 	IPv6Address string
 	DNSname     string
 }
 
+// Network host types.
+const (
+	NetworkHostIPv4 = "IPv4"
+	NetworkHostIPv6 = "IPv6"
+	NetworkHostDNS  = "DNS"
+)
+
 // NetworkInterface represents a network interface.
 type NetworkInterface struct {
 	Token   string
@@ -666,7 +833,9 @@ type IPv6NetworkInterface struct {
 // IPv4Configuration represents IPv4 configuration.
 type IPv4Configuration struct {
 	Manual []PrefixedIPv4Address
-	DHCP   bool
+	// FromDHCP is the address assigned by DHCP, if any.
+	FromDHCP *PrefixedIPv4Address
+	DHCP     bool
 }
 
 // IPv6Configuration represents IPv6 configuration.
@@ -675,6 +844,22 @@ type IPv6Configuration struct {
 	DHCP   bool
 }
 
+// NetworkInterfaceSetConfiguration represents a change to a network interface.
+// Nil fields are left unchanged by the device.
+type NetworkInterfaceSetConfiguration struct {
+	Enabled *bool
+	MTU     int
+	IPv4    *IPv4NetworkInterfaceSetConfiguration
+}
+
+// IPv4NetworkInterfaceSetConfiguration represents a change to the IPv4
+// configuration of a network interface.
+type IPv4NetworkInterfaceSetConfiguration struct {
+	Enabled *bool
+	Manual  []PrefixedIPv4Address
+	DHCP    *bool
+}
+
 // PrefixedIPv4Address represents an IPv4 address with prefix.
 type PrefixedIPv4Address struct {
 	Address      string
@@ -689,17 +874,38 @@ type PrefixedIPv6Address struct {
 
 // Scope represents a device scope.
 type Scope struct {
-	ScopeDef  string
+	ScopeDef  ScopeDefinition
 	ScopeItem string
 }
 
+// ScopeDefinition tells whether a scope can be changed with SetScopes,
+// AddScopes and RemoveScopes.
+type ScopeDefinition string
+
+const (
+	ScopeDefinitionFixed        ScopeDefinition = "Fixed"
+	ScopeDefinitionConfigurable ScopeDefinition = "Configurable"
+)
+
 // User represents a user account.
 type User struct {
-	Username  string
+	Username string
+	// Password is only sent to the device; GetUsers never returns it.
 	Password  string
-	UserLevel string // Administrator, Operator, User
+	UserLevel UserLevel
 }
 
+// UserLevel represents the access level of a user account.
+type UserLevel string
+
+const (
+	UserLevelAdministrator UserLevel = "Administrator"
+	UserLevelOperator      UserLevel = "Operator"
+	UserLevelUser          UserLevel = "User"
+	UserLevelAnonymous     UserLevel = "Anonymous"
+	UserLevelExtended      UserLevel = "Extended"
+)
+
 // VideoSource represents a video source.
 type VideoSource struct {
 	Token      string
@@ -807,7 +1013,7 @@ type ImagingStatus struct {
 // FocusStatus represents focus status.
 type FocusStatus struct {
 	Position   float64
-	MoveStatus string
+	MoveStatus string // IDLE, MOVING, UNKNOWN
 	Error      string
 }
 
@@ -816,7 +1022,10 @@ type Service struct {
 	Namespace    string
 	XAddr        string
 	Capabilities interface{}
-	Version      OnvifVersion
+	// CapabilitiesXML is the raw capabilities element of the service, set when
+	// GetServices is called with includeCapability.
+	CapabilitiesXML []byte
+	Version         OnvifVersion
 }
 
 // OnvifVersion represents ONVIF version.
@@ -927,7 +1136,10 @@ type SystemLog struct {
 // AttachmentData represents attachment/binary data.
 type AttachmentData struct {
 	ContentType string
-	Include     *Include
+	// Data is the decoded content when the device sends it inline.
+	Data []byte
+	// Include references the content when the device sends it as an MTOM part.
+	Include *Include
 }
 
 // Include represents XOP include.
@@ -941,7 +1153,19 @@ type BackupFile struct {
 	Data AttachmentData
 }
 
-// FactoryDefaultType represents factory default type.
+// FirmwareUpgradeInfo tells where and when to upload new firmware, see
+// StartFirmwareUpgrade.
+type FirmwareUpgradeInfo struct {
+	// UploadURI is the address to post the firmware to with UploadFirmware.
+	UploadURI string
+	// UploadDelay is how long to wait before uploading.
+	UploadDelay time.Duration
+	// ExpectedDownTime is how long the device is expected to be unreachable
+	// while it installs the firmware and restarts.
+	ExpectedDownTime time.Duration
+}
+
+// FactoryDefaultType represents factory default type, see SetSystemFactoryDefault.
 type FactoryDefaultType string
 
 const (
@@ -1044,6 +1268,13 @@ const (
 	IPAddressFilterDeny  IPAddressFilterType = "Deny"
 )
 
+// IPFilterAllow and IPFilterDeny are short names for the IP address filter types.
+// An Allow filter admits only the listed addresses; a Deny filter rejects them.
+const (
+	IPFilterAllow = IPAddressFilterAllow
+	IPFilterDeny  = IPAddressFilterDeny
+)
+
 // RemoteUser represents remote user configuration.
 type RemoteUser struct {
 	Username           string
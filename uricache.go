@@ -0,0 +1,87 @@
+package onvif
+
+import (
+	"sync"
+	"time"
+)
+
+// mediaURICache caches media URIs, see WithMediaURICache.
+type mediaURICache struct {
+	mu      sync.Mutex
+	entries map[string]mediaURIEntry
+}
+
+// mediaURIEntry is a cached media URI; expires is zero if it does not expire.
+type mediaURIEntry struct {
+	uri     *MediaURI
+	expires time.Time
+}
+
+// WithMediaURICache caches the URIs returned by GetStreamURI and
+// GetSnapshotURI per profile and protocol, so that players reconnecting to a
+// stream do not query the device each time. URIs the device marks
+// InvalidAfterConnect are never cached, URIs with a Timeout expire after it,
+// and all URIs are dropped when a reboot is detected, see OnReboot. Callers
+// receiving a cached URI must treat it as read-only.
+func WithMediaURICache() ClientOption {
+	return func(c *Client) {
+		c.mediaURIs = &mediaURICache{
+			entries: make(map[string]mediaURIEntry),
+		}
+	}
+}
+
+// cachedMediaURI returns the URI cached under key, or fetches and caches it.
+func (c *Client) cachedMediaURI(key string, fetch func() (*MediaURI, error)) (*MediaURI, error) {
+	cache := c.mediaURIs
+	if cache == nil {
+		return fetch()
+	}
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	cache.mu.Unlock()
+
+	if ok && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		return entry.uri, nil
+	}
+
+	uri, err := fetch()
+	if err != nil || uri.InvalidAfterConnect || uri.URI == "" {
+		return uri, err
+	}
+
+	entry = mediaURIEntry{uri: uri}
+	if uri.Timeout > 0 {
+		entry.expires = time.Now().Add(uri.Timeout)
+	}
+
+	cache.mu.Lock()
+	cache.entries[key] = entry
+	cache.mu.Unlock()
+
+	return uri, nil
+}
+
+// dropRebootMediaURIs removes the cached URIs the device marked
+// InvalidAfterReboot, once it rebooted or was told to.
+func (c *Client) dropRebootMediaURIs() {
+	c.dropMediaURIs(func(uri *MediaURI) bool { return uri.InvalidAfterReboot })
+}
+
+// dropMediaURIs removes the cached URIs for which drop returns true.
+func (c *Client) dropMediaURIs(drop func(*MediaURI) bool) {
+	cache := c.mediaURIs
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for key, entry := range cache.entries {
+		if drop(entry.uri) {
+			delete(cache.entries, key)
+		}
+	}
+}